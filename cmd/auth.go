@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+
+	"github.com/opencode-ai/opencode/internal/config"
+	"github.com/opencode-ai/opencode/internal/llm/discovery"
+	"github.com/opencode-ai/opencode/internal/llm/models"
+	"github.com/opencode-ai/opencode/internal/llm/provider"
+	"github.com/spf13/cobra"
+)
+
+var authCmd = &cobra.Command{
+	Use:   "auth",
+	Short: "Log in to providers that use an account instead of an API key",
+}
+
+var authCopilotCmd = &cobra.Command{
+	Use:   "copilot",
+	Short: "Log in with a GitHub Copilot subscription",
+	Long: `Log in to GitHub to use the models of a Copilot subscription with the copilot
+provider. The GitHub token is saved in the opencode config directory. The login
+of a Copilot editor plugin is used without logging in again.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cwd, _ := cmd.Flags().GetString("cwd")
+		if cwd == "" {
+			c, err := os.Getwd()
+			if err != nil {
+				return fmt.Errorf("failed to get current working directory: %v", err)
+			}
+			cwd = c
+		}
+		// The login comes before any provider is configured, the configuration
+		// only matters for its proxy
+		var proxy string
+		if cfg, err := config.Load(cwd, false); err == nil {
+			proxy = cfg.Providers[models.ProviderCopilot].Proxy
+			if proxy == "" {
+				proxy = cfg.Proxy
+			}
+		}
+		proxyFunc, err := discovery.ProxyFunc(proxy)
+		if err != nil {
+			return err
+		}
+		client := &http.Client{Transport: &http.Transport{Proxy: proxyFunc}}
+
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer cancel()
+		code, err := provider.RequestCopilotDeviceCode(ctx, client)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "Open %s and enter the code %s\n", code.VerificationURI, code.UserCode)
+		token, err := provider.PollCopilotAccessToken(ctx, client, code)
+		if err != nil {
+			return err
+		}
+		if err := config.SaveCopilotToken(token); err != nil {
+			return err
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "Logged in, the token is saved in %s\n", config.CopilotTokenPath())
+		return nil
+	},
+}
+
+func init() {
+	authCopilotCmd.Flags().StringP("cwd", "c", "", "Current working directory")
+	authCmd.AddCommand(authCopilotCmd)
+	rootCmd.AddCommand(authCmd)
+}
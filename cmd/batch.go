@@ -0,0 +1,207 @@
+package cmd
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/opencode-ai/opencode/internal/config"
+	"github.com/opencode-ai/opencode/internal/db"
+	"github.com/opencode-ai/opencode/internal/llm/agent"
+	"github.com/opencode-ai/opencode/internal/llm/provider"
+	"github.com/opencode-ai/opencode/internal/message"
+	"github.com/opencode-ai/opencode/internal/session"
+	"github.com/opencode-ai/opencode/internal/usage"
+	"github.com/spf13/cobra"
+)
+
+var batchCmd = &cobra.Command{
+	Use:   "batch",
+	Short: "Answer prompts offline in provider batch jobs",
+	Long: `Answer prompts offline in a batch job of the provider of the coder model, for
+workloads that don't need an immediate answer such as bulk code reviews. Batch
+jobs finish within 24 hours at about half the price of interactive requests.
+Every prompt becomes a session, its answer is written to the session when the
+batch is collected. Batch requests are answered without tools, so prompts must
+include the code they are about. Only OpenAI supports batch jobs.`,
+}
+
+var batchSubmitCmd = &cobra.Command{
+	Use:   "submit [file...]",
+	Short: "Submit a batch of prompts",
+	Long: `Submit a batch of prompts. Every file is a prompt, prompts can also be given
+with --prompt, or one per line on standard input when there are neither.`,
+	Example: `  opencode batch submit review-*.md
+  opencode batch submit -p "Review this code: $(cat main.go)" --wait
+  printf 'Explain %s\n' "monads" "goroutines" | opencode batch submit`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		prompts, _ := cmd.Flags().GetStringArray("prompt")
+		for _, path := range args {
+			content, err := os.ReadFile(path)
+			if err != nil {
+				return fmt.Errorf("failed to read prompt: %w", err)
+			}
+			prompts = append(prompts, string(content))
+		}
+		if len(prompts) == 0 {
+			input, err := io.ReadAll(cmd.InOrStdin())
+			if err != nil {
+				return fmt.Errorf("failed to read prompts: %w", err)
+			}
+			for _, line := range strings.Split(string(input), "\n") {
+				if strings.TrimSpace(line) != "" {
+					prompts = append(prompts, line)
+				}
+			}
+		}
+		if len(prompts) == 0 {
+			return fmt.Errorf("no prompts to submit")
+		}
+
+		return withBatchService(cmd, func(ctx context.Context, service agent.BatchService) error {
+			batch, err := service.Submit(ctx, prompts)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Submitted batch %s with %d prompt(s)\n", batch.ID, len(prompts))
+			if wait, _ := cmd.Flags().GetBool("wait"); wait {
+				return collectBatch(ctx, cmd, service, batch.ID)
+			}
+			return nil
+		})
+	},
+}
+
+var batchStatusCmd = &cobra.Command{
+	Use:   "status [batch-id]",
+	Short: "Show the progress of batches",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return withBatchService(cmd, func(ctx context.Context, service agent.BatchService) error {
+			var batches []provider.Batch
+			if len(args) == 1 {
+				batch, err := service.Get(ctx, args[0])
+				if err != nil {
+					return err
+				}
+				batches = append(batches, batch)
+			} else {
+				var err error
+				batches, err = service.List(ctx)
+				if err != nil {
+					return err
+				}
+			}
+
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "Batch\tStatus\tCreated\tCompleted\tFailed\tTotal")
+			for _, batch := range batches {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%d\t%d\n",
+					batch.ID,
+					batch.Status,
+					batch.CreatedAt.Format(time.DateTime),
+					batch.Completed,
+					batch.Failed,
+					batch.Total,
+				)
+				for _, e := range batch.Errors {
+					fmt.Fprintf(w, "\t%s\n", e)
+				}
+			}
+			return w.Flush()
+		})
+	},
+}
+
+var batchCollectCmd = &cobra.Command{
+	Use:   "collect <batch-id>",
+	Short: "Write the answers of a completed batch to their sessions",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return withBatchService(cmd, func(ctx context.Context, service agent.BatchService) error {
+			return collectBatch(ctx, cmd, service, args[0])
+		})
+	},
+}
+
+// withBatchService loads the configuration and runs fn with the batch service.
+func withBatchService(cmd *cobra.Command, fn func(ctx context.Context, service agent.BatchService) error) error {
+	cwd, _ := cmd.Flags().GetString("cwd")
+	if cwd == "" {
+		c, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("failed to get current working directory: %v", err)
+		}
+		cwd = c
+	}
+	if _, err := config.Load(cwd, false); err != nil {
+		return err
+	}
+	conn, err := db.Connect()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	service, err := newBatchService(conn)
+	if err != nil {
+		return err
+	}
+	return fn(context.Background(), service)
+}
+
+func newBatchService(conn *sql.DB) (agent.BatchService, error) {
+	q := db.New(conn)
+	return agent.NewBatchService(session.NewService(q), message.NewService(q), usage.NewService(q))
+}
+
+// collectBatch waits for the batch to finish when --wait is set and writes its
+// answers to their sessions.
+func collectBatch(ctx context.Context, cmd *cobra.Command, service agent.BatchService, id string) error {
+	wait, _ := cmd.Flags().GetBool("wait")
+	interval, _ := cmd.Flags().GetDuration("interval")
+	if interval <= 0 {
+		return fmt.Errorf("interval must be positive")
+	}
+	for {
+		batch, err := service.Get(ctx, id)
+		if err != nil {
+			return err
+		}
+		if batch.Done() {
+			if batch.Status != "completed" {
+				return fmt.Errorf("batch %s is %s: %s", id, batch.Status, strings.Join(batch.Errors, "; "))
+			}
+			break
+		}
+		if !wait {
+			return fmt.Errorf("batch %s is %s (%d of %d done), use --wait to wait for it", id, batch.Status, batch.Completed+batch.Failed, batch.Total)
+		}
+		fmt.Fprintf(cmd.ErrOrStderr(), "Batch %s is %s (%d of %d done)\n", id, batch.Status, batch.Completed+batch.Failed, batch.Total)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+
+	written, err := service.Collect(ctx, id)
+	fmt.Fprintf(cmd.OutOrStdout(), "Wrote %d answer(s) of batch %s to their sessions\n", written, id)
+	return err
+}
+
+func init() {
+	batchCmd.PersistentFlags().StringP("cwd", "c", "", "Current working directory")
+	batchSubmitCmd.Flags().StringArrayP("prompt", "p", nil, "Prompt to submit, can be repeated")
+	for _, cmd := range []*cobra.Command{batchSubmitCmd, batchCollectCmd} {
+		cmd.Flags().Bool("wait", false, "Wait for the batch to finish and collect its answers")
+		cmd.Flags().Duration("interval", time.Minute, "How often to check the batch while waiting")
+	}
+	batchCmd.AddCommand(batchSubmitCmd, batchStatusCmd, batchCollectCmd)
+	rootCmd.AddCommand(batchCmd)
+}
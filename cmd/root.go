@@ -57,6 +57,11 @@ to assist developers in writing, debugging, and understanding code directly from
 		if err != nil {
 			return err
 		}
+		if profile, _ := cmd.Flags().GetString("tools"); profile != "" {
+			if err := config.SetDefaultToolProfile(profile); err != nil {
+				return err
+			}
+		}
 
 		// Connect DB, this will also run migrations
 		conn, err := db.Connect()
@@ -219,6 +224,8 @@ func setupSubscriptions(app *app.App, parentCtx context.Context) (chan tea.Msg,
 	setupSubscriber(ctx, &wg, "sessions", app.Sessions.Subscribe, ch)
 	setupSubscriber(ctx, &wg, "messages", app.Messages.Subscribe, ch)
 	setupSubscriber(ctx, &wg, "permissions", app.Permissions.Subscribe, ch)
+	setupSubscriber(ctx, &wg, "tool-output", agent.SubscribeToolOutput, ch)
+	setupSubscriber(ctx, &wg, "todos", app.Todos.Subscribe, ch)
 
 	cleanupFunc := func() {
 		logging.Info("Cancelling all subscriptions")
@@ -255,4 +262,5 @@ func init() {
 	rootCmd.Flags().BoolP("version", "v", false, "Version")
 	rootCmd.Flags().BoolP("debug", "d", false, "Debug")
 	rootCmd.Flags().StringP("cwd", "c", "", "Current working directory")
+	rootCmd.Flags().String("tools", "", "Tool profile of the sessions without their own, e.g. readonly")
 }
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"sort"
 
 	"github.com/opencode-ai/opencode/internal/config"
 	"github.com/opencode-ai/opencode/internal/llm/models"
@@ -77,6 +78,31 @@ func generateSchema() map[string]any {
 		"default":     false,
 	}
 
+	schema["properties"].(map[string]any)["debugTraces"] = map[string]any{
+		"type":        "boolean",
+		"description": "Write raw provider requests and responses to the traces directory in the data directory",
+		"default":     false,
+	}
+
+	schema["properties"].(map[string]any)["redact"] = map[string]any{
+		"type":        "array",
+		"description": "Regular expressions of secrets replaced with [REDACTED] in the messages sent to providers",
+		"items": map[string]any{
+			"type": "string",
+		},
+	}
+
+	schema["properties"].(map[string]any)["cacheResponses"] = map[string]any{
+		"type":        "boolean",
+		"description": "Answer prompts that were already answered from a cache in the data directory instead of sending them again",
+		"default":     false,
+	}
+
+	schema["properties"].(map[string]any)["proxy"] = map[string]any{
+		"type":        "string",
+		"description": "URL of the HTTP, HTTPS or SOCKS5 proxy used for all providers, instead of HTTP_PROXY and HTTPS_PROXY",
+	}
+
 	schema["properties"].(map[string]any)["contextPaths"] = map[string]any{
 		"type":        "array",
 		"description": "Context paths for the application",
@@ -84,6 +110,9 @@ func generateSchema() map[string]any {
 			"type": "string",
 		},
 		"default": []string{
+			"AGENTS.md",
+			"AGENTS.local.md",
+			".opencode.md",
 			".github/copilot-instructions.md",
 			".cursorrules",
 			".cursor/rules/",
@@ -146,6 +175,428 @@ func generateSchema() map[string]any {
 		},
 	}
 
+	schema["properties"].(map[string]any)["webSearch"] = map[string]any{
+		"type":        "object",
+		"description": "Configuration of the websearch tool",
+		"properties": map[string]any{
+			"engine": map[string]any{
+				"type":        "string",
+				"description": "Search engine used by the websearch tool",
+				"enum": []string{
+					string(config.WebSearchDuckDuckGo),
+					string(config.WebSearchSearxNG),
+					string(config.WebSearchBrave),
+					string(config.WebSearchGoogle),
+				},
+				"default": string(config.WebSearchDuckDuckGo),
+			},
+			"apiKey": map[string]any{
+				"type":        "string",
+				"description": "API key for the brave and google engines",
+			},
+			"baseURL": map[string]any{
+				"type":        "string",
+				"description": "URL of the SearxNG instance for the searxng engine",
+			},
+			"searchEngineID": map[string]any{
+				"type":        "string",
+				"description": "ID (cx) of the programmable search engine for the google engine",
+			},
+			"disabled": map[string]any{
+				"type":        "boolean",
+				"description": "Disable the websearch tool",
+				"default":     false,
+			},
+		},
+	}
+
+	schema["properties"].(map[string]any)["container"] = map[string]any{
+		"type":        "object",
+		"description": "Container the container tool runs commands in",
+		"properties": map[string]any{
+			"image": map[string]any{
+				"type":        "string",
+				"description": "Image of the container started for each command, with the working directory mounted",
+			},
+			"service": map[string]any{
+				"type":        "string",
+				"description": "Running docker compose service the commands are executed in",
+			},
+			"composeFile": map[string]any{
+				"type":        "string",
+				"description": "Compose file of the service",
+			},
+			"runtime": map[string]any{
+				"type":        "string",
+				"description": "Container CLI",
+				"enum":        []string{"docker", "podman"},
+				"default":     "docker",
+			},
+			"workdir": map[string]any{
+				"type":        "string",
+				"description": "Directory in the container the commands run in",
+			},
+			"env": map[string]any{
+				"type":        "array",
+				"description": "Environment variables of the commands, as KEY=value",
+				"items": map[string]any{
+					"type": "string",
+				},
+			},
+		},
+	}
+
+	schema["properties"].(map[string]any)["sandbox"] = map[string]any{
+		"type":        "object",
+		"description": "Sandbox isolating the commands of the bash tool from the host, they can only write to the working directory",
+		"properties": map[string]any{
+			"backend": map[string]any{
+				"type":        "string",
+				"description": "Program running the sandboxed shell",
+				"enum": []string{
+					string(config.SandboxBubblewrap),
+					string(config.SandboxFirejail),
+					string(config.SandboxDocker),
+				},
+			},
+			"image": map[string]any{
+				"type":        "string",
+				"description": "Image of the docker backend",
+			},
+			"network": map[string]any{
+				"type":        "boolean",
+				"description": "Allow the commands to use the network",
+				"default":     false,
+			},
+			"writablePaths": map[string]any{
+				"type":        "array",
+				"description": "Absolute paths writable besides the working directory",
+				"items": map[string]any{
+					"type": "string",
+				},
+			},
+			"autoApprove": map[string]any{
+				"type":        "boolean",
+				"description": "Run the commands of the bash tool without asking for permission",
+				"default":     false,
+			},
+		},
+	}
+
+	schema["properties"].(map[string]any)["http"] = map[string]any{
+		"type":        "object",
+		"description": "Hosts the http tool can send requests to",
+		"properties": map[string]any{
+			"allowedHosts": map[string]any{
+				"type":        "array",
+				"description": "Hosts with an optional port, *.example.com allows all subdomains",
+				"items": map[string]any{
+					"type": "string",
+				},
+			},
+			"headers": map[string]any{
+				"type":        "object",
+				"description": "Headers added to the requests, by allowed host, values can use environment variables as $NAME",
+				"additionalProperties": map[string]any{
+					"type": "object",
+					"additionalProperties": map[string]any{
+						"type": "string",
+					},
+				},
+			},
+			"autoApprove": map[string]any{
+				"type":        "boolean",
+				"description": "Send GET, HEAD and OPTIONS requests without asking for permission",
+				"default":     false,
+			},
+		},
+	}
+
+	schema["properties"].(map[string]any)["format"] = map[string]any{
+		"type":        "object",
+		"description": "Formatting of the files the edit, multiedit and write tools change",
+		"properties": map[string]any{
+			"enabled": map[string]any{
+				"type":        "boolean",
+				"description": "Run the formatter of a file after the tools wrote it",
+				"default":     false,
+			},
+			"formatters": map[string]any{
+				"type":        "object",
+				"description": "Commands formatting files in place by extension, like .go, the path of the file is added as the last argument, an empty command disables formatting",
+				"additionalProperties": map[string]any{
+					"type": "array",
+					"items": map[string]any{
+						"type": "string",
+					},
+				},
+			},
+		},
+	}
+
+	schema["properties"].(map[string]any)["browser"] = map[string]any{
+		"type":        "object",
+		"description": "Headless Chromium the browser tool drives",
+		"properties": map[string]any{
+			"enabled": map[string]any{
+				"type":        "boolean",
+				"description": "Add the browser tool",
+				"default":     false,
+			},
+			"path": map[string]any{
+				"type":        "string",
+				"description": "Chromium or Chrome executable, found in PATH when empty",
+			},
+			"args": map[string]any{
+				"type":        "array",
+				"description": "Arguments added to the ones Chromium is started with",
+				"items": map[string]any{
+					"type": "string",
+				},
+			},
+			"allowedHosts": map[string]any{
+				"type":        "array",
+				"description": "Hosts opened without asking for permission, with an optional port, *.example.com allows all subdomains",
+				"items": map[string]any{
+					"type": "string",
+				},
+			},
+		},
+	}
+
+	schema["properties"].(map[string]any)["databases"] = map[string]any{
+		"type":        "object",
+		"description": "Databases the sql tool can query, by name",
+		"additionalProperties": map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"driver": map[string]any{
+					"type":        "string",
+					"description": "Kind of the database",
+					"enum": []string{
+						string(config.DatabaseSQLite),
+						string(config.DatabasePostgres),
+						string(config.DatabaseMySQL),
+					},
+				},
+				"dsn": map[string]any{
+					"type":        "string",
+					"description": "Path of the SQLite file, or URL of the Postgres or MySQL database",
+				},
+				"allowWrite": map[string]any{
+					"type":        "boolean",
+					"description": "Allow statements that change the database, after asking for permission",
+					"default":     false,
+				},
+			},
+			"required": []string{"driver", "dsn"},
+		},
+	}
+
+	schema["properties"].(map[string]any)["toolTimeouts"] = map[string]any{
+		"type":        "object",
+		"description": "How long the calls of a tool may run, by tool name, with default for the tools without a timeout of their own (e.g. 2m, 0 for no limit)",
+		"additionalProperties": map[string]any{
+			"type": "string",
+		},
+	}
+
+	schema["properties"].(map[string]any)["toolProfiles"] = map[string]any{
+		"type":        "object",
+		"description": "Tool profiles restricting the tools offered in a session, by name, all and readonly are built in",
+		"additionalProperties": map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"allow": map[string]any{
+					"type":        "array",
+					"description": "Tools offered, all of them when empty, names can have * wildcards",
+					"items": map[string]any{
+						"type": "string",
+					},
+				},
+				"deny": map[string]any{
+					"type":        "array",
+					"description": "Tools not offered even when allowed, names can have * wildcards",
+					"items": map[string]any{
+						"type": "string",
+					},
+				},
+			},
+		},
+	}
+
+	schema["properties"].(map[string]any)["toolProfile"] = map[string]any{
+		"type":        "string",
+		"description": "Tool profile of the sessions without their own",
+		"default":     "all",
+	}
+
+	schema["properties"].(map[string]any)["compaction"] = map[string]any{
+		"type":        "object",
+		"description": "Summarization of the older messages of a conversation that fills most of the context window of the model",
+		"properties": map[string]any{
+			"disabled": map[string]any{
+				"type":        "boolean",
+				"description": "Send the whole conversation until the model rejects it",
+				"default":     false,
+			},
+			"threshold": map[string]any{
+				"type":             "number",
+				"description":      "Share of the context window the conversation can fill before it is summarized",
+				"default":          0.85,
+				"minimum":          0,
+				"exclusiveMaximum": 1,
+			},
+		},
+	}
+
+	schema["properties"].(map[string]any)["budget"] = map[string]any{
+		"type":        "object",
+		"description": "Limits of a session; the agent warns when they are close and pauses at them until the user lets it continue",
+		"properties": map[string]any{
+			"maxTokens": map[string]any{
+				"type":        "integer",
+				"description": "Prompt and completion tokens of a session",
+				"minimum":     0,
+			},
+			"maxCost": map[string]any{
+				"type":        "number",
+				"description": "Cost of a session in dollars",
+				"minimum":     0,
+			},
+			"maxToolCalls": map[string]any{
+				"type":        "integer",
+				"description": "Tool calls of a turn",
+				"minimum":     0,
+			},
+			"warnAt": map[string]any{
+				"type":             "number",
+				"description":      "Share of a limit that shows a warning",
+				"default":          0.8,
+				"minimum":          0,
+				"exclusiveMaximum": 1,
+			},
+		},
+	}
+
+	schema["properties"].(map[string]any)["examples"] = map[string]any{
+		"type":        "array",
+		"description": "Example exchanges sent before the conversation to the models they are for, to show them how to call the tools",
+		"items": map[string]any{
+			"type":     "object",
+			"required": []string{"models", "messages"},
+			"properties": map[string]any{
+				"models": map[string]any{
+					"type":        "array",
+					"description": "IDs of the models the example is sent to, with * wildcards like ollama.*",
+					"items":       map[string]any{"type": "string"},
+				},
+				"messages": map[string]any{
+					"type":        "array",
+					"description": "Messages of the exchange, starting with a user message; the tool messages after an assistant message are the results of its tool calls, in order",
+					"items": map[string]any{
+						"type":     "object",
+						"required": []string{"role"},
+						"properties": map[string]any{
+							"role": map[string]any{
+								"type": "string",
+								"enum": []string{"user", "assistant", "tool"},
+							},
+							"content": map[string]any{
+								"type":        "string",
+								"description": "Text of the message, the result for a tool message",
+							},
+							"toolCalls": map[string]any{
+								"type":        "array",
+								"description": "Tool calls of an assistant message",
+								"items": map[string]any{
+									"type":     "object",
+									"required": []string{"name"},
+									"properties": map[string]any{
+										"name": map[string]any{
+											"type":        "string",
+											"description": "Name of the tool",
+										},
+										"input": map[string]any{
+											"type":        "object",
+											"description": "Parameters of the call",
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	schema["properties"].(map[string]any)["instructions"] = map[string]any{
+		"type":        "object",
+		"description": "Limits of the instruction files of the project and of the user added to the system prompt",
+		"properties": map[string]any{
+			"maxFileSize": map[string]any{
+				"type":        "integer",
+				"description": "Size in bytes an instruction file is cut to",
+				"default":     32000,
+				"minimum":     0,
+			},
+			"maxSize": map[string]any{
+				"type":        "integer",
+				"description": "Size in bytes of all the instruction files, the files after it are skipped",
+				"default":     100000,
+				"minimum":     0,
+			},
+			"noSubdirectories": map[string]any{
+				"type":        "boolean",
+				"description": "Only load the instruction files of the root of the project, not those of its subdirectories",
+				"default":     false,
+			},
+		},
+	}
+
+	schema["properties"].(map[string]any)["tools"] = map[string]any{
+		"type":        "object",
+		"description": "Custom tools running a command, by tool name",
+		"additionalProperties": map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"description": map[string]any{
+					"type":        "string",
+					"description": "Description of the tool for the model",
+				},
+				"parameters": map[string]any{
+					"type":        "object",
+					"description": "JSON schema of the parameters of the tool, of type object",
+				},
+				"command": map[string]any{
+					"type":        "string",
+					"description": "Command to run, parameters can be used as {{.name}}",
+				},
+				"args": map[string]any{
+					"type":        "array",
+					"description": "Arguments of the command, parameters can be used as {{.name}} and empty arguments are left out",
+					"items": map[string]any{
+						"type": "string",
+					},
+				},
+				"env": map[string]any{
+					"type":        "array",
+					"description": "Environment variables added for the command (KEY=value)",
+					"items": map[string]any{
+						"type": "string",
+					},
+				},
+				"autoApprove": map[string]any{
+					"type":        "boolean",
+					"description": "Run the command without asking for permission",
+					"default":     false,
+				},
+			},
+			"required": []string{"description", "command"},
+		},
+	}
+
 	// Add providers
 	providerSchema := map[string]any{
 		"type":        "object",
@@ -163,6 +614,229 @@ func generateSchema() map[string]any {
 					"description": "Whether the provider is disabled",
 					"default":     false,
 				},
+				"baseURL": map[string]any{
+					"type":        "string",
+					"description": "Base URL of the provider API (e.g. http://localhost:11434 for Ollama)",
+				},
+				"baseURLs": map[string]any{
+					"type":        "array",
+					"description": "Fallback base URLs tried in order when baseURL is unreachable or busy (Ollama)",
+					"items": map[string]any{
+						"type": "string",
+					},
+				},
+				"headers": map[string]any{
+					"type":        "object",
+					"description": "Custom HTTP headers sent with every request to the provider",
+					"additionalProperties": map[string]any{
+						"type": "string",
+					},
+				},
+				"timeout": map[string]any{
+					"type":        "string",
+					"description": "Request timeout (e.g. 5m), for streaming responses the maximum time between two chunks",
+				},
+				"retry": map[string]any{
+					"type":        "object",
+					"description": "Retry behavior for transient errors such as rate limits and overloaded servers",
+					"properties": map[string]any{
+						"maxAttempts": map[string]any{
+							"type":        "integer",
+							"description": "Number of retries after the first attempt",
+							"default":     8,
+						},
+						"initialBackoff": map[string]any{
+							"type":        "string",
+							"description": "Delay before the first retry, doubled for every further retry",
+							"default":     "2s",
+						},
+						"maxBackoff": map[string]any{
+							"type":        "string",
+							"description": "Maximum delay between two attempts",
+							"default":     "5m",
+						},
+						"statusCodes": map[string]any{
+							"type":        "array",
+							"description": "HTTP status codes that are retried",
+							"items": map[string]any{
+								"type": "integer",
+							},
+						},
+					},
+				},
+				"rateLimit": map[string]any{
+					"type":        "object",
+					"description": "Client side budget for the requests to the provider, requests beyond it wait in a queue",
+					"properties": map[string]any{
+						"requestsPerMinute": map[string]any{
+							"type":        "integer",
+							"description": "Maximum number of requests per minute",
+							"minimum":     0,
+						},
+						"tokensPerMinute": map[string]any{
+							"type":        "integer",
+							"description": "Maximum number of prompt and completion tokens per minute",
+							"minimum":     0,
+						},
+					},
+				},
+				"proxy": map[string]any{
+					"type":        "string",
+					"description": "URL of the HTTP, HTTPS or SOCKS5 proxy for this provider, overrides the global proxy",
+				},
+				"tls": map[string]any{
+					"type":        "object",
+					"description": "Custom TLS settings for self-hosted HTTPS endpoints",
+					"properties": map[string]any{
+						"caFile": map[string]any{
+							"type":        "string",
+							"description": "Path to a PEM encoded CA bundle trusted in addition to the system roots",
+						},
+						"certFile": map[string]any{
+							"type":        "string",
+							"description": "Path to a PEM encoded client certificate",
+						},
+						"keyFile": map[string]any{
+							"type":        "string",
+							"description": "Path to the PEM encoded private key of the client certificate",
+						},
+						"insecureSkipVerify": map[string]any{
+							"type":        "boolean",
+							"description": "Skip verification of the server certificate",
+							"default":     false,
+						},
+					},
+				},
+				"completionModels": map[string]any{
+					"type":        "array",
+					"description": "Ollama models without a chat template, prompted through /api/generate",
+					"items": map[string]any{
+						"type": "string",
+					},
+				},
+				"models": map[string]any{
+					"type":        "array",
+					"description": "Additional models registered as <provider>.<name> (Ollama)",
+					"items": map[string]any{
+						"type": "object",
+						"properties": map[string]any{
+							"name": map[string]any{
+								"type":        "string",
+								"description": "Name of the model, used in the model ID",
+							},
+							"apiModel": map[string]any{
+								"type":        "string",
+								"description": "Model name sent to the provider, defaults to name",
+							},
+							"contextWindow": map[string]any{
+								"type":        "integer",
+								"description": "Context window of the model in tokens",
+							},
+							"maxTokens": map[string]any{
+								"type":        "integer",
+								"description": "Default maximum tokens to generate",
+							},
+							"canReason": map[string]any{
+								"type":        "boolean",
+								"description": "Whether the model supports reasoning",
+							},
+							"noTools": map[string]any{
+								"type":        "boolean",
+								"description": "Whether the model lacks native tool calling, tools are then described in the prompt",
+							},
+							"vision": map[string]any{
+								"type":        "boolean",
+								"description": "Whether the model accepts images",
+							},
+							"completion": map[string]any{
+								"type":        "boolean",
+								"description": "Whether the model has no chat template and is prompted through /api/generate",
+							},
+							"raw": map[string]any{
+								"type":        "boolean",
+								"description": "Send the conversation as a plain transcript without applying the model's chat template",
+							},
+							"template": map[string]any{
+								"type":        "string",
+								"description": "Chat template in the Ollama Modelfile TEMPLATE syntax used instead of the model's built-in template",
+							},
+							"temperature": map[string]any{
+								"type":        "number",
+								"description": "Default sampling temperature of the model, agents can override it",
+							},
+							"topP": map[string]any{
+								"type":        "number",
+								"description": "Default nucleus sampling probability mass of the model",
+							},
+							"topK": map[string]any{
+								"type":        "integer",
+								"description": "Default number of top tokens considered when sampling",
+							},
+							"numCtx": map[string]any{
+								"type":        "integer",
+								"description": "Default context window size allocated for the model",
+							},
+							"repeatPenalty": map[string]any{
+								"type":        "number",
+								"description": "Default penalty applied to repeated tokens",
+							},
+						},
+						"required": []string{"name"},
+					},
+				},
+				"disableCache": map[string]any{
+					"type":        "boolean",
+					"description": "Turn off prompt caching (Anthropic) and context caching (Gemini)",
+					"default":     false,
+				},
+				"apiVersion": map[string]any{
+					"type":        "string",
+					"description": "API version (Azure OpenAI, e.g. 2025-04-01-preview)",
+				},
+				"deployments": map[string]any{
+					"type":        "array",
+					"description": "Deployments that are not named after their model (Azure OpenAI)",
+					"items": map[string]any{
+						"type": "object",
+						"properties": map[string]any{
+							"model": map[string]any{
+								"type":        "string",
+								"description": "Model ID, e.g. azure.gpt-4.1",
+							},
+							"name": map[string]any{
+								"type":        "string",
+								"description": "Name of the deployment",
+							},
+						},
+						"required": []string{"model", "name"},
+					},
+				},
+				"project": map[string]any{
+					"type":        "string",
+					"description": "GCP project (Vertex AI, defaults to GOOGLE_CLOUD_PROJECT or the credentials' project)",
+				},
+				"location": map[string]any{
+					"type":        "string",
+					"description": "GCP region or global (Vertex AI, defaults to GOOGLE_CLOUD_LOCATION or us-central1)",
+				},
+				"credentialsFile": map[string]any{
+					"type":        "string",
+					"description": "Service account key file used instead of Application Default Credentials (Vertex AI)",
+				},
+				"keepAlive": map[string]any{
+					"type":        "string",
+					"description": "How long Ollama keeps the model loaded between requests (e.g. 10m, -1 to keep loaded, 0 to unload)",
+				},
+				"gpuLayers": map[string]any{
+					"type":        "integer",
+					"description": "Number of layers the in-process GGUF provider offloads to the GPU, 0 for CPU only (default: all)",
+					"minimum":     0,
+				},
+				"threads": map[string]any{
+					"type":        "integer",
+					"description": "Number of CPU threads of the in-process GGUF provider",
+					"minimum":     1,
+				},
 			},
 		},
 	}
@@ -175,6 +849,18 @@ func generateSchema() map[string]any {
 		string(models.ProviderGROQ),
 		string(models.ProviderBedrock),
 		string(models.ProviderAzure),
+		string(models.ProviderOllama),
+		string(models.ProviderOpenAICompatible),
+		string(models.ProviderLMStudio),
+		string(models.ProviderLlamaCpp),
+		string(models.ProviderGGUF),
+		string(models.ProviderVertexAI),
+		string(models.ProviderOpenRouter),
+		string(models.ProviderDeepSeek),
+		string(models.ProviderXAI),
+		string(models.ProviderMistral),
+		string(models.ProviderTogether),
+		string(models.ProviderCopilot),
 	}
 
 	providerSchema["additionalProperties"].(map[string]any)["properties"].(map[string]any)["provider"] = map[string]any{
@@ -207,6 +893,66 @@ func generateSchema() map[string]any {
 					"description": "Reasoning effort for models that support it (OpenAI, Anthropic)",
 					"enum":        []string{"low", "medium", "high"},
 				},
+				"thinkingBudget": map[string]any{
+					"type":        "integer",
+					"description": "Token budget of extended thinking for Claude models, 0 thinks only when asked to",
+					"minimum":     0,
+				},
+				"fallback": map[string]any{
+					"type":        "array",
+					"description": "Models tried in order when the provider of the model is rate limited, failing or not responding",
+					"items": map[string]any{
+						"type": "string",
+					},
+				},
+				"timeout": map[string]any{
+					"type":        "string",
+					"description": "Overrides the request timeout of the provider for this agent (e.g. 10m)",
+				},
+				"toolConcurrency": map[string]any{
+					"type":        "integer",
+					"description": "Number of read-only tool calls run at the same time, 1 runs them one after another (default: 4)",
+					"minimum":     1,
+				},
+				"temperature": map[string]any{
+					"type":        "number",
+					"description": "Sampling temperature (Ollama)",
+				},
+				"topP": map[string]any{
+					"type":        "number",
+					"description": "Nucleus sampling probability mass (Ollama)",
+				},
+				"topK": map[string]any{
+					"type":        "integer",
+					"description": "Number of top tokens considered when sampling (Ollama)",
+				},
+				"numCtx": map[string]any{
+					"type":        "integer",
+					"description": "Context window size, defaults to the model's context window (Ollama)",
+				},
+				"repeatPenalty": map[string]any{
+					"type":        "number",
+					"description": "Penalty applied to repeated tokens (Ollama)",
+				},
+				"stop": map[string]any{
+					"type":        "array",
+					"description": "Sequences that stop the generation (Ollama)",
+					"items": map[string]any{
+						"type": "string",
+					},
+				},
+				"seed": map[string]any{
+					"type":        "integer",
+					"description": "Random seed for reproducible outputs (Ollama)",
+				},
+				"format": map[string]any{
+					"type":        "string",
+					"description": "\"json\" or a JSON schema constraining the responses (Ollama)",
+				},
+				"grammar": map[string]any{
+					"type":        "string",
+					"description": "GBNF grammar constraining the responses (llama.cpp)",
+				},
 			},
 			"required": []string{"model"},
 		},
@@ -217,7 +963,9 @@ func generateSchema() map[string]any {
 	for modelID := range models.SupportedModels {
 		modelEnum = append(modelEnum, string(modelID))
 	}
+	sort.Strings(modelEnum)
 	agentSchema["additionalProperties"].(map[string]any)["properties"].(map[string]any)["model"].(map[string]any)["enum"] = modelEnum
+	agentSchema["additionalProperties"].(map[string]any)["properties"].(map[string]any)["fallback"].(map[string]any)["items"].(map[string]any)["enum"] = modelEnum
 
 	// Add specific agent properties
 	agentProperties := map[string]any{}
@@ -225,6 +973,7 @@ func generateSchema() map[string]any {
 		string(config.AgentCoder),
 		string(config.AgentTask),
 		string(config.AgentTitle),
+		string(config.AgentSummarizer),
 	}
 
 	for _, agentName := range knownAgents {
@@ -246,6 +995,23 @@ func generateSchema() map[string]any {
 		"agent": agentSchema["additionalProperties"],
 	}
 
+	schema["properties"].(map[string]any)["models"] = map[string]any{
+		"type":        "object",
+		"description": "Default models of the agents by tier, the model of an agent overrides its tier",
+		"properties": map[string]any{
+			"small": map[string]any{
+				"type":        "string",
+				"description": "Model of the title and summarizer agents",
+				"enum":        modelEnum,
+			},
+			"large": map[string]any{
+				"type":        "string",
+				"description": "Model of the coder and task agents",
+				"enum":        modelEnum,
+			},
+		},
+	}
+
 	// Add LSP configuration
 	schema["properties"].(map[string]any)["lsp"] = map[string]any{
 		"type":        "object",
@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/opencode-ai/opencode/internal/config"
+	"github.com/opencode-ai/opencode/internal/db"
+	"github.com/opencode-ai/opencode/internal/usage"
+	"github.com/spf13/cobra"
+)
+
+var usageCmd = &cobra.Command{
+	Use:   "usage",
+	Short: "Report the tokens and cost of past requests",
+	Long: `Report the tokens and cost of the requests made to all providers, summed per
+session, per day or per provider. The usage is kept when a session is deleted.`,
+	Example: `  opencode usage
+  opencode usage --by provider --days 7`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		by, _ := cmd.Flags().GetString("by")
+		days, _ := cmd.Flags().GetInt("days")
+		if days <= 0 {
+			return fmt.Errorf("days must be positive")
+		}
+
+		cwd, _ := cmd.Flags().GetString("cwd")
+		if cwd == "" {
+			c, err := os.Getwd()
+			if err != nil {
+				return fmt.Errorf("failed to get current working directory: %v", err)
+			}
+			cwd = c
+		}
+		if _, err := config.Load(cwd, false); err != nil {
+			return err
+		}
+		conn, err := db.Connect()
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+
+		ctx := context.Background()
+		service := usage.NewService(db.New(conn))
+		since := time.Now().AddDate(0, 0, -days)
+		var stats []usage.Stats
+		var header string
+		switch by {
+		case "session":
+			header = "Session"
+			stats, err = service.BySession(ctx, since)
+		case "day":
+			header = "Day"
+			stats, err = service.ByDay(ctx, since)
+		case "provider":
+			header = "Provider"
+			stats, err = service.ByProvider(ctx, since)
+		default:
+			return fmt.Errorf("invalid value for --by: %s (expected session, day or provider)", by)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read usage: %w", err)
+		}
+
+		w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', tabwriter.AlignRight)
+		fmt.Fprintf(w, "%s\tRequests\tInput\tOutput\tCache write\tCache read\tCost\t\n", header)
+		var total usage.Stats
+		for _, s := range stats {
+			name := s.Title
+			if name == "" {
+				name = s.Key
+			}
+			writeUsageRow(w, name, s)
+			total.Requests += s.Requests
+			total.InputTokens += s.InputTokens
+			total.OutputTokens += s.OutputTokens
+			total.CacheCreationTokens += s.CacheCreationTokens
+			total.CacheReadTokens += s.CacheReadTokens
+			total.Cost += s.Cost
+		}
+		writeUsageRow(w, "Total", total)
+		return w.Flush()
+	},
+}
+
+func writeUsageRow(w *tabwriter.Writer, name string, s usage.Stats) {
+	fmt.Fprintf(w, "%s\t%d\t%s\t%s\t%s\t%s\t$%.4f\t\n",
+		name,
+		s.Requests,
+		usage.FormatTokens(s.InputTokens),
+		usage.FormatTokens(s.OutputTokens),
+		usage.FormatTokens(s.CacheCreationTokens),
+		usage.FormatTokens(s.CacheReadTokens),
+		s.Cost,
+	)
+}
+
+func init() {
+	usageCmd.Flags().String("by", "session", "Sum the usage per session, day or provider")
+	usageCmd.Flags().Int("days", 30, "Number of days to report")
+	usageCmd.Flags().StringP("cwd", "c", "", "Current working directory")
+	rootCmd.AddCommand(usageCmd)
+}
@@ -7,6 +7,8 @@ import (
 	"sync"
 	"time"
 
+	"github.com/opencode-ai/opencode/internal/browser"
+	"github.com/opencode-ai/opencode/internal/checkpoint"
 	"github.com/opencode-ai/opencode/internal/config"
 	"github.com/opencode-ai/opencode/internal/db"
 	"github.com/opencode-ai/opencode/internal/history"
@@ -14,8 +16,12 @@ import (
 	"github.com/opencode-ai/opencode/internal/logging"
 	"github.com/opencode-ai/opencode/internal/lsp"
 	"github.com/opencode-ai/opencode/internal/message"
+	"github.com/opencode-ai/opencode/internal/note"
+	"github.com/opencode-ai/opencode/internal/notebook"
 	"github.com/opencode-ai/opencode/internal/permission"
 	"github.com/opencode-ai/opencode/internal/session"
+	"github.com/opencode-ai/opencode/internal/todo"
+	"github.com/opencode-ai/opencode/internal/usage"
 )
 
 type App struct {
@@ -23,6 +29,10 @@ type App struct {
 	Messages    message.Service
 	History     history.Service
 	Permissions permission.Service
+	Usage       usage.Service
+	Notes       note.Service
+	Todos       todo.Service
+	Checkpoints checkpoint.Service
 
 	CoderAgent agent.Service
 
@@ -40,12 +50,17 @@ func New(ctx context.Context, conn *sql.DB) (*App, error) {
 	sessions := session.NewService(q)
 	messages := message.NewService(q)
 	files := history.NewService(q, conn)
+	usages := usage.NewService(q)
 
 	app := &App{
 		Sessions:    sessions,
 		Messages:    messages,
 		History:     files,
 		Permissions: permission.NewPermissionService(),
+		Usage:       usages,
+		Notes:       note.NewService(q),
+		Todos:       todo.NewService(q, conn),
+		Checkpoints: checkpoint.NewService(q, sessions, messages, files),
 		LSPClients:  make(map[string]*lsp.Client),
 	}
 
@@ -57,11 +72,15 @@ func New(ctx context.Context, conn *sql.DB) (*App, error) {
 		config.AgentCoder,
 		app.Sessions,
 		app.Messages,
+		app.Usage,
 		agent.CoderAgentTools(
 			app.Permissions,
 			app.Sessions,
 			app.Messages,
 			app.History,
+			app.Usage,
+			app.Notes,
+			app.Todos,
 			app.LSPClients,
 		),
 	)
@@ -83,6 +102,12 @@ func (app *App) Shutdown() {
 	app.cancelFuncsMutex.Unlock()
 	app.watcherWG.Wait()
 
+	// Stop the kernels of the notebook tool
+	notebook.CloseKernels()
+
+	// Stop the headless browser of the browser tool
+	browser.Close()
+
 	// Perform additional cleanup for LSP clients
 	app.clientsMutex.RLock()
 	clients := make(map[string]*lsp.Client, len(app.LSPClients))
@@ -0,0 +1,267 @@
+// Package browser drives a headless Chromium with the Chrome DevTools
+// Protocol, so pages of the project can be opened, inspected and scripted.
+package browser
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrNotFound is returned when no Chromium or Chrome executable is found.
+var ErrNotFound = errors.New("no Chromium or Chrome executable found, install Chromium or set browser.path in the configuration")
+
+const (
+	// startTimeout is how long Chromium may take to start
+	startTimeout = 30 * time.Second
+	// closeTimeout is how long Chromium may take to exit before it is killed
+	closeTimeout = 5 * time.Second
+)
+
+// executables are the names of Chromium and Chrome in PATH, in the order they
+// are tried.
+var executables = []string{
+	"chromium",
+	"chromium-browser",
+	"google-chrome",
+	"google-chrome-stable",
+	"chrome",
+	"microsoft-edge",
+}
+
+// appPaths are where Chromium and Chrome are installed on the systems where
+// they aren't in PATH.
+var appPaths = map[string][]string{
+	"darwin": {
+		"/Applications/Chromium.app/Contents/MacOS/Chromium",
+		"/Applications/Google Chrome.app/Contents/MacOS/Google Chrome",
+		"/Applications/Microsoft Edge.app/Contents/MacOS/Microsoft Edge",
+	},
+	"windows": {
+		`C:\Program Files\Google\Chrome\Application\chrome.exe`,
+		`C:\Program Files (x86)\Google\Chrome\Application\chrome.exe`,
+		`C:\Program Files (x86)\Microsoft\Edge\Application\msedge.exe`,
+	},
+}
+
+var devToolsPattern = regexp.MustCompile(`DevTools listening on (ws://\S+)`)
+
+// findExecutable returns the configured executable, or the first Chromium or
+// Chrome that is installed.
+func findExecutable(path string) (string, error) {
+	if path != "" {
+		found, err := exec.LookPath(path)
+		if err != nil {
+			return "", fmt.Errorf("browser %s not found: %w", path, err)
+		}
+		return found, nil
+	}
+	for _, name := range executables {
+		if found, err := exec.LookPath(name); err == nil {
+			return found, nil
+		}
+	}
+	for _, candidate := range appPaths[runtime.GOOS] {
+		if _, err := os.Stat(candidate); err == nil {
+			return candidate, nil
+		}
+	}
+	return "", ErrNotFound
+}
+
+// launchArgs are the arguments Chromium is started with, before the
+// configured ones.
+func launchArgs(dataDir string) []string {
+	args := []string{
+		"--headless=new",
+		"--remote-debugging-port=0",
+		"--remote-allow-origins=http://127.0.0.1",
+		"--user-data-dir=" + dataDir,
+		"--no-first-run",
+		"--no-default-browser-check",
+		"--disable-extensions",
+		"--disable-background-networking",
+		"--disable-sync",
+		"--mute-audio",
+		"--window-size=1280,800",
+	}
+	// The sandbox of Chromium can't run as root, which is common in
+	// containers
+	if os.Geteuid() == 0 {
+		args = append(args, "--no-sandbox")
+	}
+	return args
+}
+
+// Browser is a running headless Chromium.
+type Browser struct {
+	cmd     *exec.Cmd
+	conn    *conn
+	dataDir string
+	exited  chan struct{}
+}
+
+// Launch starts a headless Chromium with a new profile, the executable at the
+// path or an installed one when it is empty, with the args added to its
+// arguments.
+func Launch(ctx context.Context, path string, args []string) (*Browser, error) {
+	executable, err := findExecutable(path)
+	if err != nil {
+		return nil, err
+	}
+	dataDir, err := os.MkdirTemp("", "opencode-browser-")
+	if err != nil {
+		return nil, err
+	}
+	cmd := exec.Command(executable, append(launchArgs(dataDir), args...)...)
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		os.RemoveAll(dataDir)
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		os.RemoveAll(dataDir)
+		return nil, fmt.Errorf("failed to start %s: %w", executable, err)
+	}
+	b := &Browser{cmd: cmd, dataDir: dataDir, exited: make(chan struct{})}
+	go func() {
+		cmd.Wait()
+		close(b.exited)
+	}()
+
+	url, output := readDevToolsURL(stderr)
+	ctx, cancel := context.WithTimeout(ctx, startTimeout)
+	defer cancel()
+	select {
+	case <-ctx.Done():
+		b.Close()
+		return nil, fmt.Errorf("the browser did not start: %w", ctx.Err())
+	case u, ok := <-url:
+		if !ok {
+			b.Close()
+			return nil, fmt.Errorf("the browser exited: %s", lastLine(output()))
+		}
+		b.conn, err = dial(ctx, u)
+		if err != nil {
+			b.Close()
+			return nil, fmt.Errorf("failed to connect to the browser: %w", err)
+		}
+	}
+	return b, nil
+}
+
+// readDevToolsURL reads the stderr of Chromium, the returned channel gets the
+// URL of the DevTools protocol it prints when it started, and is closed when
+// Chromium exited before. Output returns what Chromium printed until then.
+func readDevToolsURL(stderr io.Reader) (<-chan string, func() string) {
+	url := make(chan string, 1)
+	var mu sync.Mutex
+	var lines []string
+	go func() {
+		scanner := bufio.NewScanner(stderr)
+		found := false
+		for scanner.Scan() {
+			if found {
+				continue
+			}
+			if match := devToolsPattern.FindStringSubmatch(scanner.Text()); match != nil {
+				found = true
+				url <- match[1]
+				continue
+			}
+			mu.Lock()
+			lines = append(lines, scanner.Text())
+			mu.Unlock()
+		}
+		if !found {
+			close(url)
+		}
+	}()
+	return url, func() string {
+		mu.Lock()
+		defer mu.Unlock()
+		return strings.Join(lines, "\n")
+	}
+}
+
+// lastLine returns the last line of the output that isn't empty, which is
+// usually the error.
+func lastLine(output string) string {
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	if last := strings.TrimSpace(lines[len(lines)-1]); last != "" {
+		return last
+	}
+	return "no output"
+}
+
+// NewPage opens a new tab of the size in CSS pixels.
+func (b *Browser) NewPage(ctx context.Context, width, height int) (*Page, error) {
+	var target struct {
+		TargetID string `json:"targetId"`
+	}
+	if err := b.conn.call(ctx, "", "Target.createTarget", map[string]any{"url": "about:blank"}, &target); err != nil {
+		return nil, err
+	}
+	var session struct {
+		SessionID string `json:"sessionId"`
+	}
+	if err := b.conn.call(ctx, "", "Target.attachToTarget", map[string]any{"targetId": target.TargetID, "flatten": true}, &session); err != nil {
+		return nil, err
+	}
+	p := &Page{conn: b.conn, targetID: target.TargetID, sessionID: session.SessionID}
+	b.conn.handle(p.sessionID, p.event)
+	for _, method := range []string{"Page.enable", "Runtime.enable", "Log.enable"} {
+		if err := p.call(ctx, method, nil, nil); err != nil {
+			p.Close(ctx)
+			return nil, err
+		}
+	}
+	if err := p.call(ctx, "Emulation.setDeviceMetricsOverride", map[string]any{
+		"width":             width,
+		"height":            height,
+		"deviceScaleFactor": 1,
+		"mobile":            false,
+	}, nil); err != nil {
+		p.Close(ctx)
+		return nil, err
+	}
+	return p, nil
+}
+
+// Running reports whether Chromium is still running.
+func (b *Browser) Running() bool {
+	select {
+	case <-b.exited:
+		return false
+	case <-b.conn.done:
+		return false
+	default:
+		return true
+	}
+}
+
+// Close shuts Chromium down and removes its profile.
+func (b *Browser) Close() {
+	if b.conn != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), closeTimeout)
+		b.conn.call(ctx, "", "Browser.close", nil, nil)
+		cancel()
+		b.conn.close()
+	}
+	select {
+	case <-b.exited:
+	case <-time.After(closeTimeout):
+		b.cmd.Process.Kill()
+		<-b.exited
+	}
+	os.RemoveAll(b.dataDir)
+}
@@ -0,0 +1,98 @@
+package browser
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/websocket"
+)
+
+// fakeBrowser answers the commands with the results of handle by method, and
+// sends a console event before each result.
+func fakeBrowser(t *testing.T, handle map[string]string) *conn {
+	t.Helper()
+	server := httptest.NewServer(websocket.Handler(func(ws *websocket.Conn) {
+		for {
+			var req struct {
+				ID        int64  `json:"id"`
+				SessionID string `json:"sessionId"`
+				Method    string `json:"method"`
+			}
+			if err := websocket.JSON.Receive(ws, &req); err != nil {
+				return
+			}
+			websocket.Message.Send(ws, `{"sessionId":"`+req.SessionID+`","method":"Runtime.consoleAPICalled","params":{"type":"log","args":[{"type":"string","value":"called"},{"type":"number","value":1}]}}`)
+			result, ok := handle[req.Method]
+			if !ok {
+				websocket.Message.Send(ws, `{"id":`+strconv.FormatInt(req.ID, 10)+`,"error":{"code":-32601,"message":"'`+req.Method+`' wasn't found"}}`)
+				continue
+			}
+			websocket.Message.Send(ws, `{"id":`+strconv.FormatInt(req.ID, 10)+`,"result":`+result+`}`)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	c, err := dial(context.Background(), "ws"+strings.TrimPrefix(server.URL, "http"))
+	require.NoError(t, err)
+	t.Cleanup(c.close)
+	return c
+}
+
+func TestPage(t *testing.T) {
+	c := fakeBrowser(t, map[string]string{
+		"Runtime.evaluate": `{"result":{"type":"object","value":{"a":[1,2]}}}`,
+	})
+	p := &Page{conn: c, sessionID: "s1"}
+	c.handle("s1", p.event)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	value, err := p.Evaluate(ctx, "({a: [1, 2]})")
+	require.NoError(t, err)
+	assert.Equal(t, "{\n  \"a\": [\n    1,\n    2\n  ]\n}", value)
+
+	messages, dropped := p.Console()
+	assert.Equal(t, []ConsoleMessage{{Level: "log", Text: "called 1"}}, messages)
+	assert.Zero(t, dropped)
+	messages, _ = p.Console()
+	assert.Empty(t, messages)
+
+	err = p.call(ctx, "Unknown.method", nil, nil)
+	var protocolErr *protocolError
+	require.True(t, errors.As(err, &protocolErr))
+	assert.Equal(t, "Unknown.method: 'Unknown.method' wasn't found", err.Error())
+}
+
+func TestFormatObject(t *testing.T) {
+	tests := []struct {
+		object remoteObject
+		want   string
+	}{
+		{remoteObject{Type: "undefined"}, "undefined"},
+		{remoteObject{Type: "string", Value: json.RawMessage(`"<b>hi</b>"`)}, "<b>hi</b>"},
+		{remoteObject{Type: "number", UnserializableValue: "NaN"}, "NaN"},
+		{remoteObject{Type: "object", Value: json.RawMessage(`{"html":"<p>"}`)}, "{\n  \"html\": \"<p>\"\n}"},
+		{remoteObject{Type: "function", Description: "function f() {}"}, "function f() {}"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, formatObject(tt.object))
+	}
+}
+
+func TestReadDevToolsURL(t *testing.T) {
+	url, output := readDevToolsURL(strings.NewReader("[0101/ERROR] something failed\n\nDevTools listening on ws://127.0.0.1:41234/devtools/browser/abc\n"))
+	assert.Equal(t, "ws://127.0.0.1:41234/devtools/browser/abc", <-url)
+
+	url, output = readDevToolsURL(strings.NewReader("Fontconfig error\n[0101/FATAL] Running as root without --no-sandbox is not supported\n"))
+	_, ok := <-url
+	assert.False(t, ok)
+	assert.Equal(t, "[0101/FATAL] Running as root without --no-sandbox is not supported", lastLine(output()))
+}
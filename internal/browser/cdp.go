@@ -0,0 +1,174 @@
+package browser
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"golang.org/x/net/websocket"
+)
+
+// maxMessageSize is the largest message read from Chromium, screenshots of
+// whole pages are big.
+const maxMessageSize = 64 * 1024 * 1024
+
+// errClosed is returned by the calls of a closed connection.
+var errClosed = errors.New("the connection to the browser is closed")
+
+// request is a command sent to Chromium. The commands of a page are sent with
+// the session ID of the page.
+type request struct {
+	ID        int64  `json:"id"`
+	SessionID string `json:"sessionId,omitempty"`
+	Method    string `json:"method"`
+	Params    any    `json:"params,omitempty"`
+}
+
+// response is a message of Chromium, the result of a command or an event.
+type response struct {
+	ID        int64           `json:"id,omitempty"`
+	SessionID string          `json:"sessionId,omitempty"`
+	Method    string          `json:"method,omitempty"`
+	Params    json.RawMessage `json:"params,omitempty"`
+	Result    json.RawMessage `json:"result,omitempty"`
+	Error     *protocolError  `json:"error,omitempty"`
+}
+
+type protocolError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *protocolError) Error() string {
+	return e.Message
+}
+
+// conn is a connection to Chromium with the DevTools protocol. The pages are
+// attached to with flattened sessions, so all of them share the connection.
+type conn struct {
+	ws *websocket.Conn
+
+	sendMu sync.Mutex
+	mu     sync.Mutex
+	nextID int64
+	// pending are the channels of the commands waiting for their result
+	pending map[int64]chan response
+	// handlers get the events of the sessions
+	handlers map[string]func(method string, params json.RawMessage)
+	closed   bool
+	done     chan struct{}
+}
+
+func dial(ctx context.Context, url string) (*conn, error) {
+	cfg, err := websocket.NewConfig(url, "http://127.0.0.1")
+	if err != nil {
+		return nil, err
+	}
+	ws, err := cfg.DialContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ws.MaxPayloadBytes = maxMessageSize
+	c := &conn{
+		ws:       ws,
+		pending:  make(map[int64]chan response),
+		handlers: make(map[string]func(string, json.RawMessage)),
+		done:     make(chan struct{}),
+	}
+	go c.read()
+	return c, nil
+}
+
+// read dispatches the messages of Chromium until the connection is closed.
+func (c *conn) read() {
+	defer c.close()
+	for {
+		var msg response
+		if err := websocket.JSON.Receive(c.ws, &msg); err != nil {
+			return
+		}
+		c.mu.Lock()
+		if msg.Method == "" {
+			if ch, ok := c.pending[msg.ID]; ok {
+				delete(c.pending, msg.ID)
+				ch <- msg
+			}
+			c.mu.Unlock()
+			continue
+		}
+		handler := c.handlers[msg.SessionID]
+		c.mu.Unlock()
+		if handler != nil {
+			handler(msg.Method, msg.Params)
+		}
+	}
+}
+
+// call sends the command to the session, the browser itself when it is empty,
+// and decodes its result into result when it isn't nil.
+func (c *conn) call(ctx context.Context, sessionID, method string, params, result any) error {
+	ch := make(chan response, 1)
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return errClosed
+	}
+	c.nextID++
+	id := c.nextID
+	c.pending[id] = ch
+	c.mu.Unlock()
+
+	c.sendMu.Lock()
+	err := websocket.JSON.Send(c.ws, request{ID: id, SessionID: sessionID, Method: method, Params: params})
+	c.sendMu.Unlock()
+	if err != nil {
+		c.forget(id)
+		return fmt.Errorf("%s: %w", method, err)
+	}
+
+	select {
+	case <-ctx.Done():
+		c.forget(id)
+		return ctx.Err()
+	case <-c.done:
+		return errClosed
+	case msg := <-ch:
+		if msg.Error != nil {
+			return fmt.Errorf("%s: %w", method, msg.Error)
+		}
+		if result == nil || len(msg.Result) == 0 {
+			return nil
+		}
+		return json.Unmarshal(msg.Result, result)
+	}
+}
+
+func (c *conn) forget(id int64) {
+	c.mu.Lock()
+	delete(c.pending, id)
+	c.mu.Unlock()
+}
+
+// handle sets the handler of the events of the session, nil removes it.
+func (c *conn) handle(sessionID string, handler func(method string, params json.RawMessage)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if handler == nil {
+		delete(c.handlers, sessionID)
+		return
+	}
+	c.handlers[sessionID] = handler
+}
+
+func (c *conn) close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return
+	}
+	c.closed = true
+	c.ws.Close()
+	close(c.done)
+}
@@ -0,0 +1,492 @@
+package browser
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// maxConsoleMessages is how many console messages a page keeps until they
+	// are read, older ones are dropped
+	maxConsoleMessages = 200
+	// pollInterval is how often a page is checked while waiting for it
+	pollInterval = 100 * time.Millisecond
+)
+
+// ConsoleMessage is a message of the console of a page, logged by its
+// scripts, an uncaught exception or a message of the browser, like a failed
+// request.
+type ConsoleMessage struct {
+	Level string
+	Text  string
+}
+
+// Element is an element matching a selector.
+type Element struct {
+	Tag        string            `json:"tag"`
+	Text       string            `json:"text"`
+	Attributes map[string]string `json:"attributes"`
+	X          float64           `json:"x"`
+	Y          float64           `json:"y"`
+	Width      float64           `json:"width"`
+	Height     float64           `json:"height"`
+	Visible    bool              `json:"visible"`
+}
+
+// ScriptError is an exception thrown by a script run on the page.
+type ScriptError struct {
+	Message string
+}
+
+func (e *ScriptError) Error() string {
+	return e.Message
+}
+
+// Page is a tab of the browser.
+type Page struct {
+	conn      *conn
+	targetID  string
+	sessionID string
+
+	mu      sync.Mutex
+	console []ConsoleMessage
+	dropped int
+}
+
+type remoteObject struct {
+	Type                string          `json:"type"`
+	Subtype             string          `json:"subtype,omitempty"`
+	Value               json.RawMessage `json:"value,omitempty"`
+	UnserializableValue string          `json:"unserializableValue,omitempty"`
+	Description         string          `json:"description,omitempty"`
+}
+
+type exceptionDetails struct {
+	Text       string        `json:"text"`
+	URL        string        `json:"url,omitempty"`
+	LineNumber int           `json:"lineNumber"`
+	Exception  *remoteObject `json:"exception,omitempty"`
+}
+
+// message returns the description of the exception, which has its stack.
+func (e exceptionDetails) message() string {
+	if e.Exception != nil && e.Exception.Description != "" {
+		return e.Exception.Description
+	}
+	if e.Exception != nil && len(e.Exception.Value) > 0 {
+		return e.Text + " " + string(e.Exception.Value)
+	}
+	return e.Text
+}
+
+func (p *Page) call(ctx context.Context, method string, params, result any) error {
+	return p.conn.call(ctx, p.sessionID, method, params, result)
+}
+
+// event handles the events of the page. It runs on the reader of the
+// connection, so it must not wait for the results of commands.
+func (p *Page) event(method string, params json.RawMessage) {
+	switch method {
+	case "Runtime.consoleAPICalled":
+		var event struct {
+			Type string         `json:"type"`
+			Args []remoteObject `json:"args"`
+		}
+		if json.Unmarshal(params, &event) != nil {
+			return
+		}
+		args := make([]string, len(event.Args))
+		for i, arg := range event.Args {
+			args[i] = formatObject(arg)
+		}
+		p.log(event.Type, strings.Join(args, " "))
+	case "Runtime.exceptionThrown":
+		var event struct {
+			ExceptionDetails exceptionDetails `json:"exceptionDetails"`
+		}
+		if json.Unmarshal(params, &event) != nil {
+			return
+		}
+		p.log("exception", event.ExceptionDetails.message())
+	case "Log.entryAdded":
+		var event struct {
+			Entry struct {
+				Level string `json:"level"`
+				Text  string `json:"text"`
+				URL   string `json:"url"`
+			} `json:"entry"`
+		}
+		if json.Unmarshal(params, &event) != nil {
+			return
+		}
+		text := event.Entry.Text
+		if event.Entry.URL != "" {
+			text += " (" + event.Entry.URL + ")"
+		}
+		p.log(event.Entry.Level, text)
+	case "Page.javascriptDialogOpening":
+		// Dialogs block the scripts of the page until they are closed
+		var event struct {
+			Type    string `json:"type"`
+			Message string `json:"message"`
+		}
+		json.Unmarshal(params, &event)
+		p.log("dialog", fmt.Sprintf("%s accepted: %s", event.Type, event.Message))
+		go p.call(context.Background(), "Page.handleJavaScriptDialog", map[string]any{"accept": true}, nil)
+	}
+}
+
+func (p *Page) log(level, text string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.console) == maxConsoleMessages {
+		p.console = p.console[1:]
+		p.dropped++
+	}
+	p.console = append(p.console, ConsoleMessage{Level: level, Text: text})
+}
+
+// Console returns the console messages logged since it was last called, and
+// how many older ones were dropped.
+func (p *Page) Console() ([]ConsoleMessage, int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	messages, dropped := p.console, p.dropped
+	p.console, p.dropped = nil, 0
+	return messages, dropped
+}
+
+// Navigate opens the URL and waits until the page loaded. It returns the URL
+// the page ended up at, after redirects, and its title.
+func (p *Page) Navigate(ctx context.Context, url string) (string, string, error) {
+	var result struct {
+		ErrorText string `json:"errorText"`
+	}
+	if err := p.call(ctx, "Page.navigate", map[string]any{"url": url}, &result); err != nil {
+		return "", "", err
+	}
+	if result.ErrorText != "" {
+		return "", "", fmt.Errorf("failed to open %s: %s", url, result.ErrorText)
+	}
+	if err := p.poll(ctx, `document.readyState === "complete"`); err != nil {
+		return "", "", fmt.Errorf("the page did not finish loading: %w", err)
+	}
+	var page struct {
+		URL   string `json:"url"`
+		Title string `json:"title"`
+	}
+	if err := p.evaluateValue(ctx, `({url: location.href, title: document.title})`, &page); err != nil {
+		return "", "", err
+	}
+	return page.URL, page.Title, nil
+}
+
+// Evaluate runs the JavaScript expression on the page and returns its value,
+// formatted as JSON when it can be. Promises are awaited.
+func (p *Page) Evaluate(ctx context.Context, expression string) (string, error) {
+	object, err := p.evaluate(ctx, expression)
+	if err != nil {
+		return "", err
+	}
+	return formatObject(object), nil
+}
+
+func (p *Page) evaluate(ctx context.Context, expression string) (remoteObject, error) {
+	var result struct {
+		Result           remoteObject      `json:"result"`
+		ExceptionDetails *exceptionDetails `json:"exceptionDetails,omitempty"`
+	}
+	if err := p.call(ctx, "Runtime.evaluate", map[string]any{
+		"expression":    expression,
+		"returnByValue": true,
+		"awaitPromise":  true,
+		"userGesture":   true,
+	}, &result); err != nil {
+		return remoteObject{}, err
+	}
+	if result.ExceptionDetails != nil {
+		return remoteObject{}, &ScriptError{Message: result.ExceptionDetails.message()}
+	}
+	return result.Result, nil
+}
+
+// evaluateValue runs the expression and decodes its value into v.
+func (p *Page) evaluateValue(ctx context.Context, expression string, v any) error {
+	object, err := p.evaluate(ctx, expression)
+	if err != nil {
+		return err
+	}
+	if len(object.Value) == 0 {
+		return json.Unmarshal([]byte("null"), v)
+	}
+	return json.Unmarshal(object.Value, v)
+}
+
+// poll waits until the expression is true.
+func (p *Page) poll(ctx context.Context, expression string) error {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		var done bool
+		err := p.evaluateValue(ctx, expression, &done)
+		// The context of the scripts is replaced while a page is loading, the
+		// errors until then are retried
+		var protocolErr *protocolError
+		if err != nil && !errors.As(err, &protocolErr) {
+			return err
+		}
+		if done {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// WaitFor waits until an element matches the selector.
+func (p *Page) WaitFor(ctx context.Context, selector string) error {
+	return p.poll(ctx, fmt.Sprintf(`document.querySelector(%s) !== null`, quote(selector)))
+}
+
+// Query returns the first elements matching the selector, at most limit, and
+// how many match.
+func (p *Page) Query(ctx context.Context, selector string, limit int) ([]Element, int, error) {
+	var result struct {
+		Count    int       `json:"count"`
+		Elements []Element `json:"elements"`
+	}
+	err := p.evaluateValue(ctx, fmt.Sprintf(queryScript, quote(selector), limit), &result)
+	return result.Elements, result.Count, err
+}
+
+const queryScript = `(() => {
+	const elements = Array.from(document.querySelectorAll(%s));
+	const shorten = (s, n) => s.length > n ? s.slice(0, n) + "..." : s;
+	return {
+		count: elements.length,
+		elements: elements.slice(0, %d).map(el => {
+			const r = el.getBoundingClientRect();
+			const attributes = {};
+			for (const a of el.attributes) attributes[a.name] = shorten(a.value, 200);
+			const text = (el.innerText ?? el.textContent ?? "").trim().replace(/\s+/g, " ");
+			return {
+				tag: el.tagName.toLowerCase(),
+				text: shorten(text, 300),
+				attributes,
+				x: r.x, y: r.y, width: r.width, height: r.height,
+				visible: r.width > 0 && r.height > 0 && getComputedStyle(el).visibility !== "hidden",
+			};
+		}),
+	};
+})()`
+
+// Click scrolls the first element matching the selector into view and clicks
+// its center with the mouse.
+func (p *Page) Click(ctx context.Context, selector string) error {
+	var point *struct {
+		X      float64 `json:"x"`
+		Y      float64 `json:"y"`
+		Width  float64 `json:"width"`
+		Height float64 `json:"height"`
+	}
+	if err := p.evaluateValue(ctx, fmt.Sprintf(`(() => {
+	const el = document.querySelector(%s);
+	if (!el) return null;
+	el.scrollIntoView({block: "center", inline: "center"});
+	const r = el.getBoundingClientRect();
+	return {x: r.x + r.width / 2, y: r.y + r.height / 2, width: r.width, height: r.height};
+})()`, quote(selector)), &point); err != nil {
+		return err
+	}
+	if point == nil {
+		return fmt.Errorf("no element matches %s", selector)
+	}
+	if point.Width == 0 || point.Height == 0 {
+		return fmt.Errorf("the element matching %s is not visible", selector)
+	}
+	for _, event := range []string{"mouseMoved", "mousePressed", "mouseReleased"} {
+		if err := p.call(ctx, "Input.dispatchMouseEvent", map[string]any{
+			"type":       event,
+			"x":          point.X,
+			"y":          point.Y,
+			"button":     "left",
+			"clickCount": 1,
+		}, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Type focuses the first element matching the selector, selects its content
+// and types the text, which replaces it.
+func (p *Page) Type(ctx context.Context, selector, text string) error {
+	var found bool
+	if err := p.evaluateValue(ctx, fmt.Sprintf(`(() => {
+	const el = document.querySelector(%s);
+	if (!el) return false;
+	el.scrollIntoView({block: "center"});
+	el.focus();
+	if (typeof el.select === "function") el.select();
+	else if (el.isContentEditable) document.execCommand("selectAll");
+	return true;
+})()`, quote(selector)), &found); err != nil {
+		return err
+	}
+	if !found {
+		return fmt.Errorf("no element matches %s", selector)
+	}
+	return p.call(ctx, "Input.insertText", map[string]any{"text": text}, nil)
+}
+
+// key is a key that can be pressed.
+type key struct {
+	name    string
+	code    string
+	keyCode int
+	text    string
+}
+
+// keys are the keys Press accepts.
+var keys = []key{
+	{"Enter", "Enter", 13, "\r"},
+	{"Tab", "Tab", 9, ""},
+	{"Escape", "Escape", 27, ""},
+	{"Backspace", "Backspace", 8, ""},
+	{"Delete", "Delete", 46, ""},
+	{"Space", "Space", 32, " "},
+	{"ArrowUp", "ArrowUp", 38, ""},
+	{"ArrowDown", "ArrowDown", 40, ""},
+	{"ArrowLeft", "ArrowLeft", 37, ""},
+	{"ArrowRight", "ArrowRight", 39, ""},
+	{"Home", "Home", 36, ""},
+	{"End", "End", 35, ""},
+	{"PageUp", "PageUp", 33, ""},
+	{"PageDown", "PageDown", 34, ""},
+}
+
+// KeyNames returns the names of the keys Press accepts.
+func KeyNames() []string {
+	names := make([]string, len(keys))
+	for i, k := range keys {
+		names[i] = k.name
+	}
+	return names
+}
+
+// Press presses the key in the focused element.
+func (p *Page) Press(ctx context.Context, name string) error {
+	i := slices.IndexFunc(keys, func(k key) bool { return k.name == name })
+	if i < 0 {
+		return fmt.Errorf("unknown key %q, must be one of: %s", name, strings.Join(KeyNames(), ", "))
+	}
+	k := keys[i]
+	keyName := name
+	if name == "Space" {
+		keyName = " "
+	}
+	down := map[string]any{
+		"type":                  "rawKeyDown",
+		"key":                   keyName,
+		"code":                  k.code,
+		"windowsVirtualKeyCode": k.keyCode,
+		"nativeVirtualKeyCode":  k.keyCode,
+	}
+	if k.text != "" {
+		down["type"] = "keyDown"
+		down["text"] = k.text
+	}
+	if err := p.call(ctx, "Input.dispatchKeyEvent", down, nil); err != nil {
+		return err
+	}
+	return p.call(ctx, "Input.dispatchKeyEvent", map[string]any{
+		"type":                  "keyUp",
+		"key":                   keyName,
+		"code":                  k.code,
+		"windowsVirtualKeyCode": k.keyCode,
+		"nativeVirtualKeyCode":  k.keyCode,
+	}, nil)
+}
+
+// Screenshot captures the viewport of the page as a PNG image, or the whole
+// page when fullPage is set.
+func (p *Page) Screenshot(ctx context.Context, fullPage bool) ([]byte, error) {
+	params := map[string]any{"format": "png"}
+	if fullPage {
+		var metrics struct {
+			CSSContentSize struct {
+				Width  float64 `json:"width"`
+				Height float64 `json:"height"`
+			} `json:"cssContentSize"`
+		}
+		if err := p.call(ctx, "Page.getLayoutMetrics", nil, &metrics); err != nil {
+			return nil, err
+		}
+		params["captureBeyondViewport"] = true
+		params["clip"] = map[string]any{
+			"x":      0,
+			"y":      0,
+			"width":  metrics.CSSContentSize.Width,
+			"height": metrics.CSSContentSize.Height,
+			"scale":  1,
+		}
+	}
+	var result struct {
+		Data string `json:"data"`
+	}
+	if err := p.call(ctx, "Page.captureScreenshot", params, &result); err != nil {
+		return nil, err
+	}
+	return base64.StdEncoding.DecodeString(result.Data)
+}
+
+// Close closes the tab.
+func (p *Page) Close(ctx context.Context) {
+	p.conn.call(ctx, "", "Target.closeTarget", map[string]any{"targetId": p.targetID}, nil)
+	p.conn.handle(p.sessionID, nil)
+}
+
+// formatObject formats a value of the page, as JSON when it has one.
+func formatObject(object remoteObject) string {
+	switch {
+	case object.Type == "undefined":
+		return "undefined"
+	case object.UnserializableValue != "":
+		return object.UnserializableValue
+	case len(object.Value) > 0:
+		var s string
+		if object.Type == "string" && json.Unmarshal(object.Value, &s) == nil {
+			return s
+		}
+		var indented strings.Builder
+		var v any
+		if json.Unmarshal(object.Value, &v) == nil {
+			encoder := json.NewEncoder(&indented)
+			encoder.SetEscapeHTML(false)
+			encoder.SetIndent("", "  ")
+			if encoder.Encode(v) == nil {
+				return strings.TrimSuffix(indented.String(), "\n")
+			}
+		}
+		return string(object.Value)
+	case object.Description != "":
+		return object.Description
+	}
+	return object.Type
+}
+
+// quote returns the string as a JavaScript string literal.
+func quote(s string) string {
+	b, _ := json.Marshal(s)
+	return string(b)
+}
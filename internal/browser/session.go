@@ -0,0 +1,86 @@
+package browser
+
+import (
+	"context"
+	"sync"
+)
+
+const (
+	// pageWidth and pageHeight are the size of the pages in CSS pixels
+	pageWidth  = 1280
+	pageHeight = 800
+)
+
+// running is the browser shared by the sessions, and the page of each session.
+var running = struct {
+	sync.Mutex
+	browser *Browser
+	pages   map[string]*Page
+}{pages: make(map[string]*Page)}
+
+// PageFor returns the page of the session, and opens one when it has none,
+// starting the browser with the executable at the path and the args when it
+// isn't running.
+func PageFor(ctx context.Context, sessionID, path string, args []string) (*Page, error) {
+	running.Lock()
+	defer running.Unlock()
+	if p := openPage(sessionID); p != nil {
+		return p, nil
+	}
+	if running.browser == nil {
+		b, err := Launch(ctx, path, args)
+		if err != nil {
+			return nil, err
+		}
+		running.browser = b
+	}
+	p, err := running.browser.NewPage(ctx, pageWidth, pageHeight)
+	if err != nil {
+		return nil, err
+	}
+	running.pages[sessionID] = p
+	return p, nil
+}
+
+// OpenPage returns the page of the session, or nil when it has none.
+func OpenPage(sessionID string) *Page {
+	running.Lock()
+	defer running.Unlock()
+	return openPage(sessionID)
+}
+
+// openPage returns the page of the session, pages of a browser that exited
+// are forgotten. The caller holds the lock of running.
+func openPage(sessionID string) *Page {
+	if running.browser != nil && !running.browser.Running() {
+		running.browser.Close()
+		running.browser = nil
+		running.pages = make(map[string]*Page)
+	}
+	return running.pages[sessionID]
+}
+
+// ClosePage closes the page of the session, the browser keeps running for the
+// other sessions.
+func ClosePage(ctx context.Context, sessionID string) bool {
+	running.Lock()
+	p, ok := running.pages[sessionID]
+	delete(running.pages, sessionID)
+	running.Unlock()
+	if ok {
+		p.Close(ctx)
+	}
+	return ok
+}
+
+// Close shuts the browser down, when it is running.
+func Close() {
+	running.Lock()
+	b := running.browser
+	running.browser = nil
+	running.pages = make(map[string]*Page)
+	running.Unlock()
+	if b != nil {
+		b.Close()
+	}
+}
@@ -0,0 +1,285 @@
+package checkpoint
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/opencode-ai/opencode/internal/db"
+	"github.com/opencode-ai/opencode/internal/history"
+	"github.com/opencode-ai/opencode/internal/message"
+	"github.com/opencode-ai/opencode/internal/pubsub"
+	"github.com/opencode-ai/opencode/internal/session"
+)
+
+// Checkpoint is a point of a session it can be rewound to.
+type Checkpoint struct {
+	ID        string
+	SessionID string
+	// MessageID is the last message of the session at the checkpoint, empty
+	// when the session had none
+	MessageID string
+	Label     string
+	// Auto is set for the checkpoints created before each message of the user
+	Auto bool
+	// Files are the IDs of the latest versions of the files of the session in
+	// the file history at the checkpoint, by path
+	Files     map[string]string
+	CreatedAt int64
+}
+
+// Rewind is the result of rewinding a session to a checkpoint.
+type Rewind struct {
+	// Messages is the number of messages discarded
+	Messages int
+	// Restored are the files written back as they were at the checkpoint
+	Restored []string
+	// Removed are the files the session created after the checkpoint
+	Removed []string
+}
+
+type Service interface {
+	pubsub.Suscriber[Checkpoint]
+	// Create marks the current state of the session as a checkpoint.
+	Create(ctx context.Context, sessionID, label string, auto bool) (Checkpoint, error)
+	// List returns the checkpoints of the session, the newest first.
+	List(ctx context.Context, sessionID string) ([]Checkpoint, error)
+	// Rewind discards the messages of the session after the checkpoint, and
+	// the checkpoints after it. With revertFiles the files the session
+	// changed since are written back as they were at the checkpoint.
+	Rewind(ctx context.Context, id string, revertFiles bool) (Rewind, error)
+}
+
+type service struct {
+	*pubsub.Broker[Checkpoint]
+	q        db.Querier
+	sessions session.Service
+	messages message.Service
+	files    history.Service
+}
+
+func NewService(q db.Querier, sessions session.Service, messages message.Service, files history.Service) Service {
+	return &service{
+		Broker:   pubsub.NewBroker[Checkpoint](),
+		q:        q,
+		sessions: sessions,
+		messages: messages,
+		files:    files,
+	}
+}
+
+func (s *service) Create(ctx context.Context, sessionID, label string, auto bool) (Checkpoint, error) {
+	msgs, err := s.messages.List(ctx, sessionID)
+	if err != nil {
+		return Checkpoint{}, err
+	}
+	messageID := ""
+	if len(msgs) > 0 {
+		messageID = msgs[len(msgs)-1].ID
+	}
+	all, err := s.files.ListBySession(ctx, sessionID)
+	if err != nil {
+		return Checkpoint{}, err
+	}
+	latest := latestVersions(all)
+	files := make(map[string]string, len(latest))
+	for path, file := range latest {
+		files[path] = file.ID
+	}
+	filesJSON, err := json.Marshal(files)
+	if err != nil {
+		return Checkpoint{}, err
+	}
+
+	dbCheckpoint, err := s.q.CreateCheckpoint(ctx, db.CreateCheckpointParams{
+		ID:        uuid.New().String(),
+		SessionID: sessionID,
+		MessageID: messageID,
+		Label:     label,
+		Auto:      auto,
+		Files:     string(filesJSON),
+	})
+	if err != nil {
+		return Checkpoint{}, err
+	}
+	checkpoint, err := fromDBItem(dbCheckpoint)
+	if err != nil {
+		return Checkpoint{}, err
+	}
+	s.Publish(pubsub.CreatedEvent, checkpoint)
+	return checkpoint, nil
+}
+
+func (s *service) List(ctx context.Context, sessionID string) ([]Checkpoint, error) {
+	dbCheckpoints, err := s.q.ListCheckpoints(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	checkpoints := make([]Checkpoint, len(dbCheckpoints))
+	for i, dbCheckpoint := range dbCheckpoints {
+		if checkpoints[i], err = fromDBItem(dbCheckpoint); err != nil {
+			return nil, err
+		}
+	}
+	return checkpoints, nil
+}
+
+func (s *service) Rewind(ctx context.Context, id string, revertFiles bool) (Rewind, error) {
+	dbCheckpoint, err := s.q.GetCheckpoint(ctx, id)
+	if err != nil {
+		return Rewind{}, fmt.Errorf("failed to get checkpoint: %w", err)
+	}
+	checkpoint, err := fromDBItem(dbCheckpoint)
+	if err != nil {
+		return Rewind{}, err
+	}
+
+	var result Rewind
+	if revertFiles {
+		if result.Restored, result.Removed, err = s.revertFiles(ctx, checkpoint); err != nil {
+			return result, err
+		}
+	}
+
+	msgs, err := s.messages.List(ctx, checkpoint.SessionID)
+	if err != nil {
+		return result, err
+	}
+	keep := 0
+	if checkpoint.MessageID != "" {
+		keep = slices.IndexFunc(msgs, func(msg message.Message) bool { return msg.ID == checkpoint.MessageID }) + 1
+		if keep == 0 {
+			return result, fmt.Errorf("the last message of the checkpoint was deleted")
+		}
+	}
+	discarded := msgs[keep:]
+	for _, msg := range discarded {
+		if err := s.messages.Delete(ctx, msg.ID); err != nil {
+			return result, fmt.Errorf("failed to delete message: %w", err)
+		}
+		result.Messages++
+	}
+
+	// A summary of messages that are gone no longer describes the session
+	sess, err := s.sessions.Get(ctx, checkpoint.SessionID)
+	if err != nil {
+		return result, err
+	}
+	if sess.SummaryMessageID != "" && slices.ContainsFunc(discarded, func(msg message.Message) bool { return msg.ID == sess.SummaryMessageID }) {
+		sess.Summary, sess.SummaryMessageID = "", ""
+		if _, err := s.sessions.Save(ctx, sess); err != nil {
+			return result, err
+		}
+	}
+
+	if err := s.q.DeleteCheckpointsAfter(ctx, db.DeleteCheckpointsAfterParams{SessionID: checkpoint.SessionID, ID: checkpoint.ID}); err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+// revertFiles writes the files the session changed after the checkpoint back
+// as they were then, and removes those it created. The reverted contents are
+// added to the file history.
+func (s *service) revertFiles(ctx context.Context, checkpoint Checkpoint) (restored, removed []string, err error) {
+	all, err := s.files.ListBySession(ctx, checkpoint.SessionID)
+	if err != nil {
+		return nil, nil, err
+	}
+	latest := latestVersions(all)
+	paths := slices.Sorted(maps.Keys(latest))
+	for _, path := range paths {
+		file := latest[path]
+		id, ok := checkpoint.Files[file.Path]
+		if ok && id == file.ID {
+			continue
+		}
+		var content string
+		created := false
+		if ok {
+			version, err := s.files.Get(ctx, id)
+			if err != nil {
+				return restored, removed, fmt.Errorf("failed to get the version of %s: %w", file.Path, err)
+			}
+			content = version.Content
+		} else {
+			// The session changed the file only after the checkpoint, its
+			// initial version is the content before that
+			i := slices.IndexFunc(all, func(f history.File) bool {
+				return f.Path == file.Path && f.Version == history.InitialVersion
+			})
+			if i < 0 {
+				continue
+			}
+			content, created = all[i].Content, all[i].IsNew
+		}
+
+		if created {
+			// The session created the file
+			if err := os.Remove(file.Path); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return restored, removed, fmt.Errorf("failed to remove %s: %w", file.Path, err)
+			}
+			removed = append(removed, file.Path)
+		} else {
+			mode := os.FileMode(0o644)
+			if info, err := os.Stat(file.Path); err == nil {
+				mode = info.Mode().Perm()
+			}
+			if err := os.WriteFile(file.Path, []byte(content), mode); err != nil {
+				return restored, removed, fmt.Errorf("failed to restore %s: %w", file.Path, err)
+			}
+			restored = append(restored, file.Path)
+		}
+		if _, err := s.files.CreateVersion(ctx, checkpoint.SessionID, file.Path, content); err != nil {
+			return restored, removed, fmt.Errorf("failed to record the restored %s: %w", file.Path, err)
+		}
+	}
+	return restored, removed, nil
+}
+
+// latestVersions returns the latest version of each file in the file history,
+// by path. The versions of a file are often created within the same second,
+// the version number orders them.
+func latestVersions(files []history.File) map[string]history.File {
+	latest := make(map[string]history.File)
+	for _, file := range files {
+		current, ok := latest[file.Path]
+		if !ok || file.CreatedAt > current.CreatedAt ||
+			file.CreatedAt == current.CreatedAt && versionNumber(file.Version) > versionNumber(current.Version) {
+			latest[file.Path] = file
+		}
+	}
+	return latest
+}
+
+// versionNumber is the number of a version of the file history, 0 for the
+// initial version.
+func versionNumber(version string) int {
+	n, err := strconv.Atoi(strings.TrimPrefix(version, "v"))
+	if err != nil {
+		return 0
+	}
+	return n
+}
+
+func fromDBItem(item db.Checkpoint) (Checkpoint, error) {
+	checkpoint := Checkpoint{
+		ID:        item.ID,
+		SessionID: item.SessionID,
+		MessageID: item.MessageID,
+		Label:     item.Label,
+		Auto:      item.Auto,
+		CreatedAt: item.CreatedAt,
+	}
+	if err := json.Unmarshal([]byte(item.Files), &checkpoint.Files); err != nil {
+		return Checkpoint{}, fmt.Errorf("failed to read the files of checkpoint %s: %w", item.ID, err)
+	}
+	return checkpoint, nil
+}
@@ -0,0 +1,114 @@
+package checkpoint
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+
+	_ "github.com/ncruces/go-sqlite3/driver"
+	_ "github.com/ncruces/go-sqlite3/embed"
+	"github.com/opencode-ai/opencode/internal/db"
+	"github.com/opencode-ai/opencode/internal/history"
+	"github.com/opencode-ai/opencode/internal/message"
+	"github.com/opencode-ai/opencode/internal/session"
+	"github.com/pressly/goose/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRewind(t *testing.T) {
+	conn, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+	defer conn.Close()
+	conn.SetMaxOpenConns(1)
+	goose.SetBaseFS(db.FS)
+	require.NoError(t, goose.SetDialect("sqlite3"))
+	require.NoError(t, goose.Up(conn, "migrations"))
+	q := db.New(conn)
+	ctx := context.Background()
+
+	sessions := session.NewService(q)
+	messages := message.NewService(q)
+	files := history.NewService(q, conn)
+	checkpoints := NewService(q, sessions, messages, files)
+
+	sess, err := sessions.Create(ctx, "rewind")
+	require.NoError(t, err)
+	send := func(text string) {
+		_, err := messages.Create(ctx, sess.ID, message.CreateMessageParams{
+			Role:  message.User,
+			Parts: []message.ContentPart{message.TextContent{Text: text}},
+		})
+		require.NoError(t, err)
+	}
+	edit := func(path, before, after string) {
+		if _, err := files.GetByPathAndSession(ctx, path, sess.ID); err != nil {
+			if _, statErr := os.Stat(path); statErr != nil {
+				_, err = files.CreateNew(ctx, sess.ID, path)
+			} else {
+				_, err = files.Create(ctx, sess.ID, path, before)
+			}
+			require.NoError(t, err)
+		}
+		require.NoError(t, os.WriteFile(path, []byte(after), 0o644))
+		_, err := files.CreateVersion(ctx, sess.ID, path, after)
+		require.NoError(t, err)
+	}
+
+	dir := t.TempDir()
+	edited := filepath.Join(dir, "edited.go")
+	created := filepath.Join(dir, "created.go")
+	untouched := filepath.Join(dir, "untouched.go")
+	empty := filepath.Join(dir, "empty.go")
+	require.NoError(t, os.WriteFile(edited, []byte("one"), 0o644))
+	require.NoError(t, os.WriteFile(untouched, []byte("before"), 0o644))
+	require.NoError(t, os.WriteFile(empty, nil, 0o644))
+
+	send("change edited.go")
+	edit(edited, "one", "two")
+	first, err := checkpoints.Create(ctx, sess.ID, "first", true)
+	require.NoError(t, err)
+
+	send("change more")
+	edit(edited, "two", "three")
+	edit(created, "", "new")
+	edit(untouched, "before", "after")
+	edit(empty, "", "filled")
+	_, err = checkpoints.Create(ctx, sess.ID, "second", false)
+	require.NoError(t, err)
+	send("and more")
+
+	list, err := checkpoints.List(ctx, sess.ID)
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	assert.Equal(t, "second", list[0].Label)
+	assert.Equal(t, first.ID, list[1].ID)
+
+	result, err := checkpoints.Rewind(ctx, first.ID, true)
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Messages)
+	assert.ElementsMatch(t, []string{edited, untouched, empty}, result.Restored)
+	assert.Equal(t, []string{created}, result.Removed)
+
+	content, err := os.ReadFile(edited)
+	require.NoError(t, err)
+	assert.Equal(t, "two", string(content))
+	content, err = os.ReadFile(untouched)
+	require.NoError(t, err)
+	assert.Equal(t, "before", string(content))
+	assert.NoFileExists(t, created)
+	// The file existed before the session, empty
+	content, err = os.ReadFile(empty)
+	require.NoError(t, err)
+	assert.Empty(t, content)
+
+	msgs, err := messages.List(ctx, sess.ID)
+	require.NoError(t, err)
+	assert.Len(t, msgs, 1)
+	list, err = checkpoints.List(ctx, sess.ID)
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	assert.Equal(t, first.ID, list[0].ID)
+}
@@ -0,0 +1,116 @@
+// Package clipboard reads and writes the system clipboard with the clipboard
+// commands of the platform, and writes it with OSC 52 escape sequences when
+// there are none or the session is remote.
+package clipboard
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/muesli/termenv"
+)
+
+// backend is a pair of commands copying stdin to the clipboard and printing
+// the clipboard.
+type backend struct {
+	name  string
+	copy  []string
+	paste []string
+}
+
+// OSC52 is the name of the terminal escape sequence backend.
+const OSC52 = "osc52"
+
+// ErrUnavailable is returned when no clipboard command is installed.
+var ErrUnavailable = errors.New("no clipboard command found, install pbcopy, wl-clipboard, xclip or xsel")
+
+var (
+	lookPath = exec.LookPath
+	getenv   = os.Getenv
+	goos     = runtime.GOOS
+)
+
+// backends returns the clipboard commands that are installed, in the order
+// they are tried.
+func backends() []backend {
+	var candidates []backend
+	switch goos {
+	case "darwin":
+		candidates = append(candidates, backend{"pbcopy", []string{"pbcopy"}, []string{"pbpaste"}})
+	case "windows":
+		candidates = append(candidates, backend{"powershell", []string{"clip.exe"}, []string{"powershell.exe", "-NoProfile", "-Command", "Get-Clipboard -Raw"}})
+	default:
+		if getenv("WAYLAND_DISPLAY") != "" {
+			candidates = append(candidates, backend{"wl-copy", []string{"wl-copy"}, []string{"wl-paste", "--no-newline"}})
+		}
+		if getenv("DISPLAY") != "" {
+			candidates = append(candidates,
+				backend{"xclip", []string{"xclip", "-selection", "clipboard", "-in"}, []string{"xclip", "-selection", "clipboard", "-out"}},
+				backend{"xsel", []string{"xsel", "--clipboard", "--input"}, []string{"xsel", "--clipboard", "--output"}},
+			)
+		}
+		// WSL can use the clipboard of Windows
+		candidates = append(candidates, backend{"powershell", []string{"clip.exe"}, []string{"powershell.exe", "-NoProfile", "-Command", "Get-Clipboard -Raw"}})
+	}
+
+	var installed []backend
+	for _, b := range candidates {
+		if _, err := lookPath(b.copy[0]); err == nil {
+			installed = append(installed, b)
+		}
+	}
+	return installed
+}
+
+// remote reports whether OpenCode runs over SSH, where the clipboard commands
+// would use the clipboard of the remote machine.
+func remote() bool {
+	return getenv("SSH_TTY") != "" || getenv("SSH_CONNECTION") != ""
+}
+
+// Write copies the text to the clipboard and returns the name of the backend
+// that did. Over SSH and without clipboard commands the text is sent to the
+// terminal with OSC 52, which most terminals copy to the local clipboard.
+func Write(text string) (string, error) {
+	if !remote() {
+		for _, b := range backends() {
+			cmd := exec.Command(b.copy[0], b.copy[1:]...)
+			cmd.Stdin = strings.NewReader(text)
+			if err := cmd.Run(); err == nil {
+				return b.name, nil
+			}
+		}
+	}
+	termenv.NewOutput(os.Stdout).Copy(text)
+	return OSC52, nil
+}
+
+// Read returns the text of the clipboard. Terminals don't allow reading the
+// clipboard with OSC 52, so it needs a clipboard command.
+func Read() (string, error) {
+	installed := backends()
+	if len(installed) == 0 {
+		return "", ErrUnavailable
+	}
+	var errs []error
+	for _, b := range installed {
+		var stdout, stderr bytes.Buffer
+		cmd := exec.Command(b.paste[0], b.paste[1:]...)
+		cmd.Stdout, cmd.Stderr = &stdout, &stderr
+		if err := cmd.Run(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w: %s", b.name, err, strings.TrimSpace(stderr.String())))
+			continue
+		}
+		text := stdout.String()
+		if b.name == "powershell" {
+			text = strings.ReplaceAll(strings.TrimSuffix(text, "\r\n"), "\r\n", "\n")
+		}
+		return text, nil
+	}
+	return "", fmt.Errorf("failed to read the clipboard: %w", errors.Join(errs...))
+}
@@ -0,0 +1,50 @@
+package clipboard
+
+import (
+	"errors"
+	"os/exec"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackends(t *testing.T) {
+	defer func(l func(string) (string, error), g func(string) string, o string) {
+		lookPath, getenv, goos = l, g, o
+	}(lookPath, getenv, goos)
+
+	installed := []string{"pbcopy", "wl-copy", "xsel"}
+	lookPath = func(file string) (string, error) {
+		if slices.Contains(installed, file) {
+			return "/usr/bin/" + file, nil
+		}
+		return "", exec.ErrNotFound
+	}
+	env := map[string]string{}
+	getenv = func(key string) string { return env[key] }
+	names := func() []string {
+		var names []string
+		for _, b := range backends() {
+			names = append(names, b.name)
+		}
+		return names
+	}
+
+	goos = "darwin"
+	assert.Equal(t, []string{"pbcopy"}, names())
+
+	goos = "linux"
+	assert.Empty(t, names())
+	env["WAYLAND_DISPLAY"] = "wayland-0"
+	env["DISPLAY"] = ":0"
+	assert.Equal(t, []string{"wl-copy", "xsel"}, names())
+
+	delete(env, "WAYLAND_DISPLAY")
+	installed = append(installed, "clip.exe")
+	assert.Equal(t, []string{"xsel", "powershell"}, names())
+
+	installed = nil
+	_, err := Read()
+	assert.True(t, errors.Is(err, ErrUnavailable))
+}
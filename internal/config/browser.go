@@ -0,0 +1,18 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// validateBrowser checks that the allowed hosts of the browser tool are host
+// patterns.
+func validateBrowser(b Browser) error {
+	for _, host := range b.AllowedHosts {
+		name := strings.TrimPrefix(host, "*.")
+		if name == "" || strings.ContainsAny(name, "/*?#@ ") {
+			return fmt.Errorf("invalid browser configuration: allowed host %q must be a host with an optional port, like localhost:3000 or *.example.com", host)
+		}
+	}
+	return nil
+}
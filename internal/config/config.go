@@ -2,11 +2,15 @@
 package config
 
 import (
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
 	"log/slog"
 	"os"
+	"regexp"
 	"strings"
 
+	"github.com/opencode-ai/opencode/internal/llm/discovery"
 	"github.com/opencode-ai/opencode/internal/llm/models"
 	"github.com/opencode-ai/opencode/internal/logging"
 	"github.com/spf13/viper"
@@ -31,25 +35,456 @@ type MCPServer struct {
 	Headers map[string]string `json:"headers"`
 }
 
+// WebSearchEngine is the search engine used by the websearch tool.
+type WebSearchEngine string
+
+// Supported web search engines
+const (
+	WebSearchDuckDuckGo WebSearchEngine = "duckduckgo"
+	WebSearchSearxNG    WebSearchEngine = "searxng"
+	WebSearchBrave      WebSearchEngine = "brave"
+	WebSearchGoogle     WebSearchEngine = "google"
+)
+
+// WebSearch defines the configuration of the websearch tool.
+type WebSearch struct {
+	Engine WebSearchEngine `json:"engine,omitempty"`
+	// APIKey is the key of the Brave Search API or the Google Custom Search
+	// JSON API
+	APIKey string `json:"apiKey,omitempty"`
+	// BaseURL is the URL of the SearxNG instance
+	BaseURL string `json:"baseURL,omitempty"`
+	// SearchEngineID is the ID (cx) of the Google programmable search engine
+	SearchEngineID string `json:"searchEngineID,omitempty"`
+	Disabled       bool   `json:"disabled,omitempty"`
+}
+
+// Container defines the container the container tool runs commands in,
+// either a new container of an image or a running docker compose service.
+type Container struct {
+	// Image is the image of the container started for each command, with the
+	// working directory mounted
+	Image string `json:"image,omitempty"`
+	// Service is the docker compose service the commands are executed in
+	Service string `json:"service,omitempty"`
+	// ComposeFile is the compose file of the service, docker compose finds
+	// it in the working directory when empty
+	ComposeFile string `json:"composeFile,omitempty"`
+	// Runtime is the container CLI, docker or podman
+	Runtime string `json:"runtime,omitempty"`
+	// Workdir is the directory in the container the commands run in
+	Workdir string   `json:"workdir,omitempty"`
+	Env     []string `json:"env,omitempty"`
+}
+
+// SandboxBackend is the program isolating the shell of the bash tool.
+type SandboxBackend string
+
+// Supported sandbox backends
+const (
+	SandboxBubblewrap SandboxBackend = "bubblewrap"
+	SandboxFirejail   SandboxBackend = "firejail"
+	SandboxDocker     SandboxBackend = "docker"
+)
+
+// Sandbox defines the isolation of the shell of the bash tool from the host.
+// The sandboxed shell can read the host filesystem but only write to the
+// working directory and WritablePaths.
+type Sandbox struct {
+	Backend SandboxBackend `json:"backend,omitempty"`
+	// Image is the image of the docker backend, with the working directory
+	// mounted at the same path
+	Image string `json:"image,omitempty"`
+	// Network allows the commands to use the network
+	Network bool `json:"network,omitempty"`
+	// WritablePaths are absolute paths writable besides the working directory,
+	// e.g. caches of package managers
+	WritablePaths []string `json:"writablePaths,omitempty"`
+	// AutoApprove runs the commands of the bash tool without asking for
+	// permission
+	AutoApprove bool `json:"autoApprove,omitempty"`
+}
+
+// CustomTool defines a tool of the agent running an external command. The
+// arguments of a call are passed to the command as a JSON object on stdin.
+type CustomTool struct {
+	Description string `json:"description"`
+	// Parameters is the JSON schema of the arguments, an object schema with
+	// properties and required
+	Parameters map[string]any `json:"parameters,omitempty"`
+	// Command and Args are text/template templates of the command line, with
+	// the arguments of the call as data, e.g. "--env={{.env}}". Args that are
+	// empty after rendering are left out.
+	Command string   `json:"command"`
+	Args    []string `json:"args,omitempty"`
+	Env     []string `json:"env,omitempty"`
+	// AutoApprove runs the tool without asking for permission
+	AutoApprove bool `json:"autoApprove,omitempty"`
+}
+
+// HTTP defines the hosts the http tool can send requests to.
+type HTTP struct {
+	// AllowedHosts are the hosts requests can be sent to, with an optional
+	// port, e.g. localhost:3000, and *.example.com for all subdomains
+	AllowedHosts []string `json:"allowedHosts,omitempty"`
+	// Headers are added to the requests by host pattern of AllowedHosts, e.g.
+	// the authorization of an internal API. Their values can use environment
+	// variables as $NAME and are hidden from the model.
+	Headers map[string]map[string]string `json:"headers,omitempty"`
+	// AutoApprove sends GET, HEAD and OPTIONS requests without asking for
+	// permission
+	AutoApprove bool `json:"autoApprove,omitempty"`
+}
+
+// Format defines the formatting of the files the edit, multiedit and write
+// tools change.
+type Format struct {
+	// Enabled runs the formatter of a file after the tools wrote it
+	Enabled bool `json:"enabled,omitempty"`
+	// Formatters are the commands formatting files in place by extension,
+	// e.g. ".ts": ["npx", "prettier", "--write"], the path of the file is
+	// added as the last argument. They replace the detected formatter, an
+	// empty command doesn't format files of the extension.
+	Formatters map[string][]string `json:"formatters,omitempty"`
+}
+
+// Browser defines the headless Chromium the browser tool drives.
+type Browser struct {
+	// Enabled adds the browser tool
+	Enabled bool `json:"enabled,omitempty"`
+	// Path is the Chromium or Chrome executable, found in PATH when empty
+	Path string `json:"path,omitempty"`
+	// Args are added to the arguments Chromium is started with
+	Args []string `json:"args,omitempty"`
+	// AllowedHosts are opened without asking for permission, with an
+	// optional port, e.g. localhost:3000, and *.example.com for all
+	// subdomains
+	AllowedHosts []string `json:"allowedHosts,omitempty"`
+}
+
+// Compaction defines how a conversation that fills most of the context window
+// of the model is summarized.
+type Compaction struct {
+	// Disabled sends the whole conversation until the model rejects it
+	Disabled bool `json:"disabled,omitempty"`
+	// Threshold is the share of the context window the conversation can fill
+	// before its older messages are summarized, 0.85 when 0
+	Threshold float64 `json:"threshold,omitempty"`
+}
+
+// Budget limits the usage of a session. The agent warns when a session
+// reaches WarnAt of a limit, and pauses at the limit until the user lets it
+// continue.
+type Budget struct {
+	// MaxTokens is the number of prompt and completion tokens of a session
+	MaxTokens int64 `json:"maxTokens,omitempty"`
+	// MaxCost is the cost of a session in dollars
+	MaxCost float64 `json:"maxCost,omitempty"`
+	// MaxToolCalls is the number of tool calls of a turn
+	MaxToolCalls int `json:"maxToolCalls,omitempty"`
+	// WarnAt is the share of a limit that shows a warning, 0.8 when 0
+	WarnAt float64 `json:"warnAt,omitempty"`
+}
+
+// Instructions defines how the instruction files of the project and of the
+// user are added to the system prompt.
+type Instructions struct {
+	// MaxFileSize is the size in bytes a file is cut to, 32 KB when 0
+	MaxFileSize int `json:"maxFileSize,omitempty"`
+	// MaxSize is the size in bytes of all the files, the files after it are
+	// skipped, 100 KB when 0
+	MaxSize int `json:"maxSize,omitempty"`
+	// NoSubdirectories only loads the files of the root of the project, not
+	// the AGENTS.md files and the like of its subdirectories
+	NoSubdirectories bool `json:"noSubdirectories,omitempty"`
+}
+
+// ToolProfile restricts the tools offered to the model in a session. The
+// names can have * wildcards, like github_* for the tools of an MCP server.
+type ToolProfile struct {
+	// Allow are the tools offered, all of them when empty
+	Allow []string `json:"allow,omitempty"`
+	// Deny are the tools not offered, even when allowed
+	Deny []string `json:"deny,omitempty"`
+}
+
+// DatabaseDriver is the kind of a database of the sql tool.
+type DatabaseDriver string
+
+// Supported database drivers
+const (
+	DatabaseSQLite   DatabaseDriver = "sqlite"
+	DatabasePostgres DatabaseDriver = "postgres"
+	DatabaseMySQL    DatabaseDriver = "mysql"
+)
+
+// Database defines a database the sql tool can query.
+type Database struct {
+	Driver DatabaseDriver `json:"driver"`
+	// DSN is the path of the SQLite file, or the URL of the Postgres or
+	// MySQL database
+	DSN string `json:"dsn"`
+	// AllowWrite lets the sql tool run statements that change the database,
+	// after asking for permission
+	AllowWrite bool `json:"allowWrite,omitempty"`
+}
+
 type AgentName string
 
 const (
 	AgentCoder AgentName = "coder"
 	AgentTask  AgentName = "task"
 	AgentTitle AgentName = "title"
+	// AgentSummarizer summarizes the older messages of long conversations,
+	// the coder summarizes them when it isn't configured
+	AgentSummarizer AgentName = "summarizer"
 )
 
+// ModelTiers defines the default models of the agents by the size of their
+// work. The model of an agent in agents overrides its tier.
+type ModelTiers struct {
+	// Small is the model of the titles and the summaries of the
+	// conversations, e.g. a local Ollama model
+	Small models.ModelID `json:"small,omitempty"`
+	// Large is the model of the coder and task agents
+	Large models.ModelID `json:"large,omitempty"`
+}
+
+// tierAgents are the agents of each model tier
+var tierAgents = map[string][]AgentName{
+	"models.small": {AgentTitle, AgentSummarizer},
+	"models.large": {AgentCoder, AgentTask},
+}
+
 // Agent defines configuration for different LLM models and their token limits.
 type Agent struct {
 	Model           models.ModelID `json:"model"`
 	MaxTokens       int64          `json:"maxTokens"`
 	ReasoningEffort string         `json:"reasoningEffort"` // For openai models low,medium,heigh
+	// ThinkingBudget is the token budget of Claude extended thinking. When it
+	// is 0 Claude only thinks when asked to.
+	ThinkingBudget int64 `json:"thinkingBudget,omitempty"`
+
+	// Fallback lists the models tried in order when the provider of Model is
+	// rate limited, failing or not responding
+	Fallback []models.ModelID `json:"fallback,omitempty"`
+
+	// Timeout overrides the request timeout of the provider for this agent
+	Timeout string `json:"timeout,omitempty"`
+
+	// ToolConcurrency is the number of read-only tool calls of a response run
+	// at the same time, 1 runs them one after another
+	ToolConcurrency int `json:"toolConcurrency,omitempty"`
+
+	// Sampling parameters, currently honored by Ollama models
+	Temperature   *float64 `json:"temperature,omitempty"`
+	TopP          *float64 `json:"topP,omitempty"`
+	TopK          *int64   `json:"topK,omitempty"`
+	NumCtx        *int64   `json:"numCtx,omitempty"`
+	RepeatPenalty *float64 `json:"repeatPenalty,omitempty"`
+	Stop          []string `json:"stop,omitempty"`
+	Seed          *int64   `json:"seed,omitempty"`
+	// Format constrains the responses of Ollama models to JSON, it is "json"
+	// or a JSON schema. The schema is a string as the keys of the config
+	// aren't case-sensitive.
+	Format string `json:"format,omitempty"`
+	// Grammar is a GBNF grammar constraining the responses of llama.cpp models
+	Grammar string `json:"grammar,omitempty"`
+}
+
+// Sampling returns the sampling parameters set for the agent, they override
+// the defaults of its model.
+func (a Agent) Sampling() models.Sampling {
+	return models.Sampling{
+		Temperature:   a.Temperature,
+		TopP:          a.TopP,
+		TopK:          a.TopK,
+		NumCtx:        a.NumCtx,
+		RepeatPenalty: a.RepeatPenalty,
+	}
 }
 
 // Provider defines configuration for an LLM provider.
 type Provider struct {
 	APIKey   string `json:"apiKey"`
 	Disabled bool   `json:"disabled"`
+	BaseURL  string `json:"baseURL,omitempty"`
+
+	// BaseURLs are fallback servers tried in order when BaseURL is unreachable
+	BaseURLs []string `json:"baseURLs,omitempty"`
+
+	// Headers are added to every request, e.g. for a provider behind a proxy
+	Headers map[string]string `json:"headers,omitempty"`
+
+	// Timeout limits a request, for streams it is the maximum time between
+	// two chunks (e.g. 5m)
+	Timeout string `json:"timeout,omitempty"`
+
+	// Retry controls how transient errors such as rate limits are retried
+	Retry *RetryConfig `json:"retry,omitempty"`
+
+	// RateLimit is a client side budget, requests beyond it are queued
+	RateLimit *RateLimitConfig `json:"rateLimit,omitempty"`
+
+	// TLS customizes certificate handling for self-hosted HTTPS endpoints
+	TLS TLSConfig `json:"tls,omitempty"`
+
+	// Proxy is the URL of an HTTP, HTTPS or SOCKS5 proxy for the requests to
+	// the provider, it overrides the global proxy and HTTP_PROXY/HTTPS_PROXY
+	Proxy string `json:"proxy,omitempty"`
+
+	// DisableCache turns off prompt caching (Anthropic) and context caching (Gemini)
+	DisableCache bool `json:"disableCache,omitempty"`
+
+	// APIVersion is the Azure OpenAI API version, e.g. 2025-04-01-preview
+	APIVersion string `json:"apiVersion,omitempty"`
+
+	// Deployments names the Azure OpenAI deployments that are not named
+	// after their model
+	Deployments []Deployment `json:"deployments,omitempty"`
+
+	// Project and Location select the GCP project and region of Vertex AI
+	Project  string `json:"project,omitempty"`
+	Location string `json:"location,omitempty"`
+
+	// CredentialsFile is a service account key used instead of Application
+	// Default Credentials (Vertex AI)
+	CredentialsFile string `json:"credentialsFile,omitempty"`
+
+	// GPULayers is the number of layers the GGUF provider offloads to the
+	// GPU, 0 to run on the CPU only. All layers are offloaded when unset
+	GPULayers *int `json:"gpuLayers,omitempty"`
+
+	// Threads is the number of CPU threads of the GGUF provider
+	Threads int `json:"threads,omitempty"`
+
+	// KeepAlive is how long Ollama keeps the model loaded between requests
+	KeepAlive string `json:"keepAlive,omitempty"`
+
+	// CompletionModels are Ollama models without a chat template, they are
+	// prompted through the generate endpoint instead of chat
+	CompletionModels []string `json:"completionModels,omitempty"`
+
+	// Models registers additional models for this provider
+	Models []CustomModel `json:"models,omitempty"`
+}
+
+// CustomModel defines a model that is not known ahead of time, e.g. a local
+// Ollama model with a tuned Modelfile. It is available as <provider>.<name>.
+type CustomModel struct {
+	Name          string `json:"name"`
+	APIModel      string `json:"apiModel,omitempty"`
+	ContextWindow int64  `json:"contextWindow,omitempty"`
+	MaxTokens     int64  `json:"maxTokens,omitempty"`
+	CanReason     bool   `json:"canReason,omitempty"`
+	// NoTools marks a model without native tool calling, tools are then
+	// described in the prompt
+	NoTools bool `json:"noTools,omitempty"`
+	// Vision marks a model that accepts images
+	Vision bool `json:"vision,omitempty"`
+	// Completion marks a model without a chat template, see Provider.CompletionModels
+	Completion bool `json:"completion,omitempty"`
+	// Raw sends the conversation as a plain transcript without applying the
+	// model's chat template, like Completion
+	Raw bool `json:"raw,omitempty"`
+	// Template is a chat template in the Ollama Modelfile TEMPLATE syntax used
+	// to render the raw prompt instead of the model's built-in template
+	Template string `json:"template,omitempty"`
+
+	// Default sampling parameters of the model, the agents can override them
+	Temperature   *float64 `json:"temperature,omitempty"`
+	TopP          *float64 `json:"topP,omitempty"`
+	TopK          *int64   `json:"topK,omitempty"`
+	NumCtx        *int64   `json:"numCtx,omitempty"`
+	RepeatPenalty *float64 `json:"repeatPenalty,omitempty"`
+}
+
+// sampling returns the default sampling parameters declared for a custom
+// model.
+func (c CustomModel) sampling() models.Sampling {
+	return models.Sampling{
+		Temperature:   c.Temperature,
+		TopP:          c.TopP,
+		TopK:          c.TopK,
+		NumCtx:        c.NumCtx,
+		RepeatPenalty: c.RepeatPenalty,
+	}
+}
+
+// withCapabilities applies the tool and image support declared for a custom
+// model.
+func (c CustomModel) withCapabilities(model models.Model) models.Model {
+	if !c.NoTools && !c.Vision {
+		return model
+	}
+	capabilities := models.ModelCapabilities(model)
+	capabilities.SupportsTools = !c.NoTools
+	capabilities.SupportsVision = c.Vision
+	return model.WithCapabilities(capabilities)
+}
+
+// Deployment maps a model to the Azure OpenAI deployment serving it.
+type Deployment struct {
+	Model models.ModelID `json:"model"`
+	Name  string         `json:"name"`
+}
+
+// RetryConfig defines how requests to a provider are retried after transient
+// errors. Unset fields keep their defaults.
+type RetryConfig struct {
+	MaxAttempts    *int   `json:"maxAttempts,omitempty"`
+	InitialBackoff string `json:"initialBackoff,omitempty"`
+	MaxBackoff     string `json:"maxBackoff,omitempty"`
+	StatusCodes    []int  `json:"statusCodes,omitempty"`
+}
+
+// RateLimitConfig defines the requests and tokens a provider may be sent per
+// minute, e.g. the limits of the tier of the API key.
+type RateLimitConfig struct {
+	RequestsPerMinute int   `json:"requestsPerMinute,omitempty"`
+	TokensPerMinute   int64 `json:"tokensPerMinute,omitempty"`
+}
+
+// TLSConfig defines custom TLS settings for the HTTP client of a provider.
+type TLSConfig struct {
+	CAFile             string `json:"caFile,omitempty"`
+	CertFile           string `json:"certFile,omitempty"`
+	KeyFile            string `json:"keyFile,omitempty"`
+	InsecureSkipVerify bool   `json:"insecureSkipVerify,omitempty"`
+}
+
+// ClientConfig builds the tls.Config described by the settings.
+// It returns nil when no custom TLS settings are configured.
+func (t TLSConfig) ClientConfig() (*tls.Config, error) {
+	if t == (TLSConfig{}) {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: t.InsecureSkipVerify,
+	}
+	if t.CAFile != "" {
+		caCert, err := os.ReadFile(t.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA file: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(caCert) {
+			return nil, fmt.Errorf("no certificates found in CA file %s", t.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if t.CertFile != "" || t.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(t.CertFile, t.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return tlsConfig, nil
 }
 
 // Data defines storage configuration.
@@ -70,12 +505,46 @@ type Config struct {
 	Data         Data                              `json:"data"`
 	WorkingDir   string                            `json:"wd,omitempty"`
 	MCPServers   map[string]MCPServer              `json:"mcpServers,omitempty"`
+	WebSearch    WebSearch                         `json:"webSearch,omitempty"`
+	Container    Container                         `json:"container,omitempty"`
+	Sandbox      Sandbox                           `json:"sandbox,omitempty"`
+	HTTP         HTTP                              `json:"http,omitempty"`
+	Format       Format                            `json:"format,omitempty"`
+	Browser      Browser                           `json:"browser,omitempty"`
+	Databases    map[string]Database               `json:"databases,omitempty"`
+	Tools        map[string]CustomTool             `json:"tools,omitempty"`
+	ToolTimeouts map[string]string                 `json:"toolTimeouts,omitempty"`
 	Providers    map[models.ModelProvider]Provider `json:"providers,omitempty"`
 	LSP          map[string]LSPConfig              `json:"lsp,omitempty"`
 	Agents       map[AgentName]Agent               `json:"agents"`
+	Models       ModelTiers                        `json:"models,omitempty"`
 	Debug        bool                              `json:"debug,omitempty"`
 	DebugLSP     bool                              `json:"debugLSP,omitempty"`
+	DebugTraces  bool                              `json:"debugTraces,omitempty"`
 	ContextPaths []string                          `json:"contextPaths,omitempty"`
+	// Redact are regular expressions of secrets replaced in the messages
+	// before they are sent to a provider
+	Redact []string `json:"redact,omitempty"`
+	// Proxy is the URL of the proxy used for all providers, instead of the
+	// HTTP_PROXY and HTTPS_PROXY environment variables
+	Proxy string `json:"proxy,omitempty"`
+	// CacheResponses answers prompts that were already answered from an
+	// on-disk cache instead of sending them again
+	CacheResponses bool `json:"cacheResponses,omitempty"`
+	// ToolProfiles restrict the tools offered in a session, by name
+	ToolProfiles map[string]ToolProfile `json:"toolProfiles,omitempty"`
+	// ToolProfile is the tool profile of the sessions without their own,
+	// all the tools when empty
+	ToolProfile string `json:"toolProfile,omitempty"`
+	// Compaction summarizes the older messages of long conversations
+	Compaction Compaction `json:"compaction,omitempty"`
+	// Instructions limits the instruction files added to the system prompt
+	Instructions Instructions `json:"instructions,omitempty"`
+	// Examples are exchanges sent before the conversation to the models they
+	// are for, to show them how to call the tools
+	Examples []Example `json:"examples,omitempty"`
+	// Budget limits the tokens, the cost and the tool calls of the sessions
+	Budget Budget `json:"budget,omitempty"`
 }
 
 // Application constants
@@ -86,6 +555,9 @@ const (
 )
 
 var defaultContextPaths = []string{
+	"AGENTS.md",
+	"AGENTS.local.md",
+	".opencode.md",
 	".github/copilot-instructions.md",
 	".cursorrules",
 	".cursor/rules/",
@@ -129,6 +601,18 @@ func Load(workingDir string, debug bool) (*Config, error) {
 	// Load and merge local config
 	mergeLocalConfig(workingDir)
 
+	// The model tiers are defaults of the agents, before their models are discovered
+	applyModelTiers()
+
+	// Discover local models before agents are validated against them
+	discoverOllamaModels()
+	discoverLMStudioModels()
+	discoverLlamaCppModels()
+	registerOpenAICompatibleModels()
+	registerGGUFModels()
+	discoverOpenRouterModels()
+	discoverTogetherModels()
+
 	// Apply configuration to the struct
 	if err := viper.Unmarshal(cfg); err != nil {
 		return cfg, fmt.Errorf("failed to unmarshal config: %w", err)
@@ -201,6 +685,7 @@ func configureViper() {
 func setDefaults(debug bool) {
 	viper.SetDefault("data.directory", defaultDataDirectory)
 	viper.SetDefault("contextPaths", defaultContextPaths)
+	viper.SetDefault("webSearch.engine", WebSearchDuckDuckGo)
 
 	if debug {
 		viper.SetDefault("debug", true)
@@ -226,13 +711,39 @@ func setProviderDefaults() {
 	if apiKey := os.Getenv("GROQ_API_KEY"); apiKey != "" {
 		viper.SetDefault("providers.groq.apiKey", apiKey)
 	}
+	if apiKey := os.Getenv("OPENROUTER_API_KEY"); apiKey != "" {
+		viper.SetDefault("providers.openrouter.apiKey", apiKey)
+	}
+	if apiKey := os.Getenv("DEEPSEEK_API_KEY"); apiKey != "" {
+		viper.SetDefault("providers.deepseek.apiKey", apiKey)
+	}
+	if apiKey := os.Getenv("XAI_API_KEY"); apiKey != "" {
+		viper.SetDefault("providers.xai.apiKey", apiKey)
+	}
+	if apiKey := os.Getenv("MISTRAL_API_KEY"); apiKey != "" {
+		viper.SetDefault("providers.mistral.apiKey", apiKey)
+	}
+	if apiKey := os.Getenv("TOGETHER_API_KEY"); apiKey != "" {
+		viper.SetDefault("providers.together.apiKey", apiKey)
+	}
+	if token := copilotToken(); token != "" {
+		viper.SetDefault("providers.copilot.apiKey", token)
+	}
+	if host := os.Getenv("OLLAMA_HOST"); host != "" {
+		viper.SetDefault("providers.ollama.baseURL", host)
+	}
 
 	// Use this order to set the default models
 	// 1. Anthropic
 	// 2. OpenAI
 	// 3. Google Gemini
 	// 4. Groq
-	// 5. AWS Bedrock
+	// 5. DeepSeek
+	// 6. xAI
+	// 7. Mistral
+	// 8. Together AI
+	// 9. GitHub Copilot
+	// 10. AWS Bedrock
 	// Anthropic configuration
 	if apiKey := os.Getenv("ANTHROPIC_API_KEY"); apiKey != "" {
 		viper.SetDefault("agents.coder.model", models.Claude37Sonnet)
@@ -265,6 +776,46 @@ func setProviderDefaults() {
 		return
 	}
 
+	// DeepSeek configuration
+	if apiKey := os.Getenv("DEEPSEEK_API_KEY"); apiKey != "" {
+		viper.SetDefault("agents.coder.model", models.DeepSeekChat)
+		viper.SetDefault("agents.task.model", models.DeepSeekChat)
+		viper.SetDefault("agents.title.model", models.DeepSeekChat)
+		return
+	}
+
+	// xAI configuration
+	if apiKey := os.Getenv("XAI_API_KEY"); apiKey != "" {
+		viper.SetDefault("agents.coder.model", models.XAIGrok3)
+		viper.SetDefault("agents.task.model", models.XAIGrok3Mini)
+		viper.SetDefault("agents.title.model", models.XAIGrok3Mini)
+		return
+	}
+
+	// Mistral configuration
+	if apiKey := os.Getenv("MISTRAL_API_KEY"); apiKey != "" {
+		viper.SetDefault("agents.coder.model", models.MistralLarge)
+		viper.SetDefault("agents.task.model", models.MistralSmall)
+		viper.SetDefault("agents.title.model", models.MistralSmall)
+		return
+	}
+
+	// Together AI configuration
+	if apiKey := os.Getenv("TOGETHER_API_KEY"); apiKey != "" {
+		viper.SetDefault("agents.coder.model", models.TogetherQwen25Coder32B)
+		viper.SetDefault("agents.task.model", models.TogetherLlama33_70B)
+		viper.SetDefault("agents.title.model", models.TogetherLlama33_70B)
+		return
+	}
+
+	// GitHub Copilot configuration
+	if copilotToken() != "" {
+		viper.SetDefault("agents.coder.model", models.CopilotGPT41)
+		viper.SetDefault("agents.task.model", models.CopilotGPT41)
+		viper.SetDefault("agents.title.model", models.CopilotGPT4o)
+		return
+	}
+
 	// AWS Bedrock configuration
 	if hasAWSCredentials() {
 		viper.SetDefault("agents.coder.model", models.BedrockClaude37Sonnet)
@@ -273,7 +824,7 @@ func setProviderDefaults() {
 		return
 	}
 
-	if os.Getenv("AZURE_OPENAI_ENDPOINT") != "" {
+	if os.Getenv("AZURE_OPENAI_ENDPOINT") != "" || viper.GetString("providers.azure.baseURL") != "" {
 		// api-key may be empty when using Entra ID credentials – that's okay
 		viper.SetDefault("providers.azure.apiKey", os.Getenv("AZURE_OPENAI_API_KEY"))
 		viper.SetDefault("agents.coder.model", models.AzureGPT41)
@@ -281,6 +832,14 @@ func setProviderDefaults() {
 		viper.SetDefault("agents.title.model", models.AzureGPT41Mini)
 		return
 	}
+
+	// Vertex AI authenticates with Application Default Credentials
+	if os.Getenv("GOOGLE_CLOUD_PROJECT") != "" || viper.GetString("providers.vertexai.project") != "" {
+		viper.SetDefault("agents.coder.model", models.VertexAIGemini25)
+		viper.SetDefault("agents.task.model", models.VertexAIGemini25Flash)
+		viper.SetDefault("agents.title.model", models.VertexAIGemini25Flash)
+		return
+	}
 }
 
 // hasAWSCredentials checks if AWS credentials are available in the environment.
@@ -336,6 +895,20 @@ func mergeLocalConfig(workingDir string) {
 	}
 }
 
+// applyModelTiers sets the models of the tiers as the default models of
+// their agents, the models configured for the agents take precedence.
+func applyModelTiers() {
+	for key, agents := range tierAgents {
+		model := viper.GetString(key)
+		if model == "" {
+			continue
+		}
+		for _, agent := range agents {
+			viper.SetDefault(fmt.Sprintf("agents.%s.model", agent), model)
+		}
+	}
+}
+
 // applyDefaultValues sets default values for configuration fields that need processing.
 func applyDefaultValues() {
 	// Set default MCP type if not specified
@@ -354,6 +927,72 @@ func Validate() error {
 		return fmt.Errorf("config not loaded")
 	}
 
+	for _, pattern := range cfg.Redact {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("invalid redact pattern %q: %w", pattern, err)
+		}
+	}
+
+	if _, err := discovery.ProxyFunc(cfg.Proxy); err != nil {
+		return err
+	}
+	if err := validateWebSearch(cfg.WebSearch); err != nil {
+		return err
+	}
+	if err := validateContainer(cfg.Container); err != nil {
+		return err
+	}
+	if err := validateSandbox(cfg.Sandbox); err != nil {
+		return err
+	}
+	if err := validateDatabases(cfg.Databases); err != nil {
+		return err
+	}
+	if err := validateFormat(cfg.Format); err != nil {
+		return err
+	}
+	if err := validateHTTP(cfg.HTTP); err != nil {
+		return err
+	}
+	if err := validateBrowser(cfg.Browser); err != nil {
+		return err
+	}
+	if err := validateToolTimeouts(cfg.ToolTimeouts); err != nil {
+		return err
+	}
+	if err := validateCustomTools(cfg.Tools); err != nil {
+		return err
+	}
+	if err := validateToolProfiles(cfg.ToolProfiles, cfg.ToolProfile); err != nil {
+		return err
+	}
+	if t := cfg.Compaction.Threshold; t < 0 || t >= 1 {
+		return fmt.Errorf("invalid compaction threshold %v: must be between 0 and 1", t)
+	}
+	if b := cfg.Budget; b.MaxTokens < 0 || b.MaxCost < 0 || b.MaxToolCalls < 0 {
+		return fmt.Errorf("invalid budget: the limits must not be negative")
+	}
+	if w := cfg.Budget.WarnAt; w < 0 || w >= 1 {
+		return fmt.Errorf("invalid budget warning %v: must be between 0 and 1", w)
+	}
+	if err := validateExamples(cfg.Examples); err != nil {
+		return err
+	}
+	if cfg.Instructions.MaxFileSize < 0 || cfg.Instructions.MaxSize < 0 {
+		return fmt.Errorf("invalid instructions size limit: must not be negative")
+	}
+	for provider, providerCfg := range cfg.Providers {
+		if _, err := discovery.ProxyFunc(providerCfg.Proxy); err != nil {
+			return fmt.Errorf("invalid proxy for provider %s: %w", provider, err)
+		}
+		if limit := providerCfg.RateLimit; limit != nil && (limit.RequestsPerMinute < 0 || limit.TokensPerMinute < 0) {
+			return fmt.Errorf("invalid rate limit for provider %s: limits must not be negative", provider)
+		}
+		if (providerCfg.GPULayers != nil && *providerCfg.GPULayers < 0) || providerCfg.Threads < 0 {
+			return fmt.Errorf("invalid configuration for provider %s: gpuLayers and threads must not be negative", provider)
+		}
+	}
+
 	// Validate agent models
 	for name, agent := range cfg.Agents {
 		// Check if model exists
@@ -376,7 +1015,15 @@ func Validate() error {
 		provider := model.Provider
 		providerCfg, providerExists := cfg.Providers[provider]
 
-		if !providerExists {
+		if !providerExists && !providerNeedsAPIKey(provider) {
+			// Local providers work without any configuration
+			local := Provider{}
+			if provider == models.ProviderOllama {
+				local.BaseURL = os.Getenv("OLLAMA_HOST")
+			}
+			cfg.Providers[provider] = local
+			logging.Info("added local provider", "provider", provider)
+		} else if !providerExists {
 			// Provider not configured, check if we have environment variables
 			apiKey := getProviderAPIKey(provider)
 			if apiKey == "" {
@@ -398,7 +1045,7 @@ func Validate() error {
 				}
 				logging.Info("added provider from environment", "provider", provider)
 			}
-		} else if providerCfg.Disabled || providerCfg.APIKey == "" {
+		} else if providerCfg.Disabled || (providerCfg.APIKey == "" && providerNeedsAPIKey(provider)) {
 			// Provider is disabled or has no API key
 			logging.Warn("provider is disabled or has no API key, reverting to default",
 				"agent", name,
@@ -443,7 +1090,7 @@ func Validate() error {
 		}
 
 		// Validate reasoning effort for models that support reasoning
-		if model.CanReason && provider == models.ProviderOpenAI {
+		if model.CanReason && (provider == models.ProviderOpenAI || provider == models.ProviderAzure) {
 			if agent.ReasoningEffort == "" {
 				// Set default reasoning effort for models that support it
 				logging.Info("setting default reasoning effort for model that supports reasoning",
@@ -481,11 +1128,51 @@ func Validate() error {
 			updatedAgent.ReasoningEffort = ""
 			cfg.Agents[name] = updatedAgent
 		}
+
+		// Validate the thinking budget of Claude models
+		if agent.ThinkingBudget != 0 {
+			if !model.CanReason || (provider != models.ProviderAnthropic && provider != models.ProviderBedrock) {
+				logging.Warn("model doesn't support extended thinking but a thinking budget is set, ignoring",
+					"agent", name,
+					"model", agent.Model,
+					"thinking_budget", agent.ThinkingBudget)
+
+				updatedAgent := cfg.Agents[name]
+				updatedAgent.ThinkingBudget = 0
+				cfg.Agents[name] = updatedAgent
+			} else if agent.ThinkingBudget < minThinkingBudget {
+				logging.Warn("thinking budget is below the minimum, adjusting",
+					"agent", name,
+					"model", agent.Model,
+					"thinking_budget", agent.ThinkingBudget)
+
+				updatedAgent := cfg.Agents[name]
+				updatedAgent.ThinkingBudget = minThinkingBudget
+				cfg.Agents[name] = updatedAgent
+			}
+		}
+
+		// Drop fallback models that cannot be used
+		if len(agent.Fallback) > 0 {
+			var fallback []models.ModelID
+			for _, id := range agent.Fallback {
+				if !modelAvailable(id) {
+					logging.Warn("fallback model is not available, ignoring",
+						"agent", name,
+						"model", id)
+					continue
+				}
+				fallback = append(fallback, id)
+			}
+			updatedAgent := cfg.Agents[name]
+			updatedAgent.Fallback = fallback
+			cfg.Agents[name] = updatedAgent
+		}
 	}
 
 	// Validate providers
 	for provider, providerCfg := range cfg.Providers {
-		if providerCfg.APIKey == "" && !providerCfg.Disabled {
+		if providerCfg.APIKey == "" && !providerCfg.Disabled && providerNeedsAPIKey(provider) {
 			logging.Warn("provider has no API key, marking as disabled", "provider", provider)
 			providerCfg.Disabled = true
 			cfg.Providers[provider] = providerCfg
@@ -504,6 +1191,45 @@ func Validate() error {
 	return nil
 }
 
+// providerNeedsAPIKey reports whether a provider can only be used with an API key.
+// Local providers such as Ollama, LM Studio and llama.cpp, and OpenAI compatible servers
+// which are often self-hosted, are reachable without one. Azure OpenAI can
+// authenticate with Entra ID instead.
+// modelAvailable reports whether a model is supported and its provider can be
+// used, adding local providers and providers configured through the
+// environment like for the main model of an agent.
+func modelAvailable(id models.ModelID) bool {
+	model, ok := models.SupportedModels[id]
+	if !ok {
+		return false
+	}
+	provider := model.Provider
+	if providerCfg, ok := cfg.Providers[provider]; ok {
+		return !providerCfg.Disabled && (providerCfg.APIKey != "" || !providerNeedsAPIKey(provider))
+	}
+	if !providerNeedsAPIKey(provider) {
+		local := Provider{}
+		if provider == models.ProviderOllama {
+			local.BaseURL = os.Getenv("OLLAMA_HOST")
+		}
+		cfg.Providers[provider] = local
+		return true
+	}
+	if apiKey := getProviderAPIKey(provider); apiKey != "" {
+		cfg.Providers[provider] = Provider{APIKey: apiKey}
+		return true
+	}
+	return false
+}
+
+func providerNeedsAPIKey(provider models.ModelProvider) bool {
+	switch provider {
+	case models.ProviderOllama, models.ProviderLMStudio, models.ProviderLlamaCpp, models.ProviderGGUF, models.ProviderOpenAICompatible, models.ProviderAzure, models.ProviderVertexAI:
+		return false
+	}
+	return true
+}
+
 // getProviderAPIKey gets the API key for a provider from environment variables
 func getProviderAPIKey(provider models.ModelProvider) string {
 	switch provider {
@@ -515,6 +1241,18 @@ func getProviderAPIKey(provider models.ModelProvider) string {
 		return os.Getenv("GEMINI_API_KEY")
 	case models.ProviderGROQ:
 		return os.Getenv("GROQ_API_KEY")
+	case models.ProviderOpenRouter:
+		return os.Getenv("OPENROUTER_API_KEY")
+	case models.ProviderDeepSeek:
+		return os.Getenv("DEEPSEEK_API_KEY")
+	case models.ProviderXAI:
+		return os.Getenv("XAI_API_KEY")
+	case models.ProviderMistral:
+		return os.Getenv("MISTRAL_API_KEY")
+	case models.ProviderTogether:
+		return os.Getenv("TOGETHER_API_KEY")
+	case models.ProviderCopilot:
+		return copilotToken()
 	case models.ProviderAzure:
 		return os.Getenv("AZURE_OPENAI_API_KEY")
 	case models.ProviderBedrock:
@@ -599,6 +1337,86 @@ func setDefaultModelForAgent(agent AgentName) bool {
 		return true
 	}
 
+	if apiKey := os.Getenv("DEEPSEEK_API_KEY"); apiKey != "" {
+		maxTokens := int64(5000)
+		if agent == AgentTitle {
+			maxTokens = 80
+		}
+
+		cfg.Agents[agent] = Agent{
+			Model:     models.DeepSeekChat,
+			MaxTokens: maxTokens,
+		}
+		return true
+	}
+
+	if apiKey := os.Getenv("XAI_API_KEY"); apiKey != "" {
+		maxTokens := int64(5000)
+		if agent == AgentTitle {
+			maxTokens = 80
+		}
+
+		cfg.Agents[agent] = Agent{
+			Model:     models.XAIGrok3,
+			MaxTokens: maxTokens,
+		}
+		return true
+	}
+
+	if apiKey := os.Getenv("MISTRAL_API_KEY"); apiKey != "" {
+		maxTokens := int64(5000)
+		if agent == AgentTitle {
+			maxTokens = 80
+		}
+
+		cfg.Agents[agent] = Agent{
+			Model:     models.MistralLarge,
+			MaxTokens: maxTokens,
+		}
+		return true
+	}
+
+	if apiKey := os.Getenv("TOGETHER_API_KEY"); apiKey != "" {
+		maxTokens := int64(4096)
+		if agent == AgentTitle {
+			maxTokens = 80
+		}
+
+		cfg.Agents[agent] = Agent{
+			Model:     models.TogetherQwen25Coder32B,
+			MaxTokens: maxTokens,
+		}
+		return true
+	}
+
+	if copilotToken() != "" {
+		maxTokens := int64(5000)
+		if agent == AgentTitle {
+			maxTokens = 80
+		}
+
+		cfg.Agents[agent] = Agent{
+			Model:     models.CopilotGPT41,
+			MaxTokens: maxTokens,
+		}
+		return true
+	}
+
+	if apiKey := os.Getenv("OPENROUTER_API_KEY"); apiKey != "" {
+		if _, ok := models.OpenRouterModels[models.OpenRouterModelID(openrouterDefaultModel)]; ok {
+			maxTokens := int64(5000)
+			if agent == AgentTitle {
+				maxTokens = 80
+			}
+
+			cfg.Agents[agent] = Agent{
+				Model:     models.OpenRouterModelID(openrouterDefaultModel),
+				MaxTokens: maxTokens,
+			}
+			return true
+		}
+	}
+
 	if hasAWSCredentials() {
 		maxTokens := int64(5000)
 		if agent == AgentTitle {
@@ -613,9 +1431,112 @@ func setDefaultModelForAgent(agent AgentName) bool {
 		return true
 	}
 
+	if os.Getenv("GOOGLE_CLOUD_PROJECT") != "" {
+		maxTokens := int64(5000)
+		if agent == AgentTitle {
+			maxTokens = 80
+		}
+
+		cfg.Agents[agent] = Agent{
+			Model:     models.VertexAIGemini25Flash,
+			MaxTokens: maxTokens,
+		}
+		return true
+	}
+
+	if model, ok := models.DefaultOllamaModel(); ok {
+		maxTokens := int64(4096)
+		if agent == AgentTitle {
+			maxTokens = 80
+		}
+
+		cfg.Agents[agent] = Agent{
+			Model:     model,
+			MaxTokens: maxTokens,
+		}
+		return true
+	}
+
 	return false
 }
 
+// minThinkingBudget is the smallest thinking budget Anthropic accepts.
+const minThinkingBudget = 1024
+
+// UpdateAgentReasoning changes the reasoning effort and the thinking budget of
+// an agent for the rest of the session, the configuration file is not
+// changed.
+func UpdateAgentReasoning(name AgentName, effort string, thinkingBudget int64) error {
+	if cfg == nil {
+		return fmt.Errorf("config not loaded")
+	}
+	agent, ok := cfg.Agents[name]
+	if !ok {
+		return fmt.Errorf("agent %s not configured", name)
+	}
+	switch effort {
+	case "", "low", "medium", "high":
+	default:
+		return fmt.Errorf("invalid reasoning effort %q, must be low, medium or high", effort)
+	}
+	if thinkingBudget != 0 && thinkingBudget < minThinkingBudget {
+		return fmt.Errorf("thinking budget must be at least %d tokens", minThinkingBudget)
+	}
+	agent.ReasoningEffort = effort
+	agent.ThinkingBudget = thinkingBudget
+	cfg.Agents[name] = agent
+	return nil
+}
+
+// UpdateAgentModel switches the model of an agent for the rest of the session,
+// the configuration file is not changed. The max tokens and the reasoning
+// settings are reset to the defaults of the new model.
+func UpdateAgentModel(name AgentName, modelID models.ModelID) error {
+	if cfg == nil {
+		return fmt.Errorf("config not loaded")
+	}
+	agent, ok := cfg.Agents[name]
+	if !ok {
+		return fmt.Errorf("agent %s not configured", name)
+	}
+	if !modelAvailable(modelID) {
+		return fmt.Errorf("model %s is not available", modelID)
+	}
+	model := models.SupportedModels[modelID]
+	agent.Model = modelID
+	agent.MaxTokens = model.DefaultMaxTokens
+	if agent.MaxTokens <= 0 {
+		agent.MaxTokens = 4096
+	}
+	agent.ThinkingBudget = 0
+	agent.ReasoningEffort = ""
+	if model.CanReason && (model.Provider == models.ProviderOpenAI || model.Provider == models.ProviderAzure) {
+		agent.ReasoningEffort = "medium"
+	}
+	cfg.Agents[name] = agent
+	return nil
+}
+
+// AvailableModels returns the supported models whose provider is configured
+// and enabled.
+func AvailableModels() []models.Model {
+	var available []models.Model
+	for _, model := range models.SupportedModels {
+		providerCfg, ok := cfg.Providers[model.Provider]
+		if !ok && providerNeedsAPIKey(model.Provider) {
+			continue
+		}
+		if ok && (providerCfg.Disabled || (providerCfg.APIKey == "" && providerNeedsAPIKey(model.Provider))) {
+			continue
+		}
+		if model.Provider == models.ProviderMock {
+			continue
+		}
+		available = append(available, model)
+	}
+	return available
+}
+
 // Get returns the current configuration.
 // It's safe to call this function multiple times.
 func Get() *Config {
@@ -0,0 +1,41 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/opencode-ai/opencode/internal/llm/models"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApplyModelTiers(t *testing.T) {
+	t.Cleanup(viper.Reset)
+
+	viper.Set("models.small", "ollama.llama3.2")
+	viper.Set("models.large", "claude-4-sonnet")
+	viper.Set("agents.task.model", "gpt-4.1")
+
+	applyModelTiers()
+
+	// The agents of each tier default to its model, the models configured for
+	// the agents take precedence
+	assert.Equal(t, "ollama.llama3.2", viper.GetString("agents.title.model"))
+	assert.Equal(t, "ollama.llama3.2", viper.GetString("agents.summarizer.model"))
+	assert.Equal(t, "claude-4-sonnet", viper.GetString("agents.coder.model"))
+	assert.Equal(t, "gpt-4.1", viper.GetString("agents.task.model"))
+
+	// The models of the summarizer are discovered like those of the other agents
+	assert.Equal(t, []models.ModelID{"ollama.llama3.2"}, configuredModels(models.ProviderOllama))
+}
+
+func TestApplyModelTiersUnset(t *testing.T) {
+	t.Cleanup(viper.Reset)
+
+	viper.Set("models.large", "claude-4-sonnet")
+
+	applyModelTiers()
+
+	assert.Equal(t, "claude-4-sonnet", viper.GetString("agents.coder.model"))
+	assert.False(t, viper.IsSet("agents.title.model"))
+	assert.False(t, viper.IsSet("agents.summarizer.model"))
+}
@@ -0,0 +1,34 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// validateContainer checks that the container tool has either an image or a
+// compose service to run commands in.
+func validateContainer(c Container) error {
+	if c.Image == "" && c.Service == "" {
+		if c.ComposeFile != "" || c.Runtime != "" || c.Workdir != "" || len(c.Env) > 0 {
+			return fmt.Errorf("invalid container configuration: an image or a service is required")
+		}
+		return nil
+	}
+	if c.Image != "" && c.Service != "" {
+		return fmt.Errorf("invalid container configuration: image and service can't be used together")
+	}
+	if c.ComposeFile != "" && c.Service == "" {
+		return fmt.Errorf("invalid container configuration: composeFile needs a service")
+	}
+	switch c.Runtime {
+	case "", "docker", "podman":
+	default:
+		return fmt.Errorf("invalid container configuration: unknown runtime %q, must be docker or podman", c.Runtime)
+	}
+	for _, env := range c.Env {
+		if key, _, ok := strings.Cut(env, "="); !ok || key == "" {
+			return fmt.Errorf("invalid container configuration: env %q must be KEY=value", env)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// copilotCredentials is the format of the token files of opencode and of the
+// Copilot editor plugins.
+type copilotCredentials struct {
+	OAuthToken string `json:"oauth_token"`
+}
+
+// userConfigDir is $XDG_CONFIG_HOME, or ~/.config where it is not set.
+func userConfigDir() string {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return dir
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".config")
+}
+
+// UserDir is the directory of the configuration of the user, like
+// ~/.config/opencode, with the instruction files for all projects.
+func UserDir() string {
+	return filepath.Join(userConfigDir(), appName)
+}
+
+// CopilotTokenPath is where `opencode auth copilot` saves the GitHub token.
+func CopilotTokenPath() string {
+	return filepath.Join(userConfigDir(), appName, "copilot.json")
+}
+
+// SaveCopilotToken saves the GitHub OAuth token of a Copilot subscription,
+// readable only by the user.
+func SaveCopilotToken(token string) error {
+	path := CopilotTokenPath()
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	data, err := json.Marshal(copilotCredentials{OAuthToken: token})
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return fmt.Errorf("failed to save copilot token: %w", err)
+	}
+	return nil
+}
+
+// copilotToken returns the GitHub OAuth token of a Copilot subscription, from
+// GITHUB_COPILOT_TOKEN, the token saved by `opencode auth copilot` or the login
+// of a Copilot editor plugin, e.g. copilot.vim or the JetBrains plugin.
+func copilotToken() string {
+	if token := os.Getenv("GITHUB_COPILOT_TOKEN"); token != "" {
+		return token
+	}
+	if data, err := os.ReadFile(CopilotTokenPath()); err == nil {
+		var credentials copilotCredentials
+		if json.Unmarshal(data, &credentials) == nil && credentials.OAuthToken != "" {
+			return credentials.OAuthToken
+		}
+	}
+	for _, name := range []string{"hosts.json", "apps.json"} {
+		data, err := os.ReadFile(filepath.Join(userConfigDir(), "github-copilot", name))
+		if err != nil {
+			continue
+		}
+		// The logins are keyed by host, and by the OAuth app in apps.json
+		var logins map[string]copilotCredentials
+		if json.Unmarshal(data, &logins) != nil {
+			continue
+		}
+		for host, credentials := range logins {
+			if strings.HasPrefix(host, "github.com") && credentials.OAuthToken != "" {
+				return credentials.OAuthToken
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,40 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"text/template"
+)
+
+// customToolNamePattern matches the tool names the providers accept.
+var customToolNamePattern = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,64}$`)
+
+// validateCustomTools checks that the custom tools have a valid name, a
+// description, a command and templates that parse.
+func validateCustomTools(customTools map[string]CustomTool) error {
+	for name, tool := range customTools {
+		if !customToolNamePattern.MatchString(name) {
+			return fmt.Errorf("invalid tool %s: names can only have letters, digits, _ and -, at most 64", name)
+		}
+		if tool.Description == "" {
+			return fmt.Errorf("invalid tool %s: description is required", name)
+		}
+		if tool.Command == "" {
+			return fmt.Errorf("invalid tool %s: command is required", name)
+		}
+		if t, ok := tool.Parameters["type"]; ok && t != "object" {
+			return fmt.Errorf("invalid tool %s: parameters must be an object schema", name)
+		}
+		if properties, ok := tool.Parameters["properties"]; ok {
+			if _, ok := properties.(map[string]any); !ok {
+				return fmt.Errorf("invalid tool %s: properties of the parameters must be an object", name)
+			}
+		}
+		for _, text := range append([]string{tool.Command}, tool.Args...) {
+			if _, err := template.New(name).Parse(text); err != nil {
+				return fmt.Errorf("invalid tool %s: %w", name, err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+)
+
+// validateDatabases checks that the databases of the sql tool have a known
+// driver and a DSN the driver can connect to.
+func validateDatabases(databases map[string]Database) error {
+	for name, database := range databases {
+		if database.DSN == "" {
+			return fmt.Errorf("invalid database %s: dsn is required", name)
+		}
+		switch database.Driver {
+		case DatabaseSQLite:
+		case DatabasePostgres, DatabaseMySQL:
+			u, err := url.Parse(database.DSN)
+			if err != nil {
+				return fmt.Errorf("invalid database %s: %w", name, err)
+			}
+			if database.Driver == DatabasePostgres && u.Scheme != "postgres" && u.Scheme != "postgresql" {
+				return fmt.Errorf("invalid database %s: dsn must be a postgres:// URL", name)
+			}
+			if database.Driver == DatabaseMySQL && u.Scheme != "mysql" {
+				return fmt.Errorf("invalid database %s: dsn must be a mysql:// URL", name)
+			}
+		default:
+			return fmt.Errorf("invalid database %s: unknown driver %q, must be one of: sqlite, postgres, mysql", name, database.Driver)
+		}
+	}
+	return nil
+}
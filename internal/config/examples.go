@@ -0,0 +1,103 @@
+package config
+
+import (
+	"fmt"
+	"path"
+)
+
+// Roles of the messages of an example exchange
+const (
+	ExampleRoleUser      = "user"
+	ExampleRoleAssistant = "assistant"
+	ExampleRoleTool      = "tool"
+)
+
+// Example is an exchange sent before the conversation to the models it is
+// for, to show them how to call the tools. Small local models often need a
+// demonstration to emit tool calls the agent can use.
+type Example struct {
+	// Models are the IDs of the models the example is sent to, with *
+	// wildcards like ollama.*
+	Models   []string         `json:"models"`
+	Messages []ExampleMessage `json:"messages"`
+}
+
+// ExampleMessage is a message of an example exchange. The tool messages after
+// an assistant message are the results of its tool calls, in order.
+type ExampleMessage struct {
+	Role      string            `json:"role"`
+	Content   string            `json:"content,omitempty"`
+	ToolCalls []ExampleToolCall `json:"toolCalls,omitempty"`
+}
+
+// ExampleToolCall is a tool call of an assistant message of an example.
+type ExampleToolCall struct {
+	Name  string         `json:"name"`
+	Input map[string]any `json:"input,omitempty"`
+}
+
+// ExamplesFor returns the examples of the configuration for the model, in the
+// order they are configured.
+func ExamplesFor(modelID string) []Example {
+	var examples []Example
+	for _, example := range cfg.Examples {
+		for _, pattern := range example.Models {
+			if ok, _ := path.Match(pattern, modelID); ok {
+				examples = append(examples, example)
+				break
+			}
+		}
+	}
+	return examples
+}
+
+// validateExamples checks that the examples are for some models and that their
+// tool results answer the tool calls before them.
+func validateExamples(examples []Example) error {
+	for i, example := range examples {
+		if len(example.Models) == 0 {
+			return fmt.Errorf("example %d is for no model", i+1)
+		}
+		for _, pattern := range example.Models {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("invalid model pattern %q of example %d: %w", pattern, i+1, err)
+			}
+		}
+		if len(example.Messages) == 0 || example.Messages[0].Role != ExampleRoleUser {
+			return fmt.Errorf("example %d must start with a user message", i+1)
+		}
+		pending := 0
+		for j, msg := range example.Messages {
+			if msg.Role != ExampleRoleTool && pending > 0 {
+				return fmt.Errorf("message %d of example %d: %d tool calls have no result", j+1, i+1, pending)
+			}
+			switch msg.Role {
+			case ExampleRoleUser:
+				if msg.Content == "" {
+					return fmt.Errorf("message %d of example %d: user message is empty", j+1, i+1)
+				}
+			case ExampleRoleAssistant:
+				if msg.Content == "" && len(msg.ToolCalls) == 0 {
+					return fmt.Errorf("message %d of example %d: assistant message is empty", j+1, i+1)
+				}
+				for _, call := range msg.ToolCalls {
+					if call.Name == "" {
+						return fmt.Errorf("message %d of example %d: tool call without a name", j+1, i+1)
+					}
+				}
+				pending = len(msg.ToolCalls)
+			case ExampleRoleTool:
+				if pending == 0 {
+					return fmt.Errorf("message %d of example %d: tool result without a tool call", j+1, i+1)
+				}
+				pending--
+			default:
+				return fmt.Errorf("message %d of example %d: unknown role %q, must be user, assistant or tool", j+1, i+1, msg.Role)
+			}
+		}
+		if pending > 0 {
+			return fmt.Errorf("example %d: %d tool calls have no result", i+1, pending)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExamplesFor(t *testing.T) {
+	previous := cfg
+	t.Cleanup(func() { cfg = previous })
+
+	ls := Example{Models: []string{"ollama.*", "llama3.1:8b"}, Messages: []ExampleMessage{{Role: ExampleRoleUser, Content: "list the files"}}}
+	view := Example{Models: []string{"ollama.qwen*"}, Messages: []ExampleMessage{{Role: ExampleRoleUser, Content: "read main.go"}}}
+	cfg = &Config{Examples: []Example{ls, view}}
+
+	assert.Equal(t, []Example{ls, view}, ExamplesFor("ollama.qwen2.5-coder"))
+	assert.Equal(t, []Example{ls}, ExamplesFor("ollama.llama3.1"))
+	assert.Equal(t, []Example{ls}, ExamplesFor("llama3.1:8b"))
+	assert.Empty(t, ExamplesFor("claude-3.7-sonnet"))
+}
+
+func TestValidateExamples(t *testing.T) {
+	user := ExampleMessage{Role: ExampleRoleUser, Content: "list the files"}
+	call := ExampleMessage{Role: ExampleRoleAssistant, ToolCalls: []ExampleToolCall{{Name: "ls", Input: map[string]any{"path": "."}}}}
+	result := ExampleMessage{Role: ExampleRoleTool, Content: "main.go"}
+	answer := ExampleMessage{Role: ExampleRoleAssistant, Content: "There is main.go."}
+	models := []string{"ollama.*"}
+
+	tests := []struct {
+		name    string
+		example Example
+		err     string
+	}{
+		{name: "valid", example: Example{Models: models, Messages: []ExampleMessage{user, call, result, answer}}},
+		{name: "no model", example: Example{Messages: []ExampleMessage{user}}, err: "example 1 is for no model"},
+		{name: "invalid pattern", example: Example{Models: []string{"ollama.["}, Messages: []ExampleMessage{user}}, err: `invalid model pattern "ollama.["`},
+		{name: "starts with the assistant", example: Example{Models: models, Messages: []ExampleMessage{answer}}, err: "must start with a user message"},
+		{name: "empty user message", example: Example{Models: models, Messages: []ExampleMessage{{Role: ExampleRoleUser}}}, err: "user message is empty"},
+		{name: "empty assistant message", example: Example{Models: models, Messages: []ExampleMessage{user, {Role: ExampleRoleAssistant}}}, err: "assistant message is empty"},
+		{
+			name:    "tool call without a name",
+			example: Example{Models: models, Messages: []ExampleMessage{user, {Role: ExampleRoleAssistant, ToolCalls: []ExampleToolCall{{}}}, result}},
+			err:     "tool call without a name",
+		},
+		{name: "missing result", example: Example{Models: models, Messages: []ExampleMessage{user, call, answer}}, err: "message 3 of example 1: 1 tool calls have no result"},
+		{name: "missing last result", example: Example{Models: models, Messages: []ExampleMessage{user, call}}, err: "example 1: 1 tool calls have no result"},
+		{name: "result without a call", example: Example{Models: models, Messages: []ExampleMessage{user, result}}, err: "tool result without a tool call"},
+		{name: "unknown role", example: Example{Models: models, Messages: []ExampleMessage{user, {Role: "system", Content: "be brief"}}}, err: `unknown role "system"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateExamples([]Example{tt.example})
+			if tt.err == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tt.err)
+			}
+		})
+	}
+}
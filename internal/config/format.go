@@ -0,0 +1,19 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// validateFormat checks that the formatters are configured by extension.
+func validateFormat(f Format) error {
+	for ext, command := range f.Formatters {
+		if !strings.HasPrefix(ext, ".") || strings.ContainsAny(ext, "/\\ ") {
+			return fmt.Errorf("invalid format configuration: %q is not an extension like .go", ext)
+		}
+		if len(command) > 0 && command[0] == "" {
+			return fmt.Errorf("invalid format configuration: the formatter of %s has no command", ext)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package config
+
+import (
+	"sort"
+
+	"github.com/opencode-ai/opencode/internal/llm/models"
+	"github.com/opencode-ai/opencode/internal/logging"
+	"github.com/spf13/viper"
+)
+
+// registerGGUFModels registers the model files configured for the in-process
+// GGUF provider. The files are only read once an agent uses them.
+func registerGGUFModels() {
+	key := "providers." + string(models.ProviderGGUF)
+	if !viper.IsSet(key) || viper.GetBool(key+".disabled") {
+		return
+	}
+
+	var configured []CustomModel
+	if err := viper.UnmarshalKey(key+".models", &configured); err != nil {
+		logging.Warn("invalid gguf models configuration", "error", err)
+		return
+	}
+
+	registered := make(map[models.ModelID]models.Model, len(configured))
+	for _, c := range configured {
+		if c.APIModel == "" {
+			logging.Warn("ignoring gguf model without a file, set its apiModel to the path of the .gguf file", "name", c.Name)
+			continue
+		}
+		model := models.GGUFModel(c.Name, c.APIModel)
+		model.CanReason = c.CanReason
+		if c.ContextWindow > 0 {
+			model.ContextWindow = c.ContextWindow
+		}
+		if c.MaxTokens > 0 {
+			model.DefaultMaxTokens = c.MaxTokens
+		}
+		registered[model.ID] = model
+	}
+	models.RegisterGGUFModels(registered)
+
+	if len(registered) > 0 && !viper.IsSet("agents.coder.model") {
+		ids := make([]string, 0, len(registered))
+		for id := range registered {
+			ids = append(ids, string(id))
+		}
+		sort.Strings(ids)
+		viper.SetDefault("agents.coder.model", ids[0])
+		viper.SetDefault("agents.task.model", ids[0])
+		viper.SetDefault("agents.title.model", ids[0])
+	}
+}
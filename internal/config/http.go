@@ -0,0 +1,29 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// validateHTTP checks that the allowed hosts of the http tool are host
+// patterns and that the headers are added to allowed hosts.
+func validateHTTP(h HTTP) error {
+	for _, host := range h.AllowedHosts {
+		name := strings.TrimPrefix(host, "*.")
+		if name == "" || strings.ContainsAny(name, "/*?#@ ") {
+			return fmt.Errorf("invalid http configuration: allowed host %q must be a host with an optional port, like localhost:3000 or *.example.com", host)
+		}
+	}
+	for host, headers := range h.Headers {
+		if !slices.Contains(h.AllowedHosts, host) {
+			return fmt.Errorf("invalid http configuration: headers of %q, which is not one of the allowed hosts", host)
+		}
+		for name := range headers {
+			if name == "" || strings.ContainsAny(name, ": \t\r\n") {
+				return fmt.Errorf("invalid http configuration: invalid header name %q for %s", name, host)
+			}
+		}
+	}
+	return nil
+}
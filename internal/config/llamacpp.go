@@ -0,0 +1,57 @@
+package config
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/opencode-ai/opencode/internal/llm/discovery"
+	"github.com/opencode-ai/opencode/internal/llm/models"
+	"github.com/opencode-ai/opencode/internal/logging"
+	"github.com/spf13/viper"
+)
+
+// discoverLlamaCppModels registers the model loaded in the llama.cpp server.
+// It only looks for a server when the provider is configured or used by an agent.
+func discoverLlamaCppModels() {
+	key := "providers." + string(models.ProviderLlamaCpp)
+	if viper.GetBool(key + ".disabled") {
+		return
+	}
+	configured := configuredModels(models.ProviderLlamaCpp)
+	if !viper.IsSet(key) && len(configured) == 0 {
+		return
+	}
+
+	// The server answers for any model name, keep the configured ones
+	registered := make(map[models.ModelID]models.Model, len(configured)+1)
+	for _, id := range configured {
+		registered[id] = models.LlamaCppModel(strings.TrimPrefix(string(id), string(models.ProviderLlamaCpp)+"."))
+	}
+	defer models.RegisterLlamaCppModels(registered)
+
+	client, err := localProviderClient(models.ProviderLlamaCpp)
+	if err != nil {
+		logging.Warn("failed to configure llamacpp client", "error", err)
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	model, err := discovery.FetchLlamaCppModel(ctx, client, viper.GetString(key+".baseURL"))
+	if err != nil {
+		logging.Warn("failed to discover llamacpp model", "error", err)
+		return
+	}
+	for id, m := range registered {
+		m.ContextWindow = model.ContextWindow
+		m.DefaultMaxTokens = model.DefaultMaxTokens
+		registered[id] = m
+	}
+	registered[model.ID] = model
+
+	if !viper.IsSet("agents.coder.model") {
+		viper.SetDefault("agents.coder.model", model.ID)
+		viper.SetDefault("agents.task.model", model.ID)
+		viper.SetDefault("agents.title.model", model.ID)
+	}
+}
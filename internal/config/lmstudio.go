@@ -0,0 +1,91 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/opencode-ai/opencode/internal/llm/discovery"
+	"github.com/opencode-ai/opencode/internal/llm/models"
+	"github.com/opencode-ai/opencode/internal/logging"
+	"github.com/spf13/viper"
+)
+
+// discoverLMStudioModels registers the models downloaded in LM Studio. It only
+// looks for a server when the provider is configured or used by an agent.
+func discoverLMStudioModels() {
+	key := "providers." + string(models.ProviderLMStudio)
+	if viper.GetBool(key + ".disabled") {
+		return
+	}
+	configured := configuredModels(models.ProviderLMStudio)
+	if !viper.IsSet(key) && len(configured) == 0 {
+		return
+	}
+
+	client, err := localProviderClient(models.ProviderLMStudio)
+	if err != nil {
+		logging.Warn("failed to configure lmstudio client", "error", err)
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	discovered, loaded, err := discovery.FetchLMStudioModels(ctx, client, viper.GetString(key+".baseURL"))
+	if err != nil {
+		logging.Warn("failed to discover lmstudio models", "error", err)
+		// Keep the configured models, LM Studio may be started later
+		placeholders := make(map[models.ModelID]models.Model, len(configured))
+		for _, id := range configured {
+			placeholders[id] = models.LMStudioModel(strings.TrimPrefix(string(id), string(models.ProviderLMStudio)+"."))
+		}
+		models.RegisterLMStudioModels(placeholders, nil)
+		return
+	}
+	models.RegisterLMStudioModels(discovered, loaded)
+
+	if defaultModel, ok := models.DefaultLMStudioModel(); ok && !viper.IsSet("agents.coder.model") {
+		viper.SetDefault("agents.coder.model", defaultModel)
+		viper.SetDefault("agents.task.model", defaultModel)
+		viper.SetDefault("agents.title.model", defaultModel)
+	}
+}
+
+// localProviderClient returns the HTTP client used to discover the models of
+// a provider, with the TLS and proxy settings of the provider applied.
+func localProviderClient(provider models.ModelProvider) (*http.Client, error) {
+	var tlsSettings TLSConfig
+	if err := viper.UnmarshalKey("providers."+string(provider)+".tls", &tlsSettings); err != nil {
+		return nil, fmt.Errorf("invalid %s tls configuration: %w", provider, err)
+	}
+	tlsConfig, err := tlsSettings.ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load %s tls configuration: %w", provider, err)
+	}
+	proxy, err := providerProxy(provider)
+	if err != nil {
+		return nil, err
+	}
+	return &http.Client{
+		Transport: &http.Transport{
+			Proxy:           proxy,
+			TLSClientConfig: tlsConfig,
+		},
+	}, nil
+}
+
+// providerProxy returns the proxy selection for the requests to a provider,
+// its own proxy, the global one or the proxy environment variables.
+func providerProxy(provider models.ModelProvider) (func(*http.Request) (*url.URL, error), error) {
+	proxy := viper.GetString("providers." + string(provider) + ".proxy")
+	if proxy == "" {
+		proxy = viper.GetString("proxy")
+	}
+	proxyFunc, err := discovery.ProxyFunc(proxy)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s proxy configuration: %w", provider, err)
+	}
+	return proxyFunc, nil
+}
@@ -0,0 +1,302 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/opencode-ai/opencode/internal/llm/discovery"
+	"github.com/opencode-ai/opencode/internal/llm/models"
+	"github.com/opencode-ai/opencode/internal/logging"
+	"github.com/spf13/viper"
+)
+
+var (
+	// missingOllamaModels holds the configured Ollama models that are not
+	// pulled on the server yet.
+	missingOllamaModels   = map[models.ModelID]models.Model{}
+	missingOllamaModelsMu sync.Mutex
+)
+
+// ollamaConnection returns the HTTP client, base URLs and headers used to talk
+// to the configured Ollama servers outside of the provider client.
+func ollamaConnection() (*http.Client, []string, map[string]string, error) {
+	var tlsSettings TLSConfig
+	if err := viper.UnmarshalKey("providers.ollama.tls", &tlsSettings); err != nil {
+		return nil, nil, nil, fmt.Errorf("invalid ollama tls configuration: %w", err)
+	}
+	tlsConfig, err := tlsSettings.ClientConfig()
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to load ollama tls configuration: %w", err)
+	}
+	proxy, err := providerProxy(models.ProviderOllama)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	transport := &http.Transport{
+		Proxy:           proxy,
+		TLSClientConfig: tlsConfig,
+	}
+	baseURLs := append([]string{viper.GetString("providers.ollama.baseURL")}, viper.GetStringSlice("providers.ollama.baseURLs")...)
+	discovery.EnableUnixSockets(transport, baseURLs...)
+	client := &http.Client{
+		Transport: transport,
+	}
+
+	headers := viper.GetStringMapString("providers.ollama.headers")
+	if apiKey := viper.GetString("providers.ollama.apiKey"); apiKey != "" {
+		headers["Authorization"] = "Bearer " + apiKey
+	}
+	return client, baseURLs, headers, nil
+}
+
+// reachableOllamaHost returns the first of the Ollama servers that responds.
+func reachableOllamaHost(ctx context.Context, client *http.Client, baseURLs []string, headers map[string]string) (string, error) {
+	var lastErr error
+	for _, baseURL := range baseURLs {
+		if baseURL == "" && len(baseURLs) > 1 {
+			continue
+		}
+		if _, err := discovery.OllamaVersion(ctx, client, baseURL, headers); err != nil {
+			lastErr = err
+			continue
+		}
+		return baseURL, nil
+	}
+	return "", lastErr
+}
+
+// configuredModels returns the model IDs of a provider configured for the agents.
+func configuredModels(provider models.ModelProvider) []models.ModelID {
+	names := []string{string(AgentCoder), string(AgentTask), string(AgentTitle), string(AgentSummarizer)}
+	for name := range viper.GetStringMap("agents") {
+		names = append(names, name)
+	}
+
+	var ids []models.ModelID
+	for _, name := range names {
+		model := models.ModelID(viper.GetString("agents." + name + ".model"))
+		if strings.HasPrefix(string(model), string(provider)+".") && !slices.Contains(ids, model) {
+			ids = append(ids, model)
+		}
+	}
+	return ids
+}
+
+// discoverOllamaModels registers the models pulled on the configured Ollama server.
+// Configured models that are not pulled yet are registered as well, so they
+// survive validation and can be pulled on demand.
+func discoverOllamaModels() {
+	if viper.GetBool("providers.ollama.disabled") {
+		return
+	}
+	configured := configuredModels(models.ProviderOllama)
+	if !viper.IsSet("providers.ollama") && os.Getenv("OLLAMA_HOST") == "" && len(configured) == 0 {
+		return
+	}
+
+	custom, err := customOllamaModels()
+	if err != nil {
+		logging.Warn("invalid ollama models configuration", "error", err)
+	}
+	// Custom models are registered last so they take precedence over discovered ones
+	defer models.RegisterOllamaModels(custom)
+
+	client, baseURLs, headers, err := ollamaConnection()
+	if err != nil {
+		logging.Warn("failed to configure ollama client", "error", err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	baseURL, err := reachableOllamaHost(ctx, client, baseURLs, headers)
+	var discovered map[models.ModelID]models.Model
+	if err == nil {
+		discovered, err = discovery.FetchOllamaModels(ctx, client, baseURL, headers)
+	}
+	if err != nil {
+		logging.Warn("failed to discover ollama models", "error", err)
+		// Keep the configured models, the startup health check reports the problem
+		for _, id := range configured {
+			if _, ok := custom[id]; !ok {
+				models.RegisterOllamaModels(map[models.ModelID]models.Model{id: ollamaModelFromID(id)})
+			}
+		}
+		return
+	}
+	models.RegisterOllamaModels(discovered)
+
+	if defaultModel, ok := models.DefaultOllamaModel(); ok && !viper.IsSet("agents.coder.model") {
+		viper.SetDefault("agents.coder.model", defaultModel)
+		viper.SetDefault("agents.task.model", defaultModel)
+		viper.SetDefault("agents.title.model", defaultModel)
+	}
+
+	updateOllamaModelDetails(client, baseURL, headers, discovered)
+
+	missingOllamaModelsMu.Lock()
+	defer missingOllamaModelsMu.Unlock()
+	for _, id := range configured {
+		model, isCustom := custom[id]
+		if !isCustom {
+			model = ollamaModelFromID(id)
+		}
+		if _, ok := discovered[models.OllamaModelID(model.APIModel)]; ok {
+			continue
+		}
+		missingOllamaModels[id] = model
+		if !isCustom {
+			models.RegisterOllamaModels(map[models.ModelID]models.Model{id: model})
+		}
+	}
+}
+
+// updateOllamaModelDetails replaces the default context window of the models
+// used by the agents with the context length Ollama reports for them, for
+// compaction and the context meter as the provider caps num_ctx, marks
+// models with the thinking capability as reasoning models and records whether
+// they support tools and images.
+func updateOllamaModelDetails(client *http.Client, baseURL string, headers map[string]string, discovered map[models.ModelID]models.Model) {
+	for _, id := range configuredModels(models.ProviderOllama) {
+		model, ok := discovered[id]
+		if !ok {
+			continue
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		info, err := discovery.ShowOllamaModel(ctx, client, baseURL, headers, model.APIModel)
+		cancel()
+		if err != nil {
+			logging.Warn("failed to fetch ollama model details", "model", model.APIModel, "error", err)
+			continue
+		}
+		if info.ContextLength > 0 {
+			model.ContextWindow = info.ContextLength
+		}
+		model.CanReason = slices.Contains(info.Capabilities, "thinking")
+		// Older servers do not report capabilities
+		if len(info.Capabilities) > 0 {
+			capabilities := models.ModelCapabilities(model)
+			capabilities.SupportsTools = slices.Contains(info.Capabilities, "tools")
+			capabilities.SupportsVision = slices.Contains(info.Capabilities, "vision")
+			model = model.WithCapabilities(capabilities)
+		}
+		models.RegisterOllamaModels(map[models.ModelID]models.Model{id: model})
+	}
+}
+
+// customOllamaModels returns the models defined in providers.ollama.models.
+func customOllamaModels() (map[models.ModelID]models.Model, error) {
+	var configured []CustomModel
+	if err := viper.UnmarshalKey("providers.ollama.models", &configured); err != nil {
+		return nil, err
+	}
+
+	custom := make(map[models.ModelID]models.Model, len(configured))
+	for _, c := range configured {
+		if c.Name == "" {
+			logging.Warn("ignoring ollama model without a name")
+			continue
+		}
+		apiModel := c.APIModel
+		if apiModel == "" {
+			apiModel = c.Name
+		}
+		model := models.OllamaModel(apiModel)
+		model.ID = models.OllamaModelID(c.Name)
+		model.Name = "Ollama: " + c.Name
+		model.CanReason = c.CanReason
+		if c.ContextWindow > 0 {
+			model.ContextWindow = c.ContextWindow
+		}
+		if c.MaxTokens > 0 {
+			model.DefaultMaxTokens = c.MaxTokens
+		}
+		model.Sampling = c.sampling()
+		model = c.withCapabilities(model)
+		custom[model.ID] = model
+	}
+	return custom, nil
+}
+
+func ollamaModelFromID(id models.ModelID) models.Model {
+	return models.OllamaModel(strings.TrimPrefix(string(id), string(models.ProviderOllama)+"."))
+}
+
+// CheckOllama verifies that the Ollama server is reachable and serves the model,
+// so problems surface before the first prompt instead of mid-conversation.
+// Models that are offered for pulling are not reported.
+func CheckOllama(ctx context.Context, id models.ModelID) error {
+	client, baseURLs, headers, err := ollamaConnection()
+	if err != nil {
+		return err
+	}
+	baseURL, err := reachableOllamaHost(ctx, client, baseURLs, headers)
+	if err != nil {
+		return err
+	}
+
+	discovered, err := discovery.FetchOllamaModels(ctx, client, baseURL, headers)
+	if err != nil {
+		return err
+	}
+	model, ok := models.SupportedModels[id]
+	if !ok {
+		model = ollamaModelFromID(id)
+	}
+	if _, ok := discovered[models.OllamaModelID(model.APIModel)]; ok {
+		return nil
+	}
+
+	missingOllamaModelsMu.Lock()
+	_, pending := missingOllamaModels[id]
+	missingOllamaModelsMu.Unlock()
+	if pending {
+		return nil
+	}
+	name := model.APIModel
+	return fmt.Errorf("model %s is not available on the Ollama server, pull it with `ollama pull %s`", name, name)
+}
+
+// MissingOllamaModels returns the configured Ollama models that still need to
+// be pulled on the server.
+func MissingOllamaModels() []models.Model {
+	missingOllamaModelsMu.Lock()
+	defer missingOllamaModelsMu.Unlock()
+
+	missing := make([]models.Model, 0, len(missingOllamaModels))
+	for _, model := range missingOllamaModels {
+		missing = append(missing, model)
+	}
+	sort.Slice(missing, func(i, j int) bool {
+		return missing[i].ID < missing[j].ID
+	})
+	return missing
+}
+
+// PullOllamaModel pulls a model on the configured Ollama server, reporting the
+// download progress through the progress callback.
+func PullOllamaModel(ctx context.Context, model models.Model, progress func(discovery.OllamaPullProgress)) error {
+	client, baseURLs, headers, err := ollamaConnection()
+	if err != nil {
+		return err
+	}
+	baseURL, err := reachableOllamaHost(ctx, client, baseURLs, headers)
+	if err != nil {
+		return err
+	}
+	if err := discovery.PullOllamaModel(ctx, client, baseURL, headers, model.APIModel, progress); err != nil {
+		return err
+	}
+
+	missingOllamaModelsMu.Lock()
+	delete(missingOllamaModels, model.ID)
+	missingOllamaModelsMu.Unlock()
+	return nil
+}
@@ -0,0 +1,156 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/opencode-ai/opencode/internal/llm/discovery"
+	"github.com/opencode-ai/opencode/internal/llm/models"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// withOllamaServer configures an Ollama server answering with the handler. The
+// configuration and the Ollama models registered by the test are removed after
+// it.
+func withOllamaServer(t *testing.T, handler http.HandlerFunc) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	viper.Set("providers.ollama.baseURL", server.URL)
+	t.Cleanup(func() {
+		viper.Reset()
+		for id := range models.OllamaModels {
+			delete(models.SupportedModels, id)
+			delete(models.OllamaModels, id)
+		}
+		missingOllamaModelsMu.Lock()
+		clear(missingOllamaModels)
+		missingOllamaModelsMu.Unlock()
+	})
+	return server
+}
+
+// ollamaHandler answers like an Ollama server serving the tags.
+func ollamaHandler(t *testing.T, tags string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/version":
+			fmt.Fprint(w, `{"version":"0.6.2"}`)
+		case "/api/tags":
+			fmt.Fprint(w, tags)
+		default:
+			t.Errorf("unexpected request to %s", r.URL.Path)
+			http.NotFound(w, r)
+		}
+	}
+}
+
+func TestCheckOllama(t *testing.T) {
+	tags := `{"models":[{"name":"qwen2.5-coder:32b","model":"qwen2.5-coder:32b"}]}`
+
+	t.Run("served model", func(t *testing.T) {
+		withOllamaServer(t, ollamaHandler(t, tags))
+		assert.NoError(t, CheckOllama(context.Background(), "ollama.qwen2.5-coder:32b"))
+	})
+
+	t.Run("missing model", func(t *testing.T) {
+		withOllamaServer(t, ollamaHandler(t, tags))
+		err := CheckOllama(context.Background(), "ollama.llama3.1")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "pull it with `ollama pull llama3.1`")
+	})
+
+	t.Run("model offered for pulling", func(t *testing.T) {
+		withOllamaServer(t, ollamaHandler(t, tags))
+		missingOllamaModels["ollama.llama3.1"] = models.OllamaModel("llama3.1")
+		assert.NoError(t, CheckOllama(context.Background(), "ollama.llama3.1"))
+	})
+
+	t.Run("server not running", func(t *testing.T) {
+		server := withOllamaServer(t, ollamaHandler(t, tags))
+		server.Close()
+		err := CheckOllama(context.Background(), "ollama.qwen2.5-coder:32b")
+		var unavailable *discovery.OllamaUnavailableError
+		assert.True(t, errors.As(err, &unavailable), "got %v", err)
+	})
+}
+
+func TestDiscoverOllamaModelDetails(t *testing.T) {
+	tags := `{"models":[{"name":"qwen3:8b","model":"qwen3:8b"},{"name":"llama3.1:latest","model":"llama3.1:latest"}]}`
+	withOllamaServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/show" {
+			ollamaHandler(t, tags)(w, r)
+			return
+		}
+		var request struct {
+			Model string `json:"model"`
+		}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+		switch request.Model {
+		case "qwen3:8b":
+			fmt.Fprint(w, `{"model_info":{"general.architecture":"qwen3","qwen3.context_length":40960},"capabilities":["completion","tools","thinking"]}`)
+		default:
+			http.Error(w, "model not found", http.StatusNotFound)
+		}
+	})
+	viper.Set("agents.coder.model", "ollama.qwen3:8b")
+	viper.Set("agents.task.model", "ollama.llama3.1")
+
+	discoverOllamaModels()
+
+	// The details of the models of the agents are read from the server
+	qwen := models.SupportedModels["ollama.qwen3:8b"]
+	assert.Equal(t, int64(40960), qwen.ContextWindow)
+	assert.True(t, qwen.CanReason)
+	capabilities := models.ModelCapabilities(qwen)
+	assert.True(t, capabilities.SupportsTools)
+	assert.False(t, capabilities.SupportsVision)
+
+	// The defaults are kept when the server doesn't describe the model
+	llama := models.SupportedModels["ollama.llama3.1"]
+	assert.Equal(t, int64(8192), llama.ContextWindow)
+	assert.False(t, llama.CanReason)
+}
+
+func TestCustomOllamaModels(t *testing.T) {
+	withOllamaServer(t, ollamaHandler(t, `{"models":[{"name":"qwen2.5-coder:32b","model":"qwen2.5-coder:32b"}]}`))
+	viper.Set("providers.ollama.models", []map[string]any{
+		{"name": "coder-long", "apiModel": "qwen2.5-coder:32b", "contextWindow": 32768, "maxTokens": 8192, "temperature": 0.2},
+		{"name": "vision", "apiModel": "llava", "vision": true, "noTools": true},
+		{"apiModel": "nameless"},
+	})
+	viper.Set("agents.coder.model", "ollama.coder-long")
+	viper.Set("agents.task.model", "ollama.vision")
+
+	custom, err := customOllamaModels()
+	require.NoError(t, err)
+	require.Len(t, custom, 2)
+	long := custom["ollama.coder-long"]
+	assert.Equal(t, "Ollama: coder-long", long.Name)
+	assert.Equal(t, "qwen2.5-coder:32b", long.APIModel)
+	assert.Equal(t, int64(32768), long.ContextWindow)
+	assert.Equal(t, int64(8192), long.DefaultMaxTokens)
+	require.NotNil(t, long.Sampling.Temperature)
+	assert.Equal(t, 0.2, *long.Sampling.Temperature)
+	vision := models.ModelCapabilities(custom["ollama.vision"])
+	assert.False(t, vision.SupportsTools)
+	assert.True(t, vision.SupportsVision)
+
+	discoverOllamaModels()
+
+	// The custom models take precedence over the discovered ones, those whose
+	// model isn't pulled are offered for pulling
+	assert.Equal(t, long, models.SupportedModels["ollama.coder-long"])
+	assert.Contains(t, models.SupportedModels, models.ModelID("ollama.qwen2.5-coder:32b"))
+	missing := MissingOllamaModels()
+	require.Len(t, missing, 1)
+	assert.Equal(t, models.ModelID("ollama.vision"), missing[0].ID)
+	assert.Equal(t, "llava", missing[0].APIModel)
+}
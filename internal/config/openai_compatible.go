@@ -0,0 +1,64 @@
+package config
+
+import (
+	"sort"
+
+	"github.com/opencode-ai/opencode/internal/llm/models"
+	"github.com/opencode-ai/opencode/internal/logging"
+	"github.com/spf13/viper"
+)
+
+// registerOpenAICompatibleModels registers the models configured for the
+// OpenAI compatible provider, which has no model catalog of its own.
+func registerOpenAICompatibleModels() {
+	key := "providers." + string(models.ProviderOpenAICompatible)
+	if !viper.IsSet(key) || viper.GetBool(key+".disabled") {
+		return
+	}
+	if viper.GetString(key+".baseURL") == "" {
+		logging.Warn("openai-compatible provider has no baseURL, ignoring its models")
+		return
+	}
+
+	var configured []CustomModel
+	if err := viper.UnmarshalKey(key+".models", &configured); err != nil {
+		logging.Warn("invalid openai-compatible models configuration", "error", err)
+		return
+	}
+
+	registered := make(map[models.ModelID]models.Model, len(configured))
+	for _, c := range configured {
+		if c.Name == "" {
+			logging.Warn("ignoring openai-compatible model without a name")
+			continue
+		}
+		apiModel := c.APIModel
+		if apiModel == "" {
+			apiModel = c.Name
+		}
+		model := models.OpenAICompatibleModel(apiModel)
+		model.ID = models.OpenAICompatibleModelID(c.Name)
+		model.Name = "OpenAI Compatible: " + c.Name
+		model.CanReason = c.CanReason
+		if c.ContextWindow > 0 {
+			model.ContextWindow = c.ContextWindow
+		}
+		if c.MaxTokens > 0 {
+			model.DefaultMaxTokens = c.MaxTokens
+		}
+		model = c.withCapabilities(model)
+		registered[model.ID] = model
+	}
+	models.RegisterOpenAICompatibleModels(registered)
+
+	if len(registered) > 0 && !viper.IsSet("agents.coder.model") {
+		ids := make([]string, 0, len(registered))
+		for id := range registered {
+			ids = append(ids, string(id))
+		}
+		sort.Strings(ids)
+		viper.SetDefault("agents.coder.model", ids[0])
+		viper.SetDefault("agents.task.model", ids[0])
+		viper.SetDefault("agents.title.model", ids[0])
+	}
+}
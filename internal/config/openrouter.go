@@ -0,0 +1,114 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/opencode-ai/opencode/internal/llm/discovery"
+	"github.com/opencode-ai/opencode/internal/llm/models"
+	"github.com/opencode-ai/opencode/internal/logging"
+	"github.com/spf13/viper"
+)
+
+// openrouterCatalogTTL is how long the downloaded OpenRouter catalog is used
+// before it is fetched again.
+const openrouterCatalogTTL = 24 * time.Hour
+
+const (
+	openrouterDefaultModel      = "anthropic/claude-3.7-sonnet"
+	openrouterDefaultSmallModel = "anthropic/claude-3.5-haiku"
+)
+
+// discoverOpenRouterModels registers the models of the OpenRouter catalog
+// with their pricing. The catalog is cached in the data directory, a stale
+// cache is still used when OpenRouter cannot be reached.
+func discoverOpenRouterModels() {
+	key := "providers." + string(models.ProviderOpenRouter)
+	if viper.GetBool(key + ".disabled") {
+		return
+	}
+	configured := configuredModels(models.ProviderOpenRouter)
+	if !viper.IsSet(key) && os.Getenv("OPENROUTER_API_KEY") == "" && len(configured) == 0 {
+		return
+	}
+
+	cachePath := filepath.Join(viper.GetString("data.directory"), "openrouter-models.json")
+	catalog, fresh := readOpenRouterCatalog(cachePath)
+	if !fresh {
+		if fetched, err := fetchOpenRouterCatalog(cachePath); err != nil {
+			logging.Warn("failed to fetch openrouter models", "error", err)
+		} else {
+			catalog = fetched
+		}
+	}
+
+	registered := make(map[models.ModelID]models.Model, len(configured))
+	// Keep the configured models, the catalog may be unavailable
+	for _, id := range configured {
+		registered[id] = models.OpenRouterModel(strings.TrimPrefix(string(id), string(models.ProviderOpenRouter)+"."))
+	}
+	if catalog != nil {
+		for id, model := range catalog.Models() {
+			registered[id] = model
+		}
+	}
+	models.RegisterOpenRouterModels(registered)
+
+	coder := models.OpenRouterModelID(openrouterDefaultModel)
+	small := models.OpenRouterModelID(openrouterDefaultSmallModel)
+	if _, ok := models.OpenRouterModels[coder]; ok && viper.GetString(key+".apiKey") != "" && !viper.IsSet("agents.coder.model") {
+		viper.SetDefault("agents.coder.model", coder)
+		viper.SetDefault("agents.task.model", coder)
+		if _, ok := models.OpenRouterModels[small]; ok {
+			viper.SetDefault("agents.title.model", small)
+		} else {
+			viper.SetDefault("agents.title.model", coder)
+		}
+	}
+}
+
+// readOpenRouterCatalog reads the cached catalog and reports whether it is
+// recent enough to be used without fetching it again.
+func readOpenRouterCatalog(path string) (*discovery.OpenRouterCatalog, bool) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, false
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+	var catalog discovery.OpenRouterCatalog
+	if err := json.Unmarshal(data, &catalog); err != nil {
+		logging.Warn("ignoring invalid openrouter model cache", "path", path, "error", err)
+		return nil, false
+	}
+	return &catalog, time.Since(info.ModTime()) < openrouterCatalogTTL
+}
+
+func fetchOpenRouterCatalog(cachePath string) (*discovery.OpenRouterCatalog, error) {
+	client, err := localProviderClient(models.ProviderOpenRouter)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	catalog, err := discovery.FetchOpenRouterCatalog(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+
+	if data, err := json.Marshal(catalog); err == nil {
+		if err := os.MkdirAll(filepath.Dir(cachePath), 0o755); err == nil {
+			err = os.WriteFile(cachePath, data, 0o644)
+		}
+		if err != nil {
+			logging.Warn("failed to cache openrouter models", "error", err)
+		}
+	}
+	return catalog, nil
+}
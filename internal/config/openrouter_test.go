@@ -0,0 +1,37 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/opencode-ai/opencode/internal/llm/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadOpenRouterCatalog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "openrouter-models.json")
+	catalog, fresh := readOpenRouterCatalog(path)
+	assert.Nil(t, catalog)
+	assert.False(t, fresh)
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"data":[{"id":"anthropic/claude-3.7-sonnet","pricing":{"prompt":"0.000003","completion":"0.000015"}}]}`), 0o644))
+	catalog, fresh = readOpenRouterCatalog(path)
+	require.NotNil(t, catalog)
+	assert.True(t, fresh)
+	assert.Contains(t, catalog.Models(), models.OpenRouterModelID("anthropic/claude-3.7-sonnet"))
+
+	// A stale catalog is still returned, for when OpenRouter can't be reached
+	stale := time.Now().Add(-openrouterCatalogTTL - time.Minute)
+	require.NoError(t, os.Chtimes(path, stale, stale))
+	catalog, fresh = readOpenRouterCatalog(path)
+	assert.NotNil(t, catalog)
+	assert.False(t, fresh)
+
+	require.NoError(t, os.WriteFile(path, []byte("not json"), 0o644))
+	catalog, fresh = readOpenRouterCatalog(path)
+	assert.Nil(t, catalog)
+	assert.False(t, fresh)
+}
@@ -0,0 +1,34 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// validateSandbox checks that the sandbox of the bash tool has a known backend
+// with what it needs.
+func validateSandbox(s Sandbox) error {
+	switch s.Backend {
+	case "":
+		if s.Image != "" || s.Network || len(s.WritablePaths) > 0 || s.AutoApprove {
+			return fmt.Errorf("invalid sandbox configuration: a backend is required")
+		}
+		return nil
+	case SandboxBubblewrap, SandboxFirejail:
+		if s.Image != "" {
+			return fmt.Errorf("invalid sandbox configuration: image is only used by the docker backend")
+		}
+	case SandboxDocker:
+		if s.Image == "" {
+			return fmt.Errorf("invalid sandbox configuration: the docker backend needs an image")
+		}
+	default:
+		return fmt.Errorf("invalid sandbox configuration: unknown backend %q, must be one of: bubblewrap, firejail, docker", s.Backend)
+	}
+	for _, path := range s.WritablePaths {
+		if !filepath.IsAbs(path) {
+			return fmt.Errorf("invalid sandbox configuration: writable path %q must be absolute", path)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package config
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/opencode-ai/opencode/internal/llm/discovery"
+	"github.com/opencode-ai/opencode/internal/llm/models"
+	"github.com/opencode-ai/opencode/internal/logging"
+	"github.com/spf13/viper"
+)
+
+// discoverTogetherModels registers the chat models served by Together AI with
+// their pricing, next to the built-in ones. It needs an API key to list them.
+func discoverTogetherModels() {
+	key := "providers." + string(models.ProviderTogether)
+	if viper.GetBool(key + ".disabled") {
+		return
+	}
+
+	// Keep the configured models, they may be missing from the listing
+	registered := make(map[models.ModelID]models.Model)
+	for _, id := range configuredModels(models.ProviderTogether) {
+		if _, ok := models.TogetherModels[id]; !ok {
+			registered[id] = models.TogetherModel(strings.TrimPrefix(string(id), string(models.ProviderTogether)+"."))
+		}
+	}
+	defer models.RegisterTogetherModels(registered)
+
+	apiKey := viper.GetString(key + ".apiKey")
+	if apiKey == "" {
+		return
+	}
+	client, err := localProviderClient(models.ProviderTogether)
+	if err != nil {
+		logging.Warn("failed to configure together client", "error", err)
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	discovered, err := discovery.FetchTogetherModels(ctx, client, apiKey)
+	if err != nil {
+		logging.Warn("failed to discover together models", "error", err)
+		return
+	}
+	for id, model := range discovered {
+		registered[id] = model
+	}
+}
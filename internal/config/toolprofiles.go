@@ -0,0 +1,147 @@
+package config
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Built-in tool profiles, the configuration can replace them
+const (
+	// ToolProfileAll offers all the tools
+	ToolProfileAll = "all"
+	// ToolProfileReadOnly offers the tools that read the project without
+	// changing it or running commands
+	ToolProfileReadOnly = "readonly"
+)
+
+var builtinToolProfiles = map[string]ToolProfile{
+	ToolProfileAll: {},
+	ToolProfileReadOnly: {
+		Allow: []string{
+			"agent",
+			"dependencies",
+			"diagnostics",
+			"fetch",
+			"glob",
+			"grep",
+			"ls",
+			"notes",
+			"output",
+			"sourcegraph",
+			"symbols",
+			"todo",
+			"view",
+			"websearch",
+		},
+	},
+}
+
+// Allows reports whether the profile offers the tool.
+func (p ToolProfile) Allows(tool string) bool {
+	if len(p.Allow) > 0 && !matchesToolPattern(p.Allow, tool) {
+		return false
+	}
+	return !matchesToolPattern(p.Deny, tool)
+}
+
+func matchesToolPattern(patterns []string, tool string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, tool); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// validateToolProfiles checks that the patterns of the tool profiles are
+// valid and that the default profile exists.
+func validateToolProfiles(profiles map[string]ToolProfile, defaultProfile string) error {
+	for name, profile := range profiles {
+		if name == "" || strings.ContainsAny(name, " \t") {
+			return fmt.Errorf("invalid tool profile %q: names can't be empty or have spaces", name)
+		}
+		for _, pattern := range append(slices.Clone(profile.Allow), profile.Deny...) {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("invalid tool profile %s: pattern %q: %w", name, pattern, err)
+			}
+		}
+	}
+	if defaultProfile != "" {
+		if _, ok := lookupToolProfile(profiles, defaultProfile); !ok {
+			return fmt.Errorf("unknown tool profile %q, must be one of %s", defaultProfile, strings.Join(toolProfileNames(profiles), ", "))
+		}
+	}
+	return nil
+}
+
+func lookupToolProfile(profiles map[string]ToolProfile, name string) (ToolProfile, bool) {
+	if profile, ok := profiles[name]; ok {
+		return profile, true
+	}
+	profile, ok := builtinToolProfiles[name]
+	return profile, ok
+}
+
+func toolProfileNames(profiles map[string]ToolProfile) []string {
+	names := []string{ToolProfileAll, ToolProfileReadOnly}
+	var configured []string
+	for name := range profiles {
+		if _, ok := builtinToolProfiles[name]; !ok {
+			configured = append(configured, name)
+		}
+	}
+	sort.Strings(configured)
+	return append(names, configured...)
+}
+
+// ToolProfileNames returns the names of the built-in and the configured tool
+// profiles.
+func ToolProfileNames() []string {
+	if cfg == nil {
+		return toolProfileNames(nil)
+	}
+	return toolProfileNames(cfg.ToolProfiles)
+}
+
+// DefaultToolProfile returns the name of the profile of the sessions without
+// their own.
+func DefaultToolProfile() string {
+	if cfg == nil || cfg.ToolProfile == "" {
+		return ToolProfileAll
+	}
+	return cfg.ToolProfile
+}
+
+// GetToolProfile returns the tool profile, the default one when the name is
+// empty. Unknown profiles, like those removed from the configuration after a
+// session used them, offer no tools.
+func GetToolProfile(name string) (ToolProfile, bool) {
+	if name == "" {
+		name = DefaultToolProfile()
+	}
+	var profiles map[string]ToolProfile
+	if cfg != nil {
+		profiles = cfg.ToolProfiles
+	}
+	profile, ok := lookupToolProfile(profiles, name)
+	if !ok {
+		return ToolProfile{Deny: []string{"*"}}, false
+	}
+	return profile, true
+}
+
+// SetDefaultToolProfile changes the profile of the sessions without their own
+// for the rest of the run, the configuration file is not changed.
+func SetDefaultToolProfile(name string) error {
+	if cfg == nil {
+		return fmt.Errorf("config not loaded")
+	}
+	if _, ok := lookupToolProfile(cfg.ToolProfiles, name); !ok {
+		return fmt.Errorf("unknown tool profile %q, must be one of %s", name, strings.Join(ToolProfileNames(), ", "))
+	}
+	cfg.ToolProfile = name
+	return nil
+}
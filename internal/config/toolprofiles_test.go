@@ -0,0 +1,94 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToolProfileAllows(t *testing.T) {
+	tests := []struct {
+		name    string
+		profile ToolProfile
+		allowed []string
+		denied  []string
+	}{
+		{
+			name:    "all tools",
+			profile: ToolProfile{},
+			allowed: []string{"bash", "edit", "github_create_issue"},
+		},
+		{
+			name:    "allowed tools",
+			profile: ToolProfile{Allow: []string{"view", "grep"}},
+			allowed: []string{"view", "grep"},
+			denied:  []string{"edit", "bash", "viewer"},
+		},
+		{
+			name:    "denied tools",
+			profile: ToolProfile{Deny: []string{"bash"}},
+			allowed: []string{"view", "edit"},
+			denied:  []string{"bash"},
+		},
+		{
+			name:    "wildcards",
+			profile: ToolProfile{Allow: []string{"view", "github_*"}, Deny: []string{"github_delete_*"}},
+			allowed: []string{"view", "github_list_issues"},
+			denied:  []string{"edit", "github_delete_repo", "gitlab_list_issues"},
+		},
+		{
+			name:    "deny wins over allow",
+			profile: ToolProfile{Allow: []string{"*"}, Deny: []string{"edit", "write"}},
+			allowed: []string{"view"},
+			denied:  []string{"edit", "write"},
+		},
+		{
+			name:    "read only",
+			profile: builtinToolProfiles[ToolProfileReadOnly],
+			allowed: []string{"view", "grep", "glob", "ls", "agent"},
+			denied:  []string{"bash", "edit", "write", "patch", "sql", "github_create_issue"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, tool := range tt.allowed {
+				assert.True(t, tt.profile.Allows(tool), tool)
+			}
+			for _, tool := range tt.denied {
+				assert.False(t, tt.profile.Allows(tool), tool)
+			}
+		})
+	}
+}
+
+func TestGetToolProfile(t *testing.T) {
+	loaded := cfg
+	t.Cleanup(func() { cfg = loaded })
+
+	review := ToolProfile{Allow: []string{"view", "grep"}}
+	strictReadOnly := ToolProfile{Allow: []string{"view"}}
+	denyAll := ToolProfile{Deny: []string{"*"}}
+	tests := []struct {
+		name    string
+		cfg     *Config
+		profile string
+		want    ToolProfile
+		ok      bool
+	}{
+		{name: "not loaded", cfg: nil, profile: "", want: ToolProfile{}, ok: true},
+		{name: "default", cfg: &Config{}, profile: "", want: ToolProfile{}, ok: true},
+		{name: "configured default", cfg: &Config{ToolProfiles: map[string]ToolProfile{"review": review}, ToolProfile: "review"}, profile: "", want: review, ok: true},
+		{name: "built-in", cfg: &Config{}, profile: ToolProfileReadOnly, want: builtinToolProfiles[ToolProfileReadOnly], ok: true},
+		{name: "configured", cfg: &Config{ToolProfiles: map[string]ToolProfile{"review": review}}, profile: "review", want: review, ok: true},
+		{name: "replaced built-in", cfg: &Config{ToolProfiles: map[string]ToolProfile{ToolProfileReadOnly: strictReadOnly}}, profile: ToolProfileReadOnly, want: strictReadOnly, ok: true},
+		{name: "unknown", cfg: &Config{}, profile: "removed", want: denyAll, ok: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg = tt.cfg
+			profile, ok := GetToolProfile(tt.profile)
+			assert.Equal(t, tt.want, profile)
+			assert.Equal(t, tt.ok, ok)
+		})
+	}
+}
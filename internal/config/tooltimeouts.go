@@ -0,0 +1,60 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// DefaultToolTimeout is the key of the toolTimeouts of all tools without their
+// own.
+const DefaultToolTimeout = "default"
+
+// defaultToolTimeouts limit the tool calls when toolTimeouts doesn't, 0 is no
+// limit. Sub-agents run as long as their own tool calls take.
+var defaultToolTimeouts = map[string]time.Duration{
+	DefaultToolTimeout: 5 * time.Minute,
+	"agent":            0,
+	"bash":             2 * time.Minute,
+	"browser":          time.Minute,
+	"container":        2 * time.Minute,
+	"fetch":            30 * time.Second,
+	"http":             30 * time.Second,
+	"notebook":         10 * time.Minute,
+	"sourcegraph":      30 * time.Second,
+	"test":             10 * time.Minute,
+	"websearch":        30 * time.Second,
+}
+
+// validateToolTimeouts checks that the toolTimeouts are durations.
+func validateToolTimeouts(timeouts map[string]string) error {
+	for name, timeout := range timeouts {
+		d, err := time.ParseDuration(timeout)
+		if err != nil {
+			return fmt.Errorf("invalid timeout %q for tool %s: %w", timeout, name, err)
+		}
+		if d < 0 {
+			return fmt.Errorf("invalid timeout %q for tool %s: must not be negative", timeout, name)
+		}
+	}
+	return nil
+}
+
+// ToolTimeout returns how long a call of the tool may run, 0 is no limit.
+func ToolTimeout(name string) time.Duration {
+	var timeouts map[string]string
+	if cfg != nil {
+		timeouts = cfg.ToolTimeouts
+	}
+	if timeout, ok := timeouts[name]; ok {
+		d, _ := time.ParseDuration(timeout)
+		return d
+	}
+	if d, ok := defaultToolTimeouts[name]; ok {
+		return d
+	}
+	if timeout, ok := timeouts[DefaultToolTimeout]; ok {
+		d, _ := time.ParseDuration(timeout)
+		return d
+	}
+	return defaultToolTimeouts[DefaultToolTimeout]
+}
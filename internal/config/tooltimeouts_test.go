@@ -0,0 +1,41 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToolTimeout(t *testing.T) {
+	previous := cfg
+	t.Cleanup(func() { cfg = previous })
+
+	cfg = nil
+	assert.Equal(t, 2*time.Minute, ToolTimeout("bash"))
+	assert.Equal(t, 30*time.Second, ToolTimeout("fetch"))
+	assert.Equal(t, 5*time.Minute, ToolTimeout("view"))
+	// Sub-agents are not limited
+	assert.Zero(t, ToolTimeout("agent"))
+
+	cfg = &Config{ToolTimeouts: map[string]string{
+		"bash":             "10m",
+		"agent":            "30m",
+		"grep":             "0s",
+		DefaultToolTimeout: "1m",
+	}}
+	assert.Equal(t, 10*time.Minute, ToolTimeout("bash"))
+	assert.Equal(t, 30*time.Minute, ToolTimeout("agent"))
+	assert.Zero(t, ToolTimeout("grep"))
+	// The tools with a default of their own keep it
+	assert.Equal(t, 30*time.Second, ToolTimeout("fetch"))
+	assert.Equal(t, time.Minute, ToolTimeout("view"))
+	assert.Equal(t, time.Minute, ToolTimeout("github_list_issues"))
+}
+
+func TestValidateToolTimeouts(t *testing.T) {
+	assert.NoError(t, validateToolTimeouts(nil))
+	assert.NoError(t, validateToolTimeouts(map[string]string{"bash": "90s", DefaultToolTimeout: "0"}))
+	assert.ErrorContains(t, validateToolTimeouts(map[string]string{"bash": "90"}), `invalid timeout "90" for tool bash`)
+	assert.ErrorContains(t, validateToolTimeouts(map[string]string{"fetch": "-1s"}), "must not be negative")
+}
@@ -0,0 +1,29 @@
+package config
+
+import "fmt"
+
+// validateWebSearch checks that the engine of the websearch tool has what it
+// needs to send requests.
+func validateWebSearch(w WebSearch) error {
+	if w.Disabled {
+		return nil
+	}
+	switch w.Engine {
+	case WebSearchDuckDuckGo, "":
+	case WebSearchSearxNG:
+		if w.BaseURL == "" {
+			return fmt.Errorf("invalid webSearch configuration: the searxng engine needs the baseURL of an instance")
+		}
+	case WebSearchBrave:
+		if w.APIKey == "" {
+			return fmt.Errorf("invalid webSearch configuration: the brave engine needs an apiKey")
+		}
+	case WebSearchGoogle:
+		if w.APIKey == "" || w.SearchEngineID == "" {
+			return fmt.Errorf("invalid webSearch configuration: the google engine needs an apiKey and a searchEngineID")
+		}
+	default:
+		return fmt.Errorf("invalid webSearch configuration: unknown engine %q", w.Engine)
+	}
+	return nil
+}
@@ -24,6 +24,12 @@ func New(db DBTX) *Queries {
 func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 	q := Queries{db: db}
 	var err error
+	if q.addSessionUsageStmt, err = db.PrepareContext(ctx, addSessionUsage); err != nil {
+		return nil, fmt.Errorf("error preparing query AddSessionUsage: %w", err)
+	}
+	if q.createCheckpointStmt, err = db.PrepareContext(ctx, createCheckpoint); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateCheckpoint: %w", err)
+	}
 	if q.createFileStmt, err = db.PrepareContext(ctx, createFile); err != nil {
 		return nil, fmt.Errorf("error preparing query CreateFile: %w", err)
 	}
@@ -33,12 +39,24 @@ func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 	if q.createSessionStmt, err = db.PrepareContext(ctx, createSession); err != nil {
 		return nil, fmt.Errorf("error preparing query CreateSession: %w", err)
 	}
+	if q.createTodoStmt, err = db.PrepareContext(ctx, createTodo); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateTodo: %w", err)
+	}
+	if q.createUsageStmt, err = db.PrepareContext(ctx, createUsage); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateUsage: %w", err)
+	}
+	if q.deleteCheckpointsAfterStmt, err = db.PrepareContext(ctx, deleteCheckpointsAfter); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteCheckpointsAfter: %w", err)
+	}
 	if q.deleteFileStmt, err = db.PrepareContext(ctx, deleteFile); err != nil {
 		return nil, fmt.Errorf("error preparing query DeleteFile: %w", err)
 	}
 	if q.deleteMessageStmt, err = db.PrepareContext(ctx, deleteMessage); err != nil {
 		return nil, fmt.Errorf("error preparing query DeleteMessage: %w", err)
 	}
+	if q.deleteNoteStmt, err = db.PrepareContext(ctx, deleteNote); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteNote: %w", err)
+	}
 	if q.deleteSessionStmt, err = db.PrepareContext(ctx, deleteSession); err != nil {
 		return nil, fmt.Errorf("error preparing query DeleteSession: %w", err)
 	}
@@ -48,6 +66,12 @@ func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 	if q.deleteSessionMessagesStmt, err = db.PrepareContext(ctx, deleteSessionMessages); err != nil {
 		return nil, fmt.Errorf("error preparing query DeleteSessionMessages: %w", err)
 	}
+	if q.deleteSessionTodosStmt, err = db.PrepareContext(ctx, deleteSessionTodos); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteSessionTodos: %w", err)
+	}
+	if q.getCheckpointStmt, err = db.PrepareContext(ctx, getCheckpoint); err != nil {
+		return nil, fmt.Errorf("error preparing query GetCheckpoint: %w", err)
+	}
 	if q.getFileStmt, err = db.PrepareContext(ctx, getFile); err != nil {
 		return nil, fmt.Errorf("error preparing query GetFile: %w", err)
 	}
@@ -57,9 +81,15 @@ func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 	if q.getMessageStmt, err = db.PrepareContext(ctx, getMessage); err != nil {
 		return nil, fmt.Errorf("error preparing query GetMessage: %w", err)
 	}
+	if q.getNoteStmt, err = db.PrepareContext(ctx, getNote); err != nil {
+		return nil, fmt.Errorf("error preparing query GetNote: %w", err)
+	}
 	if q.getSessionByIDStmt, err = db.PrepareContext(ctx, getSessionByID); err != nil {
 		return nil, fmt.Errorf("error preparing query GetSessionByID: %w", err)
 	}
+	if q.listCheckpointsStmt, err = db.PrepareContext(ctx, listCheckpoints); err != nil {
+		return nil, fmt.Errorf("error preparing query ListCheckpoints: %w", err)
+	}
 	if q.listFilesByPathStmt, err = db.PrepareContext(ctx, listFilesByPath); err != nil {
 		return nil, fmt.Errorf("error preparing query ListFilesByPath: %w", err)
 	}
@@ -75,9 +105,30 @@ func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 	if q.listNewFilesStmt, err = db.PrepareContext(ctx, listNewFiles); err != nil {
 		return nil, fmt.Errorf("error preparing query ListNewFiles: %w", err)
 	}
+	if q.listNotesStmt, err = db.PrepareContext(ctx, listNotes); err != nil {
+		return nil, fmt.Errorf("error preparing query ListNotes: %w", err)
+	}
 	if q.listSessionsStmt, err = db.PrepareContext(ctx, listSessions); err != nil {
 		return nil, fmt.Errorf("error preparing query ListSessions: %w", err)
 	}
+	if q.listTodosStmt, err = db.PrepareContext(ctx, listTodos); err != nil {
+		return nil, fmt.Errorf("error preparing query ListTodos: %w", err)
+	}
+	if q.listUsageByDayStmt, err = db.PrepareContext(ctx, listUsageByDay); err != nil {
+		return nil, fmt.Errorf("error preparing query ListUsageByDay: %w", err)
+	}
+	if q.listUsageByProviderStmt, err = db.PrepareContext(ctx, listUsageByProvider); err != nil {
+		return nil, fmt.Errorf("error preparing query ListUsageByProvider: %w", err)
+	}
+	if q.listUsageBySessionStmt, err = db.PrepareContext(ctx, listUsageBySession); err != nil {
+		return nil, fmt.Errorf("error preparing query ListUsageBySession: %w", err)
+	}
+	if q.listUsageForSessionStmt, err = db.PrepareContext(ctx, listUsageForSession); err != nil {
+		return nil, fmt.Errorf("error preparing query ListUsageForSession: %w", err)
+	}
+	if q.moveUsageToMessageStmt, err = db.PrepareContext(ctx, moveUsageToMessage); err != nil {
+		return nil, fmt.Errorf("error preparing query MoveUsageToMessage: %w", err)
+	}
 	if q.updateFileStmt, err = db.PrepareContext(ctx, updateFile); err != nil {
 		return nil, fmt.Errorf("error preparing query UpdateFile: %w", err)
 	}
@@ -87,11 +138,24 @@ func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 	if q.updateSessionStmt, err = db.PrepareContext(ctx, updateSession); err != nil {
 		return nil, fmt.Errorf("error preparing query UpdateSession: %w", err)
 	}
+	if q.upsertNoteStmt, err = db.PrepareContext(ctx, upsertNote); err != nil {
+		return nil, fmt.Errorf("error preparing query UpsertNote: %w", err)
+	}
 	return &q, nil
 }
 
 func (q *Queries) Close() error {
 	var err error
+	if q.addSessionUsageStmt != nil {
+		if cerr := q.addSessionUsageStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing addSessionUsageStmt: %w", cerr)
+		}
+	}
+	if q.createCheckpointStmt != nil {
+		if cerr := q.createCheckpointStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createCheckpointStmt: %w", cerr)
+		}
+	}
 	if q.createFileStmt != nil {
 		if cerr := q.createFileStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing createFileStmt: %w", cerr)
@@ -107,6 +171,21 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing createSessionStmt: %w", cerr)
 		}
 	}
+	if q.createTodoStmt != nil {
+		if cerr := q.createTodoStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createTodoStmt: %w", cerr)
+		}
+	}
+	if q.createUsageStmt != nil {
+		if cerr := q.createUsageStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createUsageStmt: %w", cerr)
+		}
+	}
+	if q.deleteCheckpointsAfterStmt != nil {
+		if cerr := q.deleteCheckpointsAfterStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteCheckpointsAfterStmt: %w", cerr)
+		}
+	}
 	if q.deleteFileStmt != nil {
 		if cerr := q.deleteFileStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing deleteFileStmt: %w", cerr)
@@ -117,6 +196,11 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing deleteMessageStmt: %w", cerr)
 		}
 	}
+	if q.deleteNoteStmt != nil {
+		if cerr := q.deleteNoteStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteNoteStmt: %w", cerr)
+		}
+	}
 	if q.deleteSessionStmt != nil {
 		if cerr := q.deleteSessionStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing deleteSessionStmt: %w", cerr)
@@ -132,6 +216,16 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing deleteSessionMessagesStmt: %w", cerr)
 		}
 	}
+	if q.deleteSessionTodosStmt != nil {
+		if cerr := q.deleteSessionTodosStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteSessionTodosStmt: %w", cerr)
+		}
+	}
+	if q.getCheckpointStmt != nil {
+		if cerr := q.getCheckpointStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getCheckpointStmt: %w", cerr)
+		}
+	}
 	if q.getFileStmt != nil {
 		if cerr := q.getFileStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing getFileStmt: %w", cerr)
@@ -147,11 +241,21 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing getMessageStmt: %w", cerr)
 		}
 	}
+	if q.getNoteStmt != nil {
+		if cerr := q.getNoteStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getNoteStmt: %w", cerr)
+		}
+	}
 	if q.getSessionByIDStmt != nil {
 		if cerr := q.getSessionByIDStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing getSessionByIDStmt: %w", cerr)
 		}
 	}
+	if q.listCheckpointsStmt != nil {
+		if cerr := q.listCheckpointsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listCheckpointsStmt: %w", cerr)
+		}
+	}
 	if q.listFilesByPathStmt != nil {
 		if cerr := q.listFilesByPathStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing listFilesByPathStmt: %w", cerr)
@@ -177,11 +281,46 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing listNewFilesStmt: %w", cerr)
 		}
 	}
+	if q.listNotesStmt != nil {
+		if cerr := q.listNotesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listNotesStmt: %w", cerr)
+		}
+	}
 	if q.listSessionsStmt != nil {
 		if cerr := q.listSessionsStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing listSessionsStmt: %w", cerr)
 		}
 	}
+	if q.listTodosStmt != nil {
+		if cerr := q.listTodosStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listTodosStmt: %w", cerr)
+		}
+	}
+	if q.listUsageByDayStmt != nil {
+		if cerr := q.listUsageByDayStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listUsageByDayStmt: %w", cerr)
+		}
+	}
+	if q.listUsageByProviderStmt != nil {
+		if cerr := q.listUsageByProviderStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listUsageByProviderStmt: %w", cerr)
+		}
+	}
+	if q.listUsageBySessionStmt != nil {
+		if cerr := q.listUsageBySessionStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listUsageBySessionStmt: %w", cerr)
+		}
+	}
+	if q.listUsageForSessionStmt != nil {
+		if cerr := q.listUsageForSessionStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listUsageForSessionStmt: %w", cerr)
+		}
+	}
+	if q.moveUsageToMessageStmt != nil {
+		if cerr := q.moveUsageToMessageStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing moveUsageToMessageStmt: %w", cerr)
+		}
+	}
 	if q.updateFileStmt != nil {
 		if cerr := q.updateFileStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing updateFileStmt: %w", cerr)
@@ -197,6 +336,11 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing updateSessionStmt: %w", cerr)
 		}
 	}
+	if q.upsertNoteStmt != nil {
+		if cerr := q.upsertNoteStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing upsertNoteStmt: %w", cerr)
+		}
+	}
 	return err
 }
 
@@ -236,53 +380,89 @@ func (q *Queries) queryRow(ctx context.Context, stmt *sql.Stmt, query string, ar
 type Queries struct {
 	db                          DBTX
 	tx                          *sql.Tx
+	addSessionUsageStmt         *sql.Stmt
+	createCheckpointStmt        *sql.Stmt
 	createFileStmt              *sql.Stmt
 	createMessageStmt           *sql.Stmt
 	createSessionStmt           *sql.Stmt
+	createTodoStmt              *sql.Stmt
+	createUsageStmt             *sql.Stmt
+	deleteCheckpointsAfterStmt  *sql.Stmt
 	deleteFileStmt              *sql.Stmt
 	deleteMessageStmt           *sql.Stmt
+	deleteNoteStmt              *sql.Stmt
 	deleteSessionStmt           *sql.Stmt
 	deleteSessionFilesStmt      *sql.Stmt
 	deleteSessionMessagesStmt   *sql.Stmt
+	deleteSessionTodosStmt      *sql.Stmt
+	getCheckpointStmt           *sql.Stmt
 	getFileStmt                 *sql.Stmt
 	getFileByPathAndSessionStmt *sql.Stmt
 	getMessageStmt              *sql.Stmt
+	getNoteStmt                 *sql.Stmt
 	getSessionByIDStmt          *sql.Stmt
+	listCheckpointsStmt         *sql.Stmt
 	listFilesByPathStmt         *sql.Stmt
 	listFilesBySessionStmt      *sql.Stmt
 	listLatestSessionFilesStmt  *sql.Stmt
 	listMessagesBySessionStmt   *sql.Stmt
 	listNewFilesStmt            *sql.Stmt
+	listNotesStmt               *sql.Stmt
 	listSessionsStmt            *sql.Stmt
+	listTodosStmt               *sql.Stmt
+	listUsageByDayStmt          *sql.Stmt
+	listUsageByProviderStmt     *sql.Stmt
+	listUsageBySessionStmt      *sql.Stmt
+	listUsageForSessionStmt     *sql.Stmt
+	moveUsageToMessageStmt      *sql.Stmt
 	updateFileStmt              *sql.Stmt
 	updateMessageStmt           *sql.Stmt
 	updateSessionStmt           *sql.Stmt
+	upsertNoteStmt              *sql.Stmt
 }
 
 func (q *Queries) WithTx(tx *sql.Tx) *Queries {
 	return &Queries{
 		db:                          tx,
 		tx:                          tx,
+		addSessionUsageStmt:         q.addSessionUsageStmt,
+		createCheckpointStmt:        q.createCheckpointStmt,
 		createFileStmt:              q.createFileStmt,
 		createMessageStmt:           q.createMessageStmt,
 		createSessionStmt:           q.createSessionStmt,
+		createTodoStmt:              q.createTodoStmt,
+		createUsageStmt:             q.createUsageStmt,
+		deleteCheckpointsAfterStmt:  q.deleteCheckpointsAfterStmt,
 		deleteFileStmt:              q.deleteFileStmt,
 		deleteMessageStmt:           q.deleteMessageStmt,
+		deleteNoteStmt:              q.deleteNoteStmt,
 		deleteSessionStmt:           q.deleteSessionStmt,
 		deleteSessionFilesStmt:      q.deleteSessionFilesStmt,
 		deleteSessionMessagesStmt:   q.deleteSessionMessagesStmt,
+		deleteSessionTodosStmt:      q.deleteSessionTodosStmt,
+		getCheckpointStmt:           q.getCheckpointStmt,
 		getFileStmt:                 q.getFileStmt,
 		getFileByPathAndSessionStmt: q.getFileByPathAndSessionStmt,
 		getMessageStmt:              q.getMessageStmt,
+		getNoteStmt:                 q.getNoteStmt,
 		getSessionByIDStmt:          q.getSessionByIDStmt,
+		listCheckpointsStmt:         q.listCheckpointsStmt,
 		listFilesByPathStmt:         q.listFilesByPathStmt,
 		listFilesBySessionStmt:      q.listFilesBySessionStmt,
 		listLatestSessionFilesStmt:  q.listLatestSessionFilesStmt,
 		listMessagesBySessionStmt:   q.listMessagesBySessionStmt,
 		listNewFilesStmt:            q.listNewFilesStmt,
+		listNotesStmt:               q.listNotesStmt,
 		listSessionsStmt:            q.listSessionsStmt,
+		listTodosStmt:               q.listTodosStmt,
+		listUsageByDayStmt:          q.listUsageByDayStmt,
+		listUsageByProviderStmt:     q.listUsageByProviderStmt,
+		listUsageBySessionStmt:      q.listUsageBySessionStmt,
+		listUsageForSessionStmt:     q.listUsageForSessionStmt,
+		moveUsageToMessageStmt:      q.moveUsageToMessageStmt,
 		updateFileStmt:              q.updateFileStmt,
 		updateMessageStmt:           q.updateMessageStmt,
 		updateSessionStmt:           q.updateSessionStmt,
+		upsertNoteStmt:              q.upsertNoteStmt,
 	}
 }
@@ -16,12 +16,13 @@ INSERT INTO files (
     path,
     content,
     version,
+    is_new,
     created_at,
     updated_at
 ) VALUES (
-    ?, ?, ?, ?, ?, strftime('%s', 'now'), strftime('%s', 'now')
+    ?, ?, ?, ?, ?, ?, strftime('%s', 'now'), strftime('%s', 'now')
 )
-RETURNING id, session_id, path, content, version, created_at, updated_at
+RETURNING id, session_id, path, content, version, created_at, updated_at, is_new
 `
 
 type CreateFileParams struct {
@@ -30,6 +31,7 @@ type CreateFileParams struct {
 	Path      string `json:"path"`
 	Content   string `json:"content"`
 	Version   string `json:"version"`
+	IsNew     bool   `json:"is_new"`
 }
 
 func (q *Queries) CreateFile(ctx context.Context, arg CreateFileParams) (File, error) {
@@ -39,6 +41,7 @@ func (q *Queries) CreateFile(ctx context.Context, arg CreateFileParams) (File, e
 		arg.Path,
 		arg.Content,
 		arg.Version,
+		arg.IsNew,
 	)
 	var i File
 	err := row.Scan(
@@ -49,6 +52,7 @@ func (q *Queries) CreateFile(ctx context.Context, arg CreateFileParams) (File, e
 		&i.Version,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.IsNew,
 	)
 	return i, err
 }
@@ -74,7 +78,7 @@ func (q *Queries) DeleteSessionFiles(ctx context.Context, sessionID string) erro
 }
 
 const getFile = `-- name: GetFile :one
-SELECT id, session_id, path, content, version, created_at, updated_at
+SELECT id, session_id, path, content, version, created_at, updated_at, is_new
 FROM files
 WHERE id = ? LIMIT 1
 `
@@ -90,12 +94,13 @@ func (q *Queries) GetFile(ctx context.Context, id string) (File, error) {
 		&i.Version,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.IsNew,
 	)
 	return i, err
 }
 
 const getFileByPathAndSession = `-- name: GetFileByPathAndSession :one
-SELECT id, session_id, path, content, version, created_at, updated_at
+SELECT id, session_id, path, content, version, created_at, updated_at, is_new
 FROM files
 WHERE path = ? AND session_id = ?
 ORDER BY created_at DESC
@@ -118,12 +123,13 @@ func (q *Queries) GetFileByPathAndSession(ctx context.Context, arg GetFileByPath
 		&i.Version,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.IsNew,
 	)
 	return i, err
 }
 
 const listFilesByPath = `-- name: ListFilesByPath :many
-SELECT id, session_id, path, content, version, created_at, updated_at
+SELECT id, session_id, path, content, version, created_at, updated_at, is_new
 FROM files
 WHERE path = ?
 ORDER BY created_at DESC
@@ -146,6 +152,7 @@ func (q *Queries) ListFilesByPath(ctx context.Context, path string) ([]File, err
 			&i.Version,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.IsNew,
 		); err != nil {
 			return nil, err
 		}
@@ -161,7 +168,7 @@ func (q *Queries) ListFilesByPath(ctx context.Context, path string) ([]File, err
 }
 
 const listFilesBySession = `-- name: ListFilesBySession :many
-SELECT id, session_id, path, content, version, created_at, updated_at
+SELECT id, session_id, path, content, version, created_at, updated_at, is_new
 FROM files
 WHERE session_id = ?
 ORDER BY created_at ASC
@@ -184,6 +191,7 @@ func (q *Queries) ListFilesBySession(ctx context.Context, sessionID string) ([]F
 			&i.Version,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.IsNew,
 		); err != nil {
 			return nil, err
 		}
@@ -199,7 +207,7 @@ func (q *Queries) ListFilesBySession(ctx context.Context, sessionID string) ([]F
 }
 
 const listLatestSessionFiles = `-- name: ListLatestSessionFiles :many
-SELECT f.id, f.session_id, f.path, f.content, f.version, f.created_at, f.updated_at
+SELECT f.id, f.session_id, f.path, f.content, f.version, f.created_at, f.updated_at, f.is_new
 FROM files f
 INNER JOIN (
     SELECT path, MAX(created_at) as max_created_at
@@ -227,6 +235,7 @@ func (q *Queries) ListLatestSessionFiles(ctx context.Context, sessionID string)
 			&i.Version,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.IsNew,
 		); err != nil {
 			return nil, err
 		}
@@ -242,7 +251,7 @@ func (q *Queries) ListLatestSessionFiles(ctx context.Context, sessionID string)
 }
 
 const listNewFiles = `-- name: ListNewFiles :many
-SELECT id, session_id, path, content, version, created_at, updated_at
+SELECT id, session_id, path, content, version, created_at, updated_at, is_new
 FROM files
 WHERE is_new = 1
 ORDER BY created_at DESC
@@ -265,6 +274,7 @@ func (q *Queries) ListNewFiles(ctx context.Context) ([]File, error) {
 			&i.Version,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.IsNew,
 		); err != nil {
 			return nil, err
 		}
@@ -286,7 +296,7 @@ SET
     version = ?,
     updated_at = strftime('%s', 'now')
 WHERE id = ?
-RETURNING id, session_id, path, content, version, created_at, updated_at
+RETURNING id, session_id, path, content, version, created_at, updated_at, is_new
 `
 
 type UpdateFileParams struct {
@@ -306,6 +316,7 @@ func (q *Queries) UpdateFile(ctx context.Context, arg UpdateFileParams) (File, e
 		&i.Version,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.IsNew,
 	)
 	return i, err
 }
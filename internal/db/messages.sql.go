@@ -141,17 +141,24 @@ UPDATE messages
 SET
     parts = ?,
     finished_at = ?,
+    model = ?,
     updated_at = strftime('%s', 'now')
 WHERE id = ?
 `
 
 type UpdateMessageParams struct {
-	Parts      string        `json:"parts"`
-	FinishedAt sql.NullInt64 `json:"finished_at"`
-	ID         string        `json:"id"`
+	Parts      string         `json:"parts"`
+	FinishedAt sql.NullInt64  `json:"finished_at"`
+	Model      sql.NullString `json:"model"`
+	ID         string         `json:"id"`
 }
 
 func (q *Queries) UpdateMessage(ctx context.Context, arg UpdateMessageParams) error {
-	_, err := q.exec(ctx, q.updateMessageStmt, updateMessage, arg.Parts, arg.FinishedAt, arg.ID)
+	_, err := q.exec(ctx, q.updateMessageStmt, updateMessage,
+		arg.Parts,
+		arg.FinishedAt,
+		arg.Model,
+		arg.ID,
+	)
 	return err
 }
@@ -0,0 +1,27 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Usage is kept when a session is deleted, it is the billing history
+CREATE TABLE IF NOT EXISTS usage (
+    id TEXT PRIMARY KEY,
+    session_id TEXT NOT NULL,
+    message_id TEXT,
+    provider TEXT NOT NULL,
+    model TEXT NOT NULL,
+    input_tokens INTEGER NOT NULL DEFAULT 0 CHECK (input_tokens >= 0),
+    output_tokens INTEGER NOT NULL DEFAULT 0 CHECK (output_tokens >= 0),
+    cache_creation_tokens INTEGER NOT NULL DEFAULT 0 CHECK (cache_creation_tokens >= 0),
+    cache_read_tokens INTEGER NOT NULL DEFAULT 0 CHECK (cache_read_tokens >= 0),
+    cost REAL NOT NULL DEFAULT 0.0 CHECK (cost >= 0.0),
+    created_at INTEGER NOT NULL  -- Unix timestamp in seconds
+);
+
+CREATE INDEX IF NOT EXISTS idx_usage_session_id ON usage (session_id);
+CREATE INDEX IF NOT EXISTS idx_usage_created_at ON usage (created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_usage_created_at;
+DROP INDEX IF EXISTS idx_usage_session_id;
+DROP TABLE IF EXISTS usage;
+-- +goose StatementEnd
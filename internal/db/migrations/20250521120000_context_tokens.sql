@@ -0,0 +1,10 @@
+-- +goose Up
+-- +goose StatementBegin
+-- The size of the conversation sent with the last turn, for the context meter
+ALTER TABLE sessions ADD COLUMN context_tokens INTEGER NOT NULL DEFAULT 0 CHECK (context_tokens >= 0);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE sessions DROP COLUMN context_tokens;
+-- +goose StatementEnd
@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Notes are the named notes the agent keeps for itself in a session
+CREATE TABLE IF NOT EXISTS notes (
+    id TEXT PRIMARY KEY,
+    session_id TEXT NOT NULL,
+    name TEXT NOT NULL,
+    content TEXT NOT NULL,
+    created_at INTEGER NOT NULL,  -- Unix timestamp in seconds
+    updated_at INTEGER NOT NULL,  -- Unix timestamp in seconds
+    FOREIGN KEY (session_id) REFERENCES sessions (id) ON DELETE CASCADE,
+    UNIQUE(session_id, name)
+);
+
+CREATE INDEX IF NOT EXISTS idx_notes_session_id ON notes (session_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_notes_session_id;
+DROP TABLE IF EXISTS notes;
+-- +goose StatementEnd
@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Todos are the task list the agent keeps for the job it is working on in a
+-- session, in the order of their position
+CREATE TABLE IF NOT EXISTS todos (
+    id TEXT PRIMARY KEY,
+    session_id TEXT NOT NULL,
+    position INTEGER NOT NULL,
+    content TEXT NOT NULL,
+    status TEXT NOT NULL,
+    created_at INTEGER NOT NULL,  -- Unix timestamp in seconds
+    updated_at INTEGER NOT NULL,  -- Unix timestamp in seconds
+    FOREIGN KEY (session_id) REFERENCES sessions (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_todos_session_id ON todos (session_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_todos_session_id;
+DROP TABLE IF EXISTS todos;
+-- +goose StatementEnd
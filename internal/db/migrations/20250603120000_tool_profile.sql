@@ -0,0 +1,10 @@
+-- +goose Up
+-- +goose StatementBegin
+-- The tool profile restricting the tools of the session, empty for the default
+ALTER TABLE sessions ADD COLUMN tool_profile TEXT NOT NULL DEFAULT '';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE sessions DROP COLUMN tool_profile;
+-- +goose StatementEnd
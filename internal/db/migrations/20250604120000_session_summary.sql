@@ -0,0 +1,12 @@
+-- +goose Up
+-- +goose StatementBegin
+-- The summary of the messages before summary_message_id, sent instead of them
+ALTER TABLE sessions ADD COLUMN summary TEXT NOT NULL DEFAULT '';
+ALTER TABLE sessions ADD COLUMN summary_message_id TEXT NOT NULL DEFAULT '';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE sessions DROP COLUMN summary_message_id;
+ALTER TABLE sessions DROP COLUMN summary;
+-- +goose StatementEnd
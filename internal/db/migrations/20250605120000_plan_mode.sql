@@ -0,0 +1,10 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Sessions in plan mode only get the read-only tools until a plan is approved
+ALTER TABLE sessions ADD COLUMN plan_mode BOOLEAN NOT NULL DEFAULT FALSE;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE sessions DROP COLUMN plan_mode;
+-- +goose StatementEnd
@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Checkpoints are points of a session it can be rewound to: the last message
+-- of the session then, and the latest versions of its files in the file
+-- history as a JSON object of the file IDs by path
+CREATE TABLE IF NOT EXISTS checkpoints (
+    id TEXT PRIMARY KEY,
+    session_id TEXT NOT NULL,
+    message_id TEXT NOT NULL DEFAULT '',
+    label TEXT NOT NULL,
+    auto BOOLEAN NOT NULL DEFAULT FALSE,
+    files TEXT NOT NULL DEFAULT '{}',
+    created_at INTEGER NOT NULL,  -- Unix timestamp in seconds
+    FOREIGN KEY (session_id) REFERENCES sessions (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_checkpoints_session_id ON checkpoints (session_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_checkpoints_session_id;
+DROP TABLE IF EXISTS checkpoints;
+-- +goose StatementEnd
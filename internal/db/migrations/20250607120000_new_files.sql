@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+-- is_new is set on the initial version of the files the session created,
+-- their content before the session is their absence rather than being empty
+ALTER TABLE files ADD COLUMN is_new BOOLEAN NOT NULL DEFAULT FALSE;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE files DROP COLUMN is_new;
+-- +goose StatementEnd
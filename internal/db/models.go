@@ -8,6 +8,16 @@ import (
 	"database/sql"
 )
 
+type Checkpoint struct {
+	ID        string `json:"id"`
+	SessionID string `json:"session_id"`
+	MessageID string `json:"message_id"`
+	Label     string `json:"label"`
+	Auto      bool   `json:"auto"`
+	Files     string `json:"files"`
+	CreatedAt int64  `json:"created_at"`
+}
+
 type File struct {
 	ID        string `json:"id"`
 	SessionID string `json:"session_id"`
@@ -16,6 +26,7 @@ type File struct {
 	Version   string `json:"version"`
 	CreatedAt int64  `json:"created_at"`
 	UpdatedAt int64  `json:"updated_at"`
+	IsNew     bool   `json:"is_new"`
 }
 
 type Message struct {
@@ -29,6 +40,15 @@ type Message struct {
 	FinishedAt sql.NullInt64  `json:"finished_at"`
 }
 
+type Note struct {
+	ID        string `json:"id"`
+	SessionID string `json:"session_id"`
+	Name      string `json:"name"`
+	Content   string `json:"content"`
+	CreatedAt int64  `json:"created_at"`
+	UpdatedAt int64  `json:"updated_at"`
+}
+
 type Session struct {
 	ID               string         `json:"id"`
 	ParentSessionID  sql.NullString `json:"parent_session_id"`
@@ -39,4 +59,33 @@ type Session struct {
 	Cost             float64        `json:"cost"`
 	UpdatedAt        int64          `json:"updated_at"`
 	CreatedAt        int64          `json:"created_at"`
+	ContextTokens    int64          `json:"context_tokens"`
+	ToolProfile      string         `json:"tool_profile"`
+	Summary          string         `json:"summary"`
+	SummaryMessageID string         `json:"summary_message_id"`
+	PlanMode         bool           `json:"plan_mode"`
+}
+
+type Todo struct {
+	ID        string `json:"id"`
+	SessionID string `json:"session_id"`
+	Position  int64  `json:"position"`
+	Content   string `json:"content"`
+	Status    string `json:"status"`
+	CreatedAt int64  `json:"created_at"`
+	UpdatedAt int64  `json:"updated_at"`
+}
+
+type Usage struct {
+	ID                  string         `json:"id"`
+	SessionID           string         `json:"session_id"`
+	MessageID           sql.NullString `json:"message_id"`
+	Provider            string         `json:"provider"`
+	Model               string         `json:"model"`
+	InputTokens         int64          `json:"input_tokens"`
+	OutputTokens        int64          `json:"output_tokens"`
+	CacheCreationTokens int64          `json:"cache_creation_tokens"`
+	CacheReadTokens     int64          `json:"cache_read_tokens"`
+	Cost                float64        `json:"cost"`
+	CreatedAt           int64          `json:"created_at"`
 }
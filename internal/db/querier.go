@@ -9,27 +9,45 @@ import (
 )
 
 type Querier interface {
+	AddSessionUsage(ctx context.Context, arg AddSessionUsageParams) (Session, error)
+	CreateCheckpoint(ctx context.Context, arg CreateCheckpointParams) (Checkpoint, error)
 	CreateFile(ctx context.Context, arg CreateFileParams) (File, error)
 	CreateMessage(ctx context.Context, arg CreateMessageParams) (Message, error)
 	CreateSession(ctx context.Context, arg CreateSessionParams) (Session, error)
+	CreateTodo(ctx context.Context, arg CreateTodoParams) error
+	CreateUsage(ctx context.Context, arg CreateUsageParams) error
+	DeleteCheckpointsAfter(ctx context.Context, arg DeleteCheckpointsAfterParams) error
 	DeleteFile(ctx context.Context, id string) error
 	DeleteMessage(ctx context.Context, id string) error
+	DeleteNote(ctx context.Context, arg DeleteNoteParams) error
 	DeleteSession(ctx context.Context, id string) error
 	DeleteSessionFiles(ctx context.Context, sessionID string) error
 	DeleteSessionMessages(ctx context.Context, sessionID string) error
+	DeleteSessionTodos(ctx context.Context, sessionID string) error
+	GetCheckpoint(ctx context.Context, id string) (Checkpoint, error)
 	GetFile(ctx context.Context, id string) (File, error)
 	GetFileByPathAndSession(ctx context.Context, arg GetFileByPathAndSessionParams) (File, error)
 	GetMessage(ctx context.Context, id string) (Message, error)
+	GetNote(ctx context.Context, arg GetNoteParams) (Note, error)
 	GetSessionByID(ctx context.Context, id string) (Session, error)
+	ListCheckpoints(ctx context.Context, sessionID string) ([]Checkpoint, error)
 	ListFilesByPath(ctx context.Context, path string) ([]File, error)
 	ListFilesBySession(ctx context.Context, sessionID string) ([]File, error)
 	ListLatestSessionFiles(ctx context.Context, sessionID string) ([]File, error)
 	ListMessagesBySession(ctx context.Context, sessionID string) ([]Message, error)
 	ListNewFiles(ctx context.Context) ([]File, error)
+	ListNotes(ctx context.Context, sessionID string) ([]Note, error)
 	ListSessions(ctx context.Context) ([]Session, error)
+	ListTodos(ctx context.Context, sessionID string) ([]Todo, error)
+	ListUsageByDay(ctx context.Context, since int64) ([]ListUsageByDayRow, error)
+	ListUsageByProvider(ctx context.Context, since int64) ([]ListUsageByProviderRow, error)
+	ListUsageBySession(ctx context.Context, since int64) ([]ListUsageBySessionRow, error)
+	ListUsageForSession(ctx context.Context, sessionID string) ([]Usage, error)
+	MoveUsageToMessage(ctx context.Context, arg MoveUsageToMessageParams) error
 	UpdateFile(ctx context.Context, arg UpdateFileParams) (File, error)
 	UpdateMessage(ctx context.Context, arg UpdateMessageParams) error
 	UpdateSession(ctx context.Context, arg UpdateSessionParams) (Session, error)
+	UpsertNote(ctx context.Context, arg UpsertNoteParams) (Note, error)
 }
 
 var _ Querier = (*Queries)(nil)
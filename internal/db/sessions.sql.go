@@ -10,6 +10,53 @@ import (
 	"database/sql"
 )
 
+const addSessionUsage = `-- name: AddSessionUsage :one
+UPDATE sessions
+SET
+    prompt_tokens = prompt_tokens + ?,
+    completion_tokens = completion_tokens + ?,
+    cost = cost + ?,
+    context_tokens = CASE WHEN ?4 > 0 THEN ?4 ELSE context_tokens END
+WHERE id = ?5
+RETURNING id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, context_tokens, tool_profile, summary, summary_message_id, plan_mode
+`
+
+type AddSessionUsageParams struct {
+	PromptTokens     int64   `json:"prompt_tokens"`
+	CompletionTokens int64   `json:"completion_tokens"`
+	Cost             float64 `json:"cost"`
+	ContextTokens    int64   `json:"context_tokens"`
+	ID               string  `json:"id"`
+}
+
+func (q *Queries) AddSessionUsage(ctx context.Context, arg AddSessionUsageParams) (Session, error) {
+	row := q.queryRow(ctx, q.addSessionUsageStmt, addSessionUsage,
+		arg.PromptTokens,
+		arg.CompletionTokens,
+		arg.Cost,
+		arg.ContextTokens,
+		arg.ID,
+	)
+	var i Session
+	err := row.Scan(
+		&i.ID,
+		&i.ParentSessionID,
+		&i.Title,
+		&i.MessageCount,
+		&i.PromptTokens,
+		&i.CompletionTokens,
+		&i.Cost,
+		&i.UpdatedAt,
+		&i.CreatedAt,
+		&i.ContextTokens,
+		&i.ToolProfile,
+		&i.Summary,
+		&i.SummaryMessageID,
+		&i.PlanMode,
+	)
+	return i, err
+}
+
 const createSession = `-- name: CreateSession :one
 INSERT INTO sessions (
     id,
@@ -31,7 +78,7 @@ INSERT INTO sessions (
     ?,
     strftime('%s', 'now'),
     strftime('%s', 'now')
-) RETURNING id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at
+) RETURNING id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, context_tokens, tool_profile, summary, summary_message_id, plan_mode
 `
 
 type CreateSessionParams struct {
@@ -65,6 +112,11 @@ func (q *Queries) CreateSession(ctx context.Context, arg CreateSessionParams) (S
 		&i.Cost,
 		&i.UpdatedAt,
 		&i.CreatedAt,
+		&i.ContextTokens,
+		&i.ToolProfile,
+		&i.Summary,
+		&i.SummaryMessageID,
+		&i.PlanMode,
 	)
 	return i, err
 }
@@ -80,7 +132,7 @@ func (q *Queries) DeleteSession(ctx context.Context, id string) error {
 }
 
 const getSessionByID = `-- name: GetSessionByID :one
-SELECT id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at
+SELECT id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, context_tokens, tool_profile, summary, summary_message_id, plan_mode
 FROM sessions
 WHERE id = ? LIMIT 1
 `
@@ -98,12 +150,17 @@ func (q *Queries) GetSessionByID(ctx context.Context, id string) (Session, error
 		&i.Cost,
 		&i.UpdatedAt,
 		&i.CreatedAt,
+		&i.ContextTokens,
+		&i.ToolProfile,
+		&i.Summary,
+		&i.SummaryMessageID,
+		&i.PlanMode,
 	)
 	return i, err
 }
 
 const listSessions = `-- name: ListSessions :many
-SELECT id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at
+SELECT id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, context_tokens, tool_profile, summary, summary_message_id, plan_mode
 FROM sessions
 WHERE parent_session_id is NULL
 ORDER BY created_at DESC
@@ -128,6 +185,11 @@ func (q *Queries) ListSessions(ctx context.Context) ([]Session, error) {
 			&i.Cost,
 			&i.UpdatedAt,
 			&i.CreatedAt,
+			&i.ContextTokens,
+			&i.ToolProfile,
+			&i.Summary,
+			&i.SummaryMessageID,
+			&i.PlanMode,
 		); err != nil {
 			return nil, err
 		}
@@ -146,27 +208,33 @@ const updateSession = `-- name: UpdateSession :one
 UPDATE sessions
 SET
     title = ?,
-    prompt_tokens = ?,
-    completion_tokens = ?,
-    cost = ?
+    context_tokens = ?,
+    tool_profile = ?,
+    summary = ?,
+    summary_message_id = ?,
+    plan_mode = ?
 WHERE id = ?
-RETURNING id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at
+RETURNING id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, context_tokens, tool_profile, summary, summary_message_id, plan_mode
 `
 
 type UpdateSessionParams struct {
-	Title            string  `json:"title"`
-	PromptTokens     int64   `json:"prompt_tokens"`
-	CompletionTokens int64   `json:"completion_tokens"`
-	Cost             float64 `json:"cost"`
-	ID               string  `json:"id"`
+	Title            string `json:"title"`
+	ContextTokens    int64  `json:"context_tokens"`
+	ToolProfile      string `json:"tool_profile"`
+	Summary          string `json:"summary"`
+	SummaryMessageID string `json:"summary_message_id"`
+	PlanMode         bool   `json:"plan_mode"`
+	ID               string `json:"id"`
 }
 
 func (q *Queries) UpdateSession(ctx context.Context, arg UpdateSessionParams) (Session, error) {
 	row := q.queryRow(ctx, q.updateSessionStmt, updateSession,
 		arg.Title,
-		arg.PromptTokens,
-		arg.CompletionTokens,
-		arg.Cost,
+		arg.ContextTokens,
+		arg.ToolProfile,
+		arg.Summary,
+		arg.SummaryMessageID,
+		arg.PlanMode,
 		arg.ID,
 	)
 	var i Session
@@ -180,6 +248,11 @@ func (q *Queries) UpdateSession(ctx context.Context, arg UpdateSessionParams) (S
 		&i.Cost,
 		&i.UpdatedAt,
 		&i.CreatedAt,
+		&i.ContextTokens,
+		&i.ToolProfile,
+		&i.Summary,
+		&i.SummaryMessageID,
+		&i.PlanMode,
 	)
 	return i, err
 }
@@ -0,0 +1,28 @@
+-- name: CreateCheckpoint :one
+INSERT INTO checkpoints (
+    id,
+    session_id,
+    message_id,
+    label,
+    auto,
+    files,
+    created_at
+) VALUES (
+    ?, ?, ?, ?, ?, ?, strftime('%s', 'now')
+)
+RETURNING *;
+
+-- name: GetCheckpoint :one
+SELECT *
+FROM checkpoints
+WHERE id = ? LIMIT 1;
+
+-- name: ListCheckpoints :many
+SELECT *
+FROM checkpoints
+WHERE session_id = ?
+ORDER BY rowid DESC;
+
+-- name: DeleteCheckpointsAfter :exec
+DELETE FROM checkpoints
+WHERE session_id = ? AND rowid > (SELECT c.rowid FROM checkpoints c WHERE c.id = ?);
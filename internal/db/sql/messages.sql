@@ -28,6 +28,7 @@ UPDATE messages
 SET
     parts = ?,
     finished_at = ?,
+    model = ?,
     updated_at = strftime('%s', 'now')
 WHERE id = ?;
 
@@ -0,0 +1,30 @@
+-- name: UpsertNote :one
+INSERT INTO notes (
+    id,
+    session_id,
+    name,
+    content,
+    created_at,
+    updated_at
+) VALUES (
+    ?, ?, ?, ?, strftime('%s', 'now'), strftime('%s', 'now')
+)
+ON CONFLICT (session_id, name) DO UPDATE SET
+    content = excluded.content,
+    updated_at = strftime('%s', 'now')
+RETURNING *;
+
+-- name: GetNote :one
+SELECT *
+FROM notes
+WHERE session_id = ? AND name = ? LIMIT 1;
+
+-- name: ListNotes :many
+SELECT *
+FROM notes
+WHERE session_id = ?
+ORDER BY name;
+
+-- name: DeleteNote :exec
+DELETE FROM notes
+WHERE session_id = ? AND name = ?;
@@ -36,13 +36,25 @@ ORDER BY created_at DESC;
 UPDATE sessions
 SET
     title = ?,
-    prompt_tokens = ?,
-    completion_tokens = ?,
-    cost = ?
+    context_tokens = ?,
+    tool_profile = ?,
+    summary = ?,
+    summary_message_id = ?,
+    plan_mode = ?
 WHERE id = ?
 RETURNING *;
 
 
+-- name: AddSessionUsage :one
+UPDATE sessions
+SET
+    prompt_tokens = prompt_tokens + ?,
+    completion_tokens = completion_tokens + ?,
+    cost = cost + ?,
+    context_tokens = CASE WHEN ?4 > 0 THEN ?4 ELSE context_tokens END
+WHERE id = ?5
+RETURNING *;
+
 -- name: DeleteSession :exec
 DELETE FROM sessions
 WHERE id = ?;
@@ -0,0 +1,22 @@
+-- name: CreateTodo :exec
+INSERT INTO todos (
+    id,
+    session_id,
+    position,
+    content,
+    status,
+    created_at,
+    updated_at
+) VALUES (
+    ?, ?, ?, ?, ?, strftime('%s', 'now'), strftime('%s', 'now')
+);
+
+-- name: ListTodos :many
+SELECT *
+FROM todos
+WHERE session_id = ?
+ORDER BY position;
+
+-- name: DeleteSessionTodos :exec
+DELETE FROM todos
+WHERE session_id = ?;
@@ -0,0 +1,71 @@
+-- name: CreateUsage :exec
+INSERT INTO usage (
+    id,
+    session_id,
+    message_id,
+    provider,
+    model,
+    input_tokens,
+    output_tokens,
+    cache_creation_tokens,
+    cache_read_tokens,
+    cost,
+    created_at
+) VALUES (
+    ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, strftime('%s', 'now')
+);
+
+-- name: ListUsageBySession :many
+SELECT
+    u.session_id,
+    CAST(COALESCE(s.title, '') AS TEXT) AS title,
+    COUNT(*) AS requests,
+    CAST(COALESCE(SUM(u.input_tokens), 0) AS INTEGER) AS input_tokens,
+    CAST(COALESCE(SUM(u.output_tokens), 0) AS INTEGER) AS output_tokens,
+    CAST(COALESCE(SUM(u.cache_creation_tokens), 0) AS INTEGER) AS cache_creation_tokens,
+    CAST(COALESCE(SUM(u.cache_read_tokens), 0) AS INTEGER) AS cache_read_tokens,
+    CAST(COALESCE(SUM(u.cost), 0.0) AS REAL) AS cost
+FROM usage u
+LEFT JOIN sessions s ON s.id = u.session_id
+WHERE u.created_at >= sqlc.arg(since)
+GROUP BY u.session_id
+ORDER BY MAX(u.created_at) DESC;
+
+-- name: ListUsageByDay :many
+SELECT
+    CAST(date(created_at, 'unixepoch', 'localtime') AS TEXT) AS day,
+    COUNT(*) AS requests,
+    CAST(COALESCE(SUM(input_tokens), 0) AS INTEGER) AS input_tokens,
+    CAST(COALESCE(SUM(output_tokens), 0) AS INTEGER) AS output_tokens,
+    CAST(COALESCE(SUM(cache_creation_tokens), 0) AS INTEGER) AS cache_creation_tokens,
+    CAST(COALESCE(SUM(cache_read_tokens), 0) AS INTEGER) AS cache_read_tokens,
+    CAST(COALESCE(SUM(cost), 0.0) AS REAL) AS cost
+FROM usage
+WHERE created_at >= sqlc.arg(since)
+GROUP BY day
+ORDER BY day DESC;
+
+-- name: ListUsageByProvider :many
+SELECT
+    provider,
+    COUNT(*) AS requests,
+    CAST(COALESCE(SUM(input_tokens), 0) AS INTEGER) AS input_tokens,
+    CAST(COALESCE(SUM(output_tokens), 0) AS INTEGER) AS output_tokens,
+    CAST(COALESCE(SUM(cache_creation_tokens), 0) AS INTEGER) AS cache_creation_tokens,
+    CAST(COALESCE(SUM(cache_read_tokens), 0) AS INTEGER) AS cache_read_tokens,
+    CAST(COALESCE(SUM(cost), 0.0) AS REAL) AS cost
+FROM usage
+WHERE created_at >= sqlc.arg(since)
+GROUP BY provider
+ORDER BY cost DESC;
+
+-- name: ListUsageForSession :many
+SELECT *
+FROM usage
+WHERE session_id = ?
+ORDER BY created_at ASC, rowid ASC;
+
+-- name: MoveUsageToMessage :exec
+UPDATE usage
+SET message_id = sqlc.arg(to_message_id)
+WHERE message_id = sqlc.arg(from_message_id);
@@ -0,0 +1,106 @@
+package deps
+
+import (
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+func parseCargo(m *Manifest, data []byte, root string) error {
+	m.Ecosystem = EcosystemCargo
+	var cargo struct {
+		Package struct {
+			Name    string `toml:"name"`
+			Version any    `toml:"version"`
+		} `toml:"package"`
+		Dependencies      map[string]any `toml:"dependencies"`
+		DevDependencies   map[string]any `toml:"dev-dependencies"`
+		BuildDependencies map[string]any `toml:"build-dependencies"`
+		Target            map[string]struct {
+			Dependencies      map[string]any `toml:"dependencies"`
+			DevDependencies   map[string]any `toml:"dev-dependencies"`
+			BuildDependencies map[string]any `toml:"build-dependencies"`
+		} `toml:"target"`
+		Workspace struct {
+			Dependencies map[string]any `toml:"dependencies"`
+		} `toml:"workspace"`
+	}
+	if err := toml.Unmarshal(data, &cargo); err != nil {
+		return err
+	}
+	m.Name = cargo.Package.Name
+	// The version is a table when it is inherited from the workspace
+	if version, ok := cargo.Package.Version.(string); ok {
+		m.Version = version
+	}
+
+	locked := cargoLockVersions(m, root)
+	addCargoDependencies(m, cargo.Dependencies, "", locked)
+	addCargoDependencies(m, cargo.DevDependencies, "dev", locked)
+	addCargoDependencies(m, cargo.BuildDependencies, "build", locked)
+	for _, target := range slices.Sorted(maps.Keys(cargo.Target)) {
+		deps := cargo.Target[target]
+		addCargoDependencies(m, deps.Dependencies, target, locked)
+		addCargoDependencies(m, deps.DevDependencies, target+" dev", locked)
+		addCargoDependencies(m, deps.BuildDependencies, target+" build", locked)
+	}
+	addCargoDependencies(m, cargo.Workspace.Dependencies, "workspace", locked)
+	return nil
+}
+
+// addCargoDependencies adds the dependencies of a Cargo.toml table, whose
+// values are a version or a table with the version or where the crate comes
+// from.
+func addCargoDependencies(m *Manifest, deps map[string]any, group string, locked map[string]string) {
+	for _, name := range slices.Sorted(maps.Keys(deps)) {
+		// The key is only the name the crate is used by when its package is
+		// renamed
+		crate := name
+		if table, ok := deps[name].(map[string]any); ok {
+			if pkg, ok := table["package"].(string); ok {
+				crate = pkg
+			}
+		}
+		m.Dependencies = append(m.Dependencies, Dependency{
+			Name:       crate,
+			Constraint: tableConstraint(deps[name]),
+			Version:    locked[crate],
+			Group:      group,
+		})
+	}
+}
+
+// cargoLockVersions returns the versions of the crates of the Cargo.lock of
+// the manifest, crates locked in several versions have all of them.
+func cargoLockVersions(m *Manifest, root string) map[string]string {
+	versions := make(map[string]string)
+	path := findLockFile(m, root, "Cargo.lock")
+	if path == "" {
+		return versions
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return versions
+	}
+	var lock struct {
+		Package []struct {
+			Name    string `toml:"name"`
+			Version string `toml:"version"`
+		} `toml:"package"`
+	}
+	if toml.Unmarshal(data, &lock) != nil {
+		return versions
+	}
+	m.LockFile = path
+	all := make(map[string][]string)
+	for _, p := range lock.Package {
+		all[p.Name] = append(all[p.Name], p.Version)
+	}
+	for name, list := range all {
+		versions[name] = strings.Join(list, ", ")
+	}
+	return versions
+}
@@ -0,0 +1,155 @@
+// Package deps reads the dependencies the manifests of a project declare, like
+// go.mod and package.json, and the versions their lockfiles resolved.
+package deps
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Ecosystems of the manifests.
+const (
+	EcosystemGo       = "go"
+	EcosystemNPM      = "npm"
+	EcosystemPython   = "python"
+	EcosystemCargo    = "cargo"
+	EcosystemComposer = "composer"
+)
+
+// Dependency is a dependency declared in a manifest.
+type Dependency struct {
+	Name string
+	// Constraint is the declared version or range, like ^1.2.0, or where the
+	// dependency comes from when it has no version, like a path or git URL
+	Constraint string
+	// Version is the version the lockfile resolved or that is installed,
+	// empty when it is unknown
+	Version string
+	// Group is the kind of dependency, like dev or build, empty for the
+	// dependencies of the program
+	Group    string
+	Indirect bool
+}
+
+// Manifest is a manifest file of a project.
+type Manifest struct {
+	Path      string
+	Ecosystem string
+	// Name and Version of the project, Version is the Go version for go.mod
+	Name    string
+	Version string
+	// LockFile is the file the resolved versions are from
+	LockFile     string
+	Dependencies []Dependency
+}
+
+// manifestFiles are the manifests by file name.
+var manifestFiles = []struct {
+	name  string
+	parse func(m *Manifest, data []byte, root string) error
+}{
+	{"go.mod", parseGoMod},
+	{"package.json", parsePackageJSON},
+	{"pyproject.toml", parsePyproject},
+	{"requirements.txt", parseRequirements},
+	{"requirements-dev.txt", parseRequirements},
+	{"Cargo.toml", parseCargo},
+	{"composer.json", parseComposer},
+}
+
+// Find reads the manifests in the directory. Lockfiles are searched in the
+// directory and its parents up to root, as workspaces share one.
+func Find(dir, root string) ([]Manifest, error) {
+	var manifests []Manifest
+	for _, file := range manifestFiles {
+		path := filepath.Join(dir, file.name)
+		data, err := os.ReadFile(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		m := Manifest{Path: path}
+		if err := file.parse(&m, data, root); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		manifests = append(manifests, m)
+	}
+	return manifests, nil
+}
+
+// findLockFile returns the path of the first of the names in the directory of
+// the manifest or its parents up to root.
+func findLockFile(m *Manifest, root string, names ...string) string {
+	dir := filepath.Dir(m.Path)
+	for {
+		for _, name := range names {
+			path := filepath.Join(dir, name)
+			if _, err := os.Stat(path); err == nil {
+				return path
+			}
+		}
+		parent := filepath.Dir(dir)
+		if dir == root || parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+func parseGoMod(m *Manifest, data []byte, root string) error {
+	m.Ecosystem = EcosystemGo
+	replaced := make(map[string]string)
+	block := ""
+	for _, line := range strings.Split(string(data), "\n") {
+		line, comment, _ := strings.Cut(line, "//")
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		verb := block
+		switch {
+		case block != "" && fields[0] == ")":
+			block = ""
+			continue
+		case block == "" && len(fields) == 2 && fields[1] == "(":
+			block = fields[0]
+			continue
+		case block == "":
+			verb, fields = fields[0], fields[1:]
+		}
+		switch verb {
+		case "module":
+			if len(fields) > 0 {
+				m.Name = strings.Trim(fields[0], `"`)
+			}
+		case "go":
+			if len(fields) > 0 {
+				m.Version = "go " + fields[0]
+			}
+		case "require":
+			if len(fields) >= 2 {
+				m.Dependencies = append(m.Dependencies, Dependency{
+					Name:       fields[0],
+					Constraint: fields[1],
+					Version:    fields[1],
+					Indirect:   strings.TrimSpace(comment) == "indirect",
+				})
+			}
+		case "replace":
+			if i := slices.Index(fields, "=>"); i > 0 && i < len(fields)-1 {
+				replaced[fields[0]] = strings.Join(fields[i+1:], " ")
+			}
+		}
+	}
+	for i, dep := range m.Dependencies {
+		if replacement, ok := replaced[dep.Name]; ok {
+			m.Dependencies[i].Version = "=> " + replacement
+		}
+	}
+	return nil
+}
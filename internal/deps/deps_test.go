@@ -0,0 +1,155 @@
+package deps
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+}
+
+func TestFindGoAndNPM(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"go.mod": `module example.com/app
+
+go 1.24
+
+require (
+	github.com/a/b v1.2.0
+	github.com/c/d v0.1.0 // indirect
+)
+
+require github.com/e/f v2.0.0+incompatible
+
+replace github.com/a/b => ../b
+`,
+		"package.json": `{"name": "app", "version": "1.0.0",
+			"dependencies": {"react": "^18.2.0", "left-pad": "1.x"},
+			"devDependencies": {"vite": "^5.0.0"}}`,
+		"package-lock.json": `{"lockfileVersion": 3, "packages": {
+			"": {"name": "app"},
+			"node_modules/react": {"version": "18.3.1"},
+			"node_modules/vite": {"version": "5.4.2"},
+			"node_modules/vite/node_modules/react": {"version": "17.0.0"}}}`,
+		"node_modules/left-pad/package.json": `{"version": "1.3.0"}`,
+	})
+
+	manifests, err := Find(root, root)
+	require.NoError(t, err)
+	require.Len(t, manifests, 2)
+
+	gomod := manifests[0]
+	assert.Equal(t, EcosystemGo, gomod.Ecosystem)
+	assert.Equal(t, "example.com/app", gomod.Name)
+	assert.Equal(t, "go 1.24", gomod.Version)
+	assert.Equal(t, []Dependency{
+		{Name: "github.com/a/b", Constraint: "v1.2.0", Version: "=> ../b"},
+		{Name: "github.com/c/d", Constraint: "v0.1.0", Version: "v0.1.0", Indirect: true},
+		{Name: "github.com/e/f", Constraint: "v2.0.0+incompatible", Version: "v2.0.0+incompatible"},
+	}, gomod.Dependencies)
+
+	npm := manifests[1]
+	assert.Equal(t, filepath.Join(root, "package-lock.json"), npm.LockFile)
+	assert.Equal(t, []Dependency{
+		{Name: "left-pad", Constraint: "1.x", Version: "1.3.0"},
+		{Name: "react", Constraint: "^18.2.0", Version: "18.3.1"},
+		{Name: "vite", Constraint: "^5.0.0", Version: "5.4.2", Group: "dev"},
+	}, npm.Dependencies)
+}
+
+func TestFindPythonAndCargo(t *testing.T) {
+	root := t.TempDir()
+	crate := filepath.Join(root, "crates", "core")
+	writeFiles(t, root, map[string]string{
+		"pyproject.toml": `[project]
+name = "tool"
+version = "0.3.0"
+dependencies = ["Requests[socks]>=2.31; python_version > '3.8'", "click"]
+
+[dependency-groups]
+dev = ["pytest>=8", {include-group = "lint"}]
+`,
+		"uv.lock": `version = 1
+
+[[package]]
+name = "requests"
+version = "2.32.3"
+
+[[package]]
+name = "pytest"
+version = "8.3.2"
+`,
+		"crates/core/Cargo.toml": `[package]
+name = "core"
+version.workspace = true
+
+[dependencies]
+serde = { version = "1", features = ["derive"] }
+rand = "0.8"
+util = { path = "../util" }
+tokio-rt = { package = "tokio", workspace = true }
+`,
+		"Cargo.lock": `[[package]]
+name = "rand"
+version = "0.8.5"
+
+[[package]]
+name = "rand"
+version = "0.7.3"
+
+[[package]]
+name = "tokio"
+version = "1.40.0"
+`,
+	})
+
+	manifests, err := Find(root, root)
+	require.NoError(t, err)
+	require.Len(t, manifests, 1)
+	python := manifests[0]
+	assert.Equal(t, "tool", python.Name)
+	assert.Equal(t, []Dependency{
+		{Name: "Requests[socks]", Constraint: ">=2.31; python_version > '3.8'", Version: "2.32.3"},
+		{Name: "click"},
+		{Name: "pytest", Constraint: ">=8", Version: "8.3.2", Group: "dev"},
+	}, python.Dependencies)
+
+	// The lockfile of the workspace is found in the parent directories
+	manifests, err = Find(crate, root)
+	require.NoError(t, err)
+	require.Len(t, manifests, 1)
+	cargo := manifests[0]
+	assert.Equal(t, "core", cargo.Name)
+	assert.Equal(t, filepath.Join(root, "Cargo.lock"), cargo.LockFile)
+	assert.Equal(t, []Dependency{
+		{Name: "rand", Constraint: "0.8", Version: "0.8.5, 0.7.3"},
+		{Name: "serde", Constraint: "1"},
+		{Name: "tokio", Constraint: "workspace", Version: "1.40.0"},
+		{Name: "util", Constraint: "path ../util"},
+	}, cargo.Dependencies)
+}
+
+func TestParseRequirements(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"requirements-dev.txt": "# tools\n-r requirements.txt\n-e .\nruff==0.6.1  # linter\nmypy\n",
+	})
+	manifests, err := Find(root, root)
+	require.NoError(t, err)
+	require.Len(t, manifests, 1)
+	assert.Equal(t, []Dependency{
+		{Name: "ruff", Constraint: "==0.6.1", Group: "dev"},
+		{Name: "mypy", Group: "dev"},
+	}, manifests[0].Dependencies)
+}
@@ -0,0 +1,150 @@
+package deps
+
+import (
+	"encoding/json"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// npmGroups are the dependency fields of package.json with their group.
+var npmGroups = []struct{ field, group string }{
+	{"dependencies", ""},
+	{"devDependencies", "dev"},
+	{"peerDependencies", "peer"},
+	{"optionalDependencies", "optional"},
+}
+
+func parsePackageJSON(m *Manifest, data []byte, root string) error {
+	m.Ecosystem = EcosystemNPM
+	var pkg map[string]json.RawMessage
+	if err := json.Unmarshal(data, &pkg); err != nil {
+		return err
+	}
+	json.Unmarshal(pkg["name"], &m.Name)
+	json.Unmarshal(pkg["version"], &m.Version)
+
+	installed := npmLockVersions(m, root)
+	for _, g := range npmGroups {
+		var deps map[string]string
+		json.Unmarshal(pkg[g.field], &deps)
+		for _, name := range slices.Sorted(maps.Keys(deps)) {
+			version, ok := installed[name]
+			if !ok {
+				version = npmInstalledVersion(filepath.Dir(m.Path), name)
+			}
+			m.Dependencies = append(m.Dependencies, Dependency{
+				Name:       name,
+				Constraint: deps[name],
+				Version:    version,
+				Group:      g.group,
+			})
+		}
+	}
+	return nil
+}
+
+// npmLockVersions returns the versions of the top-level packages of the
+// package-lock.json of the manifest.
+func npmLockVersions(m *Manifest, root string) map[string]string {
+	versions := make(map[string]string)
+	path := findLockFile(m, root, "package-lock.json", "npm-shrinkwrap.json")
+	if path == "" {
+		return versions
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return versions
+	}
+	var lock struct {
+		// Packages are the packages by path since lockfile version 2,
+		// Dependencies the packages by name before
+		Packages map[string]struct {
+			Version string `json:"version"`
+		} `json:"packages"`
+		Dependencies map[string]struct {
+			Version string `json:"version"`
+		} `json:"dependencies"`
+	}
+	if json.Unmarshal(data, &lock) != nil {
+		return versions
+	}
+	for name, dep := range lock.Dependencies {
+		versions[name] = dep.Version
+	}
+	for path, pkg := range lock.Packages {
+		name, ok := strings.CutPrefix(path, "node_modules/")
+		// Nested packages are the dependencies of other packages
+		if ok && pkg.Version != "" && !strings.Contains(name, "node_modules/") {
+			versions[name] = pkg.Version
+		}
+	}
+	if len(versions) > 0 {
+		m.LockFile = path
+	}
+	return versions
+}
+
+// npmInstalledVersion returns the version of the package in the node_modules
+// of the directory, for projects locked by yarn or pnpm.
+func npmInstalledVersion(dir, name string) string {
+	data, err := os.ReadFile(filepath.Join(dir, "node_modules", name, "package.json"))
+	if err != nil {
+		return ""
+	}
+	var pkg struct {
+		Version string `json:"version"`
+	}
+	json.Unmarshal(data, &pkg)
+	return pkg.Version
+}
+
+func parseComposer(m *Manifest, data []byte, root string) error {
+	m.Ecosystem = EcosystemComposer
+	var pkg struct {
+		Name       string            `json:"name"`
+		Version    string            `json:"version"`
+		Require    map[string]string `json:"require"`
+		RequireDev map[string]string `json:"require-dev"`
+	}
+	if err := json.Unmarshal(data, &pkg); err != nil {
+		return err
+	}
+	m.Name, m.Version = pkg.Name, pkg.Version
+
+	locked := make(map[string]string)
+	if path := findLockFile(m, root, "composer.lock"); path != "" {
+		var lock struct {
+			Packages []struct {
+				Name    string `json:"name"`
+				Version string `json:"version"`
+			} `json:"packages"`
+			PackagesDev []struct {
+				Name    string `json:"name"`
+				Version string `json:"version"`
+			} `json:"packages-dev"`
+		}
+		if data, err := os.ReadFile(path); err == nil && json.Unmarshal(data, &lock) == nil {
+			m.LockFile = path
+			for _, p := range append(lock.Packages, lock.PackagesDev...) {
+				locked[p.Name] = p.Version
+			}
+		}
+	}
+	for _, g := range []struct {
+		deps  map[string]string
+		group string
+	}{{pkg.Require, ""}, {pkg.RequireDev, "dev"}} {
+		for _, name := range slices.Sorted(maps.Keys(g.deps)) {
+			m.Dependencies = append(m.Dependencies, Dependency{
+				Name:       name,
+				Constraint: g.deps[name],
+				Version:    locked[name],
+				Group:      g.group,
+			})
+		}
+	}
+	return nil
+}
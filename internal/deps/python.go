@@ -0,0 +1,189 @@
+package deps
+
+import (
+	"maps"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+// pep508Name matches the name and the extras of a PEP 508 requirement, the
+// rest is its version specifier and markers.
+var pep508Name = regexp.MustCompile(`^\s*([A-Za-z0-9][A-Za-z0-9._-]*)\s*(\[[^\]]*\])?\s*`)
+
+func parsePyproject(m *Manifest, data []byte, root string) error {
+	m.Ecosystem = EcosystemPython
+	var pyproject struct {
+		Project struct {
+			Name                 string              `toml:"name"`
+			Version              string              `toml:"version"`
+			Dependencies         []string            `toml:"dependencies"`
+			OptionalDependencies map[string][]string `toml:"optional-dependencies"`
+		} `toml:"project"`
+		// DependencyGroups are the groups of PEP 735, their items are
+		// requirements or tables including other groups
+		DependencyGroups map[string][]any `toml:"dependency-groups"`
+		Tool             struct {
+			Poetry struct {
+				Name            string         `toml:"name"`
+				Version         string         `toml:"version"`
+				Dependencies    map[string]any `toml:"dependencies"`
+				DevDependencies map[string]any `toml:"dev-dependencies"`
+				Group           map[string]struct {
+					Dependencies map[string]any `toml:"dependencies"`
+				} `toml:"group"`
+			} `toml:"poetry"`
+		} `toml:"tool"`
+	}
+	if err := toml.Unmarshal(data, &pyproject); err != nil {
+		return err
+	}
+	project, poetry := pyproject.Project, pyproject.Tool.Poetry
+	m.Name, m.Version = project.Name, project.Version
+	if m.Name == "" {
+		m.Name, m.Version = poetry.Name, poetry.Version
+	}
+
+	locked := pythonLockVersions(m, root)
+	addRequirements(m, project.Dependencies, "", locked)
+	for _, group := range slices.Sorted(maps.Keys(project.OptionalDependencies)) {
+		addRequirements(m, project.OptionalDependencies[group], group, locked)
+	}
+	for _, group := range slices.Sorted(maps.Keys(pyproject.DependencyGroups)) {
+		var requirements []string
+		for _, item := range pyproject.DependencyGroups[group] {
+			if requirement, ok := item.(string); ok {
+				requirements = append(requirements, requirement)
+			}
+		}
+		addRequirements(m, requirements, group, locked)
+	}
+	addPoetryDependencies(m, poetry.Dependencies, "", locked)
+	addPoetryDependencies(m, poetry.DevDependencies, "dev", locked)
+	for _, group := range slices.Sorted(maps.Keys(poetry.Group)) {
+		addPoetryDependencies(m, poetry.Group[group].Dependencies, group, locked)
+	}
+	return nil
+}
+
+func parseRequirements(m *Manifest, data []byte, root string) error {
+	m.Ecosystem = EcosystemPython
+	group := ""
+	if strings.HasSuffix(m.Path, "-dev.txt") {
+		group = "dev"
+	}
+	var requirements []string
+	for _, line := range strings.Split(string(data), "\n") {
+		line, _, _ = strings.Cut(line, " #")
+		line = strings.TrimSpace(line)
+		// Options like -r other.txt and -e . aren't requirements
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "-") {
+			continue
+		}
+		requirements = append(requirements, line)
+	}
+	addRequirements(m, requirements, group, pythonLockVersions(m, root))
+	return nil
+}
+
+// addRequirements adds the PEP 508 requirements to the dependencies.
+func addRequirements(m *Manifest, requirements []string, group string, locked map[string]string) {
+	for _, requirement := range requirements {
+		match := pep508Name.FindStringSubmatch(requirement)
+		if match == nil {
+			continue
+		}
+		name := match[1] + match[2]
+		constraint := strings.TrimSpace(requirement[len(match[0]):])
+		m.Dependencies = append(m.Dependencies, Dependency{
+			Name:       name,
+			Constraint: constraint,
+			Version:    locked[normalizePythonName(match[1])],
+			Group:      group,
+		})
+	}
+}
+
+// addPoetryDependencies adds the dependencies of a poetry table, whose values
+// are a version or a table with the version, path or git URL.
+func addPoetryDependencies(m *Manifest, deps map[string]any, group string, locked map[string]string) {
+	for _, name := range slices.Sorted(maps.Keys(deps)) {
+		if name == "python" {
+			continue
+		}
+		m.Dependencies = append(m.Dependencies, Dependency{
+			Name:       name,
+			Constraint: tableConstraint(deps[name]),
+			Version:    locked[normalizePythonName(name)],
+			Group:      group,
+		})
+	}
+}
+
+// pythonLockVersions returns the versions of the packages of the uv, poetry
+// or pdm lockfile of the manifest, by normalized name.
+func pythonLockVersions(m *Manifest, root string) map[string]string {
+	versions := make(map[string]string)
+	path := findLockFile(m, root, "uv.lock", "poetry.lock", "pdm.lock")
+	if path == "" {
+		return versions
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return versions
+	}
+	var lock struct {
+		Package []struct {
+			Name    string `toml:"name"`
+			Version string `toml:"version"`
+		} `toml:"package"`
+	}
+	if toml.Unmarshal(data, &lock) != nil {
+		return versions
+	}
+	m.LockFile = path
+	for _, p := range lock.Package {
+		versions[normalizePythonName(p.Name)] = p.Version
+	}
+	return versions
+}
+
+// normalizePythonName returns the name of a package as PEP 503 compares
+// them, lowercase with runs of -, _ and . as -.
+func normalizePythonName(name string) string {
+	return strings.ToLower(pythonNameSeparators.ReplaceAllString(name, "-"))
+}
+
+var pythonNameSeparators = regexp.MustCompile(`[-_.]+`)
+
+// tableConstraint returns the version of a dependency given as a version or
+// as a table, or where it comes from when it has none.
+func tableConstraint(value any) string {
+	switch value := value.(type) {
+	case string:
+		return value
+	case map[string]any:
+		for _, key := range []string{"version", "path", "git", "url"} {
+			if s, ok := value[key].(string); ok {
+				if key == "version" {
+					return s
+				}
+				return key + " " + s
+			}
+		}
+		if workspace, _ := value["workspace"].(bool); workspace {
+			return "workspace"
+		}
+	case []any:
+		// Poetry allows several constraints for different markers
+		var constraints []string
+		for _, item := range value {
+			constraints = append(constraints, tableConstraint(item))
+		}
+		return strings.Join(constraints, " | ")
+	}
+	return ""
+}
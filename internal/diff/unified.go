@@ -0,0 +1,312 @@
+package diff
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// FileDiff is the change of one file in a unified diff.
+type FileDiff struct {
+	OldPath string // empty when the file is created
+	NewPath string // empty when the file is deleted
+	Hunks   []FileHunk
+}
+
+// FileHunk is a hunk of a FileDiff. OldStart is the first line of the hunk in
+// the old file, or -1 when the header has no line numbers.
+type FileHunk struct {
+	Header   string
+	OldStart int
+	Lines    []DiffLine
+}
+
+// HunkResult reports how a hunk was applied.
+type HunkResult struct {
+	Header string
+	// Line is the first line of the hunk in the result, 1-based
+	Line int
+	// Fuzzy is how the hunk was matched when it didn't match exactly,
+	// "whitespace" or "context"
+	Fuzzy string
+	// Error is why the hunk wasn't applied
+	Error string
+}
+
+// maxHunkFuzz is the number of context lines that can be dropped at each end
+// of a hunk to find it
+const maxHunkFuzz = 2
+
+var unifiedHunkHeaderRe = regexp.MustCompile(`^@@ -(\d+)(?:,\d+)? \+\d+(?:,\d+)? @@`)
+
+// ParseFileDiffs parses a unified diff of one or more files, like the output
+// of diff -u or git diff. The a/ and b/ prefixes of git are removed from the
+// paths, and hunk headers without line numbers are accepted.
+func ParseFileDiffs(text string) ([]FileDiff, error) {
+	lines := strings.Split(strings.TrimSuffix(strings.ReplaceAll(text, "\r\n", "\n"), "\n"), "\n")
+	var files []FileDiff
+	var file *FileDiff
+	var hunk *FileHunk
+	endFile := func() {
+		if file == nil {
+			return
+		}
+		if hunk != nil {
+			file.Hunks = append(file.Hunks, *hunk)
+			hunk = nil
+		}
+		files = append(files, *file)
+		file = nil
+	}
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			endFile()
+		case strings.HasPrefix(line, "--- ") && i+1 < len(lines) && strings.HasPrefix(lines[i+1], "+++ ") &&
+			(hunk == nil || i+2 == len(lines) || strings.HasPrefix(lines[i+2], "@@") || strings.HasPrefix(lines[i+2], "diff --git ")):
+			// In a hunk, --- and +++ lines can also be a removed "-- " and an
+			// added "++ " line, they start a file when a hunk follows
+			endFile()
+			file = &FileDiff{
+				OldPath: diffPath(line[4:], "a/"),
+				NewPath: diffPath(lines[i+1][4:], "b/"),
+			}
+			if file.OldPath == "" && file.NewPath == "" {
+				return nil, NewDiffError(fmt.Sprintf("no file path in %q", line))
+			}
+			i++
+		case strings.HasPrefix(line, "@@"):
+			if file == nil {
+				return nil, NewDiffError(fmt.Sprintf("hunk %q before the --- and +++ lines of its file", line))
+			}
+			if hunk != nil {
+				file.Hunks = append(file.Hunks, *hunk)
+			}
+			hunk = &FileHunk{Header: line, OldStart: -1}
+			if matches := unifiedHunkHeaderRe.FindStringSubmatch(line); matches != nil {
+				hunk.OldStart, _ = strconv.Atoi(matches[1])
+			}
+		case hunk != nil && line == "":
+			// Editors and models often strip the space of empty context lines
+			hunk.Lines = append(hunk.Lines, DiffLine{Kind: LineContext})
+		case hunk != nil && line[0] == ' ':
+			hunk.Lines = append(hunk.Lines, DiffLine{Kind: LineContext, Content: line[1:]})
+		case hunk != nil && line[0] == '-':
+			hunk.Lines = append(hunk.Lines, DiffLine{Kind: LineRemoved, Content: line[1:]})
+		case hunk != nil && line[0] == '+':
+			hunk.Lines = append(hunk.Lines, DiffLine{Kind: LineAdded, Content: line[1:]})
+		default:
+			// Index and mode lines, "\ No newline at end of file" markers and
+			// text around the diff are ignored
+		}
+	}
+	endFile()
+
+	if len(files) == 0 {
+		return nil, NewDiffError("no file diffs found, the diff needs --- and +++ lines for each file")
+	}
+	return files, nil
+}
+
+// diffPath returns the path of a --- or +++ line without the git prefix and
+// timestamp, or empty for /dev/null.
+func diffPath(text, prefix string) string {
+	path, _, _ := strings.Cut(text, "\t")
+	path = strings.TrimSpace(path)
+	if path == "/dev/null" {
+		return ""
+	}
+	return strings.TrimPrefix(path, prefix)
+}
+
+// ApplyFileDiff applies the hunks of the diff to the content in order, and
+// returns the result of each. Hunks are searched near their line number,
+// ignoring whitespace and then up to maxHunkFuzz context lines at each end if
+// they don't match exactly. Hunks that aren't found are skipped.
+func ApplyFileDiff(content string, fd FileDiff) (string, []HunkResult) {
+	crlf := strings.Contains(content, "\r\n")
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+	trailingNewline := content == "" || strings.HasSuffix(content, "\n")
+	var lines []string
+	if content != "" {
+		lines = strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	}
+
+	results := make([]HunkResult, 0, len(fd.Hunks))
+	// offset is how many lines the applied hunks added, from is where the
+	// next hunk can start
+	offset, from := 0, 0
+	for _, hunk := range fd.Hunks {
+		result := HunkResult{Header: hunk.Header}
+		idx, oldLines, newLines, fuzzy := locateHunk(lines, hunk, from, offset)
+		if idx < 0 {
+			result.Error = hunkNotFound(lines, hunk)
+			results = append(results, result)
+			continue
+		}
+		lines = append(lines[:idx], append(newLines, lines[idx+len(oldLines):]...)...)
+		offset += len(newLines) - len(oldLines)
+		from = idx + len(newLines)
+		result.Line = idx + 1
+		result.Fuzzy = fuzzy
+		results = append(results, result)
+	}
+
+	newContent := strings.Join(lines, "\n")
+	if trailingNewline && len(lines) > 0 {
+		newContent += "\n"
+	}
+	if crlf {
+		newContent = strings.ReplaceAll(newContent, "\n", "\r\n")
+	}
+	return newContent, results
+}
+
+// locateHunk finds the hunk in lines from from on, nearest to its line number
+// moved by offset. It returns the index and the old and new lines of the hunk
+// as matched, or -1 when the hunk isn't found.
+func locateHunk(lines []string, hunk FileHunk, from, offset int) (int, []string, []string, string) {
+	hunkLines, dropped := hunk.Lines, 0
+	for fuzz := 0; fuzz <= maxHunkFuzz; fuzz++ {
+		if fuzz > 0 {
+			var ok bool
+			if hunkLines, dropped, ok = trimHunkContext(hunk.Lines, fuzz); !ok {
+				break
+			}
+		}
+		oldLines, newLines := hunkSides(hunkLines)
+		hint := from
+		if hunk.OldStart >= 0 {
+			hint = hunk.OldStart - 1 + offset + dropped
+			if len(oldLines) == 0 {
+				// Hunks without old lines insert after their line
+				hint++
+			}
+		}
+		if len(oldLines) == 0 {
+			if fuzz > 0 {
+				// Dropping all context would insert anywhere
+				break
+			}
+			return min(max(hint, from), len(lines)), oldLines, newLines, ""
+		}
+
+		matchers := []struct {
+			name  string
+			equal func(a, b string) bool
+		}{
+			{"", func(a, b string) bool { return a == b }},
+			{"whitespace", func(a, b string) bool { return strings.TrimRight(a, " \t") == strings.TrimRight(b, " \t") }},
+			{"whitespace", func(a, b string) bool { return strings.TrimSpace(a) == strings.TrimSpace(b) }},
+		}
+		for _, matcher := range matchers {
+			if idx := nearestMatch(lines, oldLines, from, hint, matcher.equal); idx >= 0 {
+				fuzzy := matcher.name
+				if fuzz > 0 {
+					fuzzy = "context"
+				}
+				// Context lines keep their whitespace in the file
+				matched := lines[idx : idx+len(oldLines)]
+				replacement, i := make([]string, 0, len(newLines)), 0
+				for _, line := range hunkLines {
+					switch line.Kind {
+					case LineContext:
+						replacement = append(replacement, matched[i])
+						i++
+					case LineRemoved:
+						i++
+					case LineAdded:
+						replacement = append(replacement, line.Content)
+					}
+				}
+				return idx, matched, replacement, fuzzy
+			}
+		}
+	}
+	return -1, nil, nil, ""
+}
+
+// trimHunkContext drops up to n context lines at the start and the end of the
+// hunk lines, and returns how many were dropped at the start.
+func trimHunkContext(lines []DiffLine, n int) ([]DiffLine, int, bool) {
+	start, end := 0, len(lines)
+	for start < n && start < end && lines[start].Kind == LineContext {
+		start++
+	}
+	for len(lines)-end < n && end > start && lines[end-1].Kind == LineContext {
+		end--
+	}
+	if start == 0 && end == len(lines) {
+		return nil, 0, false
+	}
+	return lines[start:end], start, true
+}
+
+// hunkSides returns the lines of the hunk in the old and in the new file.
+func hunkSides(lines []DiffLine) (oldLines, newLines []string) {
+	for _, line := range lines {
+		switch line.Kind {
+		case LineContext:
+			oldLines = append(oldLines, line.Content)
+			newLines = append(newLines, line.Content)
+		case LineRemoved:
+			oldLines = append(oldLines, line.Content)
+		case LineAdded:
+			newLines = append(newLines, line.Content)
+		}
+	}
+	return oldLines, newLines
+}
+
+// nearestMatch returns the index of the match of old in lines from from on
+// that is nearest to hint, or -1.
+func nearestMatch(lines, old []string, from, hint int, equal func(a, b string) bool) int {
+	best := -1
+	for i := from; i+len(old) <= len(lines); i++ {
+		match := true
+		for j := range old {
+			if !equal(lines[i+j], old[j]) {
+				match = false
+				break
+			}
+		}
+		if match && (best < 0 || abs(i-hint) < abs(best-hint)) {
+			best = i
+		}
+	}
+	return best
+}
+
+// hunkNotFound describes why the hunk wasn't found, with the lines its first
+// removed line is at in the file when there are any.
+func hunkNotFound(lines []string, hunk FileHunk) string {
+	for _, line := range hunk.Lines {
+		if line.Kind != LineRemoved || strings.TrimSpace(line.Content) == "" {
+			continue
+		}
+		var found []string
+		for i, l := range lines {
+			if strings.TrimSpace(l) == strings.TrimSpace(line.Content) {
+				found = append(found, strconv.Itoa(i+1))
+				if len(found) == 3 {
+					break
+				}
+			}
+		}
+		if len(found) == 0 {
+			return fmt.Sprintf("the removed line %q is not in the file", line.Content)
+		}
+		return fmt.Sprintf("the lines of the hunk don't match the file, the removed line %q is at line %s", line.Content, strings.Join(found, ", "))
+	}
+	return "the context lines of the hunk don't match the file"
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
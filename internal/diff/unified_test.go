@@ -0,0 +1,138 @@
+package diff
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const unifiedTestFile = `package main
+
+import "log"
+
+func main() {
+	cfg := loadConfig()
+	srv := newServer(cfg)
+
+	log.Fatal(srv.ListenAndServe())
+}
+
+func loadConfig() config {
+	return config{}
+}
+`
+
+func TestParseFileDiffs(t *testing.T) {
+	files, err := ParseFileDiffs(`diff --git a/main.go b/main.go
+index 3b18e51..a9c5f33 100644
+--- a/main.go
++++ b/main.go
+@@ -5,3 +5,3 @@ func main() {
+ 	cfg := loadConfig()
+--- old
++++ new
+-	srv := newServer(cfg)
++	srv := newServer(cfg, log.Default())
+@@ ... @@
+ 	return config{}
+--- /dev/null
++++ b/docs/README.md
+@@ -0,0 +1 @@
++# Docs
+--- old.txt	2024-01-01 10:00:00
++++ /dev/null
+`)
+	require.NoError(t, err)
+	require.Len(t, files, 3)
+
+	assert.Equal(t, "main.go", files[0].OldPath)
+	assert.Equal(t, "main.go", files[0].NewPath)
+	require.Len(t, files[0].Hunks, 2)
+	assert.Equal(t, 5, files[0].Hunks[0].OldStart)
+	assert.Equal(t, -1, files[0].Hunks[1].OldStart)
+	assert.Equal(t, []DiffLine{
+		{Kind: LineContext, Content: "\tcfg := loadConfig()"},
+		{Kind: LineRemoved, Content: "-- old"},
+		{Kind: LineAdded, Content: "++ new"},
+		{Kind: LineRemoved, Content: "\tsrv := newServer(cfg)"},
+		{Kind: LineAdded, Content: "\tsrv := newServer(cfg, log.Default())"},
+	}, files[0].Hunks[0].Lines)
+
+	assert.Equal(t, "", files[1].OldPath)
+	assert.Equal(t, "docs/README.md", files[1].NewPath)
+	assert.Equal(t, "old.txt", files[2].OldPath)
+	assert.Equal(t, "", files[2].NewPath)
+
+	_, err = ParseFileDiffs("just some text\n")
+	assert.Error(t, err)
+}
+
+func TestApplyFileDiff(t *testing.T) {
+	files, err := ParseFileDiffs(`--- a/main.go
++++ b/main.go
+@@ -1,4 +1,4 @@
+ package main
+
+-import "log"
++import "fmt"
+
+@@ -40,4 +40,4 @@ func main() {
+     cfg := loadConfig()
+     srv := newServer(cfg)
+
+-	log.Fatal(srv.ListenAndServe())
++	fmt.Println(srv.ListenAndServe())
+@@ -12,3 +12,3 @@
+ func loadConfig() config {
+-	return config{Debug: true}
++	return config{Debug: false}
+ }
+@@ -12,2 +12,3 @@
+ func loadConfig() config {
++	// Defaults
+ 	return config{}
+ }
+
+ // wrong trailing context
+`)
+	require.NoError(t, err)
+
+	content, results := ApplyFileDiff(unifiedTestFile, files[0])
+	require.Len(t, results, 4)
+	assert.Equal(t, HunkResult{Header: "@@ -1,4 +1,4 @@", Line: 1}, results[0])
+	assert.Equal(t, 6, results[1].Line)
+	assert.Equal(t, "whitespace", results[1].Fuzzy)
+	assert.Contains(t, results[2].Error, `the removed line "\treturn config{Debug: true}" is not in the file`)
+	assert.Equal(t, 13, results[3].Line)
+	assert.Equal(t, "context", results[3].Fuzzy)
+
+	assert.Equal(t, `package main
+
+import "fmt"
+
+func main() {
+	cfg := loadConfig()
+	srv := newServer(cfg)
+
+	fmt.Println(srv.ListenAndServe())
+}
+
+func loadConfig() config {
+	// Defaults
+	return config{}
+}
+`, content)
+}
+
+func TestApplyFileDiffNewFileAndLineEndings(t *testing.T) {
+	files, err := ParseFileDiffs("--- /dev/null\n+++ b/notes.txt\n@@ -0,0 +1,2 @@\n+one\n+two\n--- a/dos.txt\n+++ b/dos.txt\n@@ -1,2 +1,2 @@\n a\n-b\n+c\n")
+	require.NoError(t, err)
+
+	content, results := ApplyFileDiff("", files[0])
+	assert.Equal(t, "one\ntwo\n", content)
+	assert.Empty(t, results[0].Error)
+
+	content, _ = ApplyFileDiff("a\r\nb\r\n", files[1])
+	assert.Equal(t, "a\r\nc\r\n", content)
+}
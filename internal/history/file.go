@@ -25,11 +25,15 @@ type File struct {
 	Version   string
 	CreatedAt int64
 	UpdatedAt int64
+	// IsNew is set on the initial version of a file the session created
+	IsNew bool
 }
 
 type Service interface {
 	pubsub.Suscriber[File]
 	Create(ctx context.Context, sessionID, path, content string) (File, error)
+	// CreateNew records the initial version of a file the session created.
+	CreateNew(ctx context.Context, sessionID, path string) (File, error)
 	CreateVersion(ctx context.Context, sessionID, path, content string) (File, error)
 	Get(ctx context.Context, id string) (File, error)
 	GetByPathAndSession(ctx context.Context, path, sessionID string) (File, error)
@@ -55,7 +59,11 @@ func NewService(q *db.Queries, db *sql.DB) Service {
 }
 
 func (s *service) Create(ctx context.Context, sessionID, path, content string) (File, error) {
-	return s.createWithVersion(ctx, sessionID, path, content, InitialVersion)
+	return s.createWithVersion(ctx, sessionID, path, content, InitialVersion, false)
+}
+
+func (s *service) CreateNew(ctx context.Context, sessionID, path string) (File, error) {
+	return s.createWithVersion(ctx, sessionID, path, "", InitialVersion, true)
 }
 
 func (s *service) CreateVersion(ctx context.Context, sessionID, path, content string) (File, error) {
@@ -91,10 +99,10 @@ func (s *service) CreateVersion(ctx context.Context, sessionID, path, content st
 		nextVersion = fmt.Sprintf("v%d", latestFile.CreatedAt)
 	}
 
-	return s.createWithVersion(ctx, sessionID, path, content, nextVersion)
+	return s.createWithVersion(ctx, sessionID, path, content, nextVersion, false)
 }
 
-func (s *service) createWithVersion(ctx context.Context, sessionID, path, content, version string) (File, error) {
+func (s *service) createWithVersion(ctx context.Context, sessionID, path, content, version string, isNew bool) (File, error) {
 	// Maximum number of retries for transaction conflicts
 	const maxRetries = 3
 	var file File
@@ -118,6 +126,7 @@ func (s *service) createWithVersion(ctx context.Context, sessionID, path, conten
 			Path:      path,
 			Content:   content,
 			Version:   version,
+			IsNew:     isNew,
 		})
 		if txErr != nil {
 			// Rollback the transaction
@@ -248,5 +257,6 @@ func (s *service) fromDBItem(item db.File) File {
 		Version:   item.Version,
 		CreatedAt: item.CreatedAt,
 		UpdatedAt: item.UpdatedAt,
+		IsNew:     item.IsNew,
 	}
 }
@@ -10,31 +10,80 @@ import (
 	"github.com/opencode-ai/opencode/internal/lsp"
 	"github.com/opencode-ai/opencode/internal/message"
 	"github.com/opencode-ai/opencode/internal/session"
+	"github.com/opencode-ai/opencode/internal/usage"
 )
 
 type agentTool struct {
 	sessions   session.Service
 	messages   message.Service
+	usage      usage.Service
 	lspClients map[string]*lsp.Client
 }
 
 const (
 	AgentToolName = "agent"
+
+	// AgentModelTask runs the sub-agent with the model of the task agent,
+	// usually a faster and cheaper one
+	AgentModelTask = "task"
+	// AgentModelCoder runs the sub-agent with the model of the coder agent
+	AgentModelCoder = "coder"
 )
 
 type AgentParams struct {
-	Prompt string `json:"prompt"`
+	Description string `json:"description,omitempty"`
+	Prompt      string `json:"prompt"`
+	Model       string `json:"model,omitempty"`
+}
+
+type AgentResponseMetadata struct {
+	Model            string  `json:"model"`
+	ToolCalls        int     `json:"tool_calls"`
+	PromptTokens     int64   `json:"prompt_tokens"`
+	CompletionTokens int64   `json:"completion_tokens"`
+	Cost             float64 `json:"cost"`
 }
 
+const agentDescription = `Launch a sub-agent that works on a task in a conversation of its own and returns only its final report.
+
+WHEN TO USE THIS TOOL:
+- Use it for searches and investigations that take several steps, like "find where X is configured" or "which files handle Y?", when you are not confident to find the right match on the first try
+- Use it to keep your own context small on big codebases: the files the sub-agent reads and the searches it runs don't become part of your conversation
+- Launch several sub-agents at once for independent questions, with several tool uses in a single message
+
+WHEN NOT TO USE THIS TOOL:
+- To read a file whose path you know, use the view tool
+- To find a definition like "class Foo", use the glob, grep or symbols tool directly
+
+HOW TO USE:
+- Give the task in the prompt with all the details the sub-agent needs, it doesn't see your conversation
+- Say exactly what the report should contain, like the file paths and line numbers of what it finds
+- Give a short description of the task in 3-5 words, it is shown to the user
+- The sub-agent uses the model of the task agent, usually faster and cheaper; set model to "coder" to use your own model for tasks that need more reasoning
+
+LIMITATIONS:
+- The sub-agent can read and search the project with the glob, grep, ls, view, symbols, dependencies, sourcegraph and websearch tools, it can't run commands or change files
+- Each call is stateless: you can't send more messages to the sub-agent, and it can't ask you questions
+- The report is not shown to the user, tell the user what matters in your own response`
+
 func (b *agentTool) Info() tools.ToolInfo {
 	return tools.ToolInfo{
 		Name:        AgentToolName,
-		Description: "Launch a new agent that has access to the following tools: GlobTool, GrepTool, LS, View. When you are searching for a keyword or file and are not confident that you will find the right match on the first try, use the Agent tool to perform the search for you. For example:\n\n- If you are searching for a keyword like \"config\" or \"logger\", or for questions like \"which file does X?\", the Agent tool is strongly recommended\n- If you want to read a specific file path, use the View or GlobTool tool instead of the Agent tool, to find the match more quickly\n- If you are searching for a specific class definition like \"class Foo\", use the GlobTool tool instead, to find the match more quickly\n\nUsage notes:\n1. Launch multiple agents concurrently whenever possible, to maximize performance; to do that, use a single message with multiple tool uses\n2. When the agent is done, it will return a single message back to you. The result returned by the agent is not visible to the user. To show the user the result, you should send a text message back to the user with a concise summary of the result.\n3. Each agent invocation is stateless. You will not be able to send additional messages to the agent, nor will the agent be able to communicate with you outside of its final report. Therefore, your prompt should contain a highly detailed task description for the agent to perform autonomously and you should specify exactly what information the agent should return back to you in its final and only message to you.\n4. The agent's outputs should generally be trusted\n5. IMPORTANT: The agent can not use Bash, Replace, Edit, so can not modify files. If you want to use these tools, use them directly instead of going through the agent.",
+		Description: agentDescription,
 		Parameters: map[string]any{
+			"description": map[string]any{
+				"type":        "string",
+				"description": "A short description of the task in 3-5 words",
+			},
 			"prompt": map[string]any{
 				"type":        "string",
 				"description": "The task for the agent to perform",
 			},
+			"model": map[string]any{
+				"type":        "string",
+				"description": "The model of the sub-agent: task (default) for the model of the task agent, coder for the model of the coder agent",
+				"enum":        []string{AgentModelTask, AgentModelCoder},
+			},
 		},
 		Required: []string{"prompt"},
 	}
@@ -48,23 +97,35 @@ func (b *agentTool) Run(ctx context.Context, call tools.ToolCall) (tools.ToolRes
 	if params.Prompt == "" {
 		return tools.NewTextErrorResponse("prompt is required"), nil
 	}
+	modelOf := config.AgentTask
+	switch params.Model {
+	case "", AgentModelTask:
+	case AgentModelCoder:
+		modelOf = config.AgentCoder
+	default:
+		return tools.NewTextErrorResponse(fmt.Sprintf("invalid model %q, must be %s or %s", params.Model, AgentModelTask, AgentModelCoder)), nil
+	}
 
 	sessionID, messageID := tools.GetContextValues(ctx)
 	if sessionID == "" || messageID == "" {
 		return tools.ToolResponse{}, fmt.Errorf("session_id and message_id are required")
 	}
 
-	agent, err := NewAgent(config.AgentTask, b.sessions, b.messages, TaskAgentTools(b.lspClients))
+	agent, err := b.newTaskAgent(modelOf)
 	if err != nil {
 		return tools.ToolResponse{}, fmt.Errorf("error creating agent: %s", err)
 	}
 
-	session, err := b.sessions.CreateTaskSession(ctx, call.ID, sessionID, "New Agent Session")
+	title := params.Description
+	if title == "" {
+		title = "New Agent Session"
+	}
+	taskSession, err := b.sessions.CreateTaskSession(ctx, call.ID, sessionID, title)
 	if err != nil {
 		return tools.ToolResponse{}, fmt.Errorf("error creating session: %s", err)
 	}
 
-	done, err := agent.Run(ctx, session.ID, params.Prompt)
+	done, err := agent.Run(ctx, taskSession.ID, params.Prompt)
 	if err != nil {
 		return tools.ToolResponse{}, fmt.Errorf("error generating agent: %s", err)
 	}
@@ -78,34 +139,64 @@ func (b *agentTool) Run(ctx context.Context, call tools.ToolCall) (tools.ToolRes
 		return tools.NewTextErrorResponse("no response"), nil
 	}
 
-	updatedSession, err := b.sessions.Get(ctx, session.ID)
+	// The turn of the sub-agent is part of the turn that called it. Sub-agents
+	// run at the same time, so their usage is added in one update.
+	turn := result.Usage()
+	addTurnUsage(ctx, turn)
+	_, err = b.sessions.AddUsage(ctx, sessionID, session.Usage{
+		PromptTokens:     turn.PromptTokens(),
+		CompletionTokens: turn.OutputTokens,
+		Cost:             turn.Cost,
+	})
 	if err != nil {
-		return tools.ToolResponse{}, fmt.Errorf("error getting session: %s", err)
-	}
-	parentSession, err := b.sessions.Get(ctx, sessionID)
-	if err != nil {
-		return tools.ToolResponse{}, fmt.Errorf("error getting parent session: %s", err)
+		return tools.ToolResponse{}, fmt.Errorf("error saving parent session: %s", err)
 	}
 
-	parentSession.Cost += updatedSession.Cost
-	parentSession.PromptTokens += updatedSession.PromptTokens
-	parentSession.CompletionTokens += updatedSession.CompletionTokens
+	metadata := AgentResponseMetadata{
+		Model:            agent.Model().Name,
+		PromptTokens:     turn.PromptTokens(),
+		CompletionTokens: turn.OutputTokens,
+		Cost:             turn.Cost,
+	}
+	if taskMessages, err := b.messages.List(ctx, taskSession.ID); err == nil {
+		for _, msg := range taskMessages {
+			metadata.ToolCalls += len(msg.ToolCalls())
+		}
+	}
+	return tools.WithResponseMetadata(tools.NewTextResponse(response.Content().String()), metadata), nil
+}
 
-	_, err = b.sessions.Save(ctx, parentSession)
+// newTaskAgent creates the sub-agent with the tools of the task agent and the
+// model of the agent modelOf.
+func (b *agentTool) newTaskAgent(modelOf config.AgentName) (*agent, error) {
+	agentConfig, ok := config.Get().Agents[modelOf]
+	if !ok {
+		return nil, fmt.Errorf("agent %s not found", modelOf)
+	}
+	agentProvider, err := createProvider(config.AgentTask, agentConfig)
 	if err != nil {
-		return tools.ToolResponse{}, fmt.Errorf("error saving parent session: %s", err)
+		return nil, err
 	}
-	return tools.NewTextResponse(response.Content().String()), nil
+	return &agent{
+		name:     config.AgentTask,
+		provider: agentProvider,
+		sessions: b.sessions,
+		messages: b.messages,
+		usage:    b.usage,
+		tools:    TaskAgentTools(b.lspClients),
+	}, nil
 }
 
 func NewAgentTool(
 	Sessions session.Service,
 	Messages message.Service,
+	Usage usage.Service,
 	LspClients map[string]*lsp.Client,
 ) tools.BaseTool {
 	return &agentTool{
 		sessions:   Sessions,
 		messages:   Messages,
+		usage:      Usage,
 		lspClients: LspClients,
 	}
 }
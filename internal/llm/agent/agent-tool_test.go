@@ -0,0 +1,100 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/opencode-ai/opencode/internal/config"
+	"github.com/opencode-ai/opencode/internal/llm/models"
+	"github.com/opencode-ai/opencode/internal/llm/tools"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAgentToolModel(t *testing.T) {
+	var requested []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request struct {
+			Model string `json:"model"`
+		}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+		requested = append(requested, request.Model)
+		fmt.Fprintln(w, `{"message":{"role":"assistant","content":"The port is set in config.go:42."},"done":true,"done_reason":"stop","prompt_eval_count":1000,"eval_count":20}`)
+	}))
+	t.Cleanup(server.Close)
+
+	a, sess := newTestAgent(t)
+	small, large := models.OllamaModel("qwen2.5-coder:1.5b"), models.OllamaModel("qwen2.5-coder:32b")
+	models.RegisterOllamaModels(map[models.ModelID]models.Model{small.ID: small, large.ID: large})
+	cfg := config.Get()
+	previousAgents := map[config.AgentName]config.Agent{config.AgentTask: cfg.Agents[config.AgentTask], config.AgentCoder: cfg.Agents[config.AgentCoder]}
+	previousProvider, hadProvider := cfg.Providers[models.ProviderOllama]
+	cfg.Agents[config.AgentTask] = config.Agent{Model: small.ID, MaxTokens: 1000}
+	cfg.Agents[config.AgentCoder] = config.Agent{Model: large.ID, MaxTokens: 1000}
+	cfg.Providers[models.ProviderOllama] = config.Provider{BaseURL: server.URL}
+	t.Cleanup(func() {
+		for name, agentConfig := range previousAgents {
+			cfg.Agents[name] = agentConfig
+		}
+		if hadProvider {
+			cfg.Providers[models.ProviderOllama] = previousProvider
+		} else {
+			delete(cfg.Providers, models.ProviderOllama)
+		}
+		for _, id := range []models.ModelID{small.ID, large.ID} {
+			delete(models.SupportedModels, id)
+			delete(models.OllamaModels, id)
+		}
+	})
+
+	tool := NewAgentTool(a.sessions, a.messages, a.usage, nil)
+	ctx := context.WithValue(context.Background(), tools.SessionIDContextKey, sess.ID)
+	ctx = context.WithValue(ctx, tools.MessageIDContextKey, "msg-1")
+
+	tests := []struct {
+		name  string
+		input string
+		model models.Model
+		title string
+	}{
+		{name: "task model by default", input: `{"prompt":"find where the port is configured"}`, model: small, title: "New Agent Session"},
+		{name: "task model", input: `{"prompt":"find where the port is configured","model":"task"}`, model: small, title: "New Agent Session"},
+		{name: "coder model", input: `{"description":"Find the port","prompt":"find where the port is configured","model":"coder"}`, model: large, title: "Find the port"},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requested = nil
+			response, err := tool.Run(ctx, tools.ToolCall{ID: fmt.Sprintf("call-%d", i), Name: AgentToolName, Input: tt.input})
+			require.NoError(t, err)
+			require.False(t, response.IsError, response.Content)
+			// Only the report of the sub-agent is returned
+			assert.Equal(t, "The port is set in config.go:42.", response.Content)
+			assert.Equal(t, []string{tt.model.APIModel}, requested)
+
+			var metadata AgentResponseMetadata
+			require.NoError(t, json.Unmarshal([]byte(response.Metadata), &metadata))
+			assert.Equal(t, tt.model.Name, metadata.Model)
+			assert.Equal(t, int64(1000), metadata.PromptTokens)
+			assert.Equal(t, int64(20), metadata.CompletionTokens)
+
+			// The conversation of the sub-agent is a session of its own
+			task, err := a.sessions.Get(context.Background(), fmt.Sprintf("call-%d", i))
+			require.NoError(t, err)
+			assert.Equal(t, sess.ID, task.ParentSessionID)
+			assert.Equal(t, tt.title, task.Title)
+		})
+	}
+
+	parent, err := a.sessions.Get(context.Background(), sess.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3*1000), parent.PromptTokens)
+
+	response, err := tool.Run(ctx, tools.ToolCall{ID: "call-invalid", Name: AgentToolName, Input: `{"prompt":"find it","model":"gpt-4o"}`})
+	require.NoError(t, err)
+	assert.True(t, response.IsError)
+	assert.Equal(t, `invalid model "gpt-4o", must be task or coder`, response.Content)
+}
@@ -2,20 +2,28 @@ package agent
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"path/filepath"
+	"regexp"
+	"slices"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/opencode-ai/opencode/internal/config"
+	"github.com/opencode-ai/opencode/internal/llm/discovery"
 	"github.com/opencode-ai/opencode/internal/llm/models"
 	"github.com/opencode-ai/opencode/internal/llm/prompt"
 	"github.com/opencode-ai/opencode/internal/llm/provider"
+	"github.com/opencode-ai/opencode/internal/llm/tokenizer"
 	"github.com/opencode-ai/opencode/internal/llm/tools"
+	"github.com/opencode-ai/opencode/internal/llm/tools/shell"
 	"github.com/opencode-ai/opencode/internal/logging"
 	"github.com/opencode-ai/opencode/internal/message"
-	"github.com/opencode-ai/opencode/internal/permission"
 	"github.com/opencode-ai/opencode/internal/session"
+	"github.com/opencode-ai/opencode/internal/usage"
 )
 
 // Common errors
@@ -27,6 +35,7 @@ var (
 type AgentEvent struct {
 	message message.Message
 	err     error
+	usage   TurnUsage
 }
 
 func (e *AgentEvent) Err() error {
@@ -37,51 +46,98 @@ func (e *AgentEvent) Response() message.Message {
 	return e.message
 }
 
+// Usage is the usage of the turn, also when it failed or was canceled.
+func (e *AgentEvent) Usage() TurnUsage {
+	return e.usage
+}
+
 type Service interface {
-	Run(ctx context.Context, sessionID string, content string) (<-chan AgentEvent, error)
+	// Run sends the content to the agent as a user message, with the
+	// attachments, like screenshots, as its images.
+	Run(ctx context.Context, sessionID string, content string, attachments ...message.BinaryContent) (<-chan AgentEvent, error)
 	Cancel(sessionID string)
 	IsSessionBusy(sessionID string) bool
 	IsBusy() bool
+	// SetReasoning changes the reasoning effort and the thinking budget of
+	// the agent's model, it fails while a request is running.
+	SetReasoning(effort string, thinkingBudget int64) error
+	// SetModel switches the agent to another model for the following turns,
+	// sessions keep their history. It fails while a request is running.
+	SetModel(modelID models.ModelID) error
+	Model() models.Model
 }
 
 type agent struct {
+	name     config.AgentName
 	sessions session.Service
 	messages message.Service
+	usage    usage.Service
 
-	tools    []tools.BaseTool
-	provider provider.Provider
+	tools []tools.BaseTool
+	// provider is replaced when the model or the reasoning of the agent
+	// changes, providerMu guards it. Turns use the provider they started with.
+	providerMu sync.RWMutex
+	provider   provider.Provider
 
 	titleProvider provider.Provider
+	// summaryProvider summarizes long conversations, they are summarized by
+	// the provider of the agent when it is nil
+	summaryProvider provider.Provider
+
+	// fileChanges are the changes to the working directory made outside of
+	// the sessions, only watched for the coder agent
+	fileChanges *fileChanges
 
 	activeRequests sync.Map
+
+	// budgetChecked holds the usage of each session last checked against the
+	// budget, by session ID
+	budgetChecked sync.Map
+
+	// sessionMu serializes the changes of the agent to its sessions, the
+	// title is saved while the turn updates the session
+	sessionMu sync.Mutex
 }
 
 func NewAgent(
 	agentName config.AgentName,
 	sessions session.Service,
 	messages message.Service,
+	usage usage.Service,
 	agentTools []tools.BaseTool,
 ) (Service, error) {
 	agentProvider, err := createAgentProvider(agentName)
 	if err != nil {
 		return nil, err
 	}
-	var titleProvider provider.Provider
-	// Only generate titles for the coder agent
+	var titleProvider, summaryProvider provider.Provider
+	var changes *fileChanges
+	// Only generate titles and watch the files for the coder agent
 	if agentName == config.AgentCoder {
 		titleProvider, err = createAgentProvider(config.AgentTitle)
 		if err != nil {
 			return nil, err
 		}
+		if _, ok := config.Get().Agents[config.AgentSummarizer]; ok {
+			summaryProvider, err = createAgentProvider(config.AgentSummarizer)
+			if err != nil {
+				return nil, err
+			}
+		}
+		changes = watchFileChanges(config.WorkingDirectory())
 	}
 
 	agent := &agent{
-		provider:       agentProvider,
-		messages:       messages,
-		sessions:       sessions,
-		tools:          agentTools,
-		titleProvider:  titleProvider,
-		activeRequests: sync.Map{},
+		name:            agentName,
+		provider:        agentProvider,
+		messages:        messages,
+		sessions:        sessions,
+		usage:           usage,
+		tools:           agentTools,
+		titleProvider:   titleProvider,
+		summaryProvider: summaryProvider,
+		fileChanges:     changes,
+		activeRequests:  sync.Map{},
 	}
 
 	return agent, nil
@@ -115,14 +171,72 @@ func (a *agent) IsSessionBusy(sessionID string) bool {
 	return busy
 }
 
-func (a *agent) generateTitle(ctx context.Context, sessionID string, content string) error {
-	if a.titleProvider == nil {
-		return nil
+func (a *agent) Model() models.Model {
+	a.providerMu.RLock()
+	defer a.providerMu.RUnlock()
+	return a.provider.Model()
+}
+
+func (a *agent) SetReasoning(effort string, thinkingBudget int64) error {
+	return a.reloadProvider(func() error {
+		return config.UpdateAgentReasoning(a.name, effort, thinkingBudget)
+	})
+}
+
+func (a *agent) SetModel(modelID models.ModelID) error {
+	return a.reloadProvider(func() error {
+		return config.UpdateAgentModel(a.name, modelID)
+	})
+}
+
+// reloadProvider changes the configuration of the agent with update and
+// creates the provider again. It is refused while a turn runs, no turn starts
+// until the provider is replaced.
+func (a *agent) reloadProvider(update func() error) error {
+	a.providerMu.Lock()
+	defer a.providerMu.Unlock()
+	if a.IsBusy() {
+		return ErrSessionBusy
 	}
-	session, err := a.sessions.Get(ctx, sessionID)
+	if err := update(); err != nil {
+		return err
+	}
+	agentProvider, err := createAgentProvider(a.name)
 	if err != nil {
 		return err
 	}
+	a.provider = agentProvider
+	return nil
+}
+
+type turnProviderKey struct{}
+
+// startTurn marks the session busy and returns the provider for its turn,
+// false when the session is busy already.
+func (a *agent) startTurn(sessionID string, cancel context.CancelFunc) (provider.Provider, bool) {
+	a.providerMu.RLock()
+	defer a.providerMu.RUnlock()
+	if _, busy := a.activeRequests.LoadOrStore(sessionID, cancel); busy {
+		return nil, false
+	}
+	return a.provider, true
+}
+
+// turnProvider returns the provider the turn of the context started with, or
+// the current provider of the agent outside of a turn.
+func (a *agent) turnProvider(ctx context.Context) provider.Provider {
+	if p, ok := ctx.Value(turnProviderKey{}).(provider.Provider); ok {
+		return p
+	}
+	a.providerMu.RLock()
+	defer a.providerMu.RUnlock()
+	return a.provider
+}
+
+func (a *agent) generateTitle(ctx context.Context, sessionID string, content string) error {
+	if a.titleProvider == nil {
+		return nil
+	}
 	response, err := a.titleProvider.SendMessages(
 		ctx,
 		[]message.Message{
@@ -140,15 +254,39 @@ func (a *agent) generateTitle(ctx context.Context, sessionID string, content str
 	if err != nil {
 		return err
 	}
+	model := a.titleProvider.Model()
+	if fallback, ok := models.SupportedModels[response.Model]; ok {
+		model = fallback
+	}
+	if err := a.TrackUsage(ctx, sessionID, "", model, response.Usage); err != nil {
+		return err
+	}
 
 	title := strings.TrimSpace(strings.ReplaceAll(response.Content, "\n", " "))
 	if title == "" {
 		return nil
 	}
 
-	session.Title = title
-	_, err = a.sessions.Save(ctx, session)
-	return err
+	return a.updateSession(ctx, sessionID, func(sess *session.Session) {
+		sess.Title = title
+	})
+}
+
+// updateSession reads, changes and saves the session. The changes of the
+// agent are serialized, so they don't overwrite each other with the session
+// they read before.
+func (a *agent) updateSession(ctx context.Context, sessionID string, update func(*session.Session)) error {
+	a.sessionMu.Lock()
+	defer a.sessionMu.Unlock()
+	sess, err := a.sessions.Get(ctx, sessionID)
+	if err != nil {
+		return fmt.Errorf("failed to get session: %w", err)
+	}
+	update(&sess)
+	if _, err := a.sessions.Save(ctx, sess); err != nil {
+		return fmt.Errorf("failed to save session: %w", err)
+	}
+	return nil
 }
 
 func (a *agent) err(err error) AgentEvent {
@@ -157,23 +295,27 @@ func (a *agent) err(err error) AgentEvent {
 	}
 }
 
-func (a *agent) Run(ctx context.Context, sessionID string, content string) (<-chan AgentEvent, error) {
+func (a *agent) Run(ctx context.Context, sessionID string, content string, attachments ...message.BinaryContent) (<-chan AgentEvent, error) {
 	events := make(chan AgentEvent)
-	if a.IsSessionBusy(sessionID) {
+	genCtx, cancel := context.WithCancel(ctx)
+	turnProvider, ok := a.startTurn(sessionID, cancel)
+	if !ok {
+		cancel()
 		return nil, ErrSessionBusy
 	}
+	genCtx = context.WithValue(genCtx, turnProviderKey{}, turnProvider)
 
-	genCtx, cancel := context.WithCancel(ctx)
-
-	a.activeRequests.Store(sessionID, cancel)
 	go func() {
 		logging.Debug("Request started", "sessionID", sessionID)
 		defer logging.RecoverPanic("agent.Run", func() {
 			events <- a.err(fmt.Errorf("panic while running the agent"))
 		})
 
-		result := a.processGeneration(genCtx, sessionID, content)
-		if result.Err() != nil && !errors.Is(result.Err(), ErrRequestCancelled) && !errors.Is(result.Err(), context.Canceled) {
+		turnCtx, turn := withTurnUsage(genCtx)
+		result := a.processGeneration(turnCtx, sessionID, content, attachments)
+		result.usage = turn.total()
+		logging.Debug("Turn usage", "sessionID", sessionID, "requests", result.usage.Requests, "prompt_tokens", result.usage.PromptTokens(), "completion_tokens", result.usage.OutputTokens, "cost", result.usage.Cost)
+		if result.Err() != nil && !errors.Is(result.Err(), ErrRequestCancelled) && !errors.Is(result.Err(), context.Canceled) && !errors.Is(result.Err(), ErrBudgetReached) {
 			logging.ErrorPersist(fmt.Sprintf("Generation error for session %s: %v", sessionID, result))
 		}
 		logging.Debug("Request completed", "sessionID", sessionID)
@@ -185,7 +327,7 @@ func (a *agent) Run(ctx context.Context, sessionID string, content string) (<-ch
 	return events, nil
 }
 
-func (a *agent) processGeneration(ctx context.Context, sessionID, content string) AgentEvent {
+func (a *agent) processGeneration(ctx context.Context, sessionID, content string, attachments []message.BinaryContent) AgentEvent {
 	// List existing messages; if none, start title generation asynchronously.
 	msgs, err := a.messages.List(ctx, sessionID)
 	if err != nil {
@@ -203,13 +345,52 @@ func (a *agent) processGeneration(ctx context.Context, sessionID, content string
 		}()
 	}
 
-	userMsg, err := a.createUserMessage(ctx, sessionID, content)
+	userMsg, err := a.createUserMessage(ctx, sessionID, content, attachments)
 	if err != nil {
 		return a.err(fmt.Errorf("failed to create user message: %w", err))
 	}
+	// The named shells of the bash tool only live for the turn
+	defer shell.CloseSessionShells(sessionID)
+
+	sess, err := a.sessions.Get(ctx, sessionID)
+	if err != nil {
+		return a.err(fmt.Errorf("failed to get session: %w", err))
+	}
 
-	// Append the new user message to the conversation history.
-	msgHistory := append(msgs, userMsg)
+	// Tell the model about the files changed since its last turn, and that
+	// it should only plan in plan mode. The notes are only sent with this
+	// turn, they aren't part of the stored message.
+	text := content
+	if note := a.fileChanges.note(sessionID); note != "" && len(msgs) > 0 {
+		text += "\n\n" + note
+	}
+	if sess.PlanMode {
+		text += "\n\n" + prompt.PlanModePrompt()
+	}
+	if text != content {
+		userMsg.Parts = slices.Clone(userMsg.Parts)
+		userMsg.Parts[0] = message.TextContent{Text: text}
+	}
+
+	// Append the new user message to the conversation history, the messages
+	// of a compacted conversation start with the summary of the earlier ones.
+	msgHistory := sessionHistory(sess, append(msgs, userMsg))
+	// Only the sessions of the user have a budget, sub-agents can't ask the
+	// user to continue
+	budget := config.Get().Budget
+	checksBudget := sess.ParentSessionID == ""
+	if checksBudget {
+		// The session may have reached a limit since it was last checked,
+		// e.g. by the summaries of its conversation
+		reached, err := a.checkSessionBudget(ctx, sessionID, budget, 0, 0)
+		if err != nil {
+			return a.err(err)
+		}
+		if reached != "" {
+			return a.err(fmt.Errorf("%w: %s", ErrBudgetReached, reached))
+		}
+	}
+	toolCalls := 0
 	for {
 		// Check for cancellation before each iteration
 		select {
@@ -218,6 +399,7 @@ func (a *agent) processGeneration(ctx context.Context, sessionID, content string
 		default:
 			// Continue processing
 		}
+		msgHistory = a.compact(ctx, sessionID, msgHistory)
 		agentMessage, toolResults, err := a.streamAndHandleEvents(ctx, sessionID, msgHistory)
 		if err != nil {
 			if errors.Is(err, context.Canceled) {
@@ -227,6 +409,21 @@ func (a *agent) processGeneration(ctx context.Context, sessionID, content string
 			}
 			return a.err(fmt.Errorf("failed to process events: %w", err))
 		}
+		toolCallsBefore := toolCalls
+		toolCalls += len(agentMessage.ToolCalls())
+		if checksBudget {
+			reached, err := a.checkSessionBudget(ctx, sessionID, budget, toolCallsBefore, toolCalls)
+			if err != nil {
+				return a.err(err)
+			}
+			if reached != "" {
+				// Pause with the tool results stored, a new message continues
+				return AgentEvent{
+					message: agentMessage,
+					err:     fmt.Errorf("%w: %s", ErrBudgetReached, reached),
+				}
+			}
+		}
 		logging.Info("Result", "message", agentMessage.FinishReason(), "toolResults", toolResults)
 		if (agentMessage.FinishReason() == message.FinishReasonToolUse) && toolResults != nil {
 			// We are not done, we need to respond with the tool response
@@ -239,25 +436,86 @@ func (a *agent) processGeneration(ctx context.Context, sessionID, content string
 	}
 }
 
-func (a *agent) createUserMessage(ctx context.Context, sessionID, content string) (message.Message, error) {
+func (a *agent) createUserMessage(ctx context.Context, sessionID, content string, attachments []message.BinaryContent) (message.Message, error) {
+	parts := []message.ContentPart{
+		message.TextContent{Text: content},
+	}
+	for _, attachment := range attachments {
+		parts = append(parts, attachment)
+	}
 	return a.messages.Create(ctx, sessionID, message.CreateMessageParams{
-		Role: message.User,
-		Parts: []message.ContentPart{
-			message.TextContent{Text: content},
-		},
+		Role:  message.User,
+		Parts: parts,
 	})
 }
 
 func (a *agent) streamAndHandleEvents(ctx context.Context, sessionID string, msgHistory []message.Message) (message.Message, *message.Message, error) {
-	eventChan := a.provider.StreamResponse(ctx, msgHistory, a.tools)
+	available, err := a.sessionTools(ctx, sessionID)
+	if err != nil {
+		return message.Message{}, nil, err
+	}
+	if err := a.estimateContext(ctx, sessionID, msgHistory, available); err != nil {
+		return message.Message{}, nil, err
+	}
+	// The example exchanges for the model come before the conversation
+	if examples := exampleMessages(a.turnProvider(ctx).Model(), available); len(examples) > 0 {
+		msgHistory = append(examples, msgHistory...)
+	}
+
+	var assistantMsg message.Message
+	// rejected is the response with invalid calls the next one replaces, its
+	// usage is billed to the replacement
+	var rejected string
+	for repairs := 0; ; repairs++ {
+		assistantMsg, err = a.streamResponse(ctx, sessionID, msgHistory, available)
+		if rejected != "" && assistantMsg.ID != "" {
+			if err := a.usage.MoveToMessage(ctx, rejected, assistantMsg.ID); err != nil {
+				return assistantMsg, nil, fmt.Errorf("failed to move the usage of the message: %w", err)
+			}
+		}
+		if err != nil {
+			return assistantMsg, nil, err
+		}
+		invalid := invalidToolCalls(assistantMsg.ToolCalls(), available)
+		if len(invalid) == 0 || repairs == maxToolCallRepairs {
+			break
+		}
+		logging.Warn("Asking the model to repair the arguments of its tool calls", "message", assistantMsg.ID, "invalid", len(invalid))
+		// The response with the invalid calls and the corrective turn aren't
+		// kept, the repaired response replaces them
+		if err := a.messages.Delete(ctx, assistantMsg.ID); err != nil {
+			return assistantMsg, nil, fmt.Errorf("failed to delete message: %w", err)
+		}
+		rejected = assistantMsg.ID
+		msgHistory = append(slices.Clip(msgHistory), withObjectInputs(assistantMsg), repairMessage(assistantMsg, invalid))
+	}
+
+	if len(assistantMsg.ToolCalls()) == 0 {
+		return assistantMsg, nil, nil
+	}
+	// Add the session and message ID into the context if needed by tools.
+	ctx = context.WithValue(ctx, tools.MessageIDContextKey, assistantMsg.ID)
+	ctx = context.WithValue(ctx, tools.SessionIDContextKey, sessionID)
+	msg, err := a.runToolCalls(ctx, &assistantMsg, available)
+	if err != nil {
+		return assistantMsg, nil, err
+	}
+	return assistantMsg, msg, nil
+}
+
+// streamResponse streams the response of the model to the conversation, with
+// the tools available, into a new assistant message.
+func (a *agent) streamResponse(ctx context.Context, sessionID string, msgHistory []message.Message, available []tools.BaseTool) (message.Message, error) {
+	turnProvider := a.turnProvider(ctx)
+	eventChan := turnProvider.StreamResponse(ctx, msgHistory, available)
 
 	assistantMsg, err := a.messages.Create(ctx, sessionID, message.CreateMessageParams{
 		Role:  message.Assistant,
 		Parts: []message.ContentPart{},
-		Model: a.provider.Model().ID,
+		Model: turnProvider.Model().ID,
 	})
 	if err != nil {
-		return assistantMsg, nil, fmt.Errorf("failed to create assistant message: %w", err)
+		return assistantMsg, fmt.Errorf("failed to create assistant message: %w", err)
 	}
 
 	// Add the session and message ID into the context if needed by tools.
@@ -266,98 +524,49 @@ func (a *agent) streamAndHandleEvents(ctx context.Context, sessionID string, msg
 
 	// Process each event in the stream.
 	for event := range eventChan {
+		if isCanceledCompletion(event) {
+			keepPartialContent(&assistantMsg, event)
+			a.finishCanceled(&assistantMsg, eventChan)
+			return assistantMsg, context.Canceled
+		}
 		if processErr := a.processEvent(ctx, sessionID, &assistantMsg, event); processErr != nil {
-			a.finishMessage(ctx, &assistantMsg, message.FinishReasonCanceled)
-			return assistantMsg, nil, processErr
+			if errors.Is(processErr, context.Canceled) {
+				a.finishCanceled(&assistantMsg, eventChan)
+			} else {
+				a.finishMessage(context.Background(), &assistantMsg, message.FinishReasonCanceled)
+			}
+			return assistantMsg, processErr
 		}
 		if ctx.Err() != nil {
-			a.finishMessage(context.Background(), &assistantMsg, message.FinishReasonCanceled)
-			return assistantMsg, nil, ctx.Err()
+			a.finishCanceled(&assistantMsg, eventChan)
+			return assistantMsg, ctx.Err()
 		}
 	}
+	return assistantMsg, nil
+}
 
-	toolResults := make([]message.ToolResult, len(assistantMsg.ToolCalls()))
-	toolCalls := assistantMsg.ToolCalls()
-	for i, toolCall := range toolCalls {
-		select {
-		case <-ctx.Done():
-			a.finishMessage(context.Background(), &assistantMsg, message.FinishReasonCanceled)
-			// Make all future tool calls cancelled
-			for j := i; j < len(toolCalls); j++ {
-				toolResults[j] = message.ToolResult{
-					ToolCallID: toolCalls[j].ID,
-					Content:    "Tool execution canceled by user",
-					IsError:    true,
-				}
-			}
-			goto out
-		default:
-			// Continue processing
-			var tool tools.BaseTool
-			for _, availableTools := range a.tools {
-				if availableTools.Info().Name == toolCall.Name {
-					tool = availableTools
-				}
-			}
-
-			// Tool not found
-			if tool == nil {
-				toolResults[i] = message.ToolResult{
-					ToolCallID: toolCall.ID,
-					Content:    fmt.Sprintf("Tool not found: %s", toolCall.Name),
-					IsError:    true,
-				}
-				continue
-			}
-
-			toolResult, toolErr := tool.Run(ctx, tools.ToolCall{
-				ID:    toolCall.ID,
-				Name:  toolCall.Name,
-				Input: toolCall.Input,
-			})
-			if toolErr != nil {
-				if errors.Is(toolErr, permission.ErrorPermissionDenied) {
-					toolResults[i] = message.ToolResult{
-						ToolCallID: toolCall.ID,
-						Content:    "Permission denied",
-						IsError:    true,
-					}
-					for j := i + 1; j < len(toolCalls); j++ {
-						toolResults[j] = message.ToolResult{
-							ToolCallID: toolCalls[j].ID,
-							Content:    "Tool execution canceled by user",
-							IsError:    true,
-						}
-					}
-					a.finishMessage(ctx, &assistantMsg, message.FinishReasonPermissionDenied)
-					break
-				}
-			}
-			toolResults[i] = message.ToolResult{
-				ToolCallID: toolCall.ID,
-				Content:    toolResult.Content,
-				Metadata:   toolResult.Metadata,
-				IsError:    toolResult.IsError,
-			}
+// finishCanceled reads the rest of a canceled stream, whose canceled
+// completion carries the content generated before the interrupt, and saves the
+// message with it. The deltas streamed after the interrupt aren't processed.
+func (a *agent) finishCanceled(msg *message.Message, events <-chan provider.ProviderEvent) {
+	for event := range events {
+		if isCanceledCompletion(event) {
+			keepPartialContent(msg, event)
 		}
 	}
-out:
-	if len(toolResults) == 0 {
-		return assistantMsg, nil, nil
-	}
-	parts := make([]message.ContentPart, 0)
-	for _, tr := range toolResults {
-		parts = append(parts, tr)
-	}
-	msg, err := a.messages.Create(context.Background(), assistantMsg.SessionID, message.CreateMessageParams{
-		Role:  message.Tool,
-		Parts: parts,
-	})
-	if err != nil {
-		return assistantMsg, nil, fmt.Errorf("failed to create cancelled tool message: %w", err)
-	}
+	a.finishMessage(context.Background(), msg, message.FinishReasonCanceled)
+}
 
-	return assistantMsg, &msg, err
+func isCanceledCompletion(event provider.ProviderEvent) bool {
+	return event.Type == provider.EventComplete && event.Response.FinishReason == message.FinishReasonCanceled
+}
+
+// keepPartialContent adds the content of a canceled completion that wasn't
+// streamed to the message yet.
+func keepPartialContent(msg *message.Message, event provider.ProviderEvent) {
+	if rest, ok := strings.CutPrefix(event.Response.Content, msg.Content().Text); ok && rest != "" {
+		msg.AppendContent(rest)
+	}
 }
 
 func (a *agent) finishMessage(ctx context.Context, msg *message.Message, finishReson message.FinishReason) {
@@ -375,7 +584,7 @@ func (a *agent) processEvent(ctx context.Context, sessionID string, assistantMsg
 
 	switch event.Type {
 	case provider.EventThinkingDelta:
-		assistantMsg.AppendReasoningContent(event.Content)
+		assistantMsg.AppendReasoningContent(event.Thinking)
 		return a.messages.Update(ctx, *assistantMsg)
 	case provider.EventContentDelta:
 		assistantMsg.AppendContent(event.Content)
@@ -405,34 +614,141 @@ func (a *agent) processEvent(ctx context.Context, sessionID string, assistantMsg
 	case provider.EventComplete:
 		assistantMsg.SetToolCalls(event.Response.ToolCalls)
 		assistantMsg.AddFinish(event.Response.FinishReason)
+		model := a.turnProvider(ctx).Model()
+		// Record the fallback model that answered the turn
+		if fallback, ok := models.SupportedModels[event.Response.Model]; ok && fallback.ID != model.ID {
+			model = fallback
+			assistantMsg.Model = fallback.ID
+		}
 		if err := a.messages.Update(ctx, *assistantMsg); err != nil {
 			return fmt.Errorf("failed to update message: %w", err)
 		}
-		return a.TrackUsage(ctx, sessionID, a.provider.Model(), event.Response.Usage)
+		return a.TrackUsage(ctx, sessionID, assistantMsg.ID, model, event.Response.Usage)
 	}
 
 	return nil
 }
 
-func (a *agent) TrackUsage(ctx context.Context, sessionID string, model models.Model, usage provider.TokenUsage) error {
+// sessionTools returns the tools of the agent the tool profile of the session
+// offers, only the read-only ones in plan mode. Sessions of sub-agents without
+// a profile of their own use the one of their parent.
+func (a *agent) sessionTools(ctx context.Context, sessionID string) ([]tools.BaseTool, error) {
+	if len(a.tools) == 0 {
+		return a.tools, nil
+	}
 	sess, err := a.sessions.Get(ctx, sessionID)
 	if err != nil {
-		return fmt.Errorf("failed to get session: %w", err)
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+	planMode := sess.PlanMode
+	for sess.ToolProfile == "" && sess.ParentSessionID != "" {
+		if sess, err = a.sessions.Get(ctx, sess.ParentSessionID); err != nil {
+			return nil, fmt.Errorf("failed to get parent session: %w", err)
+		}
+	}
+	profile, ok := config.GetToolProfile(sess.ToolProfile)
+	if !ok {
+		logging.Warn("Unknown tool profile, no tools are offered", "session", sessionID, "profile", sess.ToolProfile)
+	}
+	readOnly, _ := config.GetToolProfile(config.ToolProfileReadOnly)
+	var available []tools.BaseTool
+	for _, tool := range a.tools {
+		name := tool.Info().Name
+		if profile.Allows(name) && (!planMode || readOnly.Allows(name)) {
+			available = append(available, tool)
+		}
+	}
+	return available, nil
+}
+
+// estimateContext estimates the size of the prompt of the next turn, the
+// system prompt, the tool definitions and the conversation, and records it on
+// the session before the provider reports its own count.
+func (a *agent) estimateContext(ctx context.Context, sessionID string, msgHistory []message.Message, available []tools.BaseTool) error {
+	model := a.turnProvider(ctx).Model()
+	contextTokens := a.contextSize(model, msgHistory, available)
+	if model.ContextWindow > 0 && contextTokens > model.ContextWindow {
+		logging.Warn("The conversation may not fit in the context window of the model", "model", model.Name, "estimated_tokens", contextTokens, "context_window", model.ContextWindow)
 	}
 
-	cost := model.CostPer1MInCached/1e6*float64(usage.CacheCreationTokens) +
-		model.CostPer1MOutCached/1e6*float64(usage.CacheReadTokens) +
-		model.CostPer1MIn/1e6*float64(usage.InputTokens) +
-		model.CostPer1MOut/1e6*float64(usage.OutputTokens)
+	return a.updateSession(ctx, sessionID, func(sess *session.Session) {
+		sess.ContextTokens = contextTokens
+	})
+}
+
+// contextSize estimates the tokens of a prompt to the model with the
+// conversation, the example exchanges for the model and the tools.
+func (a *agent) contextSize(model models.Model, msgHistory []message.Message, available []tools.BaseTool) int64 {
+	t := tokenizer.ForModel(model)
+	contextTokens := t.Count(prompt.GetAgentPrompt(a.name, model.Provider)) + tokenizer.CountMessages(t, msgHistory)
+	contextTokens += tokenizer.CountMessages(t, exampleMessages(model, available))
+	for _, tool := range available {
+		info := tool.Info()
+		params, _ := json.Marshal(info.Parameters)
+		contextTokens += t.Count(info.Name) + t.Count(info.Description) + t.Count(string(params))
+	}
+	return contextTokens
+}
+
+// TrackUsage adds the usage of a request to the session, and to the turn of
+// the context.
+func (a *agent) TrackUsage(ctx context.Context, sessionID, messageID string, model models.Model, tokens provider.TokenUsage) error {
+	cost := usageCost(model, tokens)
+	if err := trackUsage(ctx, a.sessions, a.usage, sessionID, messageID, model, tokens, cost); err != nil {
+		return err
+	}
+	addTurnUsage(ctx, TurnUsage{TokenUsage: tokens, Cost: cost, Requests: 1})
+	return nil
+}
 
-	sess.Cost += cost
-	sess.CompletionTokens += usage.OutputTokens
-	sess.PromptTokens += usage.InputTokens
+// usageCost is the price of the tokens at the prices of the model.
+func usageCost(model models.Model, tokens provider.TokenUsage) float64 {
+	return model.CostPer1MInCached/1e6*float64(tokens.CacheCreationTokens) +
+		model.CostPer1MOutCached/1e6*float64(tokens.CacheReadTokens) +
+		model.CostPer1MIn/1e6*float64(tokens.InputTokens) +
+		model.CostPer1MOut/1e6*float64(tokens.OutputTokens)
+}
 
-	_, err = a.sessions.Save(ctx, sess)
+// trackUsage adds the tokens and their cost to the session and records them
+// in the usage history.
+func trackUsage(ctx context.Context, sessions session.Service, usageService usage.Service, sessionID, messageID string, model models.Model, tokens provider.TokenUsage, cost float64) error {
+	// Cached prompt tokens are billed differently, but they are still part
+	// of the prompt
+	promptTokens := tokens.InputTokens + tokens.CacheCreationTokens + tokens.CacheReadTokens
+	update := session.Usage{
+		PromptTokens:     promptTokens,
+		CompletionTokens: tokens.OutputTokens,
+		Cost:             cost,
+	}
+	// The count of the provider replaces the estimate of the turn, the
+	// prompts of titles and summaries aren't the conversation
+	if promptTokens > 0 && messageID != "" {
+		update.ContextTokens = promptTokens + tokens.OutputTokens
+	}
+	sess, err := sessions.AddUsage(ctx, sessionID, update)
 	if err != nil {
 		return fmt.Errorf("failed to save session: %w", err)
 	}
+
+	// Task and title sessions are billed to the session they belong to
+	billedSessionID := sess.ID
+	if sess.ParentSessionID != "" {
+		billedSessionID = sess.ParentSessionID
+	}
+	err = usageService.Record(ctx, usage.Record{
+		SessionID:           billedSessionID,
+		MessageID:           messageID,
+		Provider:            model.Provider,
+		Model:               model.ID,
+		InputTokens:         tokens.InputTokens,
+		OutputTokens:        tokens.OutputTokens,
+		CacheCreationTokens: tokens.CacheCreationTokens,
+		CacheReadTokens:     tokens.CacheReadTokens,
+		Cost:                cost,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record usage: %w", err)
+	}
 	return nil
 }
 
@@ -442,50 +758,336 @@ func createAgentProvider(agentName config.AgentName) (provider.Provider, error)
 	if !ok {
 		return nil, fmt.Errorf("agent %s not found", agentName)
 	}
+	return createProvider(agentName, agentConfig)
+}
+
+// createProvider creates the provider of the agent for the configuration of
+// its model, with the fallback models of the configuration.
+func createProvider(agentName config.AgentName, agentConfig config.Agent) (provider.Provider, error) {
+	primary, err := createModelProvider(agentName, agentConfig)
+	if err != nil {
+		return nil, err
+	}
+	if len(agentConfig.Fallback) == 0 {
+		return primary, nil
+	}
+
+	var fallbacks []provider.Provider
+	for _, modelID := range agentConfig.Fallback {
+		// The max tokens of the agent are tuned for its own model
+		fallbackConfig := agentConfig
+		fallbackConfig.Model = modelID
+		fallbackConfig.MaxTokens = 0
+		fallback, err := createModelProvider(agentName, fallbackConfig)
+		if err != nil {
+			logging.Warn("skipping fallback model", "agent", agentName, "model", modelID, "error", err)
+			continue
+		}
+		fallbacks = append(fallbacks, fallback)
+	}
+	return provider.NewFallbackProvider(primary, fallbacks...), nil
+}
+
+func createModelProvider(agentName config.AgentName, agentConfig config.Agent) (provider.Provider, error) {
+	model, opts, err := providerOptions(agentName, agentConfig)
+	if err != nil {
+		return nil, err
+	}
+	agentProvider, err := provider.NewProvider(
+		model.Provider,
+		opts...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("could not create provider: %v", err)
+	}
+
+	return agentProvider, nil
+}
+
+// providerOptions returns the model of the agent and the options of its
+// provider client from the configuration.
+func providerOptions(agentName config.AgentName, agentConfig config.Agent) (models.Model, []provider.ProviderClientOption, error) {
+	cfg := config.Get()
 	model, ok := models.SupportedModels[agentConfig.Model]
 	if !ok {
-		return nil, fmt.Errorf("model %s not supported", agentConfig.Model)
+		return models.Model{}, nil, fmt.Errorf("model %s not supported", agentConfig.Model)
 	}
 
 	providerCfg, ok := cfg.Providers[model.Provider]
 	if !ok {
-		return nil, fmt.Errorf("provider %s not supported", model.Provider)
+		return models.Model{}, nil, fmt.Errorf("provider %s not supported", model.Provider)
 	}
 	if providerCfg.Disabled {
-		return nil, fmt.Errorf("provider %s is not enabled", model.Provider)
+		return models.Model{}, nil, fmt.Errorf("provider %s is not enabled", model.Provider)
 	}
 	maxTokens := model.DefaultMaxTokens
 	if agentConfig.MaxTokens > 0 {
 		maxTokens = agentConfig.MaxTokens
 	}
+	// Azure routes requests by deployment name instead of model name
+	for _, deployment := range providerCfg.Deployments {
+		if deployment.Model == model.ID && deployment.Name != "" {
+			model.APIModel = deployment.Name
+		}
+	}
 	opts := []provider.ProviderClientOption{
 		provider.WithAPIKey(providerCfg.APIKey),
 		provider.WithModel(model),
 		provider.WithSystemMessage(prompt.GetAgentPrompt(agentName, model.Provider)),
 		provider.WithMaxTokens(maxTokens),
+		provider.WithReasoning(agentConfig.ReasoningEffort, agentConfig.ThinkingBudget),
 	}
-	if model.Provider == models.ProviderOpenAI && model.CanReason {
+	if model.Provider == models.ProviderAzure {
 		opts = append(
 			opts,
-			provider.WithOpenAIOptions(
-				provider.WithReasoningEffort(agentConfig.ReasoningEffort),
+			provider.WithAzureOptions(
+				provider.WithAzureEndpoint(providerCfg.BaseURL, providerCfg.APIVersion),
 			),
 		)
-	} else if model.Provider == models.ProviderAnthropic && model.CanReason && agentName == config.AgentCoder {
+	}
+	if model.Provider == models.ProviderVertexAI {
 		opts = append(
 			opts,
-			provider.WithAnthropicOptions(
-				provider.WithAnthropicShouldThinkFn(provider.DefaultShouldThinkFn),
+			provider.WithVertexAIOptions(
+				provider.WithVertexAIProject(providerCfg.Project, providerCfg.Location),
+				provider.WithVertexAICredentialsFile(providerCfg.CredentialsFile),
 			),
 		)
 	}
-	agentProvider, err := provider.NewProvider(
-		model.Provider,
-		opts...,
-	)
+	if model.Provider == models.ProviderAnthropic {
+		var anthropicOpts []provider.AnthropicOption
+		if model.CanReason && agentName == config.AgentCoder {
+			anthropicOpts = append(anthropicOpts, provider.WithAnthropicShouldThinkFn(provider.DefaultShouldThinkFn))
+		}
+		if providerCfg.DisableCache {
+			anthropicOpts = append(anthropicOpts, provider.WithAnthropicDisableCache())
+		}
+		opts = append(opts, provider.WithAnthropicOptions(anthropicOpts...))
+	}
+	if model.Provider == models.ProviderGemini && providerCfg.DisableCache {
+		opts = append(
+			opts,
+			provider.WithGeminiOptions(
+				provider.WithGeminiDisableCache(),
+			),
+		)
+	}
+	timeout := providerCfg.Timeout
+	if agentConfig.Timeout != "" {
+		timeout = agentConfig.Timeout
+	}
+	if timeout != "" {
+		d, err := time.ParseDuration(timeout)
+		if err != nil {
+			return models.Model{}, nil, fmt.Errorf("invalid timeout %q for agent %s: %w", timeout, agentName, err)
+		}
+		opts = append(opts, provider.WithTimeout(d))
+	}
+	if providerCfg.Retry != nil {
+		policy, err := retryPolicy(*providerCfg.Retry)
+		if err != nil {
+			return models.Model{}, nil, fmt.Errorf("invalid retry configuration for provider %s: %w", model.Provider, err)
+		}
+		opts = append(opts, provider.WithRetryPolicy(policy))
+	}
+	if providerCfg.RateLimit != nil {
+		opts = append(opts, provider.WithRateLimit(provider.RateLimit{
+			RequestsPerMinute: providerCfg.RateLimit.RequestsPerMinute,
+			TokensPerMinute:   providerCfg.RateLimit.TokensPerMinute,
+		}))
+	}
+	tlsConfig, err := providerCfg.TLS.ClientConfig()
 	if err != nil {
-		return nil, fmt.Errorf("could not create provider: %v", err)
+		return models.Model{}, nil, fmt.Errorf("invalid tls configuration for provider %s: %w", model.Provider, err)
+	}
+	if tlsConfig != nil {
+		opts = append(opts, provider.WithTLSConfig(tlsConfig))
+	}
+	proxy := providerCfg.Proxy
+	if proxy == "" {
+		proxy = cfg.Proxy
+	}
+	if proxy != "" {
+		proxyFunc, err := discovery.ProxyFunc(proxy)
+		if err != nil {
+			return models.Model{}, nil, fmt.Errorf("invalid proxy for provider %s: %w", model.Provider, err)
+		}
+		opts = append(opts, provider.WithProxy(proxyFunc))
+	}
+	if cfg.DebugTraces {
+		opts = append(opts, provider.WithTraceDir(filepath.Join(cfg.Data.Directory, "traces")))
+	}
+	if cfg.CacheResponses {
+		opts = append(opts, provider.WithResponseCache(filepath.Join(cfg.Data.Directory, "cache", "responses")))
+	}
+	var middleware []provider.Middleware
+	if len(cfg.Redact) > 0 {
+		patterns := make([]*regexp.Regexp, len(cfg.Redact))
+		for i, pattern := range cfg.Redact {
+			patterns[i], err = regexp.Compile(pattern)
+			if err != nil {
+				return models.Model{}, nil, fmt.Errorf("invalid redact pattern %q: %w", pattern, err)
+			}
+		}
+		middleware = append(middleware, provider.RedactMiddleware(patterns...))
+	}
+	if cfg.Debug {
+		middleware = append(middleware, provider.LoggingMiddleware())
+	}
+	if len(middleware) > 0 {
+		opts = append(opts, provider.WithMiddleware(middleware...))
+	}
+	if model.Provider == models.ProviderOpenAICompatible {
+		if providerCfg.BaseURL == "" {
+			return models.Model{}, nil, fmt.Errorf("provider %s requires a baseURL", model.Provider)
+		}
+		opts = append(
+			opts,
+			provider.WithOpenAIOptions(
+				provider.WithOpenAIBaseURL(providerCfg.BaseURL),
+				provider.WithOpenAIHeaders(providerCfg.Headers),
+			),
+		)
+	}
+	if model.Provider == models.ProviderMistral && providerCfg.BaseURL != "" {
+		opts = append(
+			opts,
+			provider.WithOpenAIOptions(
+				provider.WithOpenAIBaseURL(providerCfg.BaseURL),
+			),
+		)
+	}
+	if model.Provider == models.ProviderOpenRouter {
+		opts = append(
+			opts,
+			provider.WithOpenAIOptions(
+				provider.WithOpenAIHeaders(providerCfg.Headers),
+			),
+		)
+	}
+	if model.Provider == models.ProviderLMStudio {
+		opts = append(
+			opts,
+			provider.WithOpenAIOptions(
+				provider.WithOpenAIBaseURL(models.LMStudioBaseURL(providerCfg.BaseURL)+"/v1"),
+				provider.WithOpenAIHeaders(providerCfg.Headers),
+			),
+		)
+	}
+	if model.Provider == models.ProviderLlamaCpp {
+		opts = append(
+			opts,
+			provider.WithLlamaCppOptions(
+				provider.WithLlamaCppBaseURL(providerCfg.BaseURL),
+				provider.WithLlamaCppHeaders(providerCfg.Headers),
+				provider.WithLlamaCppGrammar(agentConfig.Grammar),
+			),
+		)
+	}
+	if model.Provider == models.ProviderGGUF {
+		var ggufOpts []provider.GGUFOption
+		if providerCfg.GPULayers != nil {
+			ggufOpts = append(ggufOpts, provider.WithGGUFGPULayers(*providerCfg.GPULayers))
+		}
+		if providerCfg.Threads > 0 {
+			ggufOpts = append(ggufOpts, provider.WithGGUFThreads(providerCfg.Threads))
+		}
+		opts = append(opts, provider.WithGGUFOptions(ggufOpts...))
 	}
+	if model.Provider == models.ProviderOllama {
+		ollamaOpts, err := ollamaOptions(model, providerCfg, agentConfig)
+		if err != nil {
+			return models.Model{}, nil, fmt.Errorf("invalid format for agent %s: %w", agentName, err)
+		}
+		opts = append(
+			opts,
+			provider.WithOllamaOptions(ollamaOpts...),
+		)
+	}
+	return model, opts, nil
+}
 
-	return agentProvider, nil
+func retryPolicy(retryCfg config.RetryConfig) (provider.RetryPolicy, error) {
+	policy := provider.DefaultRetryPolicy()
+	if retryCfg.MaxAttempts != nil {
+		policy.MaxAttempts = *retryCfg.MaxAttempts
+	}
+	if retryCfg.InitialBackoff != "" {
+		d, err := time.ParseDuration(retryCfg.InitialBackoff)
+		if err != nil {
+			return policy, fmt.Errorf("invalid initialBackoff: %w", err)
+		}
+		policy.InitialBackoff = d
+	}
+	if retryCfg.MaxBackoff != "" {
+		d, err := time.ParseDuration(retryCfg.MaxBackoff)
+		if err != nil {
+			return policy, fmt.Errorf("invalid maxBackoff: %w", err)
+		}
+		policy.MaxBackoff = d
+	}
+	if len(retryCfg.StatusCodes) > 0 {
+		policy.StatusCodes = retryCfg.StatusCodes
+	}
+	return policy, nil
+}
+
+func ollamaOptions(model models.Model, providerCfg config.Provider, agentConfig config.Agent) ([]provider.OllamaOption, error) {
+	opts := []provider.OllamaOption{
+		provider.WithOllamaBaseURLs(append([]string{providerCfg.BaseURL}, providerCfg.BaseURLs...)...),
+		provider.WithOllamaHeaders(providerCfg.Headers),
+		provider.WithOllamaKeepAlive(providerCfg.KeepAlive),
+	}
+	completion := slices.ContainsFunc(providerCfg.CompletionModels, func(name string) bool {
+		return models.OllamaModelID(name) == model.ID
+	})
+	for _, custom := range providerCfg.Models {
+		if models.OllamaModelID(custom.Name) != model.ID {
+			continue
+		}
+		completion = completion || custom.Completion || custom.Raw
+		if custom.Template != "" {
+			opts = append(opts, provider.WithOllamaTemplate(custom.Template))
+		}
+	}
+	if completion {
+		opts = append(opts, provider.WithOllamaCompletionMode())
+	}
+	// The defaults of the model come first, the settings of the agent
+	// override them
+	for _, sampling := range []models.Sampling{model.Sampling, agentConfig.Sampling()} {
+		if sampling.Temperature != nil {
+			opts = append(opts, provider.WithOllamaTemperature(*sampling.Temperature))
+		}
+		if sampling.TopP != nil {
+			opts = append(opts, provider.WithOllamaTopP(*sampling.TopP))
+		}
+		if sampling.TopK != nil {
+			opts = append(opts, provider.WithOllamaTopK(*sampling.TopK))
+		}
+		if sampling.NumCtx != nil {
+			opts = append(opts, provider.WithOllamaNumCtx(*sampling.NumCtx))
+		}
+		if sampling.RepeatPenalty != nil {
+			opts = append(opts, provider.WithOllamaRepeatPenalty(*sampling.RepeatPenalty))
+		}
+	}
+	if len(agentConfig.Stop) > 0 {
+		opts = append(opts, provider.WithOllamaStop(agentConfig.Stop...))
+	}
+	if agentConfig.Seed != nil {
+		opts = append(opts, provider.WithOllamaSeed(*agentConfig.Seed))
+	}
+	switch format := strings.TrimSpace(agentConfig.Format); format {
+	case "":
+	case "json":
+		opts = append(opts, provider.WithOllamaJSONFormat())
+	default:
+		var schema map[string]any
+		if err := json.Unmarshal([]byte(format), &schema); err != nil {
+			return nil, fmt.Errorf("format must be \"json\" or a JSON schema: %w", err)
+		}
+		opts = append(opts, provider.WithOllamaJSONSchema(schema))
+	}
+	return opts, nil
 }
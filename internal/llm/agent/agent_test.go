@@ -0,0 +1,470 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/opencode-ai/opencode/internal/config"
+	"github.com/opencode-ai/opencode/internal/llm/models"
+	"github.com/opencode-ai/opencode/internal/llm/prompt"
+	"github.com/opencode-ai/opencode/internal/llm/provider"
+	"github.com/opencode-ai/opencode/internal/llm/tools"
+	"github.com/opencode-ai/opencode/internal/message"
+	"github.com/opencode-ai/opencode/internal/session"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testProvider answers the requests with its responses in order.
+type testProvider struct {
+	model models.Model
+
+	mu        sync.Mutex
+	responses []provider.ProviderResponse
+	// sent are the messages of the requests, offered the names of their tools
+	sent    [][]message.Message
+	offered [][]string
+	// stream returns the events of a streamed response, the content and the
+	// completion by default
+	stream func(ctx context.Context, response provider.ProviderResponse) <-chan provider.ProviderEvent
+}
+
+func (p *testProvider) next(messages []message.Message, available []tools.BaseTool) provider.ProviderResponse {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.sent = append(p.sent, messages)
+	var names []string
+	for _, tool := range available {
+		names = append(names, tool.Info().Name)
+	}
+	p.offered = append(p.offered, names)
+	if len(p.responses) == 0 {
+		return provider.ProviderResponse{Content: "no more responses", FinishReason: message.FinishReasonEndTurn}
+	}
+	response := p.responses[0]
+	p.responses = p.responses[1:]
+	return response
+}
+
+func (p *testProvider) SendMessages(ctx context.Context, messages []message.Message, tools []tools.BaseTool) (*provider.ProviderResponse, error) {
+	response := p.next(messages, tools)
+	return &response, nil
+}
+
+func (p *testProvider) StreamResponse(ctx context.Context, messages []message.Message, tools []tools.BaseTool) <-chan provider.ProviderEvent {
+	response := p.next(messages, tools)
+	if p.stream != nil {
+		return p.stream(ctx, response)
+	}
+	events := make(chan provider.ProviderEvent, 2)
+	if response.Content != "" {
+		events <- provider.ProviderEvent{Type: provider.EventContentDelta, Content: response.Content}
+	}
+	events <- provider.ProviderEvent{Type: provider.EventComplete, Response: &response}
+	close(events)
+	return events
+}
+
+func (p *testProvider) Model() models.Model {
+	return p.model
+}
+
+func TestTurnUsage(t *testing.T) {
+	view := testTool{name: tools.ViewToolName, run: func(ctx context.Context, call tools.ToolCall) (tools.ToolResponse, error) {
+		return tools.NewTextResponse("content of the file"), nil
+	}}
+	a, sess := newTestAgent(t, view)
+	model := models.Model{ID: "local", Provider: models.ProviderOllama, CostPer1MIn: 1, CostPer1MOut: 2}
+	a.provider = &testProvider{model: model, responses: []provider.ProviderResponse{
+		{
+			ToolCalls:    []message.ToolCall{{ID: "view-1", Name: tools.ViewToolName, Input: "{}", Finished: true}},
+			Usage:        provider.TokenUsage{InputTokens: 1000, OutputTokens: 100},
+			FinishReason: message.FinishReasonToolUse,
+		},
+		{
+			Content:      "The file is fine.",
+			Usage:        provider.TokenUsage{InputTokens: 200, OutputTokens: 50, CacheReadTokens: 1100},
+			FinishReason: message.FinishReasonEndTurn,
+		},
+	}}
+
+	// Requests outside of the turn, like the title of the session, are added to
+	// the session at the same time
+	var wg sync.WaitGroup
+	for range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, a.TrackUsage(context.Background(), sess.ID, "", model, provider.TokenUsage{InputTokens: 10, OutputTokens: 1}))
+		}()
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		assert.NoError(t, a.updateSession(context.Background(), sess.ID, func(s *session.Session) { s.Title = "Check the file" }))
+	}()
+
+	done, err := a.Run(context.Background(), sess.ID, "check the file")
+	require.NoError(t, err)
+	result := <-done
+	require.NoError(t, result.Err())
+	wg.Wait()
+
+	response := result.Response()
+	assert.Equal(t, "The file is fine.", response.Content().Text)
+	assert.Equal(t, TurnUsage{
+		TokenUsage: provider.TokenUsage{InputTokens: 1200, OutputTokens: 150, CacheReadTokens: 1100},
+		Cost:       (1200 + 2*150) / 1e6,
+		Requests:   2,
+	}, result.Usage())
+
+	updated, err := a.sessions.Get(context.Background(), sess.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Check the file", updated.Title)
+	assert.Equal(t, int64(2300+20*10), updated.PromptTokens)
+	assert.Equal(t, int64(150+20), updated.CompletionTokens)
+	// The cached tokens of the model are free
+	assert.InDelta(t, (1200+2*150+20*(10+2*1))/1e6, updated.Cost, 1e-12)
+	// The context of the last request replaces the estimate
+	assert.Equal(t, int64(200+1100+50), updated.ContextTokens)
+}
+
+func TestTrackUsageRecords(t *testing.T) {
+	a, sess := newTestAgent(t)
+	ctx := context.Background()
+	model := models.Model{
+		ID:                 "sonnet",
+		Provider:           models.ProviderAnthropic,
+		CostPer1MIn:        3,
+		CostPer1MOut:       15,
+		CostPer1MInCached:  3.75,
+		CostPer1MOutCached: 0.3,
+	}
+	tokens := provider.TokenUsage{InputTokens: 1000, OutputTokens: 100, CacheCreationTokens: 2000, CacheReadTokens: 4000}
+	require.NoError(t, a.TrackUsage(ctx, sess.ID, "msg-1", model, tokens))
+
+	// Sub-agents and titles are billed to the session they belong to
+	task, err := a.sessions.CreateTaskSession(ctx, "call-1", sess.ID, "Find the file")
+	require.NoError(t, err)
+	require.NoError(t, a.TrackUsage(ctx, task.ID, "msg-2", model, provider.TokenUsage{InputTokens: 500}))
+	title, err := a.sessions.CreateTitleSession(ctx, sess.ID)
+	require.NoError(t, err)
+	require.NoError(t, a.TrackUsage(ctx, title.ID, "", model, provider.TokenUsage{InputTokens: 50, OutputTokens: 5}))
+
+	records, err := a.usage.ForSession(ctx, sess.ID)
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+	assert.Equal(t, "msg-1", records[0].MessageID)
+	assert.Equal(t, models.ProviderAnthropic, records[0].Provider)
+	assert.Equal(t, models.ModelID("sonnet"), records[0].Model)
+	assert.Equal(t, int64(2000), records[0].CacheCreationTokens)
+	assert.Equal(t, int64(4000), records[0].CacheReadTokens)
+	assert.InDelta(t, (3*1000+15*100+3.75*2000+0.3*4000)/1e6, records[0].Cost, 1e-12)
+	assert.Equal(t, "msg-2", records[1].MessageID)
+	assert.InDelta(t, 3*500/1e6, records[1].Cost, 1e-12)
+	assert.Empty(t, records[2].MessageID)
+
+	records, err = a.usage.ForSession(ctx, task.ID)
+	require.NoError(t, err)
+	assert.Empty(t, records)
+}
+
+func TestRunKeepsCanceledContent(t *testing.T) {
+	a, sess := newTestAgent(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	a.provider = &testProvider{
+		model:     models.Model{ID: "local", Provider: models.ProviderOllama},
+		responses: []provider.ProviderResponse{{}},
+		stream: func(streamCtx context.Context, _ provider.ProviderResponse) <-chan provider.ProviderEvent {
+			events := make(chan provider.ProviderEvent)
+			go func() {
+				defer close(events)
+				events <- provider.ProviderEvent{Type: provider.EventContentDelta, Content: "Hel"}
+				cancel()
+				<-streamCtx.Done()
+				// Deltas read before the interrupt are still relayed, followed by
+				// the canceled completion with all of the content
+				events <- provider.ProviderEvent{Type: provider.EventContentDelta, Content: "lo"}
+				events <- provider.ProviderEvent{Type: provider.EventComplete, Response: &provider.ProviderResponse{
+					Content:      "Hello",
+					FinishReason: message.FinishReasonCanceled,
+				}}
+			}()
+			return events
+		},
+	}
+
+	done, err := a.Run(ctx, sess.ID, "say hello")
+	require.NoError(t, err)
+	result := <-done
+	assert.ErrorIs(t, result.Err(), ErrRequestCancelled)
+
+	msgs, err := a.messages.List(context.Background(), sess.ID)
+	require.NoError(t, err)
+	require.Len(t, msgs, 2)
+	assert.Equal(t, message.Assistant, msgs[1].Role)
+	assert.Equal(t, "Hello", msgs[1].Content().Text)
+	assert.Equal(t, message.FinishReasonCanceled, msgs[1].FinishReason())
+}
+
+func TestRunKeepsReasoningApart(t *testing.T) {
+	a, sess := newTestAgent(t)
+	a.provider = &testProvider{
+		model:     models.Model{ID: "deepseek-reasoner", Provider: models.ProviderDeepSeek},
+		responses: []provider.ProviderResponse{{Content: "Use a map.", FinishReason: message.FinishReasonEndTurn}},
+		stream: func(ctx context.Context, response provider.ProviderResponse) <-chan provider.ProviderEvent {
+			events := make(chan provider.ProviderEvent, 4)
+			events <- provider.ProviderEvent{Type: provider.EventThinkingDelta, Thinking: "The lookups are "}
+			events <- provider.ProviderEvent{Type: provider.EventThinkingDelta, Thinking: "by key."}
+			events <- provider.ProviderEvent{Type: provider.EventContentDelta, Content: response.Content}
+			events <- provider.ProviderEvent{Type: provider.EventComplete, Response: &response}
+			close(events)
+			return events
+		},
+	}
+
+	result := runTurn(t, a, sess.ID, "which data structure?")
+	require.NoError(t, result.Err())
+
+	msgs, err := a.messages.List(context.Background(), sess.ID)
+	require.NoError(t, err)
+	require.Len(t, msgs, 2)
+	// The reasoning is stored apart from the content of the response
+	assert.Equal(t, "The lookups are by key.", msgs[1].ReasoningContent().Thinking)
+	assert.Equal(t, "Use a map.", msgs[1].Content().Text)
+	assert.False(t, msgs[1].IsThinking())
+}
+
+func TestSessionTools(t *testing.T) {
+	noop := func(ctx context.Context, call tools.ToolCall) (tools.ToolResponse, error) {
+		return tools.NewTextResponse(""), nil
+	}
+	var agentTools []tools.BaseTool
+	for _, name := range []string{tools.ViewToolName, tools.GrepToolName, tools.EditToolName, tools.BashToolName, AgentToolName, "github_create_issue"} {
+		agentTools = append(agentTools, testTool{name: name, run: noop})
+	}
+	loadTestConfig(t)
+	profiles := config.Get().ToolProfiles
+	config.Get().ToolProfiles = map[string]config.ToolProfile{
+		"no-shell": {Deny: []string{tools.BashToolName, "github_*"}},
+	}
+	t.Cleanup(func() { config.Get().ToolProfiles = profiles })
+
+	all := []string{tools.ViewToolName, tools.GrepToolName, tools.EditToolName, tools.BashToolName, AgentToolName, "github_create_issue"}
+	readOnly := []string{tools.ViewToolName, tools.GrepToolName, AgentToolName}
+	tests := []struct {
+		name string
+		// parent is the session the task session runs in, none when nil
+		parent *session.Session
+		sess   session.Session
+		tools  []string
+	}{
+		{name: "default profile", sess: session.Session{}, tools: all},
+		{name: "read only", sess: session.Session{ToolProfile: config.ToolProfileReadOnly}, tools: readOnly},
+		{name: "configured profile", sess: session.Session{ToolProfile: "no-shell"}, tools: []string{tools.ViewToolName, tools.GrepToolName, tools.EditToolName, AgentToolName}},
+		{name: "unknown profile", sess: session.Session{ToolProfile: "removed"}, tools: nil},
+		{name: "plan mode", sess: session.Session{PlanMode: true}, tools: readOnly},
+		{name: "plan mode and profile", sess: session.Session{ToolProfile: "no-shell", PlanMode: true}, tools: readOnly},
+		{
+			name:   "profile of the parent",
+			parent: &session.Session{ToolProfile: config.ToolProfileReadOnly},
+			sess:   session.Session{},
+			tools:  readOnly,
+		},
+		{
+			name:   "own profile of a task",
+			parent: &session.Session{ToolProfile: config.ToolProfileReadOnly},
+			sess:   session.Session{ToolProfile: "no-shell"},
+			tools:  []string{tools.ViewToolName, tools.GrepToolName, tools.EditToolName, AgentToolName},
+		},
+		{
+			name:   "plan mode of a task",
+			parent: &session.Session{},
+			sess:   session.Session{PlanMode: true},
+			tools:  readOnly,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, sess := newTestAgent(t, agentTools...)
+			ctx := context.Background()
+			if tt.parent != nil {
+				parent := *tt.parent
+				parent.ID = sess.ID
+				_, err := a.sessions.Save(ctx, parent)
+				require.NoError(t, err)
+				sess, err = a.sessions.CreateTaskSession(ctx, "call-1", parent.ID, "task")
+				require.NoError(t, err)
+			}
+			sess.ToolProfile = tt.sess.ToolProfile
+			sess.PlanMode = tt.sess.PlanMode
+			_, err := a.sessions.Save(ctx, sess)
+			require.NoError(t, err)
+
+			available, err := a.sessionTools(ctx, sess.ID)
+			require.NoError(t, err)
+			var names []string
+			for _, tool := range available {
+				names = append(names, tool.Info().Name)
+			}
+			assert.Equal(t, tt.tools, names)
+		})
+	}
+}
+
+func TestRunPlanMode(t *testing.T) {
+	noop := func(ctx context.Context, call tools.ToolCall) (tools.ToolResponse, error) {
+		return tools.NewTextResponse(""), nil
+	}
+	a, sess := newTestAgent(t,
+		testTool{name: tools.ViewToolName, run: noop},
+		testTool{name: tools.EditToolName, run: noop},
+		testTool{name: tools.BashToolName, run: noop},
+	)
+	sess.PlanMode = true
+	_, err := a.sessions.Save(context.Background(), sess)
+	require.NoError(t, err)
+	p := &testProvider{model: models.Model{ID: "local", Provider: models.ProviderOllama}, responses: []provider.ProviderResponse{
+		{Content: "1. Change the port in config.go\n2. Run go test ./...", FinishReason: message.FinishReasonEndTurn},
+		{Content: "Done.", FinishReason: message.FinishReasonEndTurn},
+	}}
+	a.provider = p
+
+	result := runTurn(t, a, sess.ID, "make the port configurable")
+	require.NoError(t, result.Err())
+	// The model is asked for a plan and can only read
+	assert.Equal(t, "make the port configurable\n\n"+prompt.PlanModePrompt(), p.sent[0][0].Content().Text)
+	assert.Equal(t, []string{tools.ViewToolName}, p.offered[0])
+	msgs, err := a.messages.List(context.Background(), sess.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "make the port configurable", msgs[0].Content().Text)
+
+	// The approved plan is carried out with all the tools
+	sess.PlanMode = false
+	_, err = a.sessions.Save(context.Background(), sess)
+	require.NoError(t, err)
+	result = runTurn(t, a, sess.ID, "The plan is approved, carry it out")
+	require.NoError(t, result.Err())
+	assert.Equal(t, "The plan is approved, carry it out", p.sent[1][len(p.sent[1])-1].Content().Text)
+	assert.Equal(t, []string{tools.ViewToolName, tools.EditToolName, tools.BashToolName}, p.offered[1])
+}
+
+func TestSetModel(t *testing.T) {
+	a, sess := newTestAgent(t)
+	first, second := models.OllamaModel("llama3.1"), models.OllamaModel("qwen2.5-coder")
+	models.RegisterOllamaModels(map[models.ModelID]models.Model{first.ID: first, second.ID: second})
+	t.Cleanup(func() {
+		for _, id := range []models.ModelID{first.ID, second.ID} {
+			delete(models.SupportedModels, id)
+			delete(models.OllamaModels, id)
+		}
+	})
+	a.provider = &testProvider{model: first}
+
+	// The sidebar reads the model while it is switched
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				_ = a.Model()
+			}
+		}
+	}()
+	for _, model := range []models.Model{second, first, second} {
+		require.NoError(t, a.SetModel(model.ID))
+		require.NoError(t, a.SetReasoning("high", 0))
+	}
+	close(stop)
+	wg.Wait()
+	assert.Equal(t, second.ID, a.Model().ID)
+	assert.Equal(t, "high", config.Get().Agents[testAgentName].ReasoningEffort)
+
+	// A running turn keeps its provider, the model can't be switched under it
+	started, release := make(chan struct{}), make(chan struct{})
+	a.provider = &testProvider{
+		model: first,
+		stream: func(ctx context.Context, response provider.ProviderResponse) <-chan provider.ProviderEvent {
+			close(started)
+			<-release
+			events := make(chan provider.ProviderEvent, 1)
+			events <- provider.ProviderEvent{Type: provider.EventComplete, Response: &response}
+			close(events)
+			return events
+		},
+		responses: []provider.ProviderResponse{{Content: "Hi.", FinishReason: message.FinishReasonEndTurn}},
+	}
+	done, err := a.Run(context.Background(), sess.ID, "hi")
+	require.NoError(t, err)
+	<-started
+	assert.ErrorIs(t, a.SetModel(second.ID), ErrSessionBusy)
+	assert.ErrorIs(t, a.SetReasoning("high", 0), ErrSessionBusy)
+	close(release)
+	result := <-done
+	require.NoError(t, result.Err())
+	response := result.Response()
+	assert.Equal(t, first.ID, response.Model)
+	assert.Equal(t, first.ID, a.Model().ID)
+}
+
+func TestOllamaOptionsSampling(t *testing.T) {
+	var options map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request struct {
+			Options map[string]any `json:"options"`
+		}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+		options = request.Options
+		fmt.Fprintln(w, `{"message":{"role":"assistant","content":"ok"},"done":true,"done_reason":"stop"}`)
+	}))
+	t.Cleanup(server.Close)
+
+	temperature, topK, numCtx := 0.2, int64(20), int64(16384)
+	model := models.OllamaModel("qwen2.5-coder")
+	model.Sampling = models.Sampling{Temperature: &temperature, TopK: &topK, NumCtx: &numCtx}
+	agentTemperature := 0.7
+
+	tests := []struct {
+		name     string
+		agent    config.Agent
+		expected map[string]any
+	}{
+		{
+			name:     "model defaults",
+			agent:    config.Agent{},
+			expected: map[string]any{"temperature": 0.2, "top_k": float64(20), "num_ctx": float64(16384)},
+		},
+		{
+			name:     "agent overrides",
+			agent:    config.Agent{Temperature: &agentTemperature},
+			expected: map[string]any{"temperature": 0.7, "top_k": float64(20), "num_ctx": float64(16384)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts, err := ollamaOptions(model, config.Provider{BaseURL: server.URL}, tt.agent)
+			require.NoError(t, err)
+			p, err := provider.NewProvider(models.ProviderOllama, provider.WithModel(model), provider.WithOllamaOptions(opts...))
+			require.NoError(t, err)
+
+			_, err = p.SendMessages(context.Background(), []message.Message{
+				{Role: message.User, Parts: []message.ContentPart{message.TextContent{Text: "hi"}}},
+			}, nil)
+			require.NoError(t, err)
+			for key, value := range tt.expected {
+				assert.Equal(t, value, options[key], key)
+			}
+		})
+	}
+}
@@ -0,0 +1,180 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/opencode-ai/opencode/internal/config"
+	"github.com/opencode-ai/opencode/internal/llm/models"
+	"github.com/opencode-ai/opencode/internal/llm/provider"
+	"github.com/opencode-ai/opencode/internal/message"
+	"github.com/opencode-ai/opencode/internal/session"
+	"github.com/opencode-ai/opencode/internal/usage"
+)
+
+// batchDiscount is the price of a batch request relative to the same request
+// sent interactively.
+const batchDiscount = 0.5
+
+// BatchService answers prompts offline in batch jobs of the provider of the
+// coder model. Every prompt becomes a session, its answer is written to the
+// session when the batch is collected.
+type BatchService interface {
+	Submit(ctx context.Context, prompts []string) (provider.Batch, error)
+	Get(ctx context.Context, id string) (provider.Batch, error)
+	List(ctx context.Context) ([]provider.Batch, error)
+	// Collect writes the answers of a completed batch to their sessions and
+	// returns how many were written. Sessions that already have an answer are
+	// skipped, so a batch can be collected again.
+	Collect(ctx context.Context, id string) (int, error)
+}
+
+type batchService struct {
+	client   provider.BatchClient
+	model    models.Model
+	sessions session.Service
+	messages message.Service
+	usage    usage.Service
+}
+
+func NewBatchService(sessions session.Service, messages message.Service, usage usage.Service) (BatchService, error) {
+	agentConfig, ok := config.Get().Agents[config.AgentCoder]
+	if !ok {
+		return nil, fmt.Errorf("agent %s not found", config.AgentCoder)
+	}
+	model, opts, err := providerOptions(config.AgentCoder, agentConfig)
+	if err != nil {
+		return nil, err
+	}
+	client, err := provider.NewBatchClient(model.Provider, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &batchService{
+		client:   client,
+		model:    model,
+		sessions: sessions,
+		messages: messages,
+		usage:    usage,
+	}, nil
+}
+
+func (b *batchService) Submit(ctx context.Context, prompts []string) (provider.Batch, error) {
+	var requests []provider.BatchRequest
+	// The sessions of a batch that could not be submitted would never be
+	// answered
+	cleanup := func() {
+		for _, req := range requests {
+			_ = b.sessions.Delete(ctx, req.ID)
+		}
+	}
+	for _, prompt := range prompts {
+		sess, err := b.sessions.Create(ctx, batchTitle(prompt))
+		if err != nil {
+			cleanup()
+			return provider.Batch{}, fmt.Errorf("failed to create session: %w", err)
+		}
+		requests = append(requests, provider.BatchRequest{ID: sess.ID})
+		msg, err := b.messages.Create(ctx, sess.ID, message.CreateMessageParams{
+			Role: message.User,
+			Parts: []message.ContentPart{
+				message.TextContent{Text: prompt},
+			},
+		})
+		if err != nil {
+			cleanup()
+			return provider.Batch{}, fmt.Errorf("failed to create user message: %w", err)
+		}
+		requests[len(requests)-1].Messages = []message.Message{msg}
+	}
+
+	batch, err := b.client.SubmitBatch(ctx, requests)
+	if err != nil {
+		cleanup()
+		return provider.Batch{}, err
+	}
+	return batch, nil
+}
+
+func (b *batchService) Get(ctx context.Context, id string) (provider.Batch, error) {
+	return b.client.GetBatch(ctx, id)
+}
+
+func (b *batchService) List(ctx context.Context) ([]provider.Batch, error) {
+	return b.client.ListBatches(ctx)
+}
+
+func (b *batchService) Collect(ctx context.Context, id string) (int, error) {
+	results, err := b.client.BatchResults(ctx, id)
+	if err != nil {
+		return 0, err
+	}
+
+	written := 0
+	var errs []error
+	for _, result := range results {
+		if result.Error != nil {
+			errs = append(errs, fmt.Errorf("session %s: %w", result.ID, result.Error))
+			continue
+		}
+		ok, err := b.answer(ctx, result.ID, result.Response)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("session %s: %w", result.ID, err))
+			continue
+		}
+		if ok {
+			written++
+		}
+	}
+	return written, errors.Join(errs...)
+}
+
+// answer writes the response to the session unless it already has an answer.
+func (b *batchService) answer(ctx context.Context, sessionID string, response *provider.ProviderResponse) (bool, error) {
+	msgs, err := b.messages.List(ctx, sessionID)
+	if err != nil {
+		return false, fmt.Errorf("failed to list messages: %w", err)
+	}
+	for _, msg := range msgs {
+		if msg.Role == message.Assistant {
+			return false, nil
+		}
+	}
+
+	// Batch requests have no tools, the answer is the text
+	msg, err := b.messages.Create(ctx, sessionID, message.CreateMessageParams{
+		Role: message.Assistant,
+		Parts: []message.ContentPart{
+			message.TextContent{Text: response.Content},
+		},
+		Model: b.model.ID,
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to create assistant message: %w", err)
+	}
+	msg.AddFinish(response.FinishReason)
+	if err := b.messages.Update(ctx, msg); err != nil {
+		return false, fmt.Errorf("failed to update assistant message: %w", err)
+	}
+
+	cost := usageCost(b.model, response.Usage) * batchDiscount
+	if err := trackUsage(ctx, b.sessions, b.usage, sessionID, msg.ID, b.model, response.Usage, cost); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// batchTitle is the first line of the prompt, there is no title agent to
+// summarize it offline.
+func batchTitle(prompt string) string {
+	title := strings.TrimSpace(prompt)
+	if i := strings.IndexByte(title, '\n'); i >= 0 {
+		title = strings.TrimSpace(title[:i])
+	}
+	if runes := []rune(title); len(runes) > 50 {
+		title = string(runes[:47]) + "..."
+	}
+	return title
+}
@@ -0,0 +1,94 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/opencode-ai/opencode/internal/config"
+	"github.com/opencode-ai/opencode/internal/logging"
+)
+
+// defaultBudgetWarnAt is the share of a limit of the budget that shows a warning
+const defaultBudgetWarnAt = 0.8
+
+// ErrBudgetReached pauses a turn when the session reached a limit of its
+// budget, the user decides whether it continues.
+var ErrBudgetReached = errors.New("budget reached")
+
+// budgetUsage is what the budget limits: the tokens and the cost of the
+// session and the tool calls of the turn.
+type budgetUsage struct {
+	tokens    int64
+	cost      float64
+	toolCalls int
+}
+
+// budgetUsage returns the usage of the session, with the tool calls of the
+// turn so far.
+func (a *agent) budgetUsage(ctx context.Context, sessionID string, toolCalls int) (budgetUsage, error) {
+	sess, err := a.sessions.Get(ctx, sessionID)
+	if err != nil {
+		return budgetUsage{}, fmt.Errorf("failed to get session: %w", err)
+	}
+	return budgetUsage{
+		tokens:    sess.PromptTokens + sess.CompletionTokens,
+		cost:      sess.Cost,
+		toolCalls: toolCalls,
+	}, nil
+}
+
+// checkSessionBudget checks the usage of the session against the budget since
+// it was last checked, with the tool calls of the turn before and after the
+// step. It describes the limits reached, empty when it reached none.
+func (a *agent) checkSessionBudget(ctx context.Context, sessionID string, budget config.Budget, toolCallsBefore, toolCalls int) (string, error) {
+	after, err := a.budgetUsage(ctx, sessionID, toolCalls)
+	if err != nil {
+		return "", err
+	}
+	before := budgetUsage{toolCalls: toolCallsBefore}
+	if checked, ok := a.budgetChecked.Load(sessionID); ok {
+		before.tokens = checked.(budgetUsage).tokens
+		before.cost = checked.(budgetUsage).cost
+	}
+	a.budgetChecked.Store(sessionID, after)
+	return checkBudget(budget, before, after), nil
+}
+
+// checkBudget compares the usage before and after a step of the turn. It warns
+// when the step came close to a limit, and describes the limits the step
+// reached, empty when it reached none. A session that continued past a limit
+// reaches it again once it used the limit once more.
+func checkBudget(budget config.Budget, before, after budgetUsage) string {
+	warnAt := budget.WarnAt
+	if warnAt == 0 {
+		warnAt = defaultBudgetWarnAt
+	}
+	limits := []struct {
+		before, after float64
+		limit         float64
+		// format describes the usage of the limit
+		format string
+	}{
+		{float64(before.tokens), float64(after.tokens), float64(budget.MaxTokens), "%.0f of %.0f tokens"},
+		{before.cost, after.cost, budget.MaxCost, "$%.2f of $%.2f"},
+		{float64(before.toolCalls), float64(after.toolCalls), float64(budget.MaxToolCalls), "%.0f of %.0f tool calls in this turn"},
+	}
+
+	var reached []string
+	for _, l := range limits {
+		if l.limit <= 0 {
+			continue
+		}
+		if math.Floor(l.before/l.limit) < math.Floor(l.after/l.limit) {
+			reached = append(reached, fmt.Sprintf(l.format, l.after, l.limit))
+			continue
+		}
+		if l.before < warnAt*l.limit && l.after >= warnAt*l.limit {
+			logging.WarnPersist("The session is close to its budget: " + fmt.Sprintf(l.format, l.after, l.limit))
+		}
+	}
+	return strings.Join(reached, ", ")
+}
@@ -0,0 +1,226 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/opencode-ai/opencode/internal/config"
+	"github.com/opencode-ai/opencode/internal/llm/models"
+	"github.com/opencode-ai/opencode/internal/llm/provider"
+	"github.com/opencode-ai/opencode/internal/llm/tools"
+	"github.com/opencode-ai/opencode/internal/message"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckBudget(t *testing.T) {
+	var logs bytes.Buffer
+	defaultLogger := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&logs, nil)))
+	t.Cleanup(func() { slog.SetDefault(defaultLogger) })
+
+	tokens := config.Budget{MaxTokens: 1000}
+	tests := []struct {
+		name          string
+		budget        config.Budget
+		before, after budgetUsage
+		reached       string
+		warns         bool
+	}{
+		{
+			name:   "no limits",
+			budget: config.Budget{},
+			before: budgetUsage{},
+			after:  budgetUsage{tokens: 1_000_000, cost: 100, toolCalls: 1000},
+		},
+		{
+			name:   "below the limit",
+			budget: tokens,
+			before: budgetUsage{tokens: 500},
+			after:  budgetUsage{tokens: 700},
+		},
+		{
+			name:    "tokens reached",
+			budget:  tokens,
+			before:  budgetUsage{tokens: 900},
+			after:   budgetUsage{tokens: 1000},
+			reached: "1000 of 1000 tokens",
+		},
+		{
+			name:    "cost reached",
+			budget:  config.Budget{MaxCost: 1},
+			before:  budgetUsage{cost: 0.9},
+			after:   budgetUsage{cost: 1.2},
+			reached: "$1.20 of $1.00",
+		},
+		{
+			name:    "tool calls reached",
+			budget:  config.Budget{MaxToolCalls: 10},
+			before:  budgetUsage{toolCalls: 9},
+			after:   budgetUsage{toolCalls: 10},
+			reached: "10 of 10 tool calls in this turn",
+		},
+		{
+			name:    "several limits reached",
+			budget:  config.Budget{MaxTokens: 1000, MaxCost: 1, MaxToolCalls: 10},
+			before:  budgetUsage{tokens: 900, cost: 0.5, toolCalls: 9},
+			after:   budgetUsage{tokens: 1100, cost: 1, toolCalls: 9},
+			reached: "1100 of 1000 tokens, $1.00 of $1.00",
+		},
+		{
+			name:   "close to the limit",
+			budget: tokens,
+			before: budgetUsage{tokens: 700},
+			after:  budgetUsage{tokens: 800},
+			warns:  true,
+		},
+		{
+			name:   "close to the limit of warnAt",
+			budget: config.Budget{MaxTokens: 1000, WarnAt: 0.5},
+			before: budgetUsage{tokens: 400},
+			after:  budgetUsage{tokens: 500},
+			warns:  true,
+		},
+		{
+			name:   "below warnAt",
+			budget: config.Budget{MaxTokens: 1000, WarnAt: 0.95},
+			before: budgetUsage{tokens: 700},
+			after:  budgetUsage{tokens: 900},
+		},
+		{
+			name:   "already close to the limit",
+			budget: tokens,
+			before: budgetUsage{tokens: 850},
+			after:  budgetUsage{tokens: 900},
+		},
+		{
+			name:    "reached without a warning before",
+			budget:  tokens,
+			before:  budgetUsage{tokens: 500},
+			after:   budgetUsage{tokens: 1200},
+			reached: "1200 of 1000 tokens",
+		},
+		{
+			name:   "continued past the limit",
+			budget: tokens,
+			before: budgetUsage{tokens: 1000},
+			after:  budgetUsage{tokens: 1900},
+		},
+		{
+			name:    "reached again after another full limit",
+			budget:  tokens,
+			before:  budgetUsage{tokens: 1900},
+			after:   budgetUsage{tokens: 2000},
+			reached: "2000 of 1000 tokens",
+		},
+		{
+			name:    "reached again in a step using more than the limit",
+			budget:  tokens,
+			before:  budgetUsage{tokens: 1200},
+			after:   budgetUsage{tokens: 3500},
+			reached: "3500 of 1000 tokens",
+		},
+		{
+			name:   "no warning after continuing past the limit",
+			budget: tokens,
+			before: budgetUsage{tokens: 1700},
+			after:  budgetUsage{tokens: 1850},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs.Reset()
+			assert.Equal(t, tt.reached, checkBudget(tt.budget, tt.before, tt.after))
+			assert.Equal(t, tt.warns, strings.Contains(logs.String(), "The session is close to its budget"))
+		})
+	}
+}
+
+// withBudget sets the budget of the sessions for the test.
+func withBudget(t *testing.T, budget config.Budget) {
+	t.Helper()
+	previous := config.Get().Budget
+	config.Get().Budget = budget
+	t.Cleanup(func() { config.Get().Budget = previous })
+}
+
+// runTurn runs a turn of the session and waits for its result.
+func runTurn(t *testing.T, a *agent, sessionID, content string) AgentEvent {
+	t.Helper()
+	done, err := a.Run(context.Background(), sessionID, content)
+	require.NoError(t, err)
+	return <-done
+}
+
+func TestRunPausesAtBudget(t *testing.T) {
+	view := testTool{name: tools.ViewToolName, run: func(ctx context.Context, call tools.ToolCall) (tools.ToolResponse, error) {
+		return tools.NewTextResponse("content of the file"), nil
+	}}
+	model := models.Model{ID: "local", Provider: models.ProviderOllama}
+
+	t.Run("tool use", func(t *testing.T) {
+		a, sess := newTestAgent(t, view)
+		withBudget(t, config.Budget{MaxTokens: 1000})
+		p := &testProvider{model: model, responses: []provider.ProviderResponse{
+			{
+				ToolCalls:    []message.ToolCall{{ID: "view-1", Name: tools.ViewToolName, Input: "{}", Finished: true}},
+				Usage:        provider.TokenUsage{InputTokens: 900, OutputTokens: 200},
+				FinishReason: message.FinishReasonToolUse,
+			},
+		}}
+		a.provider = p
+
+		result := runTurn(t, a, sess.ID, "check the file")
+		require.ErrorIs(t, result.Err(), ErrBudgetReached)
+		assert.Contains(t, result.Err().Error(), "1100 of 1000 tokens")
+		assert.Len(t, p.sent, 1)
+		// The tool results are stored, a new message continues the turn
+		msgs, err := a.messages.List(context.Background(), sess.ID)
+		require.NoError(t, err)
+		require.Len(t, msgs, 3)
+		assert.Equal(t, message.Tool, msgs[2].Role)
+	})
+
+	t.Run("end turn", func(t *testing.T) {
+		a, sess := newTestAgent(t)
+		withBudget(t, config.Budget{MaxTokens: 1000})
+		p := &testProvider{model: model, responses: []provider.ProviderResponse{
+			{Content: "Done.", Usage: provider.TokenUsage{InputTokens: 900, OutputTokens: 200}, FinishReason: message.FinishReasonEndTurn},
+			{Content: "Continued.", Usage: provider.TokenUsage{InputTokens: 100}, FinishReason: message.FinishReasonEndTurn},
+		}}
+		a.provider = p
+
+		result := runTurn(t, a, sess.ID, "do it")
+		require.ErrorIs(t, result.Err(), ErrBudgetReached)
+		assert.Contains(t, result.Err().Error(), "1100 of 1000 tokens")
+		response := result.Response()
+		assert.Equal(t, "Done.", response.Content().Text)
+
+		// The session continued past the limit, it isn't paused again until it
+		// used the limit once more
+		result = runTurn(t, a, sess.ID, "Continue where you stopped.")
+		require.NoError(t, result.Err())
+		assert.Len(t, p.sent, 2)
+	})
+
+	t.Run("over the limit before the turn", func(t *testing.T) {
+		a, sess := newTestAgent(t)
+		withBudget(t, config.Budget{MaxTokens: 1000})
+		p := &testProvider{model: model}
+		a.provider = p
+		// Usage outside of the turns, like the summaries of the conversation
+		require.NoError(t, a.TrackUsage(context.Background(), sess.ID, "", model, provider.TokenUsage{InputTokens: 1200}))
+
+		result := runTurn(t, a, sess.ID, "do it")
+		require.ErrorIs(t, result.Err(), ErrBudgetReached)
+		assert.Contains(t, result.Err().Error(), "1200 of 1000 tokens")
+		assert.Empty(t, p.sent)
+
+		result = runTurn(t, a, sess.ID, "Continue where you stopped.")
+		require.NoError(t, result.Err())
+		assert.Len(t, p.sent, 1)
+	})
+}
@@ -0,0 +1,203 @@
+package agent
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/opencode-ai/opencode/internal/logging"
+)
+
+const (
+	// fileChangeGrace is how long after a tool finished the changes are still
+	// made by the tool, as the events arrive shortly after the writes
+	fileChangeGrace = time.Second
+	// maxFileChanges is the number of changed files listed in a note
+	maxFileChanges = 20
+)
+
+// fileChangeExcludedDirs are not watched, with all directories starting with a
+// dot.
+var fileChangeExcludedDirs = map[string]bool{
+	"node_modules": true,
+	"vendor":       true,
+	"target":       true,
+	"dist":         true,
+	"build":        true,
+	"coverage":     true,
+	"__pycache__":  true,
+}
+
+type fileChange struct {
+	at time.Time
+	// created is set when the file didn't exist before the first change, a
+	// created file that is gone again was a temporary file
+	created bool
+}
+
+// fileChanges records the files changed in the working directory while no
+// tool of the agent runs, e.g. in an editor, so the agent can be told before
+// its next turn that what it read of them is stale.
+type fileChanges struct {
+	root    string
+	started time.Time
+
+	mu      sync.Mutex
+	changes map[string]fileChange
+	// seen is when each session was last told about the changes
+	seen         map[string]time.Time
+	toolsRunning int
+	toolsDone    time.Time
+}
+
+// watchFileChanges starts watching the directories of root, it returns nil
+// when the watcher can't be created.
+func watchFileChanges(root string) *fileChanges {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		logging.Error("Error creating file change watcher", "error", err)
+		return nil
+	}
+	c := &fileChanges{
+		root:    root,
+		started: time.Now(),
+		changes: make(map[string]fileChange),
+		seen:    make(map[string]time.Time),
+	}
+	c.addDirs(watcher, root)
+	go c.watch(watcher)
+	return c
+}
+
+func (c *fileChanges) addDirs(watcher *fsnotify.Watcher, dir string) {
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return nil
+		}
+		if path != c.root && (strings.HasPrefix(d.Name(), ".") || fileChangeExcludedDirs[d.Name()]) {
+			return filepath.SkipDir
+		}
+		if err := watcher.Add(path); err != nil {
+			logging.Debug("Error watching directory for changes", "path", path, "error", err)
+		}
+		return nil
+	})
+	if err != nil {
+		logging.Error("Error walking the working directory", "error", err)
+	}
+}
+
+func (c *fileChanges) watch(watcher *fsnotify.Watcher) {
+	defer watcher.Close()
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			if event.Op&fsnotify.Create != 0 {
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+					c.addDirs(watcher, event.Name)
+					continue
+				}
+			}
+			c.record(event)
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			logging.Error("Error watching file changes", "error", err)
+		}
+	}
+}
+
+func (c *fileChanges) record(event fsnotify.Event) {
+	name := filepath.Base(event.Name)
+	// Backup and swap files of editors
+	if strings.HasSuffix(name, "~") || strings.HasSuffix(name, ".swp") || strings.HasSuffix(name, ".swx") || name == "4913" {
+		return
+	}
+	if event.Op&(fsnotify.Create|fsnotify.Write|fsnotify.Remove|fsnotify.Rename) == 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	if c.toolsRunning > 0 || now.Sub(c.toolsDone) < fileChangeGrace {
+		return
+	}
+	change, ok := c.changes[event.Name]
+	if !ok {
+		change.created = event.Op&fsnotify.Create != 0
+	}
+	change.at = now
+	c.changes[event.Name] = change
+}
+
+// toolStarted and toolFinished mark the time the changes are made by the
+// agent.
+func (c *fileChanges) toolStarted() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.toolsRunning++
+}
+
+func (c *fileChanges) toolFinished() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.toolsRunning--
+	c.toolsDone = time.Now()
+}
+
+// note describes the files changed since the last turn of the session, or
+// since the watcher started for sessions of earlier runs.
+func (c *fileChanges) note(sessionID string) string {
+	if c == nil {
+		return ""
+	}
+	c.mu.Lock()
+	since, ok := c.seen[sessionID]
+	if !ok {
+		since = c.started
+	}
+	c.seen[sessionID] = time.Now()
+	var lines []string
+	for path, change := range c.changes {
+		if !change.at.After(since) {
+			continue
+		}
+		op := "changed"
+		if _, err := os.Stat(path); err != nil {
+			if change.created {
+				continue
+			}
+			op = "deleted"
+		}
+		if rel, err := filepath.Rel(c.root, path); err == nil {
+			path = rel
+		}
+		lines = append(lines, fmt.Sprintf("- %s (%s)", filepath.ToSlash(path), op))
+	}
+	c.mu.Unlock()
+
+	if len(lines) == 0 {
+		return ""
+	}
+	slices.Sort(lines)
+	if len(lines) > maxFileChanges {
+		lines = append(lines[:maxFileChanges], fmt.Sprintf("- and %d more", len(lines)-maxFileChanges))
+	}
+	return fmt.Sprintf("Note: these files were changed outside of the session since your last turn, read them again before editing them:\n%s", strings.Join(lines, "\n"))
+}
@@ -0,0 +1,149 @@
+package agent
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/opencode-ai/opencode/internal/llm/models"
+	"github.com/opencode-ai/opencode/internal/llm/provider"
+	"github.com/opencode-ai/opencode/internal/message"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestFileChanges returns changes of root that are not watched, the events
+// are recorded by the test.
+func newTestFileChanges(root string) *fileChanges {
+	return &fileChanges{
+		root:    root,
+		started: time.Now().Add(-time.Minute),
+		changes: make(map[string]fileChange),
+		seen:    make(map[string]time.Time),
+	}
+}
+
+func TestFileChangesNote(t *testing.T) {
+	root := t.TempDir()
+	write := func(name string) string {
+		path := filepath.Join(root, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte("content"), 0o644))
+		return path
+	}
+
+	c := newTestFileChanges(root)
+	assert.Empty(t, c.note("s1"))
+
+	c.record(fsnotify.Event{Name: write("main.go"), Op: fsnotify.Write})
+	c.record(fsnotify.Event{Name: write("internal/app/app.go"), Op: fsnotify.Create})
+	removed := filepath.Join(root, "old.go")
+	c.record(fsnotify.Event{Name: removed, Op: fsnotify.Remove})
+	// A temporary file that is gone again
+	temp := write("main.go.tmp")
+	c.record(fsnotify.Event{Name: temp, Op: fsnotify.Create})
+	require.NoError(t, os.Remove(temp))
+	// Backup and swap files of editors
+	c.record(fsnotify.Event{Name: write(".main.go.swp"), Op: fsnotify.Write})
+	c.record(fsnotify.Event{Name: write("main.go~"), Op: fsnotify.Write})
+	c.record(fsnotify.Event{Name: write("mode.go"), Op: fsnotify.Chmod})
+
+	assert.Equal(t, "Note: these files were changed outside of the session since your last turn, read them again before editing them:\n"+
+		"- internal/app/app.go (changed)\n"+
+		"- main.go (changed)\n"+
+		"- old.go (deleted)", c.note("s1"))
+	// Each session is told once
+	assert.Empty(t, c.note("s1"))
+	assert.Contains(t, c.note("s2"), "- main.go (changed)")
+
+	// Changes made by the tools of the agent are left out, with those arriving
+	// shortly after the tool finished
+	c.toolStarted()
+	c.record(fsnotify.Event{Name: write("edited.go"), Op: fsnotify.Write})
+	c.toolFinished()
+	c.record(fsnotify.Event{Name: write("formatted.go"), Op: fsnotify.Write})
+	assert.Empty(t, c.note("s1"))
+
+	c.toolsDone = time.Now().Add(-fileChangeGrace)
+	c.record(fsnotify.Event{Name: write("main.go"), Op: fsnotify.Write})
+	assert.Equal(t, "Note: these files were changed outside of the session since your last turn, read them again before editing them:\n"+
+		"- main.go (changed)", c.note("s1"))
+
+	// A nil watcher, for the other agents, has no changes
+	var none *fileChanges
+	none.toolStarted()
+	none.toolFinished()
+	assert.Empty(t, none.note("s1"))
+}
+
+func TestFileChangesNoteLimit(t *testing.T) {
+	root := t.TempDir()
+	c := newTestFileChanges(root)
+	for i := range maxFileChanges + 5 {
+		path := filepath.Join(root, fmt.Sprintf("file%02d.go", i))
+		require.NoError(t, os.WriteFile(path, nil, 0o644))
+		c.record(fsnotify.Event{Name: path, Op: fsnotify.Write})
+	}
+
+	note := c.note("s1")
+	assert.Equal(t, maxFileChanges+1, strings.Count(note, "\n- "))
+	assert.True(t, strings.HasSuffix(note, "\n- and 5 more"), note)
+}
+
+func TestWatchFileChanges(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(root, "node_modules"), 0o755))
+	c := watchFileChanges(root)
+	require.NotNil(t, c)
+
+	require.NoError(t, os.WriteFile(filepath.Join(root, "node_modules", "index.js"), nil, 0o644))
+	// The directories created after the start are watched too
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "cmd"), 0o755))
+	require.Eventually(t, func() bool {
+		require.NoError(t, os.WriteFile(filepath.Join(root, "cmd", "main.go"), []byte("package main"), 0o644))
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		_, ok := c.changes[filepath.Join(root, "cmd", "main.go")]
+		return ok
+	}, 5*time.Second, 50*time.Millisecond)
+
+	note := c.note("s1")
+	assert.Contains(t, note, "- cmd/main.go (changed)")
+	assert.NotContains(t, note, "node_modules")
+}
+
+func TestRunNotesFileChanges(t *testing.T) {
+	a, sess := newTestAgent(t)
+	root := t.TempDir()
+	a.fileChanges = newTestFileChanges(root)
+	p := &testProvider{model: models.Model{ID: "local", Provider: models.ProviderOllama}, responses: []provider.ProviderResponse{
+		{Content: "Done.", FinishReason: message.FinishReasonEndTurn},
+		{Content: "Done again.", FinishReason: message.FinishReasonEndTurn},
+	}}
+	a.provider = p
+
+	path := filepath.Join(root, "main.go")
+	require.NoError(t, os.WriteFile(path, []byte("package main"), 0o644))
+	a.fileChanges.record(fsnotify.Event{Name: path, Op: fsnotify.Write})
+	// The first turn has not read any file yet
+	result := runTurn(t, a, sess.ID, "fix the bug")
+	require.NoError(t, result.Err())
+	assert.Equal(t, "fix the bug", p.sent[0][0].Content().Text)
+
+	a.fileChanges.record(fsnotify.Event{Name: path, Op: fsnotify.Write})
+	result = runTurn(t, a, sess.ID, "and the test")
+	require.NoError(t, result.Err())
+	sent := p.sent[1][len(p.sent[1])-1].Content().Text
+	assert.True(t, strings.HasPrefix(sent, "and the test\n\nNote: these files were changed outside of the session"), sent)
+	assert.Contains(t, sent, "- main.go (changed)")
+
+	// The note isn't stored with the message
+	msgs, err := a.messages.List(t.Context(), sess.ID)
+	require.NoError(t, err)
+	require.Len(t, msgs, 4)
+	assert.Equal(t, "and the test", msgs[2].Content().Text)
+}
@@ -0,0 +1,233 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/opencode-ai/opencode/internal/config"
+	"github.com/opencode-ai/opencode/internal/llm/models"
+	"github.com/opencode-ai/opencode/internal/llm/prompt"
+	"github.com/opencode-ai/opencode/internal/llm/tokenizer"
+	"github.com/opencode-ai/opencode/internal/logging"
+	"github.com/opencode-ai/opencode/internal/message"
+	"github.com/opencode-ai/opencode/internal/session"
+)
+
+const (
+	// defaultCompactionThreshold is the share of the context window a
+	// conversation can fill before it is compacted
+	defaultCompactionThreshold = 0.85
+	// compactionKeepShare is the share of the context window the recent
+	// messages kept as they are can fill
+	compactionKeepShare = 0.25
+	// minCompactionShare is the share of the context window the summarized
+	// messages must fill at least, so one huge message doesn't compact the
+	// conversation on every step of the turn
+	minCompactionShare = 0.1
+	// maxTranscriptPart is the length of the tool calls and the tool results
+	// in the transcript that is summarized
+	maxTranscriptPart = 2000
+	// maxPinnedRequest is the length of the first request of the session kept
+	// as it is with the summary
+	maxPinnedRequest = 10000
+)
+
+// sessionHistory returns the conversation sent to the model for the messages
+// of the session: the summary and the messages after it once the session was
+// compacted.
+func sessionHistory(sess session.Session, msgs []message.Message) []message.Message {
+	if sess.SummaryMessageID == "" {
+		return msgs
+	}
+	start := slices.IndexFunc(msgs, func(msg message.Message) bool { return msg.ID == sess.SummaryMessageID })
+	if start < 0 {
+		// The first message after the summary was deleted
+		return msgs
+	}
+	return append([]message.Message{summaryMessage(sess.ID, sess.Summary, firstRequest(msgs))}, msgs[start:]...)
+}
+
+// compact replaces the older messages of the conversation with a summary the
+// model writes when the conversation fills most of its context window. The
+// first request of the session and the recent messages are kept as they are,
+// and the summary is stored with the session for the following turns. The
+// conversation is returned unchanged when the summary fails.
+func (a *agent) compact(ctx context.Context, sessionID string, msgHistory []message.Message) []message.Message {
+	compaction := config.Get().Compaction
+	model := a.turnProvider(ctx).Model()
+	if compaction.Disabled || model.ContextWindow <= 0 {
+		return msgHistory
+	}
+	available, err := a.sessionTools(ctx, sessionID)
+	if err != nil {
+		return msgHistory
+	}
+	threshold := compaction.Threshold
+	if threshold == 0 {
+		threshold = defaultCompactionThreshold
+	}
+	if float64(a.contextSize(model, msgHistory, available)) <= threshold*float64(model.ContextWindow) {
+		return msgHistory
+	}
+
+	t := tokenizer.ForModel(model)
+	cut := compactionCut(t, msgHistory, int64(compactionKeepShare*float64(model.ContextWindow)))
+	if cut == 0 || float64(tokenizer.CountMessages(t, msgHistory[:cut])) < minCompactionShare*float64(model.ContextWindow) {
+		return msgHistory
+	}
+	summary, err := a.summarize(ctx, sessionID, msgHistory[:cut])
+	if err != nil {
+		logging.WarnPersist(fmt.Sprintf("Failed to compact the conversation: %v", err))
+		return msgHistory
+	}
+	msgs, err := a.messages.List(ctx, sessionID)
+	if err != nil {
+		logging.WarnPersist(fmt.Sprintf("Failed to compact the conversation: %v", err))
+		return msgHistory
+	}
+	err = a.updateSession(ctx, sessionID, func(sess *session.Session) {
+		sess.Summary = summary
+		sess.SummaryMessageID = msgHistory[cut].ID
+	})
+	if err != nil {
+		logging.WarnPersist(fmt.Sprintf("Failed to compact the conversation: %v", err))
+		return msgHistory
+	}
+	logging.InfoPersist(fmt.Sprintf("Summarized the earlier messages of the conversation to fit the context window of %s", model.Name))
+	return append([]message.Message{summaryMessage(sessionID, summary, firstRequest(msgs))}, msgHistory[cut:]...)
+}
+
+// compactionCut returns the index of the first message kept as it is, 0 when
+// no message can be summarized. The kept messages fill at most budget tokens,
+// but the last turn is kept at least, and they don't start with tool results,
+// which must follow their tool calls.
+func compactionCut(t tokenizer.Tokenizer, msgs []message.Message, budget int64) int {
+	cut := 0
+	var tokens int64
+	for i := len(msgs) - 1; i > 0; i-- {
+		tokens += tokenizer.CountMessages(t, msgs[i:i+1])
+		if msgs[i].Role == message.Tool {
+			continue
+		}
+		if cut != 0 && tokens > budget {
+			break
+		}
+		cut = i
+	}
+	return cut
+}
+
+// summarize asks the summarizer for a summary of the messages, or the model
+// of the agent when there is no summarizer or the messages don't fit in the
+// context window of its model.
+func (a *agent) summarize(ctx context.Context, sessionID string, msgs []message.Message) (string, error) {
+	request := prompt.SummarizerPrompt() + "\n\n<conversation>\n" + transcript(msgs) + "\n</conversation>"
+	summarizer := a.turnProvider(ctx)
+	if a.summaryProvider != nil {
+		model := a.summaryProvider.Model()
+		maxTokens := config.Get().Agents[config.AgentSummarizer].MaxTokens
+		if model.ContextWindow <= 0 || tokenizer.ForModel(model).Count(request)+maxTokens <= model.ContextWindow {
+			summarizer = a.summaryProvider
+		} else {
+			logging.Info("conversation too long for the summarizer, summarizing with the agent model", "model", model.ID)
+		}
+	}
+	response, err := summarizer.SendMessages(
+		ctx,
+		[]message.Message{
+			{
+				Role:  message.User,
+				Parts: []message.ContentPart{message.TextContent{Text: request}},
+			},
+		},
+		nil,
+	)
+	if err != nil {
+		return "", err
+	}
+	model := summarizer.Model()
+	if fallback, ok := models.SupportedModels[response.Model]; ok {
+		model = fallback
+	}
+	if err := a.TrackUsage(ctx, sessionID, "", model, response.Usage); err != nil {
+		return "", err
+	}
+	summary := strings.TrimSpace(response.Content)
+	if summary == "" {
+		return "", fmt.Errorf("the model returned an empty summary")
+	}
+	return summary, nil
+}
+
+// transcript renders the messages as text for the summary, with the tool calls
+// and their results shortened.
+func transcript(msgs []message.Message) string {
+	var b strings.Builder
+	for _, msg := range msgs {
+		switch msg.Role {
+		case message.User:
+			b.WriteString("User: " + msg.Content().Text + "\n")
+			for _, attachment := range msg.BinaryContent() {
+				fmt.Fprintf(&b, "[attached %s]\n", attachment.MIMEType)
+			}
+		case message.Assistant:
+			if text := msg.Content().Text; text != "" {
+				b.WriteString("Assistant: " + text + "\n")
+			}
+			for _, call := range msg.ToolCalls() {
+				fmt.Fprintf(&b, "Tool call %s: %s\n", call.Name, shorten(call.Input, maxTranscriptPart))
+			}
+		case message.Tool:
+			for _, result := range msg.ToolResults() {
+				kind := "Tool result"
+				if result.IsError {
+					kind = "Tool error"
+				}
+				fmt.Fprintf(&b, "%s %s: %s\n", kind, result.Name, shorten(result.Content, maxTranscriptPart))
+			}
+		}
+		b.WriteString("\n")
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// shorten cuts the text to at most n bytes, on a rune boundary.
+func shorten(text string, n int) string {
+	if len(text) <= n {
+		return text
+	}
+	for n > 0 && !utf8.RuneStart(text[n]) {
+		n--
+	}
+	return text[:n] + fmt.Sprintf(" [... %d more bytes]", len(text)-n)
+}
+
+// firstRequest returns the text of the first user message of the session.
+func firstRequest(msgs []message.Message) string {
+	for _, msg := range msgs {
+		if msg.Role == message.User {
+			return msg.Content().Text
+		}
+	}
+	return ""
+}
+
+// summaryMessage is the user message that replaces the summarized messages of
+// a conversation, with the first request of the session kept as it is.
+func summaryMessage(sessionID, summary, request string) message.Message {
+	var b strings.Builder
+	b.WriteString("The earlier part of this conversation was summarized to fit the context window.\n\n")
+	if request != "" {
+		b.WriteString("<first_request>\n" + shorten(request, maxPinnedRequest) + "\n</first_request>\n\n")
+	}
+	b.WriteString("<summary>\n" + summary + "\n</summary>")
+	return message.Message{
+		ID:        "summary-" + sessionID,
+		SessionID: sessionID,
+		Role:      message.User,
+		Parts:     []message.ContentPart{message.TextContent{Text: b.String()}},
+	}
+}
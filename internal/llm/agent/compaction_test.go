@@ -0,0 +1,341 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/opencode-ai/opencode/internal/config"
+	"github.com/opencode-ai/opencode/internal/llm/models"
+	"github.com/opencode-ai/opencode/internal/llm/provider"
+	"github.com/opencode-ai/opencode/internal/llm/tokenizer"
+	"github.com/opencode-ai/opencode/internal/message"
+	"github.com/opencode-ai/opencode/internal/session"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func textMessage(id string, role message.MessageRole, text string) message.Message {
+	return message.Message{ID: id, Role: role, Parts: []message.ContentPart{message.TextContent{Text: text}}}
+}
+
+func callMessage(id string, calls ...message.ToolCall) message.Message {
+	msg := message.Message{ID: id, Role: message.Assistant}
+	for _, call := range calls {
+		msg.Parts = append(msg.Parts, call)
+	}
+	return msg
+}
+
+func resultMessage(id string, results ...message.ToolResult) message.Message {
+	msg := message.Message{ID: id, Role: message.Tool}
+	for _, result := range results {
+		msg.Parts = append(msg.Parts, result)
+	}
+	return msg
+}
+
+func TestCompactionCut(t *testing.T) {
+	tok := tokenizer.New(tokenizer.FamilySentencePiece)
+	text := strings.Repeat("word ", 50)
+	conversation := []message.Message{
+		textMessage("u1", message.User, text),
+		callMessage("a1", message.ToolCall{ID: "c1", Name: "view", Input: text}),
+		resultMessage("t1", message.ToolResult{ToolCallID: "c1", Name: "view", Content: text}),
+		textMessage("a2", message.Assistant, text),
+		textMessage("u2", message.User, text),
+		callMessage("a3", message.ToolCall{ID: "c2", Name: "view", Input: text}, message.ToolCall{ID: "c3", Name: "ls", Input: text}),
+		resultMessage("t2", message.ToolResult{ToolCallID: "c2", Name: "view", Content: text}, message.ToolResult{ToolCallID: "c3", Name: "ls", Content: text}),
+		textMessage("a4", message.Assistant, text),
+	}
+	tokens := func(msgs []message.Message) int64 { return tokenizer.CountMessages(tok, msgs) }
+
+	tests := []struct {
+		name   string
+		msgs   []message.Message
+		budget int64
+		cut    int
+	}{
+		{name: "no messages", msgs: nil, budget: 1000, cut: 0},
+		{name: "a single message", msgs: conversation[:1], budget: 0, cut: 0},
+		{name: "everything fits", msgs: conversation, budget: tokens(conversation), cut: 1},
+		{name: "the last message is kept at least", msgs: conversation, budget: 0, cut: 7},
+		{name: "the kept messages fit the budget", msgs: conversation, budget: tokens(conversation[4:]), cut: 4},
+		{name: "less than the budget", msgs: conversation, budget: tokens(conversation[4:]) - 1, cut: 5},
+		// The tool results can't be kept without the tool calls before them
+		{name: "tool results fit the budget", msgs: conversation, budget: tokens(conversation[6:]), cut: 7},
+		{name: "tool calls and results fit the budget", msgs: conversation, budget: tokens(conversation[5:]), cut: 5},
+		{name: "the last message is a tool result", msgs: conversation[:3], budget: 0, cut: 1},
+		{name: "only tool results after the first message", msgs: []message.Message{conversation[0], conversation[2], conversation[6]}, budget: 0, cut: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.cut, compactionCut(tok, tt.msgs, tt.budget))
+		})
+	}
+}
+
+func TestSessionHistory(t *testing.T) {
+	msgs := []message.Message{
+		textMessage("u1", message.User, "fix the tests"),
+		textMessage("a1", message.Assistant, "they pass"),
+		textMessage("u2", message.User, "and the build"),
+		textMessage("a2", message.Assistant, "it builds"),
+	}
+	summary := summaryMessage("s1", "the tests were fixed", "fix the tests")
+
+	tests := []struct {
+		name    string
+		session session.Session
+		history []message.Message
+	}{
+		{
+			name:    "not compacted",
+			session: session.Session{ID: "s1"},
+			history: msgs,
+		},
+		{
+			name:    "compacted",
+			session: session.Session{ID: "s1", Summary: "the tests were fixed", SummaryMessageID: "u2"},
+			history: []message.Message{summary, msgs[2], msgs[3]},
+		},
+		{
+			name:    "first message after the summary deleted",
+			session: session.Session{ID: "s1", Summary: "the tests were fixed", SummaryMessageID: "deleted"},
+			history: msgs,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.history, sessionHistory(tt.session, msgs))
+		})
+	}
+}
+
+func TestSummaryMessage(t *testing.T) {
+	msg := summaryMessage("s1", "the tests were fixed", "fix the tests")
+	assert.Equal(t, "summary-s1", msg.ID)
+	assert.Equal(t, message.User, msg.Role)
+	assert.Equal(t, "The earlier part of this conversation was summarized to fit the context window.\n\n"+
+		"<first_request>\nfix the tests\n</first_request>\n\n"+
+		"<summary>\nthe tests were fixed\n</summary>", msg.Content().Text)
+
+	msg = summaryMessage("s1", "the tests were fixed", "")
+	assert.NotContains(t, msg.Content().Text, "<first_request>")
+}
+
+func TestTranscript(t *testing.T) {
+	// The cut falls inside the é
+	long := strings.Repeat("a", maxTranscriptPart-1) + "é" + strings.Repeat("b", 10)
+	withImage := textMessage("u2", message.User, "what is this")
+	withImage.Parts = append(withImage.Parts, message.BinaryContent{MIMEType: "image/png", Data: []byte("png")})
+
+	tests := []struct {
+		name       string
+		msgs       []message.Message
+		transcript string
+	}{
+		{
+			name: "conversation",
+			msgs: []message.Message{
+				textMessage("u1", message.User, "list the files"),
+				callMessage("a1", message.ToolCall{ID: "c1", Name: "ls", Input: `{"path":"."}`}),
+				resultMessage("t1", message.ToolResult{ToolCallID: "c1", Name: "ls", Content: "main.go"}),
+				textMessage("a2", message.Assistant, "There is main.go"),
+			},
+			transcript: "User: list the files\n\n" +
+				"Tool call ls: {\"path\":\".\"}\n\n" +
+				"Tool result ls: main.go\n\n" +
+				"Assistant: There is main.go",
+		},
+		{
+			name: "text and tool calls of a response",
+			msgs: []message.Message{{
+				ID:   "a1",
+				Role: message.Assistant,
+				Parts: []message.ContentPart{
+					message.TextContent{Text: "Reading both"},
+					message.ToolCall{ID: "c1", Name: "view", Input: `{"file_path":"a.go"}`},
+					message.ToolCall{ID: "c2", Name: "view", Input: `{"file_path":"b.go"}`},
+				},
+			}},
+			transcript: "Assistant: Reading both\n" +
+				"Tool call view: {\"file_path\":\"a.go\"}\n" +
+				"Tool call view: {\"file_path\":\"b.go\"}",
+		},
+		{
+			name: "tool errors",
+			msgs: []message.Message{
+				resultMessage("t1",
+					message.ToolResult{ToolCallID: "c1", Name: "view", Content: "file not found", IsError: true},
+					message.ToolResult{ToolCallID: "c2", Name: "view", Content: "package main"},
+				),
+			},
+			transcript: "Tool error view: file not found\nTool result view: package main",
+		},
+		{
+			name:       "long tool results",
+			msgs:       []message.Message{resultMessage("t1", message.ToolResult{ToolCallID: "c1", Name: "view", Content: long})},
+			transcript: "Tool result view: " + strings.Repeat("a", maxTranscriptPart-1) + " [... 12 more bytes]",
+		},
+		{
+			name:       "attachments",
+			msgs:       []message.Message{withImage},
+			transcript: "User: what is this\n[attached image/png]",
+		},
+		{
+			name: "earlier summary",
+			msgs: []message.Message{
+				summaryMessage("s1", "the tests were fixed", "fix the tests"),
+				textMessage("u2", message.User, "and the build"),
+			},
+			transcript: "User: The earlier part of this conversation was summarized to fit the context window.\n\n" +
+				"<first_request>\nfix the tests\n</first_request>\n\n" +
+				"<summary>\nthe tests were fixed\n</summary>\n\n" +
+				"User: and the build",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.transcript, transcript(tt.msgs))
+		})
+	}
+}
+
+func TestCompactAgain(t *testing.T) {
+	a, sess := newTestAgent(t)
+	p := &testProvider{
+		model: models.Model{ID: "local", Provider: models.ProviderOllama, ContextWindow: 4000},
+		responses: []provider.ProviderResponse{
+			{Content: "first summary"},
+			{Content: "second summary"},
+		},
+	}
+	a.provider = p
+	ctx := context.Background()
+	text := strings.Repeat("word ", 500)
+	addMessages := func(from, to int) {
+		for i := from; i < to; i++ {
+			role := message.User
+			if i%2 == 1 {
+				role = message.Assistant
+			}
+			_, err := a.messages.Create(ctx, sess.ID, message.CreateMessageParams{
+				Role:  role,
+				Parts: []message.ContentPart{message.TextContent{Text: fmt.Sprintf("message %d: %s", i, text)}},
+			})
+			require.NoError(t, err)
+		}
+	}
+	history := func() []message.Message {
+		sess, err := a.sessions.Get(ctx, sess.ID)
+		require.NoError(t, err)
+		msgs, err := a.messages.List(ctx, sess.ID)
+		require.NoError(t, err)
+		return sessionHistory(sess, msgs)
+	}
+
+	addMessages(0, 12)
+	compacted := a.compact(ctx, sess.ID, history())
+	require.Equal(t, compacted, history())
+	assert.Contains(t, compacted[0].Content().Text, "<summary>\nfirst summary\n</summary>")
+	first, err := a.sessions.Get(ctx, sess.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "first summary", first.Summary)
+
+	// The second summary covers the first one, the first request stays pinned
+	addMessages(12, 20)
+	compacted = a.compact(ctx, sess.ID, history())
+	require.Equal(t, compacted, history())
+	second, err := a.sessions.Get(ctx, sess.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "second summary", second.Summary)
+	assert.NotEqual(t, first.SummaryMessageID, second.SummaryMessageID)
+	assert.Equal(t, second.SummaryMessageID, compacted[1].ID)
+	assert.Contains(t, compacted[0].Content().Text, "<first_request>\nmessage 0: ")
+	assert.Contains(t, compacted[0].Content().Text, "<summary>\nsecond summary\n</summary>")
+
+	require.Len(t, p.sent, 2)
+	request := p.sent[1][0].Content().Text
+	assert.Contains(t, request, "<summary>\nfirst summary\n</summary>")
+}
+
+func TestSummarizeModel(t *testing.T) {
+	msgs := []message.Message{
+		textMessage("u1", message.User, strings.Repeat("word ", 500)),
+		textMessage("a1", message.Assistant, "done"),
+	}
+
+	tests := []struct {
+		name         string
+		summarizer   *models.Model
+		maxTokens    int64
+		bySummarizer bool
+	}{
+		{name: "no summarizer"},
+		{
+			name:         "fits the summarizer",
+			summarizer:   &models.Model{ID: "small", Provider: models.ProviderOllama, ContextWindow: 100_000},
+			maxTokens:    1000,
+			bySummarizer: true,
+		},
+		{
+			name:         "no context window",
+			summarizer:   &models.Model{ID: "small", Provider: models.ProviderOllama},
+			maxTokens:    1000,
+			bySummarizer: true,
+		},
+		{
+			name:       "too long for the summarizer",
+			summarizer: &models.Model{ID: "small", Provider: models.ProviderOllama, ContextWindow: 500},
+			maxTokens:  100,
+		},
+		{
+			// The response of the summarizer must fit its context window as well
+			name:       "no room for the response",
+			summarizer: &models.Model{ID: "small", Provider: models.ProviderOllama, ContextWindow: 100_000},
+			maxTokens:  100_000,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, sess := newTestAgent(t)
+			p := &testProvider{
+				model:     models.Model{ID: "large", Provider: models.ProviderOllama, ContextWindow: 200_000},
+				responses: []provider.ProviderResponse{{Content: "summary of the agent"}},
+			}
+			a.provider = p
+			var summarizer *testProvider
+			if tt.summarizer != nil {
+				summarizer = &testProvider{
+					model:     *tt.summarizer,
+					responses: []provider.ProviderResponse{{Content: "summary of the summarizer"}},
+				}
+				a.summaryProvider = summarizer
+				previous, configured := config.Get().Agents[config.AgentSummarizer]
+				config.Get().Agents[config.AgentSummarizer] = config.Agent{Model: tt.summarizer.ID, MaxTokens: tt.maxTokens}
+				t.Cleanup(func() {
+					if configured {
+						config.Get().Agents[config.AgentSummarizer] = previous
+					} else {
+						delete(config.Get().Agents, config.AgentSummarizer)
+					}
+				})
+			}
+
+			summary, err := a.summarize(context.Background(), sess.ID, msgs)
+			require.NoError(t, err)
+			if tt.bySummarizer {
+				assert.Equal(t, "summary of the summarizer", summary)
+				assert.Empty(t, p.sent)
+				return
+			}
+			assert.Equal(t, "summary of the agent", summary)
+			assert.Len(t, p.sent, 1)
+			if summarizer != nil {
+				assert.Empty(t, summarizer.sent)
+			}
+		})
+	}
+}
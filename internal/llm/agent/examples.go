@@ -0,0 +1,94 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/opencode-ai/opencode/internal/config"
+	"github.com/opencode-ai/opencode/internal/llm/models"
+	"github.com/opencode-ai/opencode/internal/llm/tools"
+	"github.com/opencode-ai/opencode/internal/message"
+)
+
+// exampleMessages returns the example exchanges of the configuration for the
+// model as messages sent before the conversation. Examples calling tools that
+// aren't offered in the session are left out, so the model isn't shown calls
+// it can't make.
+func exampleMessages(model models.Model, available []tools.BaseTool) []message.Message {
+	examples := config.ExamplesFor(string(model.ID))
+	if len(examples) == 0 {
+		return nil
+	}
+	offered := make(map[string]bool, len(available))
+	for _, tool := range available {
+		offered[tool.Info().Name] = true
+	}
+
+	var msgs []message.Message
+	for i, example := range examples {
+		if converted, ok := exampleConversation(i, example, offered); ok {
+			msgs = append(msgs, converted...)
+		}
+	}
+	return msgs
+}
+
+// exampleConversation converts the messages of an example, it reports false
+// when the example calls a tool that isn't offered.
+func exampleConversation(n int, example config.Example, offered map[string]bool) ([]message.Message, bool) {
+	msgs := make([]message.Message, 0, len(example.Messages))
+	var calls []message.ToolCall
+	for j, msg := range example.Messages {
+		id := fmt.Sprintf("example-%d-%d", n+1, j+1)
+		switch msg.Role {
+		case config.ExampleRoleUser:
+			msgs = append(msgs, message.Message{
+				ID:    id,
+				Role:  message.User,
+				Parts: []message.ContentPart{message.TextContent{Text: msg.Content}},
+			})
+		case config.ExampleRoleAssistant:
+			var parts []message.ContentPart
+			if msg.Content != "" {
+				parts = append(parts, message.TextContent{Text: msg.Content})
+			}
+			calls = calls[:0]
+			for k, call := range msg.ToolCalls {
+				if !offered[call.Name] {
+					return nil, false
+				}
+				input, err := json.Marshal(call.Input)
+				if err != nil || call.Input == nil {
+					input = []byte("{}")
+				}
+				toolCall := message.ToolCall{
+					ID:       fmt.Sprintf("%s-%d", id, k+1),
+					Name:     call.Name,
+					Input:    string(input),
+					Type:     "function",
+					Finished: true,
+				}
+				calls = append(calls, toolCall)
+				parts = append(parts, toolCall)
+			}
+			reason := message.FinishReasonEndTurn
+			if len(calls) > 0 {
+				reason = message.FinishReasonToolUse
+			}
+			parts = append(parts, message.Finish{Reason: reason})
+			msgs = append(msgs, message.Message{ID: id, Role: message.Assistant, Parts: parts})
+		case config.ExampleRoleTool:
+			// The configuration is validated, each tool message answers a call
+			call := calls[0]
+			calls = calls[1:]
+			result := message.ToolResult{ToolCallID: call.ID, Name: call.Name, Content: msg.Content}
+			// The results of the calls of a message are sent together
+			if last := &msgs[len(msgs)-1]; last.Role == message.Tool {
+				last.Parts = append(last.Parts, result)
+				continue
+			}
+			msgs = append(msgs, message.Message{ID: id, Role: message.Tool, Parts: []message.ContentPart{result}})
+		}
+	}
+	return msgs, true
+}
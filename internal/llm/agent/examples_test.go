@@ -0,0 +1,101 @@
+package agent
+
+import (
+	"context"
+	"testing"
+
+	"github.com/opencode-ai/opencode/internal/config"
+	"github.com/opencode-ai/opencode/internal/llm/models"
+	"github.com/opencode-ai/opencode/internal/llm/provider"
+	"github.com/opencode-ai/opencode/internal/llm/tools"
+	"github.com/opencode-ai/opencode/internal/message"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// withExamples sets the examples of the configuration for the test.
+func withExamples(t *testing.T, examples ...config.Example) {
+	t.Helper()
+	loadTestConfig(t)
+	previous := config.Get().Examples
+	config.Get().Examples = examples
+	t.Cleanup(func() { config.Get().Examples = previous })
+}
+
+func TestExampleMessages(t *testing.T) {
+	withExamples(t,
+		config.Example{Models: []string{"ollama.*"}, Messages: []config.ExampleMessage{
+			{Role: config.ExampleRoleUser, Content: "Which Go files are there?"},
+			{Role: config.ExampleRoleAssistant, Content: "Let me look.", ToolCalls: []config.ExampleToolCall{
+				{Name: tools.GlobToolName, Input: map[string]any{"pattern": "**/*.go"}},
+				{Name: tools.LSToolName},
+			}},
+			{Role: config.ExampleRoleTool, Content: "main.go"},
+			{Role: config.ExampleRoleTool, Content: "main.go\ngo.mod"},
+			{Role: config.ExampleRoleAssistant, Content: "There is main.go."},
+		}},
+		// Calls a tool that isn't offered
+		config.Example{Models: []string{"ollama.*"}, Messages: []config.ExampleMessage{
+			{Role: config.ExampleRoleUser, Content: "Run the tests"},
+			{Role: config.ExampleRoleAssistant, ToolCalls: []config.ExampleToolCall{{Name: tools.BashToolName, Input: map[string]any{"command": "go test ./..."}}}},
+			{Role: config.ExampleRoleTool, Content: "ok"},
+		}},
+	)
+	noop := func(ctx context.Context, call tools.ToolCall) (tools.ToolResponse, error) {
+		return tools.NewTextResponse(""), nil
+	}
+	available := []tools.BaseTool{testTool{name: tools.GlobToolName, run: noop}, testTool{name: tools.LSToolName, run: noop}}
+
+	msgs := exampleMessages(models.Model{ID: "ollama.qwen2.5-coder"}, available)
+	require.Len(t, msgs, 4)
+	assert.Equal(t, message.User, msgs[0].Role)
+	assert.Equal(t, "Which Go files are there?", msgs[0].Content().Text)
+
+	assert.Equal(t, message.Assistant, msgs[1].Role)
+	assert.Equal(t, "Let me look.", msgs[1].Content().Text)
+	assert.Equal(t, []message.ToolCall{
+		{ID: "example-1-2-1", Name: tools.GlobToolName, Input: `{"pattern":"**/*.go"}`, Type: "function", Finished: true},
+		{ID: "example-1-2-2", Name: tools.LSToolName, Input: "{}", Type: "function", Finished: true},
+	}, msgs[1].ToolCalls())
+	assert.Equal(t, message.FinishReasonToolUse, msgs[1].FinishReason())
+
+	// The results of the calls of a message are sent together, in order
+	assert.Equal(t, message.Tool, msgs[2].Role)
+	assert.Equal(t, []message.ToolResult{
+		{ToolCallID: "example-1-2-1", Name: tools.GlobToolName, Content: "main.go"},
+		{ToolCallID: "example-1-2-2", Name: tools.LSToolName, Content: "main.go\ngo.mod"},
+	}, msgs[2].ToolResults())
+
+	assert.Equal(t, "There is main.go.", msgs[3].Content().Text)
+	assert.Equal(t, message.FinishReasonEndTurn, msgs[3].FinishReason())
+
+	assert.Empty(t, exampleMessages(models.Model{ID: models.Claude37Sonnet}, available))
+}
+
+func TestRunSendsExamples(t *testing.T) {
+	withExamples(t, config.Example{Models: []string{"ollama.*"}, Messages: []config.ExampleMessage{
+		{Role: config.ExampleRoleUser, Content: "What is in main.go?"},
+		{Role: config.ExampleRoleAssistant, ToolCalls: []config.ExampleToolCall{{Name: tools.ViewToolName, Input: map[string]any{"file_path": "main.go"}}}},
+		{Role: config.ExampleRoleTool, Content: "package main"},
+		{Role: config.ExampleRoleAssistant, Content: "The main package."},
+	}})
+	view := testTool{name: tools.ViewToolName, run: func(ctx context.Context, call tools.ToolCall) (tools.ToolResponse, error) {
+		return tools.NewTextResponse("content"), nil
+	}}
+	a, sess := newTestAgent(t, view)
+	p := &testProvider{model: models.Model{ID: "ollama.qwen2.5-coder", Provider: models.ProviderOllama}, responses: []provider.ProviderResponse{
+		{Content: "Done.", FinishReason: message.FinishReasonEndTurn},
+	}}
+	a.provider = p
+
+	result := runTurn(t, a, sess.ID, "check the config")
+	require.NoError(t, result.Err())
+	require.Len(t, p.sent[0], 5)
+	assert.Equal(t, "What is in main.go?", p.sent[0][0].Content().Text)
+	assert.Equal(t, "check the config", p.sent[0][4].Content().Text)
+
+	// The examples aren't part of the conversation
+	msgs, err := a.messages.List(context.Background(), sess.ID)
+	require.NoError(t, err)
+	assert.Len(t, msgs, 2)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
 
 	"github.com/opencode-ai/opencode/internal/config"
 	"github.com/opencode-ai/opencode/internal/llm/tools"
@@ -67,18 +68,37 @@ func runTool(ctx context.Context, c MCPClient, toolName string, input string) (t
 		return tools.NewTextErrorResponse(err.Error()), nil
 	}
 
-	output := ""
-	for _, v := range result.Content {
-		if v, ok := v.(mcp.TextContent); ok {
-			output = v.Text
-		} else {
-			output = fmt.Sprintf("%v", v)
-		}
+	output := mcpToolOutput(result)
+	if result.IsError {
+		return tools.NewTextErrorResponse(output), nil
 	}
-
 	return tools.NewTextResponse(output), nil
 }
 
+// mcpToolOutput joins the content of a tool result. Binary content can't be
+// passed on to the model, it is only described.
+func mcpToolOutput(result *mcp.CallToolResult) string {
+	parts := make([]string, 0, len(result.Content))
+	for _, content := range result.Content {
+		switch content := content.(type) {
+		case mcp.TextContent:
+			parts = append(parts, content.Text)
+		case mcp.ImageContent:
+			parts = append(parts, fmt.Sprintf("[image %s]", content.MIMEType))
+		case mcp.EmbeddedResource:
+			switch resource := content.Resource.(type) {
+			case mcp.TextResourceContents:
+				parts = append(parts, fmt.Sprintf("%s:\n%s", resource.URI, resource.Text))
+			case mcp.BlobResourceContents:
+				parts = append(parts, fmt.Sprintf("[resource %s %s]", resource.URI, resource.MIMEType))
+			}
+		default:
+			parts = append(parts, fmt.Sprintf("%v", content))
+		}
+	}
+	return strings.Join(parts, "\n\n")
+}
+
 func (b *mcpTool) Run(ctx context.Context, params tools.ToolCall) (tools.ToolResponse, error) {
 	sessionID, messageID := tools.GetContextValues(ctx)
 	if sessionID == "" || messageID == "" {
@@ -99,29 +119,38 @@ func (b *mcpTool) Run(ctx context.Context, params tools.ToolCall) (tools.ToolRes
 		return tools.NewTextErrorResponse("permission denied"), nil
 	}
 
-	switch b.mcpConfig.Type {
+	c, err := newMCPClient(ctx, b.mcpConfig)
+	if err != nil {
+		return tools.NewTextErrorResponse(err.Error()), nil
+	}
+	return runTool(ctx, c, b.tool.Name, params.Input)
+}
+
+// newMCPClient connects to an MCP server. SSE clients have to be started
+// to receive the endpoint that requests are posted to.
+func newMCPClient(ctx context.Context, m config.MCPServer) (MCPClient, error) {
+	switch m.Type {
 	case config.MCPStdio:
-		c, err := client.NewStdioMCPClient(
-			b.mcpConfig.Command,
-			b.mcpConfig.Env,
-			b.mcpConfig.Args...,
+		return client.NewStdioMCPClient(
+			m.Command,
+			m.Env,
+			m.Args...,
 		)
-		if err != nil {
-			return tools.NewTextErrorResponse(err.Error()), nil
-		}
-		return runTool(ctx, c, b.tool.Name, params.Input)
 	case config.MCPSse:
 		c, err := client.NewSSEMCPClient(
-			b.mcpConfig.URL,
-			client.WithHeaders(b.mcpConfig.Headers),
+			m.URL,
+			client.WithHeaders(m.Headers),
 		)
 		if err != nil {
-			return tools.NewTextErrorResponse(err.Error()), nil
+			return nil, err
 		}
-		return runTool(ctx, c, b.tool.Name, params.Input)
+		if err := c.Start(ctx); err != nil {
+			c.Close()
+			return nil, err
+		}
+		return c, nil
 	}
-
-	return tools.NewTextErrorResponse("invalid mcp type"), nil
+	return nil, fmt.Errorf("invalid mcp type %q", m.Type)
 }
 
 func NewMcpTool(name string, tool mcp.Tool, permissions permission.Service, mcpConfig config.MCPServer) tools.BaseTool {
@@ -136,6 +165,7 @@ func NewMcpTool(name string, tool mcp.Tool, permissions permission.Service, mcpC
 var mcpTools []tools.BaseTool
 
 func getTools(ctx context.Context, name string, m config.MCPServer, permissions permission.Service, c MCPClient) []tools.BaseTool {
+	defer c.Close()
 	var stdioTools []tools.BaseTool
 	initRequest := mcp.InitializeRequest{}
 	initRequest.Params.ProtocolVersion = mcp.LATEST_PROTOCOL_VERSION
@@ -158,7 +188,6 @@ func getTools(ctx context.Context, name string, m config.MCPServer, permissions
 	for _, t := range tools.Tools {
 		stdioTools = append(stdioTools, NewMcpTool(name, t, permissions, m))
 	}
-	defer c.Close()
 	return stdioTools
 }
 
@@ -167,30 +196,12 @@ func GetMcpTools(ctx context.Context, permissions permission.Service) []tools.Ba
 		return mcpTools
 	}
 	for name, m := range config.Get().MCPServers {
-		switch m.Type {
-		case config.MCPStdio:
-			c, err := client.NewStdioMCPClient(
-				m.Command,
-				m.Env,
-				m.Args...,
-			)
-			if err != nil {
-				logging.Error("error creating mcp client", "error", err)
-				continue
-			}
-
-			mcpTools = append(mcpTools, getTools(ctx, name, m, permissions, c)...)
-		case config.MCPSse:
-			c, err := client.NewSSEMCPClient(
-				m.URL,
-				client.WithHeaders(m.Headers),
-			)
-			if err != nil {
-				logging.Error("error creating mcp client", "error", err)
-				continue
-			}
-			mcpTools = append(mcpTools, getTools(ctx, name, m, permissions, c)...)
+		c, err := newMCPClient(ctx, m)
+		if err != nil {
+			logging.Error("error creating mcp client", "error", err)
+			continue
 		}
+		mcpTools = append(mcpTools, getTools(ctx, name, m, permissions, c)...)
 	}
 
 	return mcpTools
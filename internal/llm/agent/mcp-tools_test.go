@@ -0,0 +1,137 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/opencode-ai/opencode/internal/llm/tools"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// parseToolResult parses a tool result like the MCP client does.
+func parseToolResult(t *testing.T, result string) *mcp.CallToolResult {
+	t.Helper()
+	raw := json.RawMessage(result)
+	parsed, err := mcp.ParseCallToolResult(&raw)
+	require.NoError(t, err)
+	return parsed
+}
+
+func TestMCPToolOutput(t *testing.T) {
+	tests := []struct {
+		name   string
+		result string
+		output string
+	}{
+		{
+			name:   "text",
+			result: `{"content":[{"type":"text","text":"3 issues"}]}`,
+			output: "3 issues",
+		},
+		{
+			name:   "no content",
+			result: `{"content":[]}`,
+			output: "",
+		},
+		{
+			name:   "several blocks",
+			result: `{"content":[{"type":"text","text":"first"},{"type":"image","data":"cG5n","mimeType":"image/png"},{"type":"text","text":"last"}]}`,
+			output: "first\n\n[image image/png]\n\nlast",
+		},
+		{
+			name:   "text resource",
+			result: `{"content":[{"type":"resource","resource":{"uri":"file:///notes.md","mimeType":"text/markdown","text":"# Notes"}}]}`,
+			output: "file:///notes.md:\n# Notes",
+		},
+		{
+			name:   "binary resource",
+			result: `{"content":[{"type":"resource","resource":{"uri":"file:///logo.png","mimeType":"image/png","blob":"cG5n"}}]}`,
+			output: "[resource file:///logo.png image/png]",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.output, mcpToolOutput(parseToolResult(t, tt.result)))
+		})
+	}
+}
+
+// testMCPClient answers the tool calls with a result.
+type testMCPClient struct {
+	result  *mcp.CallToolResult
+	err     error
+	request mcp.CallToolRequest
+	closed  bool
+}
+
+func (c *testMCPClient) Initialize(ctx context.Context, request mcp.InitializeRequest) (*mcp.InitializeResult, error) {
+	return &mcp.InitializeResult{}, nil
+}
+
+func (c *testMCPClient) ListTools(ctx context.Context, request mcp.ListToolsRequest) (*mcp.ListToolsResult, error) {
+	return &mcp.ListToolsResult{}, nil
+}
+
+func (c *testMCPClient) CallTool(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	c.request = request
+	return c.result, c.err
+}
+
+func (c *testMCPClient) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestRunMCPTool(t *testing.T) {
+	tests := []struct {
+		name     string
+		result   string
+		err      error
+		input    string
+		response tools.ToolResponse
+	}{
+		{
+			name:     "result",
+			result:   `{"content":[{"type":"text","text":"3 issues"}]}`,
+			input:    `{"state":"open"}`,
+			response: tools.NewTextResponse("3 issues"),
+		},
+		{
+			name:     "error result",
+			result:   `{"content":[{"type":"text","text":"rate limited"},{"type":"text","text":"retry in 60s"}],"isError":true}`,
+			input:    `{"state":"open"}`,
+			response: tools.NewTextErrorResponse("rate limited\n\nretry in 60s"),
+		},
+		{
+			name:     "failed call",
+			err:      errors.New("connection closed"),
+			input:    `{"state":"open"}`,
+			response: tools.NewTextErrorResponse("connection closed"),
+		},
+		{
+			name:     "invalid parameters",
+			input:    `{"state":`,
+			response: tools.NewTextErrorResponse("error parsing parameters: unexpected end of JSON input"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &testMCPClient{err: tt.err}
+			if tt.result != "" {
+				c.result = parseToolResult(t, tt.result)
+			}
+			response, err := runTool(context.Background(), c, "list_issues", tt.input)
+			require.NoError(t, err)
+			assert.Equal(t, tt.response, response)
+			assert.True(t, c.closed)
+			if tt.result != "" {
+				assert.Equal(t, "list_issues", c.request.Params.Name)
+				assert.Equal(t, map[string]any{"state": "open"}, c.request.Params.Arguments)
+			}
+		})
+	}
+}
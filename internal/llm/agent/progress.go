@@ -0,0 +1,107 @@
+package agent
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/opencode-ai/opencode/internal/pubsub"
+)
+
+const (
+	// maxToolOutput is how much of the end of the output of a running tool is
+	// kept for showing it
+	maxToolOutput = 16 * 1024
+	// toolOutputInterval is the least time between two updates of the output
+	// of a running tool
+	toolOutputInterval = 100 * time.Millisecond
+)
+
+// ToolOutput is the output a tool call has written so far while it runs. It
+// is updated while the tool runs and deleted when it finished, the result of
+// the tool is in the tool message then.
+type ToolOutput struct {
+	SessionID  string
+	ToolCallID string
+	Output     string
+}
+
+var toolOutputs = pubsub.NewBroker[ToolOutput]()
+
+// SubscribeToolOutput returns the output of the running tool calls of all
+// sessions.
+func SubscribeToolOutput(ctx context.Context) <-chan pubsub.Event[ToolOutput] {
+	return toolOutputs.Subscribe(ctx)
+}
+
+// toolProgress is the progress writer of a tool call, it publishes the end of
+// the output at most every toolOutputInterval.
+type toolProgress struct {
+	sessionID  string
+	toolCallID string
+
+	mu        sync.Mutex
+	output    []byte
+	published time.Time
+	timer     *time.Timer
+	done      bool
+}
+
+func newToolProgress(sessionID, toolCallID string) *toolProgress {
+	return &toolProgress{sessionID: sessionID, toolCallID: toolCallID}
+}
+
+func (p *toolProgress) Write(b []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.done {
+		return len(b), nil
+	}
+	p.output = append(p.output, b...)
+	if len(p.output) > maxToolOutput {
+		p.output = append([]byte(nil), p.output[len(p.output)-maxToolOutput:]...)
+	}
+	if wait := toolOutputInterval - time.Since(p.published); wait > 0 {
+		if p.timer == nil {
+			p.timer = time.AfterFunc(wait, p.flush)
+		}
+		return len(b), nil
+	}
+	p.publish()
+	return len(b), nil
+}
+
+func (p *toolProgress) flush() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.timer = nil
+	if !p.done {
+		p.publish()
+	}
+}
+
+func (p *toolProgress) publish() {
+	p.published = time.Now()
+	toolOutputs.Publish(pubsub.UpdatedEvent, ToolOutput{
+		SessionID:  p.sessionID,
+		ToolCallID: p.toolCallID,
+		Output:     string(p.output),
+	})
+}
+
+// finish stops the updates of the output, it is deleted if there was any.
+func (p *toolProgress) finish() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.done = true
+	if p.timer != nil {
+		p.timer.Stop()
+		p.timer = nil
+	}
+	if !p.published.IsZero() {
+		toolOutputs.Publish(pubsub.DeletedEvent, ToolOutput{
+			SessionID:  p.sessionID,
+			ToolCallID: p.toolCallID,
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	"github.com/opencode-ai/opencode/internal/llm/tools"
+	"github.com/opencode-ai/opencode/internal/message"
+)
+
+// maxToolCallRepairs is how many times in a row the model is asked to repeat
+// tool calls whose arguments don't match the parameters of their tool. The
+// invalid calls of the last response fail with what is wrong with them.
+const maxToolCallRepairs = 2
+
+// invalidToolCalls returns why the arguments of the calls of available tools
+// are invalid, by tool call ID. Calls of other tools fail when they run.
+func invalidToolCalls(calls []message.ToolCall, available []tools.BaseTool) map[string]error {
+	invalid := make(map[string]error)
+	for _, call := range calls {
+		for _, tool := range available {
+			if tool.Info().Name != call.Name {
+				continue
+			}
+			if err := tools.ValidateInput(tool.Info(), call.Input); err != nil {
+				invalid[call.ID] = err
+			}
+			break
+		}
+	}
+	return invalid
+}
+
+// repairMessage is the corrective turn answering the calls of the message
+// without running them, it asks the model to repeat the invalid ones with
+// arguments matching the parameters of their tool.
+func repairMessage(assistantMsg message.Message, invalid map[string]error) message.Message {
+	calls := assistantMsg.ToolCalls()
+	results := make([]message.ContentPart, len(calls))
+	for i, call := range calls {
+		content := "Not run, other tool calls of the response have invalid arguments. Make this call again if it is still needed."
+		if err, ok := invalid[call.ID]; ok {
+			content = fmt.Sprintf("Invalid arguments for %s: %s. Make the call again with a JSON object of arguments matching the parameters of the tool.", call.Name, err)
+		}
+		results[i] = message.ToolResult{ToolCallID: call.ID, Name: call.Name, Content: content, IsError: true}
+	}
+	return message.Message{
+		ID:        assistantMsg.ID + "-repair",
+		SessionID: assistantMsg.SessionID,
+		Role:      message.Tool,
+		Parts:     results,
+	}
+}
+
+// withObjectInputs returns the message with the arguments of its tool calls
+// that aren't a JSON object replaced by an empty object, providers reject a
+// conversation with such calls. The tool results tell the model what was
+// wrong with them.
+func withObjectInputs(msg message.Message) message.Message {
+	msg.Parts = slices.Clone(msg.Parts)
+	for i, part := range msg.Parts {
+		call, ok := part.(message.ToolCall)
+		if !ok {
+			continue
+		}
+		var args map[string]any
+		if json.Unmarshal([]byte(call.Input), &args) != nil {
+			call.Input = "{}"
+			msg.Parts[i] = call
+		}
+	}
+	return msg
+}
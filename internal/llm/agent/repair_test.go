@@ -0,0 +1,64 @@
+package agent
+
+import (
+	"context"
+	"testing"
+
+	"github.com/opencode-ai/opencode/internal/llm/models"
+	"github.com/opencode-ai/opencode/internal/llm/provider"
+	"github.com/opencode-ai/opencode/internal/llm/tools"
+	"github.com/opencode-ai/opencode/internal/message"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunRepairsToolCalls(t *testing.T) {
+	var inputs []string
+	view := testTool{name: tools.ViewToolName, run: func(ctx context.Context, call tools.ToolCall) (tools.ToolResponse, error) {
+		inputs = append(inputs, call.Input)
+		return tools.NewTextResponse("content of the file"), nil
+	}}
+	a, sess := newTestAgent(t, view)
+	p := &testProvider{model: models.Model{ID: "local", Provider: models.ProviderOllama}, responses: []provider.ProviderResponse{
+		{
+			ToolCalls:    []message.ToolCall{{ID: "view-1", Name: tools.ViewToolName, Input: "not json", Finished: true}},
+			Usage:        provider.TokenUsage{InputTokens: 100},
+			FinishReason: message.FinishReasonToolUse,
+		},
+		{
+			ToolCalls:    []message.ToolCall{{ID: "view-2", Name: tools.ViewToolName, Input: "{}", Finished: true}},
+			Usage:        provider.TokenUsage{InputTokens: 150},
+			FinishReason: message.FinishReasonToolUse,
+		},
+		{Content: "Done.", Usage: provider.TokenUsage{InputTokens: 200}, FinishReason: message.FinishReasonEndTurn},
+	}}
+	a.provider = p
+
+	result := runTurn(t, a, sess.ID, "check the file")
+	require.NoError(t, result.Err())
+	assert.Equal(t, []string{"{}"}, inputs)
+
+	// The repair request answers the invalid call without running it
+	require.Len(t, p.sent, 3)
+	repair := p.sent[1][len(p.sent[1])-1]
+	assert.Equal(t, message.Tool, repair.Role)
+	require.Len(t, repair.ToolResults(), 1)
+	assert.True(t, repair.ToolResults()[0].IsError)
+	assert.Contains(t, repair.ToolResults()[0].Content, "Invalid arguments for view")
+
+	// The response with the invalid call is replaced by the repaired one
+	msgs, err := a.messages.List(context.Background(), sess.ID)
+	require.NoError(t, err)
+	require.Len(t, msgs, 4)
+	repaired := msgs[1]
+	require.Len(t, repaired.ToolCalls(), 1)
+	assert.Equal(t, "view-2", repaired.ToolCalls()[0].ID)
+
+	// Its usage is billed to the repaired response
+	records, err := a.usage.ForSession(context.Background(), sess.ID)
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+	assert.Equal(t, repaired.ID, records[0].MessageID)
+	assert.Equal(t, repaired.ID, records[1].MessageID)
+	assert.Equal(t, msgs[3].ID, records[2].MessageID)
+}
@@ -0,0 +1,318 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/opencode-ai/opencode/internal/config"
+	"github.com/opencode-ai/opencode/internal/llm/tools"
+	"github.com/opencode-ai/opencode/internal/logging"
+	"github.com/opencode-ai/opencode/internal/message"
+	"github.com/opencode-ai/opencode/internal/permission"
+)
+
+const (
+	// defaultToolConcurrency is the number of read-only tool calls run at the
+	// same time when the agent doesn't configure it
+	defaultToolConcurrency = 4
+	// toolTimeoutGrace is how long a tool that timed out has to return its
+	// partial result, and how much longer than the timeout it asks for a tool
+	// call may run
+	toolTimeoutGrace = 5 * time.Second
+)
+
+// toolTimeoutUnits are the units of the timeout parameters of the tools that
+// have one.
+var toolTimeoutUnits = map[string]time.Duration{
+	tools.BashToolName:        time.Millisecond,
+	tools.BrowserToolName:     time.Second,
+	tools.ContainerToolName:   time.Millisecond,
+	tools.FetchToolName:       time.Second,
+	tools.HTTPToolName:        time.Second,
+	tools.NotebookToolName:    time.Millisecond,
+	tools.SourcegraphToolName: time.Second,
+	tools.TestToolName:        time.Millisecond,
+}
+
+// parallelTools only read and never ask for permission, so consecutive calls
+// of them can run at the same time.
+var parallelTools = map[string]bool{
+	AgentToolName:              true,
+	tools.DependenciesToolName: true,
+	tools.DiagnosticsToolName:  true,
+	tools.GlobToolName:         true,
+	tools.GrepToolName:         true,
+	tools.LSToolName:           true,
+	tools.OutputToolName:       true,
+	tools.SourcegraphToolName:  true,
+	tools.SymbolsToolName:      true,
+	tools.ViewToolName:         true,
+	tools.WebSearchToolName:    true,
+}
+
+// toolRun collects the results of the tool calls of an assistant message. The
+// results are published in the tool message as they finish, in the order of
+// the calls.
+type toolRun struct {
+	agent     *agent
+	sessionID string
+	calls     []message.ToolCall
+	// available are the tools the session offers
+	available []tools.BaseTool
+
+	mu      sync.Mutex
+	results []*message.ToolResult
+	msg     *message.Message
+}
+
+// runToolCalls runs the tool calls of the assistant message and returns the
+// tool message with their results. Consecutive calls of parallelTools run
+// concurrently, every other call runs on its own after the calls before it.
+// Calls of tools that aren't available fail.
+func (a *agent) runToolCalls(ctx context.Context, assistantMsg *message.Message, available []tools.BaseTool) (*message.Message, error) {
+	run := &toolRun{
+		agent:     a,
+		sessionID: assistantMsg.SessionID,
+		calls:     assistantMsg.ToolCalls(),
+		available: available,
+	}
+	run.results = make([]*message.ToolResult, len(run.calls))
+	concurrency := defaultToolConcurrency
+	if n := config.Get().Agents[a.name].ToolConcurrency; n > 0 {
+		concurrency = n
+	}
+
+	for i := 0; i < len(run.calls); {
+		if ctx.Err() != nil {
+			a.finishMessage(context.Background(), assistantMsg, message.FinishReasonCanceled)
+			run.cancelFrom(i)
+			break
+		}
+		end := i + 1
+		if parallelTools[run.calls[i].Name] {
+			for end < len(run.calls) && parallelTools[run.calls[end].Name] {
+				end++
+			}
+		}
+		if denied := run.runBatch(ctx, i, end, concurrency); denied {
+			run.cancelFrom(end)
+			a.finishMessage(ctx, assistantMsg, message.FinishReasonPermissionDenied)
+			break
+		}
+		i = end
+	}
+	return run.finish()
+}
+
+// runBatch runs the calls from start to end, at most concurrency at a time,
+// and reports if the permission of one of them was denied.
+func (r *toolRun) runBatch(ctx context.Context, start, end, concurrency int) bool {
+	var wg sync.WaitGroup
+	var denied atomic.Bool
+	slots := make(chan struct{}, concurrency)
+	for i := start; i < end; i++ {
+		slots <- struct{}{}
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			defer logging.RecoverPanic("agent.runTool", func() {
+				r.set(i, message.ToolResult{
+					ToolCallID: r.calls[i].ID,
+					Content:    "Tool execution failed",
+					IsError:    true,
+				})
+			})
+			result, err := r.agent.runTool(ctx, r.available, r.calls[i])
+			if errors.Is(err, permission.ErrorPermissionDenied) {
+				denied.Store(true)
+			}
+			r.set(i, result)
+		}(i)
+	}
+	wg.Wait()
+	return denied.Load()
+}
+
+func (a *agent) runTool(ctx context.Context, available []tools.BaseTool, toolCall message.ToolCall) (message.ToolResult, error) {
+	var tool tools.BaseTool
+	for _, availableTool := range available {
+		if availableTool.Info().Name == toolCall.Name {
+			tool = availableTool
+		}
+	}
+	if tool == nil && slices.ContainsFunc(a.tools, func(t tools.BaseTool) bool { return t.Info().Name == toolCall.Name }) {
+		return message.ToolResult{
+			ToolCallID: toolCall.ID,
+			Content:    fmt.Sprintf("Tool %s is not available in this session, its tool profile doesn't offer it", toolCall.Name),
+			IsError:    true,
+		}, nil
+	}
+	if tool == nil {
+		return message.ToolResult{
+			ToolCallID: toolCall.ID,
+			Content:    fmt.Sprintf("Tool not found: %s", toolCall.Name),
+			IsError:    true,
+		}, nil
+	}
+
+	if err := tools.ValidateInput(tool.Info(), toolCall.Input); err != nil {
+		return message.ToolResult{
+			ToolCallID: toolCall.ID,
+			Content:    fmt.Sprintf("Invalid arguments for %s: %s", toolCall.Name, err),
+			IsError:    true,
+		}, nil
+	}
+
+	timeout := toolTimeout(toolCall)
+	runCtx, cancel := ctx, context.CancelFunc(func() {})
+	if timeout > 0 {
+		runCtx, cancel = context.WithTimeout(ctx, timeout)
+	}
+	defer cancel()
+	sessionID, _ := tools.GetContextValues(ctx)
+	progress := newToolProgress(sessionID, toolCall.ID)
+	defer progress.finish()
+	runCtx = tools.WithProgress(runCtx, progress)
+
+	var toolResult tools.ToolResponse
+	var toolErr error
+	done := make(chan struct{})
+	a.fileChanges.toolStarted()
+	go func() {
+		defer close(done)
+		defer a.fileChanges.toolFinished()
+		defer logging.RecoverPanic("agent.runTool", func() {
+			toolResult = tools.NewTextErrorResponse("Tool execution failed")
+		})
+		toolResult, toolErr = tool.Run(runCtx, tools.ToolCall{
+			ID:    toolCall.ID,
+			Name:  toolCall.Name,
+			Input: toolCall.Input,
+		})
+	}()
+
+	select {
+	case <-done:
+	case <-runCtx.Done():
+		if ctx.Err() != nil {
+			// Canceled by the user, the tools stop on their own
+			<-done
+			break
+		}
+		// Give the tool the time to stop and return what it has so far
+		select {
+		case <-done:
+		case <-time.After(toolTimeoutGrace):
+			return message.ToolResult{
+				ToolCallID: toolCall.ID,
+				Content:    fmt.Sprintf("Tool execution timed out after %s", timeout),
+				IsError:    true,
+			}, nil
+		}
+	}
+	if ctx.Err() == nil && errors.Is(runCtx.Err(), context.DeadlineExceeded) {
+		content := fmt.Sprintf("Tool execution timed out after %s", timeout)
+		if toolResult.Content != "" {
+			content = toolResult.Content + "\n\n" + content
+		}
+		return message.ToolResult{
+			ToolCallID: toolCall.ID,
+			Content:    content,
+			Metadata:   toolResult.Metadata,
+			IsError:    true,
+		}, nil
+	}
+	if errors.Is(toolErr, permission.ErrorPermissionDenied) {
+		return message.ToolResult{
+			ToolCallID: toolCall.ID,
+			Content:    "Permission denied",
+			IsError:    true,
+		}, toolErr
+	}
+	return message.ToolResult{
+		ToolCallID: toolCall.ID,
+		Content:    toolResult.Content,
+		Metadata:   toolResult.Metadata,
+		IsError:    toolResult.IsError,
+	}, nil
+}
+
+// cancelFrom marks the calls from start on as canceled.
+func (r *toolRun) cancelFrom(start int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := start; i < len(r.calls); i++ {
+		r.results[i] = &message.ToolResult{
+			ToolCallID: r.calls[i].ID,
+			Content:    "Tool execution canceled by user",
+			IsError:    true,
+		}
+	}
+}
+
+// set stores the result of a call and publishes the results so far.
+func (r *toolRun) set(i int, result message.ToolResult) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.results[i] = &result
+	if err := r.publish(); err != nil {
+		logging.Error("Failed to publish tool results", "error", err)
+	}
+}
+
+// publish creates or updates the tool message with the finished results, it
+// must be called with mu held.
+func (r *toolRun) publish() error {
+	parts := make([]message.ContentPart, 0, len(r.results))
+	for _, result := range r.results {
+		if result != nil {
+			parts = append(parts, *result)
+		}
+	}
+	if r.msg == nil {
+		msg, err := r.agent.messages.Create(context.Background(), r.sessionID, message.CreateMessageParams{
+			Role:  message.Tool,
+			Parts: parts,
+		})
+		if err != nil {
+			return err
+		}
+		r.msg = &msg
+		return nil
+	}
+	r.msg.Parts = parts
+	return r.agent.messages.Update(context.Background(), *r.msg)
+}
+
+func (r *toolRun) finish() (*message.Message, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.publish(); err != nil {
+		return nil, fmt.Errorf("failed to create tool message: %w", err)
+	}
+	return r.msg, nil
+}
+
+// toolTimeout returns the configured timeout of the tool, extended for calls
+// asking for a longer one with their timeout parameter.
+func toolTimeout(toolCall message.ToolCall) time.Duration {
+	timeout := config.ToolTimeout(toolCall.Name)
+	unit, ok := toolTimeoutUnits[toolCall.Name]
+	if timeout == 0 || !ok {
+		return timeout
+	}
+	var params struct {
+		Timeout int `json:"timeout"`
+	}
+	if err := json.Unmarshal([]byte(toolCall.Input), &params); err == nil && params.Timeout > 0 {
+		timeout = max(timeout, time.Duration(params.Timeout)*unit+toolTimeoutGrace)
+	}
+	return timeout
+}
@@ -0,0 +1,347 @@
+package agent
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	_ "github.com/ncruces/go-sqlite3/driver"
+	_ "github.com/ncruces/go-sqlite3/embed"
+	"github.com/opencode-ai/opencode/internal/config"
+	"github.com/opencode-ai/opencode/internal/db"
+	"github.com/opencode-ai/opencode/internal/llm/tools"
+	"github.com/opencode-ai/opencode/internal/message"
+	"github.com/opencode-ai/opencode/internal/permission"
+	"github.com/opencode-ai/opencode/internal/session"
+	"github.com/opencode-ai/opencode/internal/usage"
+	"github.com/pressly/goose/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testAgentName is the agent of the tests, its configuration is set by them
+const testAgentName config.AgentName = "test"
+
+var loadConfig sync.Once
+
+// loadTestConfig loads the configuration of the tests once, without the
+// configuration and the keys of the user.
+func loadTestConfig(t *testing.T) {
+	t.Helper()
+	loadConfig.Do(func() {
+		t.Setenv("HOME", t.TempDir())
+		t.Setenv("ANTHROPIC_API_KEY", "")
+		_, err := config.Load(t.TempDir(), false)
+		require.NoError(t, err)
+	})
+}
+
+// newTestAgent returns an agent with the tools, backed by an in-memory
+// database, and a session of it.
+func newTestAgent(t *testing.T, agentTools ...tools.BaseTool) (*agent, session.Session) {
+	t.Helper()
+	loadTestConfig(t)
+	config.Get().Agents[testAgentName] = config.Agent{}
+
+	conn, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	conn.SetMaxOpenConns(1)
+	goose.SetBaseFS(db.FS)
+	require.NoError(t, goose.SetDialect("sqlite3"))
+	require.NoError(t, goose.Up(conn, "migrations"))
+	q := db.New(conn)
+
+	a := &agent{
+		name:     testAgentName,
+		sessions: session.NewService(q),
+		messages: message.NewService(q),
+		usage:    usage.NewService(q),
+		tools:    agentTools,
+	}
+	sess, err := a.sessions.Create(context.Background(), "test")
+	require.NoError(t, err)
+	return a, sess
+}
+
+// testTool is a tool running a function.
+type testTool struct {
+	name string
+	run  func(ctx context.Context, call tools.ToolCall) (tools.ToolResponse, error)
+}
+
+func (t testTool) Info() tools.ToolInfo {
+	return tools.ToolInfo{Name: t.name, Parameters: map[string]any{}}
+}
+
+func (t testTool) Run(ctx context.Context, call tools.ToolCall) (tools.ToolResponse, error) {
+	return t.run(ctx, call)
+}
+
+// assistantMessage creates an assistant message with the finished tool calls.
+func assistantMessage(t *testing.T, a *agent, sessionID string, calls ...message.ToolCall) *message.Message {
+	t.Helper()
+	parts := make([]message.ContentPart, len(calls))
+	for i, call := range calls {
+		call.Input = "{}"
+		call.Finished = true
+		parts[i] = call
+	}
+	msg, err := a.messages.Create(context.Background(), sessionID, message.CreateMessageParams{
+		Role:  message.Assistant,
+		Parts: parts,
+	})
+	require.NoError(t, err)
+	return &msg
+}
+
+func resultContents(msg *message.Message) []string {
+	var contents []string
+	for _, result := range msg.ToolResults() {
+		contents = append(contents, result.ToolCallID+": "+result.Content)
+	}
+	return contents
+}
+
+func TestRunToolCallsBatches(t *testing.T) {
+	// events records the start and the end of the calls
+	var mu sync.Mutex
+	var events []string
+	record := func(event string) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, event)
+	}
+	tool := func(name string, run func() string) tools.BaseTool {
+		return testTool{name: name, run: func(ctx context.Context, call tools.ToolCall) (tools.ToolResponse, error) {
+			record("start " + call.ID)
+			defer record("end " + call.ID)
+			return tools.NewTextResponse(run()), nil
+		}}
+	}
+	// The view waits for the grep that runs at the same time
+	grepStarted := make(chan struct{})
+	var grepOnce sync.Once
+	a, sess := newTestAgent(t,
+		tool(tools.ViewToolName, func() string {
+			select {
+			case <-grepStarted:
+				return "done"
+			case <-time.After(time.Second):
+				return "not run with grep"
+			}
+		}),
+		tool(tools.GrepToolName, func() string {
+			grepOnce.Do(func() { close(grepStarted) })
+			return "done"
+		}),
+		tool(tools.EditToolName, func() string { return "done" }),
+	)
+
+	msg := assistantMessage(t, a, sess.ID,
+		message.ToolCall{ID: "view-1", Name: tools.ViewToolName},
+		message.ToolCall{ID: "grep-1", Name: tools.GrepToolName},
+		message.ToolCall{ID: "edit-1", Name: tools.EditToolName},
+		message.ToolCall{ID: "grep-2", Name: tools.GrepToolName},
+		message.ToolCall{ID: "missing-1", Name: "missing"},
+	)
+	toolMsg, err := a.runToolCalls(context.Background(), msg, a.tools)
+	require.NoError(t, err)
+
+	// The results are in the order of the calls, not of their end
+	assert.Equal(t, []string{
+		"view-1: done",
+		"grep-1: done",
+		"edit-1: done",
+		"grep-2: done",
+		"missing-1: Tool not found: missing",
+	}, resultContents(toolMsg))
+
+	// The read-only calls before the edit run together, it waits for them
+	require.Len(t, events, 8)
+	assert.ElementsMatch(t, []string{"start view-1", "start grep-1", "end view-1", "end grep-1"}, events[:4])
+	assert.Equal(t, []string{"start edit-1", "end edit-1", "start grep-2", "end grep-2"}, events[4:])
+
+	stored, err := a.messages.Get(context.Background(), toolMsg.ID)
+	require.NoError(t, err)
+	assert.Equal(t, resultContents(toolMsg), resultContents(&stored))
+}
+
+func TestRunToolCallsConcurrency(t *testing.T) {
+	var running, maxRunning atomic.Int32
+	view := testTool{name: tools.ViewToolName, run: func(ctx context.Context, call tools.ToolCall) (tools.ToolResponse, error) {
+		n := running.Add(1)
+		defer running.Add(-1)
+		for {
+			current := maxRunning.Load()
+			if n <= current || maxRunning.CompareAndSwap(current, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		return tools.NewTextResponse("done"), nil
+	}}
+	a, sess := newTestAgent(t, view)
+	config.Get().Agents[testAgentName] = config.Agent{ToolConcurrency: 2}
+
+	var calls []message.ToolCall
+	for _, id := range []string{"view-1", "view-2", "view-3", "view-4", "view-5"} {
+		calls = append(calls, message.ToolCall{ID: id, Name: tools.ViewToolName})
+	}
+	toolMsg, err := a.runToolCalls(context.Background(), assistantMessage(t, a, sess.ID, calls...), a.tools)
+	require.NoError(t, err)
+	assert.Len(t, toolMsg.ToolResults(), 5)
+	assert.Equal(t, int32(2), maxRunning.Load())
+}
+
+func TestRunToolCallsStops(t *testing.T) {
+	done := testTool{name: tools.ViewToolName, run: func(ctx context.Context, call tools.ToolCall) (tools.ToolResponse, error) {
+		return tools.NewTextResponse("done"), nil
+	}}
+	denied := testTool{name: tools.EditToolName, run: func(ctx context.Context, call tools.ToolCall) (tools.ToolResponse, error) {
+		return tools.ToolResponse{}, permission.ErrorPermissionDenied
+	}}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	canceling := testTool{name: tools.BashToolName, run: func(_ context.Context, call tools.ToolCall) (tools.ToolResponse, error) {
+		cancel()
+		return tools.NewTextResponse("interrupted"), nil
+	}}
+
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		call     message.ToolCall
+		results  []string
+		finished message.FinishReason
+	}{
+		{
+			name: "permission denied",
+			ctx:  context.Background(),
+			call: message.ToolCall{ID: "edit-1", Name: tools.EditToolName},
+			results: []string{
+				"view-1: done",
+				"edit-1: Permission denied",
+				"view-2: Tool execution canceled by user",
+				"view-3: Tool execution canceled by user",
+			},
+			finished: message.FinishReasonPermissionDenied,
+		},
+		{
+			name: "canceled",
+			ctx:  ctx,
+			call: message.ToolCall{ID: "bash-1", Name: tools.BashToolName},
+			results: []string{
+				"view-1: done",
+				"bash-1: interrupted",
+				"view-2: Tool execution canceled by user",
+				"view-3: Tool execution canceled by user",
+			},
+			finished: message.FinishReasonCanceled,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, sess := newTestAgent(t, done, denied, canceling)
+			msg := assistantMessage(t, a, sess.ID,
+				message.ToolCall{ID: "view-1", Name: tools.ViewToolName},
+				tt.call,
+				message.ToolCall{ID: "view-2", Name: tools.ViewToolName},
+				message.ToolCall{ID: "view-3", Name: tools.ViewToolName},
+			)
+			toolMsg, err := a.runToolCalls(tt.ctx, msg, a.tools)
+			require.NoError(t, err)
+			assert.Equal(t, tt.results, resultContents(toolMsg))
+			assert.Equal(t, tt.finished, msg.FinishReason())
+		})
+	}
+}
+
+func TestRunToolCallsNotOffered(t *testing.T) {
+	var edits atomic.Int32
+	a, sess := newTestAgent(t,
+		testTool{name: tools.ViewToolName, run: func(ctx context.Context, call tools.ToolCall) (tools.ToolResponse, error) {
+			return tools.NewTextResponse("done"), nil
+		}},
+		testTool{name: tools.EditToolName, run: func(ctx context.Context, call tools.ToolCall) (tools.ToolResponse, error) {
+			edits.Add(1)
+			return tools.NewTextResponse("edited"), nil
+		}},
+	)
+	sess.ToolProfile = config.ToolProfileReadOnly
+	_, err := a.sessions.Save(context.Background(), sess)
+	require.NoError(t, err)
+	available, err := a.sessionTools(context.Background(), sess.ID)
+	require.NoError(t, err)
+
+	// The model calls a tool of the agent the session doesn't offer
+	msg := assistantMessage(t, a, sess.ID,
+		message.ToolCall{ID: "view-1", Name: tools.ViewToolName},
+		message.ToolCall{ID: "edit-1", Name: tools.EditToolName},
+	)
+	toolMsg, err := a.runToolCalls(context.Background(), msg, available)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"view-1: done",
+		"edit-1: Tool edit is not available in this session, its tool profile doesn't offer it",
+	}, resultContents(toolMsg))
+	assert.True(t, toolMsg.ToolResults()[1].IsError)
+	assert.Zero(t, edits.Load())
+}
+
+func TestRunToolTimeout(t *testing.T) {
+	loadTestConfig(t)
+	previous := config.Get().ToolTimeouts
+	config.Get().ToolTimeouts = map[string]string{tools.GrepToolName: "50ms"}
+	t.Cleanup(func() { config.Get().ToolTimeouts = previous })
+
+	// The tool stops at the deadline of its context, with what it found so far
+	slow := testTool{name: tools.GrepToolName, run: func(ctx context.Context, call tools.ToolCall) (tools.ToolResponse, error) {
+		_, hasDeadline := ctx.Deadline()
+		assert.True(t, hasDeadline)
+		<-ctx.Done()
+		return tools.NewTextResponse("main.go:12: found"), nil
+	}}
+	fast := testTool{name: tools.ViewToolName, run: func(ctx context.Context, call tools.ToolCall) (tools.ToolResponse, error) {
+		return tools.NewTextResponse("done"), nil
+	}}
+	a, sess := newTestAgent(t, slow, fast)
+	msg := assistantMessage(t, a, sess.ID,
+		message.ToolCall{ID: "grep-1", Name: tools.GrepToolName},
+		message.ToolCall{ID: "view-1", Name: tools.ViewToolName},
+	)
+	toolMsg, err := a.runToolCalls(context.Background(), msg, a.tools)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"grep-1: main.go:12: found\n\nTool execution timed out after 50ms",
+		"view-1: done",
+	}, resultContents(toolMsg))
+	results := toolMsg.ToolResults()
+	assert.True(t, results[0].IsError)
+	assert.False(t, results[1].IsError)
+	// The turn goes on after a tool timed out
+	assert.Empty(t, msg.FinishReason())
+}
+
+func TestToolTimeout(t *testing.T) {
+	loadTestConfig(t)
+	tests := []struct {
+		call message.ToolCall
+		want time.Duration
+	}{
+		{message.ToolCall{Name: tools.BashToolName, Input: `{"command":"ls"}`}, 2 * time.Minute},
+		// A longer timeout parameter extends the timeout, with the time to stop
+		{message.ToolCall{Name: tools.BashToolName, Input: `{"command":"make","timeout":600000}`}, 10*time.Minute + toolTimeoutGrace},
+		{message.ToolCall{Name: tools.BashToolName, Input: `{"command":"ls","timeout":1000}`}, 2 * time.Minute},
+		{message.ToolCall{Name: tools.FetchToolName, Input: `{"url":"https://example.com","timeout":120}`}, 2*time.Minute + toolTimeoutGrace},
+		// Tools without a timeout parameter
+		{message.ToolCall{Name: tools.ViewToolName, Input: `{"timeout":600000}`}, 5 * time.Minute},
+		{message.ToolCall{Name: AgentToolName, Input: `{"prompt":"find the bug"}`}, 0},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, toolTimeout(tt.call), tt.call.Input)
+	}
+}
@@ -2,13 +2,20 @@ package agent
 
 import (
 	"context"
+	"maps"
+	"slices"
 
+	"github.com/opencode-ai/opencode/internal/config"
 	"github.com/opencode-ai/opencode/internal/history"
 	"github.com/opencode-ai/opencode/internal/llm/tools"
+	"github.com/opencode-ai/opencode/internal/logging"
 	"github.com/opencode-ai/opencode/internal/lsp"
 	"github.com/opencode-ai/opencode/internal/message"
+	"github.com/opencode-ai/opencode/internal/note"
 	"github.com/opencode-ai/opencode/internal/permission"
 	"github.com/opencode-ai/opencode/internal/session"
+	"github.com/opencode-ai/opencode/internal/todo"
+	"github.com/opencode-ai/opencode/internal/usage"
 )
 
 func CoderAgentTools(
@@ -16,36 +23,93 @@ func CoderAgentTools(
 	sessions session.Service,
 	messages message.Service,
 	history history.Service,
+	usage usage.Service,
+	notes note.Service,
+	todos todo.Service,
 	lspClients map[string]*lsp.Client,
 ) []tools.BaseTool {
 	ctx := context.Background()
-	otherTools := GetMcpTools(ctx, permissions)
+	var otherTools []tools.BaseTool
+	if container := config.Get().Container; container.Image != "" || container.Service != "" {
+		otherTools = append(otherTools, tools.NewContainerTool(container, permissions))
+	}
+	if databases := config.Get().Databases; len(databases) > 0 {
+		otherTools = append(otherTools, tools.NewSQLTool(databases, permissions))
+	}
+	if http := config.Get().HTTP; len(http.AllowedHosts) > 0 {
+		otherTools = append(otherTools, tools.NewHTTPTool(http, permissions))
+	}
+	if browser := config.Get().Browser; browser.Enabled {
+		otherTools = append(otherTools, tools.NewBrowserTool(browser, permissions))
+	}
+	if webSearch := config.Get().WebSearch; !webSearch.Disabled {
+		otherTools = append(otherTools, tools.NewWebSearchTool(webSearch))
+	}
+	otherTools = append(otherTools, GetMcpTools(ctx, permissions)...)
 	if len(lspClients) > 0 {
 		otherTools = append(otherTools, tools.NewDiagnosticsTool(lspClients))
 	}
-	return append(
+	coderTools := append(
 		[]tools.BaseTool{
 			tools.NewBashTool(permissions),
+			tools.NewClipboardTool(permissions),
+			tools.NewDependenciesTool(),
 			tools.NewEditTool(lspClients, permissions, history),
+			tools.NewMultiEditTool(lspClients, permissions, history),
 			tools.NewFetchTool(permissions),
+			tools.NewGitTool(permissions),
 			tools.NewGlobTool(),
 			tools.NewGrepTool(),
 			tools.NewLsTool(),
+			tools.NewNotebookTool(permissions, history),
+			tools.NewNotesTool(notes),
+			tools.NewOutputTool(),
+			tools.NewRenameTool(lspClients, permissions, history),
 			tools.NewSourcegraphTool(),
+			tools.NewSymbolsTool(lspClients),
+			tools.NewTestTool(permissions),
+			tools.NewTodoTool(todos),
 			tools.NewViewTool(lspClients),
 			tools.NewPatchTool(lspClients, permissions, history),
+			tools.NewApplyDiffTool(lspClients, permissions, history),
 			tools.NewWriteTool(lspClients, permissions, history),
-			NewAgentTool(sessions, messages, lspClients),
+			NewAgentTool(sessions, messages, usage, lspClients),
 		}, otherTools...,
 	)
+	return append(coderTools, customTools(coderTools, permissions)...)
+}
+
+// customTools creates the tools of the configuration, in the order of their
+// names, except the ones named like a tool of the agent.
+func customTools(agentTools []tools.BaseTool, permissions permission.Service) []tools.BaseTool {
+	taken := make(map[string]bool, len(agentTools))
+	for _, tool := range agentTools {
+		taken[tool.Info().Name] = true
+	}
+	configured := config.Get().Tools
+	var custom []tools.BaseTool
+	for _, name := range slices.Sorted(maps.Keys(configured)) {
+		if taken[name] {
+			logging.Warn("custom tool has the name of another tool, ignoring", "tool", name)
+			continue
+		}
+		custom = append(custom, tools.NewCustomTool(name, configured[name], permissions))
+	}
+	return custom
 }
 
 func TaskAgentTools(lspClients map[string]*lsp.Client) []tools.BaseTool {
-	return []tools.BaseTool{
+	taskTools := []tools.BaseTool{
+		tools.NewDependenciesTool(),
 		tools.NewGlobTool(),
 		tools.NewGrepTool(),
 		tools.NewLsTool(),
 		tools.NewSourcegraphTool(),
+		tools.NewSymbolsTool(lspClients),
 		tools.NewViewTool(lspClients),
 	}
+	if webSearch := config.Get().WebSearch; !webSearch.Disabled {
+		taskTools = append(taskTools, tools.NewWebSearchTool(webSearch))
+	}
+	return taskTools
 }
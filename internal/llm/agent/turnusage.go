@@ -0,0 +1,65 @@
+package agent
+
+import (
+	"context"
+	"sync"
+
+	"github.com/opencode-ai/opencode/internal/llm/provider"
+)
+
+// TurnUsage is the usage of a turn: the responses of the model, the summaries
+// of the conversation and the turns of the sub-agents it ran.
+type TurnUsage struct {
+	provider.TokenUsage
+	Cost float64
+	// Requests is the number of requests sent to the providers
+	Requests int
+}
+
+// PromptTokens are the prompt tokens of the turn, cached or not.
+func (u TurnUsage) PromptTokens() int64 {
+	return u.InputTokens + u.CacheCreationTokens + u.CacheReadTokens
+}
+
+func (u *TurnUsage) add(other TurnUsage) {
+	u.InputTokens += other.InputTokens
+	u.OutputTokens += other.OutputTokens
+	u.CacheCreationTokens += other.CacheCreationTokens
+	u.CacheReadTokens += other.CacheReadTokens
+	u.Cost += other.Cost
+	u.Requests += other.Requests
+}
+
+type turnUsageKey struct{}
+
+// turnUsage collects the usage of a turn, the sub-agents running at the same
+// time add theirs concurrently.
+type turnUsage struct {
+	mu    sync.Mutex
+	usage TurnUsage
+}
+
+// withTurnUsage returns a context collecting the usage of a new turn, the
+// turns of sub-agents have their own.
+func withTurnUsage(ctx context.Context) (context.Context, *turnUsage) {
+	turn := &turnUsage{}
+	return context.WithValue(ctx, turnUsageKey{}, turn), turn
+}
+
+// addTurnUsage adds the usage to the turn of the context, requests outside of
+// a turn, like the title of a session, aren't part of one.
+func addTurnUsage(ctx context.Context, usage TurnUsage) {
+	turn, ok := ctx.Value(turnUsageKey{}).(*turnUsage)
+	if !ok {
+		return
+	}
+	turn.mu.Lock()
+	defer turn.mu.Unlock()
+	turn.usage.add(usage)
+}
+
+func (t *turnUsage) total() TurnUsage {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.usage
+}
@@ -0,0 +1,52 @@
+package discovery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/opencode-ai/opencode/internal/llm/models"
+)
+
+type llamacppPropsResponse struct {
+	ModelPath                 string `json:"model_path"`
+	DefaultGenerationSettings struct {
+		NCtx int64 `json:"n_ctx"`
+	} `json:"default_generation_settings"`
+}
+
+// FetchLlamaCppModel returns the model loaded in the llama.cpp server, which
+// serves a single model, using the /props endpoint. The model is named after
+// its GGUF file.
+func FetchLlamaCppModel(ctx context.Context, client *http.Client, baseURL string) (models.Model, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, models.LlamaCppBaseURL(baseURL)+"/props", nil)
+	if err != nil {
+		return models.Model{}, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return models.Model{}, fmt.Errorf("llama.cpp: not running at %s: %w", models.LlamaCppBaseURL(baseURL), err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return models.Model{}, fmt.Errorf("llama.cpp: unexpected status reading server properties: %s", resp.Status)
+	}
+
+	var props llamacppPropsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&props); err != nil {
+		return models.Model{}, fmt.Errorf("llama.cpp: failed to decode server properties: %w", err)
+	}
+	name := strings.TrimSuffix(filepath.Base(props.ModelPath), ".gguf")
+	if props.ModelPath == "" {
+		name = "default"
+	}
+	model := models.LlamaCppModel(name)
+	if props.DefaultGenerationSettings.NCtx > 0 {
+		model.ContextWindow = props.DefaultGenerationSettings.NCtx
+		model.DefaultMaxTokens = min(model.DefaultMaxTokens, props.DefaultGenerationSettings.NCtx/2)
+	}
+	return model, nil
+}
@@ -0,0 +1,60 @@
+package discovery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/opencode-ai/opencode/internal/llm/models"
+)
+
+type lmstudioModelsResponse struct {
+	Data []struct {
+		ID               string `json:"id"`
+		Type             string `json:"type"`
+		State            string `json:"state"`
+		MaxContextLength int64  `json:"max_context_length"`
+	} `json:"data"`
+}
+
+// FetchLMStudioModels lists the chat models downloaded in LM Studio using its
+// native /api/v0/models endpoint, which also reports the context length and
+// whether a model is loaded. The second result holds the IDs of loaded models.
+func FetchLMStudioModels(ctx context.Context, client *http.Client, baseURL string) (map[models.ModelID]models.Model, map[models.ModelID]bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, models.LMStudioBaseURL(baseURL)+"/api/v0/models", nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("lmstudio: not running at %s: %w", models.LMStudioBaseURL(baseURL), err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil, fmt.Errorf("lmstudio: unexpected status listing models: %s", resp.Status)
+	}
+
+	var list lmstudioModelsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		return nil, nil, fmt.Errorf("lmstudio: failed to decode model list: %w", err)
+	}
+
+	discovered := make(map[models.ModelID]models.Model, len(list.Data))
+	loaded := make(map[models.ModelID]bool)
+	for _, m := range list.Data {
+		// Embedding models cannot chat
+		if m.Type != "llm" && m.Type != "vlm" {
+			continue
+		}
+		model := models.LMStudioModel(m.ID)
+		if m.MaxContextLength > 0 {
+			model.ContextWindow = m.MaxContextLength
+		}
+		discovered[model.ID] = model
+		if m.State == "loaded" {
+			loaded[model.ID] = true
+		}
+	}
+	return discovered, loaded, nil
+}
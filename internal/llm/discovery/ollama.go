@@ -0,0 +1,226 @@
+// Package discovery asks the model servers which models they serve, like the
+// models pulled on an Ollama server, and holds the HTTP transport used to
+// reach them. The models it finds are described with the types of the models
+// package.
+package discovery
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/opencode-ai/opencode/internal/llm/models"
+)
+
+type ollamaTagsResponse struct {
+	Models []struct {
+		Name  string `json:"name"`
+		Model string `json:"model"`
+		Size  int64  `json:"size"`
+	} `json:"models"`
+}
+
+// OllamaUnavailableError is returned when the Ollama server cannot be reached.
+type OllamaUnavailableError struct {
+	BaseURL string
+	Err     error
+}
+
+func (e *OllamaUnavailableError) Error() string {
+	return fmt.Sprintf("Ollama not running at %s — start it or change providers.ollama.baseURL", e.BaseURL)
+}
+
+func (e *OllamaUnavailableError) Unwrap() error {
+	return e.Err
+}
+
+// OllamaConnectionError turns a failure to connect to the Ollama server into an
+// OllamaUnavailableError, other errors are returned unchanged.
+func OllamaConnectionError(baseURL string, err error) error {
+	var opErr *net.OpError
+	if !errors.As(err, &opErr) || opErr.Op != "dial" {
+		return err
+	}
+	baseURL = models.OllamaBaseURL(baseURL)
+	if u, parseErr := url.Parse(baseURL); parseErr == nil {
+		baseURL = u.Redacted()
+	}
+	return &OllamaUnavailableError{BaseURL: baseURL, Err: err}
+}
+
+// OllamaVersion returns the version of the Ollama server using the /api/version
+// endpoint, it doubles as a health check.
+func OllamaVersion(ctx context.Context, client *http.Client, baseURL string, headers map[string]string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, models.OllamaBaseURL(baseURL)+"/api/version", nil)
+	if err != nil {
+		return "", err
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", OllamaConnectionError(baseURL, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("ollama: unexpected status checking version: %s", resp.Status)
+	}
+
+	var version struct {
+		Version string `json:"version"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&version); err != nil {
+		return "", fmt.Errorf("ollama: failed to decode version: %w", err)
+	}
+	return version.Version, nil
+}
+
+// FetchOllamaModels lists the locally pulled models using the /api/tags endpoint.
+// The headers are sent along, for servers that sit behind an authenticating proxy.
+func FetchOllamaModels(ctx context.Context, client *http.Client, baseURL string, headers map[string]string) (map[models.ModelID]models.Model, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, models.OllamaBaseURL(baseURL)+"/api/tags", nil)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, OllamaConnectionError(baseURL, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("ollama: unexpected status listing models: %s", resp.Status)
+	}
+
+	var tags ollamaTagsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&tags); err != nil {
+		return nil, fmt.Errorf("ollama: failed to decode model list: %w", err)
+	}
+
+	discovered := make(map[models.ModelID]models.Model, len(tags.Models))
+	for _, m := range tags.Models {
+		name := m.Model
+		if name == "" {
+			name = m.Name
+		}
+		model := models.OllamaModel(name)
+		discovered[model.ID] = model
+	}
+	return discovered, nil
+}
+
+// OllamaModelInfo holds the details Ollama reports for a model.
+type OllamaModelInfo struct {
+	// ContextLength is the context window the model was trained with
+	ContextLength int64
+	Capabilities  []string
+}
+
+// ShowOllamaModel fetches the details of a model using the /api/show endpoint.
+func ShowOllamaModel(ctx context.Context, client *http.Client, baseURL string, headers map[string]string, name string) (OllamaModelInfo, error) {
+	body, err := json.Marshal(map[string]any{"model": name})
+	if err != nil {
+		return OllamaModelInfo{}, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, models.OllamaBaseURL(baseURL)+"/api/show", bytes.NewReader(body))
+	if err != nil {
+		return OllamaModelInfo{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return OllamaModelInfo{}, OllamaConnectionError(baseURL, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return OllamaModelInfo{}, fmt.Errorf("ollama: unexpected status showing %s: %s", name, resp.Status)
+	}
+
+	var show struct {
+		ModelInfo    map[string]any `json:"model_info"`
+		Capabilities []string       `json:"capabilities"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&show); err != nil {
+		return OllamaModelInfo{}, fmt.Errorf("ollama: failed to decode model details: %w", err)
+	}
+
+	info := OllamaModelInfo{Capabilities: show.Capabilities}
+	// The key is prefixed with the architecture, e.g. llama.context_length
+	for key, value := range show.ModelInfo {
+		if length, ok := value.(float64); ok && strings.HasSuffix(key, ".context_length") {
+			info.ContextLength = int64(length)
+		}
+	}
+	return info, nil
+}
+
+// OllamaPullProgress is a progress update streamed while a model is pulled.
+// Total and Completed are only set while a layer is being downloaded.
+type OllamaPullProgress struct {
+	Status    string `json:"status"`
+	Digest    string `json:"digest,omitempty"`
+	Total     int64  `json:"total,omitempty"`
+	Completed int64  `json:"completed,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// PullOllamaModel downloads a model to the Ollama server using the /api/pull
+// endpoint, calling progress for every update Ollama streams back.
+func PullOllamaModel(ctx context.Context, client *http.Client, baseURL string, headers map[string]string, name string, progress func(OllamaPullProgress)) error {
+	body, err := json.Marshal(map[string]any{
+		"model":  name,
+		"stream": true,
+	})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, models.OllamaBaseURL(baseURL)+"/api/pull", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return OllamaConnectionError(baseURL, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("ollama: unexpected status pulling %s: %s", name, resp.Status)
+	}
+
+	decoder := json.NewDecoder(resp.Body)
+	for {
+		var update OllamaPullProgress
+		if err := decoder.Decode(&update); err != nil {
+			if err == io.EOF {
+				return fmt.Errorf("ollama: pull of %s ended before completing", name)
+			}
+			return fmt.Errorf("ollama: failed to decode pull progress: %w", err)
+		}
+		if update.Error != "" {
+			return fmt.Errorf("ollama: failed to pull %s: %s", name, update.Error)
+		}
+		if progress != nil {
+			progress(update)
+		}
+		if update.Status == "success" {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package discovery
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/opencode-ai/opencode/internal/llm/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPullOllamaModel(t *testing.T) {
+	tests := []struct {
+		name     string
+		lines    []string
+		status   int
+		progress []OllamaPullProgress
+		err      string
+	}{
+		{
+			name: "progress",
+			lines: []string{
+				`{"status":"pulling manifest"}`,
+				`{"status":"pulling 6a0746a1ec1a","digest":"sha256:6a0746a1ec1a","total":4661211424}`,
+				`{"status":"pulling 6a0746a1ec1a","digest":"sha256:6a0746a1ec1a","total":4661211424,"completed":2330605712}`,
+				`{"status":"pulling 6a0746a1ec1a","digest":"sha256:6a0746a1ec1a","total":4661211424,"completed":4661211424}`,
+				`{"status":"verifying sha256 digest"}`,
+				`{"status":"success"}`,
+			},
+			progress: []OllamaPullProgress{
+				{Status: "pulling manifest"},
+				{Status: "pulling 6a0746a1ec1a", Digest: "sha256:6a0746a1ec1a", Total: 4661211424},
+				{Status: "pulling 6a0746a1ec1a", Digest: "sha256:6a0746a1ec1a", Total: 4661211424, Completed: 2330605712},
+				{Status: "pulling 6a0746a1ec1a", Digest: "sha256:6a0746a1ec1a", Total: 4661211424, Completed: 4661211424},
+				{Status: "verifying sha256 digest"},
+				{Status: "success"},
+			},
+		},
+		{
+			name: "error",
+			lines: []string{
+				`{"status":"pulling manifest"}`,
+				`{"error":"pull model manifest: file does not exist"}`,
+			},
+			progress: []OllamaPullProgress{{Status: "pulling manifest"}},
+			err:      "ollama: failed to pull llama3.1: pull model manifest: file does not exist",
+		},
+		{
+			name: "ended early",
+			lines: []string{
+				`{"status":"pulling manifest"}`,
+			},
+			progress: []OllamaPullProgress{{Status: "pulling manifest"}},
+			err:      "ollama: pull of llama3.1 ended before completing",
+		},
+		{
+			name:  "invalid progress",
+			lines: []string{`{"status":`},
+			err:   "ollama: failed to decode pull progress",
+		},
+		{
+			name:   "unexpected status",
+			status: http.StatusInternalServerError,
+			err:    "ollama: unexpected status pulling llama3.1: 500 Internal Server Error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "/api/pull", r.URL.Path)
+				assert.Equal(t, "secret", r.Header.Get("X-Api-Key"))
+				var body map[string]any
+				assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+				assert.Equal(t, map[string]any{"model": "llama3.1", "stream": true}, body)
+
+				if tt.status != 0 {
+					w.WriteHeader(tt.status)
+					return
+				}
+				for _, line := range tt.lines {
+					fmt.Fprintln(w, line)
+				}
+			}))
+			t.Cleanup(server.Close)
+
+			var progress []OllamaPullProgress
+			err := PullOllamaModel(context.Background(), server.Client(), server.URL, map[string]string{"X-Api-Key": "secret"}, "llama3.1", func(p OllamaPullProgress) {
+				progress = append(progress, p)
+			})
+			if tt.err == "" {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.err)
+			}
+			assert.Equal(t, tt.progress, progress)
+		})
+	}
+}
+
+func TestFetchOllamaModels(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/tags", r.URL.Path)
+		assert.Equal(t, "secret", r.Header.Get("X-Api-Key"))
+		fmt.Fprint(w, `{"models":[{"name":"qwen2.5-coder:32b","model":"qwen2.5-coder:32b"},{"name":"llama3.1:latest"}]}`)
+	}))
+	t.Cleanup(server.Close)
+
+	discovered, err := FetchOllamaModels(context.Background(), server.Client(), server.URL, map[string]string{"X-Api-Key": "secret"})
+	require.NoError(t, err)
+	assert.Equal(t, map[models.ModelID]models.Model{
+		"ollama.qwen2.5-coder:32b": models.OllamaModel("qwen2.5-coder:32b"),
+		"ollama.llama3.1":          models.OllamaModel("llama3.1:latest"),
+	}, discovered)
+}
+
+func TestShowOllamaModel(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/show", r.URL.Path)
+		fmt.Fprint(w, `{"model_info":{"general.architecture":"qwen2","qwen2.context_length":32768},"capabilities":["completion","tools"]}`)
+	}))
+	t.Cleanup(server.Close)
+
+	info, err := ShowOllamaModel(context.Background(), server.Client(), server.URL, nil, "qwen2.5-coder:32b")
+	require.NoError(t, err)
+	assert.Equal(t, OllamaModelInfo{ContextLength: 32768, Capabilities: []string{"completion", "tools"}}, info)
+}
+
+func TestOllamaUnavailable(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	baseURL := "http://" + listener.Addr().String()
+	require.NoError(t, listener.Close())
+
+	_, err = OllamaVersion(context.Background(), http.DefaultClient, baseURL, nil)
+	var unavailable *OllamaUnavailableError
+	require.True(t, errors.As(err, &unavailable), "got %v", err)
+	assert.Equal(t, baseURL, unavailable.BaseURL)
+
+	err = OllamaConnectionError(baseURL, errors.New("bad response"))
+	assert.False(t, errors.As(err, &unavailable))
+}
@@ -0,0 +1,97 @@
+package discovery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/opencode-ai/opencode/internal/llm/models"
+)
+
+// OpenRouterCatalog is the model list returned by the OpenRouter API. Prices
+// are in dollars per token.
+type OpenRouterCatalog struct {
+	Data []struct {
+		ID            string `json:"id"`
+		Name          string `json:"name"`
+		ContextLength int64  `json:"context_length"`
+		Pricing       struct {
+			Prompt          string `json:"prompt"`
+			Completion      string `json:"completion"`
+			InputCacheRead  string `json:"input_cache_read"`
+			InputCacheWrite string `json:"input_cache_write"`
+		} `json:"pricing"`
+		TopProvider struct {
+			MaxCompletionTokens int64 `json:"max_completion_tokens"`
+		} `json:"top_provider"`
+		Architecture struct {
+			InputModalities []string `json:"input_modalities"`
+		} `json:"architecture"`
+		SupportedParameters []string `json:"supported_parameters"`
+	} `json:"data"`
+}
+
+// FetchOpenRouterCatalog downloads the OpenRouter model catalog.
+func FetchOpenRouterCatalog(ctx context.Context, client *http.Client) (*OpenRouterCatalog, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, models.OpenRouterBaseURL+"/models", nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("openrouter: failed to list models: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("openrouter: unexpected status listing models: %s", resp.Status)
+	}
+
+	var catalog OpenRouterCatalog
+	if err := json.NewDecoder(resp.Body).Decode(&catalog); err != nil {
+		return nil, fmt.Errorf("openrouter: failed to decode model list: %w", err)
+	}
+	return &catalog, nil
+}
+
+// Models converts the catalog to models with their context window and
+// pricing. Routers with a variable price are left out.
+func (c *OpenRouterCatalog) Models() map[models.ModelID]models.Model {
+	result := make(map[models.ModelID]models.Model, len(c.Data))
+	for _, m := range c.Data {
+		price := func(perToken string) float64 {
+			value, _ := strconv.ParseFloat(perToken, 64)
+			return value * 1e6
+		}
+		if price(m.Pricing.Prompt) < 0 || price(m.Pricing.Completion) < 0 {
+			continue
+		}
+
+		model := models.OpenRouterModel(m.ID)
+		if m.Name != "" {
+			model.Name = "OpenRouter: " + m.Name
+		}
+		model.CostPer1MIn = price(m.Pricing.Prompt)
+		model.CostPer1MOut = price(m.Pricing.Completion)
+		model.CostPer1MInCached = price(m.Pricing.InputCacheWrite)
+		model.CostPer1MOutCached = price(m.Pricing.InputCacheRead)
+		if m.ContextLength > 0 {
+			model.ContextWindow = m.ContextLength
+		}
+		model.DefaultMaxTokens = min(model.ContextWindow/2, 8192)
+		if m.TopProvider.MaxCompletionTokens > 0 {
+			model.DefaultMaxTokens = min(model.DefaultMaxTokens, m.TopProvider.MaxCompletionTokens)
+		}
+		model.CanReason = slices.Contains(m.SupportedParameters, "reasoning")
+		model = model.WithCapabilities(models.Capabilities{
+			SupportsTools:     slices.Contains(m.SupportedParameters, "tools"),
+			SupportsVision:    slices.Contains(m.Architecture.InputModalities, "image"),
+			SupportsJSONMode:  slices.Contains(m.SupportedParameters, "response_format"),
+			SupportsStreaming: true,
+		})
+		result[model.ID] = model
+	}
+	return result
+}
@@ -0,0 +1,90 @@
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/opencode-ai/opencode/internal/llm/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const openRouterCatalog = `{"data":[
+	{
+		"id":"anthropic/claude-3.7-sonnet","name":"Anthropic: Claude 3.7 Sonnet","context_length":200000,
+		"pricing":{"prompt":"0.000003","completion":"0.000015","input_cache_read":"0.0000003","input_cache_write":"0.00000375"},
+		"top_provider":{"max_completion_tokens":64000},
+		"architecture":{"input_modalities":["text","image"]},
+		"supported_parameters":["tools","reasoning","max_tokens"]
+	},
+	{
+		"id":"meta-llama/llama-3-8b-instruct","context_length":8192,
+		"pricing":{"prompt":"0.00000003","completion":"0.00000006"},
+		"top_provider":{"max_completion_tokens":2048},
+		"architecture":{"input_modalities":["text"]},
+		"supported_parameters":["response_format"]
+	},
+	{"id":"openrouter/auto","pricing":{"prompt":"-1","completion":"-1"}}
+]}`
+
+// rewriteTransport sends the requests to the server instead of their host.
+type rewriteTransport struct {
+	server *url.URL
+}
+
+func (t rewriteTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.URL.Scheme, req.URL.Host = t.server.Scheme, t.server.Host
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestFetchOpenRouterCatalog(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/models", r.URL.Path)
+		fmt.Fprint(w, openRouterCatalog)
+	}))
+	t.Cleanup(server.Close)
+	serverURL, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	catalog, err := FetchOpenRouterCatalog(context.Background(), &http.Client{Transport: rewriteTransport{server: serverURL}})
+	require.NoError(t, err)
+	discovered := catalog.Models()
+
+	// Routers with a variable price are left out
+	require.Len(t, discovered, 2)
+	claude := discovered["openrouter.anthropic/claude-3.7-sonnet"]
+	assert.Equal(t, "OpenRouter: Anthropic: Claude 3.7 Sonnet", claude.Name)
+	assert.Equal(t, "anthropic/claude-3.7-sonnet", claude.APIModel)
+	assert.InDelta(t, 3, claude.CostPer1MIn, 1e-9)
+	assert.InDelta(t, 15, claude.CostPer1MOut, 1e-9)
+	assert.InDelta(t, 3.75, claude.CostPer1MInCached, 1e-9)
+	assert.InDelta(t, 0.3, claude.CostPer1MOutCached, 1e-9)
+	assert.Equal(t, int64(200000), claude.ContextWindow)
+	assert.Equal(t, int64(8192), claude.DefaultMaxTokens)
+	assert.True(t, claude.CanReason)
+	assert.Equal(t, models.Capabilities{SupportsTools: true, SupportsVision: true, SupportsStreaming: true}, models.ModelCapabilities(claude))
+
+	llama := discovered["openrouter.meta-llama/llama-3-8b-instruct"]
+	assert.Equal(t, "OpenRouter: meta-llama/llama-3-8b-instruct", llama.Name)
+	assert.Equal(t, int64(2048), llama.DefaultMaxTokens)
+	assert.False(t, llama.CanReason)
+	assert.Equal(t, models.Capabilities{SupportsJSONMode: true, SupportsStreaming: true}, models.ModelCapabilities(llama))
+}
+
+func TestFetchOpenRouterCatalogUnavailable(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "overloaded", http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(server.Close)
+	serverURL, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	_, err = FetchOpenRouterCatalog(context.Background(), &http.Client{Transport: rewriteTransport{server: serverURL}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "503")
+}
@@ -0,0 +1,64 @@
+package discovery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/opencode-ai/opencode/internal/llm/models"
+)
+
+type togetherModel struct {
+	ID            string `json:"id"`
+	Type          string `json:"type"`
+	DisplayName   string `json:"display_name"`
+	ContextLength int64  `json:"context_length"`
+	Pricing       struct {
+		Input  float64 `json:"input"`
+		Output float64 `json:"output"`
+	} `json:"pricing"`
+}
+
+// FetchTogetherModels lists the chat models served by Together AI, with their
+// context length and price per million tokens.
+func FetchTogetherModels(ctx context.Context, client *http.Client, apiKey string) (map[models.ModelID]models.Model, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, models.TogetherBaseURL+"/models", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+apiKey)
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("together: failed to list models: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("together: unexpected status listing models: %s", resp.Status)
+	}
+
+	var list []togetherModel
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		return nil, fmt.Errorf("together: failed to decode model list: %w", err)
+	}
+
+	discovered := make(map[models.ModelID]models.Model, len(list))
+	for _, m := range list {
+		// Image, embedding and rerank models cannot chat
+		if m.Type != "chat" {
+			continue
+		}
+		model := models.TogetherModel(m.ID)
+		if m.DisplayName != "" {
+			model.Name = "Together: " + m.DisplayName
+		}
+		if m.ContextLength > 0 {
+			model.ContextWindow = m.ContextLength
+		}
+		model.DefaultMaxTokens = min(model.ContextWindow/2, model.DefaultMaxTokens)
+		model.CostPer1MIn = m.Pricing.Input
+		model.CostPer1MOut = m.Pricing.Output
+		discovered[model.ID] = model
+	}
+	return discovered, nil
+}
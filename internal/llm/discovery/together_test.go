@@ -0,0 +1,46 @@
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFetchTogetherModels(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/models", r.URL.Path)
+		assert.Equal(t, "Bearer together-key", r.Header.Get("Authorization"))
+		fmt.Fprint(w, `[
+			{"id":"Qwen/Qwen2.5-Coder-32B-Instruct","type":"chat","display_name":"Qwen 2.5 Coder 32B Instruct","context_length":32768,"pricing":{"input":0.8,"output":0.8}},
+			{"id":"togethercomputer/small","type":"chat","context_length":4096,"pricing":{"input":0.1,"output":0.2}},
+			{"id":"BAAI/bge-large-en-v1.5","type":"embedding","context_length":512}
+		]`)
+	}))
+	t.Cleanup(server.Close)
+	serverURL, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	discovered, err := FetchTogetherModels(context.Background(), &http.Client{Transport: rewriteTransport{server: serverURL}}, "together-key")
+	require.NoError(t, err)
+
+	// Only the chat models are listed
+	require.Len(t, discovered, 2)
+	qwen := discovered["together.Qwen/Qwen2.5-Coder-32B-Instruct"]
+	assert.Equal(t, "Together: Qwen 2.5 Coder 32B Instruct", qwen.Name)
+	assert.Equal(t, "Qwen/Qwen2.5-Coder-32B-Instruct", qwen.APIModel)
+	assert.Equal(t, int64(32768), qwen.ContextWindow)
+	assert.Equal(t, int64(4096), qwen.DefaultMaxTokens)
+	assert.Equal(t, 0.8, qwen.CostPer1MIn)
+	assert.Equal(t, 0.8, qwen.CostPer1MOut)
+
+	// The response leaves room for the prompt in small context windows
+	small := discovered["together.togethercomputer/small"]
+	assert.Equal(t, "Together: togethercomputer/small", small.Name)
+	assert.Equal(t, int64(2048), small.DefaultMaxTokens)
+}
@@ -0,0 +1,105 @@
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+
+	"golang.org/x/net/http/httpproxy"
+)
+
+// ProxyFunc returns the proxy selection of a transport. Without a proxy URL
+// the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables are used.
+// A proxy URL (http, https, socks5 or socks5h) is used for all requests
+// except the hosts listed in NO_PROXY and loopback addresses.
+func ProxyFunc(proxy string) (func(*http.Request) (*url.URL, error), error) {
+	if proxy == "" {
+		return http.ProxyFromEnvironment, nil
+	}
+	u, err := url.Parse(proxy)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy URL %q: %w", proxy, err)
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return nil, fmt.Errorf("invalid proxy URL %q: unsupported scheme %q (expected http, https, socks5 or socks5h)", proxy, u.Scheme)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("invalid proxy URL %q: missing host", proxy)
+	}
+
+	noProxy := os.Getenv("NO_PROXY")
+	if noProxy == "" {
+		noProxy = os.Getenv("no_proxy")
+	}
+	proxyFunc := (&httpproxy.Config{
+		HTTPProxy:  proxy,
+		HTTPSProxy: proxy,
+		NoProxy:    noProxy,
+	}).ProxyFunc()
+	return func(req *http.Request) (*url.URL, error) {
+		return proxyFunc(req.URL)
+	}, nil
+}
+
+// EnableUnixSockets lets the transport serve the unix:// base URLs among
+// baseURLs, such as unix:///var/run/ollama.sock, by dialing their socket.
+// Requests to the other base URLs are sent as usual.
+func EnableUnixSockets(transport *http.Transport, baseURLs ...string) {
+	sockets := unixSockets{}
+	for _, baseURL := range baseURLs {
+		u, err := url.Parse(baseURL)
+		if err != nil || u.Scheme != "unix" || u.Path == "" {
+			continue
+		}
+		socket := path.Clean(u.Path)
+		socketTransport := transport.Clone()
+		socketTransport.Proxy = nil
+		socketTransport.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var dialer net.Dialer
+			return dialer.DialContext(ctx, "unix", socket)
+		}
+		sockets[socket] = socketTransport
+	}
+	if len(sockets) > 0 {
+		transport.RegisterProtocol("unix", sockets)
+	}
+}
+
+// unixSockets holds the transport dialing each socket, by socket path.
+type unixSockets map[string]*http.Transport
+
+func (s unixSockets) RoundTrip(req *http.Request) (*http.Response, error) {
+	// The path of a unix:// URL starts with the socket, the HTTP path follows it
+	var socket string
+	for candidate := range s {
+		rest, ok := strings.CutPrefix(req.URL.Path, candidate)
+		if ok && (rest == "" || rest[0] == '/') && len(candidate) > len(socket) {
+			socket = candidate
+		}
+	}
+	if socket == "" {
+		return nil, &net.OpError{Op: "dial", Net: "unix", Err: fmt.Errorf("no unix socket configured for %s", req.URL.Path)}
+	}
+
+	socketReq := req.Clone(req.Context())
+	socketReq.URL.Scheme = "http"
+	socketReq.URL.Host = "localhost"
+	socketReq.URL.Path = socketPath(req.URL.Path, socket)
+	if req.URL.RawPath != "" {
+		socketReq.URL.RawPath = socketPath(req.URL.RawPath, socket)
+	}
+	socketReq.Host = "localhost"
+	return s[socket].RoundTrip(socketReq)
+}
+
+// socketPath returns the HTTP path that follows the socket in a unix:// URL path.
+func socketPath(urlPath, socket string) string {
+	return "/" + strings.TrimPrefix(strings.TrimPrefix(urlPath, socket), "/")
+}
@@ -0,0 +1,78 @@
+package discovery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnableUnixSockets(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "ollama.sock")
+	listener, err := net.Listen("unix", socket)
+	require.NoError(t, err)
+	var paths []string
+	server := &httptest.Server{
+		Listener: listener,
+		Config: &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			paths = append(paths, r.URL.EscapedPath())
+			fmt.Fprint(w, `{"version":"0.6.2"}`)
+		})},
+	}
+	server.Start()
+	t.Cleanup(server.Close)
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	EnableUnixSockets(transport, "http://localhost:11434", "unix://"+socket+"/")
+	client := &http.Client{Transport: transport}
+
+	version, err := OllamaVersion(context.Background(), client, "unix://"+socket, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "0.6.2", version)
+
+	resp, err := client.Get("unix://" + socket + "/api/blobs/sha256%3Aabc%2Fdef")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, []string{"/api/version", "/api/blobs/sha256%3Aabc%2Fdef"}, paths)
+
+	// A socket that was not configured is reported like a server that is not running
+	_, err = OllamaVersion(context.Background(), client, "unix://"+filepath.Join(t.TempDir(), "other.sock"), nil)
+	var unavailable *OllamaUnavailableError
+	assert.True(t, errors.As(err, &unavailable), "got %v", err)
+}
+
+func TestProxyFunc(t *testing.T) {
+	t.Setenv("NO_PROXY", "internal.example.com")
+	proxy, err := ProxyFunc("socks5://proxy.example.com:1080")
+	require.NoError(t, err)
+	for target, want := range map[string]string{
+		"https://api.anthropic.com/v1/messages": "socks5://proxy.example.com:1080",
+		"http://ollama.example.com:11434":       "socks5://proxy.example.com:1080",
+		"https://internal.example.com/v1":       "",
+		"http://localhost:11434/api/tags":       "",
+		"http://127.0.0.1:1234/v1/models":       "",
+	} {
+		req, err := http.NewRequest(http.MethodGet, target, nil)
+		require.NoError(t, err)
+		u, err := proxy(req)
+		require.NoError(t, err)
+		if want == "" {
+			assert.Nil(t, u, target)
+		} else {
+			require.NotNil(t, u, target)
+			assert.Equal(t, want, u.String(), target)
+		}
+	}
+
+	for _, invalid := range []string{"ftp://proxy.example.com", "http://", "://proxy"} {
+		_, err := ProxyFunc(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
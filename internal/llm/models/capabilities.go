@@ -0,0 +1,70 @@
+package models
+
+// Capabilities describe what a model supports beyond plain chat. The agent
+// adapts to them, e.g. tools are described in the prompt for models without
+// native tool calling.
+type Capabilities struct {
+	SupportsTools     bool `json:"supports_tools"`
+	SupportsVision    bool `json:"supports_vision"`
+	SupportsJSONMode  bool `json:"supports_json_mode"`
+	SupportsStreaming bool `json:"supports_streaming"`
+	// MaxTools is the number of tools a request may declare, 0 for no limit
+	MaxTools int `json:"max_tools,omitempty"`
+}
+
+// openaiMaxTools is the number of functions the OpenAI API accepts in a request
+const openaiMaxTools = 128
+
+// providerCapabilities are the capabilities of the models of a provider that
+// do not declare their own.
+var providerCapabilities = map[ModelProvider]Capabilities{
+	ProviderOpenAI:           {SupportsTools: true, SupportsVision: true, SupportsJSONMode: true, SupportsStreaming: true, MaxTools: openaiMaxTools},
+	ProviderAzure:            {SupportsTools: true, SupportsVision: true, SupportsJSONMode: true, SupportsStreaming: true, MaxTools: openaiMaxTools},
+	ProviderAnthropic:        {SupportsTools: true, SupportsVision: true, SupportsStreaming: true},
+	ProviderGemini:           {SupportsTools: true, SupportsVision: true, SupportsJSONMode: true, SupportsStreaming: true},
+	ProviderVertexAI:         {SupportsTools: true, SupportsVision: true, SupportsJSONMode: true, SupportsStreaming: true},
+	ProviderBedrock:          {SupportsTools: true, SupportsVision: true, SupportsStreaming: true},
+	ProviderGROQ:             {SupportsTools: true, SupportsJSONMode: true, SupportsStreaming: true, MaxTools: openaiMaxTools},
+	ProviderDeepSeek:         {SupportsTools: true, SupportsJSONMode: true, SupportsStreaming: true, MaxTools: openaiMaxTools},
+	ProviderXAI:              {SupportsTools: true, SupportsJSONMode: true, SupportsStreaming: true, MaxTools: openaiMaxTools},
+	ProviderMistral:          {SupportsTools: true, SupportsJSONMode: true, SupportsStreaming: true},
+	ProviderTogether:         {SupportsTools: true, SupportsJSONMode: true, SupportsStreaming: true},
+	ProviderCopilot:          {SupportsTools: true, SupportsStreaming: true, MaxTools: openaiMaxTools},
+	ProviderOpenRouter:       {SupportsTools: true, SupportsJSONMode: true, SupportsStreaming: true},
+	ProviderOllama:           {SupportsTools: true, SupportsJSONMode: true, SupportsStreaming: true},
+	ProviderLMStudio:         {SupportsTools: true, SupportsJSONMode: true, SupportsStreaming: true},
+	ProviderGGUF:             {SupportsStreaming: true},
+	ProviderLlamaCpp:         {SupportsTools: true, SupportsJSONMode: true, SupportsStreaming: true},
+	ProviderOpenAICompatible: {SupportsTools: true, SupportsJSONMode: true, SupportsStreaming: true},
+}
+
+// modelCapabilities are the built-in models that differ from their provider.
+var modelCapabilities = map[ModelID]Capabilities{
+	// DeepSeek R1 has no function calling
+	DeepSeekReasoner:   {SupportsStreaming: true},
+	TogetherDeepSeekR1: {SupportsStreaming: true},
+	BedrockLlama33_70B: {SupportsTools: true, SupportsStreaming: true},
+	BedrockLlama31_8B:  {SupportsTools: true, SupportsStreaming: true},
+}
+
+// ModelCapabilities returns the capabilities of a model: its own when it
+// declares them, otherwise the ones of its provider. Unknown providers are
+// assumed to support tools and streaming.
+func ModelCapabilities(model Model) Capabilities {
+	if model.Capabilities != nil {
+		return *model.Capabilities
+	}
+	if capabilities, ok := modelCapabilities[model.ID]; ok {
+		return capabilities
+	}
+	if capabilities, ok := providerCapabilities[model.Provider]; ok {
+		return capabilities
+	}
+	return Capabilities{SupportsTools: true, SupportsStreaming: true}
+}
+
+// WithCapabilities returns the model with its capabilities set.
+func (m Model) WithCapabilities(capabilities Capabilities) Model {
+	m.Capabilities = &capabilities
+	return m
+}
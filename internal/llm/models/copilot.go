@@ -0,0 +1,80 @@
+package models
+
+const (
+	ProviderCopilot ModelProvider = "copilot"
+
+	// CopilotBaseURL serves the chat completions of individual Copilot
+	// subscriptions, business and enterprise plans are routed to the endpoint
+	// of their Copilot token.
+	CopilotBaseURL = "https://api.githubcopilot.com"
+
+	CopilotGPT4o          ModelID = "copilot.gpt-4o"
+	CopilotGPT41          ModelID = "copilot.gpt-4.1"
+	CopilotClaude35Sonnet ModelID = "copilot.claude-3.5-sonnet"
+	CopilotClaude37Sonnet ModelID = "copilot.claude-3.7-sonnet"
+	CopilotClaudeSonnet4  ModelID = "copilot.claude-sonnet-4"
+	CopilotGemini20Flash  ModelID = "copilot.gemini-2.0-flash"
+	CopilotGemini25       ModelID = "copilot.gemini-2.5-pro"
+)
+
+// CopilotModels are billed by the Copilot subscription, not per token, so
+// they have no price. The context windows are the limits of Copilot, which
+// are smaller than those of the models for some of them.
+var CopilotModels = map[ModelID]Model{
+	CopilotGPT4o: {
+		ID:               CopilotGPT4o,
+		Name:             "Copilot: GPT-4o",
+		Provider:         ProviderCopilot,
+		APIModel:         "gpt-4o",
+		ContextWindow:    128_000,
+		DefaultMaxTokens: 4096,
+	},
+	CopilotGPT41: {
+		ID:               CopilotGPT41,
+		Name:             "Copilot: GPT-4.1",
+		Provider:         ProviderCopilot,
+		APIModel:         "gpt-4.1",
+		ContextWindow:    128_000,
+		DefaultMaxTokens: 16_384,
+	},
+	CopilotClaude35Sonnet: {
+		ID:               CopilotClaude35Sonnet,
+		Name:             "Copilot: Claude 3.5 Sonnet",
+		Provider:         ProviderCopilot,
+		APIModel:         "claude-3.5-sonnet",
+		ContextWindow:    90_000,
+		DefaultMaxTokens: 8192,
+	},
+	CopilotClaude37Sonnet: {
+		ID:               CopilotClaude37Sonnet,
+		Name:             "Copilot: Claude 3.7 Sonnet",
+		Provider:         ProviderCopilot,
+		APIModel:         "claude-3.7-sonnet",
+		ContextWindow:    200_000,
+		DefaultMaxTokens: 16_384,
+	},
+	CopilotClaudeSonnet4: {
+		ID:               CopilotClaudeSonnet4,
+		Name:             "Copilot: Claude Sonnet 4",
+		Provider:         ProviderCopilot,
+		APIModel:         "claude-sonnet-4",
+		ContextWindow:    128_000,
+		DefaultMaxTokens: 16_000,
+	},
+	CopilotGemini20Flash: {
+		ID:               CopilotGemini20Flash,
+		Name:             "Copilot: Gemini 2.0 Flash",
+		Provider:         ProviderCopilot,
+		APIModel:         "gemini-2.0-flash-001",
+		ContextWindow:    128_000,
+		DefaultMaxTokens: 8192,
+	},
+	CopilotGemini25: {
+		ID:               CopilotGemini25,
+		Name:             "Copilot: Gemini 2.5 Pro",
+		Provider:         ProviderCopilot,
+		APIModel:         "gemini-2.5-pro",
+		ContextWindow:    128_000,
+		DefaultMaxTokens: 16_384,
+	},
+}
@@ -0,0 +1,38 @@
+package models
+
+const (
+	ProviderDeepSeek ModelProvider = "deepseek"
+
+	DeepSeekChat     ModelID = "deepseek-chat"
+	DeepSeekReasoner ModelID = "deepseek-reasoner"
+)
+
+// DeepSeekModels bill prompt tokens read from the context cache at the cached
+// input price.
+var DeepSeekModels = map[ModelID]Model{
+	DeepSeekChat: {
+		ID:                 DeepSeekChat,
+		Name:               "DeepSeek V3",
+		Provider:           ProviderDeepSeek,
+		APIModel:           "deepseek-chat",
+		CostPer1MIn:        0.27,
+		CostPer1MInCached:  0,
+		CostPer1MOutCached: 0.07,
+		CostPer1MOut:       1.10,
+		ContextWindow:      64_000,
+		DefaultMaxTokens:   8000,
+	},
+	DeepSeekReasoner: {
+		ID:                 DeepSeekReasoner,
+		Name:               "DeepSeek R1",
+		Provider:           ProviderDeepSeek,
+		APIModel:           "deepseek-reasoner",
+		CostPer1MIn:        0.55,
+		CostPer1MInCached:  0,
+		CostPer1MOutCached: 0.14,
+		CostPer1MOut:       2.19,
+		ContextWindow:      64_000,
+		DefaultMaxTokens:   32_000,
+		CanReason:          true,
+	},
+}
@@ -18,7 +18,7 @@ var GeminiModels = map[ModelID]Model{
 		APIModel:           "gemini-2.5-flash-preview-04-17",
 		CostPer1MIn:        0.15,
 		CostPer1MInCached:  0,
-		CostPer1MOutCached: 0,
+		CostPer1MOutCached: 0.0375,
 		CostPer1MOut:       0.60,
 		ContextWindow:      1000000,
 		DefaultMaxTokens:   50000,
@@ -30,7 +30,7 @@ var GeminiModels = map[ModelID]Model{
 		APIModel:           "gemini-2.5-pro-preview-03-25",
 		CostPer1MIn:        1.25,
 		CostPer1MInCached:  0,
-		CostPer1MOutCached: 0,
+		CostPer1MOutCached: 0.31,
 		CostPer1MOut:       10,
 		ContextWindow:      1000000,
 		DefaultMaxTokens:   50000,
@@ -43,7 +43,7 @@ var GeminiModels = map[ModelID]Model{
 		APIModel:           "gemini-2.0-flash",
 		CostPer1MIn:        0.10,
 		CostPer1MInCached:  0,
-		CostPer1MOutCached: 0,
+		CostPer1MOutCached: 0.025,
 		CostPer1MOut:       0.40,
 		ContextWindow:      1000000,
 		DefaultMaxTokens:   6000,
@@ -55,7 +55,7 @@ var GeminiModels = map[ModelID]Model{
 		APIModel:           "gemini-2.0-flash-lite",
 		CostPer1MIn:        0.05,
 		CostPer1MInCached:  0,
-		CostPer1MOutCached: 0,
+		CostPer1MOutCached: 0.01875,
 		CostPer1MOut:       0.30,
 		ContextWindow:      1000000,
 		DefaultMaxTokens:   6000,
@@ -0,0 +1,48 @@
+package models
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// ProviderGGUF runs GGUF models in the process with llama.cpp, without a
+	// server. It is only available in builds with the llama tag.
+	ProviderGGUF ModelProvider = "gguf"
+
+	ggufModelPrefix = "gguf."
+)
+
+// GGUFModels holds the model files configured for the GGUF provider, it is
+// empty until RegisterGGUFModels is called.
+var GGUFModels = map[ModelID]Model{}
+
+// GGUFModelID returns the model ID of a GGUF model by its name.
+func GGUFModelID(name string) ModelID {
+	return ModelID(ggufModelPrefix + name)
+}
+
+// GGUFModel describes a GGUF model file, named after the file unless a name
+// is given. The context window is what is allocated for the model, a larger
+// one needs more memory.
+func GGUFModel(name, path string) Model {
+	if name == "" {
+		name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	return Model{
+		ID:               GGUFModelID(name),
+		Name:             "GGUF: " + name,
+		Provider:         ProviderGGUF,
+		APIModel:         path,
+		ContextWindow:    8192,
+		DefaultMaxTokens: 2048,
+	}
+}
+
+// RegisterGGUFModels adds configured models to the supported models.
+func RegisterGGUFModels(configured map[ModelID]Model) {
+	for id, model := range configured {
+		GGUFModels[id] = model
+		SupportedModels[id] = model
+	}
+}
@@ -10,6 +10,7 @@ const (
 	Llama4Scout               ModelID = "meta-llama/llama-4-scout-17b-16e-instruct"
 	Llama4Maverick            ModelID = "meta-llama/llama-4-maverick-17b-128e-instruct"
 	Llama3_3_70BVersatile     ModelID = "llama-3.3-70b-versatile"
+	Llama3_1_8BInstant        ModelID = "llama-3.1-8b-instant"
 	DeepseekR1DistillLlama70b ModelID = "deepseek-r1-distill-llama-70b"
 )
 
@@ -65,6 +66,20 @@ var GroqModels = map[ModelID]Model{
 		CostPer1MOutCached: 0,
 		CostPer1MOut:       0.79,
 		ContextWindow:      128_000,
+		DefaultMaxTokens:   32_768,
+	},
+
+	Llama3_1_8BInstant: {
+		ID:                 Llama3_1_8BInstant,
+		Name:               "Llama3_1_8BInstant",
+		Provider:           ProviderGROQ,
+		APIModel:           "llama-3.1-8b-instant",
+		CostPer1MIn:        0.05,
+		CostPer1MInCached:  0,
+		CostPer1MOutCached: 0,
+		CostPer1MOut:       0.08,
+		ContextWindow:      128_000,
+		DefaultMaxTokens:   8192,
 	},
 
 	DeepseekR1DistillLlama70b: {
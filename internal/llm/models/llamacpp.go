@@ -0,0 +1,54 @@
+package models
+
+import (
+	"strings"
+)
+
+const (
+	ProviderLlamaCpp ModelProvider = "llamacpp"
+
+	// DefaultLlamaCppBaseURL is where llama-server listens by default.
+	DefaultLlamaCppBaseURL = "http://localhost:8080"
+
+	llamacppModelPrefix = "llamacpp."
+)
+
+// LlamaCppModels holds the model served by the llama.cpp server, it is empty
+// until RegisterLlamaCppModels is called.
+var LlamaCppModels = map[ModelID]Model{}
+
+// LlamaCppBaseURL normalizes a llama.cpp server base URL.
+func LlamaCppBaseURL(baseURL string) string {
+	if baseURL == "" {
+		return DefaultLlamaCppBaseURL
+	}
+	if !strings.Contains(baseURL, "://") {
+		baseURL = "http://" + baseURL
+	}
+	return strings.TrimSuffix(strings.TrimRight(baseURL, "/"), "/v1")
+}
+
+// LlamaCppModelID returns the model ID used for a model served by llama.cpp.
+func LlamaCppModelID(name string) ModelID {
+	return ModelID(llamacppModelPrefix + name)
+}
+
+// LlamaCppModel describes a model served by llama.cpp by its name.
+func LlamaCppModel(name string) Model {
+	return Model{
+		ID:               LlamaCppModelID(name),
+		Name:             "llama.cpp: " + name,
+		Provider:         ProviderLlamaCpp,
+		APIModel:         name,
+		ContextWindow:    4096,
+		DefaultMaxTokens: 2048,
+	}
+}
+
+// RegisterLlamaCppModels adds llama.cpp models to the supported models.
+func RegisterLlamaCppModels(discovered map[ModelID]Model) {
+	for id, model := range discovered {
+		LlamaCppModels[id] = model
+		SupportedModels[id] = model
+	}
+}
@@ -0,0 +1,82 @@
+package models
+
+import (
+	"sort"
+	"strings"
+)
+
+const (
+	ProviderLMStudio ModelProvider = "lmstudio"
+
+	// DefaultLMStudioBaseURL is where the LM Studio server listens by default.
+	DefaultLMStudioBaseURL = "http://localhost:1234"
+
+	lmstudioModelPrefix = "lmstudio."
+)
+
+// LMStudioModels holds the models discovered on the LM Studio server, it is
+// empty until RegisterLMStudioModels is called.
+var LMStudioModels = map[ModelID]Model{}
+
+// loadedLMStudioModels are the discovered models that were loaded in memory.
+var loadedLMStudioModels = map[ModelID]bool{}
+
+// LMStudioBaseURL normalizes an LM Studio base URL. The OpenAI compatible API
+// lives under /v1, which is accepted but stripped.
+func LMStudioBaseURL(baseURL string) string {
+	if baseURL == "" {
+		return DefaultLMStudioBaseURL
+	}
+	if !strings.Contains(baseURL, "://") {
+		baseURL = "http://" + baseURL
+	}
+	return strings.TrimSuffix(strings.TrimRight(baseURL, "/"), "/v1")
+}
+
+// LMStudioModelID returns the model ID used for a model downloaded in LM Studio.
+func LMStudioModelID(name string) ModelID {
+	return ModelID(lmstudioModelPrefix + name)
+}
+
+// LMStudioModel describes an LM Studio model by its identifier.
+func LMStudioModel(name string) Model {
+	return Model{
+		ID:               LMStudioModelID(name),
+		Name:             "LM Studio: " + name,
+		Provider:         ProviderLMStudio,
+		APIModel:         name,
+		ContextWindow:    8192,
+		DefaultMaxTokens: 4096,
+	}
+}
+
+// RegisterLMStudioModels adds discovered LM Studio models to the supported models.
+func RegisterLMStudioModels(discovered map[ModelID]Model, loaded map[ModelID]bool) {
+	for id, model := range discovered {
+		LMStudioModels[id] = model
+		SupportedModels[id] = model
+		if loaded[id] {
+			loadedLMStudioModels[id] = true
+		}
+	}
+}
+
+// DefaultLMStudioModel picks a model from the discovered LM Studio models,
+// preferring the ones already loaded in memory. It returns false when no
+// models were discovered.
+func DefaultLMStudioModel() (ModelID, bool) {
+	ids := make([]string, 0, len(LMStudioModels))
+	for id := range LMStudioModels {
+		ids = append(ids, string(id))
+	}
+	if len(ids) == 0 {
+		return "", false
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		if loadedLMStudioModels[ModelID(id)] {
+			return ModelID(id), true
+		}
+	}
+	return ModelID(ids[0]), true
+}
@@ -0,0 +1,75 @@
+package models
+
+const (
+	ProviderMistral ModelProvider = "mistral"
+
+	MistralLarge  ModelID = "mistral-large"
+	MistralMedium ModelID = "mistral-medium"
+	MistralSmall  ModelID = "mistral-small"
+	Codestral     ModelID = "codestral"
+	DevstralSmall ModelID = "devstral-small"
+)
+
+var MistralModels = map[ModelID]Model{
+	MistralLarge: {
+		ID:                 MistralLarge,
+		Name:               "Mistral Large",
+		Provider:           ProviderMistral,
+		APIModel:           "mistral-large-latest",
+		CostPer1MIn:        2.0,
+		CostPer1MInCached:  0,
+		CostPer1MOutCached: 0,
+		CostPer1MOut:       6.0,
+		ContextWindow:      131_072,
+		DefaultMaxTokens:   8192,
+	},
+	MistralMedium: {
+		ID:                 MistralMedium,
+		Name:               "Mistral Medium",
+		Provider:           ProviderMistral,
+		APIModel:           "mistral-medium-latest",
+		CostPer1MIn:        0.4,
+		CostPer1MInCached:  0,
+		CostPer1MOutCached: 0,
+		CostPer1MOut:       2.0,
+		ContextWindow:      131_072,
+		DefaultMaxTokens:   8192,
+	},
+	MistralSmall: {
+		ID:                 MistralSmall,
+		Name:               "Mistral Small",
+		Provider:           ProviderMistral,
+		APIModel:           "mistral-small-latest",
+		CostPer1MIn:        0.1,
+		CostPer1MInCached:  0,
+		CostPer1MOutCached: 0,
+		CostPer1MOut:       0.3,
+		ContextWindow:      131_072,
+		DefaultMaxTokens:   8192,
+	},
+	// Codestral also serves fill-in-the-middle completions
+	Codestral: {
+		ID:                 Codestral,
+		Name:               "Codestral",
+		Provider:           ProviderMistral,
+		APIModel:           "codestral-latest",
+		CostPer1MIn:        0.3,
+		CostPer1MInCached:  0,
+		CostPer1MOutCached: 0,
+		CostPer1MOut:       0.9,
+		ContextWindow:      256_000,
+		DefaultMaxTokens:   8192,
+	},
+	DevstralSmall: {
+		ID:                 DevstralSmall,
+		Name:               "Devstral Small",
+		Provider:           ProviderMistral,
+		APIModel:           "devstral-small-latest",
+		CostPer1MIn:        0.1,
+		CostPer1MInCached:  0,
+		CostPer1MOutCached: 0,
+		CostPer1MOut:       0.3,
+		ContextWindow:      128_000,
+		DefaultMaxTokens:   8192,
+	},
+}
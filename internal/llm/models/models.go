@@ -19,12 +19,30 @@ type Model struct {
 	ContextWindow      int64         `json:"context_window"`
 	DefaultMaxTokens   int64         `json:"default_max_tokens"`
 	CanReason          bool          `json:"can_reason"`
+	// Capabilities override the capabilities of the provider, see
+	// ModelCapabilities
+	Capabilities *Capabilities `json:"capabilities,omitempty"`
+	// Sampling holds the default sampling parameters of the model, the
+	// settings of an agent override them
+	Sampling Sampling `json:"sampling,omitempty"`
+}
+
+// Sampling holds sampling parameters of a model, the unset ones are left to
+// the provider. They are currently honored by Ollama models.
+type Sampling struct {
+	Temperature   *float64 `json:"temperature,omitempty"`
+	TopP          *float64 `json:"top_p,omitempty"`
+	TopK          *int64   `json:"top_k,omitempty"`
+	NumCtx        *int64   `json:"num_ctx,omitempty"`
+	RepeatPenalty *float64 `json:"repeat_penalty,omitempty"`
 }
 
 // Model IDs
 const ( // GEMINI
 	// Bedrock
 	BedrockClaude37Sonnet ModelID = "bedrock.claude-3.7-sonnet"
+	BedrockLlama33_70B    ModelID = "bedrock.llama-3.3-70b"
+	BedrockLlama31_8B     ModelID = "bedrock.llama-3.1-8b"
 )
 
 const (
@@ -69,6 +87,26 @@ var SupportedModels = map[ModelID]Model{
 		CostPer1MOutCached: 0.30,
 		CostPer1MOut:       15.0,
 	},
+	BedrockLlama33_70B: {
+		ID:               BedrockLlama33_70B,
+		Name:             "Bedrock: Llama 3.3 70B",
+		Provider:         ProviderBedrock,
+		APIModel:         "meta.llama3-3-70b-instruct-v1:0",
+		CostPer1MIn:      0.72,
+		CostPer1MOut:     0.72,
+		ContextWindow:    128_000,
+		DefaultMaxTokens: 2048,
+	},
+	BedrockLlama31_8B: {
+		ID:               BedrockLlama31_8B,
+		Name:             "Bedrock: Llama 3.1 8B",
+		Provider:         ProviderBedrock,
+		APIModel:         "meta.llama3-1-8b-instruct-v1:0",
+		CostPer1MIn:      0.22,
+		CostPer1MOut:     0.22,
+		ContextWindow:    128_000,
+		DefaultMaxTokens: 2048,
+	},
 }
 
 func init() {
@@ -77,4 +115,11 @@ func init() {
 	maps.Copy(SupportedModels, GeminiModels)
 	maps.Copy(SupportedModels, GroqModels)
 	maps.Copy(SupportedModels, AzureModels)
+	maps.Copy(SupportedModels, VertexAIModels)
+	maps.Copy(SupportedModels, DeepSeekModels)
+	maps.Copy(SupportedModels, XAIModels)
+	maps.Copy(SupportedModels, MistralModels)
+	maps.Copy(SupportedModels, TogetherModels)
+	maps.Copy(SupportedModels, CopilotModels)
+	maps.Copy(SupportedModels, OllamaModels)
 }
@@ -0,0 +1,75 @@
+package models
+
+import (
+	"sort"
+	"strings"
+)
+
+const (
+	ProviderOllama ModelProvider = "ollama"
+
+	// DefaultOllamaBaseURL is where a local Ollama server listens by default.
+	DefaultOllamaBaseURL = "http://localhost:11434"
+
+	ollamaModelPrefix = "ollama."
+)
+
+// OllamaModels holds the models discovered on the Ollama server, it is empty
+// until RegisterOllamaModels is called.
+var OllamaModels = map[ModelID]Model{}
+
+// OllamaBaseURL normalizes an Ollama base URL, OLLAMA_HOST is commonly set
+// without a scheme (e.g. 127.0.0.1:11434).
+func OllamaBaseURL(baseURL string) string {
+	if baseURL == "" {
+		return DefaultOllamaBaseURL
+	}
+	if !strings.Contains(baseURL, "://") {
+		baseURL = "http://" + baseURL
+	}
+	return strings.TrimRight(baseURL, "/")
+}
+
+// OllamaModelID returns the model ID used for a locally pulled Ollama model.
+func OllamaModelID(name string) ModelID {
+	return ModelID(ollamaModelPrefix + strings.TrimSuffix(name, ":latest"))
+}
+
+// OllamaModel describes an Ollama model by its name, e.g. qwen2.5-coder:32b.
+func OllamaModel(name string) Model {
+	return Model{
+		ID:               OllamaModelID(name),
+		Name:             "Ollama: " + strings.TrimSuffix(name, ":latest"),
+		Provider:         ProviderOllama,
+		APIModel:         name,
+		ContextWindow:    8192,
+		DefaultMaxTokens: 4096,
+	}
+}
+
+// RegisterOllamaModels adds discovered Ollama models to the supported models.
+func RegisterOllamaModels(discovered map[ModelID]Model) {
+	for id, model := range discovered {
+		OllamaModels[id] = model
+		SupportedModels[id] = model
+	}
+}
+
+// DefaultOllamaModel picks a model from the discovered Ollama models, preferring
+// coding models. It returns false when no models were discovered.
+func DefaultOllamaModel() (ModelID, bool) {
+	ids := make([]string, 0, len(OllamaModels))
+	for id := range OllamaModels {
+		ids = append(ids, string(id))
+	}
+	if len(ids) == 0 {
+		return "", false
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		if strings.Contains(id, "coder") {
+			return ModelID(id), true
+		}
+	}
+	return ModelID(ids[0]), true
+}
@@ -0,0 +1,40 @@
+package models
+
+const (
+	// ProviderOpenAICompatible talks to any server with an OpenAI compatible
+	// chat completions API, e.g. LM Studio, vLLM, llama.cpp server or LiteLLM.
+	ProviderOpenAICompatible ModelProvider = "openai-compatible"
+
+	openaiCompatibleModelPrefix = "openai-compatible."
+)
+
+// OpenAICompatibleModels holds the models configured for the OpenAI compatible
+// provider, it is empty until RegisterOpenAICompatibleModels is called.
+var OpenAICompatibleModels = map[ModelID]Model{}
+
+// OpenAICompatibleModelID returns the model ID for a model served by an OpenAI
+// compatible server.
+func OpenAICompatibleModelID(name string) ModelID {
+	return ModelID(openaiCompatibleModelPrefix + name)
+}
+
+// OpenAICompatibleModel returns a model served by an OpenAI compatible server
+// with conservative defaults, the actual limits depend on the server.
+func OpenAICompatibleModel(name string) Model {
+	return Model{
+		ID:               OpenAICompatibleModelID(name),
+		Name:             "OpenAI Compatible: " + name,
+		Provider:         ProviderOpenAICompatible,
+		APIModel:         name,
+		ContextWindow:    8192,
+		DefaultMaxTokens: 4096,
+	}
+}
+
+// RegisterOpenAICompatibleModels adds configured models to the supported models.
+func RegisterOpenAICompatibleModels(configured map[ModelID]Model) {
+	for id, model := range configured {
+		OpenAICompatibleModels[id] = model
+		SupportedModels[id] = model
+	}
+}
@@ -0,0 +1,42 @@
+package models
+
+const (
+	ProviderOpenRouter ModelProvider = "openrouter"
+
+	// OpenRouterBaseURL is the OpenAI compatible API of OpenRouter.
+	OpenRouterBaseURL = "https://openrouter.ai/api/v1"
+
+	openrouterModelPrefix = "openrouter."
+)
+
+// OpenRouterModels holds the models of the OpenRouter catalog, it is empty
+// until RegisterOpenRouterModels is called.
+var OpenRouterModels = map[ModelID]Model{}
+
+// OpenRouterModelID returns the model ID used for a model of the OpenRouter
+// catalog, e.g. openrouter.anthropic/claude-3.7-sonnet.
+func OpenRouterModelID(id string) ModelID {
+	return ModelID(openrouterModelPrefix + id)
+}
+
+// OpenRouterModel describes an OpenRouter model that is missing from the
+// catalog, it has no pricing.
+func OpenRouterModel(id string) Model {
+	return Model{
+		ID:               OpenRouterModelID(id),
+		Name:             "OpenRouter: " + id,
+		Provider:         ProviderOpenRouter,
+		APIModel:         id,
+		ContextWindow:    8192,
+		DefaultMaxTokens: 4096,
+	}
+}
+
+// RegisterOpenRouterModels adds models of the OpenRouter catalog to the
+// supported models.
+func RegisterOpenRouterModels(catalog map[ModelID]Model) {
+	for id, model := range catalog {
+		OpenRouterModels[id] = model
+		SupportedModels[id] = model
+	}
+}
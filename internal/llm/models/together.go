@@ -0,0 +1,105 @@
+package models
+
+const (
+	ProviderTogether ModelProvider = "together"
+
+	// TogetherBaseURL is the OpenAI compatible API of Together AI.
+	TogetherBaseURL = "https://api.together.xyz/v1"
+
+	togetherModelPrefix = "together."
+
+	TogetherLlama33_70B    ModelID = togetherModelPrefix + "meta-llama/Llama-3.3-70B-Instruct-Turbo"
+	TogetherQwen25Coder32B ModelID = togetherModelPrefix + "Qwen/Qwen2.5-Coder-32B-Instruct"
+	TogetherDeepSeekV3     ModelID = togetherModelPrefix + "deepseek-ai/DeepSeek-V3"
+	TogetherDeepSeekR1     ModelID = togetherModelPrefix + "deepseek-ai/DeepSeek-R1"
+	TogetherLlama4Maverick ModelID = togetherModelPrefix + "meta-llama/Llama-4-Maverick-17B-128E-Instruct-FP8"
+)
+
+const togetherDefaultMaxTokens = 4096
+
+// TogetherModels are the Together AI models known without asking the API. The
+// rest of the catalog is added by RegisterTogetherModels.
+var TogetherModels = map[ModelID]Model{
+	TogetherLlama33_70B: {
+		ID:               TogetherLlama33_70B,
+		Name:             "Together: Llama 3.3 70B Instruct Turbo",
+		Provider:         ProviderTogether,
+		APIModel:         "meta-llama/Llama-3.3-70B-Instruct-Turbo",
+		CostPer1MIn:      0.88,
+		CostPer1MOut:     0.88,
+		ContextWindow:    131_072,
+		DefaultMaxTokens: togetherDefaultMaxTokens,
+	},
+	TogetherQwen25Coder32B: {
+		ID:               TogetherQwen25Coder32B,
+		Name:             "Together: Qwen 2.5 Coder 32B Instruct",
+		Provider:         ProviderTogether,
+		APIModel:         "Qwen/Qwen2.5-Coder-32B-Instruct",
+		CostPer1MIn:      0.80,
+		CostPer1MOut:     0.80,
+		ContextWindow:    32_768,
+		DefaultMaxTokens: togetherDefaultMaxTokens,
+	},
+	TogetherDeepSeekV3: {
+		ID:               TogetherDeepSeekV3,
+		Name:             "Together: DeepSeek V3",
+		Provider:         ProviderTogether,
+		APIModel:         "deepseek-ai/DeepSeek-V3",
+		CostPer1MIn:      1.25,
+		CostPer1MOut:     1.25,
+		ContextWindow:    131_072,
+		DefaultMaxTokens: togetherDefaultMaxTokens,
+	},
+	TogetherDeepSeekR1: {
+		ID:               TogetherDeepSeekR1,
+		Name:             "Together: DeepSeek R1",
+		Provider:         ProviderTogether,
+		APIModel:         "deepseek-ai/DeepSeek-R1",
+		CostPer1MIn:      3.0,
+		CostPer1MOut:     7.0,
+		ContextWindow:    163_840,
+		DefaultMaxTokens: 16_384,
+	},
+	TogetherLlama4Maverick: {
+		ID:               TogetherLlama4Maverick,
+		Name:             "Together: Llama 4 Maverick",
+		Provider:         ProviderTogether,
+		APIModel:         "meta-llama/Llama-4-Maverick-17B-128E-Instruct-FP8",
+		CostPer1MIn:      0.27,
+		CostPer1MOut:     0.85,
+		ContextWindow:    1_048_576,
+		DefaultMaxTokens: togetherDefaultMaxTokens,
+	},
+}
+
+// TogetherModelID returns the model ID used for a model on Together AI, which
+// keeps the organization in the name, e.g. together.Qwen/QwQ-32B.
+func TogetherModelID(name string) ModelID {
+	return ModelID(togetherModelPrefix + name)
+}
+
+// TogetherModel describes a Together AI model by its name.
+func TogetherModel(name string) Model {
+	return Model{
+		ID:               TogetherModelID(name),
+		Name:             "Together: " + name,
+		Provider:         ProviderTogether,
+		APIModel:         name,
+		ContextWindow:    8192,
+		DefaultMaxTokens: togetherDefaultMaxTokens,
+	}
+}
+
+// RegisterTogetherModels adds discovered Together AI models to the supported
+// models. Built-in models keep their tuned token limits.
+func RegisterTogetherModels(discovered map[ModelID]Model) {
+	for id, model := range discovered {
+		if known, ok := TogetherModels[id]; ok {
+			known.CostPer1MIn = model.CostPer1MIn
+			known.CostPer1MOut = model.CostPer1MOut
+			model = known
+		}
+		TogetherModels[id] = model
+		SupportedModels[id] = model
+	}
+}
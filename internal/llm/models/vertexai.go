@@ -0,0 +1,26 @@
+package models
+
+const (
+	ProviderVertexAI ModelProvider = "vertexai"
+
+	VertexAIGemini25Flash     ModelID = "vertexai.gemini-2.5-flash"
+	VertexAIGemini25          ModelID = "vertexai.gemini-2.5"
+	VertexAIGemini20Flash     ModelID = "vertexai.gemini-2.0-flash"
+	VertexAIGemini20FlashLite ModelID = "vertexai.gemini-2.0-flash-lite"
+)
+
+// VertexAIModels are the Gemini models served through Vertex AI. They share
+// the model names and pricing of the Gemini API.
+var VertexAIModels = map[ModelID]Model{
+	VertexAIGemini25Flash:     vertexAIModel(VertexAIGemini25Flash, GeminiModels[Gemini25Flash]),
+	VertexAIGemini25:          vertexAIModel(VertexAIGemini25, GeminiModels[Gemini25]),
+	VertexAIGemini20Flash:     vertexAIModel(VertexAIGemini20Flash, GeminiModels[Gemini20Flash]),
+	VertexAIGemini20FlashLite: vertexAIModel(VertexAIGemini20FlashLite, GeminiModels[Gemini20FlashLite]),
+}
+
+func vertexAIModel(id ModelID, gemini Model) Model {
+	gemini.ID = id
+	gemini.Name = "Vertex AI: " + gemini.Name
+	gemini.Provider = ProviderVertexAI
+	return gemini
+}
@@ -0,0 +1,63 @@
+package models
+
+const (
+	ProviderXAI ModelProvider = "xai"
+
+	XAIGrok3         ModelID = "grok-3"
+	XAIGrok3Fast     ModelID = "grok-3-fast"
+	XAIGrok3Mini     ModelID = "grok-3-mini"
+	XAIGrok3MiniFast ModelID = "grok-3-mini-fast"
+)
+
+var XAIModels = map[ModelID]Model{
+	XAIGrok3: {
+		ID:                 XAIGrok3,
+		Name:               "Grok 3",
+		Provider:           ProviderXAI,
+		APIModel:           "grok-3",
+		CostPer1MIn:        3.0,
+		CostPer1MInCached:  0,
+		CostPer1MOutCached: 0.75,
+		CostPer1MOut:       15.0,
+		ContextWindow:      131_072,
+		DefaultMaxTokens:   16_384,
+	},
+	XAIGrok3Fast: {
+		ID:                 XAIGrok3Fast,
+		Name:               "Grok 3 Fast",
+		Provider:           ProviderXAI,
+		APIModel:           "grok-3-fast",
+		CostPer1MIn:        5.0,
+		CostPer1MInCached:  0,
+		CostPer1MOutCached: 1.25,
+		CostPer1MOut:       25.0,
+		ContextWindow:      131_072,
+		DefaultMaxTokens:   16_384,
+	},
+	XAIGrok3Mini: {
+		ID:                 XAIGrok3Mini,
+		Name:               "Grok 3 Mini",
+		Provider:           ProviderXAI,
+		APIModel:           "grok-3-mini",
+		CostPer1MIn:        0.30,
+		CostPer1MInCached:  0,
+		CostPer1MOutCached: 0.075,
+		CostPer1MOut:       0.50,
+		ContextWindow:      131_072,
+		DefaultMaxTokens:   16_384,
+		CanReason:          true,
+	},
+	XAIGrok3MiniFast: {
+		ID:                 XAIGrok3MiniFast,
+		Name:               "Grok 3 Mini Fast",
+		Provider:           ProviderXAI,
+		APIModel:           "grok-3-mini-fast",
+		CostPer1MIn:        0.60,
+		CostPer1MInCached:  0,
+		CostPer1MOutCached: 0.15,
+		CostPer1MOut:       4.0,
+		ContextWindow:      131_072,
+		DefaultMaxTokens:   16_384,
+		CanReason:          true,
+	},
+}
@@ -0,0 +1,191 @@
+package prompt
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/opencode-ai/opencode/internal/config"
+)
+
+const (
+	// defaultMaxContextFileSize is the size in bytes an instruction file is
+	// cut to
+	defaultMaxContextFileSize = 32_000
+	// defaultMaxContextSize is the size in bytes of all the instruction files
+	defaultMaxContextSize = 100_000
+	// maxContextDepth is how deep the instruction files of the subdirectories
+	// of the project are searched
+	maxContextDepth = 4
+)
+
+// userContextFiles are the instruction files of the user for all projects, in
+// the configuration directory of the user.
+var userContextFiles = []string{
+	"AGENTS.md",
+	"opencode.md",
+	"OpenCode.md",
+	"OPENCODE.md",
+}
+
+// contextExcludedDirs aren't searched for instruction files, with all the
+// directories starting with a dot.
+var contextExcludedDirs = map[string]bool{
+	"node_modules": true,
+	"vendor":       true,
+	"target":       true,
+	"dist":         true,
+	"build":        true,
+	"coverage":     true,
+	"__pycache__":  true,
+}
+
+// ContextFile is an instruction file found for the system prompt.
+type ContextFile struct {
+	Path string
+	// Dir is the subdirectory of the project the instructions are for, empty
+	// for the files of the root of the project and of the user
+	Dir string
+	// User is set for the files of the configuration directory of the user
+	User bool
+	// Size is the size of the file in bytes
+	Size int
+	// Truncated is set when the file was cut to the size limit of a file
+	Truncated bool
+	// Skipped is set when the file wasn't added, the files before it filled
+	// the size limit of all the files
+	Skipped bool
+
+	content string
+}
+
+// ContextFiles returns the instruction files of the user and of the project
+// found for the system prompt, in the order they are added.
+func ContextFiles() []ContextFile {
+	getContextFromPaths()
+	return contextFiles
+}
+
+// findContextFiles returns the instruction files of the configuration
+// directory of the user, the context paths of the project and, unless they are
+// turned off, the files named like the context paths in its subdirectories,
+// from the most general to the most specific, with the size limits applied.
+func findContextFiles(workDir string, paths []string, limits config.Instructions) []ContextFile {
+	var files []ContextFile
+	// Track processed files to avoid duplicates (case-insensitive)
+	seen := make(map[string]bool)
+	add := func(path, dir string, user bool) {
+		lowerPath := strings.ToLower(path)
+		if seen[lowerPath] {
+			return
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return
+		}
+		seen[lowerPath] = true
+		files = append(files, ContextFile{Path: path, Dir: dir, User: user, Size: len(content), content: string(content)})
+	}
+
+	if userDir := config.UserDir(); userDir != "" {
+		for _, name := range userContextFiles {
+			add(filepath.Join(userDir, name), "", true)
+		}
+	}
+
+	names := make(map[string]bool)
+	for _, p := range paths {
+		if !strings.HasSuffix(p, "/") {
+			add(filepath.Join(workDir, p), "", false)
+			if !strings.Contains(p, "/") {
+				names[p] = true
+			}
+			continue
+		}
+		filepath.WalkDir(filepath.Join(workDir, p), func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.IsDir() {
+				add(path, "", false)
+			}
+			return nil
+		})
+	}
+
+	if !limits.NoSubdirectories && len(names) > 0 {
+		filepath.WalkDir(workDir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				if d != nil && d.IsDir() && path != workDir {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			rel, err := filepath.Rel(workDir, path)
+			if err != nil || rel == "." {
+				return nil
+			}
+			if d.IsDir() {
+				if strings.HasPrefix(d.Name(), ".") || contextExcludedDirs[d.Name()] || strings.Count(rel, string(filepath.Separator)) >= maxContextDepth {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if dir := filepath.Dir(rel); dir != "." && names[d.Name()] {
+				add(path, filepath.ToSlash(dir), false)
+			}
+			return nil
+		})
+	}
+
+	maxFileSize := limits.MaxFileSize
+	if maxFileSize == 0 {
+		maxFileSize = defaultMaxContextFileSize
+	}
+	maxSize := limits.MaxSize
+	if maxSize == 0 {
+		maxSize = defaultMaxContextSize
+	}
+	total := 0
+	for i := range files {
+		f := &files[i]
+		if len(f.content) > maxFileSize {
+			n := maxFileSize
+			for n > 0 && !utf8.RuneStart(f.content[n]) {
+				n--
+			}
+			f.content, f.Truncated = f.content[:n], true
+		}
+		if total+len(f.content) > maxSize {
+			// The more specific files come last, they are the ones left out
+			for j := i; j < len(files); j++ {
+				files[j].content, files[j].Skipped = "", true
+			}
+			break
+		}
+		total += len(f.content)
+	}
+	return files
+}
+
+// contextContentOf renders the instruction files for the system prompt.
+func contextContentOf(files []ContextFile) string {
+	var results []string
+	for _, f := range files {
+		if f.Skipped {
+			continue
+		}
+		header := "# From:" + f.Path
+		if f.Dir != "" {
+			header += " (for the files in " + f.Dir + "/)"
+		}
+		content := f.content
+		if f.Truncated {
+			content += "\n[... the rest of the file was cut to fit the size limit]"
+		}
+		results = append(results, header+"\n"+content)
+	}
+	return strings.Join(results, "\n")
+}
@@ -0,0 +1,104 @@
+package prompt
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/opencode-ai/opencode/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeFiles writes the files of a directory, by path relative to it.
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+}
+
+func TestFindContextFiles(t *testing.T) {
+	configHome := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", configHome)
+	writeFiles(t, filepath.Join(configHome, "opencode"), map[string]string{
+		"AGENTS.md": "Answer in English.",
+	})
+	workDir := t.TempDir()
+	writeFiles(t, workDir, map[string]string{
+		"AGENTS.md":                    "Run go test before committing.",
+		".cursor/rules/style.md":       "Keep functions short.",
+		"internal/db/AGENTS.md":        "Edit the queries in sql/, not the generated code.",
+		"node_modules/pkg/AGENTS.md":   "Not for this project.",
+		".git/AGENTS.md":               "Not for this project.",
+		"a/b/c/d/e/AGENTS.md":          "Too deep.",
+		"internal/db/sql/CLAUDE.md":    "Not a context path of the subdirectories.",
+		"internal/db/sql/README.md":    "Not an instruction file.",
+		"internal/tui/opencode.md":     "Not a context path.",
+		"internal/tui/theme/AGENTS.md": "Use the colors of the theme.",
+	})
+	paths := []string{"AGENTS.md", "agents.md", ".cursor/rules/", "CLAUDE.local.md"}
+
+	files := findContextFiles(workDir, paths, config.Instructions{})
+	var found []string
+	for _, f := range files {
+		rel := f.Path
+		if f.User {
+			rel = "~/" + filepath.Base(f.Path)
+		} else if r, err := filepath.Rel(workDir, f.Path); err == nil {
+			rel = filepath.ToSlash(r)
+		}
+		found = append(found, rel+" for "+f.Dir)
+		assert.False(t, f.Truncated || f.Skipped, f.Path)
+	}
+	// From the most general to the most specific
+	assert.Equal(t, []string{
+		"~/AGENTS.md for ",
+		"AGENTS.md for ",
+		".cursor/rules/style.md for ",
+		"internal/db/AGENTS.md for internal/db",
+		"internal/tui/theme/AGENTS.md for internal/tui/theme",
+	}, found)
+
+	content := contextContentOf(files)
+	assert.Contains(t, content, "# From:"+filepath.Join(workDir, "internal/db/AGENTS.md")+" (for the files in internal/db/)\nEdit the queries in sql/, not the generated code.")
+	assert.Contains(t, content, "# From:"+filepath.Join(workDir, "AGENTS.md")+"\nRun go test before committing.")
+
+	files = findContextFiles(workDir, paths, config.Instructions{NoSubdirectories: true})
+	assert.Len(t, files, 3)
+}
+
+func TestFindContextFilesLimits(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	workDir := t.TempDir()
+	writeFiles(t, workDir, map[string]string{
+		"AGENTS.md":            strings.Repeat("a", 50),
+		"internal/AGENTS.md":   strings.Repeat("é", 30),
+		"internal/x/AGENTS.md": strings.Repeat("c", 20),
+	})
+
+	files := findContextFiles(workDir, []string{"AGENTS.md"}, config.Instructions{MaxFileSize: 41, MaxSize: 90})
+	require.Len(t, files, 3)
+	assert.True(t, files[0].Truncated)
+	assert.Equal(t, 50, files[0].Size)
+	assert.Equal(t, strings.Repeat("a", 41), files[0].content)
+	// Files are not cut in the middle of a character
+	assert.True(t, files[1].Truncated)
+	assert.Equal(t, strings.Repeat("é", 20), files[1].content)
+	// The most specific files are left out once the limit of all files is reached
+	assert.True(t, files[2].Skipped)
+	assert.Empty(t, files[2].content)
+
+	content := contextContentOf(files)
+	assert.Equal(t, 2, strings.Count(content, "[... the rest of the file was cut to fit the size limit]"))
+	assert.NotContains(t, content, "internal/x")
+
+	// The default limits
+	files = findContextFiles(workDir, []string{"AGENTS.md"}, config.Instructions{})
+	for _, f := range files {
+		assert.False(t, f.Truncated || f.Skipped, f.Path)
+	}
+}
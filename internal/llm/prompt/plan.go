@@ -0,0 +1,13 @@
+package prompt
+
+// PlanModePrompt is added to the prompts of a session in plan mode, where
+// the agent proposes a plan before it changes anything.
+func PlanModePrompt() string {
+	return `<plan-mode>
+Plan mode is on: don't change any files or run commands yet, the tools that could aren't available. Investigate the task with the read-only tools as far as you need to, then answer with a step-by-step plan:
+- the steps in order, each with the files and the functions it changes and what changes in them
+- the commands that will verify the work, like the tests to run
+- the open questions and the risks, if there are any
+The user will approve or edit the plan, and then you will carry it out with all the tools.
+</plan-mode>`
+}
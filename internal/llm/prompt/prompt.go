@@ -2,9 +2,6 @@ package prompt
 
 import (
 	"fmt"
-	"os"
-	"path/filepath"
-	"strings"
 	"sync"
 
 	"github.com/opencode-ai/opencode/internal/config"
@@ -30,7 +27,7 @@ func GetAgentPrompt(agentName config.AgentName, provider models.ModelProvider) s
 		contextContent := getContextFromPaths()
 		logging.Debug("Context content", "Context", contextContent)
 		if contextContent != "" {
-			return fmt.Sprintf("%s\n\n# Project-Specific Context\n Make sure to follow the instructions in the context below\n%s", basePrompt, contextContent)
+			return fmt.Sprintf("%s\n\n# Project-Specific Context\n Make sure to follow the instructions in the context below. The instructions for the files of a subdirectory take precedence over the general ones for those files.\n%s", basePrompt, contextContent)
 		}
 	}
 	return basePrompt
@@ -39,6 +36,7 @@ func GetAgentPrompt(agentName config.AgentName, provider models.ModelProvider) s
 var (
 	onceContext    sync.Once
 	contextContent string
+	contextFiles   []ContextFile
 )
 
 func getContextFromPaths() string {
@@ -49,87 +47,9 @@ func getContextFromPaths() string {
 			contextPaths = cfg.ContextPaths
 		)
 
-		contextContent = processContextPaths(workDir, contextPaths)
+		contextFiles = findContextFiles(workDir, contextPaths, cfg.Instructions)
+		contextContent = contextContentOf(contextFiles)
 	})
 
 	return contextContent
 }
-
-func processContextPaths(workDir string, paths []string) string {
-	var (
-		wg       sync.WaitGroup
-		resultCh = make(chan string)
-	)
-
-	// Track processed files to avoid duplicates
-	processedFiles := make(map[string]bool)
-	var processedMutex sync.Mutex
-
-	for _, path := range paths {
-		wg.Add(1)
-		go func(p string) {
-			defer wg.Done()
-
-			if strings.HasSuffix(p, "/") {
-				filepath.WalkDir(filepath.Join(workDir, p), func(path string, d os.DirEntry, err error) error {
-					if err != nil {
-						return err
-					}
-					if !d.IsDir() {
-						// Check if we've already processed this file (case-insensitive)
-						processedMutex.Lock()
-						lowerPath := strings.ToLower(path)
-						if !processedFiles[lowerPath] {
-							processedFiles[lowerPath] = true
-							processedMutex.Unlock()
-
-							if result := processFile(path); result != "" {
-								resultCh <- result
-							}
-						} else {
-							processedMutex.Unlock()
-						}
-					}
-					return nil
-				})
-			} else {
-				fullPath := filepath.Join(workDir, p)
-
-				// Check if we've already processed this file (case-insensitive)
-				processedMutex.Lock()
-				lowerPath := strings.ToLower(fullPath)
-				if !processedFiles[lowerPath] {
-					processedFiles[lowerPath] = true
-					processedMutex.Unlock()
-
-					result := processFile(fullPath)
-					if result != "" {
-						resultCh <- result
-					}
-				} else {
-					processedMutex.Unlock()
-				}
-			}
-		}(path)
-	}
-
-	go func() {
-		wg.Wait()
-		close(resultCh)
-	}()
-
-	results := make([]string, 0)
-	for result := range resultCh {
-		results = append(results, result)
-	}
-
-	return strings.Join(results, "\n")
-}
-
-func processFile(filePath string) string {
-	content, err := os.ReadFile(filePath)
-	if err != nil {
-		return ""
-	}
-	return "# From:" + filePath + "\n" + string(content)
-}
@@ -0,0 +1,17 @@
+package prompt
+
+// SummarizerPrompt asks for a summary of the earlier part of a conversation,
+// sent to the model instead of it when the conversation no longer fits its
+// context window.
+func SummarizerPrompt() string {
+	return `The conversation below no longer fits your context window. Write a summary of it that replaces it, so you can continue the work without it. The summary is all you will know about this part of the conversation, the recent messages after it are kept.
+
+Include:
+- what the user asked for, with their corrections and preferences
+- what was done: the files read, created and changed and why, the commands run and their outcome
+- what was found out: the relevant code, the causes of problems, the decisions made
+- the errors hit and how they were solved, or that they weren't
+- what remains to be done
+
+Be specific: keep file paths, names of functions and types, commands and error messages as they were. Leave out what no longer matters. Don't call tools and don't continue the work, only write the summary.`
+}
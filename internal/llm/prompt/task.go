@@ -11,7 +11,8 @@ func TaskPrompt(_ models.ModelProvider) string {
 Notes:
 1. IMPORTANT: You should be concise, direct, and to the point, since your responses will be displayed on a command line interface. Answer the user's question directly, without elaboration, explanation, or details. One word answers are best. Avoid introductions, conclusions, and explanations. You MUST avoid text before/after your response, such as "The answer is <answer>.", "Here is the content of the file..." or "Based on the information provided, the answer is..." or "Here is what I will do next...".
 2. When relevant, share file names and code snippets relevant to the query
-3. Any file paths you return in your final response MUST be absolute. DO NOT use relative paths.`
+3. Any file paths you return in your final response MUST be absolute. DO NOT use relative paths.
+4. Your final response is the only thing returned to the agent that gave you the task, it doesn't see your tool calls or their results. Make it a complete answer to the task on its own, with the file paths, line numbers and code it asked for.`
 
 	return fmt.Sprintf("%s\n%s\n", agentPrompt, getEnvironmentInfo())
 }
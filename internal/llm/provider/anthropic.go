@@ -2,10 +2,12 @@ package provider
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"net/http"
 	"strings"
 	"time"
 
@@ -47,6 +49,9 @@ func newAnthropicClient(opts providerClientOptions) AnthropicClient {
 	if anthropicOpts.useBedrock {
 		anthropicClientOptions = append(anthropicClientOptions, bedrock.WithLoadDefaultConfig(context.Background()))
 	}
+	if opts.customTransport() {
+		anthropicClientOptions = append(anthropicClientOptions, option.WithHTTPClient(opts.httpClient()))
+	}
 
 	client := anthropic.NewClient(anthropicClientOptions...)
 	return &anthropicClient{
@@ -57,38 +62,29 @@ func newAnthropicClient(opts providerClientOptions) AnthropicClient {
 }
 
 func (a *anthropicClient) convertMessages(messages []message.Message) (anthropicMessages []anthropic.MessageParam) {
-	for i, msg := range messages {
-		cache := false
-		if i > len(messages)-3 {
-			cache = true
-		}
+	for _, msg := range messages {
 		switch msg.Role {
 		case message.User:
-			content := anthropic.NewTextBlock(msg.Content().String())
-			if cache && !a.options.disableCache {
-				content.OfRequestTextBlock.CacheControl = anthropic.CacheControlEphemeralParam{
-					Type: "ephemeral",
+			blocks := []anthropic.ContentBlockParamUnion{anthropic.NewTextBlock(msg.Content().String())}
+			for _, binary := range msg.BinaryContent() {
+				if strings.HasPrefix(binary.MIMEType, "image/") {
+					blocks = append(blocks, anthropic.NewImageBlockBase64(binary.MIMEType, base64.StdEncoding.EncodeToString(binary.Data)))
 				}
 			}
-			anthropicMessages = append(anthropicMessages, anthropic.NewUserMessage(content))
+			anthropicMessages = append(anthropicMessages, anthropic.NewUserMessage(blocks...))
 
 		case message.Assistant:
 			blocks := []anthropic.ContentBlockParamUnion{}
 			if msg.Content().String() != "" {
-				content := anthropic.NewTextBlock(msg.Content().String())
-				if cache && !a.options.disableCache {
-					content.OfRequestTextBlock.CacheControl = anthropic.CacheControlEphemeralParam{
-						Type: "ephemeral",
-					}
-				}
-				blocks = append(blocks, content)
+				blocks = append(blocks, anthropic.NewTextBlock(msg.Content().String()))
 			}
 
 			for _, toolCall := range msg.ToolCalls() {
 				var inputMap map[string]any
-				err := json.Unmarshal([]byte(toolCall.Input), &inputMap)
-				if err != nil {
-					continue
+				// A call without a JSON object of arguments is sent with none,
+				// its tool result must follow a tool use
+				if err := json.Unmarshal([]byte(toolCall.Input), &inputMap); err != nil {
+					inputMap = map[string]any{}
 				}
 				blocks = append(blocks, anthropic.ContentBlockParamOfRequestToolUseBlock(toolCall.ID, inputMap, toolCall.Name))
 			}
@@ -107,9 +103,29 @@ func (a *anthropicClient) convertMessages(messages []message.Message) (anthropic
 			anthropicMessages = append(anthropicMessages, anthropic.NewUserMessage(results...))
 		}
 	}
+	if !a.options.disableCache {
+		a.addCacheBreakpoints(anthropicMessages)
+	}
 	return
 }
 
+// addCacheBreakpoints marks the end of the last two messages as cacheable.
+// Each request then reads the conversation up to the previous turn from the
+// cache and writes the new turn, whether it ends in text, a tool call or a
+// tool result. With the system prompt and the tools this uses all four
+// breakpoints Anthropic allows.
+func (a *anthropicClient) addCacheBreakpoints(messages []anthropic.MessageParam) {
+	for i := max(len(messages)-2, 0); i < len(messages); i++ {
+		content := messages[i].Content
+		for j := len(content) - 1; j >= 0; j-- {
+			if cacheControl := content[j].GetCacheControl(); cacheControl != nil {
+				*cacheControl = anthropic.CacheControlEphemeralParam{Type: "ephemeral"}
+				break
+			}
+		}
+	}
+}
+
 func (a *anthropicClient) convertTools(tools []tools.BaseTool) []anthropic.ToolUnionParam {
 	anthropicTools := make([]anthropic.ToolUnionParam, len(tools))
 
@@ -163,10 +179,10 @@ func (a *anthropicClient) preparedMessages(messages []anthropic.MessageParam, to
 				messageContent = m.OfRequestTextBlock.Text
 			}
 		}
-		if messageContent != "" && a.options.shouldThink != nil && a.options.shouldThink(messageContent) {
+		if budget := a.thinkingBudget(messageContent); budget > 0 {
 			thinkingParam = anthropic.ThinkingConfigParamUnion{
 				OfThinkingConfigEnabled: &anthropic.ThinkingConfigEnabledParam{
-					BudgetTokens: int64(float64(a.providerOptions.maxTokens) * 0.8),
+					BudgetTokens: budget,
 					Type:         "enabled",
 				},
 			}
@@ -181,15 +197,37 @@ func (a *anthropicClient) preparedMessages(messages []anthropic.MessageParam, to
 		Messages:    messages,
 		Tools:       tools,
 		Thinking:    thinkingParam,
-		System: []anthropic.TextBlockParam{
-			{
-				Text: a.providerOptions.systemMessage,
-				CacheControl: anthropic.CacheControlEphemeralParam{
-					Type: "ephemeral",
-				},
-			},
-		},
+		System:      a.systemPrompt(),
+	}
+}
+
+// thinkingBudget returns the extended thinking budget for a user message, 0
+// disables thinking. A configured budget always thinks, it must stay below the
+// max tokens of the response. Turns that return tool results never think, the
+// thinking blocks of earlier turns are not kept.
+func (a *anthropicClient) thinkingBudget(userMessage string) int64 {
+	if userMessage == "" {
+		return 0
+	}
+	maxTokens := a.providerOptions.maxTokens
+	if budget := a.providerOptions.thinkingBudget; budget > 0 && a.providerOptions.model.CanReason {
+		if budget >= maxTokens {
+			budget = int64(float64(maxTokens) * 0.8)
+		}
+		return budget
 	}
+	if a.options.shouldThink != nil && a.options.shouldThink(userMessage) {
+		return int64(float64(maxTokens) * 0.8)
+	}
+	return 0
+}
+
+func (a *anthropicClient) systemPrompt() []anthropic.TextBlockParam {
+	system := anthropic.TextBlockParam{Text: a.providerOptions.systemMessage}
+	if !a.options.disableCache {
+		system.CacheControl = anthropic.CacheControlEphemeralParam{Type: "ephemeral"}
+	}
+	return []anthropic.TextBlockParam{system}
 }
 
 func (a *anthropicClient) send(ctx context.Context, messages []message.Message, tools []tools.BaseTool) (resposne *ProviderResponse, err error) {
@@ -213,7 +251,7 @@ func (a *anthropicClient) send(ctx context.Context, messages []message.Message,
 				return nil, retryErr
 			}
 			if retry {
-				logging.WarnPersist(fmt.Sprintf("Retrying due to rate limit... attempt %d of %d", attempts, maxRetries), logging.PersistTimeArg, time.Millisecond*time.Duration(after+100))
+				logging.WarnPersist(fmt.Sprintf("Retrying due to rate limit... attempt %d of %d", attempts, a.providerOptions.retryPolicy.MaxAttempts), logging.PersistTimeArg, time.Millisecond*time.Duration(after+100))
 				select {
 				case <-ctx.Done():
 					return nil, ctx.Err()
@@ -351,7 +389,7 @@ func (a *anthropicClient) stream(ctx context.Context, messages []message.Message
 				return
 			}
 			if retry {
-				logging.WarnPersist(fmt.Sprintf("Retrying due to rate limit... attempt %d of %d", attempts, maxRetries), logging.PersistTimeArg, time.Millisecond*time.Duration(after+100))
+				logging.WarnPersist(fmt.Sprintf("Retrying due to rate limit... attempt %d of %d", attempts, a.providerOptions.retryPolicy.MaxAttempts), logging.PersistTimeArg, time.Millisecond*time.Duration(after+100))
 				select {
 				case <-ctx.Done():
 					// context cancelled
@@ -381,26 +419,20 @@ func (a *anthropicClient) shouldRetry(attempts int, err error) (bool, int64, err
 		return false, 0, err
 	}
 
-	if apierr.StatusCode != 429 && apierr.StatusCode != 529 {
+	policy := a.providerOptions.retryPolicy
+	if !policy.retryableStatus(apierr.StatusCode) {
 		return false, 0, err
 	}
 
-	if attempts > maxRetries {
-		return false, 0, fmt.Errorf("maximum retry attempts reached for rate limit: %d retries", maxRetries)
+	if attempts > policy.MaxAttempts {
+		return false, 0, fmt.Errorf("maximum retry attempts reached for rate limit: %d retries: %w", policy.MaxAttempts, err)
 	}
 
-	retryMs := 0
-	retryAfterValues := apierr.Response.Header.Values("Retry-After")
-
-	backoffMs := 2000 * (1 << (attempts - 1))
-	jitterMs := int(float64(backoffMs) * 0.2)
-	retryMs = backoffMs + jitterMs
-	if len(retryAfterValues) > 0 {
-		if _, err := fmt.Sscanf(retryAfterValues[0], "%d", &retryMs); err == nil {
-			retryMs = retryMs * 1000
-		}
+	var header http.Header
+	if apierr.Response != nil {
+		header = apierr.Response.Header
 	}
-	return true, int64(retryMs), nil
+	return true, policy.backoff(attempts, retryAfter(header)).Milliseconds(), nil
 }
 
 func (a *anthropicClient) toolCalls(msg anthropic.Message) []message.ToolCall {
@@ -0,0 +1,80 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/opencode-ai/opencode/internal/llm/models"
+	"github.com/opencode-ai/opencode/internal/message"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAnthropicClient_CacheBreakpoints(t *testing.T) {
+	history := []message.Message{
+		{Role: message.User, Parts: []message.ContentPart{message.TextContent{Text: "read main.go"}}},
+		{Role: message.Assistant, Parts: []message.ContentPart{
+			message.TextContent{Text: "Reading"},
+			message.ToolCall{ID: "toolu_1", Name: "view", Input: `{"file_path":"main.go"}`, Finished: true},
+		}},
+		{Role: message.Tool, Parts: []message.ContentPart{message.ToolResult{ToolCallID: "toolu_1", Content: "package main"}}},
+	}
+
+	cached := func(client *anthropicClient) []bool {
+		var result []bool
+		for _, msg := range client.convertMessages(history) {
+			for _, block := range msg.Content {
+				if cacheControl := block.GetCacheControl(); cacheControl != nil {
+					result = append(result, cacheControl.Type != "")
+				}
+			}
+		}
+		return result
+	}
+
+	client := &anthropicClient{}
+	// Text and tool use of the assistant, then the tool result
+	assert.Equal(t, []bool{false, false, true, true}, cached(client))
+	assert.NotEmpty(t, client.systemPrompt()[0].CacheControl.Type)
+
+	client.options.disableCache = true
+	assert.Equal(t, []bool{false, false, false, false}, cached(client))
+	assert.Empty(t, client.systemPrompt()[0].CacheControl.Type)
+}
+
+func TestAnthropicClient_UserImages(t *testing.T) {
+	history := []message.Message{
+		{Role: message.User, Parts: []message.ContentPart{
+			message.TextContent{Text: "why does it render like this"},
+			message.BinaryContent{MIMEType: "image/png", Data: []byte("png")},
+			message.BinaryContent{MIMEType: "application/pdf", Data: []byte("pdf")},
+		}},
+	}
+
+	client := &anthropicClient{options: anthropicOptions{disableCache: true}}
+	blocks := client.convertMessages(history)[0].Content
+	assert.Len(t, blocks, 2)
+	assert.Equal(t, "why does it render like this", blocks[0].OfRequestTextBlock.Text)
+	source := blocks[1].OfRequestImageBlock.Source.OfBase64ImageSource
+	assert.Equal(t, "cG5n", source.Data)
+	assert.EqualValues(t, "image/png", source.MediaType)
+}
+
+func TestAnthropicClient_ThinkingBudget(t *testing.T) {
+	client := &anthropicClient{
+		providerOptions: providerClientOptions{
+			model:     models.Model{CanReason: true},
+			maxTokens: 8192,
+		},
+		options: anthropicOptions{shouldThink: DefaultShouldThinkFn},
+	}
+	assert.Equal(t, int64(0), client.thinkingBudget("fix the bug"))
+	assert.Equal(t, int64(6553), client.thinkingBudget("think about the bug"))
+
+	client.providerOptions.thinkingBudget = 4096
+	assert.Equal(t, int64(4096), client.thinkingBudget("fix the bug"))
+	// Tool results never think
+	assert.Equal(t, int64(0), client.thinkingBudget(""))
+
+	// The budget must stay below the max tokens
+	client.providerOptions.thinkingBudget = 16384
+	assert.Equal(t, int64(6553), client.thinkingBudget("fix the bug"))
+}
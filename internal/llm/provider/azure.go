@@ -9,6 +9,13 @@ import (
 	"github.com/openai/openai-go/option"
 )
 
+type azureOptions struct {
+	endpoint   string
+	apiVersion string
+}
+
+type AzureOption func(*azureOptions)
+
 type azureClient struct {
 	*openaiClient
 }
@@ -16,16 +23,27 @@ type azureClient struct {
 type AzureClient ProviderClient
 
 func newAzureClient(opts providerClientOptions) AzureClient {
+	azureOpts := azureOptions{
+		endpoint:   os.Getenv("AZURE_OPENAI_ENDPOINT"),    // ex: https://foo.openai.azure.com
+		apiVersion: os.Getenv("AZURE_OPENAI_API_VERSION"), // ex: 2025-04-01-preview
+	}
+	for _, o := range opts.azureOptions {
+		o(&azureOpts)
+	}
+	openaiOpts := openaiOptions{
+		reasoningEffort: "medium",
+	}
+	for _, o := range opts.openaiOptions {
+		o(&openaiOpts)
+	}
 
-	endpoint := os.Getenv("AZURE_OPENAI_ENDPOINT")      // ex: https://foo.openai.azure.com
-	apiVersion := os.Getenv("AZURE_OPENAI_API_VERSION") // ex: 2025-04-01-preview
-
-	if endpoint == "" || apiVersion == "" {
+	if azureOpts.endpoint == "" || azureOpts.apiVersion == "" {
 		return &azureClient{openaiClient: newOpenAIClient(opts).(*openaiClient)}
 	}
 
+	// The model of a request is the deployment name in the Azure URL
 	reqOpts := []option.RequestOption{
-		azure.WithEndpoint(endpoint, apiVersion),
+		azure.WithEndpoint(azureOpts.endpoint, azureOpts.apiVersion),
 	}
 
 	if opts.apiKey != "" || os.Getenv("AZURE_OPENAI_API_KEY") != "" {
@@ -37,11 +55,28 @@ func newAzureClient(opts providerClientOptions) AzureClient {
 	} else if cred, err := azidentity.NewDefaultAzureCredential(nil); err == nil {
 		reqOpts = append(reqOpts, azure.WithTokenCredential(cred))
 	}
+	if opts.customTransport() {
+		reqOpts = append(reqOpts, option.WithHTTPClient(opts.httpClient()))
+	}
 
 	base := &openaiClient{
 		providerOptions: opts,
+		options:         openaiOpts,
 		client:          openai.NewClient(reqOpts...),
 	}
 
 	return &azureClient{openaiClient: base}
 }
+
+// WithAzureEndpoint sets the Azure OpenAI resource endpoint and API version,
+// taking precedence over AZURE_OPENAI_ENDPOINT and AZURE_OPENAI_API_VERSION.
+func WithAzureEndpoint(endpoint, apiVersion string) AzureOption {
+	return func(options *azureOptions) {
+		if endpoint != "" {
+			options.endpoint = endpoint
+		}
+		if apiVersion != "" {
+			options.apiVersion = apiVersion
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/opencode-ai/opencode/internal/llm/models"
+	"github.com/opencode-ai/opencode/internal/message"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAzureClient(t *testing.T) {
+	var path, apiVersion, apiKey string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, apiVersion, apiKey = r.URL.Path, r.URL.Query().Get("api-version"), r.Header.Get("Api-Key")
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintln(w, `{"id":"1","object":"chat.completion","model":"gpt-4.1","choices":[{"index":0,"finish_reason":"stop","message":{"role":"assistant","content":"hi"}}],"usage":{"prompt_tokens":3,"completion_tokens":1}}`)
+	}))
+	t.Cleanup(server.Close)
+	t.Setenv("AZURE_OPENAI_ENDPOINT", "https://env.openai.azure.com")
+	t.Setenv("AZURE_OPENAI_API_VERSION", "2024-10-21")
+	t.Setenv("AZURE_OPENAI_API_KEY", "")
+
+	// The configured endpoint takes precedence over the environment, and the
+	// model is the deployment
+	client := newAzureClient(providerClientOptions{
+		model:        models.Model{ID: models.AzureGPT41, APIModel: "coding-deployment"},
+		apiKey:       "azure-key",
+		azureOptions: []AzureOption{WithAzureEndpoint(server.URL, "2025-04-01-preview")},
+	}).(*azureClient)
+
+	response, err := client.send(context.Background(), []message.Message{
+		{Role: message.User, Parts: []message.ContentPart{message.TextContent{Text: "hello"}}},
+	}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "hi", response.Content)
+	assert.Equal(t, "/openai/deployments/coding-deployment/chat/completions", path)
+	assert.Equal(t, "2025-04-01-preview", apiVersion)
+	assert.Equal(t, "azure-key", apiKey)
+}
+
+func TestWithAzureEndpoint(t *testing.T) {
+	// Unset values keep those of the environment
+	options := azureOptions{endpoint: "https://env.openai.azure.com", apiVersion: "2024-10-21"}
+	WithAzureEndpoint("", "")(&options)
+	assert.Equal(t, azureOptions{endpoint: "https://env.openai.azure.com", apiVersion: "2024-10-21"}, options)
+
+	WithAzureEndpoint("https://config.openai.azure.com", "")(&options)
+	assert.Equal(t, azureOptions{endpoint: "https://config.openai.azure.com", apiVersion: "2024-10-21"}, options)
+}
@@ -0,0 +1,256 @@
+package provider
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/openai/openai-go"
+	"github.com/openai/openai-go/shared"
+	"github.com/opencode-ai/opencode/internal/llm/models"
+	"github.com/opencode-ai/opencode/internal/message"
+)
+
+// batchMetadata tags the batch jobs created by opencode, the account may have
+// others.
+var batchMetadata = shared.MetadataParam{"source": "opencode"}
+
+// BatchRequest is a conversation to answer in a batch job. The ID identifies
+// its result, e.g. the session of the conversation.
+type BatchRequest struct {
+	ID       string
+	Messages []message.Message
+}
+
+// BatchResult is the answer to a request of a batch job, or the error that
+// request failed with.
+type BatchResult struct {
+	ID       string
+	Response *ProviderResponse
+	Error    error
+}
+
+// Batch is a batch job and its progress.
+type Batch struct {
+	ID        string
+	Status    string
+	CreatedAt time.Time
+	Total     int64
+	Completed int64
+	Failed    int64
+	// Errors are the reasons the batch was rejected, e.g. an invalid request
+	Errors []string
+}
+
+// Done reports whether the batch job finished, successfully or not.
+func (b Batch) Done() bool {
+	return slices.Contains([]string{"completed", "failed", "expired", "cancelled"}, b.Status)
+}
+
+// BatchClient answers conversations in batch jobs, which are processed within
+// 24 hours at about half the price of interactive requests. Batch requests are
+// answered without tools.
+type BatchClient interface {
+	SubmitBatch(ctx context.Context, requests []BatchRequest) (Batch, error)
+	GetBatch(ctx context.Context, id string) (Batch, error)
+	ListBatches(ctx context.Context) ([]Batch, error)
+	// BatchResults returns the results of a completed batch job.
+	BatchResults(ctx context.Context, id string) ([]BatchResult, error)
+}
+
+// NewBatchClient creates a batch client for providers with a batch API.
+func NewBatchClient(providerName models.ModelProvider, opts ...ProviderClientOption) (BatchClient, error) {
+	clientOptions := providerClientOptions{
+		retryPolicy: DefaultRetryPolicy(),
+	}
+	for _, o := range opts {
+		o(&clientOptions)
+	}
+	switch providerName {
+	case models.ProviderOpenAI:
+		return newOpenAIClient(clientOptions).(*openaiClient), nil
+	}
+	return nil, fmt.Errorf("batch jobs not supported for provider: %s", providerName)
+}
+
+// openaiBatchLine is a request of the input file of a batch job.
+type openaiBatchLine struct {
+	CustomID string                         `json:"custom_id"`
+	Method   string                         `json:"method"`
+	URL      string                         `json:"url"`
+	Body     openai.ChatCompletionNewParams `json:"body"`
+}
+
+// openaiBatchOutput is a result of the output or error file of a batch job.
+type openaiBatchOutput struct {
+	CustomID string `json:"custom_id"`
+	Response *struct {
+		StatusCode int             `json:"status_code"`
+		Body       json.RawMessage `json:"body"`
+	} `json:"response"`
+	Error *struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func (o *openaiClient) SubmitBatch(ctx context.Context, requests []BatchRequest) (Batch, error) {
+	if len(requests) == 0 {
+		return Batch{}, errors.New("no requests to submit")
+	}
+	var input bytes.Buffer
+	encoder := json.NewEncoder(&input)
+	for _, req := range requests {
+		line := openaiBatchLine{
+			CustomID: req.ID,
+			Method:   "POST",
+			URL:      string(openai.BatchNewParamsEndpointV1ChatCompletions),
+			Body:     o.preparedParams(o.convertMessages(req.Messages), nil),
+		}
+		if err := encoder.Encode(line); err != nil {
+			return Batch{}, fmt.Errorf("failed to encode batch request %s: %w", req.ID, err)
+		}
+	}
+
+	file, err := o.client.Files.New(ctx, openai.FileNewParams{
+		File:    openai.File(&input, "opencode-batch.jsonl", "application/jsonl"),
+		Purpose: openai.FilePurposeBatch,
+	})
+	if err != nil {
+		return Batch{}, fmt.Errorf("failed to upload batch requests: %w", err)
+	}
+	batch, err := o.client.Batches.New(ctx, openai.BatchNewParams{
+		CompletionWindow: openai.BatchNewParamsCompletionWindow24h,
+		Endpoint:         openai.BatchNewParamsEndpointV1ChatCompletions,
+		InputFileID:      file.ID,
+		Metadata:         batchMetadata,
+	})
+	if err != nil {
+		return Batch{}, fmt.Errorf("failed to create batch: %w", err)
+	}
+	return openaiBatch(*batch), nil
+}
+
+func (o *openaiClient) GetBatch(ctx context.Context, id string) (Batch, error) {
+	batch, err := o.client.Batches.Get(ctx, id)
+	if err != nil {
+		return Batch{}, fmt.Errorf("failed to get batch %s: %w", id, err)
+	}
+	return openaiBatch(*batch), nil
+}
+
+func (o *openaiClient) ListBatches(ctx context.Context) ([]Batch, error) {
+	var batches []Batch
+	iter := o.client.Batches.ListAutoPaging(ctx, openai.BatchListParams{Limit: openai.Int(100)})
+	for iter.Next() {
+		batch := iter.Current()
+		if batch.Metadata["source"] == batchMetadata["source"] {
+			batches = append(batches, openaiBatch(batch))
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list batches: %w", err)
+	}
+	return batches, nil
+}
+
+func (o *openaiClient) BatchResults(ctx context.Context, id string) ([]BatchResult, error) {
+	batch, err := o.client.Batches.Get(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get batch %s: %w", id, err)
+	}
+	if batch.Status != openai.BatchStatusCompleted {
+		return nil, fmt.Errorf("batch %s is %s, not completed", id, batch.Status)
+	}
+
+	var results []BatchResult
+	for _, fileID := range []string{batch.OutputFileID, batch.ErrorFileID} {
+		if fileID == "" {
+			continue
+		}
+		outputs, err := o.batchOutputs(ctx, fileID)
+		if err != nil {
+			return nil, err
+		}
+		for _, output := range outputs {
+			results = append(results, o.batchResult(output))
+		}
+	}
+	return results, nil
+}
+
+func (o *openaiClient) batchOutputs(ctx context.Context, fileID string) ([]openaiBatchOutput, error) {
+	resp, err := o.client.Files.Content(ctx, fileID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download batch results: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var outputs []openaiBatchOutput
+	scanner := bufio.NewScanner(resp.Body)
+	// A line holds a whole completion
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var output openaiBatchOutput
+		if err := json.Unmarshal(scanner.Bytes(), &output); err != nil {
+			return nil, fmt.Errorf("failed to decode batch result: %w", err)
+		}
+		outputs = append(outputs, output)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read batch results: %w", err)
+	}
+	return outputs, nil
+}
+
+func (o *openaiClient) batchResult(output openaiBatchOutput) BatchResult {
+	result := BatchResult{ID: output.CustomID}
+	switch {
+	case output.Error != nil:
+		result.Error = fmt.Errorf("%s: %s", output.Error.Code, output.Error.Message)
+	case output.Response == nil:
+		result.Error = errors.New("no response")
+	case output.Response.StatusCode != 200:
+		result.Error = fmt.Errorf("request failed with status %d: %s", output.Response.StatusCode, output.Response.Body)
+	default:
+		var completion openai.ChatCompletion
+		if err := json.Unmarshal(output.Response.Body, &completion); err != nil {
+			result.Error = fmt.Errorf("failed to decode completion: %w", err)
+			break
+		}
+		if len(completion.Choices) == 0 {
+			result.Error = errors.New("completion has no choices")
+			break
+		}
+		result.Response = &ProviderResponse{
+			Content:      completion.Choices[0].Message.Content,
+			ToolCalls:    o.toolCalls(completion),
+			Usage:        o.usage(completion),
+			FinishReason: o.finishReason(string(completion.Choices[0].FinishReason)),
+		}
+	}
+	return result
+}
+
+func openaiBatch(batch openai.Batch) Batch {
+	b := Batch{
+		ID:        batch.ID,
+		Status:    string(batch.Status),
+		CreatedAt: time.Unix(batch.CreatedAt, 0),
+		Total:     batch.RequestCounts.Total,
+		Completed: batch.RequestCounts.Completed,
+		Failed:    batch.RequestCounts.Failed,
+	}
+	for _, e := range batch.Errors.Data {
+		b.Errors = append(b.Errors, e.Message)
+	}
+	return b
+}
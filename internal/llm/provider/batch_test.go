@@ -0,0 +1,93 @@
+package provider
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/opencode-ai/opencode/internal/llm/models"
+	"github.com/opencode-ai/opencode/internal/message"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenAIBatchClient(t *testing.T) {
+	var input []map[string]json.RawMessage
+	var batchParams map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.Method + " " + r.URL.Path {
+		case "POST /v1/files":
+			assert.Equal(t, "batch", r.FormValue("purpose"))
+			file, _, err := r.FormFile("file")
+			require.NoError(t, err)
+			scanner := bufio.NewScanner(file)
+			for scanner.Scan() {
+				var line map[string]json.RawMessage
+				require.NoError(t, json.Unmarshal(scanner.Bytes(), &line))
+				input = append(input, line)
+			}
+			fmt.Fprintln(w, `{"id":"file-in","object":"file","purpose":"batch","filename":"opencode-batch.jsonl","bytes":1,"created_at":1,"status":"processed"}`)
+		case "POST /v1/batches":
+			body, _ := io.ReadAll(r.Body)
+			require.NoError(t, json.Unmarshal(body, &batchParams))
+			fmt.Fprintln(w, `{"id":"batch-1","object":"batch","endpoint":"/v1/chat/completions","input_file_id":"file-in","completion_window":"24h","status":"validating","created_at":1700000000,"request_counts":{"total":2,"completed":0,"failed":0}}`)
+		case "GET /v1/batches/batch-1":
+			fmt.Fprintln(w, `{"id":"batch-1","object":"batch","endpoint":"/v1/chat/completions","input_file_id":"file-in","completion_window":"24h","status":"completed","created_at":1700000000,"output_file_id":"file-out","request_counts":{"total":2,"completed":1,"failed":1}}`)
+		case "GET /v1/files/file-out/content":
+			fmt.Fprintln(w, `{"custom_id":"session-1","response":{"status_code":200,"body":{"id":"1","object":"chat.completion","model":"gpt-4.1","choices":[{"index":0,"finish_reason":"stop","message":{"role":"assistant","content":"looks good"}}],"usage":{"prompt_tokens":30,"completion_tokens":2}}}}`)
+			fmt.Fprintln(w, `{"custom_id":"session-2","response":{"status_code":400,"body":{"error":{"message":"bad request"}}}}`)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := NewBatchClient(models.ProviderOpenAI,
+		WithAPIKey("sk-test"),
+		WithModel(models.SupportedModels[models.GPT41]),
+		WithSystemMessage("You are a reviewer"),
+		WithOpenAIOptions(WithOpenAIBaseURL(server.URL+"/v1")),
+		WithRetryPolicy(RetryPolicy{}),
+	)
+	require.NoError(t, err)
+
+	prompt := func(text string) []message.Message {
+		return []message.Message{{Role: message.User, Parts: []message.ContentPart{message.TextContent{Text: text}}}}
+	}
+	batch, err := client.SubmitBatch(context.Background(), []BatchRequest{
+		{ID: "session-1", Messages: prompt("review a.go")},
+		{ID: "session-2", Messages: prompt("review b.go")},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "batch-1", batch.ID)
+	assert.False(t, batch.Done())
+
+	require.Len(t, input, 2)
+	assert.JSONEq(t, `"session-1"`, string(input[0]["custom_id"]))
+	assert.JSONEq(t, `"/v1/chat/completions"`, string(input[0]["url"]))
+	body := input[1]["body"]
+	assert.Contains(t, string(body), "You are a reviewer")
+	assert.Contains(t, string(body), "review b.go")
+	assert.NotContains(t, string(body), `"tools"`)
+	assert.Equal(t, map[string]any{"source": "opencode"}, batchParams["metadata"])
+
+	results, err := client.BatchResults(context.Background(), "batch-1")
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, "session-1", results[0].ID)
+	require.NoError(t, results[0].Error)
+	assert.Equal(t, "looks good", results[0].Response.Content)
+	assert.Equal(t, message.FinishReasonEndTurn, results[0].Response.FinishReason)
+	assert.Equal(t, int64(30), results[0].Response.Usage.InputTokens)
+	assert.Equal(t, "session-2", results[1].ID)
+	require.Error(t, results[1].Error)
+	assert.True(t, strings.Contains(results[1].Error.Error(), "400"))
+}
@@ -66,6 +66,14 @@ func newBedrockClient(opts providerClientOptions) BedrockClient {
 		}
 	}
 
+	if strings.Contains(opts.model.APIModel, "meta.llama") {
+		return &bedrockClient{
+			providerOptions: opts,
+			options:         bedrockOpts,
+			childProvider:   newBedrockLlamaClient(opts, region),
+		}
+	}
+
 	// Return client with nil childProvider if model is not supported
 	// This will cause an error when used
 	return &bedrockClient{
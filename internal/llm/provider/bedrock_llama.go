@@ -0,0 +1,334 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/protocol/eventstream"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/opencode-ai/opencode/internal/llm/tools"
+	"github.com/opencode-ai/opencode/internal/logging"
+	"github.com/opencode-ai/opencode/internal/message"
+)
+
+// bedrockLlamaClient talks to Meta Llama models on Bedrock through the
+// InvokeModel API. Llama has no tool calling API on InvokeModel, so tools are
+// described in the Llama 3 prompt format and JSON tool calls are parsed from
+// the generation.
+type bedrockLlamaClient struct {
+	providerOptions providerClientOptions
+	region          string
+	endpoint        string
+	credentials     aws.CredentialsProvider
+	client          *http.Client
+}
+
+type bedrockLlamaRequest struct {
+	Prompt      string   `json:"prompt"`
+	MaxGenLen   int64    `json:"max_gen_len,omitempty"`
+	Temperature *float64 `json:"temperature,omitempty"`
+}
+
+type bedrockLlamaResponse struct {
+	Generation           string `json:"generation"`
+	PromptTokenCount     int64  `json:"prompt_token_count"`
+	GenerationTokenCount int64  `json:"generation_token_count"`
+	StopReason           string `json:"stop_reason"`
+}
+
+// bedrockAPIError is returned when Bedrock answers with an error status or an
+// exception event.
+type bedrockAPIError struct {
+	StatusCode int
+	Type       string
+	Message    string
+}
+
+func (e *bedrockAPIError) Error() string {
+	if e.Type != "" {
+		return fmt.Sprintf("bedrock: %s: %s (status %d)", e.Type, e.Message, e.StatusCode)
+	}
+	return fmt.Sprintf("bedrock: %s (status %d)", e.Message, e.StatusCode)
+}
+
+func newBedrockLlamaClient(opts providerClientOptions, region string) *bedrockLlamaClient {
+	return &bedrockLlamaClient{
+		providerOptions: opts,
+		region:          region,
+		endpoint:        fmt.Sprintf("https://bedrock-runtime.%s.amazonaws.com", region),
+		client:          opts.httpClient(),
+	}
+}
+
+// convertMessages renders the conversation in the Llama 3 chat format. Tool
+// results use the ipython role Llama 3.1 was trained with.
+func (b *bedrockLlamaClient) convertMessages(messages []message.Message, tools []tools.BaseTool) string {
+	var prompt strings.Builder
+	turn := func(role, content string) {
+		fmt.Fprintf(&prompt, "<|start_header_id|>%s<|end_header_id|>\n\n%s<|eot_id|>", role, content)
+	}
+
+	prompt.WriteString("<|begin_of_text|>")
+	system := b.providerOptions.systemMessage
+	if len(tools) > 0 {
+		system += "\n\n" + textToolInstructions(tools)
+	}
+	turn("system", system)
+
+	for _, msg := range messages {
+		switch msg.Role {
+		case message.User:
+			turn("user", msg.Content().String())
+		case message.Assistant:
+			content := msg.Content().String()
+			for _, call := range msg.ToolCalls() {
+				args := json.RawMessage(call.Input)
+				if !json.Valid(args) {
+					args = json.RawMessage("{}")
+				}
+				data, _ := json.Marshal(map[string]any{"name": call.Name, "parameters": args})
+				content = strings.TrimSpace(content + "\n" + string(data))
+			}
+			turn("assistant", content)
+		case message.Tool:
+			for _, result := range msg.ToolResults() {
+				turn("ipython", result.Content)
+			}
+		}
+	}
+	prompt.WriteString("<|start_header_id|>assistant<|end_header_id|>\n\n")
+	return prompt.String()
+}
+
+func (b *bedrockLlamaClient) finishReason(reason string, toolCalls []message.ToolCall) message.FinishReason {
+	if len(toolCalls) > 0 {
+		return message.FinishReasonToolUse
+	}
+	switch reason {
+	case "stop":
+		return message.FinishReasonEndTurn
+	case "length":
+		return message.FinishReasonMaxTokens
+	default:
+		return message.FinishReasonUnknown
+	}
+}
+
+// invoke sends a signed InvokeModel request, streaming when stream is set.
+func (b *bedrockLlamaClient) invoke(ctx context.Context, prompt string, stream bool) (*http.Response, error) {
+	body, err := json.Marshal(bedrockLlamaRequest{
+		Prompt:    prompt,
+		MaxGenLen: b.providerOptions.maxTokens,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+	action := "invoke"
+	if stream {
+		action = "invoke-with-response-stream"
+	}
+	endpoint := fmt.Sprintf("%s/model/%s/%s", b.endpoint, url.PathEscape(b.providerOptions.model.APIModel), action)
+
+	if b.credentials == nil {
+		cfg, err := awsconfig.LoadDefaultConfig(ctx, awsconfig.WithRegion(b.region))
+		if err != nil {
+			return nil, fmt.Errorf("failed to load AWS credentials: %w", err)
+		}
+		b.credentials = cfg.Credentials
+	}
+
+	var resp *http.Response
+	err = b.providerOptions.retryPolicy.do(ctx, func() error {
+		var err error
+		resp, err = b.invokeOnce(ctx, endpoint, body)
+		return err
+	}, b.shouldRetry)
+	return resp, err
+}
+
+func (b *bedrockLlamaClient) invokeOnce(ctx context.Context, endpoint string, body []byte) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+
+	creds, err := b.credentials.Retrieve(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve AWS credentials: %w", err)
+	}
+	hash := sha256.Sum256(body)
+	if err := v4.NewSigner().SignHTTP(ctx, creds, req, hex.EncodeToString(hash[:]), "bedrock", b.region, time.Now()); err != nil {
+		return nil, fmt.Errorf("failed to sign request: %w", err)
+	}
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(resp.Body)
+		apiErr := &bedrockAPIError{
+			StatusCode: resp.StatusCode,
+			Type:       resp.Header.Get("X-Amzn-Errortype"),
+			Message:    strings.TrimSpace(string(data)),
+		}
+		var errResp struct {
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(data, &errResp) == nil && errResp.Message != "" {
+			apiErr.Message = errResp.Message
+		}
+		apiErr.Type, _, _ = strings.Cut(apiErr.Type, ":")
+		return nil, apiErr
+	}
+	return resp, nil
+}
+
+func (b *bedrockLlamaClient) shouldRetry(err error) (bool, time.Duration) {
+	var apiErr *bedrockAPIError
+	if errors.As(err, &apiErr) {
+		return b.providerOptions.retryPolicy.retryableStatus(apiErr.StatusCode), 0
+	}
+	return isTransientNetworkError(err), 0
+}
+
+func (b *bedrockLlamaClient) send(ctx context.Context, messages []message.Message, tools []tools.BaseTool) (*ProviderResponse, error) {
+	resp, err := b.invoke(ctx, b.convertMessages(messages, tools), false)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var llamaResp bedrockLlamaResponse
+	if err := json.NewDecoder(resp.Body).Decode(&llamaResp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	content, toolCalls := parseTextToolCalls(llamaResp.Generation, tools)
+	return &ProviderResponse{
+		Content:   content,
+		ToolCalls: toolCalls,
+		Usage: TokenUsage{
+			InputTokens:  llamaResp.PromptTokenCount,
+			OutputTokens: llamaResp.GenerationTokenCount,
+		},
+		FinishReason: b.finishReason(llamaResp.StopReason, toolCalls),
+	}, nil
+}
+
+func (b *bedrockLlamaClient) stream(ctx context.Context, messages []message.Message, tools []tools.BaseTool) <-chan ProviderEvent {
+	eventChan := make(chan ProviderEvent)
+
+	go func() {
+		defer close(eventChan)
+		fail := func(err error) {
+			if ctx.Err() != nil {
+				err = ctx.Err()
+			}
+			eventChan <- ProviderEvent{Type: EventError, Error: err}
+		}
+
+		resp, err := b.invoke(ctx, b.convertMessages(messages, tools), true)
+		if err != nil {
+			fail(err)
+			return
+		}
+		defer resp.Body.Close()
+
+		var generation strings.Builder
+		var usage TokenUsage
+		var stopReason string
+		decoder := eventstream.NewDecoder()
+		for {
+			msg, err := decoder.Decode(resp.Body, nil)
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				fail(err)
+				return
+			}
+
+			if messageType := headerString(msg.Headers, ":message-type"); messageType != "event" {
+				var exception struct {
+					Message string `json:"message"`
+				}
+				_ = json.Unmarshal(msg.Payload, &exception)
+				fail(&bedrockAPIError{
+					StatusCode: resp.StatusCode,
+					Type:       headerString(msg.Headers, ":exception-type"),
+					Message:    exception.Message,
+				})
+				return
+			}
+			if headerString(msg.Headers, ":event-type") != "chunk" {
+				continue
+			}
+
+			var event struct {
+				Bytes []byte `json:"bytes"`
+			}
+			var chunk bedrockLlamaResponse
+			if err := json.Unmarshal(msg.Payload, &event); err != nil || json.Unmarshal(event.Bytes, &chunk) != nil {
+				logging.Warn("Error decoding bedrock stream chunk", "payload", string(msg.Payload))
+				continue
+			}
+			generation.WriteString(chunk.Generation)
+			// Tool calls are only known once the generation is complete
+			if len(tools) == 0 && chunk.Generation != "" {
+				eventChan <- ProviderEvent{Type: EventContentDelta, Content: chunk.Generation}
+			}
+			if chunk.PromptTokenCount > 0 {
+				usage.InputTokens = chunk.PromptTokenCount
+			}
+			usage.OutputTokens += chunk.GenerationTokenCount
+			if chunk.StopReason != "" {
+				stopReason = chunk.StopReason
+			}
+		}
+
+		content, toolCalls := parseTextToolCalls(generation.String(), tools)
+		if len(tools) > 0 {
+			if content != "" {
+				eventChan <- ProviderEvent{Type: EventContentDelta, Content: content}
+			}
+			for _, call := range toolCalls {
+				eventChan <- ProviderEvent{Type: EventToolUseStart, ToolCall: &call}
+			}
+		} else {
+			content = generation.String()
+		}
+		eventChan <- ProviderEvent{
+			Type: EventComplete,
+			Response: &ProviderResponse{
+				Content:      content,
+				ToolCalls:    toolCalls,
+				Usage:        usage,
+				FinishReason: b.finishReason(stopReason, toolCalls),
+			},
+		}
+	}()
+
+	return eventChan
+}
+
+func headerString(headers eventstream.Headers, name string) string {
+	if value := headers.Get(name); value != nil {
+		return value.String()
+	}
+	return ""
+}
@@ -0,0 +1,82 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/protocol/eventstream"
+	"github.com/opencode-ai/opencode/internal/llm/models"
+	"github.com/opencode-ai/opencode/internal/llm/tools"
+	"github.com/opencode-ai/opencode/internal/message"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBedrockLlamaClient_Stream(t *testing.T) {
+	var received bedrockLlamaRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/model/meta.llama3-3-70b-instruct-v1:0/invoke-with-response-stream", r.URL.Path)
+		assert.True(t, strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKIDTEST/"))
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+
+		encoder := eventstream.NewEncoder()
+		for _, chunk := range []bedrockLlamaResponse{
+			{Generation: `{"name": "view", `, PromptTokenCount: 40, GenerationTokenCount: 5},
+			{Generation: `"parameters": {"file_path": "main.go"}}`, GenerationTokenCount: 9, StopReason: "stop"},
+		} {
+			data, _ := json.Marshal(chunk)
+			payload, _ := json.Marshal(map[string][]byte{"bytes": data})
+			msg := eventstream.Message{Payload: payload}
+			msg.Headers.Set(":message-type", eventstream.StringValue("event"))
+			msg.Headers.Set(":event-type", eventstream.StringValue("chunk"))
+			assert.NoError(t, encoder.Encode(w, msg))
+		}
+	}))
+	defer server.Close()
+
+	model := models.SupportedModels[models.BedrockLlama33_70B]
+	client := newBedrockLlamaClient(providerClientOptions{
+		model:         model,
+		maxTokens:     1024,
+		systemMessage: "You are a coding assistant.",
+	}, "us-east-1")
+	client.endpoint = server.URL
+	client.credentials = aws.CredentialsProviderFunc(func(context.Context) (aws.Credentials, error) {
+		return aws.Credentials{AccessKeyID: "AKIDTEST", SecretAccessKey: "secret"}, nil
+	})
+
+	view := stubTool{info: tools.ToolInfo{
+		Name:        "view",
+		Description: "View a file",
+		Parameters:  map[string]any{"file_path": map[string]any{"type": "string"}},
+		Required:    []string{"file_path"},
+	}}
+	history := []message.Message{
+		{Role: message.User, Parts: []message.ContentPart{message.TextContent{Text: "read main.go"}}},
+	}
+	var complete *ProviderResponse
+	for event := range client.stream(context.Background(), history, []tools.BaseTool{view}) {
+		require.NoError(t, event.Error)
+		if event.Type == EventComplete {
+			complete = event.Response
+		}
+	}
+
+	assert.Equal(t, int64(1024), received.MaxGenLen)
+	assert.True(t, strings.HasPrefix(received.Prompt, "<|begin_of_text|><|start_header_id|>system<|end_header_id|>\n\nYou are a coding assistant."))
+	assert.Contains(t, received.Prompt, `"name":"view"`)
+	assert.True(t, strings.HasSuffix(received.Prompt, "read main.go<|eot_id|><|start_header_id|>assistant<|end_header_id|>\n\n"))
+
+	require.NotNil(t, complete)
+	assert.Empty(t, complete.Content)
+	require.Len(t, complete.ToolCalls, 1)
+	assert.Equal(t, "view", complete.ToolCalls[0].Name)
+	assert.JSONEq(t, `{"file_path": "main.go"}`, complete.ToolCalls[0].Input)
+	assert.Equal(t, message.FinishReasonToolUse, complete.FinishReason)
+	assert.Equal(t, TokenUsage{InputTokens: 40, OutputTokens: 14}, complete.Usage)
+}
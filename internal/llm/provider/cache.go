@@ -0,0 +1,240 @@
+package provider
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/opencode-ai/opencode/internal/logging"
+	"github.com/opencode-ai/opencode/internal/message"
+)
+
+// WithResponseCache stores the responses of the provider in dir and answers a
+// request that was already answered from the cache, without sending it. The
+// key is the model, the system message, the tools and the conversation, so an
+// identical prompt is not paid twice and sessions can be replayed.
+func WithResponseCache(dir string) ProviderClientOption {
+	return func(options *providerClientOptions) {
+		options.cacheDir = dir
+	}
+}
+
+// cachedResponse is a response as it is stored in the cache.
+type cachedResponse struct {
+	Content      string               `json:"content"`
+	ToolCalls    []message.ToolCall   `json:"tool_calls,omitempty"`
+	FinishReason message.FinishReason `json:"finish_reason"`
+}
+
+// responseCache answers requests with the responses stored in dir. It is
+// consulted before the rate limit, a cached answer costs nothing.
+type responseCache struct {
+	dir string
+	// systemMessage is part of the key, it is not part of the request
+	systemMessage string
+}
+
+// send answers the request from the cache or sends it and stores the answer.
+func (c *responseCache) send(req *Request, send func() (*ProviderResponse, error)) (*ProviderResponse, error) {
+	if c == nil {
+		return send()
+	}
+	key := c.key(req)
+	if response, ok := c.load(key); ok {
+		return response, nil
+	}
+	response, err := send()
+	if err == nil {
+		response.CacheKey = key
+		c.store(key, response)
+	}
+	return response, err
+}
+
+// stream replays the cached answer of the request or streams it and stores
+// the answer once it is complete.
+func (c *responseCache) stream(req *Request, stream func() <-chan ProviderEvent) <-chan ProviderEvent {
+	if c == nil {
+		return stream()
+	}
+	key := c.key(req)
+	if response, ok := c.load(key); ok {
+		return replayResponse(response)
+	}
+	events := stream()
+	relayed := make(chan ProviderEvent)
+	go func() {
+		defer close(relayed)
+		for event := range events {
+			if event.Type == EventComplete && event.Response != nil {
+				event.Response.CacheKey = key
+				c.store(key, event.Response)
+			}
+			relayed <- event
+		}
+	}()
+	return relayed
+}
+
+// key hashes the parts of the request that change the answer. The IDs of the
+// tool calls are numbered in order, as every provider generates its own.
+func (c *responseCache) key(req *Request) string {
+	type cachedPart struct {
+		Type    string `json:"type"`
+		Text    string `json:"text,omitempty"`
+		Name    string `json:"name,omitempty"`
+		Call    string `json:"call,omitempty"`
+		IsError bool   `json:"is_error,omitempty"`
+	}
+	type cachedMessage struct {
+		Role  message.MessageRole `json:"role"`
+		Parts []cachedPart        `json:"parts"`
+	}
+	type cachedTool struct {
+		Name        string         `json:"name"`
+		Description string         `json:"description"`
+		Parameters  map[string]any `json:"parameters"`
+		Required    []string       `json:"required"`
+	}
+
+	callIDs := make(map[string]string)
+	callID := func(id string) string {
+		if _, ok := callIDs[id]; !ok {
+			callIDs[id] = strconv.Itoa(len(callIDs))
+		}
+		return callIDs[id]
+	}
+	messages := make([]cachedMessage, 0, len(req.Messages))
+	for _, msg := range req.Messages {
+		cached := cachedMessage{Role: msg.Role}
+		for _, part := range msg.Parts {
+			switch part := part.(type) {
+			case message.TextContent:
+				cached.Parts = append(cached.Parts, cachedPart{Type: "text", Text: part.Text})
+			case message.ImageURLContent:
+				cached.Parts = append(cached.Parts, cachedPart{Type: "image_url", Text: part.URL})
+			case message.BinaryContent:
+				sum := sha256.Sum256(part.Data)
+				cached.Parts = append(cached.Parts, cachedPart{Type: "binary", Name: part.MIMEType, Text: hex.EncodeToString(sum[:])})
+			case message.ToolCall:
+				cached.Parts = append(cached.Parts, cachedPart{Type: "tool_call", Name: part.Name, Call: callID(part.ID), Text: part.Input})
+			case message.ToolResult:
+				cached.Parts = append(cached.Parts, cachedPart{Type: "tool_result", Name: part.Name, Call: callID(part.ToolCallID), Text: part.Content, IsError: part.IsError})
+			}
+		}
+		messages = append(messages, cached)
+	}
+	requestTools := make([]cachedTool, len(req.Tools))
+	for i, tool := range req.Tools {
+		info := tool.Info()
+		requestTools[i] = cachedTool{Name: info.Name, Description: info.Description, Parameters: info.Parameters, Required: info.Required}
+	}
+
+	data, _ := json.Marshal(struct {
+		Model    string          `json:"model"`
+		System   string          `json:"system"`
+		Tools    []cachedTool    `json:"tools"`
+		Messages []cachedMessage `json:"messages"`
+	}{string(req.Model.ID), c.systemMessage, requestTools, messages})
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func (c *responseCache) path(key string) string {
+	return filepath.Join(c.dir, key[:2], key+".json")
+}
+
+// load returns the cached response of the key. The response has no usage,
+// nothing was paid for it, and new tool call IDs, the calls of the original
+// response may be part of another session.
+func (c *responseCache) load(key string) (*ProviderResponse, bool) {
+	data, err := os.ReadFile(c.path(key))
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			logging.Warn("failed to read cached response", "error", err)
+		}
+		return nil, false
+	}
+	var cached cachedResponse
+	if err := json.Unmarshal(data, &cached); err != nil {
+		logging.Warn("failed to decode cached response", "key", key, "error", err)
+		return nil, false
+	}
+	logging.Debug("Answering from the response cache", "key", key)
+	for i := range cached.ToolCalls {
+		cached.ToolCalls[i].ID = uuid.NewString()
+	}
+	return &ProviderResponse{
+		Content:      cached.Content,
+		ToolCalls:    cached.ToolCalls,
+		FinishReason: cached.FinishReason,
+		FromCache:    true,
+		CacheKey:     key,
+	}, true
+}
+
+// store writes a complete response to the cache, responses that were cut off
+// are not reused.
+func (c *responseCache) store(key string, response *ProviderResponse) {
+	switch response.FinishReason {
+	case "", message.FinishReasonCanceled, message.FinishReasonError, message.FinishReasonUnknown:
+		return
+	}
+	data, err := json.Marshal(cachedResponse{
+		Content:      response.Content,
+		ToolCalls:    response.ToolCalls,
+		FinishReason: response.FinishReason,
+	})
+	if err == nil {
+		err = writeCacheFile(c.path(key), data)
+	}
+	if err != nil {
+		logging.Warn("failed to cache response", "error", err)
+	}
+}
+
+// writeCacheFile writes the file atomically, so concurrent sessions never read
+// a partial response.
+func writeCacheFile(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "response-*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// replayResponse streams a cached response.
+func replayResponse(response *ProviderResponse) <-chan ProviderEvent {
+	events := make(chan ProviderEvent, len(response.ToolCalls)+4)
+	events <- ProviderEvent{Type: EventContentStart}
+	if response.Content != "" {
+		events <- ProviderEvent{Type: EventContentDelta, Content: response.Content}
+	}
+	for _, call := range response.ToolCalls {
+		events <- ProviderEvent{Type: EventToolUseStart, ToolCall: &call}
+	}
+	events <- ProviderEvent{Type: EventContentStop}
+	events <- ProviderEvent{Type: EventComplete, Response: response}
+	close(events)
+	return events
+}
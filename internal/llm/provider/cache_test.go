@@ -0,0 +1,103 @@
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/opencode-ai/opencode/internal/llm/models"
+	"github.com/opencode-ai/opencode/internal/llm/tools"
+	"github.com/opencode-ai/opencode/internal/message"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type countingClient struct {
+	calls int
+}
+
+func (c *countingClient) send(ctx context.Context, messages []message.Message, tools []tools.BaseTool) (*ProviderResponse, error) {
+	c.calls++
+	return &ProviderResponse{
+		Content:      "let me look",
+		ToolCalls:    []message.ToolCall{{ID: "call_1", Name: "view", Input: `{"file_path":"main.go"}`, Finished: true}},
+		Usage:        TokenUsage{InputTokens: 100, OutputTokens: 20},
+		FinishReason: message.FinishReasonToolUse,
+	}, nil
+}
+
+func (c *countingClient) stream(ctx context.Context, messages []message.Message, tools []tools.BaseTool) <-chan ProviderEvent {
+	response, _ := c.send(ctx, messages, tools)
+	return replayResponse(response)
+}
+
+func TestResponseCache(t *testing.T) {
+	client := &countingClient{}
+	p := &baseProvider[*countingClient]{
+		options: providerClientOptions{
+			model: models.Model{ID: "test"},
+			cache: &responseCache{dir: t.TempDir(), systemMessage: "You are a test"},
+		},
+		client: client,
+	}
+	conversation := func(callID string) []message.Message {
+		return []message.Message{
+			{Role: message.User, Parts: []message.ContentPart{message.TextContent{Text: "what is in main.go?"}}},
+			{Role: message.Assistant, Parts: []message.ContentPart{message.ToolCall{ID: callID, Name: "ls", Input: "{}"}}},
+			{Role: message.Tool, Parts: []message.ContentPart{message.ToolResult{ToolCallID: callID, Name: "ls", Content: "main.go"}}},
+		}
+	}
+
+	first, err := p.SendMessages(context.Background(), conversation("toolu_1"), nil)
+	require.NoError(t, err)
+	assert.Equal(t, int64(100), first.Usage.InputTokens)
+
+	// The same conversation from another provider, with other tool call IDs
+	var cached *ProviderResponse
+	for event := range p.StreamResponse(context.Background(), conversation("call_abc"), nil) {
+		if event.Type == EventComplete {
+			cached = event.Response
+		}
+	}
+	require.NotNil(t, cached)
+	assert.Equal(t, 1, client.calls)
+	assert.Equal(t, "let me look", cached.Content)
+	assert.Equal(t, TokenUsage{}, cached.Usage)
+	require.Len(t, cached.ToolCalls, 1)
+	assert.NotEqual(t, "call_1", cached.ToolCalls[0].ID)
+
+	_, err = p.SendMessages(context.Background(), conversation("toolu_1")[:1], nil)
+	require.NoError(t, err)
+	assert.Equal(t, 2, client.calls)
+}
+
+func TestResponseCacheFromCache(t *testing.T) {
+	client := &countingClient{}
+	p := &baseProvider[*countingClient]{
+		options: providerClientOptions{
+			model: models.Model{ID: "test"},
+			cache: &responseCache{dir: t.TempDir()},
+		},
+		client: client,
+	}
+	conversation := []message.Message{
+		{Role: message.User, Parts: []message.ContentPart{message.TextContent{Text: "what is in main.go?"}}},
+	}
+
+	first, err := p.SendMessages(context.Background(), conversation, nil)
+	require.NoError(t, err)
+	assert.False(t, first.FromCache)
+	assert.NotEmpty(t, first.CacheKey)
+
+	hit, err := p.SendMessages(context.Background(), conversation, nil)
+	require.NoError(t, err)
+	assert.True(t, hit.FromCache)
+	assert.Equal(t, first.CacheKey, hit.CacheKey)
+	assert.Equal(t, 1, client.calls)
+
+	// Without the cache the responses have no key
+	p.options.cache = nil
+	uncached, err := p.SendMessages(context.Background(), conversation, nil)
+	require.NoError(t, err)
+	assert.False(t, uncached.FromCache)
+	assert.Empty(t, uncached.CacheKey)
+}
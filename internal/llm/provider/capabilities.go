@@ -0,0 +1,242 @@
+package provider
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/opencode-ai/opencode/internal/llm/models"
+	"github.com/opencode-ai/opencode/internal/llm/tools"
+	"github.com/opencode-ai/opencode/internal/logging"
+	"github.com/opencode-ai/opencode/internal/message"
+)
+
+// capabilityProvider adapts requests to a model that lacks some capabilities:
+// tools are described in the prompt when the model has no tool calling,
+// images are left out for models without vision, tools beyond the limit of
+// the model are dropped and a model that cannot stream answers in one event.
+type capabilityProvider struct {
+	Provider
+	providerName models.ModelProvider
+	options      providerClientOptions
+	capabilities models.Capabilities
+
+	// textTools is the provider with the tools described in its system
+	// message, for the tools in textToolsKey
+	mu           sync.Mutex
+	textTools    Provider
+	textToolsKey string
+}
+
+// adaptToCapabilities wraps a provider whose model does not support all the
+// features the agent uses.
+func adaptToCapabilities(p Provider, providerName models.ModelProvider, options providerClientOptions) Provider {
+	capabilities := models.ModelCapabilities(options.model)
+	if capabilities.SupportsTools && capabilities.SupportsVision && capabilities.SupportsStreaming && capabilities.MaxTools == 0 {
+		return p
+	}
+	return &capabilityProvider{
+		Provider:     p,
+		providerName: providerName,
+		options:      options,
+		capabilities: capabilities,
+	}
+}
+
+// prepare returns the provider, messages and native tools to use for a
+// request, and whether the tools are described in the prompt instead.
+func (c *capabilityProvider) prepare(messages []message.Message, requestTools []tools.BaseTool) (Provider, []message.Message, []tools.BaseTool, bool, error) {
+	if limit := c.capabilities.MaxTools; limit > 0 && len(requestTools) > limit {
+		logging.Warn("model supports fewer tools than configured, dropping the last ones", "model", c.options.model.Name, "limit", limit, "tools", len(requestTools))
+		requestTools = requestTools[:limit]
+	}
+	if !c.capabilities.SupportsVision {
+		messages = withoutImages(messages)
+	}
+	if c.capabilities.SupportsTools {
+		return c.Provider, messages, requestTools, false, nil
+	}
+
+	// Earlier turns may come from a model with tool calling
+	messages = textToolMessages(messages)
+	if len(requestTools) == 0 {
+		return c.Provider, messages, nil, false, nil
+	}
+	p, err := c.textToolsProvider(requestTools)
+	if err != nil {
+		return nil, nil, nil, false, err
+	}
+	return p, messages, nil, true, nil
+}
+
+// textToolsProvider returns a provider with the tools described in its system
+// message. It is created again only when the tools change.
+func (c *capabilityProvider) textToolsProvider(requestTools []tools.BaseTool) (Provider, error) {
+	names := make([]string, len(requestTools))
+	for i, tool := range requestTools {
+		names[i] = tool.Info().Name
+	}
+	key := strings.Join(names, ",")
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.textTools != nil && c.textToolsKey == key {
+		return c.textTools, nil
+	}
+	options := c.options
+	options.systemMessage = strings.TrimSpace(options.systemMessage + "\n\n" + textToolInstructions(requestTools))
+	if options.cache != nil {
+		options.cache = &responseCache{dir: options.cache.dir, systemMessage: options.systemMessage}
+	}
+	p, err := newProvider(c.providerName, options)
+	if err != nil {
+		return nil, err
+	}
+	c.textTools, c.textToolsKey = p, key
+	return p, nil
+}
+
+func (c *capabilityProvider) SendMessages(ctx context.Context, messages []message.Message, requestTools []tools.BaseTool) (*ProviderResponse, error) {
+	p, messages, nativeTools, textTools, err := c.prepare(messages, requestTools)
+	if err != nil {
+		return nil, err
+	}
+	response, err := p.SendMessages(ctx, messages, nativeTools)
+	if err != nil || !textTools {
+		return response, err
+	}
+	response.Content, response.ToolCalls = parseTextToolCalls(response.Content, requestTools)
+	if len(response.ToolCalls) > 0 {
+		response.FinishReason = message.FinishReasonToolUse
+	}
+	return response, nil
+}
+
+func (c *capabilityProvider) StreamResponse(ctx context.Context, messages []message.Message, requestTools []tools.BaseTool) <-chan ProviderEvent {
+	if !c.capabilities.SupportsStreaming {
+		return c.sendAsStream(ctx, messages, requestTools)
+	}
+	p, messages, nativeTools, textTools, err := c.prepare(messages, requestTools)
+	if err != nil {
+		return errorStream(err)
+	}
+	events := p.StreamResponse(ctx, messages, nativeTools)
+	if !textTools {
+		return events
+	}
+	return streamTextToolCalls(events, requestTools)
+}
+
+// sendAsStream sends the request without streaming and replays the answer as
+// stream events.
+func (c *capabilityProvider) sendAsStream(ctx context.Context, messages []message.Message, requestTools []tools.BaseTool) <-chan ProviderEvent {
+	events := make(chan ProviderEvent)
+	go func() {
+		defer close(events)
+		response, err := c.SendMessages(ctx, messages, requestTools)
+		if err != nil {
+			events <- ProviderEvent{Type: EventError, Error: err}
+			return
+		}
+		events <- ProviderEvent{Type: EventContentStart}
+		if response.Content != "" {
+			events <- ProviderEvent{Type: EventContentDelta, Content: response.Content}
+		}
+		for _, call := range response.ToolCalls {
+			events <- ProviderEvent{Type: EventToolUseStart, ToolCall: &call}
+		}
+		events <- ProviderEvent{Type: EventContentStop}
+		events <- ProviderEvent{Type: EventComplete, Response: response}
+	}()
+	return events
+}
+
+// streamTextToolCalls relays a stream and turns the JSON tool calls in its
+// content into tool calls. Lines that may be a call are held back until they
+// are complete, so calls never show up as content.
+func streamTextToolCalls(events <-chan ProviderEvent, requestTools []tools.BaseTool) <-chan ProviderEvent {
+	relayed := make(chan ProviderEvent)
+	go func() {
+		defer close(relayed)
+		var generation, pending strings.Builder
+		atLineStart := true
+		flush := func() {
+			text := pending.String()
+			pending.Reset()
+			if atLineStart {
+				if content, calls := parseTextToolCalls(text, requestTools); len(calls) > 0 {
+					text = content
+				}
+			}
+			if text != "" {
+				relayed <- ProviderEvent{Type: EventContentDelta, Content: text}
+			}
+		}
+		for event := range events {
+			switch event.Type {
+			case EventContentDelta:
+				generation.WriteString(event.Content)
+				for _, r := range event.Content {
+					pending.WriteRune(r)
+					if r == '\n' {
+						flush()
+						atLineStart = true
+					}
+				}
+				// Text that cannot start a call is passed on right away
+				if start := strings.TrimSpace(pending.String()); !atLineStart || (start != "" && !strings.HasPrefix(start, "{")) {
+					flush()
+					atLineStart = false
+				}
+				continue
+			case EventComplete:
+				flush()
+				if event.Response != nil {
+					if event.Response.Content == "" {
+						event.Response.Content = generation.String()
+					}
+					event.Response.Content, event.Response.ToolCalls = parseTextToolCalls(event.Response.Content, requestTools)
+					for _, call := range event.Response.ToolCalls {
+						relayed <- ProviderEvent{Type: EventToolUseStart, ToolCall: &call}
+					}
+					if len(event.Response.ToolCalls) > 0 {
+						event.Response.FinishReason = message.FinishReasonToolUse
+					}
+				}
+			}
+			relayed <- event
+		}
+	}()
+	return relayed
+}
+
+// withoutImages replaces the images of the messages with a note, for models
+// that cannot see them.
+func withoutImages(messages []message.Message) []message.Message {
+	converted := make([]message.Message, 0, len(messages))
+	for _, msg := range messages {
+		var parts []message.ContentPart
+		removed := false
+		for _, part := range msg.Parts {
+			switch part.(type) {
+			case message.BinaryContent, message.ImageURLContent:
+				removed = true
+			default:
+				parts = append(parts, part)
+			}
+		}
+		if removed {
+			parts = append(parts, message.TextContent{Text: "[An image was attached, but this model cannot see images]"})
+			msg.Parts = parts
+		}
+		converted = append(converted, msg)
+	}
+	return converted
+}
+
+func errorStream(err error) <-chan ProviderEvent {
+	events := make(chan ProviderEvent, 1)
+	events <- ProviderEvent{Type: EventError, Error: err}
+	close(events)
+	return events
+}
@@ -0,0 +1,71 @@
+package provider
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/opencode-ai/opencode/internal/llm/models"
+	"github.com/opencode-ai/opencode/internal/llm/tools"
+	"github.com/opencode-ai/opencode/internal/message"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCapabilityProvider_TextTools(t *testing.T) {
+	view := stubTool{info: tools.ToolInfo{Name: "view", Description: "View a file"}}
+	stub := &stubProvider{
+		model: models.Model{ID: "plain"},
+		events: []ProviderEvent{
+			{Type: EventContentStart},
+			{Type: EventContentDelta, Content: "Let me look.\n{\"name\": \"view\", "},
+			{Type: EventContentDelta, Content: `"parameters": {"file_path": "main.go"}}`},
+			{Type: EventComplete, Response: &ProviderResponse{FinishReason: message.FinishReasonEndTurn}},
+		},
+	}
+	p := &capabilityProvider{
+		Provider:     stub,
+		capabilities: models.Capabilities{SupportsStreaming: true},
+		textTools:    stub,
+		textToolsKey: "view",
+	}
+
+	var content strings.Builder
+	var started []message.ToolCall
+	var response *ProviderResponse
+	for event := range p.StreamResponse(context.Background(), nil, []tools.BaseTool{view}) {
+		switch event.Type {
+		case EventContentDelta:
+			content.WriteString(event.Content)
+		case EventToolUseStart:
+			started = append(started, *event.ToolCall)
+		case EventComplete:
+			response = event.Response
+		}
+	}
+	assert.Equal(t, "Let me look.\n", content.String())
+	require.Len(t, started, 1)
+	assert.Equal(t, "view", started[0].Name)
+	require.NotNil(t, response)
+	require.Len(t, response.ToolCalls, 1)
+	assert.JSONEq(t, `{"file_path": "main.go"}`, response.ToolCalls[0].Input)
+	assert.Equal(t, message.FinishReasonToolUse, response.FinishReason)
+	assert.Equal(t, "Let me look.", response.Content)
+}
+
+func TestTextToolMessages(t *testing.T) {
+	converted := textToolMessages([]message.Message{
+		{Role: message.Assistant, Parts: []message.ContentPart{
+			message.TextContent{Text: "Reading it"},
+			message.ToolCall{ID: "call_1", Name: "view", Input: `{"file_path":"main.go"}`},
+		}},
+		{Role: message.Tool, Parts: []message.ContentPart{
+			message.ToolResult{ToolCallID: "call_1", Name: "view", Content: "package main"},
+		}},
+	})
+	require.Len(t, converted, 2)
+	assert.Empty(t, converted[0].ToolCalls())
+	assert.Equal(t, "Reading it\n{\"name\":\"view\",\"parameters\":{\"file_path\":\"main.go\"}}", converted[0].Content().String())
+	assert.Equal(t, message.User, converted[1].Role)
+	assert.Equal(t, "Function view returned:\npackage main", converted[1].Content().String())
+}
@@ -0,0 +1,219 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// copilotClientID is the OAuth app of the Copilot editor plugins, the
+	// token exchange only accepts tokens issued to it
+	copilotClientID = "Iv1.b507a08c87ecfe98"
+
+	copilotDeviceCodeURL  = "https://github.com/login/device/code"
+	copilotAccessTokenURL = "https://github.com/login/oauth/access_token"
+)
+
+// copilotTokenURL exchanges GitHub OAuth tokens for Copilot tokens, tests point
+// it to a local server.
+var copilotTokenURL = "https://api.github.com/copilot_internal/v2/token"
+
+// copilotHeaders identify the client to the Copilot API, which only serves
+// known editor integrations.
+var copilotHeaders = map[string]string{
+	"Editor-Version":         "vscode/1.99.0",
+	"Editor-Plugin-Version":  "copilot-chat/0.26.0",
+	"Copilot-Integration-Id": "vscode-chat",
+	"User-Agent":             "GitHubCopilotChat/0.26.0",
+}
+
+// CopilotDeviceCode is the code the user enters on GitHub to authorize
+// opencode with their Copilot subscription.
+type CopilotDeviceCode struct {
+	DeviceCode      string `json:"device_code"`
+	UserCode        string `json:"user_code"`
+	VerificationURI string `json:"verification_uri"`
+	ExpiresIn       int    `json:"expires_in"`
+	Interval        int    `json:"interval"`
+}
+
+// RequestCopilotDeviceCode starts the device flow of the GitHub login.
+func RequestCopilotDeviceCode(ctx context.Context, client *http.Client) (CopilotDeviceCode, error) {
+	var code CopilotDeviceCode
+	err := copilotPostForm(ctx, client, copilotDeviceCodeURL, url.Values{
+		"client_id": {copilotClientID},
+		"scope":     {"read:user"},
+	}, &code)
+	if err != nil {
+		return CopilotDeviceCode{}, fmt.Errorf("failed to request device code: %w", err)
+	}
+	return code, nil
+}
+
+// PollCopilotAccessToken waits for the user to enter the device code and
+// returns the GitHub OAuth token, which is the API key of the copilot provider.
+func PollCopilotAccessToken(ctx context.Context, client *http.Client, code CopilotDeviceCode) (string, error) {
+	interval := time.Duration(max(code.Interval, 5)) * time.Second
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(code.ExpiresIn)*time.Second)
+	defer cancel()
+	for {
+		select {
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return "", errors.New("the device code expired")
+			}
+			return "", ctx.Err()
+		case <-time.After(interval):
+		}
+
+		var token struct {
+			AccessToken string `json:"access_token"`
+			Error       string `json:"error"`
+			Description string `json:"error_description"`
+		}
+		err := copilotPostForm(ctx, client, copilotAccessTokenURL, url.Values{
+			"client_id":   {copilotClientID},
+			"device_code": {code.DeviceCode},
+			"grant_type":  {"urn:ietf:params:oauth:grant-type:device_code"},
+		}, &token)
+		if err != nil {
+			return "", fmt.Errorf("failed to request access token: %w", err)
+		}
+		switch token.Error {
+		case "":
+			return token.AccessToken, nil
+		case "authorization_pending":
+		case "slow_down":
+			interval += 5 * time.Second
+		default:
+			return "", fmt.Errorf("login failed: %s", token.Description)
+		}
+	}
+}
+
+func copilotPostForm(ctx context.Context, client *http.Client, endpoint string, form url.Values, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("status %d: %s", resp.StatusCode, body)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// copilotTransport authenticates the requests to the Copilot API with a
+// short-lived Copilot token, exchanged for the GitHub OAuth token and renewed
+// before it expires.
+type copilotTransport struct {
+	githubToken string
+	next        http.RoundTripper
+
+	mu        sync.Mutex
+	token     string
+	expiresAt time.Time
+	// endpoint is the API of the plan of the subscription
+	endpoint *url.URL
+}
+
+func newCopilotTransport(githubToken string) func(http.RoundTripper) http.RoundTripper {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return &copilotTransport{githubToken: githubToken, next: next}
+	}
+}
+
+func (t *copilotTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	token, endpoint, err := t.copilotToken(req.Context())
+	if err != nil {
+		return nil, err
+	}
+	req = req.Clone(req.Context())
+	if endpoint != nil {
+		req.URL.Scheme, req.URL.Host = endpoint.Scheme, endpoint.Host
+		req.Host = ""
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	for k, v := range copilotHeaders {
+		req.Header.Set(k, v)
+	}
+	resp, err := t.next.RoundTrip(req)
+	if err == nil && resp.StatusCode == http.StatusUnauthorized {
+		// The token was revoked, the retry exchanges a new one
+		t.mu.Lock()
+		t.token = ""
+		t.mu.Unlock()
+	}
+	return resp, err
+}
+
+// copilotToken returns the current Copilot token, exchanging a new one a
+// minute before it expires.
+func (t *copilotTransport) copilotToken(ctx context.Context) (string, *url.URL, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.token != "" && time.Now().Before(t.expiresAt.Add(-time.Minute)) {
+		return t.token, t.endpoint, nil
+	}
+	if t.githubToken == "" {
+		return "", nil, errors.New("not logged in to GitHub Copilot, run `opencode auth copilot`")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, copilotTokenURL, nil)
+	if err != nil {
+		return "", nil, err
+	}
+	req.Header.Set("Authorization", "token "+t.githubToken)
+	req.Header.Set("Accept", "application/json")
+	for k, v := range copilotHeaders {
+		req.Header.Set(k, v)
+	}
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to get copilot token: %w", err)
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound:
+		return "", nil, fmt.Errorf("failed to get copilot token: status %d, check the Copilot subscription of the account or run `opencode auth copilot` again", resp.StatusCode)
+	default:
+		body, _ := io.ReadAll(resp.Body)
+		return "", nil, fmt.Errorf("failed to get copilot token: status %d: %s", resp.StatusCode, body)
+	}
+
+	var token struct {
+		Token     string `json:"token"`
+		ExpiresAt int64  `json:"expires_at"`
+		Endpoints struct {
+			API string `json:"api"`
+		} `json:"endpoints"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", nil, fmt.Errorf("failed to decode copilot token: %w", err)
+	}
+	t.token, t.expiresAt, t.endpoint = token.Token, time.Unix(token.ExpiresAt, 0), nil
+	if token.Endpoints.API != "" {
+		endpoint, err := url.Parse(token.Endpoints.API)
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid copilot endpoint %q: %w", token.Endpoints.API, err)
+		}
+		t.endpoint = endpoint
+	}
+	return t.token, t.endpoint, nil
+}
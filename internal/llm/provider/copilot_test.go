@@ -0,0 +1,60 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/opencode-ai/opencode/internal/llm/models"
+	"github.com/opencode-ai/opencode/internal/message"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCopilotProvider(t *testing.T) {
+	var exchanges int
+	var authorization, integration string
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/copilot_internal/v2/token":
+			exchanges++
+			assert.Equal(t, "token gho_test", r.Header.Get("Authorization"))
+			// The endpoint of the plan replaces the default API
+			fmt.Fprintf(w, `{"token":"tid=copilot","expires_at":%d,"endpoints":{"api":%q}}`, time.Now().Add(30*time.Minute).Unix(), server.URL)
+		case "/chat/completions":
+			authorization = r.Header.Get("Authorization")
+			integration = r.Header.Get("Copilot-Integration-Id")
+			fmt.Fprintln(w, `{"id":"1","object":"chat.completion","model":"gpt-4.1","choices":[{"index":0,"finish_reason":"stop","message":{"role":"assistant","content":"hi"}}],"usage":{"prompt_tokens":3,"completion_tokens":1}}`)
+		default:
+			t.Errorf("unexpected request %s", r.URL.Path)
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	tokenURL := copilotTokenURL
+	copilotTokenURL = server.URL + "/copilot_internal/v2/token"
+	t.Cleanup(func() { copilotTokenURL = tokenURL })
+
+	p, err := NewProvider(models.ProviderCopilot,
+		WithAPIKey("gho_test"),
+		WithModel(models.SupportedModels[models.CopilotGPT41]),
+		WithRetryPolicy(RetryPolicy{}),
+	)
+	require.NoError(t, err)
+
+	msg := message.Message{Role: message.User}
+	msg.AppendContent("hello")
+	for range 2 {
+		response, err := p.SendMessages(context.Background(), []message.Message{msg}, nil)
+		require.NoError(t, err)
+		assert.Equal(t, "hi", response.Content)
+	}
+	assert.Equal(t, 1, exchanges, "the token is reused until it expires")
+	assert.Equal(t, "Bearer tid=copilot", authorization)
+	assert.Equal(t, "vscode-chat", integration)
+}
@@ -0,0 +1,150 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"syscall"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/openai/openai-go"
+	"github.com/opencode-ai/opencode/internal/llm/models"
+	"github.com/opencode-ai/opencode/internal/llm/tools"
+	"github.com/opencode-ai/opencode/internal/logging"
+	"github.com/opencode-ai/opencode/internal/message"
+)
+
+type fallbackProvider struct {
+	providers []Provider
+}
+
+// NewFallbackProvider returns a provider that sends a turn to the primary
+// provider and, when it is rate limited, failing or not responding, to the
+// fallbacks in order. The model that answered is reported in the Model of the
+// response.
+func NewFallbackProvider(primary Provider, fallbacks ...Provider) Provider {
+	return &fallbackProvider{providers: append([]Provider{primary}, fallbacks...)}
+}
+
+func (f *fallbackProvider) Model() models.Model {
+	return f.providers[0].Model()
+}
+
+func (f *fallbackProvider) SendMessages(ctx context.Context, messages []message.Message, tools []tools.BaseTool) (*ProviderResponse, error) {
+	for i, p := range f.providers {
+		response, err := p.SendMessages(ctx, messages, tools)
+		if err == nil {
+			response.Model = p.Model().ID
+			return response, nil
+		}
+		if i == len(f.providers)-1 || !shouldFallback(ctx, err) {
+			return nil, err
+		}
+		f.warn(i, err)
+	}
+	return nil, errors.New("no provider configured")
+}
+
+func (f *fallbackProvider) StreamResponse(ctx context.Context, messages []message.Message, tools []tools.BaseTool) <-chan ProviderEvent {
+	relayed := make(chan ProviderEvent)
+	go func() {
+		defer close(relayed)
+		for i, p := range f.providers {
+			if !f.stream(ctx, i, p.StreamResponse(ctx, messages, tools), relayed) {
+				return
+			}
+		}
+	}()
+	return relayed
+}
+
+// stream relays the events of one provider and reports whether the turn
+// should be tried on the next provider. Events are held back until the
+// provider produced output, so a provider that fails right away leaves no
+// trace in the conversation.
+func (f *fallbackProvider) stream(ctx context.Context, i int, events <-chan ProviderEvent, relayed chan<- ProviderEvent) bool {
+	var pending []ProviderEvent
+	started := false
+	for event := range events {
+		if !started {
+			if event.Type == EventError && i < len(f.providers)-1 && shouldFallback(ctx, event.Error) {
+				f.warn(i, event.Error)
+				// Let the abandoned stream finish
+				go func() {
+					for range events {
+					}
+				}()
+				return true
+			}
+			if event.Type == EventContentStart {
+				pending = append(pending, event)
+				continue
+			}
+			started = true
+			for _, held := range pending {
+				relayed <- held
+			}
+		}
+		if event.Type == EventComplete && event.Response != nil {
+			event.Response.Model = f.providers[i].Model().ID
+		}
+		relayed <- event
+	}
+	if !started {
+		for _, held := range pending {
+			relayed <- held
+		}
+	}
+	return false
+}
+
+func (f *fallbackProvider) warn(i int, err error) {
+	logging.WarnPersist(fmt.Sprintf("%s failed, trying %s: %s", f.providers[i].Model().Name, f.providers[i+1].Model().Name, err))
+}
+
+// shouldFallback reports whether a failed turn is worth sending to another
+// provider: rate limits, server errors, timeouts and unreachable servers. A
+// request cancelled by the user is not retried.
+func shouldFallback(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if errors.Is(err, ErrNoResponse) || errors.Is(err, context.DeadlineExceeded) ||
+		errors.Is(err, syscall.ECONNREFUSED) || isTransientNetworkError(err) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	status := errorStatusCode(err)
+	return status == http.StatusTooManyRequests || status >= http.StatusInternalServerError
+}
+
+// errorStatusCode returns the HTTP status of a provider error, 0 when the
+// error did not come from a response.
+func errorStatusCode(err error) int {
+	var openaiErr *openai.Error
+	var anthropicErr *anthropic.Error
+	var bedrockErr *bedrockAPIError
+	var ollamaErr *ollamaAPIError
+	var llamacppErr *llamacppAPIError
+	var vertexaiErr *vertexaiAPIError
+	switch {
+	case errors.As(err, &openaiErr):
+		return openaiErr.StatusCode
+	case errors.As(err, &anthropicErr):
+		return anthropicErr.StatusCode
+	case errors.As(err, &bedrockErr):
+		return bedrockErr.StatusCode
+	case errors.As(err, &ollamaErr):
+		return ollamaErr.StatusCode
+	case errors.As(err, &llamacppErr):
+		return llamacppErr.StatusCode
+	case errors.As(err, &vertexaiErr):
+		return vertexaiErr.StatusCode
+	}
+	return 0
+}
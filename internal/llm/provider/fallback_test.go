@@ -0,0 +1,102 @@
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/opencode-ai/opencode/internal/llm/models"
+	"github.com/opencode-ai/opencode/internal/llm/tools"
+	"github.com/opencode-ai/opencode/internal/message"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type stubProvider struct {
+	model  models.Model
+	events []ProviderEvent
+	calls  int
+}
+
+func (s *stubProvider) SendMessages(ctx context.Context, messages []message.Message, tools []tools.BaseTool) (*ProviderResponse, error) {
+	s.calls++
+	for _, event := range s.events {
+		if event.Type == EventError {
+			return nil, event.Error
+		}
+		if event.Type == EventComplete {
+			return event.Response, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *stubProvider) StreamResponse(ctx context.Context, messages []message.Message, tools []tools.BaseTool) <-chan ProviderEvent {
+	s.calls++
+	events := make(chan ProviderEvent)
+	go func() {
+		defer close(events)
+		for _, event := range s.events {
+			events <- event
+		}
+	}()
+	return events
+}
+
+func (s *stubProvider) Model() models.Model {
+	return s.model
+}
+
+func TestFallbackProvider_Stream(t *testing.T) {
+	overloaded := &ollamaAPIError{StatusCode: 503, Message: "overloaded"}
+	primary := &stubProvider{
+		model:  models.Model{ID: "primary", Name: "Primary"},
+		events: []ProviderEvent{{Type: EventContentStart}, {Type: EventError, Error: overloaded}},
+	}
+	secondary := &stubProvider{
+		model: models.Model{ID: "secondary", Name: "Secondary"},
+		events: []ProviderEvent{
+			{Type: EventContentStart},
+			{Type: EventContentDelta, Content: "Hello"},
+			{Type: EventComplete, Response: &ProviderResponse{Content: "Hello"}},
+		},
+	}
+
+	var events []ProviderEvent
+	for event := range NewFallbackProvider(primary, secondary).StreamResponse(context.Background(), nil, nil) {
+		events = append(events, event)
+	}
+	require.Len(t, events, 3)
+	assert.Equal(t, EventContentStart, events[0].Type)
+	assert.Equal(t, "Hello", events[1].Content)
+	assert.Equal(t, models.ModelID("secondary"), events[2].Response.Model)
+
+	// Errors after the first output are not retried elsewhere
+	primary.events = []ProviderEvent{{Type: EventContentDelta, Content: "Hel"}, {Type: EventError, Error: overloaded}}
+	secondary.calls = 0
+	events = nil
+	for event := range NewFallbackProvider(primary, secondary).StreamResponse(context.Background(), nil, nil) {
+		events = append(events, event)
+	}
+	require.Len(t, events, 2)
+	assert.Equal(t, EventError, events[1].Type)
+	assert.Equal(t, 0, secondary.calls)
+}
+
+func TestFallbackProvider_Send(t *testing.T) {
+	primary := &stubProvider{
+		model:  models.Model{ID: "primary"},
+		events: []ProviderEvent{{Type: EventError, Error: &llamacppAPIError{StatusCode: 400, Message: "bad request"}}},
+	}
+	secondary := &stubProvider{
+		model:  models.Model{ID: "secondary"},
+		events: []ProviderEvent{{Type: EventComplete, Response: &ProviderResponse{Content: "Hello"}}},
+	}
+	_, err := NewFallbackProvider(primary, secondary).SendMessages(context.Background(), nil, nil)
+	assert.Error(t, err)
+	assert.Equal(t, 0, secondary.calls)
+
+	primary.events[0].Error = &llamacppAPIError{StatusCode: 429, Message: "rate limited"}
+	response, err := NewFallbackProvider(primary, secondary).SendMessages(context.Background(), nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, models.ModelID("secondary"), response.Model)
+}
@@ -2,11 +2,15 @@ package provider
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"net/http"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/google/generative-ai-go/genai"
@@ -15,12 +19,14 @@ import (
 	"github.com/opencode-ai/opencode/internal/llm/tools"
 	"github.com/opencode-ai/opencode/internal/logging"
 	"github.com/opencode-ai/opencode/internal/message"
+	"google.golang.org/api/googleapi/transport"
 	"google.golang.org/api/iterator"
 	"google.golang.org/api/option"
 )
 
 type geminiOptions struct {
 	disableCache bool
+	cacheTTL     time.Duration
 }
 
 type GeminiOption func(*geminiOptions)
@@ -29,17 +35,38 @@ type geminiClient struct {
 	providerOptions providerClientOptions
 	options         geminiOptions
 	client          *genai.Client
+
+	// The system prompt and the tools are cached on the server, keyed by a
+	// hash of both. A key that could not be cached, usually because it is
+	// below the minimum size, is not tried again.
+	cacheMu     sync.Mutex
+	cache       *genai.CachedContent
+	cacheKey    string
+	cacheFailed string
 }
 
+// geminiCacheTTL is how long the cached context lives without being used.
+const geminiCacheTTL = time.Hour
+
 type GeminiClient ProviderClient
 
 func newGeminiClient(opts providerClientOptions) GeminiClient {
-	geminiOpts := geminiOptions{}
+	geminiOpts := geminiOptions{
+		cacheTTL: geminiCacheTTL,
+	}
 	for _, o := range opts.geminiOptions {
 		o(&geminiOpts)
 	}
 
-	client, err := genai.NewClient(context.Background(), option.WithAPIKey(opts.apiKey))
+	clientOptions := []option.ClientOption{option.WithAPIKey(opts.apiKey)}
+	if opts.customTransport() {
+		// A custom HTTP client replaces the authentication of the SDK, the
+		// key is added by the transport instead
+		clientOptions = append(clientOptions, option.WithHTTPClient(&http.Client{
+			Transport: &transport.APIKey{Key: opts.apiKey, Transport: opts.httpClient().Transport},
+		}))
+	}
+	client, err := genai.NewClient(context.Background(), clientOptions...)
 	if err != nil {
 		logging.Error("Failed to create Gemini client", "error", err)
 		return nil
@@ -57,8 +84,14 @@ func (g *geminiClient) convertMessages(messages []message.Message) []*genai.Cont
 	for _, msg := range messages {
 		switch msg.Role {
 		case message.User:
+			parts := []genai.Part{genai.Text(msg.Content().String())}
+			for _, binary := range msg.BinaryContent() {
+				if strings.HasPrefix(binary.MIMEType, "image/") {
+					parts = append(parts, genai.Blob{MIMEType: binary.MIMEType, Data: binary.Data})
+				}
+			}
 			history = append(history, &genai.Content{
-				Parts: []genai.Part{genai.Text(msg.Content().String())},
+				Parts: parts,
 				Role:  "user",
 			})
 
@@ -140,6 +173,79 @@ func (g *geminiClient) convertTools(tools []tools.BaseTool) []*genai.Tool {
 	return []*genai.Tool{geminiTool}
 }
 
+// generativeModel returns the model for a request. The system prompt and the
+// tools are the same for every request of a session, they are read from the
+// context cache when it is enabled.
+func (g *geminiClient) generativeModel(ctx context.Context, tools []tools.BaseTool) *genai.GenerativeModel {
+	system := &genai.Content{
+		Parts: []genai.Part{
+			genai.Text(g.providerOptions.systemMessage),
+		},
+	}
+	var geminiTools []*genai.Tool
+	if len(tools) > 0 {
+		geminiTools = g.convertTools(tools)
+	}
+
+	var model *genai.GenerativeModel
+	if cached := g.cachedContent(ctx, system, geminiTools); cached != nil {
+		model = g.client.GenerativeModelFromCachedContent(cached)
+	} else {
+		model = g.client.GenerativeModel(g.providerOptions.model.APIModel)
+		model.SystemInstruction = system
+		model.Tools = geminiTools
+	}
+	model.SetMaxOutputTokens(int32(g.providerOptions.maxTokens))
+	return model
+}
+
+// cachedContent returns the cached system prompt and tools, creating the cache
+// when there is none or it is about to expire. It returns nil when caching is
+// disabled or the content cannot be cached.
+func (g *geminiClient) cachedContent(ctx context.Context, system *genai.Content, geminiTools []*genai.Tool) *genai.CachedContent {
+	if g.options.disableCache {
+		return nil
+	}
+	data, err := json.Marshal(struct {
+		Model  string
+		System *genai.Content
+		Tools  []*genai.Tool
+	}{g.providerOptions.model.APIModel, system, geminiTools})
+	if err != nil {
+		return nil
+	}
+	sum := sha256.Sum256(data)
+	key := hex.EncodeToString(sum[:])
+
+	g.cacheMu.Lock()
+	defer g.cacheMu.Unlock()
+	if key == g.cacheFailed {
+		return nil
+	}
+	// Leave a margin so the cache does not expire during the request
+	if g.cache != nil && key == g.cacheKey && time.Until(g.cache.Expiration.ExpireTime) > time.Minute {
+		return g.cache
+	}
+
+	cached, err := g.client.CreateCachedContent(ctx, &genai.CachedContent{
+		Model:             g.providerOptions.model.APIModel,
+		SystemInstruction: system,
+		Tools:             geminiTools,
+		Expiration:        genai.ExpireTimeOrTTL{TTL: g.options.cacheTTL},
+	})
+	if err != nil {
+		logging.Debug("gemini context is not cached", "model", g.providerOptions.model.APIModel, "error", err)
+		g.cacheFailed = key
+		return nil
+	}
+	if cached.Expiration.ExpireTime.IsZero() {
+		cached.Expiration.ExpireTime = time.Now().Add(g.options.cacheTTL)
+	}
+	g.cache = cached
+	g.cacheKey = key
+	return cached
+}
+
 func (g *geminiClient) finishReason(reason genai.FinishReason) message.FinishReason {
 	switch {
 	case reason == genai.FinishReasonStop:
@@ -152,17 +258,7 @@ func (g *geminiClient) finishReason(reason genai.FinishReason) message.FinishRea
 }
 
 func (g *geminiClient) send(ctx context.Context, messages []message.Message, tools []tools.BaseTool) (*ProviderResponse, error) {
-	model := g.client.GenerativeModel(g.providerOptions.model.APIModel)
-	model.SetMaxOutputTokens(int32(g.providerOptions.maxTokens))
-	model.SystemInstruction = &genai.Content{
-		Parts: []genai.Part{
-			genai.Text(g.providerOptions.systemMessage),
-		},
-	}
-	// Convert tools
-	if len(tools) > 0 {
-		model.Tools = g.convertTools(tools)
-	}
+	model := g.generativeModel(ctx, tools)
 
 	// Convert messages
 	geminiMessages := g.convertMessages(messages)
@@ -190,7 +286,7 @@ func (g *geminiClient) send(ctx context.Context, messages []message.Message, too
 				return nil, retryErr
 			}
 			if retry {
-				logging.WarnPersist(fmt.Sprintf("Retrying due to rate limit... attempt %d of %d", attempts, maxRetries), logging.PersistTimeArg, time.Millisecond*time.Duration(after+100))
+				logging.WarnPersist(fmt.Sprintf("Retrying due to rate limit... attempt %d of %d", attempts, g.providerOptions.retryPolicy.MaxAttempts), logging.PersistTimeArg, time.Millisecond*time.Duration(after+100))
 				select {
 				case <-ctx.Done():
 					return nil, ctx.Err()
@@ -239,17 +335,7 @@ func (g *geminiClient) send(ctx context.Context, messages []message.Message, too
 }
 
 func (g *geminiClient) stream(ctx context.Context, messages []message.Message, tools []tools.BaseTool) <-chan ProviderEvent {
-	model := g.client.GenerativeModel(g.providerOptions.model.APIModel)
-	model.SetMaxOutputTokens(int32(g.providerOptions.maxTokens))
-	model.SystemInstruction = &genai.Content{
-		Parts: []genai.Part{
-			genai.Text(g.providerOptions.systemMessage),
-		},
-	}
-	// Convert tools
-	if len(tools) > 0 {
-		model.Tools = g.convertTools(tools)
-	}
+	model := g.generativeModel(ctx, tools)
 
 	// Convert messages
 	geminiMessages := g.convertMessages(messages)
@@ -292,7 +378,7 @@ func (g *geminiClient) stream(ctx context.Context, messages []message.Message, t
 						return
 					}
 					if retry {
-						logging.WarnPersist(fmt.Sprintf("Retrying due to rate limit... attempt %d of %d", attempts, maxRetries), logging.PersistTimeArg, time.Millisecond*time.Duration(after+100))
+						logging.WarnPersist(fmt.Sprintf("Retrying due to rate limit... attempt %d of %d", attempts, g.providerOptions.retryPolicy.MaxAttempts), logging.PersistTimeArg, time.Millisecond*time.Duration(after+100))
 						select {
 						case <-ctx.Done():
 							if ctx.Err() != nil {
@@ -380,9 +466,10 @@ func (g *geminiClient) stream(ctx context.Context, messages []message.Message, t
 }
 
 func (g *geminiClient) shouldRetry(attempts int, err error) (bool, int64, error) {
+	policy := g.providerOptions.retryPolicy
 	// Check if error is a rate limit error
-	if attempts > maxRetries {
-		return false, 0, fmt.Errorf("maximum retry attempts reached for rate limit: %d retries", maxRetries)
+	if attempts > policy.MaxAttempts {
+		return false, 0, fmt.Errorf("maximum retry attempts reached for rate limit: %d retries: %w", policy.MaxAttempts, err)
 	}
 
 	// Gemini doesn't have a standard error type we can check against
@@ -403,12 +490,7 @@ func (g *geminiClient) shouldRetry(attempts int, err error) (bool, int64, error)
 		return false, 0, err
 	}
 
-	// Calculate backoff with jitter
-	backoffMs := 2000 * (1 << (attempts - 1))
-	jitterMs := int(float64(backoffMs) * 0.2)
-	retryMs := backoffMs + jitterMs
-
-	return true, int64(retryMs), nil
+	return true, policy.backoff(attempts, 0).Milliseconds(), nil
 }
 
 func (g *geminiClient) toolCalls(resp *genai.GenerateContentResponse) []message.ToolCall {
@@ -437,11 +519,13 @@ func (g *geminiClient) usage(resp *genai.GenerateContentResponse) TokenUsage {
 		return TokenUsage{}
 	}
 
+	// The prompt token count includes the tokens read from the cache
+	cached := resp.UsageMetadata.CachedContentTokenCount
 	return TokenUsage{
-		InputTokens:         int64(resp.UsageMetadata.PromptTokenCount),
+		InputTokens:         int64(resp.UsageMetadata.PromptTokenCount - cached),
 		OutputTokens:        int64(resp.UsageMetadata.CandidatesTokenCount),
 		CacheCreationTokens: 0, // Not directly provided by Gemini
-		CacheReadTokens:     int64(resp.UsageMetadata.CachedContentTokenCount),
+		CacheReadTokens:     int64(cached),
 	}
 }
 
@@ -451,6 +535,16 @@ func WithGeminiDisableCache() GeminiOption {
 	}
 }
 
+// WithGeminiCacheTTL sets how long the cached system prompt and tools are kept
+// by Gemini.
+func WithGeminiCacheTTL(ttl time.Duration) GeminiOption {
+	return func(options *geminiOptions) {
+		if ttl > 0 {
+			options.cacheTTL = ttl
+		}
+	}
+}
+
 // Helper functions
 func parseJsonToMap(jsonStr string) (map[string]interface{}, error) {
 	var result map[string]interface{}
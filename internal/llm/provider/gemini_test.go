@@ -0,0 +1,141 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/generative-ai-go/genai"
+	"github.com/opencode-ai/opencode/internal/llm/models"
+	"github.com/opencode-ai/opencode/internal/llm/tools"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/option"
+)
+
+// newTestGeminiClient returns a client sending the requests to server. The
+// context cache cannot be created there, the SDK creates it over gRPC.
+func newTestGeminiClient(t *testing.T, server *httptest.Server, opts ...GeminiOption) *geminiClient {
+	t.Helper()
+	client, err := genai.NewClient(context.Background(), option.WithAPIKey("test-key"), option.WithEndpoint(server.URL))
+	require.NoError(t, err)
+	geminiOpts := geminiOptions{cacheTTL: geminiCacheTTL}
+	for _, o := range opts {
+		o(&geminiOpts)
+	}
+	return &geminiClient{
+		providerOptions: providerClientOptions{
+			model:         models.GeminiModels[models.Gemini25Flash],
+			maxTokens:     1024,
+			systemMessage: "You are a coding assistant.",
+		},
+		options: geminiOpts,
+		client:  client,
+	}
+}
+
+func TestGeminiClient_ContextCache(t *testing.T) {
+	var received map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = nil
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		w.Write([]byte(`{"candidates":[{"content":{"role":"model","parts":[{"text":"Done."}]},"finishReason":"STOP"}]}`))
+	}))
+	defer server.Close()
+
+	view := []tools.BaseTool{stubTool{info: tools.ToolInfo{
+		Name:        "view",
+		Description: "View a file",
+		Parameters:  map[string]any{"file_path": map[string]any{"type": "string"}},
+		Required:    []string{"file_path"},
+	}}}
+	generate := func(t *testing.T, g *geminiClient, tools []tools.BaseTool) {
+		t.Helper()
+		_, err := g.generativeModel(context.Background(), tools).GenerateContent(context.Background(), genai.Text("read main.go"))
+		require.NoError(t, err)
+	}
+
+	t.Run("sent with the request when it cannot be cached", func(t *testing.T) {
+		g := newTestGeminiClient(t, server)
+		generate(t, g, view)
+		assert.Contains(t, received, "systemInstruction")
+		assert.Contains(t, received, "tools")
+		assert.NotContains(t, received, "cachedContent")
+		// The content is not cached again
+		require.NotEmpty(t, g.cacheFailed)
+		assert.Nil(t, g.cache)
+	})
+
+	t.Run("read from the cache", func(t *testing.T) {
+		g := newTestGeminiClient(t, server)
+		generate(t, g, view)
+		// Cache the content that failed above
+		g.cacheKey, g.cacheFailed = g.cacheFailed, ""
+		g.cache = &genai.CachedContent{
+			Name:       "cachedContents/session",
+			Model:      g.providerOptions.model.APIModel,
+			Expiration: genai.ExpireTimeOrTTL{ExpireTime: time.Now().Add(time.Hour)},
+		}
+
+		generate(t, g, view)
+		assert.Equal(t, "cachedContents/session", received["cachedContent"])
+		assert.NotContains(t, received, "systemInstruction")
+		assert.NotContains(t, received, "tools")
+
+		// Other tools are a different content
+		generate(t, g, nil)
+		assert.NotContains(t, received, "cachedContent")
+		assert.Contains(t, received, "systemInstruction")
+	})
+
+	t.Run("not read when about to expire", func(t *testing.T) {
+		g := newTestGeminiClient(t, server)
+		generate(t, g, view)
+		g.cacheKey, g.cacheFailed = g.cacheFailed, ""
+		g.cache = &genai.CachedContent{
+			Name:       "cachedContents/session",
+			Model:      g.providerOptions.model.APIModel,
+			Expiration: genai.ExpireTimeOrTTL{ExpireTime: time.Now().Add(30 * time.Second)},
+		}
+
+		generate(t, g, view)
+		assert.NotContains(t, received, "cachedContent")
+		assert.Contains(t, received, "systemInstruction")
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		g := newTestGeminiClient(t, server, WithGeminiDisableCache())
+		generate(t, g, view)
+		assert.Contains(t, received, "systemInstruction")
+		assert.Empty(t, g.cacheFailed)
+	})
+}
+
+func TestGeminiClient_Usage(t *testing.T) {
+	g := &geminiClient{}
+	usage := g.usage(&genai.GenerateContentResponse{UsageMetadata: &genai.UsageMetadata{
+		PromptTokenCount:        1200,
+		CachedContentTokenCount: 1000,
+		CandidatesTokenCount:    50,
+	}})
+	// The tokens read from the cache are not counted twice
+	assert.Equal(t, TokenUsage{InputTokens: 200, OutputTokens: 50, CacheReadTokens: 1000}, usage)
+	assert.Equal(t, TokenUsage{}, g.usage(&genai.GenerateContentResponse{}))
+}
+
+func TestWithGeminiCacheTTL(t *testing.T) {
+	for _, tt := range []struct {
+		ttl, want time.Duration
+	}{
+		{ttl: 10 * time.Minute, want: 10 * time.Minute},
+		{ttl: 0, want: geminiCacheTTL},
+		{ttl: -time.Minute, want: geminiCacheTTL},
+	} {
+		options := geminiOptions{cacheTTL: geminiCacheTTL}
+		WithGeminiCacheTTL(tt.ttl)(&options)
+		assert.Equal(t, tt.want, options.cacheTTL, "ttl %s", tt.ttl)
+	}
+}
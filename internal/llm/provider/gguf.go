@@ -0,0 +1,199 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/opencode-ai/opencode/internal/llm/tools"
+	"github.com/opencode-ai/opencode/internal/message"
+)
+
+type ggufOptions struct {
+	// gpuLayers is the number of layers offloaded to the GPU, -1 for all
+	gpuLayers int
+	// threads is the number of CPU threads, 0 for the llama.cpp default
+	threads int
+}
+
+type GGUFOption func(*ggufOptions)
+
+// ggufChatMessage is a message of the conversation for the chat template of
+// the model.
+type ggufChatMessage struct {
+	Role    string
+	Content string
+}
+
+// ggufGeneration is the outcome of generating a response.
+type ggufGeneration struct {
+	PromptTokens     int64
+	CompletionTokens int64
+	FinishReason     message.FinishReason
+}
+
+// ggufRuntime is a GGUF model loaded in the process. The llama.cpp runtime is
+// only linked into builds with the llama tag, see gguf_llama.go.
+type ggufRuntime interface {
+	// Template renders the conversation with the chat template of the model.
+	Template(messages []ggufChatMessage) (string, error)
+	// Generate completes the prompt with up to maxTokens tokens and passes
+	// every piece of text to emit as it is sampled.
+	Generate(ctx context.Context, prompt string, maxTokens int64, emit func(string)) (ggufGeneration, error)
+}
+
+// ggufRuntimes are the loaded models by file, a model is loaded once and shared
+// by the agents using it.
+var ggufRuntimes = struct {
+	sync.Mutex
+	runtimes map[string]ggufRuntime
+}{runtimes: make(map[string]ggufRuntime)}
+
+func ggufRuntimeFor(path string, contextWindow int64, options ggufOptions) (ggufRuntime, error) {
+	ggufRuntimes.Lock()
+	defer ggufRuntimes.Unlock()
+	if runtime, ok := ggufRuntimes.runtimes[path]; ok {
+		return runtime, nil
+	}
+	runtime, err := loadGGUF(path, contextWindow, options)
+	if err != nil {
+		return nil, err
+	}
+	ggufRuntimes.runtimes[path] = runtime
+	return runtime, nil
+}
+
+type ggufClient struct {
+	providerOptions providerClientOptions
+	options         ggufOptions
+}
+
+type GGUFClient ProviderClient
+
+func newGGUFClient(opts providerClientOptions) GGUFClient {
+	ggufOpts := ggufOptions{
+		gpuLayers: -1,
+	}
+	for _, o := range opts.ggufOptions {
+		o(&ggufOpts)
+	}
+	return &ggufClient{
+		providerOptions: opts,
+		options:         ggufOpts,
+	}
+}
+
+// runtime loads the model on first use, so the model file is only read when an
+// agent talks to it.
+func (g *ggufClient) runtime() (ggufRuntime, error) {
+	model := g.providerOptions.model
+	if model.APIModel == "" {
+		return nil, fmt.Errorf("model %s has no GGUF file", model.ID)
+	}
+	return ggufRuntimeFor(model.APIModel, model.ContextWindow, g.options)
+}
+
+func (g *ggufClient) convertMessages(messages []message.Message) []ggufChatMessage {
+	ggufMessages := []ggufChatMessage{{Role: "system", Content: g.providerOptions.systemMessage}}
+	for _, msg := range messages {
+		switch msg.Role {
+		case message.User:
+			ggufMessages = append(ggufMessages, ggufChatMessage{Role: "user", Content: msg.Content().String()})
+		case message.Assistant:
+			ggufMessages = append(ggufMessages, ggufChatMessage{Role: "assistant", Content: msg.Content().String()})
+		case message.Tool:
+			// Tools are described in the prompt, results are user messages
+			var results strings.Builder
+			for _, result := range msg.ToolResults() {
+				fmt.Fprintf(&results, "Tool result (%s): %s\n\n", toolCallName(messages, result), result.Content)
+			}
+			ggufMessages = append(ggufMessages, ggufChatMessage{Role: "user", Content: strings.TrimSpace(results.String())})
+		}
+	}
+	return ggufMessages
+}
+
+// generate renders the conversation and generates the response, passing the
+// thinking and content deltas to emit.
+func (g *ggufClient) generate(ctx context.Context, messages []message.Message, emit func(thinking, content string)) (*ProviderResponse, error) {
+	runtime, err := g.runtime()
+	if err != nil {
+		return nil, err
+	}
+	prompt, err := runtime.Template(g.convertMessages(messages))
+	if err != nil {
+		return nil, err
+	}
+
+	var thinking, content strings.Builder
+	var splitter thinkSplitter
+	collect := func(t, c string) {
+		thinking.WriteString(t)
+		content.WriteString(c)
+		if emit != nil && (t != "" || c != "") {
+			emit(t, c)
+		}
+	}
+	generation, err := runtime.Generate(ctx, prompt, g.providerOptions.maxTokens, func(piece string) {
+		collect(splitter.feed(piece))
+	})
+	if err != nil {
+		return nil, err
+	}
+	collect(splitter.flush())
+
+	return &ProviderResponse{
+		Content: content.String(),
+		Usage: TokenUsage{
+			InputTokens:  generation.PromptTokens,
+			OutputTokens: generation.CompletionTokens,
+		},
+		FinishReason: generation.FinishReason,
+	}, nil
+}
+
+func (g *ggufClient) send(ctx context.Context, messages []message.Message, tools []tools.BaseTool) (*ProviderResponse, error) {
+	return g.generate(ctx, messages, nil)
+}
+
+func (g *ggufClient) stream(ctx context.Context, messages []message.Message, tools []tools.BaseTool) <-chan ProviderEvent {
+	eventChan := make(chan ProviderEvent)
+
+	go func() {
+		defer close(eventChan)
+		response, err := g.generate(ctx, messages, func(thinking, content string) {
+			if thinking != "" {
+				eventChan <- ProviderEvent{Type: EventThinkingDelta, Thinking: thinking}
+			}
+			if content != "" {
+				eventChan <- ProviderEvent{Type: EventContentDelta, Content: content}
+			}
+		})
+		if err != nil {
+			if ctx.Err() != nil {
+				err = ctx.Err()
+			}
+			eventChan <- ProviderEvent{Type: EventError, Error: err}
+			return
+		}
+		eventChan <- ProviderEvent{Type: EventComplete, Response: response}
+	}()
+
+	return eventChan
+}
+
+// WithGGUFGPULayers sets the number of layers offloaded to the GPU, 0 to run
+// on the CPU only. All layers are offloaded by default.
+func WithGGUFGPULayers(layers int) GGUFOption {
+	return func(options *ggufOptions) {
+		options.gpuLayers = layers
+	}
+}
+
+// WithGGUFThreads sets the number of CPU threads used for inference.
+func WithGGUFThreads(threads int) GGUFOption {
+	return func(options *ggufOptions) {
+		options.threads = threads
+	}
+}
@@ -0,0 +1,215 @@
+//go:build llama
+
+package provider
+
+/*
+#cgo LDFLAGS: -lllama
+#include <stdlib.h>
+#include <llama.h>
+*/
+import "C"
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"unicode/utf8"
+	"unsafe"
+
+	"github.com/opencode-ai/opencode/internal/message"
+)
+
+// llamaAllLayers offloads every layer of the model to the GPU.
+const llamaAllLayers = 999
+
+var llamaBackend sync.Once
+
+// llamaRuntime is a model loaded with the llama.cpp C API. A context is
+// created for every response, so responses never share a KV cache, and
+// generation is serialized per model.
+type llamaRuntime struct {
+	mu      sync.Mutex
+	model   *C.struct_llama_model
+	vocab   *C.struct_llama_vocab
+	nCtx    int
+	threads int
+	// template is the chat template of the model, nil for the chatml default
+	template *C.char
+}
+
+// loadGGUF loads a model file. The model stays loaded for the lifetime of the
+// process.
+func loadGGUF(path string, contextWindow int64, options ggufOptions) (ggufRuntime, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("failed to open GGUF model: %w", err)
+	}
+	llamaBackend.Do(func() {
+		C.llama_backend_init()
+	})
+
+	params := C.llama_model_default_params()
+	params.n_gpu_layers = C.int32_t(options.gpuLayers)
+	if options.gpuLayers < 0 {
+		params.n_gpu_layers = llamaAllLayers
+	}
+	cpath := C.CString(path)
+	defer C.free(unsafe.Pointer(cpath))
+	model := C.llama_model_load_from_file(cpath, params)
+	if model == nil {
+		return nil, fmt.Errorf("failed to load GGUF model %s", path)
+	}
+
+	nCtx := int(C.llama_model_n_ctx_train(model))
+	if contextWindow > 0 && (nCtx <= 0 || int(contextWindow) < nCtx) {
+		nCtx = int(contextWindow)
+	}
+	return &llamaRuntime{
+		model:    model,
+		vocab:    C.llama_model_get_vocab(model),
+		nCtx:     nCtx,
+		threads:  options.threads,
+		template: C.llama_model_chat_template(model, nil),
+	}, nil
+}
+
+func (r *llamaRuntime) Template(messages []ggufChatMessage) (string, error) {
+	chat := (*C.struct_llama_chat_message)(C.malloc(C.size_t(len(messages)) * C.size_t(unsafe.Sizeof(C.struct_llama_chat_message{}))))
+	defer C.free(unsafe.Pointer(chat))
+	chatMessages := unsafe.Slice(chat, len(messages))
+	size := 0
+	for i, msg := range messages {
+		role, content := C.CString(msg.Role), C.CString(msg.Content)
+		defer C.free(unsafe.Pointer(role))
+		defer C.free(unsafe.Pointer(content))
+		chatMessages[i].role, chatMessages[i].content = role, content
+		size += len(msg.Role) + len(msg.Content)
+	}
+
+	// The template adds its markup to the messages, grow the buffer when it
+	// needs more
+	size = 2*size + 1024
+	for {
+		buf := (*C.char)(C.malloc(C.size_t(size)))
+		n := int(C.llama_chat_apply_template(r.template, chat, C.size_t(len(messages)), true, buf, C.int32_t(size)))
+		if n < 0 {
+			C.free(unsafe.Pointer(buf))
+			return "", errors.New("the chat template of the model is not supported by llama.cpp")
+		}
+		if n <= size {
+			prompt := C.GoStringN(buf, C.int(n))
+			C.free(unsafe.Pointer(buf))
+			return prompt, nil
+		}
+		C.free(unsafe.Pointer(buf))
+		size = n
+	}
+}
+
+func (r *llamaRuntime) Generate(ctx context.Context, prompt string, maxTokens int64, emit func(string)) (ggufGeneration, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	params := C.llama_context_default_params()
+	params.n_ctx = C.uint32_t(r.nCtx)
+	if r.threads > 0 {
+		params.n_threads = C.int32_t(r.threads)
+		params.n_threads_batch = C.int32_t(r.threads)
+	}
+	lctx := C.llama_init_from_model(r.model, params)
+	if lctx == nil {
+		return ggufGeneration{}, errors.New("failed to create llama.cpp context, the context window may not fit in memory")
+	}
+	defer C.llama_free(lctx)
+
+	tokens, err := r.tokenize(prompt)
+	if err != nil {
+		return ggufGeneration{}, err
+	}
+	defer C.free(unsafe.Pointer(unsafe.SliceData(tokens)))
+	if len(tokens) >= r.nCtx {
+		return ggufGeneration{}, fmt.Errorf("the prompt has %d tokens, more than the context window of %d", len(tokens), r.nCtx)
+	}
+	generation := ggufGeneration{PromptTokens: int64(len(tokens))}
+
+	nBatch := int(C.llama_n_batch(lctx))
+	for i := 0; i < len(tokens); i += nBatch {
+		if err := ctx.Err(); err != nil {
+			return generation, err
+		}
+		n := min(nBatch, len(tokens)-i)
+		if C.llama_decode(lctx, C.llama_batch_get_one(&tokens[i], C.int32_t(n))) != 0 {
+			return generation, errors.New("llama.cpp failed to evaluate the prompt")
+		}
+	}
+
+	sampler := C.llama_sampler_chain_init(C.llama_sampler_chain_default_params())
+	defer C.llama_sampler_free(sampler)
+	C.llama_sampler_chain_add(sampler, C.llama_sampler_init_min_p(0.05, 1))
+	C.llama_sampler_chain_add(sampler, C.llama_sampler_init_temp(0.2))
+	C.llama_sampler_chain_add(sampler, C.llama_sampler_init_dist(C.LLAMA_DEFAULT_SEED))
+
+	// A piece may end inside a multi-byte character, it is held back until
+	// the character is complete
+	token := (*C.llama_token)(C.malloc(C.size_t(unsafe.Sizeof(C.llama_token(0)))))
+	defer C.free(unsafe.Pointer(token))
+	var pending []byte
+	position := len(tokens)
+	generation.FinishReason = message.FinishReasonMaxTokens
+	for maxTokens <= 0 || generation.CompletionTokens < maxTokens {
+		if err := ctx.Err(); err != nil {
+			return generation, err
+		}
+		if position >= r.nCtx {
+			break
+		}
+		*token = C.llama_sampler_sample(sampler, lctx, -1)
+		if C.llama_vocab_is_eog(r.vocab, *token) {
+			generation.FinishReason = message.FinishReasonEndTurn
+			break
+		}
+		pending = append(pending, r.piece(*token)...)
+		if utf8.Valid(pending) || len(pending) >= utf8.UTFMax {
+			emit(string(pending))
+			pending = pending[:0]
+		}
+		generation.CompletionTokens++
+
+		if C.llama_decode(lctx, C.llama_batch_get_one(token, 1)) != 0 {
+			return generation, errors.New("llama.cpp failed to evaluate the response")
+		}
+		position++
+	}
+	if len(pending) > 0 {
+		emit(string(pending))
+	}
+	return generation, nil
+}
+
+// tokenize returns the tokens of the prompt in C memory, the caller frees them.
+func (r *llamaRuntime) tokenize(prompt string) ([]C.llama_token, error) {
+	text := C.CString(prompt)
+	defer C.free(unsafe.Pointer(text))
+	// The count is returned negated when the buffer is too small
+	n := -int(C.llama_tokenize(r.vocab, text, C.int32_t(len(prompt)), nil, 0, true, true))
+	if n <= 0 {
+		return nil, errors.New("failed to tokenize the prompt")
+	}
+	buf := (*C.llama_token)(C.malloc(C.size_t(n) * C.size_t(unsafe.Sizeof(C.llama_token(0)))))
+	if C.llama_tokenize(r.vocab, text, C.int32_t(len(prompt)), buf, C.int32_t(n), true, true) < 0 {
+		C.free(unsafe.Pointer(buf))
+		return nil, errors.New("failed to tokenize the prompt")
+	}
+	return unsafe.Slice(buf, n), nil
+}
+
+// piece returns the text of a token, control tokens have none.
+func (r *llamaRuntime) piece(token C.llama_token) []byte {
+	var buf [256]C.char
+	n := C.llama_token_to_piece(r.vocab, token, &buf[0], C.int32_t(len(buf)), 0, false)
+	if n <= 0 {
+		return nil
+	}
+	return C.GoBytes(unsafe.Pointer(&buf[0]), n)
+}
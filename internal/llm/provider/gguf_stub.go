@@ -0,0 +1,10 @@
+//go:build !llama
+
+package provider
+
+import "errors"
+
+// loadGGUF fails in builds without the llama.cpp runtime.
+func loadGGUF(path string, contextWindow int64, options ggufOptions) (ggufRuntime, error) {
+	return nil, errors.New("in-process GGUF inference is not built in, build opencode with `-tags llama` against an installed llama.cpp")
+}
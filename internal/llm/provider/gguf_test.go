@@ -0,0 +1,93 @@
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/opencode-ai/opencode/internal/llm/models"
+	"github.com/opencode-ai/opencode/internal/llm/tools"
+	"github.com/opencode-ai/opencode/internal/message"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeGGUFRuntime struct {
+	messages []ggufChatMessage
+	pieces   []string
+}
+
+func (f *fakeGGUFRuntime) Template(messages []ggufChatMessage) (string, error) {
+	f.messages = messages
+	return "prompt", nil
+}
+
+func (f *fakeGGUFRuntime) Generate(ctx context.Context, prompt string, maxTokens int64, emit func(string)) (ggufGeneration, error) {
+	for _, piece := range f.pieces {
+		emit(piece)
+	}
+	return ggufGeneration{PromptTokens: 20, CompletionTokens: int64(len(f.pieces)), FinishReason: message.FinishReasonEndTurn}, nil
+}
+
+func TestGGUFProvider_TextToolCalls(t *testing.T) {
+	const path = "/models/test.gguf"
+	runtime := &fakeGGUFRuntime{pieces: []string{"<think>look", "</think>", `{"name": "ls", `, `"parameters": {"path": "."}}`}}
+	ggufRuntimes.Lock()
+	ggufRuntimes.runtimes[path] = runtime
+	ggufRuntimes.Unlock()
+	t.Cleanup(func() {
+		ggufRuntimes.Lock()
+		delete(ggufRuntimes.runtimes, path)
+		ggufRuntimes.Unlock()
+	})
+
+	p, err := NewProvider(models.ProviderGGUF,
+		WithModel(models.GGUFModel("", path)),
+		WithSystemMessage("system"),
+	)
+	require.NoError(t, err)
+
+	history := []message.Message{
+		{Role: message.User, Parts: []message.ContentPart{message.TextContent{Text: "list"}}},
+		{Role: message.Assistant, Parts: []message.ContentPart{message.ToolCall{ID: "call_1", Name: "ls", Input: `{"path":"/"}`}}},
+		{Role: message.Tool, Parts: []message.ContentPart{message.ToolResult{ToolCallID: "call_1", Name: "ls", Content: "a.txt"}}},
+	}
+	lsTool := stubTool{info: tools.ToolInfo{
+		Name:        "ls",
+		Description: "list files",
+		Parameters:  map[string]any{"path": map[string]any{"type": "string"}},
+		Required:    []string{"path"},
+	}}
+
+	var thinking string
+	var complete *ProviderResponse
+	for event := range p.StreamResponse(context.Background(), history, []tools.BaseTool{lsTool}) {
+		require.NoError(t, event.Error)
+		switch event.Type {
+		case EventThinkingDelta:
+			thinking += event.Thinking
+		case EventComplete:
+			complete = event.Response
+		}
+	}
+
+	require.Len(t, runtime.messages, 4)
+	assert.Equal(t, "system", runtime.messages[0].Role)
+	assert.Contains(t, runtime.messages[0].Content, "list files")
+	assert.Equal(t, "user", runtime.messages[3].Role)
+	assert.Equal(t, "Function ls returned:\na.txt", runtime.messages[3].Content)
+
+	assert.Equal(t, "look", thinking)
+	require.NotNil(t, complete)
+	require.Len(t, complete.ToolCalls, 1)
+	assert.Equal(t, "ls", complete.ToolCalls[0].Name)
+	assert.JSONEq(t, `{"path": "."}`, complete.ToolCalls[0].Input)
+	assert.Equal(t, message.FinishReasonToolUse, complete.FinishReason)
+	assert.Equal(t, int64(20), complete.Usage.InputTokens)
+}
+
+func TestGGUFProvider_NotBuiltIn(t *testing.T) {
+	p, err := NewProvider(models.ProviderGGUF, WithModel(models.GGUFModel("", "/models/missing.gguf")))
+	require.NoError(t, err)
+	_, err = p.SendMessages(context.Background(), nil, nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,467 @@
+package provider
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/opencode-ai/opencode/internal/llm/models"
+	"github.com/opencode-ai/opencode/internal/llm/tools"
+	"github.com/opencode-ai/opencode/internal/logging"
+	"github.com/opencode-ai/opencode/internal/message"
+)
+
+type llamacppOptions struct {
+	baseURL string
+	headers map[string]string
+	// grammar is a GBNF grammar that constrains responses without tool calls
+	grammar string
+}
+
+type LlamaCppOption func(*llamacppOptions)
+
+type llamacppClient struct {
+	providerOptions providerClientOptions
+	options         llamacppOptions
+	client          *http.Client
+}
+
+type LlamaCppClient ProviderClient
+
+type llamacppMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type llamacppCompletionRequest struct {
+	Prompt      string `json:"prompt"`
+	NPredict    int64  `json:"n_predict,omitempty"`
+	Stream      bool   `json:"stream"`
+	CachePrompt bool   `json:"cache_prompt"`
+	Grammar     string `json:"grammar,omitempty"`
+	JSONSchema  any    `json:"json_schema,omitempty"`
+}
+
+type llamacppCompletionResponse struct {
+	Content         string `json:"content"`
+	Stop            bool   `json:"stop"`
+	StopType        string `json:"stop_type"`
+	TokensEvaluated int64  `json:"tokens_evaluated"`
+	TokensPredicted int64  `json:"tokens_predicted"`
+}
+
+// llamacppToolResponse is the JSON envelope the model answers with when tools
+// are offered. The native API has no tool calling, so the envelope is enforced
+// with a JSON schema that llama.cpp compiles into a grammar.
+type llamacppToolResponse struct {
+	Content   string `json:"content"`
+	ToolCalls []struct {
+		Name      string          `json:"name"`
+		Arguments json.RawMessage `json:"arguments"`
+	} `json:"tool_calls"`
+}
+
+// llamacppAPIError is returned when the llama.cpp server answers with a non 2xx status.
+type llamacppAPIError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *llamacppAPIError) Error() string {
+	return fmt.Sprintf("llama.cpp: %s (status %d)", e.Message, e.StatusCode)
+}
+
+func newLlamaCppClient(opts providerClientOptions) LlamaCppClient {
+	llamacppOpts := llamacppOptions{}
+	for _, o := range opts.llamacppOptions {
+		o(&llamacppOpts)
+	}
+	llamacppOpts.baseURL = models.LlamaCppBaseURL(llamacppOpts.baseURL)
+
+	return &llamacppClient{
+		providerOptions: opts,
+		options:         llamacppOpts,
+		client:          opts.httpClient(),
+	}
+}
+
+func (l *llamacppClient) convertMessages(messages []message.Message, tools []tools.BaseTool) []llamacppMessage {
+	system := l.providerOptions.systemMessage
+	if len(tools) > 0 {
+		system += "\n\n" + llamacppToolInstructions(tools)
+	}
+	llamacppMessages := []llamacppMessage{{Role: "system", Content: system}}
+
+	for _, msg := range messages {
+		switch msg.Role {
+		case message.User:
+			llamacppMessages = append(llamacppMessages, llamacppMessage{
+				Role:    "user",
+				Content: msg.Content().String(),
+			})
+
+		case message.Assistant:
+			content := msg.Content().String()
+			if len(msg.ToolCalls()) > 0 {
+				// Keep past tool calls in the format the model is asked to answer in
+				var calls []map[string]any
+				for _, call := range msg.ToolCalls() {
+					args := json.RawMessage(call.Input)
+					if !json.Valid(args) {
+						args = json.RawMessage("{}")
+					}
+					calls = append(calls, map[string]any{"name": call.Name, "arguments": args})
+				}
+				data, _ := json.Marshal(map[string]any{"content": content, "tool_calls": calls})
+				content = string(data)
+			}
+			llamacppMessages = append(llamacppMessages, llamacppMessage{
+				Role:    "assistant",
+				Content: content,
+			})
+
+		case message.Tool:
+			// Chat templates of small models rarely support a tool role
+			var results strings.Builder
+			for _, result := range msg.ToolResults() {
+				fmt.Fprintf(&results, "Tool result (%s): %s\n\n", toolCallName(messages, result), result.Content)
+			}
+			llamacppMessages = append(llamacppMessages, llamacppMessage{
+				Role:    "user",
+				Content: strings.TrimSpace(results.String()),
+			})
+		}
+	}
+
+	return llamacppMessages
+}
+
+// llamacppToolInstructions describes the tools and the response format.
+func llamacppToolInstructions(tools []tools.BaseTool) string {
+	var b strings.Builder
+	b.WriteString("You can call the following tools:\n")
+	for _, tool := range tools {
+		info := tool.Info()
+		params, _ := json.Marshal(info.Parameters)
+		fmt.Fprintf(&b, "- %s: %s Parameters: %s\n", info.Name, info.Description, params)
+	}
+	b.WriteString(`Always answer with a JSON object {"content": "<your reply>", "tool_calls": [{"name": "<tool>", "arguments": {...}}]}. Leave tool_calls empty when no tool is needed.`)
+	return b.String()
+}
+
+// llamacppToolSchema returns the JSON schema for the tool call envelope. Every tool
+// call is restricted to a known tool name with arguments matching its schema.
+func llamacppToolSchema(tools []tools.BaseTool) map[string]any {
+	calls := make([]any, 0, len(tools))
+	for _, tool := range tools {
+		info := tool.Info()
+		required := info.Required
+		if required == nil {
+			required = []string{}
+		}
+		calls = append(calls, map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"name": map[string]any{"const": info.Name},
+				"arguments": map[string]any{
+					"type":       "object",
+					"properties": info.Parameters,
+					"required":   required,
+				},
+			},
+			"required": []string{"name", "arguments"},
+		})
+	}
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"content": map[string]any{"type": "string"},
+			"tool_calls": map[string]any{
+				"type":  "array",
+				"items": map[string]any{"oneOf": calls},
+			},
+		},
+		"required": []string{"content", "tool_calls"},
+	}
+}
+
+// preparedRequest renders the conversation with the chat template of the
+// loaded model using /apply-template, and builds the completion request.
+func (l *llamacppClient) preparedRequest(ctx context.Context, messages []message.Message, tools []tools.BaseTool, stream bool) (llamacppCompletionRequest, error) {
+	resp, err := l.post(ctx, "/apply-template", map[string]any{
+		"messages": l.convertMessages(messages, tools),
+	})
+	if err != nil {
+		return llamacppCompletionRequest{}, err
+	}
+	defer resp.Body.Close()
+
+	var rendered struct {
+		Prompt string `json:"prompt"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&rendered); err != nil {
+		return llamacppCompletionRequest{}, fmt.Errorf("failed to decode rendered prompt: %w", err)
+	}
+
+	request := llamacppCompletionRequest{
+		Prompt:      rendered.Prompt,
+		NPredict:    l.providerOptions.maxTokens,
+		Stream:      stream,
+		CachePrompt: true,
+	}
+	if len(tools) > 0 {
+		request.JSONSchema = llamacppToolSchema(tools)
+	} else {
+		request.Grammar = l.options.grammar
+	}
+	return request, nil
+}
+
+func (l *llamacppClient) post(ctx context.Context, path string, payload any) (*http.Response, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	var resp *http.Response
+	err = l.providerOptions.retryPolicy.do(ctx, func() error {
+		var err error
+		resp, err = l.postOnce(ctx, path, body)
+		return err
+	}, l.shouldRetry)
+	return resp, err
+}
+
+func (l *llamacppClient) shouldRetry(err error) (bool, time.Duration) {
+	var apiErr *llamacppAPIError
+	if errors.As(err, &apiErr) {
+		return l.providerOptions.retryPolicy.retryableStatus(apiErr.StatusCode), 0
+	}
+	return isTransientNetworkError(err), 0
+}
+
+func (l *llamacppClient) postOnce(ctx context.Context, path string, body []byte) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, l.options.baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if l.providerOptions.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+l.providerOptions.apiKey)
+	}
+	for k, v := range l.options.headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := l.client.Do(req)
+	if err != nil {
+		var opErr *net.OpError
+		if errors.As(err, &opErr) && opErr.Op == "dial" {
+			return nil, fmt.Errorf("llama.cpp server not running at %s — start it or change providers.llamacpp.baseURL: %w", l.options.baseURL, err)
+		}
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		defer resp.Body.Close()
+		return nil, l.apiError(resp)
+	}
+	return resp, nil
+}
+
+func (l *llamacppClient) apiError(resp *http.Response) error {
+	data, _ := io.ReadAll(resp.Body)
+	var errResp struct {
+		Error struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	msg := strings.TrimSpace(string(data))
+	if err := json.Unmarshal(data, &errResp); err == nil && errResp.Error.Message != "" {
+		msg = errResp.Error.Message
+	}
+	if msg == "" {
+		msg = resp.Status
+	}
+	return &llamacppAPIError{StatusCode: resp.StatusCode, Message: msg}
+}
+
+// response turns the generated text into content and tool calls.
+func (l *llamacppClient) response(text string, final llamacppCompletionResponse, tools []tools.BaseTool) *ProviderResponse {
+	var splitter thinkSplitter
+	_, content := splitter.feed(text)
+	_, rest := splitter.flush()
+	content += rest
+
+	var toolCalls []message.ToolCall
+	if len(tools) > 0 {
+		var envelope llamacppToolResponse
+		if err := json.Unmarshal([]byte(content), &envelope); err != nil {
+			logging.Warn("llama.cpp response is not a valid tool call envelope", "error", err)
+		} else {
+			content = envelope.Content
+			for _, call := range envelope.ToolCalls {
+				input := string(call.Arguments)
+				if input == "" || input == "null" {
+					input = "{}"
+				}
+				toolCalls = append(toolCalls, message.ToolCall{
+					ID:       "call_" + uuid.New().String(),
+					Name:     call.Name,
+					Input:    input,
+					Type:     "function",
+					Finished: true,
+				})
+			}
+		}
+	}
+
+	return &ProviderResponse{
+		Content:   content,
+		ToolCalls: toolCalls,
+		Usage: TokenUsage{
+			InputTokens:  final.TokensEvaluated,
+			OutputTokens: final.TokensPredicted,
+		},
+		FinishReason: l.finishReason(final.StopType, toolCalls),
+	}
+}
+
+func (l *llamacppClient) finishReason(stopType string, toolCalls []message.ToolCall) message.FinishReason {
+	if len(toolCalls) > 0 {
+		return message.FinishReasonToolUse
+	}
+	switch stopType {
+	case "eos", "word":
+		return message.FinishReasonEndTurn
+	case "limit":
+		return message.FinishReasonMaxTokens
+	default:
+		return message.FinishReasonUnknown
+	}
+}
+
+func (l *llamacppClient) send(ctx context.Context, messages []message.Message, tools []tools.BaseTool) (*ProviderResponse, error) {
+	request, err := l.preparedRequest(ctx, messages, tools, false)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := l.post(ctx, "/completion", request)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var completion llamacppCompletionResponse
+	if err := json.NewDecoder(resp.Body).Decode(&completion); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return l.response(completion.Content, completion, tools), nil
+}
+
+func (l *llamacppClient) stream(ctx context.Context, messages []message.Message, tools []tools.BaseTool) <-chan ProviderEvent {
+	eventChan := make(chan ProviderEvent)
+
+	go func() {
+		defer close(eventChan)
+		fail := func(err error) {
+			if ctx.Err() != nil {
+				err = ctx.Err()
+			}
+			eventChan <- ProviderEvent{Type: EventError, Error: err}
+		}
+
+		request, err := l.preparedRequest(ctx, messages, tools, true)
+		if err != nil {
+			fail(err)
+			return
+		}
+		resp, err := l.post(ctx, "/completion", request)
+		if err != nil {
+			fail(err)
+			return
+		}
+		defer resp.Body.Close()
+
+		var text strings.Builder
+		var splitter thinkSplitter
+		emit := func(thinking, content string) {
+			if thinking != "" {
+				eventChan <- ProviderEvent{Type: EventThinkingDelta, Thinking: thinking}
+			}
+			if content != "" {
+				eventChan <- ProviderEvent{Type: EventContentDelta, Content: content}
+			}
+		}
+
+		scanner := bufio.NewScanner(resp.Body)
+		scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+		for scanner.Scan() {
+			data, ok := bytes.CutPrefix(bytes.TrimSpace(scanner.Bytes()), []byte("data: "))
+			if !ok || len(data) == 0 {
+				continue
+			}
+
+			var chunk llamacppCompletionResponse
+			if err := json.Unmarshal(data, &chunk); err != nil {
+				logging.Warn("Error decoding llama.cpp stream chunk", "error", err)
+				continue
+			}
+			text.WriteString(chunk.Content)
+			// The tool call envelope is only meaningful once complete
+			if len(tools) == 0 {
+				emit(splitter.feed(chunk.Content))
+			}
+
+			if chunk.Stop {
+				response := l.response(text.String(), chunk, tools)
+				if len(tools) == 0 {
+					emit(splitter.flush())
+				} else {
+					emit("", response.Content)
+					for _, call := range response.ToolCalls {
+						eventChan <- ProviderEvent{Type: EventToolUseStart, ToolCall: &call}
+					}
+				}
+				eventChan <- ProviderEvent{Type: EventComplete, Response: response}
+				return
+			}
+		}
+
+		err = scanner.Err()
+		if err == nil {
+			err = io.ErrUnexpectedEOF
+		}
+		fail(err)
+	}()
+
+	return eventChan
+}
+
+func WithLlamaCppBaseURL(baseURL string) LlamaCppOption {
+	return func(options *llamacppOptions) {
+		options.baseURL = baseURL
+	}
+}
+
+func WithLlamaCppHeaders(headers map[string]string) LlamaCppOption {
+	return func(options *llamacppOptions) {
+		options.headers = headers
+	}
+}
+
+// WithLlamaCppGrammar constrains responses without tool calls to a GBNF
+// grammar. Responses with tools always follow the tool call schema.
+func WithLlamaCppGrammar(grammar string) LlamaCppOption {
+	return func(options *llamacppOptions) {
+		options.grammar = grammar
+	}
+}
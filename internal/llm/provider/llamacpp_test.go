@@ -0,0 +1,100 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/opencode-ai/opencode/internal/llm/models"
+	"github.com/opencode-ai/opencode/internal/llm/tools"
+	"github.com/opencode-ai/opencode/internal/message"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestLlamaCppServer(t *testing.T, chunks []string, received *llamacppCompletionRequest) *llamacppClient {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/apply-template":
+			fmt.Fprintln(w, `{"prompt":"<|user|>read main.go<|assistant|>"}`)
+		case "/completion":
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(received))
+			if !received.Stream {
+				fmt.Fprintln(w, chunks[len(chunks)-1])
+				return
+			}
+			for _, chunk := range chunks {
+				fmt.Fprintf(w, "data: %s\n\n", chunk)
+			}
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	return newLlamaCppClient(providerClientOptions{
+		model:           models.LlamaCppModel("qwen2.5-coder-7b"),
+		maxTokens:       512,
+		llamacppOptions: []LlamaCppOption{WithLlamaCppBaseURL(server.URL), WithLlamaCppGrammar(`root ::= "yes" | "no"`)},
+	}).(*llamacppClient)
+}
+
+func TestLlamaCppClient_ToolCalls(t *testing.T) {
+	var received llamacppCompletionRequest
+	client := newTestLlamaCppServer(t, []string{
+		`{"content":"{\"content\": \"Reading\", \"tool_calls\": [{\"name\": \"view\", ","stop":false}`,
+		`{"content":"\"arguments\": {\"file_path\": \"main.go\"}}]}","stop":false}`,
+		`{"content":"","stop":true,"stop_type":"eos","tokens_evaluated":12,"tokens_predicted":20}`,
+	}, &received)
+
+	view := stubTool{info: tools.ToolInfo{
+		Name:        "view",
+		Description: "View a file",
+		Parameters:  map[string]any{"file_path": map[string]any{"type": "string"}},
+		Required:    []string{"file_path"},
+	}}
+	history := []message.Message{
+		{Role: message.User, Parts: []message.ContentPart{message.TextContent{Text: "read main.go"}}},
+	}
+	var content string
+	var complete *ProviderResponse
+	for event := range client.stream(context.Background(), history, []tools.BaseTool{view}) {
+		require.NoError(t, event.Error)
+		switch event.Type {
+		case EventContentDelta:
+			content += event.Content
+		case EventComplete:
+			complete = event.Response
+		}
+	}
+
+	assert.Equal(t, "<|user|>read main.go<|assistant|>", received.Prompt)
+	assert.Equal(t, int64(512), received.NPredict)
+	assert.NotNil(t, received.JSONSchema)
+	assert.Empty(t, received.Grammar)
+	assert.Equal(t, "Reading", content)
+	require.NotNil(t, complete)
+	assert.Equal(t, message.FinishReasonToolUse, complete.FinishReason)
+	require.Len(t, complete.ToolCalls, 1)
+	assert.Equal(t, "view", complete.ToolCalls[0].Name)
+	assert.JSONEq(t, `{"file_path":"main.go"}`, complete.ToolCalls[0].Input)
+	assert.Equal(t, TokenUsage{InputTokens: 12, OutputTokens: 20}, complete.Usage)
+}
+
+func TestLlamaCppClient_Grammar(t *testing.T) {
+	var received llamacppCompletionRequest
+	client := newTestLlamaCppServer(t, []string{
+		`{"content":"yes","stop":true,"stop_type":"eos"}`,
+	}, &received)
+
+	response, err := client.send(context.Background(), nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, `root ::= "yes" | "no"`, received.Grammar)
+	assert.Nil(t, received.JSONSchema)
+	assert.Equal(t, "yes", response.Content)
+	assert.Equal(t, message.FinishReasonEndTurn, response.FinishReason)
+}
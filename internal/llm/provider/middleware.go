@@ -0,0 +1,191 @@
+package provider
+
+import (
+	"context"
+	"regexp"
+	"time"
+
+	"github.com/opencode-ai/opencode/internal/llm/models"
+	"github.com/opencode-ai/opencode/internal/llm/tools"
+	"github.com/opencode-ai/opencode/internal/logging"
+	"github.com/opencode-ai/opencode/internal/message"
+)
+
+// Request is a turn sent to a provider client. Middleware may change it
+// before it reaches the client.
+type Request struct {
+	Model    models.Model
+	Messages []message.Message
+	Tools    []tools.BaseTool
+}
+
+// Handler answers a request, it is the provider client or the next
+// middleware of the chain.
+type Handler interface {
+	Send(ctx context.Context, req *Request) (*ProviderResponse, error)
+	Stream(ctx context.Context, req *Request) <-chan ProviderEvent
+}
+
+// Middleware wraps the handler of a provider client, e.g. to change requests,
+// inspect responses, log or collect metrics, without changing the client.
+type Middleware func(next Handler) Handler
+
+// clientHandler is the end of the chain, where the request reaches the client.
+type clientHandler struct {
+	client ProviderClient
+}
+
+func (h clientHandler) Send(ctx context.Context, req *Request) (*ProviderResponse, error) {
+	return h.client.send(ctx, req.Messages, req.Tools)
+}
+
+func (h clientHandler) Stream(ctx context.Context, req *Request) <-chan ProviderEvent {
+	return h.client.stream(ctx, req.Messages, req.Tools)
+}
+
+// chain wraps the client in the middleware, the first middleware sees the
+// request first.
+func chain(client ProviderClient, middleware []Middleware) Handler {
+	var handler Handler = clientHandler{client: client}
+	for i := len(middleware) - 1; i >= 0; i-- {
+		handler = middleware[i](handler)
+	}
+	return handler
+}
+
+// Interceptor builds middleware from hooks, for middleware that does not need
+// to control the call itself.
+type Interceptor struct {
+	// OnRequest is called before the request is sent and may change it. An
+	// error fails the turn without sending it.
+	OnRequest func(ctx context.Context, req *Request) error
+	// OnResponse is called with the response or the error of the turn, for
+	// streams once the stream is complete.
+	OnResponse func(ctx context.Context, req *Request, resp *ProviderResponse, err error, elapsed time.Duration)
+}
+
+// Middleware returns the middleware calling the hooks of the interceptor.
+func (i Interceptor) Middleware() Middleware {
+	return func(next Handler) Handler {
+		return &interceptorHandler{interceptor: i, next: next}
+	}
+}
+
+type interceptorHandler struct {
+	interceptor Interceptor
+	next        Handler
+}
+
+func (h *interceptorHandler) Send(ctx context.Context, req *Request) (*ProviderResponse, error) {
+	if h.interceptor.OnRequest != nil {
+		if err := h.interceptor.OnRequest(ctx, req); err != nil {
+			return nil, err
+		}
+	}
+	start := time.Now()
+	resp, err := h.next.Send(ctx, req)
+	if h.interceptor.OnResponse != nil {
+		h.interceptor.OnResponse(ctx, req, resp, err, time.Since(start))
+	}
+	return resp, err
+}
+
+func (h *interceptorHandler) Stream(ctx context.Context, req *Request) <-chan ProviderEvent {
+	if h.interceptor.OnRequest != nil {
+		if err := h.interceptor.OnRequest(ctx, req); err != nil {
+			return errorStream(err)
+		}
+	}
+	start := time.Now()
+	events := h.next.Stream(ctx, req)
+	if h.interceptor.OnResponse == nil {
+		return events
+	}
+
+	relayed := make(chan ProviderEvent)
+	go func() {
+		defer close(relayed)
+		for event := range events {
+			switch event.Type {
+			case EventComplete:
+				h.interceptor.OnResponse(ctx, req, event.Response, nil, time.Since(start))
+			case EventError:
+				h.interceptor.OnResponse(ctx, req, nil, event.Error, time.Since(start))
+			}
+			relayed <- event
+		}
+	}()
+	return relayed
+}
+
+// LoggingMiddleware logs every turn with its duration and token usage.
+func LoggingMiddleware() Middleware {
+	return Interceptor{
+		OnResponse: func(ctx context.Context, req *Request, resp *ProviderResponse, err error, elapsed time.Duration) {
+			if err != nil {
+				logging.Debug("Provider request failed", "model", req.Model.ID, "messages", len(req.Messages), "tools", len(req.Tools), "elapsed", elapsed, "error", err)
+				return
+			}
+			logging.Debug("Provider request completed",
+				"model", req.Model.ID,
+				"messages", len(req.Messages),
+				"tools", len(req.Tools),
+				"elapsed", elapsed,
+				"input_tokens", resp.Usage.InputTokens,
+				"output_tokens", resp.Usage.OutputTokens,
+				"finish_reason", resp.FinishReason,
+			)
+		},
+	}.Middleware()
+}
+
+// redacted replaces the secrets removed by RedactMiddleware.
+const redacted = "[REDACTED]"
+
+// RedactMiddleware replaces the text matching the patterns in the messages
+// before they are sent, e.g. API keys a tool read from a file. The messages
+// stored in the session are not changed.
+func RedactMiddleware(patterns ...*regexp.Regexp) Middleware {
+	redact := func(text string) string {
+		for _, pattern := range patterns {
+			text = pattern.ReplaceAllString(text, redacted)
+		}
+		return text
+	}
+	return Interceptor{
+		OnRequest: func(ctx context.Context, req *Request) error {
+			messages := make([]message.Message, len(req.Messages))
+			for i, msg := range req.Messages {
+				parts := make([]message.ContentPart, len(msg.Parts))
+				for j, part := range msg.Parts {
+					switch part := part.(type) {
+					case message.TextContent:
+						part.Text = redact(part.Text)
+						parts[j] = part
+					case message.ToolCall:
+						// Keep the input valid JSON, the replacement has no quotes
+						part.Input = redact(part.Input)
+						parts[j] = part
+					case message.ToolResult:
+						part.Content = redact(part.Content)
+						parts[j] = part
+					default:
+						parts[j] = part
+					}
+				}
+				msg.Parts = parts
+				messages[i] = msg
+			}
+			req.Messages = messages
+			return nil
+		},
+	}.Middleware()
+}
+
+// WithMiddleware adds middleware around the provider client, the first one
+// sees the request first.
+func WithMiddleware(middleware ...Middleware) ProviderClientOption {
+	return func(options *providerClientOptions) {
+		options.middleware = append(options.middleware, middleware...)
+	}
+}
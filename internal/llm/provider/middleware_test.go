@@ -0,0 +1,75 @@
+package provider
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/opencode-ai/opencode/internal/llm/models"
+	"github.com/opencode-ai/opencode/internal/llm/tools"
+	"github.com/opencode-ai/opencode/internal/message"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingClient struct {
+	messages []message.Message
+}
+
+func (c *recordingClient) send(ctx context.Context, messages []message.Message, tools []tools.BaseTool) (*ProviderResponse, error) {
+	c.messages = messages
+	return &ProviderResponse{Content: "done", Usage: TokenUsage{InputTokens: 10}}, nil
+}
+
+func (c *recordingClient) stream(ctx context.Context, messages []message.Message, tools []tools.BaseTool) <-chan ProviderEvent {
+	c.messages = messages
+	events := make(chan ProviderEvent, 2)
+	events <- ProviderEvent{Type: EventContentDelta, Content: "done"}
+	events <- ProviderEvent{Type: EventComplete, Response: &ProviderResponse{Content: "done"}}
+	close(events)
+	return events
+}
+
+func TestMiddleware_Chain(t *testing.T) {
+	var order []string
+	tracing := func(name string) Middleware {
+		return Interceptor{
+			OnRequest: func(ctx context.Context, req *Request) error {
+				order = append(order, name+" request")
+				return nil
+			},
+			OnResponse: func(ctx context.Context, req *Request, resp *ProviderResponse, err error, elapsed time.Duration) {
+				order = append(order, name+" response")
+			},
+		}.Middleware()
+	}
+
+	client := &recordingClient{}
+	p := &baseProvider[*recordingClient]{
+		options: providerClientOptions{
+			model: models.Model{ID: "test"},
+			middleware: []Middleware{
+				RedactMiddleware(regexp.MustCompile(`sk-[a-zA-Z0-9]+`)),
+				tracing("first"),
+				tracing("second"),
+			},
+		},
+		client: client,
+	}
+	history := []message.Message{
+		{Role: message.User, Parts: []message.ContentPart{message.TextContent{Text: "my key is sk-abc123"}}},
+	}
+
+	_, err := p.SendMessages(context.Background(), history, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"first request", "second request", "second response", "first response"}, order)
+	assert.Equal(t, "my key is [REDACTED]", client.messages[0].Content().String())
+	// The conversation itself keeps the secret
+	assert.Equal(t, "my key is sk-abc123", history[0].Content().String())
+
+	order = nil
+	for range p.StreamResponse(context.Background(), history, nil) {
+	}
+	assert.Equal(t, []string{"first request", "second request", "second response", "first response"}, order)
+}
@@ -0,0 +1,84 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/openai/openai-go"
+	"github.com/openai/openai-go/option"
+)
+
+const mistralBaseURL = "https://api.mistral.ai/v1"
+
+var mistralToolCallID = regexp.MustCompile(`^[a-zA-Z0-9]{9}$`)
+
+// mistralClient uses the OpenAI compatible chat API of La Plateforme and adds
+// the fill-in-the-middle endpoint of the Codestral models.
+type mistralClient struct {
+	*openaiClient
+}
+
+type MistralClient ProviderClient
+
+type mistralFIMRequest struct {
+	Model     string `json:"model"`
+	Prompt    string `json:"prompt"`
+	Suffix    string `json:"suffix,omitempty"`
+	MaxTokens int64  `json:"max_tokens,omitempty"`
+}
+
+func newMistralClient(opts providerClientOptions) MistralClient {
+	// The default goes first so a configured base URL, e.g. the Codestral
+	// endpoint, takes precedence
+	opts.openaiOptions = append([]OpenAIOption{WithOpenAIBaseURL(mistralBaseURL)}, opts.openaiOptions...)
+	opts.openaiOptions = append(opts.openaiOptions,
+		withOpenAICompatible(),
+		withOpenAINoStreamOptions(),
+	)
+	return &mistralClient{openaiClient: newOpenAIClient(opts).(*openaiClient)}
+}
+
+// toolCallID returns IDs of nine letters and digits, the only form Mistral
+// accepts.
+func (m *mistralClient) toolCallID(id string) string {
+	if mistralToolCallID.MatchString(id) {
+		return id
+	}
+	return hashToolCallID(id)[:9]
+}
+
+func (m *mistralClient) CompleteCode(ctx context.Context, prefix, suffix string) (string, error) {
+	request := mistralFIMRequest{
+		Model:     m.providerOptions.model.APIModel,
+		Prompt:    prefix,
+		Suffix:    suffix,
+		MaxTokens: m.providerOptions.maxTokens,
+	}
+
+	var completion openai.ChatCompletion
+	err := m.providerOptions.retryPolicy.do(ctx, func() error {
+		return m.client.Post(ctx, "fim/completions", request, &completion, option.WithMaxRetries(0))
+	}, m.shouldRetryFIM)
+	if err != nil {
+		return "", err
+	}
+	if len(completion.Choices) == 0 {
+		return "", errors.New("mistral: empty completion")
+	}
+	return completion.Choices[0].Message.Content, nil
+}
+
+func (m *mistralClient) shouldRetryFIM(err error) (bool, time.Duration) {
+	var apierr *openai.Error
+	if errors.As(err, &apierr) {
+		var header http.Header
+		if apierr.Response != nil {
+			header = apierr.Response.Header
+		}
+		return m.providerOptions.retryPolicy.retryableStatus(apierr.StatusCode), retryAfter(header)
+	}
+	return isTransientNetworkError(err), 0
+}
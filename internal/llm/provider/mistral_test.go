@@ -0,0 +1,90 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/opencode-ai/opencode/internal/llm/models"
+	"github.com/opencode-ai/opencode/internal/message"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMistralClient(t *testing.T) {
+	var chat, fim map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer mistral-key", r.Header.Get("Authorization"))
+		switch r.URL.Path {
+		case "/v1/chat/completions":
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&chat))
+			w.Header().Set("Content-Type", "text/event-stream")
+			fmt.Fprint(w, `data: {"id":"1","object":"chat.completion.chunk","model":"codestral-latest","choices":[{"index":0,"delta":{"role":"assistant","content":"hi"},"finish_reason":"stop"}],"usage":{"prompt_tokens":5,"completion_tokens":1,"total_tokens":6}}`+"\n\n")
+			fmt.Fprint(w, "data: [DONE]\n\n")
+		case "/v1/fim/completions":
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&fim))
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprintln(w, `{"id":"2","object":"chat.completion","model":"codestral-latest","choices":[{"index":0,"finish_reason":"stop","message":{"role":"assistant","content":"a + b"}}],"usage":{"prompt_tokens":9,"completion_tokens":3}}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	opts := []ProviderClientOption{
+		WithAPIKey("mistral-key"),
+		WithModel(models.MistralModels[models.Codestral]),
+		WithMaxTokens(256),
+		WithOpenAIOptions(WithOpenAIBaseURL(server.URL + "/v1")),
+		WithRetryPolicy(RetryPolicy{}),
+	}
+	p, err := NewProvider(models.ProviderMistral, opts...)
+	require.NoError(t, err)
+
+	msg := message.Message{Role: message.User}
+	msg.AppendContent("hello")
+	var complete *ProviderResponse
+	for event := range p.StreamResponse(context.Background(), []message.Message{msg}, nil) {
+		require.NoError(t, event.Error)
+		if event.Type == EventComplete {
+			complete = event.Response
+		}
+	}
+	require.NotNil(t, complete)
+	assert.Equal(t, "hi", complete.Content)
+	assert.Equal(t, TokenUsage{InputTokens: 5, OutputTokens: 1}, complete.Usage)
+	assert.NotContains(t, chat, "stream_options")
+
+	completer, err := NewCodeCompleter(models.ProviderMistral, opts...)
+	require.NoError(t, err)
+	code, err := completer.CompleteCode(context.Background(), "func add(a, b int) int {\n\treturn ", "\n}")
+	require.NoError(t, err)
+	assert.Equal(t, "a + b", code)
+	assert.Equal(t, "codestral-latest", fim["model"])
+	assert.Equal(t, "\n}", fim["suffix"])
+	assert.Equal(t, float64(256), fim["max_tokens"])
+}
+
+func TestMistralClient_ToolCallIDs(t *testing.T) {
+	// A history that started on Gemini, which uses long generated IDs
+	geminiID := "call_0d3c2a4e-7f41-4c8e-9b5a-2f6d8e1c3b7a"
+	history := []message.Message{
+		{Role: message.Assistant, Parts: []message.ContentPart{message.ToolCall{ID: geminiID, Name: "view", Input: "{}"}}},
+		{Role: message.Tool, Parts: []message.ContentPart{message.ToolResult{ToolCallID: geminiID, Content: "package main"}}},
+	}
+	p := &baseProvider[MistralClient]{client: &mistralClient{}}
+	cleaned := p.cleanMessages(history)
+
+	id := cleaned[0].ToolCalls()[0].ID
+	assert.Regexp(t, `^[a-zA-Z0-9]{9}$`, id)
+	assert.Equal(t, id, cleaned[1].ToolResults()[0].ToolCallID)
+	// The stored messages keep their IDs
+	assert.Equal(t, geminiID, history[0].ToolCalls()[0].ID)
+
+	// OpenAI accepts up to 40 characters
+	assert.Len(t, (&openaiClient{}).toolCallID(geminiID), 37)
+	assert.Equal(t, "call_abc", (&openaiClient{}).toolCallID("call_abc"))
+}
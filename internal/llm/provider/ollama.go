@@ -0,0 +1,837 @@
+package provider
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/opencode-ai/opencode/internal/config"
+	"github.com/opencode-ai/opencode/internal/llm/discovery"
+	"github.com/opencode-ai/opencode/internal/llm/models"
+	"github.com/opencode-ai/opencode/internal/llm/tools"
+	"github.com/opencode-ai/opencode/internal/logging"
+	"github.com/opencode-ai/opencode/internal/message"
+)
+
+// ollamaMaxNumCtx is the largest context allocated for a model unless numCtx
+// is set, compaction and the context meter still use the model's full window.
+const ollamaMaxNumCtx = 32768
+
+type ollamaOptions struct {
+	// baseURLs are the Ollama servers in order of preference
+	baseURLs  []string
+	headers   map[string]string
+	keepAlive any
+	// sampling holds the model parameters sent as the request options
+	sampling map[string]any
+	// format is either "json" or a JSON schema the output must follow
+	format any
+	// completion sends raw prompts to /api/generate for models without a chat template
+	completion bool
+	// template renders the raw prompt instead of the plain transcript
+	template string
+}
+
+type OllamaOption func(*ollamaOptions)
+
+type ollamaClient struct {
+	providerOptions providerClientOptions
+	options         ollamaOptions
+	client          *http.Client
+	hosts           *ollamaHosts
+}
+
+type OllamaClient ProviderClient
+
+type ollamaRequest struct {
+	Model     string          `json:"model"`
+	Messages  []ollamaMessage `json:"messages"`
+	Tools     []ollamaTool    `json:"tools,omitempty"`
+	Stream    bool            `json:"stream"`
+	KeepAlive any             `json:"keep_alive,omitempty"`
+	Options   map[string]any  `json:"options,omitempty"`
+	Format    any             `json:"format,omitempty"`
+	// Think enables the separate thinking output of reasoning models
+	Think bool `json:"think,omitempty"`
+}
+
+type ollamaGenerateRequest struct {
+	Model     string         `json:"model"`
+	Prompt    string         `json:"prompt"`
+	Images    []string       `json:"images,omitempty"`
+	Raw       bool           `json:"raw"`
+	Stream    bool           `json:"stream"`
+	KeepAlive any            `json:"keep_alive,omitempty"`
+	Options   map[string]any `json:"options,omitempty"`
+	Format    any            `json:"format,omitempty"`
+}
+
+type ollamaMessage struct {
+	Role      string           `json:"role"`
+	Content   string           `json:"content"`
+	Thinking  string           `json:"thinking,omitempty"`
+	ToolCalls []ollamaToolCall `json:"tool_calls,omitempty"`
+	ToolName  string           `json:"tool_name,omitempty"`
+	// Images are base64 encoded images for vision models such as llava
+	Images []string `json:"images,omitempty"`
+}
+
+type ollamaToolCall struct {
+	Function ollamaToolCallFunction `json:"function"`
+}
+
+type ollamaToolCallFunction struct {
+	Name      string          `json:"name"`
+	Arguments json.RawMessage `json:"arguments"`
+}
+
+type ollamaTool struct {
+	Type     string             `json:"type"`
+	Function ollamaToolFunction `json:"function"`
+}
+
+type ollamaToolFunction struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	Parameters  map[string]any `json:"parameters"`
+}
+
+type ollamaResponse struct {
+	Model   string        `json:"model"`
+	Message ollamaMessage `json:"message"`
+	// Response holds the generated text of /api/generate requests
+	Response        string `json:"response"`
+	Done            bool   `json:"done"`
+	DoneReason      string `json:"done_reason"`
+	PromptEvalCount int64  `json:"prompt_eval_count"`
+	EvalCount       int64  `json:"eval_count"`
+	Error           string `json:"error"`
+}
+
+type ollamaEmbedRequest struct {
+	Model     string   `json:"model"`
+	Input     []string `json:"input"`
+	KeepAlive any      `json:"keep_alive,omitempty"`
+}
+
+type ollamaEmbedResponse struct {
+	Embeddings [][]float32 `json:"embeddings"`
+}
+
+// ollamaAPIError is returned when the Ollama server answers with a non 2xx status.
+type ollamaAPIError struct {
+	StatusCode int
+	Message    string
+}
+
+// content returns the generated text of a chat or generate response.
+func (r ollamaResponse) content() string {
+	if r.Response != "" {
+		return r.Response
+	}
+	return r.Message.Content
+}
+
+func (e *ollamaAPIError) Error() string {
+	return fmt.Sprintf("ollama: %s (status %d)", e.Message, e.StatusCode)
+}
+
+func newOllamaClient(opts providerClientOptions) OllamaClient {
+	ollamaOpts := ollamaOptions{
+		sampling: make(map[string]any),
+	}
+	for _, o := range opts.ollamaOptions {
+		o(&ollamaOpts)
+	}
+	if len(ollamaOpts.baseURLs) == 0 {
+		ollamaOpts.baseURLs = []string{models.DefaultOllamaBaseURL}
+	}
+	for i, baseURL := range ollamaOpts.baseURLs {
+		ollamaOpts.baseURLs[i] = models.OllamaBaseURL(baseURL)
+	}
+
+	return &ollamaClient{
+		providerOptions: opts,
+		options:         ollamaOpts,
+		client: opts.httpClient(func(transport *http.Transport) {
+			discovery.EnableUnixSockets(transport, ollamaOpts.baseURLs...)
+		}),
+		hosts: newOllamaHosts(ollamaOpts.baseURLs),
+	}
+}
+
+func (o *ollamaClient) convertMessages(messages []message.Message) (ollamaMessages []ollamaMessage) {
+	// Add system message first
+	ollamaMessages = append(ollamaMessages, ollamaMessage{
+		Role:    "system",
+		Content: o.providerOptions.systemMessage,
+	})
+
+	for _, msg := range messages {
+		switch msg.Role {
+		case message.User:
+			ollamaMessages = append(ollamaMessages, ollamaMessage{
+				Role:    "user",
+				Content: msg.Content().String(),
+				Images:  o.images(msg),
+			})
+
+		case message.Assistant:
+			assistantMsg := ollamaMessage{
+				Role:    "assistant",
+				Content: msg.Content().String(),
+			}
+			for _, call := range msg.ToolCalls() {
+				args := json.RawMessage(call.Input)
+				if !json.Valid(args) {
+					args = json.RawMessage("{}")
+				}
+				assistantMsg.ToolCalls = append(assistantMsg.ToolCalls, ollamaToolCall{
+					Function: ollamaToolCallFunction{
+						Name:      call.Name,
+						Arguments: args,
+					},
+				})
+			}
+			ollamaMessages = append(ollamaMessages, assistantMsg)
+
+		case message.Tool:
+			for _, result := range msg.ToolResults() {
+				ollamaMessages = append(ollamaMessages, ollamaMessage{
+					Role:     "tool",
+					Content:  result.Content,
+					ToolName: toolCallName(messages, result),
+				})
+			}
+		}
+	}
+
+	return
+}
+
+// images collects the image attachments of a message as base64 strings.
+func (o *ollamaClient) images(msg message.Message) []string {
+	var images []string
+	for _, binary := range msg.BinaryContent() {
+		if strings.HasPrefix(binary.MIMEType, "image/") {
+			images = append(images, base64.StdEncoding.EncodeToString(binary.Data))
+		}
+	}
+	for _, image := range msg.ImageURLContent() {
+		// Ollama can't fetch remote images, only inline data URLs are supported
+		if _, data, ok := strings.Cut(image.URL, ";base64,"); ok && strings.HasPrefix(image.URL, "data:image/") {
+			images = append(images, data)
+		} else {
+			logging.Warn("Skipping image that is not a base64 data URL", "url", image.URL)
+		}
+	}
+	return images
+}
+
+// toolCallName finds the name of the tool call a result belongs to, Ollama
+// matches results by tool name since its tool calls carry no IDs.
+func toolCallName(messages []message.Message, result message.ToolResult) string {
+	if result.Name != "" {
+		return result.Name
+	}
+	for _, m := range messages {
+		if m.Role != message.Assistant {
+			continue
+		}
+		for _, call := range m.ToolCalls() {
+			if call.ID == result.ToolCallID {
+				return call.Name
+			}
+		}
+	}
+	return ""
+}
+
+func (o *ollamaClient) convertTools(tools []tools.BaseTool) []ollamaTool {
+	ollamaTools := make([]ollamaTool, len(tools))
+
+	for i, tool := range tools {
+		info := tool.Info()
+		ollamaTools[i] = ollamaTool{
+			Type: "function",
+			Function: ollamaToolFunction{
+				Name:        info.Name,
+				Description: info.Description,
+				Parameters: map[string]any{
+					"type":       "object",
+					"properties": info.Parameters,
+					"required":   info.Required,
+				},
+			},
+		}
+	}
+
+	return ollamaTools
+}
+
+func (o *ollamaClient) finishReason(reason string, toolCalls []message.ToolCall) message.FinishReason {
+	if len(toolCalls) > 0 {
+		return message.FinishReasonToolUse
+	}
+	switch reason {
+	case "stop":
+		return message.FinishReasonEndTurn
+	case "length":
+		return message.FinishReasonMaxTokens
+	default:
+		return message.FinishReasonUnknown
+	}
+}
+
+func (o *ollamaClient) preparedRequest(messages []ollamaMessage, tools []ollamaTool, stream bool) ollamaRequest {
+	return ollamaRequest{
+		Model:     o.providerOptions.model.APIModel,
+		Messages:  messages,
+		Tools:     tools,
+		Stream:    stream,
+		KeepAlive: o.options.keepAlive,
+		Options:   o.requestOptions(),
+		Format:    o.options.format,
+		Think:     o.providerOptions.model.CanReason,
+	}
+}
+
+func (o *ollamaClient) requestOptions() map[string]any {
+	options := make(map[string]any, len(o.options.sampling)+2)
+	// Ollama defaults to a 2048 token context, use the model's window instead,
+	// the context length Ollama reports for recent models doesn't fit in memory
+	if o.providerOptions.model.ContextWindow > 0 {
+		options["num_ctx"] = min(o.providerOptions.model.ContextWindow, ollamaMaxNumCtx)
+	}
+	// Bound the generation, otherwise Ollama keeps going until the context is full
+	if maxTokens := o.maxTokens(); maxTokens > 0 {
+		options["num_predict"] = maxTokens
+	}
+	maps.Copy(options, o.options.sampling)
+	return options
+}
+
+// maxTokens returns the max tokens set by the agent, falling back to the
+// model's default.
+func (o *ollamaClient) maxTokens() int64 {
+	if o.providerOptions.maxTokens > 0 {
+		return o.providerOptions.maxTokens
+	}
+	return o.providerOptions.model.DefaultMaxTokens
+}
+
+func (o *ollamaClient) doRequest(ctx context.Context, request ollamaRequest) (*http.Response, error) {
+	if o.options.completion {
+		generate, err := o.generateRequest(request)
+		if err != nil {
+			return nil, err
+		}
+		return o.post(ctx, "/api/generate", generate)
+	}
+	return o.post(ctx, "/api/chat", request)
+}
+
+// generateRequest assembles the conversation into a single raw prompt for
+// models that have no chat template, or need a custom one.
+func (o *ollamaClient) generateRequest(request ollamaRequest) (ollamaGenerateRequest, error) {
+	var images []string
+	for _, msg := range request.Messages {
+		images = append(images, msg.Images...)
+	}
+	options := maps.Clone(request.Options)
+	if options == nil {
+		options = make(map[string]any)
+	}
+
+	var prompt string
+	if o.options.template != "" {
+		var err error
+		if prompt, err = renderOllamaTemplate(o.options.template, request); err != nil {
+			return ollamaGenerateRequest{}, err
+		}
+	} else {
+		prompt = transcriptPrompt(request.Messages)
+		// Keep the model from writing the user's next turn
+		stop, _ := options["stop"].([]string)
+		options["stop"] = append(slices.Clone(stop), "\nUser:")
+	}
+
+	return ollamaGenerateRequest{
+		Model:     request.Model,
+		Prompt:    prompt,
+		Images:    images,
+		Raw:       true,
+		Stream:    request.Stream,
+		KeepAlive: request.KeepAlive,
+		Options:   options,
+		Format:    request.Format,
+	}, nil
+}
+
+// transcriptPrompt writes the conversation as a plain text transcript. Tools
+// are not offered in this mode, past tool calls and results are kept in the
+// transcript as plain text.
+func transcriptPrompt(messages []ollamaMessage) string {
+	var prompt strings.Builder
+	for _, msg := range messages {
+		switch msg.Role {
+		case "system":
+			prompt.WriteString(msg.Content + "\n\n")
+		case "user":
+			prompt.WriteString("User: " + msg.Content + "\n\n")
+		case "assistant":
+			prompt.WriteString("Assistant: " + msg.Content)
+			for _, call := range msg.ToolCalls {
+				prompt.WriteString(fmt.Sprintf("\n[called %s with %s]", call.Function.Name, call.Function.Arguments))
+			}
+			prompt.WriteString("\n\n")
+		case "tool":
+			prompt.WriteString(fmt.Sprintf("Tool result (%s): %s\n\n", msg.ToolName, msg.Content))
+		}
+	}
+	prompt.WriteString("Assistant:")
+	return prompt.String()
+}
+
+// ollamaTemplateData mirrors the data Ollama passes to model templates, so
+// templates copied from a Modelfile work unchanged.
+type ollamaTemplateData struct {
+	System   string
+	Prompt   string
+	Messages []ollamaMessage
+	Tools    []ollamaTool
+}
+
+// renderOllamaTemplate renders the conversation with a Go template in the
+// Ollama Modelfile TEMPLATE syntax.
+func renderOllamaTemplate(text string, request ollamaRequest) (string, error) {
+	tmpl, err := template.New("ollama").Funcs(template.FuncMap{
+		"json": func(v any) (string, error) {
+			data, err := json.Marshal(v)
+			return string(data), err
+		},
+	}).Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("invalid ollama template: %w", err)
+	}
+
+	data := ollamaTemplateData{Tools: request.Tools}
+	for _, msg := range request.Messages {
+		if msg.Role == "system" {
+			data.System = msg.Content
+			continue
+		}
+		data.Messages = append(data.Messages, msg)
+		if msg.Role == "user" {
+			data.Prompt = msg.Content
+		}
+	}
+
+	var prompt strings.Builder
+	if err := tmpl.Execute(&prompt, data); err != nil {
+		return "", fmt.Errorf("failed to render ollama template: %w", err)
+	}
+	return prompt.String(), nil
+}
+
+func (o *ollamaClient) post(ctx context.Context, path string, payload any) (*http.Response, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+	if cfg := config.Get(); cfg != nil && cfg.Debug {
+		logging.Debug("Prepared messages", "messages", string(body))
+	}
+
+	var resp *http.Response
+	err = o.providerOptions.retryPolicy.do(ctx, func() error {
+		var err error
+		resp, err = o.postOnce(ctx, path, body)
+		return err
+	}, o.shouldRetry)
+	return resp, err
+}
+
+// shouldRetry reports whether a failed request may be retried.
+func (o *ollamaClient) shouldRetry(err error) (bool, time.Duration) {
+	var apiErr *ollamaAPIError
+	if errors.As(err, &apiErr) {
+		return o.providerOptions.retryPolicy.retryableStatus(apiErr.StatusCode), 0
+	}
+	return isTransientNetworkError(err), 0
+}
+
+func (o *ollamaClient) postOnce(ctx context.Context, path string, body []byte) (*http.Response, error) {
+	// Fail over to the next host when a server is down or too busy to queue the request
+	var lastErr error
+	for _, baseURL := range o.hosts.order() {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, baseURL+path, bytes.NewReader(body))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		if o.providerOptions.apiKey != "" {
+			req.Header.Set("Authorization", "Bearer "+o.providerOptions.apiKey)
+		}
+		for k, v := range o.options.headers {
+			req.Header.Set(k, v)
+		}
+
+		resp, err := o.client.Do(req)
+		if err != nil {
+			err = discovery.OllamaConnectionError(baseURL, err)
+			var unavailable *discovery.OllamaUnavailableError
+			if ctx.Err() == nil && errors.As(err, &unavailable) {
+				logging.Warn("Ollama host unavailable, trying next host", "host", unavailable.BaseURL)
+				o.hosts.markDown(baseURL)
+				lastErr = err
+				continue
+			}
+			return nil, err
+		}
+		o.hosts.markUp(baseURL)
+		if resp.StatusCode == http.StatusServiceUnavailable {
+			lastErr = o.apiError(resp)
+			resp.Body.Close()
+			logging.Warn("Ollama host busy, trying next host", "error", lastErr)
+			continue
+		}
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			defer resp.Body.Close()
+			return nil, o.apiError(resp)
+		}
+		return resp, nil
+	}
+	return nil, lastErr
+}
+
+func (o *ollamaClient) apiError(resp *http.Response) error {
+	data, _ := io.ReadAll(resp.Body)
+	var errResp struct {
+		Error string `json:"error"`
+	}
+	msg := strings.TrimSpace(string(data))
+	if err := json.Unmarshal(data, &errResp); err == nil && errResp.Error != "" {
+		msg = errResp.Error
+	}
+	if msg == "" {
+		msg = resp.Status
+	}
+	return &ollamaAPIError{StatusCode: resp.StatusCode, Message: msg}
+}
+
+func (o *ollamaClient) send(ctx context.Context, messages []message.Message, tools []tools.BaseTool) (*ProviderResponse, error) {
+	request := o.preparedRequest(o.convertMessages(messages), o.convertTools(tools), false)
+
+	resp, err := o.doRequest(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var ollamaResp ollamaResponse
+	if err := json.NewDecoder(resp.Body).Decode(&ollamaResp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	if ollamaResp.Error != "" {
+		return nil, errors.New(ollamaResp.Error)
+	}
+
+	var splitter thinkSplitter
+	_, content := splitter.feed(ollamaResp.content())
+	_, rest := splitter.flush()
+
+	toolCalls := o.toolCalls(ollamaResp.Message)
+	return &ProviderResponse{
+		Content:      content + rest,
+		ToolCalls:    toolCalls,
+		Usage:        o.usage(ollamaResp),
+		FinishReason: o.finishReason(ollamaResp.DoneReason, toolCalls),
+	}, nil
+}
+
+func (o *ollamaClient) stream(ctx context.Context, messages []message.Message, tools []tools.BaseTool) <-chan ProviderEvent {
+	request := o.preparedRequest(o.convertMessages(messages), o.convertTools(tools), true)
+
+	eventChan := make(chan ProviderEvent)
+
+	go func() {
+		defer close(eventChan)
+
+		resp, err := o.doRequest(ctx, request)
+		if err != nil {
+			if ctx.Err() != nil {
+				err = ctx.Err()
+			}
+			eventChan <- ProviderEvent{Type: EventError, Error: err}
+			return
+		}
+		defer resp.Body.Close()
+
+		currentContent := ""
+		toolCalls := make([]message.ToolCall, 0)
+		var splitter thinkSplitter
+		emit := func(thinking, content string) {
+			if thinking != "" {
+				eventChan <- ProviderEvent{
+					Type:     EventThinkingDelta,
+					Thinking: thinking,
+				}
+			}
+			if content != "" {
+				eventChan <- ProviderEvent{
+					Type:    EventContentDelta,
+					Content: content,
+				}
+				currentContent += content
+			}
+		}
+
+		scanner := bufio.NewScanner(resp.Body)
+		scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+		for scanner.Scan() {
+			line := bytes.TrimSpace(scanner.Bytes())
+			if len(line) == 0 {
+				continue
+			}
+
+			var chunk ollamaResponse
+			if err := json.Unmarshal(line, &chunk); err != nil {
+				logging.Warn("Error decoding ollama stream chunk", "error", err)
+				continue
+			}
+			if chunk.Error != "" {
+				eventChan <- ProviderEvent{Type: EventError, Error: errors.New(chunk.Error)}
+				return
+			}
+
+			emit(chunk.Message.Thinking, "")
+			emit(splitter.feed(chunk.content()))
+
+			for _, call := range o.toolCalls(chunk.Message) {
+				eventChan <- ProviderEvent{
+					Type:     EventToolUseStart,
+					ToolCall: &call,
+				}
+				toolCalls = append(toolCalls, call)
+			}
+
+			if chunk.Done {
+				emit(splitter.flush())
+				eventChan <- ProviderEvent{
+					Type: EventComplete,
+					Response: &ProviderResponse{
+						Content:      currentContent,
+						ToolCalls:    toolCalls,
+						Usage:        o.usage(chunk),
+						FinishReason: o.finishReason(chunk.DoneReason, toolCalls),
+					},
+				}
+				return
+			}
+		}
+
+		err = scanner.Err()
+		if ctx.Err() != nil {
+			err = ctx.Err()
+		}
+		if err == nil {
+			err = io.ErrUnexpectedEOF
+		}
+		eventChan <- ProviderEvent{Type: EventError, Error: err}
+	}()
+
+	return eventChan
+}
+
+func (o *ollamaClient) Embed(ctx context.Context, input []string) ([][]float32, error) {
+	resp, err := o.post(ctx, "/api/embed", ollamaEmbedRequest{
+		Model:     o.providerOptions.model.APIModel,
+		Input:     input,
+		KeepAlive: o.options.keepAlive,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var embedResp ollamaEmbedResponse
+	if err := json.NewDecoder(resp.Body).Decode(&embedResp); err != nil {
+		return nil, fmt.Errorf("failed to decode embeddings: %w", err)
+	}
+	if len(embedResp.Embeddings) != len(input) {
+		return nil, fmt.Errorf("expected %d embeddings, got %d", len(input), len(embedResp.Embeddings))
+	}
+	return embedResp.Embeddings, nil
+}
+
+func (o *ollamaClient) toolCalls(msg ollamaMessage) []message.ToolCall {
+	var toolCalls []message.ToolCall
+
+	for _, call := range msg.ToolCalls {
+		input := string(call.Function.Arguments)
+		if input == "" || input == "null" {
+			input = "{}"
+		}
+		toolCalls = append(toolCalls, message.ToolCall{
+			ID:       "call_" + uuid.New().String(),
+			Name:     call.Function.Name,
+			Input:    input,
+			Type:     "function",
+			Finished: true,
+		})
+	}
+
+	return toolCalls
+}
+
+func (o *ollamaClient) usage(resp ollamaResponse) TokenUsage {
+	return TokenUsage{
+		InputTokens:  resp.PromptEvalCount,
+		OutputTokens: resp.EvalCount,
+	}
+}
+
+func WithOllamaBaseURL(baseURL string) OllamaOption {
+	return func(options *ollamaOptions) {
+		if baseURL != "" {
+			options.baseURLs = []string{baseURL}
+		}
+	}
+}
+
+// WithOllamaBaseURLs configures several Ollama servers. Requests go to the
+// first healthy one and fail over to the next when a server is down or busy.
+func WithOllamaBaseURLs(baseURLs ...string) OllamaOption {
+	return func(options *ollamaOptions) {
+		options.baseURLs = nil
+		for _, baseURL := range baseURLs {
+			if baseURL == "" {
+				continue
+			}
+			baseURL = models.OllamaBaseURL(baseURL)
+			if !slices.Contains(options.baseURLs, baseURL) {
+				options.baseURLs = append(options.baseURLs, baseURL)
+			}
+		}
+	}
+}
+
+// WithOllamaCompletionMode switches to the /api/generate endpoint with a raw
+// prompt, for models that only work as plain text completion models.
+func WithOllamaCompletionMode() OllamaOption {
+	return func(options *ollamaOptions) {
+		options.completion = true
+	}
+}
+
+// WithOllamaTemplate renders raw prompts with a custom template in the Ollama
+// Modelfile TEMPLATE syntax, for fine-tunes that need a different chat format
+// than the model's built-in one. It implies completion mode.
+func WithOllamaTemplate(tmpl string) OllamaOption {
+	return func(options *ollamaOptions) {
+		options.completion = true
+		options.template = tmpl
+	}
+}
+
+// WithOllamaHeaders adds custom headers to every request, e.g. for an Ollama
+// server behind an authenticating reverse proxy.
+func WithOllamaHeaders(headers map[string]string) OllamaOption {
+	return func(options *ollamaOptions) {
+		options.headers = headers
+	}
+}
+
+// WithOllamaKeepAlive controls how long Ollama keeps the model loaded after a
+// request. It accepts a duration ("10m", "1h") or a number of seconds, where
+// "-1" keeps the model loaded indefinitely and "0" unloads it right away.
+func WithOllamaKeepAlive(keepAlive string) OllamaOption {
+	return func(options *ollamaOptions) {
+		if keepAlive == "" {
+			return
+		}
+		// Ollama only accepts plain numbers as JSON numbers, not strings
+		if seconds, err := strconv.ParseFloat(keepAlive, 64); err == nil {
+			options.keepAlive = seconds
+			return
+		}
+		options.keepAlive = keepAlive
+	}
+}
+
+func WithOllamaTemperature(temperature float64) OllamaOption {
+	return func(options *ollamaOptions) {
+		options.sampling["temperature"] = temperature
+	}
+}
+
+func WithOllamaTopP(topP float64) OllamaOption {
+	return func(options *ollamaOptions) {
+		options.sampling["top_p"] = topP
+	}
+}
+
+func WithOllamaTopK(topK int64) OllamaOption {
+	return func(options *ollamaOptions) {
+		options.sampling["top_k"] = topK
+	}
+}
+
+func WithOllamaNumCtx(numCtx int64) OllamaOption {
+	return func(options *ollamaOptions) {
+		options.sampling["num_ctx"] = numCtx
+	}
+}
+
+func WithOllamaRepeatPenalty(repeatPenalty float64) OllamaOption {
+	return func(options *ollamaOptions) {
+		options.sampling["repeat_penalty"] = repeatPenalty
+	}
+}
+
+// WithOllamaStop sets sequences that end the generation when the model emits
+// them, e.g. the end marker of an edit format.
+func WithOllamaStop(stop ...string) OllamaOption {
+	return func(options *ollamaOptions) {
+		if len(stop) > 0 {
+			options.sampling["stop"] = stop
+		}
+	}
+}
+
+// WithOllamaSeed fixes the random seed so the same prompt produces the same
+// output, which helps when debugging.
+func WithOllamaSeed(seed int64) OllamaOption {
+	return func(options *ollamaOptions) {
+		options.sampling["seed"] = seed
+	}
+}
+
+// WithOllamaJSONFormat makes the model answer with valid JSON.
+func WithOllamaJSONFormat() OllamaOption {
+	return func(options *ollamaOptions) {
+		options.format = "json"
+	}
+}
+
+// WithOllamaJSONSchema constrains the model output to the given JSON schema,
+// this requires Ollama 0.5 or newer.
+func WithOllamaJSONSchema(schema map[string]any) OllamaOption {
+	return func(options *ollamaOptions) {
+		options.format = schema
+	}
+}
@@ -0,0 +1,63 @@
+package provider
+
+import (
+	"sync"
+	"time"
+)
+
+// ollamaHostCooldown is how long an unreachable host is skipped before it is
+// tried again.
+const ollamaHostCooldown = 30 * time.Second
+
+// ollamaHosts tracks the health of the configured Ollama servers. Hosts are
+// tried in the configured order, so the first one is the preferred server and
+// the others are fallbacks.
+type ollamaHosts struct {
+	mu        sync.Mutex
+	urls      []string
+	downUntil []time.Time
+}
+
+func newOllamaHosts(urls []string) *ollamaHosts {
+	return &ollamaHosts{
+		urls:      urls,
+		downUntil: make([]time.Time, len(urls)),
+	}
+}
+
+// order returns the hosts to try for a request, hosts that failed recently
+// are moved to the end.
+func (h *ollamaHosts) order() []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	now := time.Now()
+	healthy := make([]string, 0, len(h.urls))
+	var down []string
+	for i, url := range h.urls {
+		if now.Before(h.downUntil[i]) {
+			down = append(down, url)
+		} else {
+			healthy = append(healthy, url)
+		}
+	}
+	return append(healthy, down...)
+}
+
+func (h *ollamaHosts) markDown(url string) {
+	h.setDownUntil(url, time.Now().Add(ollamaHostCooldown))
+}
+
+func (h *ollamaHosts) markUp(url string) {
+	h.setDownUntil(url, time.Time{})
+}
+
+func (h *ollamaHosts) setDownUntil(url string, until time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for i, u := range h.urls {
+		if u == url {
+			h.downUntil[i] = until
+		}
+	}
+}
@@ -0,0 +1,505 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/opencode-ai/opencode/internal/llm/models"
+	"github.com/opencode-ai/opencode/internal/llm/tools"
+	"github.com/opencode-ai/opencode/internal/message"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type stubTool struct {
+	info tools.ToolInfo
+}
+
+func (s stubTool) Info() tools.ToolInfo {
+	return s.info
+}
+
+func (s stubTool) Run(ctx context.Context, params tools.ToolCall) (tools.ToolResponse, error) {
+	return tools.NewTextResponse(""), nil
+}
+
+func newTestOllamaClient(t *testing.T, handler http.HandlerFunc, opts ...OllamaOption) *ollamaClient {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	return newOllamaClient(providerClientOptions{
+		model:         models.Model{APIModel: "llama3.1"},
+		systemMessage: "system",
+		ollamaOptions: append([]OllamaOption{WithOllamaBaseURL(server.URL)}, opts...),
+	}).(*ollamaClient)
+}
+
+func TestOllamaClient_NativeToolCalls(t *testing.T) {
+	var received ollamaRequest
+	client := newTestOllamaClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/chat", r.URL.Path)
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		fmt.Fprintln(w, `{"message":{"role":"assistant","content":"","tool_calls":[{"function":{"name":"ls","arguments":{"path":"/tmp"}}}]},"done":false}`)
+		fmt.Fprintln(w, `{"message":{"role":"assistant","content":""},"done":true,"done_reason":"stop","prompt_eval_count":12,"eval_count":3}`)
+	})
+
+	history := []message.Message{
+		{Role: message.User, Parts: []message.ContentPart{message.TextContent{Text: "list"}}},
+		{Role: message.Assistant, Parts: []message.ContentPart{message.ToolCall{ID: "call_1", Name: "ls", Input: `{"path":"."}`}}},
+		{Role: message.Tool, Parts: []message.ContentPart{message.ToolResult{ToolCallID: "call_1", Content: "a.txt"}}},
+	}
+	lsTool := stubTool{info: tools.ToolInfo{
+		Name:        "ls",
+		Description: "list files",
+		Parameters:  map[string]any{"path": map[string]any{"type": "string"}},
+		Required:    []string{"path"},
+	}}
+
+	var complete *ProviderResponse
+	for event := range client.stream(context.Background(), history, []tools.BaseTool{lsTool}) {
+		require.NoError(t, event.Error)
+		if event.Type == EventComplete {
+			complete = event.Response
+		}
+	}
+
+	require.Len(t, received.Tools, 1)
+	assert.Equal(t, "ls", received.Tools[0].Function.Name)
+	require.Len(t, received.Messages, 4)
+	assert.Equal(t, "ls", received.Messages[2].ToolCalls[0].Function.Name)
+	assert.JSONEq(t, `{"path":"."}`, string(received.Messages[2].ToolCalls[0].Function.Arguments))
+	assert.Equal(t, "tool", received.Messages[3].Role)
+	assert.Equal(t, "ls", received.Messages[3].ToolName)
+
+	require.NotNil(t, complete)
+	require.Len(t, complete.ToolCalls, 1)
+	assert.Equal(t, "ls", complete.ToolCalls[0].Name)
+	assert.JSONEq(t, `{"path":"/tmp"}`, complete.ToolCalls[0].Input)
+	assert.NotEmpty(t, complete.ToolCalls[0].ID)
+	assert.Equal(t, message.FinishReasonToolUse, complete.FinishReason)
+	assert.Equal(t, int64(12), complete.Usage.InputTokens)
+	assert.Equal(t, int64(3), complete.Usage.OutputTokens)
+}
+
+func TestOllamaClient_RequestOptions(t *testing.T) {
+	client := newOllamaClient(providerClientOptions{
+		model: models.Model{APIModel: "llama3.1", ContextWindow: 8192},
+		ollamaOptions: []OllamaOption{
+			WithOllamaTemperature(0.2),
+			WithOllamaTopK(40),
+		},
+	}).(*ollamaClient)
+
+	request := client.preparedRequest(nil, nil, false)
+	assert.Equal(t, map[string]any{
+		"num_ctx":     int64(8192),
+		"temperature": 0.2,
+		"top_k":       int64(40),
+	}, request.Options)
+
+	client = newOllamaClient(providerClientOptions{
+		model:         models.Model{APIModel: "llama3.1", ContextWindow: 8192},
+		ollamaOptions: []OllamaOption{WithOllamaNumCtx(32768)},
+	}).(*ollamaClient)
+	assert.Equal(t, int64(32768), client.preparedRequest(nil, nil, false).Options["num_ctx"])
+
+	// The detected context length is capped, numCtx allocates more
+	client = newOllamaClient(providerClientOptions{
+		model: models.Model{APIModel: "llama3.1", ContextWindow: 131072},
+	}).(*ollamaClient)
+	assert.Equal(t, int64(ollamaMaxNumCtx), client.preparedRequest(nil, nil, false).Options["num_ctx"])
+
+	client = newOllamaClient(providerClientOptions{
+		model:         models.Model{APIModel: "llama3.1", ContextWindow: 131072},
+		ollamaOptions: []OllamaOption{WithOllamaNumCtx(65536)},
+	}).(*ollamaClient)
+	assert.Equal(t, int64(65536), client.preparedRequest(nil, nil, false).Options["num_ctx"])
+
+	client = newOllamaClient(providerClientOptions{
+		model: models.Model{APIModel: "llama3.1", DefaultMaxTokens: 4096},
+	}).(*ollamaClient)
+	assert.Equal(t, int64(4096), client.preparedRequest(nil, nil, false).Options["num_predict"])
+
+	client = newOllamaClient(providerClientOptions{
+		model:     models.Model{APIModel: "llama3.1", DefaultMaxTokens: 4096},
+		maxTokens: 1024,
+	}).(*ollamaClient)
+	assert.Equal(t, int64(1024), client.preparedRequest(nil, nil, false).Options["num_predict"])
+}
+
+func TestOllamaClient_StopAndSeed(t *testing.T) {
+	client := newOllamaClient(providerClientOptions{
+		model:         models.Model{APIModel: "llama3.1"},
+		ollamaOptions: []OllamaOption{WithOllamaStop("<<<END>>>"), WithOllamaSeed(42)},
+	}).(*ollamaClient)
+	request := client.preparedRequest(nil, nil, false)
+	assert.Equal(t, []string{"<<<END>>>"}, request.Options["stop"])
+	assert.Equal(t, int64(42), request.Options["seed"])
+
+	// Completion prompts also stop before the next turn of the user
+	generate, err := client.generateRequest(request)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"<<<END>>>", "\nUser:"}, generate.Options["stop"])
+	assert.Equal(t, []string{"<<<END>>>"}, request.Options["stop"])
+
+	// Without stop sequences the options have none of their own
+	client = newOllamaClient(providerClientOptions{
+		model:         models.Model{APIModel: "llama3.1"},
+		ollamaOptions: []OllamaOption{WithOllamaStop()},
+	}).(*ollamaClient)
+	request = client.preparedRequest(nil, nil, false)
+	assert.NotContains(t, request.Options, "stop")
+	generate, err = client.generateRequest(request)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"\nUser:"}, generate.Options["stop"])
+}
+
+func TestOllamaClient_Format(t *testing.T) {
+	schema := map[string]any{
+		"type":                 "object",
+		"properties":           map[string]any{"title": map[string]any{"type": "string"}},
+		"required":             []any{"title"},
+		"additionalProperties": false,
+	}
+	tests := []struct {
+		name   string
+		option OllamaOption
+		format string
+	}{
+		{name: "json", option: WithOllamaJSONFormat(), format: `"json"`},
+		{name: "schema", option: WithOllamaJSONSchema(schema), format: `{"type":"object","properties":{"title":{"type":"string"}},"required":["title"],"additionalProperties":false}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var received map[string]json.RawMessage
+			client := newTestOllamaClient(t, func(w http.ResponseWriter, r *http.Request) {
+				assert.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+				fmt.Fprintln(w, `{"message":{"role":"assistant","content":"{\"title\":\"hi\"}"},"done":true,"done_reason":"stop"}`)
+			}, tt.option)
+
+			response, err := client.send(context.Background(), nil, nil)
+			require.NoError(t, err)
+			assert.JSONEq(t, `{"title":"hi"}`, response.Content)
+			assert.JSONEq(t, tt.format, string(received["format"]))
+		})
+	}
+}
+
+func TestOllamaClient_Headers(t *testing.T) {
+	tests := []struct {
+		name          string
+		apiKey        string
+		headers       map[string]string
+		authorization string
+		custom        string
+	}{
+		{name: "local server"},
+		{name: "api key", apiKey: "secret", authorization: "Bearer secret"},
+		{name: "custom headers", headers: map[string]string{"X-Custom": "value"}, custom: "value"},
+		{
+			name:          "basic auth of a proxy",
+			apiKey:        "secret",
+			headers:       map[string]string{"Authorization": "Basic dXNlcjpwYXNz", "X-Custom": "value"},
+			authorization: "Basic dXNlcjpwYXNz",
+			custom:        "value",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var paths []string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				paths = append(paths, r.URL.Path)
+				assert.Equal(t, tt.authorization, r.Header.Get("Authorization"))
+				assert.Equal(t, tt.custom, r.Header.Get("X-Custom"))
+				if r.URL.Path == "/api/embed" {
+					fmt.Fprintln(w, `{"embeddings":[[0.1]]}`)
+					return
+				}
+				fmt.Fprintln(w, `{"message":{"role":"assistant","content":"hi"},"done":true,"done_reason":"stop"}`)
+			}))
+			t.Cleanup(server.Close)
+
+			client := newOllamaClient(providerClientOptions{
+				apiKey:        tt.apiKey,
+				model:         models.Model{APIModel: "llama3.1"},
+				ollamaOptions: []OllamaOption{WithOllamaBaseURL(server.URL), WithOllamaHeaders(tt.headers)},
+			}).(*ollamaClient)
+
+			_, err := client.send(context.Background(), nil, nil)
+			require.NoError(t, err)
+			for event := range client.stream(context.Background(), nil, nil) {
+				require.NoError(t, event.Error)
+			}
+			_, err = client.Embed(context.Background(), []string{"a"})
+			require.NoError(t, err)
+			assert.Equal(t, []string{"/api/chat", "/api/chat", "/api/embed"}, paths)
+		})
+	}
+}
+
+func TestOllamaClient_Embed(t *testing.T) {
+	client := newTestOllamaClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/embed", r.URL.Path)
+		var request ollamaEmbedRequest
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+		assert.Equal(t, []string{"a", "b"}, request.Input)
+		fmt.Fprintln(w, `{"embeddings":[[0.1,0.2],[0.3,0.4]]}`)
+	})
+
+	embeddings, err := client.Embed(context.Background(), []string{"a", "b"})
+	require.NoError(t, err)
+	assert.Equal(t, [][]float32{{0.1, 0.2}, {0.3, 0.4}}, embeddings)
+}
+
+func TestOllamaClient_ConvertImages(t *testing.T) {
+	client := newOllamaClient(providerClientOptions{}).(*ollamaClient)
+	msg := message.Message{Role: message.User}
+	msg.AppendContent("describe this")
+	msg.AddBinary("image/png", []byte("png"))
+	msg.AddImageURL("data:image/jpeg;base64,anBn", "")
+	msg.AddBinary("text/plain", []byte("ignored"))
+
+	converted := client.convertMessages([]message.Message{msg})
+	require.Len(t, converted, 2)
+	assert.Equal(t, []string{"cG5n", "anBn"}, converted[1].Images)
+}
+
+func TestOllamaClient_Failover(t *testing.T) {
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+	busy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		fmt.Fprintln(w, `{"error":"server busy, please try again"}`)
+	}))
+	t.Cleanup(busy.Close)
+	healthy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"message":{"role":"assistant","content":"hi"},"done":true,"done_reason":"stop"}`)
+	}))
+	t.Cleanup(healthy.Close)
+
+	client := newOllamaClient(providerClientOptions{
+		model:         models.Model{APIModel: "llama3.1"},
+		ollamaOptions: []OllamaOption{WithOllamaBaseURLs(down.URL, busy.URL, healthy.URL)},
+	}).(*ollamaClient)
+
+	response, err := client.send(context.Background(), nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "hi", response.Content)
+	assert.Equal(t, []string{busy.URL, healthy.URL, down.URL}, client.hosts.order())
+}
+
+func TestOllamaClient_CompletionMode(t *testing.T) {
+	var received ollamaGenerateRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/generate", r.URL.Path)
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		fmt.Fprintln(w, `{"response":" Hello","done":false}`)
+		fmt.Fprintln(w, `{"response":"","done":true,"done_reason":"stop"}`)
+	}))
+	t.Cleanup(server.Close)
+
+	client := newOllamaClient(providerClientOptions{
+		model:         models.Model{APIModel: "starcoder2:3b"},
+		systemMessage: "system",
+		ollamaOptions: []OllamaOption{WithOllamaBaseURL(server.URL), WithOllamaCompletionMode()},
+	}).(*ollamaClient)
+
+	history := []message.Message{
+		{Role: message.User, Parts: []message.ContentPart{message.TextContent{Text: "hi"}}},
+	}
+	var complete *ProviderResponse
+	for event := range client.stream(context.Background(), history, nil) {
+		require.NoError(t, event.Error)
+		if event.Type == EventComplete {
+			complete = event.Response
+		}
+	}
+
+	assert.True(t, received.Raw)
+	assert.Equal(t, "system\n\nUser: hi\n\nAssistant:", received.Prompt)
+	require.NotNil(t, complete)
+	assert.Equal(t, " Hello", complete.Content)
+}
+
+func TestOllamaClient_Template(t *testing.T) {
+	client := newOllamaClient(providerClientOptions{
+		model:         models.Model{APIModel: "my-finetune"},
+		systemMessage: "system",
+		ollamaOptions: []OllamaOption{
+			WithOllamaTemplate("<|sys|>{{ .System }}{{ range .Messages }}<|{{ .Role }}|>{{ .Content }}{{ end }}<|assistant|>"),
+		},
+	}).(*ollamaClient)
+
+	history := []message.Message{
+		{Role: message.User, Parts: []message.ContentPart{message.TextContent{Text: "hi"}}},
+		{Role: message.Assistant, Parts: []message.ContentPart{message.TextContent{Text: "hello"}}},
+		{Role: message.User, Parts: []message.ContentPart{message.TextContent{Text: "bye"}}},
+	}
+	request, err := client.generateRequest(client.preparedRequest(client.convertMessages(history), nil, false))
+	require.NoError(t, err)
+	assert.True(t, request.Raw)
+	assert.Equal(t, "<|sys|>system<|user|>hi<|assistant|>hello<|user|>bye<|assistant|>", request.Prompt)
+	assert.Nil(t, request.Options["stop"])
+}
+
+func TestOllamaClient_ThinkTags(t *testing.T) {
+	client := newTestOllamaClient(t, func(w http.ResponseWriter, r *http.Request) {
+		for _, chunk := range []string{"<thi", "nk>plan the ", "change</th", "ink>\n\nDone", "."} {
+			fmt.Fprintf(w, `{"message":{"role":"assistant","content":%q},"done":false}`+"\n", chunk)
+		}
+		fmt.Fprintln(w, `{"message":{"role":"assistant","content":""},"done":true,"done_reason":"stop"}`)
+	})
+
+	var thinking, content string
+	var complete *ProviderResponse
+	history := []message.Message{
+		{Role: message.User, Parts: []message.ContentPart{message.TextContent{Text: "hi"}}},
+	}
+	for event := range client.stream(context.Background(), history, nil) {
+		require.NoError(t, event.Error)
+		switch event.Type {
+		case EventThinkingDelta:
+			thinking += event.Thinking
+		case EventContentDelta:
+			content += event.Content
+		case EventComplete:
+			complete = event.Response
+		}
+	}
+
+	assert.Equal(t, "plan the change", thinking)
+	assert.Equal(t, "Done.", content)
+	require.NotNil(t, complete)
+	assert.Equal(t, "Done.", complete.Content)
+}
+
+func TestOllamaClient_UnixSocket(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "ollama.sock")
+	listener, err := net.Listen("unix", socket)
+	require.NoError(t, err)
+	server := &httptest.Server{
+		Listener: listener,
+		Config: &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/api/chat", r.URL.Path)
+			fmt.Fprintln(w, `{"message":{"role":"assistant","content":"hi"},"done":true,"done_reason":"stop"}`)
+		})},
+	}
+	server.Start()
+	t.Cleanup(server.Close)
+
+	client := newOllamaClient(providerClientOptions{
+		model:         models.Model{APIModel: "llama3.1"},
+		ollamaOptions: []OllamaOption{WithOllamaBaseURL("unix://" + socket)},
+	}).(*ollamaClient)
+
+	response, err := client.send(context.Background(), nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "hi", response.Content)
+}
+
+func TestOllamaClient_RetryTransientErrors(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprintln(w, `{"message":{"role":"assistant","content":"hi"},"done":true,"done_reason":"stop"}`)
+	}))
+	t.Cleanup(server.Close)
+
+	policy := DefaultRetryPolicy()
+	policy.InitialBackoff = time.Millisecond
+	client := newOllamaClient(providerClientOptions{
+		model:         models.Model{APIModel: "llama3.1"},
+		retryPolicy:   policy,
+		ollamaOptions: []OllamaOption{WithOllamaBaseURL(server.URL)},
+	}).(*ollamaClient)
+
+	response, err := client.send(context.Background(), nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "hi", response.Content)
+	assert.Equal(t, 2, requests)
+}
+
+func TestOllamaClient_Trace(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"message":{"role":"assistant","content":"hi"},"done":false}`)
+		fmt.Fprintln(w, `{"message":{"role":"assistant","content":""},"done":true,"done_reason":"stop"}`)
+	}))
+	t.Cleanup(server.Close)
+
+	dir := t.TempDir()
+	client := newOllamaClient(providerClientOptions{
+		apiKey:        "secret",
+		model:         models.Model{APIModel: "llama3.1"},
+		traceDir:      dir,
+		ollamaOptions: []OllamaOption{WithOllamaBaseURL(server.URL)},
+	}).(*ollamaClient)
+
+	msg := message.Message{Role: message.User}
+	msg.AppendContent("hello")
+	for range client.stream(context.Background(), []message.Message{msg}, nil) {
+	}
+
+	traces, err := filepath.Glob(filepath.Join(dir, "*.log"))
+	require.NoError(t, err)
+	require.Len(t, traces, 1)
+	trace, err := os.ReadFile(traces[0])
+	require.NoError(t, err)
+	assert.Contains(t, string(trace), `"content":"hello"`)
+	assert.Contains(t, string(trace), `"done_reason":"stop"`)
+	assert.Contains(t, string(trace), "Authorization: [REDACTED]")
+	assert.NotContains(t, string(trace), "secret")
+}
+
+func TestOllamaProvider_StreamCanceled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"message":{"role":"assistant","content":"Hel"},"done":false}`)
+		fmt.Fprintln(w, `{"message":{"role":"assistant","content":"lo"},"done":false}`)
+		w.(http.Flusher).Flush()
+		// The rest of the response never comes
+		<-r.Context().Done()
+	}))
+	t.Cleanup(server.Close)
+
+	p, err := NewProvider(models.ProviderOllama,
+		WithModel(models.OllamaModel("llama3.1")),
+		WithOllamaOptions(WithOllamaBaseURL(server.URL)),
+		WithRetryPolicy(RetryPolicy{}),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	msg := message.Message{Role: message.User}
+	msg.AppendContent("hello")
+	var content string
+	var complete *ProviderResponse
+	for event := range p.StreamResponse(ctx, []message.Message{msg}, nil) {
+		require.NoError(t, event.Error)
+		switch event.Type {
+		case EventContentDelta:
+			content += event.Content
+			if content == "Hello" {
+				cancel()
+			}
+		case EventComplete:
+			complete = event.Response
+		}
+	}
+
+	require.NotNil(t, complete)
+	assert.Equal(t, "Hello", complete.Content)
+	assert.Equal(t, message.FinishReasonCanceled, complete.FinishReason)
+}
@@ -6,6 +6,10 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"maps"
+	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/openai/openai-go"
@@ -21,6 +25,17 @@ type openaiOptions struct {
 	baseURL         string
 	disableCache    bool
 	reasoningEffort string
+	headers         map[string]string
+	// compatible marks a third party server with an OpenAI compatible API
+	compatible bool
+	// thinkTags separates <think> segments in the content as reasoning
+	thinkTags bool
+	// reasoningContent marks a server that always reasons and returns the
+	// reasoning in reasoning_content instead of taking reasoning parameters
+	reasoningContent bool
+	// noStreamOptions is set for servers that report the usage of a stream
+	// unasked and reject the stream_options parameter
+	noStreamOptions bool
 }
 
 type OpenAIOption func(*openaiOptions)
@@ -42,12 +57,27 @@ func newOpenAIClient(opts providerClientOptions) OpenAIClient {
 	}
 
 	openaiClientOptions := []option.RequestOption{}
+	if openaiOpts.compatible {
+		// The SDK picks up the OpenAI credentials from the environment, they
+		// must not leak to a third party server
+		openaiClientOptions = append(openaiClientOptions,
+			option.WithHeaderDel("authorization"),
+			option.WithHeaderDel("OpenAI-Organization"),
+			option.WithHeaderDel("OpenAI-Project"),
+		)
+	}
 	if opts.apiKey != "" {
 		openaiClientOptions = append(openaiClientOptions, option.WithAPIKey(opts.apiKey))
 	}
 	if openaiOpts.baseURL != "" {
 		openaiClientOptions = append(openaiClientOptions, option.WithBaseURL(openaiOpts.baseURL))
 	}
+	for k, v := range openaiOpts.headers {
+		openaiClientOptions = append(openaiClientOptions, option.WithHeader(k, v))
+	}
+	if opts.customTransport() {
+		openaiClientOptions = append(openaiClientOptions, option.WithHTTPClient(opts.httpClient()))
+	}
 
 	client := openai.NewClient(openaiClientOptions...)
 	return &openaiClient{
@@ -64,7 +94,18 @@ func (o *openaiClient) convertMessages(messages []message.Message) (openaiMessag
 	for _, msg := range messages {
 		switch msg.Role {
 		case message.User:
-			openaiMessages = append(openaiMessages, openai.UserMessage(msg.Content().String()))
+			images := msg.BinaryContent()
+			if len(images) == 0 {
+				openaiMessages = append(openaiMessages, openai.UserMessage(msg.Content().String()))
+				continue
+			}
+			parts := []openai.ChatCompletionContentPartUnionParam{openai.TextContentPart(msg.Content().String())}
+			for _, binary := range images {
+				if strings.HasPrefix(binary.MIMEType, "image/") {
+					parts = append(parts, openai.ImageContentPart(openai.ChatCompletionContentPartImageImageURLParam{URL: binary.String()}))
+				}
+			}
+			openaiMessages = append(openaiMessages, openai.UserMessage(parts))
 
 		case message.Assistant:
 			assistantMsg := openai.ChatCompletionAssistantMessageParam{
@@ -148,9 +189,13 @@ func (o *openaiClient) preparedParams(messages []openai.ChatCompletionMessagePar
 		Tools:    tools,
 	}
 
-	if o.providerOptions.model.CanReason == true {
+	if o.providerOptions.model.CanReason == true && !o.options.reasoningContent {
 		params.MaxCompletionTokens = openai.Int(o.providerOptions.maxTokens)
-		switch o.options.reasoningEffort {
+		effort := o.options.reasoningEffort
+		if o.providerOptions.reasoningEffort != "" {
+			effort = o.providerOptions.reasoningEffort
+		}
+		switch effort {
 		case "low":
 			params.ReasoningEffort = shared.ReasoningEffortLow
 		case "medium":
@@ -169,8 +214,7 @@ func (o *openaiClient) preparedParams(messages []openai.ChatCompletionMessagePar
 
 func (o *openaiClient) send(ctx context.Context, messages []message.Message, tools []tools.BaseTool) (response *ProviderResponse, err error) {
 	params := o.preparedParams(o.convertMessages(messages), o.convertTools(tools))
-	cfg := config.Get()
-	if cfg.Debug {
+	if cfg := config.Get(); cfg != nil && cfg.Debug {
 		jsonData, _ := json.Marshal(params)
 		logging.Debug("Prepared messages", "messages", string(jsonData))
 	}
@@ -188,7 +232,7 @@ func (o *openaiClient) send(ctx context.Context, messages []message.Message, too
 				return nil, retryErr
 			}
 			if retry {
-				logging.WarnPersist(fmt.Sprintf("Retrying due to rate limit... attempt %d of %d", attempts, maxRetries), logging.PersistTimeArg, time.Millisecond*time.Duration(after+100))
+				logging.WarnPersist(fmt.Sprintf("Retrying due to rate limit... attempt %d of %d", attempts, o.providerOptions.retryPolicy.MaxAttempts), logging.PersistTimeArg, time.Millisecond*time.Duration(after+100))
 				select {
 				case <-ctx.Done():
 					return nil, ctx.Err()
@@ -203,6 +247,12 @@ func (o *openaiClient) send(ctx context.Context, messages []message.Message, too
 		if openaiResponse.Choices[0].Message.Content != "" {
 			content = openaiResponse.Choices[0].Message.Content
 		}
+		if o.options.thinkTags {
+			var splitter thinkSplitter
+			_, content = splitter.feed(content)
+			_, rest := splitter.flush()
+			content += rest
+		}
 
 		return &ProviderResponse{
 			Content:      content,
@@ -215,12 +265,13 @@ func (o *openaiClient) send(ctx context.Context, messages []message.Message, too
 
 func (o *openaiClient) stream(ctx context.Context, messages []message.Message, tools []tools.BaseTool) <-chan ProviderEvent {
 	params := o.preparedParams(o.convertMessages(messages), o.convertTools(tools))
-	params.StreamOptions = openai.ChatCompletionStreamOptionsParam{
-		IncludeUsage: openai.Bool(true),
+	if !o.options.noStreamOptions {
+		params.StreamOptions = openai.ChatCompletionStreamOptionsParam{
+			IncludeUsage: openai.Bool(true),
+		}
 	}
 
-	cfg := config.Get()
-	if cfg.Debug {
+	if cfg := config.Get(); cfg != nil && cfg.Debug {
 		jsonData, _ := json.Marshal(params)
 		logging.Debug("Prepared messages", "messages", string(jsonData))
 	}
@@ -237,12 +288,39 @@ func (o *openaiClient) stream(ctx context.Context, messages []message.Message, t
 			)
 
 			acc := openai.ChatCompletionAccumulator{}
+			var groqUsage *openai.CompletionUsage
+			var cacheHitTokens int64
 			currentContent := ""
 			toolCalls := make([]message.ToolCall, 0)
+			var splitter thinkSplitter
+			emit := func(thinking, content string) {
+				if thinking != "" {
+					eventChan <- ProviderEvent{
+						Type:     EventThinkingDelta,
+						Thinking: thinking,
+					}
+				}
+				if content != "" {
+					eventChan <- ProviderEvent{
+						Type:    EventContentDelta,
+						Content: content,
+					}
+					currentContent += content
+				}
+			}
 
 			for openaiStream.Next() {
 				chunk := openaiStream.Current()
 				acc.AddChunk(chunk)
+				// Groq reports the usage of a stream in its x_groq extension
+				if field, ok := chunk.JSON.ExtraFields["x_groq"]; ok {
+					var ext struct {
+						Usage *openai.CompletionUsage `json:"usage"`
+					}
+					if json.Unmarshal([]byte(field.Raw()), &ext) == nil && ext.Usage != nil {
+						groqUsage = ext.Usage
+					}
+				}
 
 				if tool, ok := acc.JustFinishedToolCall(); ok {
 					toolCalls = append(toolCalls, message.ToolCall{
@@ -253,13 +331,26 @@ func (o *openaiClient) stream(ctx context.Context, messages []message.Message, t
 					})
 				}
 
+				if hits := promptCacheHitTokens(chunk.Usage); hits > 0 {
+					cacheHitTokens = hits
+				}
+
 				for _, choice := range chunk.Choices {
-					if choice.Delta.Content != "" {
-						eventChan <- ProviderEvent{
-							Type:    EventContentDelta,
-							Content: choice.Delta.Content,
+					if o.options.reasoningContent {
+						if field, ok := choice.Delta.JSON.ExtraFields["reasoning_content"]; ok {
+							var reasoning string
+							if json.Unmarshal([]byte(field.Raw()), &reasoning) == nil {
+								emit(reasoning, "")
+							}
 						}
-						currentContent += choice.Delta.Content
+					}
+					if choice.Delta.Content == "" {
+						continue
+					}
+					if o.options.thinkTags {
+						emit(splitter.feed(choice.Delta.Content))
+					} else {
+						emit("", choice.Delta.Content)
 					}
 				}
 			}
@@ -267,13 +358,29 @@ func (o *openaiClient) stream(ctx context.Context, messages []message.Message, t
 			err := openaiStream.Err()
 			if err == nil || errors.Is(err, io.EOF) {
 				// Stream completed successfully
+				emit(splitter.flush())
+				// Local servers often send the whole tool call and the finish
+				// reason in the last chunk, so the call never counts as finished
+				if len(toolCalls) == 0 {
+					toolCalls = o.toolCalls(acc.ChatCompletion)
+				}
+				if acc.Usage.PromptTokens == 0 && groqUsage != nil {
+					acc.Usage = *groqUsage
+				}
+				if cacheHitTokens > 0 {
+					acc.Usage.PromptTokensDetails.CachedTokens = cacheHitTokens
+				}
+				finishReason := ""
+				if len(acc.ChatCompletion.Choices) > 0 {
+					finishReason = string(acc.ChatCompletion.Choices[0].FinishReason)
+				}
 				eventChan <- ProviderEvent{
 					Type: EventComplete,
 					Response: &ProviderResponse{
 						Content:      currentContent,
 						ToolCalls:    toolCalls,
 						Usage:        o.usage(acc.ChatCompletion),
-						FinishReason: o.finishReason(string(acc.ChatCompletion.Choices[0].FinishReason)),
+						FinishReason: o.finishReason(finishReason),
 					},
 				}
 				close(eventChan)
@@ -288,7 +395,7 @@ func (o *openaiClient) stream(ctx context.Context, messages []message.Message, t
 				return
 			}
 			if retry {
-				logging.WarnPersist(fmt.Sprintf("Retrying due to rate limit... attempt %d of %d", attempts, maxRetries), logging.PersistTimeArg, time.Millisecond*time.Duration(after+100))
+				logging.WarnPersist(fmt.Sprintf("Retrying due to rate limit... attempt %d of %d", attempts, o.providerOptions.retryPolicy.MaxAttempts), logging.PersistTimeArg, time.Millisecond*time.Duration(after+100))
 				select {
 				case <-ctx.Done():
 					// context cancelled
@@ -310,32 +417,35 @@ func (o *openaiClient) stream(ctx context.Context, messages []message.Message, t
 	return eventChan
 }
 
+// toolCallID shortens IDs longer than the 40 characters OpenAI accepts, such
+// as the ones generated for Gemini and Ollama tool calls.
+func (o *openaiClient) toolCallID(id string) string {
+	if len(id) <= 40 {
+		return id
+	}
+	return "call_" + hashToolCallID(id)[:32]
+}
+
 func (o *openaiClient) shouldRetry(attempts int, err error) (bool, int64, error) {
 	var apierr *openai.Error
 	if !errors.As(err, &apierr) {
 		return false, 0, err
 	}
 
-	if apierr.StatusCode != 429 && apierr.StatusCode != 500 {
+	policy := o.providerOptions.retryPolicy
+	if !policy.retryableStatus(apierr.StatusCode) {
 		return false, 0, err
 	}
 
-	if attempts > maxRetries {
-		return false, 0, fmt.Errorf("maximum retry attempts reached for rate limit: %d retries", maxRetries)
+	if attempts > policy.MaxAttempts {
+		return false, 0, fmt.Errorf("maximum retry attempts reached for rate limit: %d retries: %w", policy.MaxAttempts, err)
 	}
 
-	retryMs := 0
-	retryAfterValues := apierr.Response.Header.Values("Retry-After")
-
-	backoffMs := 2000 * (1 << (attempts - 1))
-	jitterMs := int(float64(backoffMs) * 0.2)
-	retryMs = backoffMs + jitterMs
-	if len(retryAfterValues) > 0 {
-		if _, err := fmt.Sscanf(retryAfterValues[0], "%d", &retryMs); err == nil {
-			retryMs = retryMs * 1000
-		}
+	var header http.Header
+	if apierr.Response != nil {
+		header = apierr.Response.Header
 	}
-	return true, int64(retryMs), nil
+	return true, policy.backoff(attempts, retryAfter(header)).Milliseconds(), nil
 }
 
 func (o *openaiClient) toolCalls(completion openai.ChatCompletion) []message.ToolCall {
@@ -359,6 +469,9 @@ func (o *openaiClient) toolCalls(completion openai.ChatCompletion) []message.Too
 
 func (o *openaiClient) usage(completion openai.ChatCompletion) TokenUsage {
 	cachedTokens := completion.Usage.PromptTokensDetails.CachedTokens
+	if cachedTokens == 0 {
+		cachedTokens = promptCacheHitTokens(completion.Usage)
+	}
 	inputTokens := completion.Usage.PromptTokens - cachedTokens
 
 	return TokenUsage{
@@ -369,6 +482,17 @@ func (o *openaiClient) usage(completion openai.ChatCompletion) TokenUsage {
 	}
 }
 
+// promptCacheHitTokens returns the prompt tokens DeepSeek read from its cache,
+// which it reports next to the usage instead of in the prompt token details.
+func promptCacheHitTokens(usage openai.CompletionUsage) int64 {
+	field, ok := usage.JSON.ExtraFields["prompt_cache_hit_tokens"]
+	if !ok {
+		return 0
+	}
+	hits, _ := strconv.ParseInt(field.Raw(), 10, 64)
+	return hits
+}
+
 func WithOpenAIBaseURL(baseURL string) OpenAIOption {
 	return func(options *openaiOptions) {
 		options.baseURL = baseURL
@@ -393,3 +517,43 @@ func WithReasoningEffort(effort string) OpenAIOption {
 		options.reasoningEffort = defaultReasoningEffort
 	}
 }
+
+// WithOpenAIHeaders adds custom headers to every request.
+func WithOpenAIHeaders(headers map[string]string) OpenAIOption {
+	return func(options *openaiOptions) {
+		options.headers = headers
+	}
+}
+
+// withOpenAIDefaultHeaders adds headers that are not set by WithOpenAIHeaders.
+func withOpenAIDefaultHeaders(headers map[string]string) OpenAIOption {
+	return func(options *openaiOptions) {
+		merged := maps.Clone(headers)
+		maps.Copy(merged, options.headers)
+		options.headers = merged
+	}
+}
+
+func withOpenAICompatible() OpenAIOption {
+	return func(options *openaiOptions) {
+		options.compatible = true
+	}
+}
+
+func withOpenAIReasoningContent() OpenAIOption {
+	return func(options *openaiOptions) {
+		options.reasoningContent = true
+	}
+}
+
+func withOpenAINoStreamOptions() OpenAIOption {
+	return func(options *openaiOptions) {
+		options.noStreamOptions = true
+	}
+}
+
+func withOpenAIThinkTags() OpenAIOption {
+	return func(options *openaiOptions) {
+		options.thinkTags = true
+	}
+}
@@ -0,0 +1,218 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/opencode-ai/opencode/internal/llm/models"
+	"github.com/opencode-ai/opencode/internal/message"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenAICompatibleProvider(t *testing.T) {
+	t.Setenv("OPENAI_API_KEY", "sk-openai")
+
+	var authorization, custom string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/chat/completions", r.URL.Path)
+		authorization = r.Header.Get("Authorization")
+		custom = r.Header.Get("X-Custom")
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintln(w, `{"id":"1","object":"chat.completion","model":"qwen","choices":[{"index":0,"finish_reason":"stop","message":{"role":"assistant","content":"hi"}}],"usage":{"prompt_tokens":3,"completion_tokens":1}}`)
+	}))
+	t.Cleanup(server.Close)
+
+	p, err := NewProvider(models.ProviderOpenAICompatible,
+		WithModel(models.OpenAICompatibleModel("qwen")),
+		WithOpenAIOptions(
+			WithOpenAIBaseURL(server.URL+"/v1"),
+			WithOpenAIHeaders(map[string]string{"X-Custom": "value"}),
+		),
+		WithRetryPolicy(RetryPolicy{}),
+	)
+	require.NoError(t, err)
+
+	msg := message.Message{Role: message.User}
+	msg.AppendContent("hello")
+	response, err := p.SendMessages(context.Background(), []message.Message{msg}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "hi", response.Content)
+	assert.Empty(t, authorization)
+	assert.Equal(t, "value", custom)
+}
+
+func TestLMStudioProvider_Stream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/chat/completions", r.URL.Path)
+		w.Header().Set("Content-Type", "text/event-stream")
+		chunks := []string{
+			`{"id":"1","object":"chat.completion.chunk","model":"qwen3","choices":[{"index":0,"delta":{"role":"assistant","content":"<think>look"}}]}`,
+			`{"id":"1","object":"chat.completion.chunk","model":"qwen3","choices":[{"index":0,"delta":{"content":"</think>Reading"}}]}`,
+			`{"id":"1","object":"chat.completion.chunk","model":"qwen3","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"id":"call_1","type":"function","function":{"name":"view","arguments":"{\"file_path\":\"main.go\"}"}}]},"finish_reason":"tool_calls"}]}`,
+		}
+		for _, chunk := range chunks {
+			fmt.Fprintf(w, "data: %s\n\n", chunk)
+		}
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	t.Cleanup(server.Close)
+
+	p, err := NewProvider(models.ProviderLMStudio,
+		WithModel(models.LMStudioModel("qwen3")),
+		WithOpenAIOptions(WithOpenAIBaseURL(server.URL+"/v1")),
+		WithRetryPolicy(RetryPolicy{}),
+	)
+	require.NoError(t, err)
+
+	msg := message.Message{Role: message.User}
+	msg.AppendContent("read main.go")
+	var thinking, content string
+	var complete *ProviderResponse
+	for event := range p.StreamResponse(context.Background(), []message.Message{msg}, nil) {
+		require.NoError(t, event.Error)
+		switch event.Type {
+		case EventThinkingDelta:
+			thinking += event.Thinking
+		case EventContentDelta:
+			content += event.Content
+		case EventComplete:
+			complete = event.Response
+		}
+	}
+
+	assert.Equal(t, "look", thinking)
+	assert.Equal(t, "Reading", content)
+	require.NotNil(t, complete)
+	assert.Equal(t, message.FinishReasonToolUse, complete.FinishReason)
+	require.Len(t, complete.ToolCalls, 1)
+	assert.Equal(t, "view", complete.ToolCalls[0].Name)
+}
+
+func TestOpenAIClient_GroqStreamUsage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		chunks := []string{
+			`{"id":"1","object":"chat.completion.chunk","model":"llama-3.1-8b-instant","choices":[{"index":0,"delta":{"role":"assistant","content":"hi"}}]}`,
+			`{"id":"1","object":"chat.completion.chunk","model":"llama-3.1-8b-instant","choices":[{"index":0,"delta":{},"finish_reason":"stop"}],"x_groq":{"id":"req_1","usage":{"prompt_tokens":12,"completion_tokens":2,"total_tokens":14}}}`,
+		}
+		for _, chunk := range chunks {
+			fmt.Fprintf(w, "data: %s\n\n", chunk)
+		}
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	t.Cleanup(server.Close)
+
+	client := newOpenAIClient(providerClientOptions{
+		apiKey:        "gsk-test",
+		model:         models.GroqModels[models.Llama3_1_8BInstant],
+		openaiOptions: []OpenAIOption{WithOpenAIBaseURL(server.URL), withOpenAICompatible()},
+	})
+	msg := message.Message{Role: message.User}
+	msg.AppendContent("hello")
+
+	var complete *ProviderResponse
+	for event := range client.stream(context.Background(), []message.Message{msg}, nil) {
+		require.NoError(t, event.Error)
+		if event.Type == EventComplete {
+			complete = event.Response
+		}
+	}
+	require.NotNil(t, complete)
+	assert.Equal(t, TokenUsage{InputTokens: 12, OutputTokens: 2}, complete.Usage)
+}
+
+func TestOpenAIClient_DeepSeekReasoningContent(t *testing.T) {
+	var request map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+		w.Header().Set("Content-Type", "text/event-stream")
+		chunks := []string{
+			`{"id":"1","object":"chat.completion.chunk","model":"deepseek-reasoner","choices":[{"index":0,"delta":{"role":"assistant","content":null,"reasoning_content":"The user greets"}}]}`,
+			`{"id":"1","object":"chat.completion.chunk","model":"deepseek-reasoner","choices":[{"index":0,"delta":{"content":"Hello!","reasoning_content":null}}]}`,
+			`{"id":"1","object":"chat.completion.chunk","model":"deepseek-reasoner","choices":[{"index":0,"delta":{"content":""},"finish_reason":"stop"}],"usage":{"prompt_tokens":100,"completion_tokens":20,"total_tokens":120,"prompt_cache_hit_tokens":64,"prompt_cache_miss_tokens":36}}`,
+		}
+		for _, chunk := range chunks {
+			fmt.Fprintf(w, "data: %s\n\n", chunk)
+		}
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	t.Cleanup(server.Close)
+
+	client := newOpenAIClient(providerClientOptions{
+		apiKey:        "sk-test",
+		model:         models.DeepSeekModels[models.DeepSeekReasoner],
+		maxTokens:     1000,
+		openaiOptions: []OpenAIOption{WithOpenAIBaseURL(server.URL), withOpenAICompatible(), withOpenAIReasoningContent()},
+	})
+	msg := message.Message{Role: message.User}
+	msg.AppendContent("hello")
+
+	var thinking, content string
+	var complete *ProviderResponse
+	for event := range client.stream(context.Background(), []message.Message{msg}, nil) {
+		require.NoError(t, event.Error)
+		switch event.Type {
+		case EventThinkingDelta:
+			thinking += event.Thinking
+		case EventContentDelta:
+			content += event.Content
+		case EventComplete:
+			complete = event.Response
+		}
+	}
+	assert.NotContains(t, request, "reasoning_effort")
+	assert.Equal(t, float64(1000), request["max_tokens"])
+	assert.Equal(t, "The user greets", thinking)
+	assert.Equal(t, "Hello!", content)
+	require.NotNil(t, complete)
+	assert.Equal(t, TokenUsage{InputTokens: 36, OutputTokens: 20, CacheReadTokens: 64}, complete.Usage)
+}
+
+func TestXAIProvider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer xai-key", r.Header.Get("Authorization"))
+		w.Header().Set("Content-Type", "text/event-stream")
+		chunks := []string{
+			`{"id":"1","object":"chat.completion.chunk","model":"grok-3-mini","choices":[{"index":0,"delta":{"role":"assistant","reasoning_content":"Greeting back"}}]}`,
+			`{"id":"1","object":"chat.completion.chunk","model":"grok-3-mini","choices":[{"index":0,"delta":{"content":"Hi!"},"finish_reason":"stop"}],"usage":{"prompt_tokens":10,"completion_tokens":5,"total_tokens":15}}`,
+		}
+		for _, chunk := range chunks {
+			fmt.Fprintf(w, "data: %s\n\n", chunk)
+		}
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	t.Cleanup(server.Close)
+
+	p, err := NewProvider(models.ProviderXAI, WithAPIKey("xai-key"), WithModel(models.XAIModels[models.XAIGrok3Mini]))
+	require.NoError(t, err)
+	xai := p.(*capabilityProvider).Provider.(*baseProvider[OpenAIClient]).client.(*openaiClient)
+	assert.Equal(t, "https://api.x.ai/v1", xai.options.baseURL)
+	assert.True(t, xai.options.compatible)
+	assert.True(t, xai.options.reasoningContent)
+
+	// The same client, sending to the test server
+	options := xai.providerOptions
+	options.openaiOptions = append(slices.Clone(options.openaiOptions), WithOpenAIBaseURL(server.URL))
+	client := newOpenAIClient(options)
+	msg := message.Message{Role: message.User}
+	msg.AppendContent("hello")
+
+	var thinking, content string
+	for event := range client.stream(context.Background(), []message.Message{msg}, nil) {
+		require.NoError(t, event.Error)
+		switch event.Type {
+		case EventThinkingDelta:
+			thinking += event.Thinking
+		case EventContentDelta:
+			content += event.Content
+		}
+	}
+	assert.Equal(t, "Greeting back", thinking)
+	assert.Equal(t, "Hi!", content)
+}
@@ -2,7 +2,15 @@ package provider
 
 import (
 	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
 
 	"github.com/opencode-ai/opencode/internal/llm/models"
 	"github.com/opencode-ai/opencode/internal/llm/tools"
@@ -38,6 +46,13 @@ type ProviderResponse struct {
 	ToolCalls    []message.ToolCall
 	Usage        TokenUsage
 	FinishReason message.FinishReason
+	// Model is the model that answered when the turn fell back to another
+	// provider
+	Model models.ModelID
+	// FromCache is set when the response cache answered the request, CacheKey
+	// is the key of the request in the cache when it is enabled
+	FromCache bool
+	CacheKey  string
 }
 
 type ProviderEvent struct {
@@ -57,16 +72,50 @@ type Provider interface {
 	Model() models.Model
 }
 
+// Embedder turns text into embedding vectors, one vector per input.
+type Embedder interface {
+	Embed(ctx context.Context, input []string) ([][]float32, error)
+}
+
 type providerClientOptions struct {
 	apiKey        string
 	model         models.Model
 	maxTokens     int64
 	systemMessage string
+	tlsConfig     *tls.Config
+	proxy         func(*http.Request) (*url.URL, error)
+	timeout       time.Duration
+	retryPolicy   RetryPolicy
+	traceDir      string
+	// authTransport authenticates the requests with credentials that expire,
+	// e.g. the tokens of Copilot
+	authTransport func(http.RoundTripper) http.RoundTripper
+
+	// reasoningEffort and thinkingBudget tune reasoning models, they are
+	// ignored by models that cannot reason
+	reasoningEffort string
+	thinkingBudget  int64
+
+	// middleware wraps every request to the client
+	middleware []Middleware
+
+	// rateLimiter queues the requests beyond the budget of the provider
+	rateLimit   RateLimit
+	rateLimiter *rateLimiter
+
+	// cache answers the requests that were already answered
+	cacheDir string
+	cache    *responseCache
 
 	anthropicOptions []AnthropicOption
 	openaiOptions    []OpenAIOption
 	geminiOptions    []GeminiOption
 	bedrockOptions   []BedrockOption
+	ollamaOptions    []OllamaOption
+	llamacppOptions  []LlamaCppOption
+	ggufOptions      []GGUFOption
+	azureOptions     []AzureOption
+	vertexaiOptions  []VertexAIOption
 }
 
 type ProviderClientOption func(*providerClientOptions)
@@ -82,10 +131,24 @@ type baseProvider[C ProviderClient] struct {
 }
 
 func NewProvider(providerName models.ModelProvider, opts ...ProviderClientOption) (Provider, error) {
-	clientOptions := providerClientOptions{}
+	clientOptions := providerClientOptions{
+		retryPolicy: DefaultRetryPolicy(),
+	}
 	for _, o := range opts {
 		o(&clientOptions)
 	}
+	clientOptions.rateLimiter = rateLimiterFor(providerName, clientOptions.rateLimit)
+	if clientOptions.cacheDir != "" {
+		clientOptions.cache = &responseCache{dir: clientOptions.cacheDir, systemMessage: clientOptions.systemMessage}
+	}
+	p, err := newProvider(providerName, clientOptions)
+	if err != nil {
+		return nil, err
+	}
+	return adaptToCapabilities(p, providerName, clientOptions), nil
+}
+
+func newProvider(providerName models.ModelProvider, clientOptions providerClientOptions) (Provider, error) {
 	switch providerName {
 	case models.ProviderAnthropic:
 		return &baseProvider[AnthropicClient]{
@@ -110,6 +173,88 @@ func NewProvider(providerName models.ModelProvider, opts ...ProviderClientOption
 	case models.ProviderGROQ:
 		clientOptions.openaiOptions = append(clientOptions.openaiOptions,
 			WithOpenAIBaseURL("https://api.groq.com/openai/v1"),
+			withOpenAICompatible(),
+		)
+		return &baseProvider[OpenAIClient]{
+			options: clientOptions,
+			client:  newOpenAIClient(clientOptions),
+		}, nil
+	case models.ProviderOpenAICompatible:
+		clientOptions.openaiOptions = append(clientOptions.openaiOptions,
+			withOpenAICompatible(),
+		)
+		return &baseProvider[OpenAIClient]{
+			options: clientOptions,
+			client:  newOpenAIClient(clientOptions),
+		}, nil
+	case models.ProviderDeepSeek:
+		clientOptions.openaiOptions = append(clientOptions.openaiOptions,
+			WithOpenAIBaseURL("https://api.deepseek.com/v1"),
+			withOpenAICompatible(),
+			withOpenAIReasoningContent(),
+		)
+		return &baseProvider[OpenAIClient]{
+			options: clientOptions,
+			client:  newOpenAIClient(clientOptions),
+		}, nil
+	case models.ProviderXAI:
+		// Grok 3 Mini returns its reasoning like DeepSeek does
+		clientOptions.openaiOptions = append(clientOptions.openaiOptions,
+			WithOpenAIBaseURL("https://api.x.ai/v1"),
+			withOpenAICompatible(),
+			withOpenAIReasoningContent(),
+		)
+		return &baseProvider[OpenAIClient]{
+			options: clientOptions,
+			client:  newOpenAIClient(clientOptions),
+		}, nil
+	case models.ProviderMistral:
+		return &baseProvider[MistralClient]{
+			options: clientOptions,
+			client:  newMistralClient(clientOptions),
+		}, nil
+	case models.ProviderTogether:
+		// Reasoning models such as DeepSeek R1 think in <think> tags
+		clientOptions.openaiOptions = append(clientOptions.openaiOptions,
+			WithOpenAIBaseURL(models.TogetherBaseURL),
+			withOpenAICompatible(),
+			withOpenAIThinkTags(),
+		)
+		return &baseProvider[OpenAIClient]{
+			options: clientOptions,
+			client:  newOpenAIClient(clientOptions),
+		}, nil
+	case models.ProviderCopilot:
+		// The API key is the GitHub OAuth token, it is exchanged for Copilot
+		// tokens and never sent to the Copilot API
+		clientOptions.authTransport = newCopilotTransport(clientOptions.apiKey)
+		clientOptions.apiKey = ""
+		clientOptions.openaiOptions = append(clientOptions.openaiOptions,
+			WithOpenAIBaseURL(models.CopilotBaseURL),
+			withOpenAICompatible(),
+		)
+		return &baseProvider[OpenAIClient]{
+			options: clientOptions,
+			client:  newOpenAIClient(clientOptions),
+		}, nil
+	case models.ProviderOpenRouter:
+		clientOptions.openaiOptions = append(clientOptions.openaiOptions,
+			WithOpenAIBaseURL(models.OpenRouterBaseURL),
+			withOpenAICompatible(),
+			// Attribution shown on the OpenRouter rankings
+			withOpenAIDefaultHeaders(map[string]string{
+				"HTTP-Referer": "https://github.com/opencode-ai/opencode",
+				"X-Title":      "OpenCode",
+			}),
+		)
+		return &baseProvider[OpenAIClient]{
+			options: clientOptions,
+			client:  newOpenAIClient(clientOptions),
+		}, nil
+	case models.ProviderLMStudio:
+		clientOptions.openaiOptions = append(clientOptions.openaiOptions,
+			withOpenAICompatible(),
+			withOpenAIThinkTags(),
 		)
 		return &baseProvider[OpenAIClient]{
 			options: clientOptions,
@@ -120,6 +265,26 @@ func NewProvider(providerName models.ModelProvider, opts ...ProviderClientOption
 			options: clientOptions,
 			client:  newAzureClient(clientOptions),
 		}, nil
+	case models.ProviderVertexAI:
+		return &baseProvider[VertexAIClient]{
+			options: clientOptions,
+			client:  newVertexAIClient(clientOptions),
+		}, nil
+	case models.ProviderOllama:
+		return &baseProvider[OllamaClient]{
+			options: clientOptions,
+			client:  newOllamaClient(clientOptions),
+		}, nil
+	case models.ProviderLlamaCpp:
+		return &baseProvider[LlamaCppClient]{
+			options: clientOptions,
+			client:  newLlamaCppClient(clientOptions),
+		}, nil
+	case models.ProviderGGUF:
+		return &baseProvider[GGUFClient]{
+			options: clientOptions,
+			client:  newGGUFClient(clientOptions),
+		}, nil
 	case models.ProviderMock:
 		// TODO: implement mock client for test
 		panic("not implemented")
@@ -127,6 +292,44 @@ func NewProvider(providerName models.ModelProvider, opts ...ProviderClientOption
 	return nil, fmt.Errorf("provider not supported: %s", providerName)
 }
 
+// CodeCompleter fills in the code between a prefix and a suffix (FIM), for
+// completions inside a file rather than a chat.
+type CodeCompleter interface {
+	CompleteCode(ctx context.Context, prefix, suffix string) (string, error)
+}
+
+// NewEmbedder creates an embedder for providers that expose an embeddings API.
+// The model option selects the embedding model, e.g. nomic-embed-text for Ollama.
+func NewEmbedder(providerName models.ModelProvider, opts ...ProviderClientOption) (Embedder, error) {
+	clientOptions := providerClientOptions{
+		retryPolicy: DefaultRetryPolicy(),
+	}
+	for _, o := range opts {
+		o(&clientOptions)
+	}
+	switch providerName {
+	case models.ProviderOllama:
+		return newOllamaClient(clientOptions).(*ollamaClient), nil
+	}
+	return nil, fmt.Errorf("embeddings not supported for provider: %s", providerName)
+}
+
+// NewCodeCompleter creates a code completer for providers with a FIM API. The
+// model option selects the completion model, e.g. codestral for Mistral.
+func NewCodeCompleter(providerName models.ModelProvider, opts ...ProviderClientOption) (CodeCompleter, error) {
+	clientOptions := providerClientOptions{
+		retryPolicy: DefaultRetryPolicy(),
+	}
+	for _, o := range opts {
+		o(&clientOptions)
+	}
+	switch providerName {
+	case models.ProviderMistral:
+		return newMistralClient(clientOptions).(*mistralClient), nil
+	}
+	return nil, fmt.Errorf("code completion not supported for provider: %s", providerName)
+}
+
 func (p *baseProvider[C]) cleanMessages(messages []message.Message) (cleaned []message.Message) {
 	for _, msg := range messages {
 		// The message has no content
@@ -135,12 +338,70 @@ func (p *baseProvider[C]) cleanMessages(messages []message.Message) (cleaned []m
 		}
 		cleaned = append(cleaned, msg)
 	}
+	if mapper, ok := any(p.client).(toolCallIDMapper); ok {
+		cleaned = remapToolCallIDs(cleaned, mapper.toolCallID)
+	}
 	return
 }
 
+// toolCallIDMapper is implemented by clients that only accept tool call IDs of
+// a certain form. When the model of a session is switched, the IDs generated
+// by the previous provider are rewritten before the history is sent.
+type toolCallIDMapper interface {
+	toolCallID(id string) string
+}
+
+// remapToolCallIDs returns the messages with the IDs of the tool calls and
+// their results replaced. The stored messages are not modified.
+func remapToolCallIDs(messages []message.Message, toolCallID func(string) string) []message.Message {
+	remapped := make([]message.Message, len(messages))
+	for i, msg := range messages {
+		parts := make([]message.ContentPart, len(msg.Parts))
+		for j, part := range msg.Parts {
+			switch part := part.(type) {
+			case message.ToolCall:
+				part.ID = toolCallID(part.ID)
+				parts[j] = part
+			case message.ToolResult:
+				part.ToolCallID = toolCallID(part.ToolCallID)
+				parts[j] = part
+			default:
+				parts[j] = part
+			}
+		}
+		msg.Parts = parts
+		remapped[i] = msg
+	}
+	return remapped
+}
+
+// hashToolCallID derives a stable ID from another one, so a call and its
+// result map to the same ID.
+func hashToolCallID(id string) string {
+	sum := sha256.Sum256([]byte(id))
+	return hex.EncodeToString(sum[:])
+}
+
 func (p *baseProvider[C]) SendMessages(ctx context.Context, messages []message.Message, tools []tools.BaseTool) (*ProviderResponse, error) {
-	messages = p.cleanMessages(messages)
-	return p.client.send(ctx, messages, tools)
+	req := &Request{Model: p.options.model, Messages: p.cleanMessages(messages), Tools: tools}
+	return p.options.cache.send(req, func() (*ProviderResponse, error) {
+		// The time spent in the queue does not count against the timeout
+		reservation, err := p.options.rateLimiter.wait(ctx, p.options.requestTokens(req))
+		if err != nil {
+			return nil, err
+		}
+		ctx := ctx
+		if p.options.timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, p.options.timeout)
+			defer cancel()
+		}
+		response, err := chain(p.client, p.options.middleware).Send(ctx, req)
+		if err == nil {
+			p.options.rateLimiter.record(reservation, response.Usage)
+		}
+		return response, err
+	})
 }
 
 func (p *baseProvider[C]) Model() models.Model {
@@ -148,8 +409,46 @@ func (p *baseProvider[C]) Model() models.Model {
 }
 
 func (p *baseProvider[C]) StreamResponse(ctx context.Context, messages []message.Message, tools []tools.BaseTool) <-chan ProviderEvent {
-	messages = p.cleanMessages(messages)
-	return p.client.stream(ctx, messages, tools)
+	req := &Request{Model: p.options.model, Messages: p.cleanMessages(messages), Tools: tools}
+	handler := chain(p.client, p.options.middleware)
+	stream := func(ctx context.Context) <-chan ProviderEvent {
+		if p.options.timeout > 0 {
+			return streamWithIdleTimeout(ctx, p.options.timeout, func(ctx context.Context) <-chan ProviderEvent {
+				return handler.Stream(ctx, req)
+			})
+		}
+		return handler.Stream(ctx, req)
+	}
+	return streamWithPartialResponse(p.options.cache.stream(req, func() <-chan ProviderEvent {
+		return p.options.rateLimiter.stream(ctx, p.options.requestTokens(req), stream)
+	}))
+}
+
+// streamWithPartialResponse replaces the error of a cancelled stream with a
+// canceled completion that carries the content generated so far, so the
+// conversation keeps what was streamed before the interrupt.
+func streamWithPartialResponse(events <-chan ProviderEvent) <-chan ProviderEvent {
+	relayed := make(chan ProviderEvent)
+	go func() {
+		defer close(relayed)
+		var content strings.Builder
+		for event := range events {
+			switch {
+			case event.Type == EventContentDelta:
+				content.WriteString(event.Content)
+			case event.Type == EventError && errors.Is(event.Error, context.Canceled):
+				event = ProviderEvent{
+					Type: EventComplete,
+					Response: &ProviderResponse{
+						Content:      content.String(),
+						FinishReason: message.FinishReasonCanceled,
+					},
+				}
+			}
+			relayed <- event
+		}
+	}()
+	return relayed
 }
 
 func WithAPIKey(apiKey string) ProviderClientOption {
@@ -176,6 +475,16 @@ func WithSystemMessage(systemMessage string) ProviderClientOption {
 	}
 }
 
+// WithReasoning sets the reasoning effort of OpenAI reasoning models (low,
+// medium or high) and the token budget of Claude extended thinking. A zero
+// budget lets Claude think only when the prompt asks for it.
+func WithReasoning(effort string, thinkingBudget int64) ProviderClientOption {
+	return func(options *providerClientOptions) {
+		options.reasoningEffort = effort
+		options.thinkingBudget = thinkingBudget
+	}
+}
+
 func WithAnthropicOptions(anthropicOptions ...AnthropicOption) ProviderClientOption {
 	return func(options *providerClientOptions) {
 		options.anthropicOptions = anthropicOptions
@@ -199,3 +508,33 @@ func WithBedrockOptions(bedrockOptions ...BedrockOption) ProviderClientOption {
 		options.bedrockOptions = bedrockOptions
 	}
 }
+
+func WithOllamaOptions(ollamaOptions ...OllamaOption) ProviderClientOption {
+	return func(options *providerClientOptions) {
+		options.ollamaOptions = ollamaOptions
+	}
+}
+
+func WithLlamaCppOptions(llamacppOptions ...LlamaCppOption) ProviderClientOption {
+	return func(options *providerClientOptions) {
+		options.llamacppOptions = llamacppOptions
+	}
+}
+
+func WithGGUFOptions(ggufOptions ...GGUFOption) ProviderClientOption {
+	return func(options *providerClientOptions) {
+		options.ggufOptions = ggufOptions
+	}
+}
+
+func WithAzureOptions(azureOptions ...AzureOption) ProviderClientOption {
+	return func(options *providerClientOptions) {
+		options.azureOptions = azureOptions
+	}
+}
+
+func WithVertexAIOptions(vertexaiOptions ...VertexAIOption) ProviderClientOption {
+	return func(options *providerClientOptions) {
+		options.vertexaiOptions = vertexaiOptions
+	}
+}
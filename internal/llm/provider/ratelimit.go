@@ -0,0 +1,259 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/opencode-ai/opencode/internal/llm/models"
+	"github.com/opencode-ai/opencode/internal/llm/tokenizer"
+	"github.com/opencode-ai/opencode/internal/logging"
+)
+
+// RateLimit is a client side budget for the requests to a provider. Requests
+// beyond it wait in a queue instead of failing with a rate limit error.
+type RateLimit struct {
+	// RequestsPerMinute limits the requests sent in any minute, 0 for no limit
+	RequestsPerMinute int
+	// TokensPerMinute limits the prompt and completion tokens of the requests
+	// sent in any minute, 0 for no limit
+	TokensPerMinute int64
+}
+
+// WithRateLimit sets the request and token budget of the provider. The budget
+// is shared by all agents using the provider.
+func WithRateLimit(limit RateLimit) ProviderClientOption {
+	return func(options *providerClientOptions) {
+		options.rateLimit = limit
+	}
+}
+
+// rateLimiters are the limiters of the providers, shared by the agents.
+var rateLimiters = struct {
+	sync.Mutex
+	limiters map[models.ModelProvider]*rateLimiter
+}{limiters: make(map[models.ModelProvider]*rateLimiter)}
+
+// rateLimiterFor returns the limiter of a provider with its budget updated.
+func rateLimiterFor(provider models.ModelProvider, limit RateLimit) *rateLimiter {
+	rateLimiters.Lock()
+	defer rateLimiters.Unlock()
+	l, ok := rateLimiters.limiters[provider]
+	if !ok {
+		l = &rateLimiter{provider: provider, tokensRemaining: -1}
+		rateLimiters.limiters[provider] = l
+	}
+	l.mu.Lock()
+	l.limit = limit
+	l.mu.Unlock()
+	return l
+}
+
+// rateLimitEntry is a request sent in the last minute.
+type rateLimitEntry struct {
+	at     time.Time
+	tokens int64
+}
+
+// rateLimiter queues the requests to a provider that would exceed its budget
+// or the limits the provider reported in its response headers.
+type rateLimiter struct {
+	provider models.ModelProvider
+
+	mu     sync.Mutex
+	limit  RateLimit
+	window []*rateLimitEntry
+	queued int
+
+	// blockedUntil is when the provider accepts requests again after its
+	// request limit was exhausted or it answered with 429
+	blockedUntil time.Time
+	// tokensRemaining is the token budget the provider reported, -1 when
+	// unknown, until tokensReset
+	tokensRemaining int64
+	tokensReset     time.Time
+}
+
+// wait blocks until a request of the given number of tokens may be sent and
+// reserves it in the budget.
+func (l *rateLimiter) wait(ctx context.Context, tokens int64) (*rateLimitEntry, error) {
+	if l == nil {
+		return nil, nil
+	}
+	queued := false
+	defer func() {
+		if queued {
+			l.mu.Lock()
+			l.queued--
+			l.mu.Unlock()
+		}
+	}()
+	for {
+		l.mu.Lock()
+		now := time.Now()
+		delay := l.delay(now, tokens)
+		if delay <= 0 {
+			entry := &rateLimitEntry{at: now, tokens: tokens}
+			l.window = append(l.window, entry)
+			if l.tokensRemaining >= 0 && now.Before(l.tokensReset) {
+				l.tokensRemaining -= tokens
+			}
+			l.mu.Unlock()
+			return entry, nil
+		}
+		if !queued {
+			queued = true
+			l.queued++
+		}
+		waiting := l.queued
+		l.mu.Unlock()
+
+		logging.WarnPersist(fmt.Sprintf("Waiting %s for the %s rate limit, %d request(s) queued", delay.Round(time.Second), l.provider, waiting), logging.PersistTimeArg, delay+100*time.Millisecond)
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+// delay returns how long a request of the given number of tokens has to wait.
+// It must be called with the lock held.
+func (l *rateLimiter) delay(now time.Time, tokens int64) time.Duration {
+	cutoff := now.Add(-time.Minute)
+	expired := 0
+	for expired < len(l.window) && l.window[expired].at.Before(cutoff) {
+		expired++
+	}
+	l.window = l.window[expired:]
+
+	var delay time.Duration
+	if now.Before(l.blockedUntil) {
+		delay = l.blockedUntil.Sub(now)
+	}
+	if limit := l.limit.RequestsPerMinute; limit > 0 && len(l.window) >= limit {
+		delay = max(delay, l.window[len(l.window)-limit].at.Add(time.Minute).Sub(now))
+	}
+	if limit := l.limit.TokensPerMinute; limit > 0 {
+		var used int64
+		for _, entry := range l.window {
+			used += entry.tokens
+		}
+		// Wait for the oldest requests to leave the window until the request
+		// fits, a request larger than the budget waits for an empty window
+		for i := 0; used+tokens > limit && i < len(l.window); i++ {
+			used -= l.window[i].tokens
+			delay = max(delay, l.window[i].at.Add(time.Minute).Sub(now))
+		}
+	}
+	if l.tokensRemaining >= 0 && tokens > l.tokensRemaining && now.Before(l.tokensReset) {
+		delay = max(delay, l.tokensReset.Sub(now))
+	}
+	return delay
+}
+
+// record replaces the estimate of a request with the tokens it used.
+func (l *rateLimiter) record(entry *rateLimitEntry, usage TokenUsage) {
+	if l == nil || entry == nil {
+		return
+	}
+	used := usage.InputTokens + usage.OutputTokens + usage.CacheCreationTokens + usage.CacheReadTokens
+	if used == 0 {
+		return
+	}
+	l.mu.Lock()
+	entry.tokens = used
+	l.mu.Unlock()
+}
+
+// stream waits for the budget before the stream starts, the events are
+// relayed once it is sent.
+func (l *rateLimiter) stream(ctx context.Context, tokens int64, stream func(context.Context) <-chan ProviderEvent) <-chan ProviderEvent {
+	if l == nil {
+		return stream(ctx)
+	}
+	relayed := make(chan ProviderEvent)
+	go func() {
+		defer close(relayed)
+		entry, err := l.wait(ctx, tokens)
+		if err != nil {
+			relayed <- ProviderEvent{Type: EventError, Error: err}
+			return
+		}
+		for event := range stream(ctx) {
+			if event.Type == EventComplete && event.Response != nil {
+				l.record(entry, event.Response.Usage)
+			}
+			relayed <- event
+		}
+	}()
+	return relayed
+}
+
+// observe reads the rate limit headers of a response. OpenAI compatible APIs
+// report the remaining budget in x-ratelimit-* headers with the reset as a
+// duration, Anthropic in anthropic-ratelimit-* headers with the reset as a
+// timestamp.
+func (l *rateLimiter) observe(resp *http.Response) {
+	header := resp.Header
+	now := time.Now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	block := func(until time.Time) {
+		if until.After(l.blockedUntil) {
+			l.blockedUntil = until
+		}
+	}
+	if resp.StatusCode == http.StatusTooManyRequests {
+		if delay := retryAfter(header); delay > 0 {
+			block(now.Add(delay))
+		}
+	}
+
+	if header.Get("X-Ratelimit-Remaining-Requests") == "0" {
+		if reset, err := time.ParseDuration(header.Get("X-Ratelimit-Reset-Requests")); err == nil {
+			block(now.Add(reset))
+		}
+	}
+	if remaining, err := strconv.ParseInt(header.Get("X-Ratelimit-Remaining-Tokens"), 10, 64); err == nil {
+		if reset, err := time.ParseDuration(header.Get("X-Ratelimit-Reset-Tokens")); err == nil {
+			l.tokensRemaining, l.tokensReset = remaining, now.Add(reset)
+		}
+	}
+
+	if header.Get("Anthropic-Ratelimit-Requests-Remaining") == "0" {
+		if reset, err := time.Parse(time.RFC3339, header.Get("Anthropic-Ratelimit-Requests-Reset")); err == nil {
+			block(reset)
+		}
+	}
+	if remaining, err := strconv.ParseInt(header.Get("Anthropic-Ratelimit-Tokens-Remaining"), 10, 64); err == nil {
+		if reset, err := time.Parse(time.RFC3339, header.Get("Anthropic-Ratelimit-Tokens-Reset")); err == nil {
+			l.tokensRemaining, l.tokensReset = remaining, reset
+		}
+	}
+}
+
+// rateLimitTransport passes the rate limit headers of the responses to the
+// limiter of the provider.
+type rateLimitTransport struct {
+	limiter *rateLimiter
+	next    http.RoundTripper
+}
+
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	if err == nil {
+		t.limiter.observe(resp)
+	}
+	return resp, err
+}
+
+// requestTokens estimates the tokens a request counts against the budget.
+func (opts providerClientOptions) requestTokens(req *Request) int64 {
+	t := tokenizer.ForModel(req.Model)
+	return t.Count(opts.systemMessage) + tokenizer.CountMessages(t, req.Messages)
+}
@@ -0,0 +1,83 @@
+package provider
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimiter_Delay(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name    string
+		limiter *rateLimiter
+		tokens  int64
+		want    time.Duration
+	}{
+		{
+			"within budget",
+			&rateLimiter{limit: RateLimit{RequestsPerMinute: 2}, tokensRemaining: -1, window: []*rateLimitEntry{{at: now.Add(-10 * time.Second)}}},
+			100,
+			0,
+		},
+		{
+			"requests exhausted",
+			&rateLimiter{limit: RateLimit{RequestsPerMinute: 1}, tokensRemaining: -1, window: []*rateLimitEntry{{at: now.Add(-10 * time.Second)}}},
+			100,
+			50 * time.Second,
+		},
+		{
+			"expired requests",
+			&rateLimiter{limit: RateLimit{RequestsPerMinute: 1}, tokensRemaining: -1, window: []*rateLimitEntry{{at: now.Add(-2 * time.Minute)}}},
+			100,
+			0,
+		},
+		{
+			"tokens exhausted",
+			&rateLimiter{limit: RateLimit{TokensPerMinute: 1000}, tokensRemaining: -1, window: []*rateLimitEntry{
+				{at: now.Add(-40 * time.Second), tokens: 500},
+				{at: now.Add(-20 * time.Second), tokens: 400},
+			}},
+			200,
+			20 * time.Second,
+		},
+		{
+			"reported tokens exhausted",
+			&rateLimiter{tokensRemaining: 100, tokensReset: now.Add(5 * time.Second)},
+			200,
+			5 * time.Second,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.limiter.delay(now, tt.tokens))
+		})
+	}
+}
+
+func TestRateLimiter_Observe(t *testing.T) {
+	l := &rateLimiter{tokensRemaining: -1}
+	l.observe(&http.Response{
+		StatusCode: http.StatusOK,
+		Header: http.Header{
+			"X-Ratelimit-Remaining-Requests": {"0"},
+			"X-Ratelimit-Reset-Requests":     {"2s"},
+			"X-Ratelimit-Remaining-Tokens":   {"1500"},
+			"X-Ratelimit-Reset-Tokens":       {"30s"},
+		},
+	})
+	assert.WithinDuration(t, time.Now().Add(2*time.Second), l.blockedUntil, time.Second)
+	assert.Equal(t, int64(1500), l.tokensRemaining)
+
+	reset := time.Now().Add(time.Minute).UTC().Truncate(time.Second)
+	l.observe(&http.Response{
+		StatusCode: http.StatusOK,
+		Header: http.Header{
+			"Anthropic-Ratelimit-Requests-Remaining": {"0"},
+			"Anthropic-Ratelimit-Requests-Reset":     {reset.Format(time.RFC3339)},
+		},
+	})
+	assert.True(t, l.blockedUntil.Equal(reset))
+}
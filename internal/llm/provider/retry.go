@@ -0,0 +1,132 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/opencode-ai/opencode/internal/logging"
+)
+
+// RetryPolicy controls how requests that failed with a transient error, such
+// as a rate limit or an overloaded server, are retried.
+type RetryPolicy struct {
+	// MaxAttempts is the number of retries after the first attempt
+	MaxAttempts int
+	// InitialBackoff is the delay before the first retry, it doubles with every retry
+	InitialBackoff time.Duration
+	// MaxBackoff caps the delay between two attempts
+	MaxBackoff time.Duration
+	// StatusCodes are the HTTP status codes that are retried
+	StatusCodes []int
+}
+
+// DefaultRetryPolicy returns the retry policy used when none is configured.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    maxRetries,
+		InitialBackoff: 2 * time.Second,
+		MaxBackoff:     5 * time.Minute,
+		StatusCodes: []int{
+			http.StatusTooManyRequests,
+			http.StatusInternalServerError,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout,
+			529, // Anthropic overloaded
+		},
+	}
+}
+
+// WithRetryPolicy overrides the default retry policy.
+func WithRetryPolicy(policy RetryPolicy) ProviderClientOption {
+	return func(options *providerClientOptions) {
+		options.retryPolicy = policy
+	}
+}
+
+func (p RetryPolicy) retryableStatus(statusCode int) bool {
+	return slices.Contains(p.StatusCodes, statusCode)
+}
+
+// backoff returns the delay before the given retry, retryAfter is the delay
+// requested by the server and takes precedence when set.
+func (p RetryPolicy) backoff(attempt int, retryAfter time.Duration) time.Duration {
+	if retryAfter > 0 {
+		return retryAfter
+	}
+	delay := p.InitialBackoff << (attempt - 1)
+	if delay <= 0 || (p.MaxBackoff > 0 && delay > p.MaxBackoff) {
+		delay = p.MaxBackoff
+	}
+	// Add jitter so concurrent requests do not retry in lockstep
+	return delay + delay/5
+}
+
+// do calls fn until it succeeds, fails with an error that is not transient or
+// the attempts are exhausted. classify reports whether an error may be retried
+// and the delay the server asked for, if any.
+func (p RetryPolicy) do(ctx context.Context, fn func() error, classify func(error) (bool, time.Duration)) error {
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil {
+			return nil
+		}
+		retry, retryAfter := classify(err)
+		if !retry || ctx.Err() != nil {
+			return err
+		}
+		if attempt > p.MaxAttempts {
+			if p.MaxAttempts == 0 {
+				return err
+			}
+			return fmt.Errorf("maximum retry attempts reached: %d retries: %w", p.MaxAttempts, err)
+		}
+
+		delay := p.backoff(attempt, retryAfter)
+		logging.WarnPersist(fmt.Sprintf("Retrying after error... attempt %d of %d", attempt, p.MaxAttempts), logging.PersistTimeArg, delay+100*time.Millisecond)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+// retryAfter parses the Retry-After header, which holds a number of seconds.
+// Without it, the reset time of an exhausted rate limit is used, which Groq
+// and OpenAI report as a duration such as 7.66s or 2m59.56s.
+func retryAfter(header http.Header) time.Duration {
+	if header == nil {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(header.Get("Retry-After")); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second
+	}
+
+	var delay time.Duration
+	for _, limit := range []string{"requests", "tokens"} {
+		if header.Get("X-Ratelimit-Remaining-"+limit) != "0" {
+			continue
+		}
+		if reset, err := time.ParseDuration(header.Get("X-Ratelimit-Reset-" + limit)); err == nil && reset > delay {
+			delay = reset
+		}
+	}
+	return delay
+}
+
+// isTransientNetworkError reports whether the connection broke off in a way
+// that is likely to succeed when tried again.
+func isTransientNetworkError(err error) bool {
+	return errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.EPIPE) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, io.EOF)
+}
@@ -0,0 +1,44 @@
+package provider
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryAfter(t *testing.T) {
+	tests := []struct {
+		name   string
+		header http.Header
+		want   time.Duration
+	}{
+		{"none", http.Header{}, 0},
+		{"seconds", http.Header{"Retry-After": {"3"}}, 3 * time.Second},
+		{
+			"exhausted tokens",
+			http.Header{
+				"X-Ratelimit-Remaining-Requests": {"12"},
+				"X-Ratelimit-Reset-Requests":     {"2m59.56s"},
+				"X-Ratelimit-Remaining-Tokens":   {"0"},
+				"X-Ratelimit-Reset-Tokens":       {"7.66s"},
+			},
+			7660 * time.Millisecond,
+		},
+		{
+			"retry after wins",
+			http.Header{
+				"Retry-After":                  {"1"},
+				"X-Ratelimit-Remaining-Tokens": {"0"},
+				"X-Ratelimit-Reset-Tokens":     {"7.66s"},
+			},
+			time.Second,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, retryAfter(tt.header))
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package provider
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/opencode-ai/opencode/internal/llm/tools"
+	"github.com/opencode-ai/opencode/internal/message"
+)
+
+// Models without native tool calling get their tools through the prompt: the
+// tools are described in the system message and the model answers with one
+// JSON call per line, which is parsed back into tool calls.
+
+// textToolInstructions describes the tools and the JSON call format.
+func textToolInstructions(tools []tools.BaseTool) string {
+	var b strings.Builder
+	b.WriteString("You have access to the following functions:\n\n")
+	for _, tool := range tools {
+		info := tool.Info()
+		definition, _ := json.Marshal(map[string]any{
+			"name":        info.Name,
+			"description": info.Description,
+			"parameters": map[string]any{
+				"type":       "object",
+				"properties": info.Parameters,
+				"required":   info.Required,
+			},
+		})
+		b.Write(definition)
+		b.WriteString("\n\n")
+	}
+	b.WriteString(`To call a function, respond only with JSON in the format {"name": "<function name>", "parameters": {<arguments>}}. Use one line per call when calling several functions.`)
+	return b.String()
+}
+
+// parseTextToolCalls extracts the JSON tool calls from a generation. Text that
+// is not a call of a known tool stays content.
+func parseTextToolCalls(generation string, tools []tools.BaseTool) (string, []message.ToolCall) {
+	if len(tools) == 0 {
+		return generation, nil
+	}
+	known := make(map[string]bool, len(tools))
+	for _, tool := range tools {
+		known[tool.Info().Name] = true
+	}
+
+	var content []string
+	var toolCalls []message.ToolCall
+	for _, line := range strings.Split(generation, "\n") {
+		var call struct {
+			Name       string          `json:"name"`
+			Parameters json.RawMessage `json:"parameters"`
+		}
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "{") && json.Unmarshal([]byte(trimmed), &call) == nil && known[call.Name] {
+			input := string(call.Parameters)
+			if input == "" || input == "null" {
+				input = "{}"
+			}
+			toolCalls = append(toolCalls, message.ToolCall{
+				ID:       "call_" + uuid.New().String(),
+				Name:     call.Name,
+				Input:    input,
+				Type:     "function",
+				Finished: true,
+			})
+			continue
+		}
+		content = append(content, line)
+	}
+	return strings.TrimSpace(strings.Join(content, "\n")), toolCalls
+}
+
+// textToolMessages rewrites the tool calls and results of a conversation as
+// text, for models that have no tool messages.
+func textToolMessages(messages []message.Message) []message.Message {
+	converted := make([]message.Message, 0, len(messages))
+	for _, msg := range messages {
+		switch msg.Role {
+		case message.Assistant:
+			toolCalls := msg.ToolCalls()
+			if len(toolCalls) == 0 {
+				converted = append(converted, msg)
+				continue
+			}
+			content := msg.Content().String()
+			for _, call := range toolCalls {
+				args := json.RawMessage(call.Input)
+				if !json.Valid(args) {
+					args = json.RawMessage("{}")
+				}
+				data, _ := json.Marshal(map[string]any{"name": call.Name, "parameters": args})
+				content = strings.TrimSpace(content + "\n" + string(data))
+			}
+			msg.Parts = []message.ContentPart{message.TextContent{Text: content}}
+			converted = append(converted, msg)
+		case message.Tool:
+			var content strings.Builder
+			for _, result := range msg.ToolResults() {
+				status := "returned"
+				if result.IsError {
+					status = "failed"
+				}
+				fmt.Fprintf(&content, "Function %s %s:\n%s\n\n", result.Name, status, result.Content)
+			}
+			msg.Role = message.User
+			msg.Parts = []message.ContentPart{message.TextContent{Text: strings.TrimSpace(content.String())}}
+			converted = append(converted, msg)
+		default:
+			converted = append(converted, msg)
+		}
+	}
+	return converted
+}
@@ -0,0 +1,84 @@
+package provider
+
+import (
+	"strings"
+	"unicode"
+)
+
+const (
+	thinkOpenTag  = "<think>"
+	thinkCloseTag = "</think>"
+)
+
+// thinkSplitter separates the <think>...</think> segments emitted by
+// DeepSeek-R1 style reasoning models from the regular content. Tags can be
+// split across stream chunks, so a trailing partial tag is held back until the
+// next chunk arrives.
+type thinkSplitter struct {
+	thinking   bool
+	afterThink bool
+	pending    string
+}
+
+// feed consumes the next chunk of model output.
+func (s *thinkSplitter) feed(chunk string) (thinking, content string) {
+	text := s.pending + chunk
+	s.pending = ""
+
+	for text != "" {
+		tag := thinkOpenTag
+		if s.thinking {
+			tag = thinkCloseTag
+		}
+		if i := strings.Index(text, tag); i >= 0 {
+			t, c := s.emit(text[:i])
+			thinking += t
+			content += c
+			text = text[i+len(tag):]
+			s.afterThink = s.thinking
+			s.thinking = !s.thinking
+			continue
+		}
+
+		keep := partialTagLength(text, tag)
+		t, c := s.emit(text[:len(text)-keep])
+		thinking += t
+		content += c
+		s.pending = text[len(text)-keep:]
+		break
+	}
+	return thinking, content
+}
+
+// flush returns the output held back at the end of the stream.
+func (s *thinkSplitter) flush() (thinking, content string) {
+	text := s.pending
+	s.pending = ""
+	return s.emit(text)
+}
+
+func (s *thinkSplitter) emit(text string) (thinking, content string) {
+	if s.thinking {
+		return text, ""
+	}
+	// Models separate the answer from the reasoning with blank lines
+	if s.afterThink {
+		text = strings.TrimLeftFunc(text, unicode.IsSpace)
+		if text == "" {
+			return "", ""
+		}
+		s.afterThink = false
+	}
+	return "", text
+}
+
+// partialTagLength returns the length of the longest suffix of text that is a
+// prefix of tag.
+func partialTagLength(text, tag string) int {
+	for n := min(len(tag)-1, len(text)); n > 0; n-- {
+		if strings.HasSuffix(text, tag[:n]) {
+			return n
+		}
+	}
+	return 0
+}
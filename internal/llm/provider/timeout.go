@@ -0,0 +1,61 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrNoResponse is returned when a stream was idle for longer than the
+// timeout of the provider.
+var ErrNoResponse = errors.New("no response from provider")
+
+// WithTimeout limits how long a request to the provider may take. Streaming
+// requests have no overall deadline, instead the timeout applies to the time
+// between two events so long generations on slow hardware are not cut off.
+func WithTimeout(timeout time.Duration) ProviderClientOption {
+	return func(options *providerClientOptions) {
+		options.timeout = timeout
+	}
+}
+
+// streamWithIdleTimeout relays the events of a stream and cancels it when no
+// event arrived within the timeout.
+func streamWithIdleTimeout(ctx context.Context, timeout time.Duration, stream func(context.Context) <-chan ProviderEvent) <-chan ProviderEvent {
+	ctx, cancel := context.WithCancel(ctx)
+	events := stream(ctx)
+	relayed := make(chan ProviderEvent)
+
+	go func() {
+		defer close(relayed)
+		defer cancel()
+
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		for {
+			select {
+			case event, ok := <-events:
+				if !ok {
+					return
+				}
+				relayed <- event
+				timer.Reset(timeout)
+			case <-timer.C:
+				cancel()
+				// Drain the stream so the client can finish after the cancellation
+				go func() {
+					for range events {
+					}
+				}()
+				relayed <- ProviderEvent{
+					Type:  EventError,
+					Error: fmt.Errorf("%w for %s", ErrNoResponse, timeout),
+				}
+				return
+			}
+		}
+	}()
+
+	return relayed
+}
@@ -0,0 +1,93 @@
+package provider
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/opencode-ai/opencode/internal/llm/tools"
+	"github.com/opencode-ai/opencode/internal/message"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamWithIdleTimeout(t *testing.T) {
+	// slowStream sends its deltas with the delay between them, and waits for
+	// the cancellation after them when stall is set
+	slowStream := func(deltas int, delay time.Duration, stall bool, canceled chan<- struct{}) func(context.Context) <-chan ProviderEvent {
+		return func(ctx context.Context) <-chan ProviderEvent {
+			events := make(chan ProviderEvent)
+			go func() {
+				defer close(events)
+				for range deltas {
+					time.Sleep(delay)
+					events <- ProviderEvent{Type: EventContentDelta, Content: "token "}
+				}
+				if stall {
+					<-ctx.Done()
+					close(canceled)
+					return
+				}
+				events <- ProviderEvent{Type: EventComplete, Response: &ProviderResponse{Content: "done"}}
+			}()
+			return events
+		}
+	}
+
+	t.Run("slow generation", func(t *testing.T) {
+		// The stream takes longer than the timeout, each event comes within it
+		var types []EventType
+		for event := range streamWithIdleTimeout(context.Background(), 50*time.Millisecond, slowStream(5, 20*time.Millisecond, false, nil)) {
+			require.NoError(t, event.Error)
+			types = append(types, event.Type)
+		}
+		require.Len(t, types, 6)
+		assert.Equal(t, EventComplete, types[5])
+	})
+
+	t.Run("idle stream", func(t *testing.T) {
+		canceled := make(chan struct{})
+		var last ProviderEvent
+		deltas := 0
+		for event := range streamWithIdleTimeout(context.Background(), 50*time.Millisecond, slowStream(2, 10*time.Millisecond, true, canceled)) {
+			if event.Type == EventContentDelta {
+				deltas++
+			}
+			last = event
+		}
+		assert.Equal(t, 2, deltas)
+		assert.Equal(t, EventError, last.Type)
+		assert.ErrorIs(t, last.Error, ErrNoResponse)
+		select {
+		case <-canceled:
+		case <-time.After(time.Second):
+			t.Fatal("the idle stream was not cancelled")
+		}
+	})
+}
+
+// blockingClient answers only once its request is cancelled.
+type blockingClient struct{}
+
+func (c *blockingClient) send(ctx context.Context, messages []message.Message, tools []tools.BaseTool) (*ProviderResponse, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func (c *blockingClient) stream(ctx context.Context, messages []message.Message, tools []tools.BaseTool) <-chan ProviderEvent {
+	events := make(chan ProviderEvent)
+	go func() {
+		defer close(events)
+		<-ctx.Done()
+	}()
+	return events
+}
+
+func TestSendMessagesTimeout(t *testing.T) {
+	p := &baseProvider[*blockingClient]{
+		options: providerClientOptions{timeout: 20 * time.Millisecond},
+		client:  &blockingClient{},
+	}
+	_, err := p.SendMessages(context.Background(), nil, nil)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
@@ -0,0 +1,170 @@
+package provider
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/opencode-ai/opencode/internal/logging"
+)
+
+// redactedHeaders are the headers that carry credentials and are never written
+// to a trace.
+var redactedHeaders = map[string]bool{
+	"Authorization":  true,
+	"Api-Key":        true,
+	"X-Api-Key":      true,
+	"X-Goog-Api-Key": true,
+	"Cookie":         true,
+	"Set-Cookie":     true,
+}
+
+// traceTransport writes every request and the full response body to a file in
+// dir, so malformed model output can be reproduced from a bug report.
+type traceTransport struct {
+	dir  string
+	next http.RoundTripper
+}
+
+func (t *traceTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	file, err := t.create(req)
+	if err != nil {
+		logging.Warn("failed to create provider trace", "error", err)
+		return t.next.RoundTrip(req)
+	}
+
+	req, err = writeTraceRequest(file, req)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		fmt.Fprintf(file, "\n--- error ---\n%s\n", err)
+		file.Close()
+		return nil, err
+	}
+	fmt.Fprintf(file, "\n--- response ---\n%s %s\n", resp.Proto, resp.Status)
+	writeTraceHeaders(file, resp.Header)
+	fmt.Fprintln(file)
+	resp.Body = &traceBody{ReadCloser: resp.Body, file: file}
+	return resp, nil
+}
+
+func (t *traceTransport) create(req *http.Request) (*os.File, error) {
+	if err := os.MkdirAll(t.dir, 0o755); err != nil {
+		return nil, err
+	}
+	host := strings.NewReplacer(":", "_", "/", "_").Replace(req.URL.Host)
+	if host == "" {
+		host = req.URL.Scheme
+	}
+	name := fmt.Sprintf("%s-%s.log", time.Now().Format("20060102T150405.000000000"), host)
+	return os.OpenFile(filepath.Join(t.dir, name), os.O_CREATE|os.O_WRONLY|os.O_EXCL, 0o600)
+}
+
+// writeTraceRequest writes the request to the trace and returns a request
+// whose body can still be sent.
+func writeTraceRequest(w io.Writer, req *http.Request) (*http.Request, error) {
+	fmt.Fprintf(w, "--- request ---\n%s %s\n", req.Method, redactURL(req.URL))
+	writeTraceHeaders(w, req.Header)
+	if req.Body == nil || req.Body == http.NoBody {
+		return req, nil
+	}
+
+	var body io.ReadCloser
+	if req.GetBody != nil {
+		var err error
+		if body, err = req.GetBody(); err != nil {
+			return nil, err
+		}
+	} else {
+		data, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req = req.Clone(req.Context())
+		req.Body = io.NopCloser(bytes.NewReader(data))
+		body = io.NopCloser(bytes.NewReader(data))
+	}
+	defer body.Close()
+	fmt.Fprintln(w)
+	if _, err := io.Copy(w, body); err != nil {
+		return nil, err
+	}
+	fmt.Fprintln(w)
+	return req, nil
+}
+
+func writeTraceHeaders(w io.Writer, header http.Header) {
+	keys := make([]string, 0, len(header))
+	for k := range header {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		value := strings.Join(header[k], ", ")
+		if redactedHeaders[http.CanonicalHeaderKey(k)] {
+			value = "[REDACTED]"
+		}
+		fmt.Fprintf(w, "%s: %s\n", k, value)
+	}
+}
+
+func redactURL(u *url.URL) string {
+	redacted := *u
+	if redacted.User != nil {
+		redacted.User = url.User("[REDACTED]")
+	}
+	query := redacted.Query()
+	for k := range query {
+		if strings.Contains(strings.ToLower(k), "key") || strings.Contains(strings.ToLower(k), "token") {
+			query.Set(k, "[REDACTED]")
+		}
+	}
+	redacted.RawQuery = query.Encode()
+	return redacted.String()
+}
+
+// traceBody copies the response body to the trace file as it is read, which
+// keeps streaming responses streaming.
+type traceBody struct {
+	io.ReadCloser
+	file *os.File
+	once sync.Once
+}
+
+func (b *traceBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 {
+		b.file.Write(p[:n])
+	}
+	if err != nil && err != io.EOF {
+		fmt.Fprintf(b.file, "\n--- error ---\n%s\n", err)
+	}
+	return n, err
+}
+
+func (b *traceBody) Close() error {
+	b.once.Do(func() {
+		b.file.Close()
+	})
+	return b.ReadCloser.Close()
+}
+
+// WithTraceDir writes every provider request and response to timestamped files
+// in dir. Credentials are redacted.
+func WithTraceDir(dir string) ProviderClientOption {
+	return func(options *providerClientOptions) {
+		options.traceDir = dir
+	}
+}
@@ -0,0 +1,58 @@
+package provider
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/url"
+)
+
+// httpClient builds the HTTP client used by providers that talk to their API
+// directly, applying the transport level settings from the client options.
+// The configure functions adjust the transport for a provider.
+func (opts providerClientOptions) httpClient(configure ...func(*http.Transport)) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if opts.tlsConfig != nil {
+		transport.TLSClientConfig = opts.tlsConfig
+	}
+	if opts.proxy != nil {
+		transport.Proxy = opts.proxy
+	}
+	for _, c := range configure {
+		c(transport)
+	}
+	var roundTripper http.RoundTripper = transport
+	if opts.authTransport != nil {
+		roundTripper = opts.authTransport(roundTripper)
+	}
+	if opts.rateLimiter != nil {
+		roundTripper = &rateLimitTransport{limiter: opts.rateLimiter, next: roundTripper}
+	}
+	if opts.traceDir != "" {
+		roundTripper = &traceTransport{dir: opts.traceDir, next: roundTripper}
+	}
+	return &http.Client{
+		Transport: roundTripper,
+	}
+}
+
+// customTransport reports whether the options change the transport, for the
+// SDK clients that use their own HTTP client otherwise.
+func (opts providerClientOptions) customTransport() bool {
+	return opts.traceDir != "" || opts.tlsConfig != nil || opts.proxy != nil || opts.rateLimiter != nil || opts.authTransport != nil
+}
+
+// WithTLSConfig sets a custom TLS configuration, e.g. to trust an internal CA
+// or present a client certificate.
+func WithTLSConfig(tlsConfig *tls.Config) ProviderClientOption {
+	return func(options *providerClientOptions) {
+		options.tlsConfig = tlsConfig
+	}
+}
+
+// WithProxy sets the proxy selection of the transport, see discovery.ProxyFunc.
+// Without it the proxy environment variables are used.
+func WithProxy(proxy func(*http.Request) (*url.URL, error)) ProviderClientOption {
+	return func(options *providerClientOptions) {
+		options.proxy = proxy
+	}
+}
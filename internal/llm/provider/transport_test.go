@@ -0,0 +1,66 @@
+package provider
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/google/generative-ai-go/genai"
+	"github.com/opencode-ai/opencode/internal/llm/models"
+	"github.com/opencode-ai/opencode/internal/message"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithProxy(t *testing.T) {
+	var proxied []string
+	proxyServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodConnect {
+			// Tunnels to HTTPS APIs are refused, the request made it to the proxy
+			proxied = append(proxied, "CONNECT "+r.Host)
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		proxied = append(proxied, r.Method+" "+r.URL.String())
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id":"1","object":"chat.completion","model":"llama3","choices":[{"index":0,"message":{"role":"assistant","content":"Hello"},"finish_reason":"stop"}]}`))
+	}))
+	t.Cleanup(proxyServer.Close)
+	proxyURL, err := url.Parse(proxyServer.URL)
+	require.NoError(t, err)
+	proxy := http.ProxyURL(proxyURL)
+
+	msg := message.Message{Role: message.User}
+	msg.AppendContent("hello")
+
+	t.Run("openai compatible", func(t *testing.T) {
+		proxied = nil
+		client := newOpenAIClient(providerClientOptions{
+			apiKey:        "sk-test",
+			model:         models.Model{APIModel: "llama3"},
+			proxy:         proxy,
+			openaiOptions: []OpenAIOption{WithOpenAIBaseURL("http://llm.internal.example.com/v1"), withOpenAICompatible()},
+		})
+		response, err := client.send(context.Background(), []message.Message{msg}, nil)
+		require.NoError(t, err)
+		assert.Equal(t, "Hello", response.Content)
+		assert.Equal(t, []string{"POST http://llm.internal.example.com/v1/chat/completions"}, proxied)
+	})
+
+	t.Run("gemini", func(t *testing.T) {
+		proxied = nil
+		client := newGeminiClient(providerClientOptions{
+			apiKey: "test-key",
+			model:  models.GeminiModels[models.Gemini25Flash],
+			proxy:  proxy,
+			// The context cache is created over gRPC, not with the HTTP client
+			geminiOptions: []GeminiOption{WithGeminiDisableCache()},
+		}).(*geminiClient)
+		model := client.generativeModel(context.Background(), nil)
+		_, err := model.GenerateContent(context.Background(), genai.Text("hello"))
+		require.Error(t, err)
+		assert.Contains(t, proxied, "CONNECT generativelanguage.googleapis.com:443")
+	})
+}
@@ -0,0 +1,523 @@
+package provider
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/opencode-ai/opencode/internal/llm/tools"
+	"github.com/opencode-ai/opencode/internal/logging"
+	"github.com/opencode-ai/opencode/internal/message"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+)
+
+const vertexAIScope = "https://www.googleapis.com/auth/cloud-platform"
+
+type vertexaiOptions struct {
+	project         string
+	location        string
+	credentialsFile string
+	endpoint        string
+	tokenSource     oauth2.TokenSource
+}
+
+type VertexAIOption func(*vertexaiOptions)
+
+// vertexaiClient calls Gemini models through the Vertex AI REST API. Requests
+// are authorized with Application Default Credentials or a service account
+// key, and billed to a GCP project instead of a Gemini API key.
+type vertexaiClient struct {
+	providerOptions providerClientOptions
+	options         vertexaiOptions
+	client          *http.Client
+}
+
+type VertexAIClient ProviderClient
+
+type vertexaiPart struct {
+	Text             string                    `json:"text,omitempty"`
+	Thought          bool                      `json:"thought,omitempty"`
+	FunctionCall     *vertexaiFunctionCall     `json:"functionCall,omitempty"`
+	FunctionResponse *vertexaiFunctionResponse `json:"functionResponse,omitempty"`
+}
+
+type vertexaiFunctionCall struct {
+	Name string          `json:"name"`
+	Args json.RawMessage `json:"args,omitempty"`
+}
+
+type vertexaiFunctionResponse struct {
+	Name     string         `json:"name"`
+	Response map[string]any `json:"response"`
+}
+
+type vertexaiContent struct {
+	Role  string         `json:"role,omitempty"`
+	Parts []vertexaiPart `json:"parts"`
+}
+
+type vertexaiFunctionDeclaration struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	Parameters  map[string]any `json:"parameters,omitempty"`
+}
+
+type vertexaiTool struct {
+	FunctionDeclarations []vertexaiFunctionDeclaration `json:"functionDeclarations"`
+}
+
+type vertexaiRequest struct {
+	Contents          []vertexaiContent `json:"contents"`
+	SystemInstruction *vertexaiContent  `json:"systemInstruction,omitempty"`
+	Tools             []vertexaiTool    `json:"tools,omitempty"`
+	GenerationConfig  struct {
+		MaxOutputTokens int64 `json:"maxOutputTokens,omitempty"`
+	} `json:"generationConfig"`
+}
+
+type vertexaiResponse struct {
+	Candidates []struct {
+		Content      vertexaiContent `json:"content"`
+		FinishReason string          `json:"finishReason"`
+	} `json:"candidates"`
+	UsageMetadata struct {
+		PromptTokenCount        int64 `json:"promptTokenCount"`
+		CandidatesTokenCount    int64 `json:"candidatesTokenCount"`
+		CachedContentTokenCount int64 `json:"cachedContentTokenCount"`
+	} `json:"usageMetadata"`
+}
+
+// vertexaiAPIError is a Google API error returned by Vertex AI.
+type vertexaiAPIError struct {
+	StatusCode int
+	Status     string
+	Message    string
+}
+
+func (e *vertexaiAPIError) Error() string {
+	return fmt.Sprintf("vertexai: %s: %s (status %d)", e.Status, e.Message, e.StatusCode)
+}
+
+func newVertexAIClient(opts providerClientOptions) VertexAIClient {
+	vertexOpts := vertexaiOptions{
+		project:         os.Getenv("GOOGLE_CLOUD_PROJECT"),
+		location:        os.Getenv("GOOGLE_CLOUD_LOCATION"),
+		credentialsFile: os.Getenv("GOOGLE_APPLICATION_CREDENTIALS"),
+	}
+	for _, o := range opts.vertexaiOptions {
+		o(&vertexOpts)
+	}
+	if vertexOpts.location == "" {
+		vertexOpts.location = "us-central1"
+	}
+	if vertexOpts.endpoint == "" {
+		vertexOpts.endpoint = vertexAIEndpoint(vertexOpts.location)
+	}
+
+	return &vertexaiClient{
+		providerOptions: opts,
+		options:         vertexOpts,
+	}
+}
+
+// vertexAIEndpoint returns the regional endpoint of a location. The global
+// location has no regional prefix.
+func vertexAIEndpoint(location string) string {
+	if location == "global" {
+		return "https://aiplatform.googleapis.com"
+	}
+	return fmt.Sprintf("https://%s-aiplatform.googleapis.com", location)
+}
+
+// authorize resolves the credentials on first use, so a missing ADC setup is
+// reported when the model is called instead of on startup.
+func (v *vertexaiClient) authorize(ctx context.Context) error {
+	if v.client != nil {
+		return nil
+	}
+	if v.options.tokenSource == nil {
+		var creds *google.Credentials
+		var err error
+		if v.options.credentialsFile != "" {
+			var data []byte
+			if data, err = os.ReadFile(v.options.credentialsFile); err != nil {
+				return fmt.Errorf("failed to read Google credentials: %w", err)
+			}
+			creds, err = google.CredentialsFromJSON(ctx, data, vertexAIScope)
+		} else {
+			creds, err = google.FindDefaultCredentials(ctx, vertexAIScope)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to load Google credentials, run `gcloud auth application-default login` or set GOOGLE_APPLICATION_CREDENTIALS: %w", err)
+		}
+		v.options.tokenSource = creds.TokenSource
+		if v.options.project == "" {
+			v.options.project = creds.ProjectID
+		}
+	}
+	if v.options.project == "" {
+		return errors.New("vertexai: no GCP project configured, set providers.vertexai.project or GOOGLE_CLOUD_PROJECT")
+	}
+
+	base := v.providerOptions.httpClient()
+	v.client = &http.Client{
+		Transport: &oauth2.Transport{Source: v.options.tokenSource, Base: base.Transport},
+	}
+	return nil
+}
+
+func (v *vertexaiClient) convertMessages(messages []message.Message) []vertexaiContent {
+	toolNames := make(map[string]string)
+	var contents []vertexaiContent
+	for _, msg := range messages {
+		switch msg.Role {
+		case message.User:
+			contents = append(contents, vertexaiContent{
+				Role:  "user",
+				Parts: []vertexaiPart{{Text: msg.Content().String()}},
+			})
+		case message.Assistant:
+			content := vertexaiContent{Role: "model"}
+			if text := msg.Content().String(); text != "" {
+				content.Parts = append(content.Parts, vertexaiPart{Text: text})
+			}
+			for _, call := range msg.ToolCalls() {
+				toolNames[call.ID] = call.Name
+				args := json.RawMessage(call.Input)
+				if !json.Valid(args) {
+					args = json.RawMessage("{}")
+				}
+				content.Parts = append(content.Parts, vertexaiPart{
+					FunctionCall: &vertexaiFunctionCall{Name: call.Name, Args: args},
+				})
+			}
+			if len(content.Parts) > 0 {
+				contents = append(contents, content)
+			}
+		case message.Tool:
+			// All responses to a turn's calls go in a single content
+			content := vertexaiContent{Role: "user"}
+			for _, result := range msg.ToolResults() {
+				response, err := parseJsonToMap(result.Content)
+				if err != nil || response == nil {
+					response = map[string]any{"result": result.Content}
+				}
+				content.Parts = append(content.Parts, vertexaiPart{
+					FunctionResponse: &vertexaiFunctionResponse{Name: toolNames[result.ToolCallID], Response: response},
+				})
+			}
+			contents = append(contents, content)
+		}
+	}
+	return contents
+}
+
+func (v *vertexaiClient) convertTools(tools []tools.BaseTool) []vertexaiTool {
+	declarations := make([]vertexaiFunctionDeclaration, 0, len(tools))
+	for _, tool := range tools {
+		info := tool.Info()
+		declarations = append(declarations, vertexaiFunctionDeclaration{
+			Name:        info.Name,
+			Description: info.Description,
+			Parameters: vertexaiSchema(map[string]any{
+				"type":       "object",
+				"properties": info.Parameters,
+				"required":   info.Required,
+			}),
+		})
+	}
+	return []vertexaiTool{{FunctionDeclarations: declarations}}
+}
+
+// vertexaiSchema converts a JSON schema to the OpenAPI subset Vertex AI
+// accepts, whose types are upper case enum values.
+func vertexaiSchema(schema map[string]any) map[string]any {
+	converted := make(map[string]any, len(schema))
+	for k, value := range schema {
+		switch k {
+		case "type":
+			if t, ok := value.(string); ok {
+				value = strings.ToUpper(t)
+			}
+		case "items":
+			if items, ok := value.(map[string]any); ok {
+				value = vertexaiSchema(items)
+			}
+		case "properties":
+			if props, ok := value.(map[string]any); ok {
+				convertedProps := make(map[string]any, len(props))
+				for name, prop := range props {
+					if p, ok := prop.(map[string]any); ok {
+						convertedProps[name] = vertexaiSchema(p)
+					} else {
+						convertedProps[name] = prop
+					}
+				}
+				value = convertedProps
+			}
+		case "required":
+			if required, ok := value.([]string); ok && len(required) == 0 {
+				continue
+			}
+		}
+		converted[k] = value
+	}
+	return converted
+}
+
+func (v *vertexaiClient) preparedRequest(messages []message.Message, tools []tools.BaseTool) vertexaiRequest {
+	request := vertexaiRequest{
+		Contents: v.convertMessages(messages),
+	}
+	if v.providerOptions.systemMessage != "" {
+		request.SystemInstruction = &vertexaiContent{
+			Parts: []vertexaiPart{{Text: v.providerOptions.systemMessage}},
+		}
+	}
+	if len(tools) > 0 {
+		request.Tools = v.convertTools(tools)
+	}
+	request.GenerationConfig.MaxOutputTokens = v.providerOptions.maxTokens
+	return request
+}
+
+func (v *vertexaiClient) post(ctx context.Context, method string, request vertexaiRequest) (*http.Response, error) {
+	if err := v.authorize(ctx); err != nil {
+		return nil, err
+	}
+	body, err := json.Marshal(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+	endpoint := fmt.Sprintf("%s/v1/projects/%s/locations/%s/publishers/google/models/%s:%s",
+		v.options.endpoint, v.options.project, v.options.location, v.providerOptions.model.APIModel, method)
+
+	var resp *http.Response
+	err = v.providerOptions.retryPolicy.do(ctx, func() error {
+		var err error
+		resp, err = v.postOnce(ctx, endpoint, body)
+		return err
+	}, v.shouldRetry)
+	return resp, err
+}
+
+func (v *vertexaiClient) postOnce(ctx context.Context, endpoint string, body []byte) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(resp.Body)
+		apiErr := &vertexaiAPIError{StatusCode: resp.StatusCode, Status: resp.Status, Message: strings.TrimSpace(string(data))}
+		var errResp struct {
+			Error struct {
+				Message string `json:"message"`
+				Status  string `json:"status"`
+			} `json:"error"`
+		}
+		if json.Unmarshal(data, &errResp) == nil && errResp.Error.Message != "" {
+			apiErr.Status = errResp.Error.Status
+			apiErr.Message = errResp.Error.Message
+		}
+		return nil, apiErr
+	}
+	return resp, nil
+}
+
+func (v *vertexaiClient) shouldRetry(err error) (bool, time.Duration) {
+	var apiErr *vertexaiAPIError
+	if errors.As(err, &apiErr) {
+		return v.providerOptions.retryPolicy.retryableStatus(apiErr.StatusCode), 0
+	}
+	return isTransientNetworkError(err), 0
+}
+
+func (v *vertexaiClient) finishReason(reason string) message.FinishReason {
+	switch reason {
+	case "STOP":
+		return message.FinishReasonEndTurn
+	case "MAX_TOKENS":
+		return message.FinishReasonMaxTokens
+	default:
+		return message.FinishReasonUnknown
+	}
+}
+
+func (v *vertexaiClient) toolCall(call *vertexaiFunctionCall) message.ToolCall {
+	input := string(call.Args)
+	if input == "" || input == "null" {
+		input = "{}"
+	}
+	return message.ToolCall{
+		ID:       "call_" + uuid.New().String(),
+		Name:     call.Name,
+		Input:    input,
+		Type:     "function",
+		Finished: true,
+	}
+}
+
+func (v *vertexaiClient) usage(resp vertexaiResponse) TokenUsage {
+	return TokenUsage{
+		InputTokens:     resp.UsageMetadata.PromptTokenCount - resp.UsageMetadata.CachedContentTokenCount,
+		OutputTokens:    resp.UsageMetadata.CandidatesTokenCount,
+		CacheReadTokens: resp.UsageMetadata.CachedContentTokenCount,
+	}
+}
+
+func (v *vertexaiClient) send(ctx context.Context, messages []message.Message, tools []tools.BaseTool) (*ProviderResponse, error) {
+	resp, err := v.post(ctx, "generateContent", v.preparedRequest(messages, tools))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var vertexResp vertexaiResponse
+	if err := json.NewDecoder(resp.Body).Decode(&vertexResp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	response := &ProviderResponse{
+		Usage:        v.usage(vertexResp),
+		FinishReason: message.FinishReasonEndTurn,
+	}
+	if len(vertexResp.Candidates) > 0 {
+		candidate := vertexResp.Candidates[0]
+		for _, part := range candidate.Content.Parts {
+			switch {
+			case part.FunctionCall != nil:
+				response.ToolCalls = append(response.ToolCalls, v.toolCall(part.FunctionCall))
+			case !part.Thought:
+				response.Content += part.Text
+			}
+		}
+		response.FinishReason = v.finishReason(candidate.FinishReason)
+	}
+	if len(response.ToolCalls) > 0 {
+		response.FinishReason = message.FinishReasonToolUse
+	}
+	return response, nil
+}
+
+func (v *vertexaiClient) stream(ctx context.Context, messages []message.Message, tools []tools.BaseTool) <-chan ProviderEvent {
+	eventChan := make(chan ProviderEvent)
+
+	go func() {
+		defer close(eventChan)
+		fail := func(err error) {
+			if ctx.Err() != nil {
+				err = ctx.Err()
+			}
+			eventChan <- ProviderEvent{Type: EventError, Error: err}
+		}
+
+		resp, err := v.post(ctx, "streamGenerateContent?alt=sse", v.preparedRequest(messages, tools))
+		if err != nil {
+			fail(err)
+			return
+		}
+		defer resp.Body.Close()
+
+		var content strings.Builder
+		var toolCalls []message.ToolCall
+		var last vertexaiResponse
+		finishReason := message.FinishReasonEndTurn
+
+		eventChan <- ProviderEvent{Type: EventContentStart}
+		scanner := bufio.NewScanner(resp.Body)
+		scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+		for scanner.Scan() {
+			data, ok := bytes.CutPrefix(bytes.TrimSpace(scanner.Bytes()), []byte("data: "))
+			if !ok || len(data) == 0 {
+				continue
+			}
+
+			var chunk vertexaiResponse
+			if err := json.Unmarshal(data, &chunk); err != nil {
+				logging.Warn("Error decoding Vertex AI stream chunk", "error", err)
+				continue
+			}
+			last = chunk
+			if len(chunk.Candidates) == 0 {
+				continue
+			}
+			candidate := chunk.Candidates[0]
+			for _, part := range candidate.Content.Parts {
+				switch {
+				case part.FunctionCall != nil:
+					call := v.toolCall(part.FunctionCall)
+					toolCalls = append(toolCalls, call)
+					eventChan <- ProviderEvent{Type: EventToolUseStart, ToolCall: &call}
+				case part.Thought:
+					eventChan <- ProviderEvent{Type: EventThinkingDelta, Thinking: part.Text}
+				case part.Text != "":
+					content.WriteString(part.Text)
+					eventChan <- ProviderEvent{Type: EventContentDelta, Content: part.Text}
+				}
+			}
+			if candidate.FinishReason != "" {
+				finishReason = v.finishReason(candidate.FinishReason)
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			fail(err)
+			return
+		}
+		eventChan <- ProviderEvent{Type: EventContentStop}
+
+		if len(toolCalls) > 0 {
+			finishReason = message.FinishReasonToolUse
+		}
+		eventChan <- ProviderEvent{
+			Type: EventComplete,
+			Response: &ProviderResponse{
+				Content:      content.String(),
+				ToolCalls:    toolCalls,
+				Usage:        v.usage(last),
+				FinishReason: finishReason,
+			},
+		}
+	}()
+
+	return eventChan
+}
+
+// WithVertexAIProject sets the GCP project and location requests are sent to.
+// Empty values fall back to GOOGLE_CLOUD_PROJECT and GOOGLE_CLOUD_LOCATION.
+func WithVertexAIProject(project, location string) VertexAIOption {
+	return func(options *vertexaiOptions) {
+		if project != "" {
+			options.project = project
+		}
+		if location != "" {
+			options.location = location
+		}
+	}
+}
+
+// WithVertexAICredentialsFile authorizes requests with a service account key
+// file instead of Application Default Credentials.
+func WithVertexAICredentialsFile(path string) VertexAIOption {
+	return func(options *vertexaiOptions) {
+		if path != "" {
+			options.credentialsFile = path
+		}
+	}
+}
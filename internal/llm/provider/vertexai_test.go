@@ -0,0 +1,87 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/opencode-ai/opencode/internal/llm/models"
+	"github.com/opencode-ai/opencode/internal/llm/tools"
+	"github.com/opencode-ai/opencode/internal/message"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+)
+
+func TestVertexAIClient_Stream(t *testing.T) {
+	var received map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/projects/my-project/locations/europe-west4/publishers/google/models/gemini-2.5-flash-preview-04-17:streamGenerateContent", r.URL.Path)
+		assert.Equal(t, "sse", r.URL.Query().Get("alt"))
+		assert.Equal(t, "Bearer test-token", r.Header.Get("Authorization"))
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+
+		for _, chunk := range []string{
+			`{"candidates":[{"content":{"role":"model","parts":[{"text":"Reading"}]}}]}`,
+			`{"candidates":[{"content":{"role":"model","parts":[{"functionCall":{"name":"view","args":{"file_path":"main.go"}}}]},"finishReason":"STOP"}],"usageMetadata":{"promptTokenCount":30,"candidatesTokenCount":8}}`,
+		} {
+			fmt.Fprintf(w, "data: %s\r\n\r\n", chunk)
+		}
+	}))
+	defer server.Close()
+
+	client := newVertexAIClient(providerClientOptions{
+		model:         models.VertexAIModels[models.VertexAIGemini25Flash],
+		maxTokens:     1024,
+		systemMessage: "You are a coding assistant.",
+		vertexaiOptions: []VertexAIOption{
+			WithVertexAIProject("my-project", "europe-west4"),
+			func(options *vertexaiOptions) {
+				options.endpoint = server.URL
+				options.tokenSource = oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "test-token"})
+			},
+		},
+	}).(*vertexaiClient)
+
+	view := stubTool{info: tools.ToolInfo{
+		Name:        "view",
+		Description: "View a file",
+		Parameters:  map[string]any{"file_path": map[string]any{"type": "string"}},
+		Required:    []string{"file_path"},
+	}}
+	history := []message.Message{
+		{Role: message.User, Parts: []message.ContentPart{message.TextContent{Text: "read main.go"}}},
+	}
+	var content string
+	var complete *ProviderResponse
+	for event := range client.stream(context.Background(), history, []tools.BaseTool{view}) {
+		require.NoError(t, event.Error)
+		switch event.Type {
+		case EventContentDelta:
+			content += event.Content
+		case EventComplete:
+			complete = event.Response
+		}
+	}
+
+	declaration := received["tools"].([]any)[0].(map[string]any)["functionDeclarations"].([]any)[0].(map[string]any)
+	assert.Equal(t, "OBJECT", declaration["parameters"].(map[string]any)["type"])
+	assert.Equal(t, map[string]any{"type": "STRING"}, declaration["parameters"].(map[string]any)["properties"].(map[string]any)["file_path"])
+	assert.Equal(t, float64(1024), received["generationConfig"].(map[string]any)["maxOutputTokens"])
+
+	assert.Equal(t, "Reading", content)
+	require.NotNil(t, complete)
+	require.Len(t, complete.ToolCalls, 1)
+	assert.Equal(t, "view", complete.ToolCalls[0].Name)
+	assert.JSONEq(t, `{"file_path":"main.go"}`, complete.ToolCalls[0].Input)
+	assert.Equal(t, message.FinishReasonToolUse, complete.FinishReason)
+	assert.Equal(t, TokenUsage{InputTokens: 30, OutputTokens: 8}, complete.Usage)
+}
+
+func TestVertexAIEndpoint(t *testing.T) {
+	assert.Equal(t, "https://us-central1-aiplatform.googleapis.com", vertexAIEndpoint("us-central1"))
+	assert.Equal(t, "https://aiplatform.googleapis.com", vertexAIEndpoint("global"))
+}
@@ -0,0 +1,176 @@
+// Package tokenizer estimates the number of tokens of a prompt locally, before
+// it is sent and for providers that only report token counts once a turn is
+// complete.
+//
+// The estimates approximate the tokenizers of the model families (tiktoken
+// BPE for OpenAI and Llama 3, SentencePiece for Gemini and Mistral) from the
+// shape of the text rather than a vocabulary. They are meant for budgeting
+// the context window, the usage reported by the provider is what is billed.
+package tokenizer
+
+import (
+	"math"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/opencode-ai/opencode/internal/llm/models"
+	"github.com/opencode-ai/opencode/internal/message"
+)
+
+// Tokenizer counts the tokens of text for a model family.
+type Tokenizer interface {
+	Count(text string) int64
+}
+
+// Family is the tokenizer family of a model.
+type Family string
+
+const (
+	// FamilyO200k is tiktoken o200k_base, used by GPT-4o, GPT-4.1 and the o-series
+	FamilyO200k Family = "o200k"
+	// FamilyCl100k is tiktoken cl100k_base, used by GPT-4 and GPT-3.5, and
+	// close to the tokenizers of Llama 3, Qwen and DeepSeek
+	FamilyCl100k Family = "cl100k"
+	// FamilyClaude is the tokenizer of the Claude models
+	FamilyClaude Family = "claude"
+	// FamilySentencePiece is SentencePiece BPE, used by Gemini, Gemma,
+	// Mistral and Llama 2
+	FamilySentencePiece Family = "sentencepiece"
+)
+
+// approximation estimates a BPE tokenizer from the pieces its pre-tokenizer
+// splits the text into.
+type approximation struct {
+	// lettersPerToken is the length up to which a latin word is usually a
+	// single token, longer words are split
+	lettersPerToken float64
+	// runesPerToken is the average length of the tokens of other scripts
+	runesPerToken float64
+	// digitsPerToken is 3 for tiktoken, which groups up to 3 digits, and 1
+	// for SentencePiece, which splits numbers into digits
+	digitsPerToken float64
+	// symbolsPerToken is the average length of the tokens of punctuation
+	symbolsPerToken float64
+}
+
+var families = map[Family]approximation{
+	FamilyO200k:         {lettersPerToken: 6.5, runesPerToken: 1.4, digitsPerToken: 3, symbolsPerToken: 2},
+	FamilyCl100k:        {lettersPerToken: 6, runesPerToken: 1, digitsPerToken: 3, symbolsPerToken: 2},
+	FamilyClaude:        {lettersPerToken: 5.5, runesPerToken: 1, digitsPerToken: 1, symbolsPerToken: 1.5},
+	FamilySentencePiece: {lettersPerToken: 5.5, runesPerToken: 1.2, digitsPerToken: 1, symbolsPerToken: 1.5},
+}
+
+// pieces splits text like the tiktoken pre-tokenizer: contractions, words
+// with their leading space, numbers, punctuation and whitespace.
+var pieces = regexp.MustCompile(`(?i:'s|'t|'re|'ve|'m|'ll|'d)| ?\p{L}+| ?\p{N}+| ?[^\s\p{L}\p{N}]+|\s+`)
+
+// New returns the tokenizer of a family, cl100k for unknown families.
+func New(family Family) Tokenizer {
+	if a, ok := families[family]; ok {
+		return a
+	}
+	return families[FamilyCl100k]
+}
+
+// ForModel returns the tokenizer of the family of a model.
+func ForModel(model models.Model) Tokenizer {
+	return New(ModelFamily(model))
+}
+
+// ModelFamily returns the tokenizer family of a model, from its name or its
+// provider.
+func ModelFamily(model models.Model) Family {
+	name := strings.ToLower(model.APIModel)
+	if name == "" {
+		name = strings.ToLower(string(model.ID))
+	}
+	switch {
+	case strings.Contains(name, "claude"):
+		return FamilyClaude
+	case strings.Contains(name, "gpt-4o"), strings.Contains(name, "gpt-4.1"), strings.Contains(name, "gpt-4.5"),
+		strings.HasPrefix(name, "o1"), strings.HasPrefix(name, "o3"), strings.HasPrefix(name, "o4"):
+		return FamilyO200k
+	case strings.Contains(name, "gpt-"):
+		return FamilyCl100k
+	case strings.Contains(name, "gemini"), strings.Contains(name, "gemma"), strings.Contains(name, "mistral"),
+		strings.Contains(name, "mixtral"), strings.Contains(name, "codestral"), strings.Contains(name, "llama2"),
+		strings.Contains(name, "llama-2"), strings.Contains(name, "codellama"):
+		return FamilySentencePiece
+	case strings.Contains(name, "llama"), strings.Contains(name, "qwen"), strings.Contains(name, "deepseek"):
+		return FamilyCl100k
+	}
+
+	switch model.Provider {
+	case models.ProviderAnthropic, models.ProviderBedrock:
+		return FamilyClaude
+	case models.ProviderOpenAI, models.ProviderAzure:
+		return FamilyO200k
+	case models.ProviderGemini, models.ProviderVertexAI, models.ProviderMistral:
+		return FamilySentencePiece
+	}
+	return FamilyCl100k
+}
+
+// Count estimates the number of tokens of the text.
+func (a approximation) Count(text string) int64 {
+	var tokens float64
+	for _, piece := range pieces.FindAllString(text, -1) {
+		if len(piece) > 1 {
+			// The leading space is part of the token of the word
+			piece = strings.TrimPrefix(piece, " ")
+		}
+		first, _ := utf8.DecodeRuneInString(piece)
+		switch {
+		case unicode.IsSpace(first):
+			// Runs of whitespace, e.g. indentation, are single tokens
+			tokens++
+		case unicode.IsLetter(first):
+			var latin, other int
+			for _, r := range piece {
+				switch {
+				case r < utf8.RuneSelf:
+					latin++
+				case unicode.IsLetter(r):
+					other++
+				}
+			}
+			tokens += math.Ceil(float64(latin)/a.lettersPerToken) + math.Ceil(float64(other)/a.runesPerToken)
+		case unicode.IsNumber(first):
+			tokens += math.Ceil(float64(utf8.RuneCountInString(piece)) / a.digitsPerToken)
+		default:
+			tokens += math.Ceil(float64(utf8.RuneCountInString(piece)) / a.symbolsPerToken)
+		}
+	}
+	return int64(tokens)
+}
+
+const (
+	// messageTokens is the overhead of the role and delimiters of a message
+	messageTokens = 4
+	// imageTokens is the cost of an image at the usual resolution, e.g. a
+	// screenshot, the real cost depends on its size
+	imageTokens = 1000
+)
+
+// CountMessages estimates the number of prompt tokens of the messages.
+func CountMessages(t Tokenizer, messages []message.Message) int64 {
+	var tokens int64
+	for _, msg := range messages {
+		tokens += messageTokens
+		for _, part := range msg.Parts {
+			switch part := part.(type) {
+			case message.TextContent:
+				tokens += t.Count(part.Text)
+			case message.ToolCall:
+				tokens += t.Count(part.Name) + t.Count(part.Input) + messageTokens
+			case message.ToolResult:
+				tokens += t.Count(part.Content) + messageTokens
+			case message.BinaryContent, message.ImageURLContent:
+				tokens += imageTokens
+			}
+		}
+	}
+	return tokens
+}
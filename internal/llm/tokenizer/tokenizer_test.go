@@ -0,0 +1,88 @@
+package tokenizer
+
+import (
+	"testing"
+
+	"github.com/opencode-ai/opencode/internal/llm/models"
+	"github.com/opencode-ai/opencode/internal/message"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestModelFamily(t *testing.T) {
+	tests := []struct {
+		model models.Model
+		want  Family
+	}{
+		{models.Model{ID: "claude-3.7-sonnet", APIModel: "claude-3-7-sonnet-latest"}, FamilyClaude},
+		{models.Model{APIModel: "gpt-4o-mini"}, FamilyO200k},
+		{models.Model{APIModel: "o4-mini"}, FamilyO200k},
+		{models.Model{APIModel: "gpt-3.5-turbo"}, FamilyCl100k},
+		{models.Model{APIModel: "gemini-2.5-flash-preview-04-17"}, FamilySentencePiece},
+		{models.Model{APIModel: "mistral-small:24b"}, FamilySentencePiece},
+		{models.Model{APIModel: "codellama:7b"}, FamilySentencePiece},
+		{models.Model{APIModel: "llama3.1:8b"}, FamilyCl100k},
+		{models.Model{APIModel: "qwen2.5-coder:7b"}, FamilyCl100k},
+		// The ID without an API model
+		{models.Model{ID: "gemma3:4b"}, FamilySentencePiece},
+		// The provider when the name is unknown
+		{models.Model{APIModel: "us.custom-model", Provider: models.ProviderBedrock}, FamilyClaude},
+		{models.Model{APIModel: "my-deployment", Provider: models.ProviderAzure}, FamilyO200k},
+		{models.Model{APIModel: "custom", Provider: models.ProviderVertexAI}, FamilySentencePiece},
+		{models.Model{APIModel: "custom", Provider: models.ProviderOllama}, FamilyCl100k},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, ModelFamily(tt.model), "%s %s", tt.model.ID, tt.model.APIModel)
+	}
+}
+
+func TestCount(t *testing.T) {
+	tests := []struct {
+		family Family
+		text   string
+		want   int64
+	}{
+		{FamilyCl100k, "", 0},
+		// The leading space is part of the word
+		{FamilyCl100k, "hello world", 2},
+		{FamilyCl100k, "internationalization", 4},
+		{FamilyO200k, "internationalization", 4},
+		// tiktoken groups digits by 3, SentencePiece splits them
+		{FamilyCl100k, "1234567", 3},
+		{FamilySentencePiece, "1234567", 7},
+		// Indentation is a single token
+		{FamilyCl100k, "\n        return", 2},
+		{FamilyCl100k, "a != b", 3},
+		{FamilyCl100k, "你好世界", 4},
+		{FamilyO200k, "你好世界", 3},
+		{FamilyCl100k, "don't", 2},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, New(tt.family).Count(tt.text), "%s %q", tt.family, tt.text)
+	}
+
+	// Unknown families count like cl100k
+	assert.Equal(t, New(FamilyCl100k), New("unknown"))
+}
+
+func TestCountMessages(t *testing.T) {
+	tok := New(FamilyCl100k)
+	assert.Zero(t, CountMessages(tok, nil))
+
+	msgs := []message.Message{
+		{Role: message.User, Parts: []message.ContentPart{
+			message.TextContent{Text: "hello world"},
+			message.BinaryContent{MIMEType: "image/png", Data: []byte("png")},
+		}},
+		{Role: message.Assistant, Parts: []message.ContentPart{
+			message.ToolCall{ID: "call-1", Name: "view", Input: "path"},
+		}},
+		{Role: message.Tool, Parts: []message.ContentPart{
+			message.ToolResult{ToolCallID: "call-1", Content: "hello world"},
+		}},
+	}
+	// The overhead of every message and tool call, the text and an image
+	want := messageTokens + 2 + imageTokens +
+		messageTokens + 1 + 1 + messageTokens +
+		messageTokens + 2 + messageTokens
+	assert.Equal(t, int64(want), CountMessages(tok, msgs))
+}
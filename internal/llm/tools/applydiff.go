@@ -0,0 +1,257 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/opencode-ai/opencode/internal/config"
+	"github.com/opencode-ai/opencode/internal/diff"
+	"github.com/opencode-ai/opencode/internal/history"
+	"github.com/opencode-ai/opencode/internal/lsp"
+	"github.com/opencode-ai/opencode/internal/permission"
+)
+
+type ApplyDiffParams struct {
+	Diff string `json:"diff"`
+}
+
+type ApplyDiffResponseMetadata struct {
+	FilesChanged []string `json:"files_changed"`
+	Additions    int      `json:"additions"`
+	Removals     int      `json:"removals"`
+	FailedHunks  int      `json:"failed_hunks"`
+}
+
+type applyDiffTool struct {
+	lspClients  map[string]*lsp.Client
+	permissions permission.Service
+	files       history.Service
+}
+
+const (
+	ApplyDiffToolName    = "applydiff"
+	applyDiffDescription = `Applies a unified diff, like the output of git diff or diff -u, to one or more files.
+
+WHEN TO USE THIS TOOL:
+- Use to make changes in several places of one or more files at once
+- Use when writing a diff is more natural than the exact old strings of the Edit tool
+
+HOW TO USE:
+- Read the files with the View tool before changing them
+- Give each file a --- line with its old path and a +++ line with its new path, git's a/ and b/ prefixes are removed
+- Paths are relative to the working directory, or absolute
+- Use /dev/null as the old path to create a file, and as the new path to delete one
+- Start each hunk with an @@ -line,count +line,count @@ header, and prefix its lines with a space for context, - for removed and + for added lines
+- Include about 3 lines of context around each change
+
+EXAMPLE:
+--- a/server/main.go
++++ b/server/main.go
+@@ -10,7 +10,7 @@ func main() {
+ 	cfg := loadConfig()
+ 	srv := newServer(cfg)
+
+-	log.Fatal(srv.ListenAndServe())
++	log.Fatal(srv.ListenAndServeTLS(cfg.Cert, cfg.Key))
+ }
+
+MATCHING:
+- Hunks are searched near their line numbers, so wrong line numbers or counts are fine
+- Hunks whose lines differ only in whitespace from the file are applied
+- Up to 2 context lines at both ends of a hunk can be dropped to find it
+- Hunks that can't be found are reported and skipped, the other hunks are still applied; send the failed hunks again after reading the file`
+)
+
+func NewApplyDiffTool(lspClients map[string]*lsp.Client, permissions permission.Service, files history.Service) BaseTool {
+	return &applyDiffTool{
+		lspClients:  lspClients,
+		permissions: permissions,
+		files:       files,
+	}
+}
+
+func (a *applyDiffTool) Info() ToolInfo {
+	return ToolInfo{
+		Name:        ApplyDiffToolName,
+		Description: applyDiffDescription,
+		Parameters: map[string]any{
+			"diff": map[string]any{
+				"type":        "string",
+				"description": "The unified diff to apply",
+			},
+		},
+		Required: []string{"diff"},
+	}
+}
+
+func (a *applyDiffTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error) {
+	var params ApplyDiffParams
+	if err := json.Unmarshal([]byte(call.Input), &params); err != nil {
+		return NewTextErrorResponse("invalid parameters"), nil
+	}
+	if strings.TrimSpace(params.Diff) == "" {
+		return NewTextErrorResponse("diff is required"), nil
+	}
+	fileDiffs, err := diff.ParseFileDiffs(params.Diff)
+	if err != nil {
+		return NewTextErrorResponse(fmt.Sprintf("failed to parse diff: %s", err)), nil
+	}
+
+	commit := diff.Commit{Changes: make(map[string]diff.FileChange, len(fileDiffs))}
+	var report []string
+	failedHunks := 0
+	for _, fd := range fileDiffs {
+		path := fd.OldPath
+		if path == "" {
+			path = fd.NewPath
+		}
+		absPath := resolveDiffPath(path)
+		if _, ok := commit.Changes[absPath]; ok {
+			return NewTextErrorResponse(fmt.Sprintf("the diff changes %s more than once, put all of its hunks after one --- and +++ line", path)), nil
+		}
+
+		if fd.OldPath == "" {
+			if _, err := os.Stat(absPath); err == nil {
+				return NewTextErrorResponse(fmt.Sprintf("file already exists and cannot be created: %s", absPath)), nil
+			} else if !os.IsNotExist(err) {
+				return ToolResponse{}, fmt.Errorf("failed to check file: %w", err)
+			}
+			newContent, _ := diff.ApplyFileDiff("", fd)
+			commit.Changes[absPath] = diff.FileChange{Type: diff.ActionAdd, NewContent: &newContent}
+			continue
+		}
+
+		oldContent, errResponse, err := readDiffFile(absPath)
+		if err != nil {
+			return ToolResponse{}, err
+		}
+		if errResponse != "" {
+			return NewTextErrorResponse(errResponse), nil
+		}
+		if fd.NewPath == "" {
+			commit.Changes[absPath] = diff.FileChange{Type: diff.ActionDelete, OldContent: &oldContent}
+			continue
+		}
+
+		newContent, results := diff.ApplyFileDiff(oldContent, fd)
+		applied := 0
+		for i, result := range results {
+			switch {
+			case result.Error != "":
+				failedHunks++
+				report = append(report, fmt.Sprintf("%s: hunk %d (%s) failed: %s", path, i+1, result.Header, result.Error))
+			case result.Fuzzy == "whitespace":
+				applied++
+				report = append(report, fmt.Sprintf("%s: hunk %d applied at line %d ignoring whitespace", path, i+1, result.Line))
+			case result.Fuzzy == "context":
+				applied++
+				report = append(report, fmt.Sprintf("%s: hunk %d applied at line %d without some of its context lines, check the result", path, i+1, result.Line))
+			default:
+				applied++
+			}
+		}
+		change := diff.FileChange{Type: diff.ActionUpdate, OldContent: &oldContent, NewContent: &newContent}
+		if fd.NewPath != fd.OldPath {
+			movePath := resolveDiffPath(fd.NewPath)
+			if _, err := os.Stat(movePath); err == nil {
+				return NewTextErrorResponse(fmt.Sprintf("file already exists and cannot be moved to: %s", movePath)), nil
+			}
+			change.MovePath = &movePath
+		} else if applied == 0 || newContent == oldContent {
+			continue
+		}
+		commit.Changes[absPath] = change
+	}
+
+	if len(commit.Changes) == 0 {
+		report = append(report, "No changes were applied. Read the files again and fix the hunks.")
+		return NewTextErrorResponse(strings.Join(report, "\n")), nil
+	}
+
+	sessionID, messageID := GetContextValues(ctx)
+	if sessionID == "" || messageID == "" {
+		return ToolResponse{}, fmt.Errorf("session ID and message ID are required for applying a diff")
+	}
+	if !requestCommitPermissions(a.permissions, ApplyDiffToolName, sessionID, commit) {
+		return ToolResponse{}, permission.ErrorPermissionDenied
+	}
+	changedFiles, totalAdditions, totalRemovals, err := writeCommit(ctx, a.files, sessionID, commit)
+	if err != nil {
+		return NewTextErrorResponse(fmt.Sprintf("failed to apply diff: %s", err)), nil
+	}
+
+	for _, filePath := range changedFiles {
+		waitForLspDiagnostics(ctx, filePath, a.lspClients)
+	}
+
+	result := fmt.Sprintf("Diff applied. %d files changed, %d additions, %d removals",
+		len(changedFiles), totalAdditions, totalRemovals)
+	if len(report) > 0 {
+		result += "\n\n" + strings.Join(report, "\n")
+	}
+	if failedHunks > 0 {
+		result += fmt.Sprintf("\n\n%d hunks failed and were not applied, the other changes were. Read the files again and send only the failed hunks.", failedHunks)
+	}
+
+	diagnosticsText := ""
+	for _, filePath := range changedFiles {
+		diagnosticsText += getDiagnostics(filePath, a.lspClients)
+	}
+	if diagnosticsText != "" {
+		result += "\n\nDiagnostics:\n" + diagnosticsText
+	}
+
+	response := NewTextResponse(result)
+	if failedHunks > 0 {
+		response = NewTextErrorResponse(result)
+	}
+	return WithResponseMetadata(
+		response,
+		ApplyDiffResponseMetadata{
+			FilesChanged: changedFiles,
+			Additions:    totalAdditions,
+			Removals:     totalRemovals,
+			FailedHunks:  failedHunks,
+		}), nil
+}
+
+func resolveDiffPath(path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(config.WorkingDirectory(), path)
+}
+
+// readDiffFile reads a file the diff changes, which must have been read since
+// it was last modified. Problems of the file are returned as the message of an
+// error response.
+func readDiffFile(path string) (string, string, error) {
+	fileInfo, err := os.Stat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", fmt.Sprintf("file not found: %s", path), nil
+		}
+		return "", "", fmt.Errorf("failed to access file: %w", err)
+	}
+	if fileInfo.IsDir() {
+		return "", fmt.Sprintf("path is a directory, not a file: %s", path), nil
+	}
+	lastRead := getLastReadTime(path)
+	if lastRead.IsZero() {
+		return "", fmt.Sprintf("you must read the file %s before changing it. Use the View tool first", path), nil
+	}
+	if modTime := fileInfo.ModTime(); modTime.After(lastRead) {
+		return "", fmt.Sprintf("file %s has been modified since it was last read (mod time: %s, last read: %s)",
+			path, modTime.Format(time.RFC3339), lastRead.Format(time.RFC3339)), nil
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to read file %s: %w", path, err)
+	}
+	return string(content), "", nil
+}
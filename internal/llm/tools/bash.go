@@ -13,13 +13,15 @@ import (
 )
 
 type BashParams struct {
-	Command string `json:"command"`
-	Timeout int    `json:"timeout"`
+	Command   string `json:"command"`
+	Timeout   int    `json:"timeout"`
+	SessionID string `json:"session_id"`
 }
 
 type BashPermissionsParams struct {
-	Command string `json:"command"`
-	Timeout int    `json:"timeout"`
+	Command   string `json:"command"`
+	Timeout   int    `json:"timeout"`
+	SessionID string `json:"session_id,omitempty"`
 }
 
 type BashResponseMetadata struct {
@@ -86,6 +88,7 @@ Usage notes:
 - VERY IMPORTANT: You MUST avoid using search commands like 'find' and 'grep'. Instead use Grep, Glob, or Agent tools to search. You MUST avoid read tools like 'cat', 'head', 'tail', and 'ls', and use FileRead and LS tools to read files.
 - When issuing multiple commands, use the ';' or '&&' operator to separate them. DO NOT use newlines (newlines are ok in quoted strings).
 - IMPORTANT: All commands share the same shell session. Shell state (environment variables, virtual environments, current directory, etc.) persist between commands. For example, if you set an environment variable as part of a command, the environment variable will persist for subsequent commands.
+- To keep separate shell state, e.g. a virtual environment for one project and a different directory for another, set session_id to a name of your choice. Commands with the same session_id run in their own shell, which starts in the working directory and keeps its state until the end of your turn. At most %d sessions can be open at the same time.
 - Try to maintain your current working directory throughout the session by using absolute paths and avoiding usage of 'cd'. You may use 'cd' if the User explicitly requests it.
 <good-example>
 pytest /foo/bar/tests
@@ -200,7 +203,7 @@ EOF
 
 Important:
 - Return an empty response - the user will see the gh output directly
-- Never update git config`, bannedCommandsStr, MaxOutputLength)
+- Never update git config`, bannedCommandsStr, MaxOutputLength, shell.MaxSessionShells)
 }
 
 func NewBashTool(permission permission.Service) BaseTool {
@@ -210,9 +213,17 @@ func NewBashTool(permission permission.Service) BaseTool {
 }
 
 func (b *bashTool) Info() ToolInfo {
+	description := bashDescription()
+	if cfg := config.Get(); cfg != nil && cfg.Sandbox.Backend != "" {
+		description += "\n\nCommands run in a sandbox: the files outside of the working directory are read-only"
+		if !cfg.Sandbox.Network {
+			description += " and there is no network access"
+		}
+		description += ". An interrupted or timed out command restarts the shell, which loses its state."
+	}
 	return ToolInfo{
 		Name:        BashToolName,
-		Description: bashDescription(),
+		Description: description,
 		Parameters: map[string]any{
 			"command": map[string]any{
 				"type":        "string",
@@ -222,6 +233,10 @@ func (b *bashTool) Info() ToolInfo {
 				"type":        "number",
 				"description": "Optional timeout in milliseconds (max 600000)",
 			},
+			"session_id": map[string]any{
+				"type":        "string",
+				"description": "Optional name of a shell session that keeps its directory and environment until the end of the turn",
+			},
 		},
 		Required: []string{"command"},
 	}
@@ -266,7 +281,11 @@ func (b *bashTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error)
 	if sessionID == "" || messageID == "" {
 		return ToolResponse{}, fmt.Errorf("session ID and message ID are required for creating a new file")
 	}
-	if !isSafeReadOnly {
+	sandbox := config.Get().Sandbox
+	// Commands in a sandbox can't change the host outside of the working
+	// directory
+	autoApprove := sandbox.Backend != "" && sandbox.AutoApprove
+	if !isSafeReadOnly && !autoApprove {
 		p := b.permissions.Request(
 			permission.CreatePermissionRequest{
 				SessionID:   sessionID,
@@ -275,7 +294,8 @@ func (b *bashTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error)
 				Action:      "execute",
 				Description: fmt.Sprintf("Execute command: %s", params.Command),
 				Params: BashPermissionsParams{
-					Command: params.Command,
+					Command:   params.Command,
+					SessionID: params.SessionID,
 				},
 			},
 		)
@@ -284,8 +304,21 @@ func (b *bashTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error)
 		}
 	}
 	startTime := time.Now()
-	shell := shell.GetPersistentShell(config.WorkingDirectory())
-	stdout, stderr, exitCode, interrupted, err := shell.Exec(ctx, params.Command, params.Timeout)
+	sh := shell.GetPersistentShell(config.WorkingDirectory(), sandbox)
+	if params.SessionID != "" {
+		var err error
+		sh, err = shell.GetSessionShell(sessionID, params.SessionID, config.WorkingDirectory(), sandbox)
+		if err != nil {
+			return NewTextErrorResponse(err.Error()), nil
+		}
+	}
+	if sh == nil {
+		if sandbox.Backend != "" {
+			return NewTextErrorResponse(fmt.Sprintf("failed to start the shell in the %s sandbox", sandbox.Backend)), nil
+		}
+		return NewTextErrorResponse("failed to start the shell"), nil
+	}
+	stdout, stderr, exitCode, interrupted, err := sh.ExecStream(ctx, params.Command, params.Timeout, GetProgressWriter(ctx))
 	if err != nil {
 		return ToolResponse{}, fmt.Errorf("error executing command: %w", err)
 	}
@@ -336,6 +369,11 @@ func truncateOutput(content string) string {
 	end := content[len(content)-halfLength:]
 
 	truncatedLinesCount := countLines(content[halfLength : len(content)-halfLength])
+	if id, err := storeOutput(storedOutputDir(), content); err == nil {
+		firstLine := strings.Count(start, "\n") + 1
+		return fmt.Sprintf("%s\n\n... [%d lines truncated from line %d, the full output of %d lines is stored with id %s, use the %s tool to read them] ...\n\n%s",
+			start, truncatedLinesCount, firstLine, countLines(content), id, OutputToolName, end)
+	}
 	return fmt.Sprintf("%s\n\n... [%d lines truncated] ...\n\n%s", start, truncatedLinesCount, end)
 }
 
@@ -0,0 +1,380 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/opencode-ai/opencode/internal/browser"
+	"github.com/opencode-ai/opencode/internal/config"
+	"github.com/opencode-ai/opencode/internal/permission"
+)
+
+type BrowserParams struct {
+	Action     string `json:"action"`
+	URL        string `json:"url,omitempty"`
+	Selector   string `json:"selector,omitempty"`
+	Text       string `json:"text,omitempty"`
+	Key        string `json:"key,omitempty"`
+	Expression string `json:"expression,omitempty"`
+	FullPage   bool   `json:"full_page,omitempty"`
+	Timeout    int    `json:"timeout,omitempty"`
+}
+
+type BrowserPermissionsParams struct {
+	URL string `json:"url"`
+}
+
+type BrowserResponseMetadata struct {
+	Action     string `json:"action"`
+	URL        string `json:"url,omitempty"`
+	Title      string `json:"title,omitempty"`
+	Screenshot string `json:"screenshot,omitempty"`
+	Count      int    `json:"count,omitempty"`
+}
+
+type browserTool struct {
+	cfg         config.Browser
+	permissions permission.Service
+}
+
+const (
+	BrowserToolName = "browser"
+
+	// browserTimeout is how long an action may take by default, in seconds
+	browserTimeout = 30
+	// maxBrowserTimeout is the longest timeout of an action, in seconds
+	maxBrowserTimeout = 120
+	// maxQueryElements is how many of the elements matching a selector are
+	// returned
+	maxQueryElements = 50
+)
+
+var browserActions = []string{"navigate", "query", "click", "type", "press", "wait", "evaluate", "screenshot", "console", "close"}
+
+func NewBrowserTool(cfg config.Browser, permissions permission.Service) BaseTool {
+	return &browserTool{
+		cfg:         cfg,
+		permissions: permissions,
+	}
+}
+
+func (b *browserTool) Info() ToolInfo {
+	allowed := "None, opening any page asks the user for permission"
+	if len(b.cfg.AllowedHosts) > 0 {
+		allowed = "- " + strings.Join(b.cfg.AllowedHosts, "\n- ") + "\nOther hosts ask the user for permission"
+	}
+	return ToolInfo{
+		Name: BrowserToolName,
+		Description: fmt.Sprintf(`Drives a headless Chromium to open pages, inspect and use them like a user does, and run JavaScript on them.
+
+WHEN TO USE THIS TOOL:
+- Use to check that a UI change renders and behaves as intended, e.g. on the local dev server
+- Use to reproduce a frontend bug end to end, by clicking and typing through the steps and reading the console errors
+- Use the Fetch tool instead to read documentation, and the HTTP tool to call APIs
+
+ALLOWED HOSTS:
+%s
+
+HOW TO USE:
+- action "navigate" opens the url and waits until the page loaded, the page is kept for the next actions
+- action "query" returns the elements matching the CSS selector, with their text, attributes, position and size
+- action "click" clicks the first element matching the selector
+- action "type" types the text into the first element matching the selector, replacing its value
+- action "press" presses the key in the focused element, one of: %s
+- action "wait" waits until an element matches the selector, for content loaded after the page
+- action "evaluate" runs the JavaScript expression on the page and returns its value as JSON, promises are awaited
+- action "screenshot" saves a PNG image of the viewport, or of the whole page with full_page, and returns its path
+- action "console" returns the console messages, uncaught exceptions and failed requests of the page
+- action "close" closes the page
+- Every action returns the console messages logged since the last one
+- Optionally set a timeout in seconds (default %d, max %d)
+
+LIMITATIONS:
+- You can't see the screenshots, they are saved for the user; use query and evaluate to check the page yourself
+- The page is 1280x800 CSS pixels, each session has one page
+- Dialogs like alert and confirm are accepted at once
+- File uploads, downloads and iframes are not supported`, allowed, strings.Join(browser.KeyNames(), ", "), browserTimeout, maxBrowserTimeout),
+		Parameters: map[string]any{
+			"action": map[string]any{
+				"type":        "string",
+				"description": "What to do in the browser",
+				"enum":        browserActions,
+			},
+			"url": map[string]any{
+				"type":        "string",
+				"description": "The URL to open, for navigate",
+			},
+			"selector": map[string]any{
+				"type":        "string",
+				"description": "The CSS selector of the elements, for query, click, type and wait",
+			},
+			"text": map[string]any{
+				"type":        "string",
+				"description": "The text to type",
+			},
+			"key": map[string]any{
+				"type":        "string",
+				"description": "The key to press",
+				"enum":        browser.KeyNames(),
+			},
+			"expression": map[string]any{
+				"type":        "string",
+				"description": "The JavaScript expression to evaluate",
+			},
+			"full_page": map[string]any{
+				"type":        "boolean",
+				"description": "Capture the whole page instead of the viewport, for screenshot",
+			},
+			"timeout": map[string]any{
+				"type":        "number",
+				"description": "Optional timeout in seconds (max 120)",
+			},
+		},
+		Required: []string{"action"},
+	}
+}
+
+func (b *browserTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error) {
+	var params BrowserParams
+	if err := json.Unmarshal([]byte(call.Input), &params); err != nil {
+		return NewTextErrorResponse(fmt.Sprintf("error parsing parameters: %s", err)), nil
+	}
+	sessionID, messageID := GetContextValues(ctx)
+	if sessionID == "" || messageID == "" {
+		return ToolResponse{}, errors.New("session ID and message ID are required for the browser")
+	}
+	if errText := validateBrowserParams(params); errText != "" {
+		return NewTextErrorResponse(errText), nil
+	}
+	metadata := BrowserResponseMetadata{Action: params.Action}
+
+	if params.Action == "close" {
+		if !browser.ClosePage(ctx, sessionID) {
+			return WithResponseMetadata(NewTextResponse("No page is open"), metadata), nil
+		}
+		return WithResponseMetadata(NewTextResponse("Closed the page"), metadata), nil
+	}
+	if params.Action == "navigate" && !b.allowNavigate(sessionID, params.URL) {
+		return ToolResponse{}, permission.ErrorPermissionDenied
+	}
+
+	timeout := browserTimeout
+	if params.Timeout > 0 {
+		timeout = min(params.Timeout, maxBrowserTimeout)
+	}
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(timeout)*time.Second)
+	defer cancel()
+	var page *browser.Page
+	if params.Action == "navigate" {
+		var err error
+		if page, err = browser.PageFor(ctx, sessionID, b.cfg.Path, b.cfg.Args); err != nil {
+			return NewTextErrorResponse(fmt.Sprintf("failed to start the browser: %s", err)), nil
+		}
+	} else if page = browser.OpenPage(sessionID); page == nil {
+		return NewTextErrorResponse("no page is open, navigate to a URL first"), nil
+	}
+
+	output, err := b.run(ctx, page, sessionID, params, &metadata)
+	console := formatConsole(page.Console())
+	if params.Action == "console" && console == "" {
+		console = "\n\nThere are no console messages since the last action"
+	}
+	output += console
+	if err != nil {
+		return NewTextErrorResponse(strings.TrimSpace(err.Error() + output)), nil
+	}
+	return WithResponseMetadata(NewTextResponse(output), metadata), nil
+}
+
+// run runs the action on the page and returns its result.
+func (b *browserTool) run(ctx context.Context, page *browser.Page, sessionID string, params BrowserParams, metadata *BrowserResponseMetadata) (string, error) {
+	switch params.Action {
+	case "navigate":
+		pageURL, title, err := page.Navigate(ctx, params.URL)
+		if err != nil {
+			return "", err
+		}
+		metadata.URL, metadata.Title = pageURL, title
+		return fmt.Sprintf("Opened %s (title %q)", pageURL, title), nil
+	case "query":
+		elements, count, err := page.Query(ctx, params.Selector, maxQueryElements)
+		if err != nil {
+			return "", err
+		}
+		metadata.Count = count
+		return formatElements(params.Selector, elements, count), nil
+	case "click":
+		if err := page.Click(ctx, params.Selector); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Clicked %s", params.Selector), nil
+	case "type":
+		if err := page.Type(ctx, params.Selector, params.Text); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Typed %d characters into %s", len([]rune(params.Text)), params.Selector), nil
+	case "press":
+		if err := page.Press(ctx, params.Key); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Pressed %s", params.Key), nil
+	case "wait":
+		if err := page.WaitFor(ctx, params.Selector); err != nil {
+			if errors.Is(err, context.DeadlineExceeded) {
+				return "", fmt.Errorf("no element matched %s before the timeout", params.Selector)
+			}
+			return "", err
+		}
+		return fmt.Sprintf("An element matches %s", params.Selector), nil
+	case "evaluate":
+		value, err := page.Evaluate(ctx, params.Expression)
+		if err != nil {
+			return "", err
+		}
+		return truncateOutput(value), nil
+	case "screenshot":
+		data, err := page.Screenshot(ctx, params.FullPage)
+		if err != nil {
+			return "", err
+		}
+		path, err := saveScreenshot(sessionID, data)
+		if err != nil {
+			return "", fmt.Errorf("failed to save the screenshot: %w", err)
+		}
+		metadata.Screenshot = path
+		return fmt.Sprintf("Saved the screenshot to %s, tell the user to open it to see the page", path), nil
+	case "console":
+		return "Console messages of the page since the last action:", nil
+	}
+	return "", fmt.Errorf("unknown action %q", params.Action)
+}
+
+// validateBrowserParams returns why the parameters can't be used for their
+// action, or empty when they can.
+func validateBrowserParams(params BrowserParams) string {
+	switch params.Action {
+	case "navigate":
+		u, err := url.Parse(params.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return "url must be an absolute http:// or https:// URL"
+		}
+	case "query", "click", "wait":
+		if strings.TrimSpace(params.Selector) == "" {
+			return fmt.Sprintf("selector is required for %s", params.Action)
+		}
+	case "type":
+		if strings.TrimSpace(params.Selector) == "" || params.Text == "" {
+			return "selector and text are required for type"
+		}
+	case "press":
+		if params.Key == "" {
+			return "key is required for press"
+		}
+	case "evaluate":
+		if strings.TrimSpace(params.Expression) == "" {
+			return "expression is required for evaluate"
+		}
+	case "screenshot", "console", "close":
+	default:
+		return fmt.Sprintf("unknown action %q, must be one of: %s", params.Action, strings.Join(browserActions, ", "))
+	}
+	return ""
+}
+
+// allowNavigate reports whether the URL may be opened, the host is allowed or
+// the user gave permission.
+func (b *browserTool) allowNavigate(sessionID, rawURL string) bool {
+	u, _ := url.Parse(rawURL)
+	for _, pattern := range b.cfg.AllowedHosts {
+		if httpHostMatches(pattern, u) {
+			return true
+		}
+	}
+	return b.permissions.Request(
+		permission.CreatePermissionRequest{
+			SessionID:   sessionID,
+			Path:        config.WorkingDirectory(),
+			ToolName:    BrowserToolName,
+			Action:      "navigate",
+			Description: fmt.Sprintf("Open %s in the headless browser", rawURL),
+			Params:      BrowserPermissionsParams{URL: rawURL},
+		},
+	)
+}
+
+// formatElements lists the elements matching the selector, with their
+// attributes like an opening tag and their text.
+func formatElements(selector string, elements []browser.Element, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("No element matches %s", selector)
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d elements match %s", count, selector)
+	if count > len(elements) {
+		fmt.Fprintf(&b, ", the first %d are", len(elements))
+	}
+	b.WriteString(":\n")
+	for i, el := range elements {
+		fmt.Fprintf(&b, "%d. <%s", i+1, el.Tag)
+		for _, name := range slices.Sorted(maps.Keys(el.Attributes)) {
+			fmt.Fprintf(&b, " %s=%q", name, el.Attributes[name])
+		}
+		b.WriteString(">")
+		if el.Text != "" {
+			fmt.Fprintf(&b, " %q", el.Text)
+		}
+		if el.Visible {
+			fmt.Fprintf(&b, " at %.0f,%.0f size %.0fx%.0f", el.X, el.Y, el.Width, el.Height)
+		} else {
+			b.WriteString(" (hidden)")
+		}
+		b.WriteString("\n")
+	}
+	return truncateOutput(strings.TrimSuffix(b.String(), "\n"))
+}
+
+// formatConsole formats the console messages of the page for the model,
+// empty when there are none.
+func formatConsole(messages []browser.ConsoleMessage, dropped int) string {
+	if len(messages) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("\n\n<console>\n")
+	if dropped > 0 {
+		fmt.Fprintf(&b, "(%d earlier messages were dropped)\n", dropped)
+	}
+	for _, msg := range messages {
+		fmt.Fprintf(&b, "[%s] %s\n", msg.Level, msg.Text)
+	}
+	b.WriteString("</console>")
+	return truncateOutput(b.String())
+}
+
+// saveScreenshot writes the image to the screenshots directory of the data
+// directory, and returns its path.
+func saveScreenshot(sessionID string, data []byte) (string, error) {
+	cfg := config.Get()
+	if cfg == nil || cfg.Data.Directory == "" {
+		return "", errors.New("no data directory")
+	}
+	dir := filepath.Join(cfg.Data.Directory, "screenshots")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	prefix := sessionID
+	if len(prefix) > 8 {
+		prefix = prefix[:8]
+	}
+	path := filepath.Join(dir, fmt.Sprintf("%s-%s.png", prefix, time.Now().Format("20060102-150405.000")))
+	return path, os.WriteFile(path, data, 0o644)
+}
@@ -0,0 +1,34 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/opencode-ai/opencode/internal/browser"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateBrowserParams(t *testing.T) {
+	assert.Empty(t, validateBrowserParams(BrowserParams{Action: "navigate", URL: "http://localhost:3000/login"}))
+	assert.Equal(t, "url must be an absolute http:// or https:// URL", validateBrowserParams(BrowserParams{Action: "navigate", URL: "file:///etc/passwd"}))
+	assert.Equal(t, "selector is required for click", validateBrowserParams(BrowserParams{Action: "click"}))
+	assert.Equal(t, "selector and text are required for type", validateBrowserParams(BrowserParams{Action: "type", Selector: "#name"}))
+	assert.Empty(t, validateBrowserParams(BrowserParams{Action: "screenshot"}))
+	assert.Contains(t, validateBrowserParams(BrowserParams{Action: "scroll"}), `unknown action "scroll"`)
+}
+
+func TestFormatElements(t *testing.T) {
+	elements := []browser.Element{
+		{Tag: "button", Text: "Save", Attributes: map[string]string{"type": "submit", "class": "primary"}, X: 10, Y: 20.4, Width: 80, Height: 32, Visible: true},
+		{Tag: "button", Attributes: map[string]string{}},
+	}
+	assert.Equal(t, `3 elements match button, the first 2 are:
+1. <button class="primary" type="submit"> "Save" at 10,20 size 80x32
+2. <button> (hidden)`, formatElements("button", elements, 3))
+	assert.Equal(t, "No element matches .missing", formatElements(".missing", nil, 0))
+}
+
+func TestFormatConsole(t *testing.T) {
+	assert.Empty(t, formatConsole(nil, 0))
+	assert.Equal(t, "\n\n<console>\n(3 earlier messages were dropped)\n[error] Uncaught TypeError: x is undefined\n</console>",
+		formatConsole([]browser.ConsoleMessage{{Level: "error", Text: "Uncaught TypeError: x is undefined"}}, 3))
+}
@@ -0,0 +1,138 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/opencode-ai/opencode/internal/clipboard"
+	"github.com/opencode-ai/opencode/internal/config"
+	"github.com/opencode-ai/opencode/internal/permission"
+)
+
+type ClipboardParams struct {
+	Action  string `json:"action"`
+	Content string `json:"content,omitempty"`
+}
+
+type ClipboardPermissionsParams struct {
+	Action  string `json:"action"`
+	Content string `json:"content,omitempty"`
+}
+
+type ClipboardResponseMetadata struct {
+	Backend string `json:"backend"`
+	Length  int    `json:"length"`
+}
+
+type clipboardTool struct {
+	permissions permission.Service
+}
+
+const (
+	ClipboardToolName    = "clipboard"
+	clipboardDescription = `Reads or writes the system clipboard of the user.
+
+WHEN TO USE THIS TOOL:
+- Use read when the user says they copied something, like an error message, a stack trace or a log, and refers to it
+- Use write when the user asks you to copy something for them, like a command, a snippet or a message
+
+HOW TO USE:
+- Set action to read to get the text of the clipboard
+- Set action to write and content to the text to copy
+
+LIMITATIONS:
+- Both actions need the permission of the user
+- Only text is supported
+- Reading needs a clipboard command (pbpaste, wl-paste, xclip or xsel), in remote sessions it may not be available
+- Clipboard contents over %d characters are truncated`
+)
+
+func NewClipboardTool(permissions permission.Service) BaseTool {
+	return &clipboardTool{
+		permissions: permissions,
+	}
+}
+
+func (c *clipboardTool) Info() ToolInfo {
+	return ToolInfo{
+		Name:        ClipboardToolName,
+		Description: fmt.Sprintf(clipboardDescription, MaxOutputLength),
+		Parameters: map[string]any{
+			"action": map[string]any{
+				"type":        "string",
+				"description": "Whether to read or write the clipboard",
+				"enum":        []string{"read", "write"},
+			},
+			"content": map[string]any{
+				"type":        "string",
+				"description": "The text to copy to the clipboard, for write",
+			},
+		},
+		Required: []string{"action"},
+	}
+}
+
+func (c *clipboardTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error) {
+	var params ClipboardParams
+	if err := json.Unmarshal([]byte(call.Input), &params); err != nil {
+		return NewTextErrorResponse("Failed to parse clipboard parameters: " + err.Error()), nil
+	}
+
+	var description string
+	switch params.Action {
+	case "read":
+		description = "Read the text of the clipboard"
+	case "write":
+		if params.Content == "" {
+			return NewTextErrorResponse("content is required to write the clipboard"), nil
+		}
+		description = fmt.Sprintf("Copy to the clipboard:\n\n```\n%s\n```", params.Content)
+	default:
+		return NewTextErrorResponse("action must be one of: read, write"), nil
+	}
+
+	sessionID, messageID := GetContextValues(ctx)
+	if sessionID == "" || messageID == "" {
+		return ToolResponse{}, fmt.Errorf("session ID and message ID are required for using the clipboard")
+	}
+	p := c.permissions.Request(
+		permission.CreatePermissionRequest{
+			SessionID:   sessionID,
+			Path:        config.WorkingDirectory(),
+			ToolName:    ClipboardToolName,
+			Action:      params.Action,
+			Description: description,
+			Params:      ClipboardPermissionsParams(params),
+		},
+	)
+	if !p {
+		return ToolResponse{}, permission.ErrorPermissionDenied
+	}
+
+	if params.Action == "write" {
+		backend, err := clipboard.Write(params.Content)
+		if err != nil {
+			return NewTextErrorResponse(err.Error()), nil
+		}
+		result := "Copied to the clipboard"
+		if backend == clipboard.OSC52 {
+			result += " with OSC 52, the terminal of the user may not support it"
+		}
+		return WithResponseMetadata(NewTextResponse(result), ClipboardResponseMetadata{
+			Backend: backend,
+			Length:  len(params.Content),
+		}), nil
+	}
+
+	text, err := clipboard.Read()
+	if err != nil {
+		return NewTextErrorResponse(err.Error()), nil
+	}
+	if text == "" {
+		return NewTextResponse("The clipboard is empty"), nil
+	}
+	return WithResponseMetadata(NewTextResponse(truncateOutput(text)), ClipboardResponseMetadata{
+		Length: len(text),
+	}), nil
+}
@@ -0,0 +1,243 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/opencode-ai/opencode/internal/config"
+	"github.com/opencode-ai/opencode/internal/permission"
+)
+
+type ContainerParams struct {
+	Command string `json:"command"`
+	Timeout int    `json:"timeout"`
+}
+
+type ContainerPermissionsParams struct {
+	Container string `json:"container"`
+	Command   string `json:"command"`
+}
+
+type ContainerResponseMetadata struct {
+	Container string `json:"container"`
+	StartTime int64  `json:"start_time"`
+	EndTime   int64  `json:"end_time"`
+}
+
+type containerTool struct {
+	cfg         config.Container
+	permissions permission.Service
+}
+
+const (
+	ContainerToolName = "container"
+
+	// defaultContainerWorkdir is where the working directory is mounted in
+	// containers started from an image.
+	defaultContainerWorkdir = "/workspace"
+)
+
+func NewContainerTool(cfg config.Container, permission permission.Service) BaseTool {
+	return &containerTool{
+		cfg:         cfg,
+		permissions: permission,
+	}
+}
+
+// containerName describes the container of the configuration for the model
+// and the permission dialog.
+func containerName(cfg config.Container) string {
+	if cfg.Service != "" {
+		return fmt.Sprintf("compose service %s", cfg.Service)
+	}
+	return fmt.Sprintf("container of image %s", cfg.Image)
+}
+
+func (c *containerTool) Info() ToolInfo {
+	workdir := c.cfg.Workdir
+	if workdir == "" && c.cfg.Service == "" {
+		workdir = defaultContainerWorkdir
+	}
+	location := "The commands run in the default directory of the service."
+	if workdir != "" {
+		location = fmt.Sprintf("The commands run in %s.", workdir)
+	}
+	lifetime := "Every command runs in a new container with the working directory mounted, files written outside of the mounted directory are not kept between commands."
+	if c.cfg.Service != "" {
+		lifetime = "The commands are executed in the running container of the service, which must be started before."
+	}
+
+	return ToolInfo{
+		Name: ContainerToolName,
+		Description: fmt.Sprintf(`Executes a shell command in the %s, the runtime environment of the project.
+
+WHEN TO USE THIS TOOL:
+- Use to run the commands of the project, like builds, tests, package managers and scripts, where the project really runs
+- Use the bash tool for commands on the files of the host, like git
+
+HOW TO USE:
+- Commands run with sh -c
+- %s
+- %s
+- Optionally set a timeout in milliseconds (default: 60000, max: 600000)
+
+LIMITATIONS:
+- Every command needs the permission of the user
+- Commands are not interactive, stdin is closed
+- Output over %d characters is truncated`, containerName(c.cfg), location, lifetime, MaxOutputLength),
+		Parameters: map[string]any{
+			"command": map[string]any{
+				"type":        "string",
+				"description": "The command to execute in the container",
+			},
+			"timeout": map[string]any{
+				"type":        "number",
+				"description": "Optional timeout in milliseconds (max 600000)",
+			},
+		},
+		Required: []string{"command"},
+	}
+}
+
+func (c *containerTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error) {
+	var params ContainerParams
+	if err := json.Unmarshal([]byte(call.Input), &params); err != nil {
+		return NewTextErrorResponse("invalid parameters"), nil
+	}
+	if params.Timeout > MaxTimeout {
+		params.Timeout = MaxTimeout
+	} else if params.Timeout <= 0 {
+		params.Timeout = DefaultTimeout
+	}
+	if strings.TrimSpace(params.Command) == "" {
+		return NewTextErrorResponse("missing command"), nil
+	}
+
+	sessionID, messageID := GetContextValues(ctx)
+	if sessionID == "" || messageID == "" {
+		return ToolResponse{}, fmt.Errorf("session ID and message ID are required for executing a command")
+	}
+	container := containerName(c.cfg)
+	p := c.permissions.Request(
+		permission.CreatePermissionRequest{
+			SessionID:   sessionID,
+			Path:        config.WorkingDirectory(),
+			ToolName:    ContainerToolName,
+			Action:      "execute",
+			Description: fmt.Sprintf("Execute command in the %s: %s", container, params.Command),
+			Params: ContainerPermissionsParams{
+				Container: container,
+				Command:   params.Command,
+			},
+		},
+	)
+	if !p {
+		return ToolResponse{}, permission.ErrorPermissionDenied
+	}
+
+	startTime := time.Now()
+	name := newContainerName()
+	runtime, args := containerArgs(c.cfg, config.WorkingDirectory(), name, params.Command)
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(params.Timeout)*time.Millisecond)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, runtime, args...)
+	cmd.Dir = config.WorkingDirectory()
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		return NewTextErrorResponse(fmt.Sprintf("Failed to run %s: %s", runtime, err)), nil
+	}
+
+	interrupted := ctx.Err() != nil
+	if interrupted && c.cfg.Service == "" {
+		// Killing the CLI doesn't stop the container it started
+		exec.Command(runtime, "rm", "--force", name).Run()
+	}
+
+	output := truncateOutput(stdout.String())
+	errorMessage := truncateOutput(stderr.String())
+	if interrupted {
+		if errorMessage != "" {
+			errorMessage += "\n"
+		}
+		errorMessage += "Command was aborted before completion"
+	} else if exitErr != nil {
+		if errorMessage != "" {
+			errorMessage += "\n"
+		}
+		errorMessage += fmt.Sprintf("Exit code %d", exitErr.ExitCode())
+	}
+	if output != "" && stderr.Len() > 0 {
+		output += "\n"
+	}
+	if errorMessage != "" {
+		output += "\n" + errorMessage
+	}
+
+	metadata := ContainerResponseMetadata{
+		Container: container,
+		StartTime: startTime.UnixMilli(),
+		EndTime:   time.Now().UnixMilli(),
+	}
+	if output == "" {
+		return WithResponseMetadata(NewTextResponse("no output"), metadata), nil
+	}
+	return WithResponseMetadata(NewTextResponse(output), metadata), nil
+}
+
+// containerArgs returns the container CLI and its arguments to run the
+// command. Containers of an image are named, so they can be removed when the
+// command times out.
+func containerArgs(cfg config.Container, workingDir, name, command string) (string, []string) {
+	runtime := cfg.Runtime
+	if runtime == "" {
+		runtime = "docker"
+	}
+	var args []string
+	if cfg.Service != "" {
+		args = []string{"compose"}
+		if cfg.ComposeFile != "" {
+			args = append(args, "--file", cfg.ComposeFile)
+		}
+		args = append(args, "exec", "-T")
+		if cfg.Workdir != "" {
+			args = append(args, "--workdir", cfg.Workdir)
+		}
+	} else {
+		workdir := cfg.Workdir
+		if workdir == "" {
+			workdir = defaultContainerWorkdir
+		}
+		args = []string{
+			"run", "--rm", "--init",
+			"--name", name,
+			"--volume", workingDir + ":" + workdir,
+			"--workdir", workdir,
+		}
+	}
+	for _, env := range cfg.Env {
+		args = append(args, "--env", env)
+	}
+	if cfg.Service != "" {
+		args = append(args, cfg.Service)
+	} else {
+		args = append(args, cfg.Image)
+	}
+	return runtime, append(args, "sh", "-c", command)
+}
+
+func newContainerName() string {
+	b := make([]byte, 6)
+	rand.Read(b)
+	return "opencode-" + hex.EncodeToString(b)
+}
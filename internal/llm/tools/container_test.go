@@ -0,0 +1,23 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/opencode-ai/opencode/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContainerArgs(t *testing.T) {
+	runtime, args := containerArgs(config.Container{Image: "golang:1.24", Env: []string{"CGO_ENABLED=0"}}, "/repo", "opencode-1", "go test ./...")
+	assert.Equal(t, "docker", runtime)
+	assert.Equal(t, []string{
+		"run", "--rm", "--init", "--name", "opencode-1", "--volume", "/repo:/workspace", "--workdir", "/workspace",
+		"--env", "CGO_ENABLED=0", "golang:1.24", "sh", "-c", "go test ./...",
+	}, args)
+
+	runtime, args = containerArgs(config.Container{Service: "app", ComposeFile: "compose.dev.yaml", Runtime: "podman", Workdir: "/app"}, "/repo", "opencode-2", "npm test")
+	assert.Equal(t, "podman", runtime)
+	assert.Equal(t, []string{
+		"compose", "--file", "compose.dev.yaml", "exec", "-T", "--workdir", "/app", "app", "sh", "-c", "npm test",
+	}, args)
+}
@@ -0,0 +1,181 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/opencode-ai/opencode/internal/config"
+	"github.com/opencode-ai/opencode/internal/permission"
+)
+
+type CustomToolPermissionsParams struct {
+	Command string `json:"command"`
+	Input   string `json:"input"`
+}
+
+type CustomToolResponseMetadata struct {
+	Command   string `json:"command"`
+	StartTime int64  `json:"start_time"`
+	EndTime   int64  `json:"end_time"`
+}
+
+type customTool struct {
+	name        string
+	cfg         config.CustomTool
+	permissions permission.Service
+}
+
+// NewCustomTool creates a tool running the command of a tool of the
+// configuration.
+func NewCustomTool(name string, cfg config.CustomTool, permissions permission.Service) BaseTool {
+	return &customTool{
+		name:        name,
+		cfg:         cfg,
+		permissions: permissions,
+	}
+}
+
+func (c *customTool) Info() ToolInfo {
+	parameters, _ := c.cfg.Parameters["properties"].(map[string]any)
+	if parameters == nil {
+		parameters = map[string]any{}
+	}
+	var required []string
+	if values, ok := c.cfg.Parameters["required"].([]any); ok {
+		for _, value := range values {
+			if name, ok := value.(string); ok {
+				required = append(required, name)
+			}
+		}
+	}
+	return ToolInfo{
+		Name:        c.name,
+		Description: c.cfg.Description,
+		Parameters:  parameters,
+		Required:    required,
+	}
+}
+
+func (c *customTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error) {
+	args := map[string]any{}
+	if strings.TrimSpace(call.Input) != "" {
+		if err := json.Unmarshal([]byte(call.Input), &args); err != nil {
+			return NewTextErrorResponse(fmt.Sprintf("error parsing parameters: %s", err)), nil
+		}
+	}
+	for _, name := range c.Info().Required {
+		if _, ok := args[name]; !ok {
+			return NewTextErrorResponse(fmt.Sprintf("%s is required", name)), nil
+		}
+	}
+	command, err := customToolCommand(c.cfg, c.Info().Parameters, args)
+	if err != nil {
+		return NewTextErrorResponse(err.Error()), nil
+	}
+	input, err := json.Marshal(args)
+	if err != nil {
+		return ToolResponse{}, fmt.Errorf("error encoding parameters: %w", err)
+	}
+	commandLine := strings.Join(command, " ")
+
+	if !c.cfg.AutoApprove {
+		sessionID, messageID := GetContextValues(ctx)
+		if sessionID == "" || messageID == "" {
+			return ToolResponse{}, fmt.Errorf("session ID and message ID are required for running a tool")
+		}
+		p := c.permissions.Request(
+			permission.CreatePermissionRequest{
+				SessionID:   sessionID,
+				Path:        config.WorkingDirectory(),
+				ToolName:    c.name,
+				Action:      "execute",
+				Description: fmt.Sprintf("Run `%s` with the input:\n\n```json\n%s\n```", commandLine, input),
+				Params: CustomToolPermissionsParams{
+					Command: commandLine,
+					Input:   string(input),
+				},
+			},
+		)
+		if !p {
+			return ToolResponse{}, permission.ErrorPermissionDenied
+		}
+	}
+
+	startTime := time.Now()
+	cmd := exec.CommandContext(ctx, command[0], command[1:]...)
+	cmd.Dir = config.WorkingDirectory()
+	cmd.Env = append(os.Environ(), c.cfg.Env...)
+	cmd.Stdin = bytes.NewReader(input)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	err = cmd.Run()
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		return NewTextErrorResponse(fmt.Sprintf("Failed to run %s: %s", command[0], err)), nil
+	}
+
+	output := truncateOutput(stdout.String())
+	if stderr.Len() > 0 {
+		if output != "" {
+			output += "\n"
+		}
+		output += truncateOutput(stderr.String())
+	}
+	metadata := CustomToolResponseMetadata{
+		Command:   commandLine,
+		StartTime: startTime.UnixMilli(),
+		EndTime:   time.Now().UnixMilli(),
+	}
+	if exitErr != nil {
+		if output != "" {
+			output += "\n"
+		}
+		output += fmt.Sprintf("Exit code %d", exitErr.ExitCode())
+		return WithResponseMetadata(NewTextErrorResponse(output), metadata), nil
+	}
+	if output == "" {
+		output = "no output"
+	}
+	return WithResponseMetadata(NewTextResponse(output), metadata), nil
+}
+
+// customToolCommand renders the command line of a custom tool with the
+// arguments of a call. Parameters missing in the call render as empty
+// strings, and args that are empty after rendering are left out.
+func customToolCommand(cfg config.CustomTool, parameters map[string]any, args map[string]any) ([]string, error) {
+	data := make(map[string]any, len(parameters)+len(args))
+	for name := range parameters {
+		data[name] = ""
+	}
+	for name, value := range args {
+		data[name] = value
+	}
+
+	var command []string
+	for i, text := range append([]string{cfg.Command}, cfg.Args...) {
+		t, err := template.New("command").Option("missingkey=zero").Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid command template: %w", err)
+		}
+		var rendered strings.Builder
+		if err := t.Execute(&rendered, data); err != nil {
+			return nil, fmt.Errorf("failed to render the command: %w", err)
+		}
+		if rendered.Len() == 0 {
+			if i == 0 {
+				return nil, errors.New("the command is empty")
+			}
+			continue
+		}
+		command = append(command, rendered.String())
+	}
+	return command, nil
+}
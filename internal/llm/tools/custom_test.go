@@ -0,0 +1,49 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/opencode-ai/opencode/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCustomToolInfo(t *testing.T) {
+	tool := NewCustomTool("deploy", config.CustomTool{
+		Description: "Deploys a service",
+		Parameters: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"service": map[string]any{"type": "string"},
+				"env":     map[string]any{"type": "string"},
+			},
+			"required": []any{"service"},
+		},
+		Command: "deployctl",
+	}, nil)
+
+	info := tool.Info()
+	assert.Equal(t, "deploy", info.Name)
+	assert.Equal(t, "Deploys a service", info.Description)
+	assert.Len(t, info.Parameters, 2)
+	assert.Equal(t, []string{"service"}, info.Required)
+}
+
+func TestCustomToolCommand(t *testing.T) {
+	cfg := config.CustomTool{
+		Command: "deployctl",
+		Args:    []string{"up", "{{.service}}", "{{if .env}}--env={{.env}}{{end}}", "--replicas={{.replicas}}"},
+	}
+	parameters := map[string]any{"service": nil, "env": nil, "replicas": nil}
+
+	command, err := customToolCommand(cfg, parameters, map[string]any{"service": "api", "replicas": float64(3)})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"deployctl", "up", "api", "--replicas=3"}, command)
+
+	command, err = customToolCommand(cfg, parameters, map[string]any{"service": "api", "env": "prod"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"deployctl", "up", "api", "--env=prod", "--replicas="}, command)
+
+	_, err = customToolCommand(config.CustomTool{Command: "{{.bin}}"}, map[string]any{"bin": nil}, map[string]any{})
+	assert.EqualError(t, err, "the command is empty")
+}
@@ -0,0 +1,184 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/opencode-ai/opencode/internal/config"
+	"github.com/opencode-ai/opencode/internal/deps"
+)
+
+type DependenciesParams struct {
+	Path string `json:"path"`
+	Name string `json:"name"`
+}
+
+type DependenciesResponseMetadata struct {
+	Manifests    int `json:"manifests"`
+	Dependencies int `json:"dependencies"`
+}
+
+type dependenciesTool struct{}
+
+const (
+	DependenciesToolName    = "dependencies"
+	dependenciesDescription = `Lists the dependencies the manifests of a project declare, with the versions its lockfile resolved or that are installed.
+
+WHEN TO USE THIS TOOL:
+- Use before writing code against a library, to know which version of its API the project uses
+- Use to find out if the project already depends on a library before adding one
+- Use when a bug or build error may come from the version of a dependency
+
+HOW TO USE:
+- Provide the directory of the project or of a package of it (defaults to the working directory)
+- Optionally provide a name to only list the dependencies containing it, like "react" or "serde"
+- Each dependency is listed with its declared version or range, and the resolved version when it differs
+
+SUPPORTED MANIFESTS:
+- go.mod, with replace directives
+- package.json with package-lock.json, or the versions in node_modules for yarn and pnpm
+- pyproject.toml (PEP 621, PEP 735 groups and poetry) and requirements.txt, with uv.lock, poetry.lock or pdm.lock
+- Cargo.toml with Cargo.lock
+- composer.json with composer.lock
+
+LIMITATIONS:
+- Only reads the manifests of the directory, not of its subdirectories; lockfiles are also searched in the parent directories up to the working directory
+- Transitive dependencies are only listed when the manifest declares them, like the indirect requirements of go.mod
+- Does not check for available updates or vulnerabilities`
+)
+
+func NewDependenciesTool() BaseTool {
+	return &dependenciesTool{}
+}
+
+func (d *dependenciesTool) Info() ToolInfo {
+	return ToolInfo{
+		Name:        DependenciesToolName,
+		Description: dependenciesDescription,
+		Parameters: map[string]any{
+			"path": map[string]any{
+				"type":        "string",
+				"description": "The directory with the manifests (defaults to the working directory)",
+			},
+			"name": map[string]any{
+				"type":        "string",
+				"description": "Only list the dependencies whose name contains this, case-insensitive",
+			},
+		},
+		Required: []string{},
+	}
+}
+
+func (d *dependenciesTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error) {
+	var params DependenciesParams
+	if err := json.Unmarshal([]byte(call.Input), &params); err != nil {
+		return NewTextErrorResponse(fmt.Sprintf("error parsing parameters: %s", err)), nil
+	}
+
+	root := config.WorkingDirectory()
+	dir := params.Path
+	if dir == "" {
+		dir = root
+	}
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(root, dir)
+	}
+	info, err := os.Stat(dir)
+	if os.IsNotExist(err) {
+		return NewTextErrorResponse(fmt.Sprintf("path does not exist: %s", dir)), nil
+	}
+	if err != nil {
+		return ToolResponse{}, fmt.Errorf("error accessing path: %w", err)
+	}
+	if !info.IsDir() {
+		// A manifest itself is given often enough
+		dir = filepath.Dir(dir)
+	}
+
+	manifests, err := deps.Find(dir, root)
+	if err != nil {
+		return NewTextErrorResponse(fmt.Sprintf("error reading manifests: %s", err)), nil
+	}
+	if len(manifests) == 0 {
+		return NewTextErrorResponse(fmt.Sprintf("no supported manifest found in %s, provide the directory of the project", dir)), nil
+	}
+
+	output, count := formatManifests(manifests, root, params.Name)
+	if count == 0 && params.Name != "" {
+		output += fmt.Sprintf("\n\nNo dependency matches %q", params.Name)
+	}
+	return WithResponseMetadata(
+		NewTextResponse(truncateOutput(output)),
+		DependenciesResponseMetadata{
+			Manifests:    len(manifests),
+			Dependencies: count,
+		},
+	), nil
+}
+
+// formatManifests lists the dependencies of the manifests whose name contains
+// the filter, grouped by their kind. It returns the output and the number of
+// dependencies listed.
+func formatManifests(manifests []deps.Manifest, root, filter string) (string, int) {
+	filter = strings.ToLower(filter)
+	count := 0
+	var b strings.Builder
+	for i, m := range manifests {
+		if i > 0 {
+			b.WriteString("\n\n")
+		}
+		fmt.Fprintf(&b, "<manifest path=%q ecosystem=%q", relativePath(m.Path, root), m.Ecosystem)
+		if m.Name != "" {
+			fmt.Fprintf(&b, " name=%q", m.Name)
+		}
+		if m.Version != "" {
+			fmt.Fprintf(&b, " version=%q", m.Version)
+		}
+		if m.LockFile != "" {
+			fmt.Fprintf(&b, " lockfile=%q", relativePath(m.LockFile, root))
+		}
+		b.WriteString(">\n")
+
+		group := "-"
+		for _, dep := range m.Dependencies {
+			if filter != "" && !strings.Contains(strings.ToLower(dep.Name), filter) {
+				continue
+			}
+			if dep.Group != group {
+				group = dep.Group
+				if group != "" {
+					fmt.Fprintf(&b, "[%s]\n", group)
+				}
+			}
+			count++
+			b.WriteString(dep.Name)
+			if dep.Constraint != "" {
+				b.WriteString(" " + dep.Constraint)
+			}
+			if dep.Version != "" && dep.Version != dep.Constraint {
+				b.WriteString(" (" + dep.Version + ")")
+			}
+			if dep.Indirect {
+				b.WriteString(" (indirect)")
+			}
+			b.WriteString("\n")
+		}
+		if len(m.Dependencies) == 0 {
+			b.WriteString("No dependencies\n")
+		}
+		b.WriteString("</manifest>")
+	}
+	return b.String(), count
+}
+
+// relativePath returns the path relative to root when it is in it.
+func relativePath(path, root string) string {
+	if rel, err := filepath.Rel(root, path); err == nil && !strings.HasPrefix(rel, "..") {
+		return rel
+	}
+	return path
+}
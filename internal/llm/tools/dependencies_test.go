@@ -0,0 +1,36 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/opencode-ai/opencode/internal/deps"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatManifests(t *testing.T) {
+	manifests := []deps.Manifest{{
+		Path:      "/project/package.json",
+		Ecosystem: deps.EcosystemNPM,
+		Name:      "app",
+		LockFile:  "/project/package-lock.json",
+		Dependencies: []deps.Dependency{
+			{Name: "react", Constraint: "^18.2.0", Version: "18.3.1"},
+			{Name: "react-dom", Constraint: "18.3.1", Version: "18.3.1"},
+			{Name: "@types/react", Constraint: "^18.0.0", Group: "dev"},
+		},
+	}}
+
+	output, count := formatManifests(manifests, "/project", "")
+	assert.Equal(t, 3, count)
+	assert.Equal(t, `<manifest path="package.json" ecosystem="npm" name="app" lockfile="package-lock.json">
+react ^18.2.0 (18.3.1)
+react-dom 18.3.1
+[dev]
+@types/react ^18.0.0
+</manifest>`, output)
+
+	output, count = formatManifests(manifests, "/project", "Types")
+	assert.Equal(t, 1, count)
+	assert.Contains(t, output, "[dev]\n@types/react ^18.0.0\n")
+	assert.NotContains(t, output, "react-dom")
+}
@@ -222,9 +222,10 @@ func (e *editTool) createNewFile(ctx context.Context, filePath, content string)
 	if err != nil {
 		return ToolResponse{}, fmt.Errorf("failed to write file: %w", err)
 	}
+	content, formatNote := formatWrittenFile(ctx, filePath, content)
 
 	// File can't be in the history so we create a new file history
-	_, err = e.files.Create(ctx, sessionID, filePath, "")
+	_, err = e.files.CreateNew(ctx, sessionID, filePath)
 	if err != nil {
 		// Log error but don't fail the operation
 		return ToolResponse{}, fmt.Errorf("error creating file history: %w", err)
@@ -241,7 +242,7 @@ func (e *editTool) createNewFile(ctx context.Context, filePath, content string)
 	recordFileRead(filePath)
 
 	return WithResponseMetadata(
-		NewTextResponse("File created: "+filePath),
+		NewTextResponse("File created: "+filePath+formatNote),
 		EditResponseMetadata{
 			Diff:      diff,
 			Additions: additions,
@@ -333,6 +334,7 @@ func (e *editTool) deleteContent(ctx context.Context, filePath, oldString string
 	if err != nil {
 		return ToolResponse{}, fmt.Errorf("failed to write file: %w", err)
 	}
+	newContent, formatNote := formatWrittenFile(ctx, filePath, newContent)
 
 	// Check if file exists in history
 	file, err := e.files.GetByPathAndSession(ctx, filePath, sessionID)
@@ -360,7 +362,7 @@ func (e *editTool) deleteContent(ctx context.Context, filePath, oldString string
 	recordFileRead(filePath)
 
 	return WithResponseMetadata(
-		NewTextResponse("Content deleted from file: "+filePath),
+		NewTextResponse("Content deleted from file: "+filePath+formatNote),
 		EditResponseMetadata{
 			Diff:      diff,
 			Additions: additions,
@@ -453,6 +455,7 @@ func (e *editTool) replaceContent(ctx context.Context, filePath, oldString, newS
 	if err != nil {
 		return ToolResponse{}, fmt.Errorf("failed to write file: %w", err)
 	}
+	newContent, formatNote := formatWrittenFile(ctx, filePath, newContent)
 
 	// Check if file exists in history
 	file, err := e.files.GetByPathAndSession(ctx, filePath, sessionID)
@@ -480,7 +483,7 @@ func (e *editTool) replaceContent(ctx context.Context, filePath, oldString, newS
 	recordFileRead(filePath)
 
 	return WithResponseMetadata(
-		NewTextResponse("Content replaced in file: "+filePath),
+		NewTextResponse("Content replaced in file: "+filePath+formatNote),
 		EditResponseMetadata{
 			Diff:      diff,
 			Additions: additions,
@@ -6,10 +6,11 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"strings"
+	"sync"
 	"time"
 
-	md "github.com/JohannesKaufmann/html-to-markdown"
 	"github.com/PuerkitoBio/goquery"
 	"github.com/opencode-ai/opencode/internal/config"
 	"github.com/opencode-ai/opencode/internal/permission"
@@ -30,8 +31,27 @@ type FetchPermissionsParams struct {
 type fetchTool struct {
 	client      *http.Client
 	permissions permission.Service
+
+	mu sync.Mutex
+	// pages are the recently fetched responses by format and URL
+	pages map[string]fetchCacheEntry
+	// robots are the robots.txt rules by origin
+	robots map[string]fetchCacheEntry
+}
+
+type fetchCacheEntry struct {
+	content   string
+	rules     robotsRules
+	fetchedAt time.Time
 }
 
+const (
+	// fetchCacheTTL is how long fetched pages and robots.txt files are reused
+	fetchCacheTTL = 15 * time.Minute
+	// fetchCacheSize is the number of pages kept in the cache
+	fetchCacheSize = 50
+)
+
 const (
 	FetchToolName        = "fetch"
 	fetchToolDescription = `Fetches content from a URL and returns it in the specified format.
@@ -48,19 +68,22 @@ HOW TO USE:
 
 FEATURES:
 - Supports three output formats: text, markdown, and html
+- The text and markdown formats only return the main content of HTML pages, without navigation, headers, footers and scripts
 - Automatically handles HTTP redirects
 - Sets reasonable timeouts to prevent hanging
 - Validates input parameters before making requests
+- Pages fetched in the last 15 minutes are returned from a cache
 
 LIMITATIONS:
-- Maximum response size is 5MB
+- Maximum response size is 5MB, and the returned content is truncated to %d characters
 - Only supports HTTP and HTTPS protocols
 - Cannot handle authentication or cookies
+- Pages disallowed by the robots.txt of the site are not fetched
 - Some websites may block automated requests
 
 TIPS:
 - Use text format for plain text content or simple API responses
-- Use markdown format for content that should be rendered with formatting
+- Use markdown format for documentation and other pages that should keep their formatting and links
 - Use html format when you need the raw HTML structure
 - Set appropriate timeouts for potentially slow websites`
 )
@@ -71,13 +94,15 @@ func NewFetchTool(permissions permission.Service) BaseTool {
 			Timeout: 30 * time.Second,
 		},
 		permissions: permissions,
+		pages:       make(map[string]fetchCacheEntry),
+		robots:      make(map[string]fetchCacheEntry),
 	}
 }
 
 func (t *fetchTool) Info() ToolInfo {
 	return ToolInfo{
 		Name:        FetchToolName,
-		Description: fetchToolDescription,
+		Description: fmt.Sprintf(fetchToolDescription, MaxOutputLength),
 		Parameters: map[string]any{
 			"url": map[string]any{
 				"type":        "string",
@@ -115,6 +140,10 @@ func (t *fetchTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error
 	if !strings.HasPrefix(params.URL, "http://") && !strings.HasPrefix(params.URL, "https://") {
 		return NewTextErrorResponse("URL must start with http:// or https://"), nil
 	}
+	pageURL, err := url.Parse(params.URL)
+	if err != nil || pageURL.Host == "" {
+		return NewTextErrorResponse("Invalid URL: " + params.URL), nil
+	}
 
 	sessionID, messageID := GetContextValues(ctx)
 	if sessionID == "" || messageID == "" {
@@ -147,6 +176,15 @@ func (t *fetchTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error
 		}
 	}
 
+	cacheKey := format + " " + params.URL
+	if content, ok := t.cached(t.pages, cacheKey); ok {
+		return NewTextResponse(content.content), nil
+	}
+
+	if !t.robotsAllowed(ctx, client, pageURL) {
+		return NewTextErrorResponse(fmt.Sprintf("Fetching %s is disallowed by the robots.txt of %s", params.URL, pageURL.Host)), nil
+	}
+
 	req, err := http.NewRequestWithContext(ctx, "GET", params.URL, nil)
 	if err != nil {
 		return ToolResponse{}, fmt.Errorf("failed to create request: %w", err)
@@ -172,35 +210,103 @@ func (t *fetchTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error
 
 	content := string(body)
 	contentType := resp.Header.Get("Content-Type")
+	isHTML := strings.Contains(contentType, "text/html")
 
 	switch format {
 	case "text":
-		if strings.Contains(contentType, "text/html") {
-			text, err := extractTextFromHTML(content)
+		if isHTML {
+			text, err := readableText(content)
 			if err != nil {
 				return NewTextErrorResponse("Failed to extract text from HTML: " + err.Error()), nil
 			}
-			return NewTextResponse(text), nil
+			content = text
 		}
-		return NewTextResponse(content), nil
 
 	case "markdown":
-		if strings.Contains(contentType, "text/html") {
-			markdown, err := convertHTMLToMarkdown(content)
+		if isHTML {
+			// Relative links are resolved against the page after redirects
+			markdown, err := readableMarkdown(content, resp.Request.URL.String())
 			if err != nil {
 				return NewTextErrorResponse("Failed to convert HTML to Markdown: " + err.Error()), nil
 			}
-			return NewTextResponse(markdown), nil
+			content = markdown
+		} else {
+			content = "```\n" + content + "\n```"
 		}
+	}
+
+	content = truncateOutput(content)
+	t.store(t.pages, cacheKey, fetchCacheEntry{content: content})
+	return NewTextResponse(content), nil
+}
+
+// robotsAllowed reports whether the robots.txt of the site allows fetching the
+// URL. Sites without a readable robots.txt allow everything.
+func (t *fetchTool) robotsAllowed(ctx context.Context, client *http.Client, pageURL *url.URL) bool {
+	origin := pageURL.Scheme + "://" + pageURL.Host
+	entry, ok := t.cached(t.robots, origin)
+	if !ok {
+		entry = fetchCacheEntry{rules: fetchRobots(ctx, client, origin)}
+		t.store(t.robots, origin, entry)
+	}
+	path := pageURL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	if pageURL.RawQuery != "" {
+		path += "?" + pageURL.RawQuery
+	}
+	return entry.rules.allowed(path)
+}
 
-		return NewTextResponse("```\n" + content + "\n```"), nil
+func fetchRobots(ctx context.Context, client *http.Client, origin string) robotsRules {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, "GET", origin+"/robots.txt", nil)
+	if err != nil {
+		return nil
+	}
+	req.Header.Set("User-Agent", "opencode/1.0")
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 512*1024))
+	if err != nil {
+		return nil
+	}
+	return parseRobots(string(body))
+}
 
-	case "html":
-		return NewTextResponse(content), nil
+func (t *fetchTool) cached(cache map[string]fetchCacheEntry, key string) (fetchCacheEntry, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	entry, ok := cache[key]
+	if !ok || time.Since(entry.fetchedAt) > fetchCacheTTL {
+		return fetchCacheEntry{}, false
+	}
+	return entry, true
+}
 
-	default:
-		return NewTextResponse(content), nil
+// store adds an entry to a cache, making room by dropping the oldest entry.
+func (t *fetchTool) store(cache map[string]fetchCacheEntry, key string, entry fetchCacheEntry) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if _, ok := cache[key]; !ok && len(cache) >= fetchCacheSize {
+		var oldest string
+		for k, e := range cache {
+			if oldest == "" || e.fetchedAt.Before(cache[oldest].fetchedAt) {
+				oldest = k
+			}
+		}
+		delete(cache, oldest)
 	}
+	entry.fetchedAt = time.Now()
+	cache[key] = entry
 }
 
 func extractTextFromHTML(html string) (string, error) {
@@ -214,14 +320,3 @@ func extractTextFromHTML(html string) (string, error) {
 
 	return text, nil
 }
-
-func convertHTMLToMarkdown(html string) (string, error) {
-	converter := md.NewConverter("", true, nil)
-
-	markdown, err := converter.ConvertString(html)
-	if err != nil {
-		return "", err
-	}
-
-	return markdown, nil
-}
@@ -0,0 +1,115 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testDocsPage = `<html>
+<head><title>Tutorial: Generics</title><script>track()</script></head>
+<body>
+<header class="site-header"><a href="/">Home</a> <a href="/blog">Blog</a></header>
+<nav><a href="/doc">Documentation</a></nav>
+<div class="cookie-banner">We use cookies to improve the site.</div>
+<div id="page">
+  <div class="sidebar"><a href="/doc/a">Another tutorial</a></div>
+  <div class="docs">
+    <h2>Prerequisites</h2>
+    <p>An installation of Go 1.18 or later, see <a href="/doc/install">Installing Go</a>.</p>
+    <p>A tool to edit your code, any text editor you have will work fine.</p>
+    <pre>go mod init example/generics</pre>
+  </div>
+</div>
+<footer>Copyright Google</footer>
+</body>
+</html>`
+
+func TestReadableMarkdown(t *testing.T) {
+	markdown, err := readableMarkdown(testDocsPage, "https://go.dev/doc/tutorial/generics")
+	require.NoError(t, err)
+
+	assert.Contains(t, markdown, "# Tutorial: Generics")
+	assert.Contains(t, markdown, "## Prerequisites")
+	assert.Contains(t, markdown, "[Installing Go](https://go.dev/doc/install)")
+	assert.Contains(t, markdown, "go mod init example/generics")
+	for _, boilerplate := range []string{"track()", "Blog", "Documentation", "cookies", "Another tutorial", "Copyright"} {
+		assert.NotContains(t, markdown, boilerplate)
+	}
+}
+
+func TestReadableMarkdown_Article(t *testing.T) {
+	page := `<html><body>
+<div class="menu"><a href="/">Home</a></div>
+<article><header><h1>Release notes</h1></header><p>Short.</p></article>
+<div class="related"><p>Other posts that are long enough to be scored as content.</p></div>
+</body></html>`
+	markdown, err := readableMarkdown(page, "https://example.com/notes")
+	require.NoError(t, err)
+	assert.Equal(t, "# Release notes\n\nShort.", markdown)
+}
+
+func TestParseRobots(t *testing.T) {
+	rules := parseRobots(`
+# Crawlers
+User-agent: *
+Disallow: /private
+Allow: /private/docs
+Disallow: /*.pdf$
+
+User-agent: Googlebot
+Disallow: /
+`)
+	assert.True(t, rules.allowed("/"))
+	assert.True(t, rules.allowed("/doc"))
+	assert.False(t, rules.allowed("/private/keys"))
+	assert.True(t, rules.allowed("/private/docs/index.html"))
+	assert.False(t, rules.allowed("/paper.pdf"))
+	assert.True(t, rules.allowed("/paper.pdf?download=1"))
+
+	own := parseRobots(`
+User-agent: *
+Disallow: /
+
+User-agent: opencode
+Disallow:
+`)
+	assert.True(t, own.allowed("/doc"))
+}
+
+func TestFetchTool_RobotsAndCache(t *testing.T) {
+	robotsRequests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			robotsRequests++
+			fmt.Fprint(w, "User-agent: *\nDisallow: /admin\n")
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	tool := NewFetchTool(nil).(*fetchTool)
+	for _, path := range []string{"/doc", "/admin/users", "/doc/other"} {
+		u, err := url.Parse(server.URL + path)
+		require.NoError(t, err)
+		assert.Equal(t, path != "/admin/users", tool.robotsAllowed(context.Background(), server.Client(), u), path)
+	}
+	assert.Equal(t, 1, robotsRequests)
+
+	for i := range fetchCacheSize + 1 {
+		tool.store(tool.pages, fmt.Sprint(i), fetchCacheEntry{content: fmt.Sprint(i)})
+	}
+	assert.Len(t, tool.pages, fetchCacheSize)
+	_, ok := tool.cached(tool.pages, "0")
+	assert.False(t, ok)
+	entry, ok := tool.cached(tool.pages, fmt.Sprint(fetchCacheSize))
+	require.True(t, ok)
+	assert.Equal(t, fmt.Sprint(fetchCacheSize), entry.content)
+}
@@ -0,0 +1,190 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/opencode-ai/opencode/internal/config"
+	"github.com/opencode-ai/opencode/internal/diff"
+)
+
+const (
+	// formatTimeout is how long a formatter may run on a file
+	formatTimeout = 30 * time.Second
+	// maxFormatDiff is the longest diff of the formatting returned to the
+	// model, longer ones only say how many lines changed
+	maxFormatDiff = 4000
+)
+
+// formatter is a command formatting a file in place, the path of the file is
+// added as its last argument.
+type formatter struct {
+	name string
+	args []string
+}
+
+var (
+	prettierExtensions = []string{
+		".js", ".jsx", ".mjs", ".cjs", ".ts", ".tsx", ".mts", ".cts",
+		".css", ".scss", ".less", ".html", ".vue", ".svelte",
+		".json", ".md", ".yaml", ".yml", ".graphql",
+	}
+	prettierConfigs = []string{
+		".prettierrc", ".prettierrc.json", ".prettierrc.yaml", ".prettierrc.yml",
+		".prettierrc.json5", ".prettierrc.js", ".prettierrc.cjs", ".prettierrc.mjs",
+		".prettierrc.toml", "prettier.config.js", "prettier.config.cjs", "prettier.config.mjs",
+	}
+	cargoEditionPattern = regexp.MustCompile(`(?m)^\s*edition\s*=\s*"(\d+)"`)
+)
+
+// detectFormatter returns the formatter of the file, the configured one of
+// its extension or the standard formatter of its language. Go files are
+// always formatted, Rust files in crates, and Python and prettier's languages
+// only when the project configures ruff, black or prettier.
+func detectFormatter(path, root string, configured map[string][]string) (formatter, bool) {
+	ext := strings.ToLower(filepath.Ext(path))
+	if command, ok := configured[ext]; ok {
+		if len(command) == 0 {
+			return formatter{}, false
+		}
+		return formatter{name: command[0], args: command}, true
+	}
+
+	switch {
+	case ext == ".go":
+		if _, err := exec.LookPath("goimports"); err == nil {
+			return formatter{name: "goimports", args: []string{"goimports", "-w"}}, true
+		}
+		if _, err := exec.LookPath("gofmt"); err == nil {
+			return formatter{name: "gofmt", args: []string{"gofmt", "-w"}}, true
+		}
+	case ext == ".rs":
+		// rustfmt on its own formats as edition 2015, cargo fmt passes the
+		// edition of the crate
+		cargo := findUp(filepath.Dir(path), root, "Cargo.toml")
+		if _, err := exec.LookPath("rustfmt"); err == nil && cargo != "" {
+			edition := "2021"
+			if data, err := os.ReadFile(cargo); err == nil {
+				if match := cargoEditionPattern.FindSubmatch(data); match != nil {
+					edition = string(match[1])
+				}
+			}
+			return formatter{name: "rustfmt", args: []string{"rustfmt", "--edition", edition}}, true
+		}
+	case ext == ".py" || ext == ".pyi":
+		pyproject := findUp(filepath.Dir(path), root, "pyproject.toml")
+		if pyproject == "" {
+			break
+		}
+		data, _ := os.ReadFile(pyproject)
+		if _, err := exec.LookPath("ruff"); err == nil && bytes.Contains(data, []byte("[tool.ruff")) {
+			return formatter{name: "ruff", args: []string{"ruff", "format", "--quiet"}}, true
+		}
+		if _, err := exec.LookPath("black"); err == nil && bytes.Contains(data, []byte("[tool.black]")) {
+			return formatter{name: "black", args: []string{"black", "--quiet"}}, true
+		}
+	case slices.Contains(prettierExtensions, ext):
+		if dir := findPrettierConfig(filepath.Dir(path), root); dir != "" {
+			if bin := findUp(dir, root, filepath.Join("node_modules", ".bin", "prettier")); bin != "" {
+				return formatter{name: "prettier", args: []string{bin, "--write"}}, true
+			}
+			if bin, err := exec.LookPath("prettier"); err == nil {
+				return formatter{name: "prettier", args: []string{bin, "--write"}}, true
+			}
+		}
+	}
+	return formatter{}, false
+}
+
+// findPrettierConfig returns the directory of the prettier configuration of
+// the directory, which can also be the prettier key of a package.json.
+func findPrettierConfig(dir, root string) string {
+	for {
+		for _, name := range prettierConfigs {
+			if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+				return dir
+			}
+		}
+		if data, err := os.ReadFile(filepath.Join(dir, "package.json")); err == nil && bytes.Contains(data, []byte(`"prettier"`)) {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if dir == root || parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// findUp returns the path of the first name in the directory or its parents,
+// up to root when the directory is in it.
+func findUp(dir, root, name string) string {
+	for {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+		parent := filepath.Dir(dir)
+		if dir == root || parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// formatWrittenFile runs the formatter of a file the tools wrote with the
+// content, when formatting is enabled. It returns the content of the file
+// afterwards and a note for the model about what the formatter changed or why
+// it failed, which is empty when nothing happened.
+func formatWrittenFile(ctx context.Context, path, written string) (string, string) {
+	cfg := config.Get()
+	if cfg == nil || !cfg.Format.Enabled {
+		return written, ""
+	}
+	root := config.WorkingDirectory()
+	f, ok := detectFormatter(path, root, cfg.Format.Formatters)
+	if !ok {
+		return written, ""
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, formatTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, f.args[0], append(f.args[1:], path)...)
+	cmd.Dir = root
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		reason := strings.TrimSpace(ansiEscape.ReplaceAllString(string(output), ""))
+		if reason == "" {
+			reason = err.Error()
+		}
+		return currentContent(path, written), fmt.Sprintf("\n\n<format>\nFormatting %s with %s failed, the file is as you wrote it:\n%s\n</format>", path, f.name, truncateOutput(reason))
+	}
+
+	formatted := currentContent(path, written)
+	if formatted == written {
+		return formatted, ""
+	}
+	formatDiff, additions, removals := diff.GenerateDiff(written, formatted, path)
+	if len(formatDiff) > maxFormatDiff {
+		return formatted, fmt.Sprintf("\n\n<format>\n%s was formatted with %s, which added %d lines and removed %d. View the file again before editing it further.\n</format>", path, f.name, additions, removals)
+	}
+	return formatted, fmt.Sprintf("\n\n<format>\n%s was formatted with %s, it now differs from what you wrote. Use the formatted content for further edits:\n%s\n</format>", path, f.name, strings.TrimSpace(formatDiff))
+}
+
+// currentContent returns the content of the file, or the written one when it
+// can't be read.
+func currentContent(path, written string) string {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return written
+	}
+	return string(content)
+}
@@ -0,0 +1,37 @@
+package tools
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectFormatter(t *testing.T) {
+	root := t.TempDir()
+	web := filepath.Join(root, "web")
+	prettier := filepath.Join(web, "node_modules", ".bin", "prettier")
+	require.NoError(t, os.MkdirAll(filepath.Dir(prettier), 0o755))
+	require.NoError(t, os.WriteFile(prettier, []byte("#!/bin/sh\n"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(web, "package.json"), []byte(`{"prettier": {"semi": false}}`), 0o644))
+	require.NoError(t, os.MkdirAll(filepath.Join(web, "src"), 0o755))
+
+	f, ok := detectFormatter(filepath.Join(web, "src", "app.tsx"), root, nil)
+	require.True(t, ok)
+	assert.Equal(t, formatter{name: "prettier", args: []string{prettier, "--write"}}, f)
+
+	// Projects without a prettier or black configuration aren't formatted
+	_, ok = detectFormatter(filepath.Join(root, "README.md"), root, nil)
+	assert.False(t, ok)
+	_, ok = detectFormatter(filepath.Join(root, "main.py"), root, nil)
+	assert.False(t, ok)
+
+	configured := map[string][]string{".py": {"uv", "run", "ruff", "format"}, ".tsx": {}}
+	f, ok = detectFormatter(filepath.Join(root, "main.py"), root, configured)
+	require.True(t, ok)
+	assert.Equal(t, formatter{name: "uv", args: []string{"uv", "run", "ruff", "format"}}, f)
+	_, ok = detectFormatter(filepath.Join(web, "src", "app.tsx"), root, configured)
+	assert.False(t, ok)
+}
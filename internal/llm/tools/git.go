@@ -0,0 +1,317 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/opencode-ai/opencode/internal/config"
+	"github.com/opencode-ai/opencode/internal/permission"
+)
+
+type GitParams struct {
+	Action    string   `json:"action"`
+	Paths     []string `json:"paths,omitempty"`
+	Ref       string   `json:"ref,omitempty"`
+	Staged    bool     `json:"staged,omitempty"`
+	Count     int      `json:"count,omitempty"`
+	StartLine int      `json:"start_line,omitempty"`
+	EndLine   int      `json:"end_line,omitempty"`
+	Message   string   `json:"message,omitempty"`
+}
+
+type GitPermissionsParams struct {
+	Action  string   `json:"action"`
+	Paths   []string `json:"paths,omitempty"`
+	Message string   `json:"message,omitempty"`
+}
+
+type GitResponseMetadata struct {
+	Action string `json:"action"`
+}
+
+type gitTool struct {
+	permissions permission.Service
+}
+
+const (
+	GitToolName        = "git"
+	gitToolDescription = `Runs git operations on the repository of the working directory.
+
+WHEN TO USE THIS TOOL:
+- Use instead of running git through the bash tool
+- Use status and diff to review your changes before finishing a task
+- Use log and blame to understand the history of code
+- Use stage and commit only when the user asks you to commit
+
+ACTIONS:
+- status: the branch and the changed files
+- diff: the unstaged changes, the staged changes with staged=true, or the changes since ref
+- log: the last commits (default: 10, max: 100), of ref and of paths when given
+- blame: who last changed the lines of a file, optionally only start_line to end_line
+- stage: stages the paths, use "." for all changes
+- commit: commits the staged changes with the message
+
+HOW TO USE:
+- Paths are relative to the working directory
+- Stage the changes and check them with diff and staged=true before committing
+- Write commit messages with a short summary line, and a body after a blank line when needed
+
+LIMITATIONS:
+- Branches, remotes, rebases and pushes are not supported
+- stage and commit need the permission of the user
+- Output over 30000 characters is truncated`
+)
+
+// gitReadOnlyActions run without asking for permission.
+var gitReadOnlyActions = map[string]bool{
+	"status": true,
+	"diff":   true,
+	"log":    true,
+	"blame":  true,
+}
+
+func NewGitTool(permission permission.Service) BaseTool {
+	return &gitTool{
+		permissions: permission,
+	}
+}
+
+func (g *gitTool) Info() ToolInfo {
+	return ToolInfo{
+		Name:        GitToolName,
+		Description: gitToolDescription,
+		Parameters: map[string]any{
+			"action": map[string]any{
+				"type":        "string",
+				"description": "The git operation to run",
+				"enum":        []string{"status", "diff", "log", "blame", "stage", "commit"},
+			},
+			"paths": map[string]any{
+				"type":        "array",
+				"description": "Paths the operation is limited to, required for blame and stage",
+				"items": map[string]any{
+					"type": "string",
+				},
+			},
+			"ref": map[string]any{
+				"type":        "string",
+				"description": "Commit, branch or tag for diff and log",
+			},
+			"staged": map[string]any{
+				"type":        "boolean",
+				"description": "Show the staged changes with diff",
+			},
+			"count": map[string]any{
+				"type":        "number",
+				"description": "Number of commits for log (default: 10, max: 100)",
+			},
+			"start_line": map[string]any{
+				"type":        "number",
+				"description": "First line for blame",
+			},
+			"end_line": map[string]any{
+				"type":        "number",
+				"description": "Last line for blame",
+			},
+			"message": map[string]any{
+				"type":        "string",
+				"description": "The commit message, required for commit",
+			},
+		},
+		Required: []string{"action"},
+	}
+}
+
+func (g *gitTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error) {
+	var params GitParams
+	if err := json.Unmarshal([]byte(call.Input), &params); err != nil {
+		return NewTextErrorResponse("Failed to parse git parameters: " + err.Error()), nil
+	}
+
+	workingDir := config.WorkingDirectory()
+	args, err := gitArgs(workingDir, params)
+	if err != nil {
+		return NewTextErrorResponse(err.Error()), nil
+	}
+
+	if !gitReadOnlyActions[params.Action] {
+		sessionID, messageID := GetContextValues(ctx)
+		if sessionID == "" || messageID == "" {
+			return ToolResponse{}, fmt.Errorf("session ID and message ID are required for running git %s", params.Action)
+		}
+		p := g.permissions.Request(
+			permission.CreatePermissionRequest{
+				SessionID:   sessionID,
+				Path:        workingDir,
+				ToolName:    GitToolName,
+				Action:      params.Action,
+				Description: gitPermissionDescription(ctx, workingDir, params),
+				Params: GitPermissionsParams{
+					Action:  params.Action,
+					Paths:   params.Paths,
+					Message: params.Message,
+				},
+			},
+		)
+		if !p {
+			return ToolResponse{}, permission.ErrorPermissionDenied
+		}
+	}
+
+	output, err := runGit(ctx, workingDir, args...)
+	metadata := GitResponseMetadata{Action: params.Action}
+	if err != nil {
+		return WithResponseMetadata(NewTextErrorResponse(err.Error()), metadata), nil
+	}
+	if output == "" {
+		output = gitEmptyOutput(params)
+	}
+	return WithResponseMetadata(NewTextResponse(truncateOutput(output)), metadata), nil
+}
+
+// gitArgs validates the parameters and returns the arguments of the git
+// command. Paths and refs are checked so they can't be read as options.
+func gitArgs(workingDir string, params GitParams) ([]string, error) {
+	for _, path := range params.Paths {
+		if err := gitValidatePath(workingDir, path); err != nil {
+			return nil, err
+		}
+	}
+	if strings.HasPrefix(params.Ref, "-") {
+		return nil, fmt.Errorf("invalid ref: %s", params.Ref)
+	}
+
+	var args []string
+	switch params.Action {
+	case "status":
+		args = []string{"status", "--short", "--branch"}
+	case "diff":
+		args = []string{"diff"}
+		if params.Staged {
+			args = append(args, "--cached")
+		}
+		if params.Ref != "" {
+			args = append(args, params.Ref)
+		}
+	case "log":
+		count := params.Count
+		if count <= 0 {
+			count = 10
+		} else if count > 100 {
+			count = 100
+		}
+		args = []string{"log", "--date=short", "--format=%h %ad %an%d%n    %s", fmt.Sprintf("-n%d", count)}
+		if params.Ref != "" {
+			args = append(args, params.Ref)
+		}
+	case "blame":
+		if len(params.Paths) != 1 {
+			return nil, errors.New("blame needs exactly one path")
+		}
+		args = []string{"blame", "--date=short"}
+		if params.StartLine > 0 || params.EndLine > 0 {
+			start, end := max(params.StartLine, 1), ""
+			if params.EndLine > 0 {
+				if params.EndLine < start {
+					return nil, errors.New("end_line must not be before start_line")
+				}
+				end = fmt.Sprint(params.EndLine)
+			}
+			args = append(args, fmt.Sprintf("-L%d,%s", start, end))
+		}
+	case "stage":
+		if len(params.Paths) == 0 {
+			return nil, errors.New("stage needs the paths to stage, use \".\" for all changes")
+		}
+		args = []string{"add"}
+	case "commit":
+		if strings.TrimSpace(params.Message) == "" {
+			return nil, errors.New("commit needs a message")
+		}
+		if len(params.Paths) > 0 {
+			return nil, errors.New("commit only commits the staged changes, stage the paths first")
+		}
+		args = []string{"commit", "--message", params.Message}
+	case "":
+		return nil, errors.New("action parameter is required")
+	default:
+		return nil, fmt.Errorf("unknown action %q, must be one of: status, diff, log, blame, stage, commit", params.Action)
+	}
+
+	if len(params.Paths) > 0 {
+		args = append(append(args, "--"), params.Paths...)
+	}
+	return args, nil
+}
+
+// gitValidatePath accepts paths inside the working directory.
+func gitValidatePath(workingDir, path string) error {
+	if path == "" || strings.HasPrefix(path, "-") {
+		return fmt.Errorf("invalid path: %q", path)
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(workingDir, path)
+	}
+	rel, err := filepath.Rel(workingDir, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("path %s is outside of the working directory", path)
+	}
+	return nil
+}
+
+// runGit runs git in the working directory, never waiting for a terminal,
+// an editor or a pager. The output of a failed command is in the error.
+func runGit(ctx context.Context, workingDir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", append([]string{"--no-pager", "-c", "color.ui=never"}, args...)...)
+	cmd.Dir = workingDir
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0", "GIT_EDITOR=true", "GIT_PAGER=cat")
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			return "", fmt.Errorf("failed to run git: %w", err)
+		}
+		output := strings.TrimSpace(stderr.String() + "\n" + stdout.String())
+		return "", fmt.Errorf("git %s failed with exit code %d:\n%s", args[0], exitErr.ExitCode(), truncateOutput(output))
+	}
+	return strings.TrimRight(stdout.String(), "\n"), nil
+}
+
+func gitEmptyOutput(params GitParams) string {
+	switch params.Action {
+	case "diff":
+		if params.Staged {
+			return "No staged changes"
+		}
+		return "No changes"
+	case "log":
+		return "No commits"
+	case "stage":
+		return "Staged " + strings.Join(params.Paths, ", ")
+	}
+	return "no output"
+}
+
+// gitPermissionDescription describes what stage and commit change, with the
+// files that are committed.
+func gitPermissionDescription(ctx context.Context, workingDir string, params GitParams) string {
+	switch params.Action {
+	case "stage":
+		return fmt.Sprintf("Stage the changes of:\n\n- %s", strings.Join(params.Paths, "\n- "))
+	case "commit":
+		description := fmt.Sprintf("Commit the staged changes with the message:\n\n```\n%s\n```", params.Message)
+		if stat, err := runGit(ctx, workingDir, "diff", "--cached", "--stat"); err == nil && stat != "" {
+			description += fmt.Sprintf("\n\n```\n%s\n```", stat)
+		}
+		return description
+	}
+	return fmt.Sprintf("Run git %s", params.Action)
+}
@@ -0,0 +1,104 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGitArgs(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := []struct {
+		name   string
+		params GitParams
+		want   []string
+		err    string
+	}{
+		{
+			name:   "staged diff of paths",
+			params: GitParams{Action: "diff", Staged: true, Paths: []string{"main.go"}},
+			want:   []string{"diff", "--cached", "--", "main.go"},
+		},
+		{
+			name:   "blame line range",
+			params: GitParams{Action: "blame", Paths: []string{"main.go"}, StartLine: 10, EndLine: 20},
+			want:   []string{"blame", "--date=short", "-L10,20", "--", "main.go"},
+		},
+		{
+			name:   "log count is limited",
+			params: GitParams{Action: "log", Count: 1000, Ref: "main"},
+			want:   []string{"log", "--date=short", "--format=%h %ad %an%d%n    %s", "-n100", "main"},
+		},
+		{
+			name:   "commit message is not an option",
+			params: GitParams{Action: "commit", Message: "--amend"},
+			want:   []string{"commit", "--message", "--amend"},
+		},
+		{name: "option as path", params: GitParams{Action: "stage", Paths: []string{"--all"}}, err: "invalid path"},
+		{name: "option as ref", params: GitParams{Action: "diff", Ref: "--output=/tmp/x"}, err: "invalid ref"},
+		{name: "path outside", params: GitParams{Action: "stage", Paths: []string{"../other"}}, err: "outside of the working directory"},
+		{name: "stage without paths", params: GitParams{Action: "stage"}, err: "stage needs the paths"},
+		{name: "commit without message", params: GitParams{Action: "commit"}, err: "commit needs a message"},
+		{name: "unknown action", params: GitParams{Action: "push"}, err: "unknown action"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args, err := gitArgs(dir, tt.params)
+			if tt.err != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, args)
+		})
+	}
+}
+
+func TestRunGit(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+	git := func(params GitParams) (string, error) {
+		args, err := gitArgs(dir, params)
+		require.NoError(t, err)
+		return runGit(ctx, dir, args...)
+	}
+
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"config", "user.name", "Test"},
+		{"config", "user.email", "test@example.com"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		require.NoError(t, cmd.Run())
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n"), 0o644))
+
+	status, err := git(GitParams{Action: "status"})
+	require.NoError(t, err)
+	assert.Contains(t, status, "?? main.go")
+
+	_, err = git(GitParams{Action: "stage", Paths: []string{"main.go"}})
+	require.NoError(t, err)
+	diff, err := git(GitParams{Action: "diff", Staged: true})
+	require.NoError(t, err)
+	assert.Contains(t, diff, "+package main")
+
+	_, err = git(GitParams{Action: "commit", Message: "Add main"})
+	require.NoError(t, err)
+	log, err := git(GitParams{Action: "log"})
+	require.NoError(t, err)
+	assert.Contains(t, log, "Test")
+	assert.Contains(t, log, "Add main")
+
+	_, err = git(GitParams{Action: "commit", Message: "Nothing"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "git commit failed")
+}
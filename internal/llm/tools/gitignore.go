@@ -0,0 +1,164 @@
+package tools
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+// ignoreRule is a pattern of a .gitignore file.
+type ignoreRule struct {
+	pattern string
+	// negate un-ignores the paths matching the pattern, like !keep.txt
+	negate bool
+	// dirOnly matches directories only, like build/
+	dirOnly bool
+	// anchored patterns have a slash, they match the path relative to the
+	// directory of the file instead of the name at any depth
+	anchored bool
+}
+
+// gitignore are the rules of a .gitignore file in the directory dir.
+type gitignore struct {
+	dir   string
+	rules []ignoreRule
+}
+
+// parseGitignore reads the rules of a .gitignore file of the directory.
+func parseGitignore(dir string, data []byte) *gitignore {
+	g := &gitignore{dir: dir}
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimRight(line, "\r")
+		if !strings.HasSuffix(line, `\ `) {
+			line = strings.TrimRight(line, " \t")
+		}
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		var rule ignoreRule
+		if strings.HasPrefix(line, "!") {
+			rule.negate = true
+			line = line[1:]
+		} else if strings.HasPrefix(line, `\!`) || strings.HasPrefix(line, `\#`) {
+			line = line[1:]
+		}
+		if strings.HasSuffix(line, "/") {
+			rule.dirOnly = true
+			line = strings.TrimSuffix(line, "/")
+		}
+		rule.anchored = strings.Contains(line, "/")
+		rule.pattern = strings.TrimPrefix(line, "/")
+		if rule.pattern == "" {
+			continue
+		}
+		g.rules = append(g.rules, rule)
+	}
+	return g
+}
+
+// match reports whether the last rule matching the path ignores it, and
+// whether any rule matched.
+func (g *gitignore) match(path string, isDir bool) (ignored, matched bool) {
+	rel, err := filepath.Rel(g.dir, path)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return false, false
+	}
+	rel = filepath.ToSlash(rel)
+	name := filepath.Base(path)
+	for _, rule := range g.rules {
+		if rule.dirOnly && !isDir {
+			continue
+		}
+		target := name
+		if rule.anchored {
+			target = rel
+		}
+		if ok, _ := doublestar.Match(rule.pattern, target); ok {
+			ignored, matched = !rule.negate, true
+		}
+	}
+	return ignored, matched
+}
+
+// ignoreTree holds the .gitignore files of a directory tree, loaded as the
+// directories are walked, and those of its parents up to the root of the
+// repository, with .git/info/exclude.
+type ignoreTree struct {
+	root    string
+	parents []*gitignore
+	byDir   map[string]*gitignore
+}
+
+func newIgnoreTree(root string) *ignoreTree {
+	t := &ignoreTree{root: root, byDir: make(map[string]*gitignore)}
+	if exclude := readExclude(root); exclude != nil {
+		t.parents = []*gitignore{exclude}
+		return t
+	}
+	// The .gitignore files of the parents apply when the tree is in a
+	// repository
+	var parents []*gitignore
+	for dir := filepath.Dir(root); ; dir = filepath.Dir(dir) {
+		if data, err := os.ReadFile(filepath.Join(dir, ".gitignore")); err == nil {
+			parents = append(parents, parseGitignore(dir, data))
+		}
+		if exclude := readExclude(dir); exclude != nil {
+			parents = append(parents, exclude)
+			break
+		}
+		if filepath.Dir(dir) == dir {
+			return t
+		}
+	}
+	// The rules of the deeper files come last, they win
+	for i := len(parents) - 1; i >= 0; i-- {
+		t.parents = append(t.parents, parents[i])
+	}
+	return t
+}
+
+// readExclude returns the rules of .git/info/exclude when the directory is
+// the root of a repository, or nil when it isn't.
+func readExclude(dir string) *gitignore {
+	if _, err := os.Stat(filepath.Join(dir, ".git")); err != nil {
+		return nil
+	}
+	data, _ := os.ReadFile(filepath.Join(dir, ".git", "info", "exclude"))
+	return parseGitignore(dir, data)
+}
+
+// enter loads the .gitignore file of a directory of the tree.
+func (t *ignoreTree) enter(dir string) {
+	if data, err := os.ReadFile(filepath.Join(dir, ".gitignore")); err == nil {
+		t.byDir[dir] = parseGitignore(dir, data)
+	}
+}
+
+// ignored reports whether the path in the tree is ignored by the .gitignore
+// files of its directories.
+func (t *ignoreTree) ignored(path string, isDir bool) bool {
+	var chain []*gitignore
+	for dir := filepath.Dir(path); ; dir = filepath.Dir(dir) {
+		if g, ok := t.byDir[dir]; ok {
+			chain = append(chain, g)
+		}
+		if dir == t.root || filepath.Dir(dir) == dir {
+			break
+		}
+	}
+	// The deepest file that has a matching rule decides
+	for _, g := range chain {
+		if ignored, matched := g.match(path, isDir); matched {
+			return ignored
+		}
+	}
+	ignored := false
+	for _, g := range t.parents {
+		if i, matched := g.match(path, isDir); matched {
+			ignored = i
+		}
+	}
+	return ignored
+}
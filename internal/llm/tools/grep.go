@@ -2,9 +2,13 @@ package tools
 
 import (
 	"bufio"
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -13,7 +17,9 @@ import (
 	"strconv"
 	"strings"
 	"time"
+	"unicode/utf8"
 
+	"github.com/bmatcuk/doublestar/v4"
 	"github.com/opencode-ai/opencode/internal/config"
 )
 
@@ -39,7 +45,19 @@ type GrepResponseMetadata struct {
 type grepTool struct{}
 
 const (
-	GrepToolName    = "grep"
+	GrepToolName = "grep"
+
+	// maxGrepMatches is how many matching lines are returned
+	maxGrepMatches = 100
+	// grepCollectFactor is how many times maxGrepMatches are collected before
+	// the search stops, to sort the newest files first
+	grepCollectFactor = 10
+	// maxGrepLineLength is how much of a matching line is returned
+	maxGrepLineLength = 250
+	// binaryDetectionSize is how much of the start of a file is read to
+	// detect binary files
+	binaryDetectionSize = 8000
+
 	grepDescription = `Fast content search tool that finds files containing specific text or patterns, returning matching file paths sorted by modification time (newest first).
 
 WHEN TO USE THIS TOOL:
@@ -48,10 +66,10 @@ WHEN TO USE THIS TOOL:
 - Useful for finding all files that use a particular API or pattern
 
 HOW TO USE:
-- Provide a regex pattern to search for within file contents
+- Provide a regex pattern to search for within file contents, all matching lines of the files are returned
 - Set literal_text=true if you want to search for the exact text with special characters (recommended for non-regex users)
 - Optionally specify a starting directory (defaults to current working directory)
-- Optionally provide an include pattern to filter which files to search
+- Optionally provide an include pattern to filter which files to search, it matches the file name, or the path from the search directory when it has a slash
 - Results are sorted with most recently modified files first
 
 REGEX PATTERN SYNTAX (when literal_text=false):
@@ -66,10 +84,8 @@ COMMON INCLUDE PATTERN EXAMPLES:
 - '*.go' - Only search Go files
 
 LIMITATIONS:
-- Results are limited to 100 files (newest first)
-- Performance depends on the number of files being searched
-- Very large binary files may be skipped
-- Hidden files (starting with '.') are skipped
+- Results are limited to 100 matching lines (newest files first), long lines are shortened to 250 characters
+- Hidden files (starting with '.'), files ignored by .gitignore and binary files are skipped
 
 TIPS:
 - For faster, more targeted searches, first use Glob to find relevant files, then use Grep
@@ -141,7 +157,11 @@ func (g *grepTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error)
 		searchPath = config.WorkingDirectory()
 	}
 
-	matches, truncated, err := searchFiles(searchPattern, searchPath, params.Include, 100)
+	matches, truncated, err := searchFiles(ctx, searchPattern, searchPath, params.Include, maxGrepMatches)
+	var patternErr *grepPatternError
+	if errors.As(err, &patternErr) {
+		return NewTextErrorResponse(patternErr.message), nil
+	}
 	if err != nil {
 		return ToolResponse{}, fmt.Errorf("error searching files: %w", err)
 	}
@@ -182,17 +202,24 @@ func (g *grepTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error)
 	), nil
 }
 
-func searchFiles(pattern, rootPath, include string, limit int) ([]grepMatch, bool, error) {
-	matches, err := searchWithRipgrep(pattern, rootPath, include)
+func searchFiles(ctx context.Context, pattern, rootPath, include string, limit int) ([]grepMatch, bool, error) {
+	matches, err := searchWithRipgrep(ctx, pattern, rootPath, include, limit)
+	if errors.Is(err, errNoRipgrep) {
+		matches, err = searchFilesWithRegex(ctx, pattern, rootPath, include, limit)
+	}
 	if err != nil {
-		matches, err = searchFilesWithRegex(pattern, rootPath, include)
-		if err != nil {
-			return nil, false, err
-		}
+		return nil, false, err
 	}
 
-	sort.Slice(matches, func(i, j int) bool {
-		return matches[i].modTime.After(matches[j].modTime)
+	// The newest files first, and the lines of a file in order
+	sort.SliceStable(matches, func(i, j int) bool {
+		if !matches[i].modTime.Equal(matches[j].modTime) {
+			return matches[i].modTime.After(matches[j].modTime)
+		}
+		if matches[i].path != matches[j].path {
+			return matches[i].path < matches[j].path
+		}
+		return matches[i].lineNum < matches[j].lineNum
 	})
 
 	truncated := len(matches) > limit
@@ -203,117 +230,170 @@ func searchFiles(pattern, rootPath, include string, limit int) ([]grepMatch, boo
 	return matches, truncated, nil
 }
 
-func searchWithRipgrep(pattern, path, include string) ([]grepMatch, error) {
-	_, err := exec.LookPath("rg")
+// grepPatternError is an invalid pattern or include, its message is returned
+// to the model.
+type grepPatternError struct {
+	message string
+}
+
+func (e *grepPatternError) Error() string {
+	return e.message
+}
+
+var errNoRipgrep = errors.New("ripgrep not found")
+
+// searchWithRipgrep searches with rg, which skips hidden, ignored and binary
+// files like the fallback does. It stops once it found more matches than
+// collected, which tells that the results are truncated.
+func searchWithRipgrep(ctx context.Context, pattern, path, include string, limit int) ([]grepMatch, error) {
+	rgBin, err := exec.LookPath("rg")
 	if err != nil {
-		return nil, fmt.Errorf("ripgrep not found: %w", err)
+		return nil, errNoRipgrep
 	}
 
-	// Use -n to show line numbers and include the matched line
-	args := []string{"-n", pattern}
+	args := []string{
+		"--line-number",
+		"--with-filename",
+		"--no-heading",
+		"--null",
+		"--color", "never",
+		"--no-require-git",
+		"--max-columns", strconv.Itoa(maxGrepLineLength),
+		"--max-columns-preview",
+	}
 	if include != "" {
 		args = append(args, "--glob", include)
 	}
-	args = append(args, path)
-
-	cmd := exec.Command("rg", args...)
-	output, err := cmd.Output()
+	args = append(args, "--regexp", pattern, path)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, rgBin, args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
 	if err != nil {
-		if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 1 {
-			return []grepMatch{}, nil
-		}
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
 		return nil, err
 	}
 
-	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
-	matches := make([]grepMatch, 0, len(lines))
-
-	for _, line := range lines {
-		if line == "" {
-			continue
+	// More matches than returned are collected, so that the newest files
+	// are likely among them when the results are truncated
+	collect := limit * grepCollectFactor
+	var matches []grepMatch
+	modTimes := make(map[string]time.Time)
+	reader := bufio.NewReader(stdout)
+	for len(matches) < collect {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			break
 		}
-
-		// Parse ripgrep output format: file:line:content
-		parts := strings.SplitN(line, ":", 3)
-		if len(parts) < 3 {
+		// The output is path NUL line number : text
+		filePath, rest, ok := strings.Cut(strings.TrimSuffix(line, "\n"), "\x00")
+		if !ok {
 			continue
 		}
-
-		filePath := parts[0]
-		lineNum, err := strconv.Atoi(parts[1])
-		if err != nil {
+		num, text, ok := strings.Cut(rest, ":")
+		lineNum, err := strconv.Atoi(num)
+		if !ok || err != nil {
 			continue
 		}
-		lineText := parts[2]
-
-		fileInfo, err := os.Stat(filePath)
-		if err != nil {
-			continue // Skip files we can't access
+		modTime, ok := modTimes[filePath]
+		if !ok {
+			if info, err := os.Stat(filePath); err == nil {
+				modTime = info.ModTime()
+			}
+			modTimes[filePath] = modTime
 		}
-
 		matches = append(matches, grepMatch{
 			path:     filePath,
-			modTime:  fileInfo.ModTime(),
+			modTime:  modTime,
 			lineNum:  lineNum,
-			lineText: lineText,
+			lineText: strings.TrimRight(text, "\r"),
 		})
 	}
+	if len(matches) >= collect {
+		// The results are truncated anyway, rg doesn't have to finish
+		cancel()
+		cmd.Wait()
+		return matches, nil
+	}
 
-	return matches, nil
+	err = cmd.Wait()
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		switch exitErr.ExitCode() {
+		case 1:
+			// No matches
+			return nil, nil
+		case 2:
+			// Errors like unreadable files still have matches, an invalid
+			// pattern has none
+			if len(matches) > 0 {
+				return matches, nil
+			}
+			return nil, &grepPatternError{message: strings.TrimSpace(stderr.String())}
+		}
+	}
+	return matches, err
 }
 
-func searchFilesWithRegex(pattern, rootPath, include string) ([]grepMatch, error) {
-	matches := []grepMatch{}
-
+// searchFilesWithRegex searches the files with Go's regexp when rg isn't
+// installed. Like rg it skips hidden files, the files ignored by .gitignore
+// and binary files.
+func searchFilesWithRegex(ctx context.Context, pattern, rootPath, include string, limit int) ([]grepMatch, error) {
 	regex, err := regexp.Compile(pattern)
 	if err != nil {
-		return nil, fmt.Errorf("invalid regex pattern: %w", err)
+		return nil, &grepPatternError{message: fmt.Sprintf("invalid regex pattern: %s", err)}
 	}
-
-	var includePattern *regexp.Regexp
-	if include != "" {
-		regexPattern := globToRegex(include)
-		includePattern, err = regexp.Compile(regexPattern)
-		if err != nil {
-			return nil, fmt.Errorf("invalid include pattern: %w", err)
-		}
+	if include != "" && !doublestar.ValidatePattern(include) {
+		return nil, &grepPatternError{message: fmt.Sprintf("invalid include pattern %q", include)}
 	}
 
-	err = filepath.Walk(rootPath, func(path string, info os.FileInfo, err error) error {
+	collect := limit * grepCollectFactor
+	var matches []grepMatch
+	ignores := newIgnoreTree(rootPath)
+	err = filepath.WalkDir(rootPath, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return nil // Skip errors
 		}
-
-		if info.IsDir() {
-			return nil // Skip directories
+		if ctx.Err() != nil {
+			return ctx.Err()
 		}
-
-		if skipHidden(path) {
+		if d.IsDir() {
+			if path != rootPath && (strings.HasPrefix(d.Name(), ".") || ignores.ignored(path, true)) {
+				return filepath.SkipDir
+			}
+			ignores.enter(path)
 			return nil
 		}
-
-		if includePattern != nil && !includePattern.MatchString(path) {
+		if path != rootPath && (strings.HasPrefix(d.Name(), ".") || ignores.ignored(path, false)) {
+			return nil
+		}
+		if include != "" && !matchesInclude(include, rootPath, path) {
 			return nil
 		}
 
-		match, lineNum, lineText, err := fileContainsPattern(path, regex)
-		if err != nil {
+		fileMatches, err := searchFile(path, regex, collect-len(matches))
+		if err != nil || len(fileMatches) == 0 {
 			return nil // Skip files we can't read
 		}
-
-		if match {
-			matches = append(matches, grepMatch{
-				path:     path,
-				modTime:  info.ModTime(),
-				lineNum:  lineNum,
-				lineText: lineText,
-			})
-
-			if len(matches) >= 200 {
-				return filepath.SkipAll
-			}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		for i := range fileMatches {
+			fileMatches[i].modTime = info.ModTime()
+		}
+		matches = append(matches, fileMatches...)
+		if len(matches) >= collect {
+			return filepath.SkipAll
 		}
-
 		return nil
 	})
 	if err != nil {
@@ -323,36 +403,71 @@ func searchFilesWithRegex(pattern, rootPath, include string) ([]grepMatch, error
 	return matches, nil
 }
 
-func fileContainsPattern(filePath string, pattern *regexp.Regexp) (bool, int, string, error) {
+// matchesInclude reports whether the file matches the include pattern, which
+// matches the name of the file, or its path from the root when it has a
+// slash.
+func matchesInclude(include, rootPath, path string) bool {
+	target := filepath.Base(path)
+	if strings.Contains(include, "/") {
+		rel, err := filepath.Rel(rootPath, path)
+		if err != nil {
+			return false
+		}
+		target = filepath.ToSlash(rel)
+	}
+	ok, _ := doublestar.Match(strings.TrimPrefix(include, "/"), target)
+	return ok
+}
+
+// searchFile returns the lines of the file matching the pattern, at most
+// limit. Binary files, which have a NUL byte at the start, have no matches.
+func searchFile(filePath string, pattern *regexp.Regexp, limit int) ([]grepMatch, error) {
 	file, err := os.Open(filePath)
 	if err != nil {
-		return false, 0, "", err
+		return nil, err
 	}
 	defer file.Close()
 
-	scanner := bufio.NewScanner(file)
+	reader := bufio.NewReaderSize(file, 64*1024)
+	head, _ := reader.Peek(binaryDetectionSize)
+	if bytes.IndexByte(head, 0) >= 0 {
+		return nil, nil
+	}
+
+	var matches []grepMatch
 	lineNum := 0
-	for scanner.Scan() {
-		lineNum++
-		line := scanner.Text()
-		if pattern.MatchString(line) {
-			return true, lineNum, line, nil
+	for len(matches) < limit {
+		line, err := reader.ReadBytes('\n')
+		if len(line) > 0 {
+			lineNum++
+			line = bytes.TrimRight(line, "\r\n")
+			if pattern.Match(line) {
+				matches = append(matches, grepMatch{
+					path:     filePath,
+					lineNum:  lineNum,
+					lineText: truncateGrepLine(string(line)),
+				})
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return matches, err
 		}
 	}
-
-	return false, 0, "", scanner.Err()
+	return matches, nil
 }
 
-func globToRegex(glob string) string {
-	regexPattern := strings.ReplaceAll(glob, ".", "\\.")
-	regexPattern = strings.ReplaceAll(regexPattern, "*", ".*")
-	regexPattern = strings.ReplaceAll(regexPattern, "?", ".")
-
-	re := regexp.MustCompile(`\{([^}]+)\}`)
-	regexPattern = re.ReplaceAllStringFunc(regexPattern, func(match string) string {
-		inner := match[1 : len(match)-1]
-		return "(" + strings.ReplaceAll(inner, ",", "|") + ")"
-	})
-
-	return regexPattern
+// truncateGrepLine shortens long lines, like minified code, the way rg does
+// with --max-columns-preview.
+func truncateGrepLine(line string) string {
+	if len(line) <= maxGrepLineLength {
+		return line
+	}
+	cut := maxGrepLineLength
+	for cut > 0 && !utf8.RuneStart(line[cut]) {
+		cut--
+	}
+	return line[:cut] + " [... omitted end of long line]"
 }
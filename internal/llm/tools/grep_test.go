@@ -0,0 +1,105 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGitignore(t *testing.T) {
+	g := parseGitignore("/repo", []byte("# build output\n*.log\n!keep.log\nbuild/\n/root.txt\ndocs/**/*.tmp\n\\#hash\n"))
+
+	tests := []struct {
+		path    string
+		isDir   bool
+		ignored bool
+	}{
+		{"/repo/app.log", false, true},
+		{"/repo/sub/app.log", false, true},
+		{"/repo/keep.log", false, false},
+		{"/repo/build", true, true},
+		{"/repo/sub/build", true, true},
+		{"/repo/build", false, false},
+		{"/repo/root.txt", false, true},
+		{"/repo/sub/root.txt", false, false},
+		{"/repo/docs/a/b/x.tmp", false, true},
+		{"/repo/#hash", false, true},
+		{"/repo/main.go", false, false},
+	}
+	for _, tt := range tests {
+		ignored, _ := g.match(tt.path, tt.isDir)
+		assert.Equal(t, tt.ignored, ignored, tt.path)
+	}
+}
+
+func TestSearchFilesWithRegex(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		".gitignore":             "dist/\n*.gen.go\n",
+		"main.go":                "package main\n\nfunc main() {\n\tneedle()\n}\n",
+		"util/util.go":           "package util\n\n// needle is here\nfunc needle() {}\n",
+		"util/.gitignore":        "!keep.gen.go\n",
+		"util/keep.gen.go":       "package util // needle\n",
+		"util/skip.gen.go":       "package util // needle\n",
+		"dist/bundle.js":         "needle()\n",
+		".hidden/secret.go":      "needle\n",
+		"data.bin":               "needle\x00\x01\x02",
+		"long.txt":               strings.Repeat("x", 400) + "needle\n",
+		"web/src/app.ts":         "const needle = 1\n",
+		"web/src/app.test.ts":    "needle()\n",
+		"web/node_modules/x.ts":  "needle\n",
+		"web/.gitignore":         "node_modules\n",
+		"docs/needle-free.md":    "nothing\n",
+		"util/nested/deep.go":    "needle\n",
+		"util/nested/.gitignore": "deep.go\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+	// The tree is a repository, the parents' .gitignore files don't apply
+	require.NoError(t, os.MkdirAll(filepath.Join(root, ".git", "info"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, ".git", "info", "exclude"), []byte("*.test.ts\n"), 0o644))
+
+	matches, err := searchFilesWithRegex(context.Background(), "needle", root, "", 100)
+	require.NoError(t, err)
+	for _, m := range matches {
+		if filepath.Base(m.path) == "long.txt" {
+			assert.True(t, strings.HasSuffix(m.lineText, "[... omitted end of long line]"))
+		}
+	}
+	assert.Equal(t, map[string]int{
+		"main.go":          4,
+		"util/util.go":     4,
+		"util/keep.gen.go": 1,
+		"long.txt":         1,
+		"web/src/app.ts":   1,
+	}, lastMatchingLines(matches, root))
+	assert.Len(t, matches, 6)
+
+	matches, err = searchFilesWithRegex(context.Background(), "needle", root, "*.{ts,tsx}", 100)
+	require.NoError(t, err)
+	require.Len(t, matches, 1)
+	assert.Equal(t, filepath.Join(root, "web", "src", "app.ts"), matches[0].path)
+
+	_, err = searchFilesWithRegex(context.Background(), "needle(", root, "", 100)
+	var patternErr *grepPatternError
+	assert.ErrorAs(t, err, &patternErr)
+}
+
+// lastMatchingLines returns the last matching line of each file by its path
+// from the root.
+func lastMatchingLines(matches []grepMatch, root string) map[string]int {
+	lines := make(map[string]int)
+	for _, m := range matches {
+		rel, _ := filepath.Rel(root, m.path)
+		lines[filepath.ToSlash(rel)] = m.lineNum
+	}
+	return lines
+}
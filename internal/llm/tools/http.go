@@ -0,0 +1,305 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/opencode-ai/opencode/internal/config"
+	"github.com/opencode-ai/opencode/internal/permission"
+)
+
+type HTTPParams struct {
+	Method  string            `json:"method"`
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    string            `json:"body,omitempty"`
+	Timeout int               `json:"timeout,omitempty"`
+}
+
+type HTTPPermissionsParams struct {
+	Method  string            `json:"method"`
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    string            `json:"body,omitempty"`
+}
+
+type HTTPResponseMetadata struct {
+	Method     string `json:"method"`
+	URL        string `json:"url"`
+	StatusCode int    `json:"status_code"`
+	Duration   int64  `json:"duration"`
+}
+
+type httpTool struct {
+	cfg         config.HTTP
+	permissions permission.Service
+}
+
+const (
+	HTTPToolName = "http"
+
+	// httpMaxBodySize is the most of a response body that is read
+	httpMaxBodySize = 5 * 1024 * 1024
+	// httpRedacted replaces the values of the configured headers in responses
+	httpRedacted = "[REDACTED]"
+)
+
+// httpMethods are the methods the http tool can send.
+var httpMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "HEAD", "OPTIONS"}
+
+// httpSafeMethods don't change anything on the server, they are sent without
+// asking with autoApprove.
+var httpSafeMethods = []string{"GET", "HEAD", "OPTIONS"}
+
+func NewHTTPTool(cfg config.HTTP, permissions permission.Service) BaseTool {
+	return &httpTool{
+		cfg:         cfg,
+		permissions: permissions,
+	}
+}
+
+func (h *httpTool) Info() ToolInfo {
+	return ToolInfo{
+		Name: HTTPToolName,
+		Description: fmt.Sprintf(`Sends an HTTP request and returns the status, the headers and the body of the response.
+
+WHEN TO USE THIS TOOL:
+- Use to call the API of a local dev server while working on backend code, e.g. to check a new endpoint
+- Use to query internal APIs the project talks to
+- Use the Fetch tool instead to read web pages and documentation
+
+ALLOWED HOSTS:
+%s
+
+HOW TO USE:
+- Set method and the full URL with http:// or https://
+- Optionally set headers and a body, e.g. a JSON body with Content-Type: application/json
+- Optionally set a timeout in seconds (default 30, max 120)
+- Authentication headers configured for a host are added to its requests, don't ask for credentials
+
+LIMITATIONS:
+- Only the allowed hosts can be requested
+- Redirects are not followed, the response shows the Location to request next
+- The body is truncated to %d characters, binary bodies are not returned`, "- "+strings.Join(h.cfg.AllowedHosts, "\n- "), MaxOutputLength),
+		Parameters: map[string]any{
+			"method": map[string]any{
+				"type":        "string",
+				"description": "The HTTP method",
+				"enum":        httpMethods,
+			},
+			"url": map[string]any{
+				"type":        "string",
+				"description": "The URL to send the request to",
+			},
+			"headers": map[string]any{
+				"type":        "object",
+				"description": "Headers of the request",
+				"additionalProperties": map[string]any{
+					"type": "string",
+				},
+			},
+			"body": map[string]any{
+				"type":        "string",
+				"description": "Body of the request",
+			},
+			"timeout": map[string]any{
+				"type":        "number",
+				"description": "Optional timeout in seconds (max 120)",
+			},
+		},
+		Required: []string{"method", "url"},
+	}
+}
+
+func (h *httpTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error) {
+	var params HTTPParams
+	if err := json.Unmarshal([]byte(call.Input), &params); err != nil {
+		return NewTextErrorResponse("Failed to parse http parameters: " + err.Error()), nil
+	}
+	params.Method = strings.ToUpper(params.Method)
+	if !slices.Contains(httpMethods, params.Method) {
+		return NewTextErrorResponse("method must be one of: " + strings.Join(httpMethods, ", ")), nil
+	}
+	requestURL, err := url.Parse(params.URL)
+	if err != nil || (requestURL.Scheme != "http" && requestURL.Scheme != "https") || requestURL.Host == "" {
+		return NewTextErrorResponse("URL must be an absolute http:// or https:// URL"), nil
+	}
+	if h.allowedHost(requestURL) == "" {
+		return NewTextErrorResponse(fmt.Sprintf("%s is not one of the allowed hosts: %s", requestURL.Host, strings.Join(h.cfg.AllowedHosts, ", "))), nil
+	}
+
+	if !h.cfg.AutoApprove || !slices.Contains(httpSafeMethods, params.Method) {
+		sessionID, messageID := GetContextValues(ctx)
+		if sessionID == "" || messageID == "" {
+			return ToolResponse{}, fmt.Errorf("session ID and message ID are required for sending a request")
+		}
+		description := fmt.Sprintf("Send `%s %s`", params.Method, params.URL)
+		if params.Body != "" {
+			description += fmt.Sprintf(" with the body:\n\n```\n%s\n```", params.Body)
+		}
+		p := h.permissions.Request(
+			permission.CreatePermissionRequest{
+				SessionID:   sessionID,
+				Path:        config.WorkingDirectory(),
+				ToolName:    HTTPToolName,
+				Action:      strings.ToLower(params.Method),
+				Description: description,
+				Params: HTTPPermissionsParams{
+					Method:  params.Method,
+					URL:     params.URL,
+					Headers: params.Headers,
+					Body:    params.Body,
+				},
+			},
+		)
+		if !p {
+			return ToolResponse{}, permission.ErrorPermissionDenied
+		}
+	}
+
+	timeout := 30 * time.Second
+	if params.Timeout > 0 {
+		timeout = time.Duration(min(params.Timeout, 120)) * time.Second
+	}
+	client := &http.Client{
+		Timeout: timeout,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	var body io.Reader
+	if params.Body != "" {
+		body = strings.NewReader(params.Body)
+	}
+	req, err := http.NewRequestWithContext(ctx, params.Method, params.URL, body)
+	if err != nil {
+		return NewTextErrorResponse("Failed to create request: " + err.Error()), nil
+	}
+	req.Header.Set("User-Agent", "opencode/1.0")
+	for name, value := range params.Headers {
+		req.Header.Set(name, value)
+	}
+	secrets := h.injectHeaders(req, requestURL)
+
+	startTime := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		return NewTextErrorResponse("Request failed: " + redactSecrets(err.Error(), secrets)), nil
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, httpMaxBodySize))
+	if err != nil {
+		return NewTextErrorResponse("Failed to read response body: " + err.Error()), nil
+	}
+
+	metadata := HTTPResponseMetadata{
+		Method:     params.Method,
+		URL:        params.URL,
+		StatusCode: resp.StatusCode,
+		Duration:   time.Since(startTime).Milliseconds(),
+	}
+	output := redactSecrets(formatHTTPResponse(resp, respBody), secrets)
+	return WithResponseMetadata(NewTextResponse(truncateOutput(output)), metadata), nil
+}
+
+// allowedHost returns the pattern of AllowedHosts matching the host of the
+// URL, or empty when the host isn't allowed.
+func (h *httpTool) allowedHost(u *url.URL) string {
+	for _, pattern := range h.cfg.AllowedHosts {
+		if httpHostMatches(pattern, u) {
+			return pattern
+		}
+	}
+	return ""
+}
+
+// injectHeaders sets the configured headers of the host on the request, and
+// returns their values so they can be hidden from the model.
+func (h *httpTool) injectHeaders(req *http.Request, u *url.URL) []string {
+	var secrets []string
+	for name, value := range h.cfg.Headers[h.allowedHost(u)] {
+		value = os.ExpandEnv(value)
+		req.Header.Set(name, value)
+		if value != "" {
+			secrets = append(secrets, value)
+		}
+		// The credentials of schemes like Bearer may be echoed on their own
+		if _, credentials, ok := strings.Cut(value, " "); ok && len(credentials) >= 8 {
+			secrets = append(secrets, credentials)
+		}
+	}
+	return secrets
+}
+
+// httpHostMatches reports whether the host of the URL matches the pattern, a
+// host with an optional port where *. matches any subdomain.
+func httpHostMatches(pattern string, u *url.URL) bool {
+	pattern = strings.ToLower(pattern)
+	if name, port, err := net.SplitHostPort(pattern); err == nil {
+		urlPort := u.Port()
+		if urlPort == "" {
+			urlPort = map[string]string{"http": "80", "https": "443"}[u.Scheme]
+		}
+		if port != urlPort {
+			return false
+		}
+		pattern = name
+	}
+	pattern = strings.Trim(pattern, "[]")
+	host := strings.ToLower(u.Hostname())
+	if domain, ok := strings.CutPrefix(pattern, "*."); ok {
+		return strings.HasSuffix(host, "."+domain)
+	}
+	return host == pattern
+}
+
+// formatHTTPResponse formats the response like HTTP/1.1 does, with the headers
+// in order and indented JSON bodies.
+func formatHTTPResponse(resp *http.Response, body []byte) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s\n", resp.Proto, resp.Status)
+	names := make([]string, 0, len(resp.Header))
+	for name := range resp.Header {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		for _, value := range resp.Header[name] {
+			fmt.Fprintf(&b, "%s: %s\n", name, value)
+		}
+	}
+	if len(body) == 0 {
+		return b.String()
+	}
+	b.WriteString("\n")
+	if !utf8.Valid(body) {
+		fmt.Fprintf(&b, "(%d bytes of binary content)\n", len(body))
+		return b.String()
+	}
+	var indented bytes.Buffer
+	if strings.Contains(resp.Header.Get("Content-Type"), "json") && json.Indent(&indented, body, "", "  ") == nil {
+		body = indented.Bytes()
+	}
+	b.Write(body)
+	return b.String()
+}
+
+// redactSecrets replaces the values of the configured headers in the text.
+func redactSecrets(text string, secrets []string) string {
+	for _, secret := range secrets {
+		text = strings.ReplaceAll(text, secret, httpRedacted)
+	}
+	return text
+}
@@ -0,0 +1,90 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/opencode-ai/opencode/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPHostMatches(t *testing.T) {
+	tests := []struct {
+		pattern string
+		url     string
+		want    bool
+	}{
+		{"localhost", "http://localhost:3000/api", true},
+		{"localhost:3000", "http://localhost:3000/api", true},
+		{"localhost:3000", "http://localhost:4000/api", false},
+		{"api.example.com:443", "https://API.example.com/v1", true},
+		{"*.example.com", "https://api.example.com", true},
+		{"*.example.com", "https://example.com", false},
+		{"*.example.com", "https://example.com.evil.io", false},
+		{"[::1]:8080", "http://[::1]:8080/", true},
+		{"example.com", "http://example.com@evil.io/", false},
+	}
+	for _, tt := range tests {
+		u, err := url.Parse(tt.url)
+		require.NoError(t, err)
+		assert.Equal(t, tt.want, httpHostMatches(tt.pattern, u), "%s %s", tt.pattern, tt.url)
+	}
+}
+
+func TestHTTPTool(t *testing.T) {
+	t.Setenv("HTTP_TEST_TOKEN", "s3cret-token")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/echo":
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(map[string]string{
+				"authorization": r.Header.Get("Authorization"),
+				"token":         r.Header.Get("Authorization")[len("Bearer "):],
+				"trace":         r.Header.Get("X-Trace"),
+			})
+		case "/old":
+			http.Redirect(w, r, "/new", http.StatusMovedPermanently)
+		}
+	}))
+	defer server.Close()
+	serverURL, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	tool := NewHTTPTool(config.HTTP{
+		AllowedHosts: []string{serverURL.Host},
+		Headers: map[string]map[string]string{
+			serverURL.Host: {"Authorization": "Bearer $HTTP_TEST_TOKEN"},
+		},
+		AutoApprove: true,
+	}, nil)
+	run := func(params HTTPParams) ToolResponse {
+		input, err := json.Marshal(params)
+		require.NoError(t, err)
+		response, err := tool.Run(context.Background(), ToolCall{Name: HTTPToolName, Input: string(input)})
+		require.NoError(t, err)
+		return response
+	}
+
+	response := run(HTTPParams{Method: "get", URL: server.URL + "/echo", Headers: map[string]string{"X-Trace": "abc", "Authorization": "guess"}})
+	require.False(t, response.IsError, response.Content)
+	assert.Contains(t, response.Content, "HTTP/1.1 200 OK\n")
+	assert.Contains(t, response.Content, "Content-Type: application/json\n")
+	assert.Contains(t, response.Content, "{\n  \"authorization\": \"[REDACTED]\",\n  \"token\": \"[REDACTED]\",\n  \"trace\": \"abc\"\n}")
+	assert.NotContains(t, response.Content, "s3cret-token")
+
+	response = run(HTTPParams{Method: "GET", URL: server.URL + "/old"})
+	assert.Contains(t, response.Content, "301 Moved Permanently")
+	assert.Contains(t, response.Content, "Location: /new")
+
+	response = run(HTTPParams{Method: "GET", URL: "http://example.com/"})
+	assert.True(t, response.IsError)
+	assert.Contains(t, response.Content, "not one of the allowed hosts")
+
+	response = run(HTTPParams{Method: "TRACE", URL: server.URL})
+	assert.True(t, response.IsError)
+}
@@ -0,0 +1,346 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/opencode-ai/opencode/internal/config"
+	"github.com/opencode-ai/opencode/internal/diff"
+	"github.com/opencode-ai/opencode/internal/history"
+	"github.com/opencode-ai/opencode/internal/logging"
+	"github.com/opencode-ai/opencode/internal/lsp"
+	"github.com/opencode-ai/opencode/internal/permission"
+)
+
+type MultiEditOperation struct {
+	FilePath  string `json:"file_path"`
+	OldString string `json:"old_string"`
+	NewString string `json:"new_string"`
+}
+
+type MultiEditParams struct {
+	Edits []MultiEditOperation `json:"edits"`
+}
+
+// MultiEditFileDiff is the diff of one of the files of a multi-file edit.
+type MultiEditFileDiff struct {
+	FilePath  string `json:"file_path"`
+	Diff      string `json:"diff"`
+	Additions int    `json:"additions"`
+	Removals  int    `json:"removals"`
+}
+
+type MultiEditPermissionsParams struct {
+	Files []MultiEditFileDiff `json:"files"`
+}
+
+type MultiEditResponseMetadata struct {
+	Files     []MultiEditFileDiff `json:"files"`
+	Additions int                 `json:"additions"`
+	Removals  int                 `json:"removals"`
+}
+
+// multiEditFile is a file changed by a multi-file edit, with its content
+// before and after all of its edits.
+type multiEditFile struct {
+	path       string
+	oldContent string
+	newContent string
+	created    bool
+}
+
+type multiEditTool struct {
+	lspClients  map[string]*lsp.Client
+	permissions permission.Service
+	files       history.Service
+}
+
+const (
+	MultiEditToolName    = "multiedit"
+	multiEditDescription = `Edits several files in one operation by replacing text, like the edit tool. Either all edits are applied or none of them.
+
+WHEN TO USE THIS TOOL:
+- Use for coordinated changes across files, like renaming a function and its callers or changing an interface and its implementations
+- Use instead of several edit calls when the code is broken until all of the edits are made
+
+HOW TO USE:
+- Provide edits, a list of file_path, old_string and new_string like for the edit tool
+- Edits of the same file are applied in order, each to the result of the previous one
+- To create a new file, leave old_string empty in the first edit of the file
+- To delete content, leave new_string empty
+- Paths are absolute, or relative to the working directory
+
+CRITICAL REQUIREMENTS:
+- Read every file you edit with the View tool first, files changed since they were read are rejected
+- Each old_string must match the file exactly once, including all whitespace and indentation, with enough context to be unique
+- When one of the edits can't be applied, nothing is changed and the error names the edit to fix
+
+LIMITATIONS:
+- Moving, renaming and deleting files isn't supported, use the bash tool`
+)
+
+func NewMultiEditTool(lspClients map[string]*lsp.Client, permissions permission.Service, files history.Service) BaseTool {
+	return &multiEditTool{
+		lspClients:  lspClients,
+		permissions: permissions,
+		files:       files,
+	}
+}
+
+func (m *multiEditTool) Info() ToolInfo {
+	return ToolInfo{
+		Name:        MultiEditToolName,
+		Description: multiEditDescription,
+		Parameters: map[string]any{
+			"edits": map[string]any{
+				"type":        "array",
+				"description": "The edits to apply, in order",
+				"items": map[string]any{
+					"type": "object",
+					"properties": map[string]any{
+						"file_path": map[string]any{
+							"type":        "string",
+							"description": "The path to the file to modify",
+						},
+						"old_string": map[string]any{
+							"type":        "string",
+							"description": "The text to replace, empty to create the file",
+						},
+						"new_string": map[string]any{
+							"type":        "string",
+							"description": "The text to replace it with",
+						},
+					},
+					"required": []string{"file_path", "old_string", "new_string"},
+				},
+			},
+		},
+		Required: []string{"edits"},
+	}
+}
+
+func (m *multiEditTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error) {
+	var params MultiEditParams
+	if err := json.Unmarshal([]byte(call.Input), &params); err != nil {
+		return NewTextErrorResponse("invalid parameters"), nil
+	}
+	if len(params.Edits) == 0 {
+		return NewTextErrorResponse("edits are required"), nil
+	}
+
+	rootDir := config.WorkingDirectory()
+	for i := range params.Edits {
+		if params.Edits[i].FilePath == "" {
+			return NewTextErrorResponse(fmt.Sprintf("edit %d: file_path is required", i+1)), nil
+		}
+		if !filepath.IsAbs(params.Edits[i].FilePath) {
+			params.Edits[i].FilePath = filepath.Join(rootDir, params.Edits[i].FilePath)
+		}
+	}
+
+	files, err := planMultiEdit(params.Edits)
+	if err != nil {
+		return NewTextErrorResponse(err.Error()), nil
+	}
+
+	sessionID, messageID := GetContextValues(ctx)
+	if sessionID == "" || messageID == "" {
+		return ToolResponse{}, fmt.Errorf("session ID and message ID are required for editing files")
+	}
+
+	metadata := MultiEditResponseMetadata{}
+	var paths []string
+	permissionPath := rootDir
+	for _, file := range files {
+		fileDiff, additions, removals := diff.GenerateDiff(file.oldContent, file.newContent, file.path)
+		metadata.Files = append(metadata.Files, MultiEditFileDiff{
+			FilePath:  file.path,
+			Diff:      fileDiff,
+			Additions: additions,
+			Removals:  removals,
+		})
+		metadata.Additions += additions
+		metadata.Removals += removals
+		paths = append(paths, file.path)
+		if !strings.HasPrefix(file.path, rootDir) {
+			permissionPath = filepath.Dir(file.path)
+		}
+	}
+
+	p := m.permissions.Request(
+		permission.CreatePermissionRequest{
+			SessionID:   sessionID,
+			Path:        permissionPath,
+			ToolName:    MultiEditToolName,
+			Action:      "write",
+			Description: fmt.Sprintf("Edit %d files:\n- %s", len(files), strings.Join(paths, "\n- ")),
+			Params: MultiEditPermissionsParams{
+				Files: metadata.Files,
+			},
+		},
+	)
+	if !p {
+		return ToolResponse{}, permission.ErrorPermissionDenied
+	}
+
+	if err := applyMultiEdit(files); err != nil {
+		return NewTextErrorResponse(err.Error()), nil
+	}
+
+	var formatNotes strings.Builder
+	for _, file := range files {
+		var formatNote string
+		file.newContent, formatNote = formatWrittenFile(ctx, file.path, file.newContent)
+		formatNotes.WriteString(formatNote)
+		recordEditHistory(ctx, m.files, sessionID, file)
+		recordFileWrite(file.path)
+		recordFileRead(file.path)
+	}
+
+	var result strings.Builder
+	fmt.Fprintf(&result, "<result>\nEdited %d files, %d additions, %d removals:\n- %s\n</result>\n",
+		len(files), metadata.Additions, metadata.Removals, strings.Join(paths, "\n- "))
+	result.WriteString(formatNotes.String())
+	for _, file := range files {
+		waitForLspDiagnostics(ctx, file.path, m.lspClients)
+	}
+	for _, file := range files {
+		result.WriteString(getDiagnostics(file.path, m.lspClients))
+	}
+	return WithResponseMetadata(NewTextResponse(result.String()), metadata), nil
+}
+
+// planMultiEdit validates all edits and returns the new contents of the
+// files, in the order they are first edited. No file is changed.
+func planMultiEdit(edits []MultiEditOperation) ([]*multiEditFile, error) {
+	var files []*multiEditFile
+	byPath := make(map[string]*multiEditFile)
+	for i, edit := range edits {
+		file, ok := byPath[edit.FilePath]
+		if !ok {
+			var err error
+			file, err = loadMultiEditFile(edit)
+			if err != nil {
+				return nil, fmt.Errorf("edit %d: %w", i+1, err)
+			}
+			byPath[edit.FilePath] = file
+			files = append(files, file)
+			if file.created {
+				continue
+			}
+		}
+
+		if edit.OldString == "" {
+			return nil, fmt.Errorf("edit %d: old_string is empty, but %s already exists or is edited before", i+1, edit.FilePath)
+		}
+		index := strings.Index(file.newContent, edit.OldString)
+		if index == -1 {
+			return nil, fmt.Errorf("edit %d: old_string not found in %s. Make sure it matches exactly, including whitespace and line breaks", i+1, edit.FilePath)
+		}
+		if index != strings.LastIndex(file.newContent, edit.OldString) {
+			return nil, fmt.Errorf("edit %d: old_string appears multiple times in %s. Please provide more context to ensure a unique match", i+1, edit.FilePath)
+		}
+		file.newContent = file.newContent[:index] + edit.NewString + file.newContent[index+len(edit.OldString):]
+	}
+
+	for _, file := range files {
+		if !file.created && file.newContent == file.oldContent {
+			return nil, fmt.Errorf("the edits of %s don't change it", file.path)
+		}
+	}
+	return files, nil
+}
+
+// loadMultiEditFile reads the file of its first edit, which must have been
+// read since it was last changed, or creates it with an empty old_string.
+func loadMultiEditFile(edit MultiEditOperation) (*multiEditFile, error) {
+	fileInfo, err := os.Stat(edit.FilePath)
+	if errors.Is(err, os.ErrNotExist) {
+		if edit.OldString != "" {
+			return nil, fmt.Errorf("file not found: %s", edit.FilePath)
+		}
+		return &multiEditFile{path: edit.FilePath, newContent: edit.NewString, created: true}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to access file: %w", err)
+	}
+	if fileInfo.IsDir() {
+		return nil, fmt.Errorf("path is a directory, not a file: %s", edit.FilePath)
+	}
+	if edit.OldString == "" {
+		return nil, fmt.Errorf("file already exists: %s", edit.FilePath)
+	}
+
+	lastRead := getLastReadTime(edit.FilePath)
+	if lastRead.IsZero() {
+		return nil, fmt.Errorf("you must read %s before editing it. Use the View tool first", edit.FilePath)
+	}
+	if modTime := fileInfo.ModTime(); modTime.After(lastRead) {
+		return nil, fmt.Errorf("file %s has been modified since it was last read (mod time: %s, last read: %s)",
+			edit.FilePath, modTime.Format(time.RFC3339), lastRead.Format(time.RFC3339))
+	}
+
+	content, err := os.ReadFile(edit.FilePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	return &multiEditFile{path: edit.FilePath, oldContent: string(content), newContent: string(content)}, nil
+}
+
+// applyMultiEdit writes the new contents of the files. When a write fails,
+// the files written before are restored and the created files removed.
+func applyMultiEdit(files []*multiEditFile) error {
+	for i, file := range files {
+		err := os.MkdirAll(filepath.Dir(file.path), 0o755)
+		if err == nil {
+			err = os.WriteFile(file.path, []byte(file.newContent), 0o644)
+		}
+		if err == nil {
+			continue
+		}
+
+		for _, written := range files[:i] {
+			var restoreErr error
+			if written.created {
+				restoreErr = os.Remove(written.path)
+			} else {
+				restoreErr = os.WriteFile(written.path, []byte(written.oldContent), 0o644)
+			}
+			if restoreErr != nil {
+				logging.Error("Failed to restore file after a failed edit", "path", written.path, "error", restoreErr)
+			}
+		}
+		return fmt.Errorf("failed to write %s, no file was changed: %w", file.path, err)
+	}
+	return nil
+}
+
+// recordEditHistory stores the versions of an edited file, like the edit tool.
+func recordEditHistory(ctx context.Context, files history.Service, sessionID string, file *multiEditFile) {
+	existing, err := files.GetByPathAndSession(ctx, file.path, sessionID)
+	if err != nil {
+		if file.created {
+			_, err = files.CreateNew(ctx, sessionID, file.path)
+		} else {
+			_, err = files.Create(ctx, sessionID, file.path, file.oldContent)
+		}
+		if err != nil {
+			logging.Debug("Error creating file history", "error", err)
+			return
+		}
+	} else if existing.Content != file.oldContent {
+		// User manually changed the content, store an intermediate version
+		if _, err := files.CreateVersion(ctx, sessionID, file.path, file.oldContent); err != nil {
+			logging.Debug("Error creating file history version", "error", err)
+		}
+	}
+	if _, err := files.CreateVersion(ctx, sessionID, file.path, file.newContent); err != nil {
+		logging.Debug("Error creating file history version", "error", err)
+	}
+}
@@ -0,0 +1,85 @@
+package tools
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlanMultiEdit(t *testing.T) {
+	dir := t.TempDir()
+	api := filepath.Join(dir, "api.go")
+	main := filepath.Join(dir, "main.go")
+	require.NoError(t, os.WriteFile(api, []byte("func Load() {}\n"), 0o644))
+	require.NoError(t, os.WriteFile(main, []byte("func main() {\n\tLoad()\n\tLoad()\n}\n"), 0o644))
+	recordFileRead(api)
+	recordFileRead(main)
+
+	files, err := planMultiEdit([]MultiEditOperation{
+		{FilePath: api, OldString: "func Load()", NewString: "func LoadConfig()"},
+		{FilePath: main, OldString: "\tLoad()\n\tLoad()", NewString: "\tLoadConfig()"},
+		{FilePath: filepath.Join(dir, "config", "config.go"), NewString: "package config\n"},
+		{FilePath: filepath.Join(dir, "config", "config.go"), OldString: "config\n", NewString: "config\n\nconst Name = \"app\"\n"},
+	})
+	require.NoError(t, err)
+	require.Len(t, files, 3)
+	assert.Equal(t, "func LoadConfig() {}\n", files[0].newContent)
+	assert.Equal(t, "func main() {\n\tLoadConfig()\n}\n", files[1].newContent)
+	assert.True(t, files[2].created)
+	assert.Equal(t, "package config\n\nconst Name = \"app\"\n", files[2].newContent)
+
+	tests := []struct {
+		name  string
+		edits []MultiEditOperation
+		err   string
+	}{
+		{"not found", []MultiEditOperation{{FilePath: api, OldString: "func Save()", NewString: "x"}}, "edit 1: old_string not found"},
+		{"not unique", []MultiEditOperation{{FilePath: api, OldString: "{}", NewString: "{ }"}, {FilePath: main, OldString: "Load()", NewString: "x"}}, "edit 2: old_string appears multiple times"},
+		{"create existing", []MultiEditOperation{{FilePath: api, NewString: "x"}}, "file already exists"},
+		{"no change", []MultiEditOperation{{FilePath: api, OldString: "Load", NewString: "Load"}}, "don't change it"},
+		{"missing file", []MultiEditOperation{{FilePath: filepath.Join(dir, "missing.go"), OldString: "a", NewString: "b"}}, "file not found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := planMultiEdit(tt.edits)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.err)
+		})
+	}
+
+	// A file changed after it was read fails all edits
+	later := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(main, later, later))
+	_, err = planMultiEdit([]MultiEditOperation{
+		{FilePath: api, OldString: "Load", NewString: "LoadConfig"},
+		{FilePath: main, OldString: "func main", NewString: "func run"},
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "edit 2: file "+main+" has been modified since it was last read")
+}
+
+func TestApplyMultiEdit_Rollback(t *testing.T) {
+	dir := t.TempDir()
+	existing := filepath.Join(dir, "a.txt")
+	require.NoError(t, os.WriteFile(existing, []byte("old"), 0o644))
+	blocker := filepath.Join(dir, "blocker")
+	require.NoError(t, os.WriteFile(blocker, nil, 0o644))
+
+	err := applyMultiEdit([]*multiEditFile{
+		{path: existing, oldContent: "old", newContent: "new"},
+		{path: filepath.Join(dir, "b.txt"), newContent: "b", created: true},
+		// The parent is a file, so the write fails
+		{path: filepath.Join(blocker, "c.txt"), newContent: "c", created: true},
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no file was changed")
+
+	content, err := os.ReadFile(existing)
+	require.NoError(t, err)
+	assert.Equal(t, "old", string(content))
+	assert.NoFileExists(t, filepath.Join(dir, "b.txt"))
+}
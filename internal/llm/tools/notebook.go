@@ -0,0 +1,480 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/opencode-ai/opencode/internal/config"
+	"github.com/opencode-ai/opencode/internal/diff"
+	"github.com/opencode-ai/opencode/internal/history"
+	"github.com/opencode-ai/opencode/internal/notebook"
+	"github.com/opencode-ai/opencode/internal/permission"
+)
+
+type NotebookParams struct {
+	NotebookPath string `json:"notebook_path"`
+	Action       string `json:"action"`
+	CellIndex    *int   `json:"cell_index,omitempty"`
+	EditMode     string `json:"edit_mode,omitempty"`
+	CellType     string `json:"cell_type,omitempty"`
+	Source       string `json:"source,omitempty"`
+	Restart      bool   `json:"restart,omitempty"`
+	Timeout      int    `json:"timeout,omitempty"`
+}
+
+type NotebookPermissionsParams struct {
+	NotebookPath string `json:"notebook_path"`
+	Kernel       string `json:"kernel"`
+	Code         string `json:"code"`
+}
+
+type NotebookResponseMetadata struct {
+	Cells    int    `json:"cells"`
+	Kernel   string `json:"kernel,omitempty"`
+	Executed int    `json:"executed,omitempty"`
+	Failed   bool   `json:"failed,omitempty"`
+}
+
+type notebookTool struct {
+	permissions permission.Service
+	files       history.Service
+}
+
+const (
+	NotebookToolName    = "notebook"
+	notebookDescription = `Reads, edits and executes the cells of Jupyter notebooks (.ipynb files).
+
+WHEN TO USE THIS TOOL:
+- Use for notebooks, instead of the View, Edit and Write tools, which see their raw JSON
+- Use execute to run cells and see their outputs, like printed text, results and error tracebacks
+
+HOW TO USE:
+- Set action to read to see the cells with their index, type, source and outputs
+- Set action to edit with edit_mode replace (default) to change the source of the cell at cell_index, insert to add a cell before cell_index, or delete to remove it; insert needs cell_type
+- Set action to execute to run the code cell at cell_index, or all code cells in order without it; the outputs are saved in the notebook
+- Read a notebook before editing or executing it
+- Cell indexes start at 0, insert at the number of cells to append a cell
+
+KERNEL:
+- Cells run in a kernel of the notebook's kernelspec, which keeps its variables between executions, like in Jupyter
+- Set restart to true to start with a fresh kernel, e.g. after changing imported modules
+- Running all cells stops at the first cell that raises an error
+- Optionally set a timeout in milliseconds (default: %d, max: %d), the running cell is interrupted when it is reached
+
+LIMITATIONS:
+- Executing cells needs the permission of the user
+- Executing needs Python 3 with jupyter_client and the kernel of the notebook, e.g. pip install jupyter_client ipykernel
+- Cells can't read input
+- Images and HTML outputs are only named, their text/plain representation is shown when they have one
+- Output over %d characters is truncated`
+
+	// notebookDefaultTimeout is how long cells run by default, in
+	// milliseconds.
+	notebookDefaultTimeout = 5 * 60 * 1000
+)
+
+func NewNotebookTool(permissions permission.Service, files history.Service) BaseTool {
+	return &notebookTool{
+		permissions: permissions,
+		files:       files,
+	}
+}
+
+func (n *notebookTool) Info() ToolInfo {
+	return ToolInfo{
+		Name:        NotebookToolName,
+		Description: fmt.Sprintf(notebookDescription, notebookDefaultTimeout, MaxTimeout, MaxOutputLength),
+		Parameters: map[string]any{
+			"notebook_path": map[string]any{
+				"type":        "string",
+				"description": "The path to the notebook",
+			},
+			"action": map[string]any{
+				"type":        "string",
+				"description": "Whether to read, edit or execute the notebook",
+				"enum":        []string{"read", "edit", "execute"},
+			},
+			"cell_index": map[string]any{
+				"type":        "number",
+				"description": "The index of the cell to edit or execute, starting at 0",
+			},
+			"edit_mode": map[string]any{
+				"type":        "string",
+				"description": "How to edit the cell, replace by default",
+				"enum":        []string{"replace", "insert", "delete"},
+			},
+			"cell_type": map[string]any{
+				"type":        "string",
+				"description": "The type of the cell, needed to insert one",
+				"enum":        []string{notebook.CellCode, notebook.CellMarkdown, notebook.CellRaw},
+			},
+			"source": map[string]any{
+				"type":        "string",
+				"description": "The new source of the cell, to replace or insert one",
+			},
+			"restart": map[string]any{
+				"type":        "boolean",
+				"description": "Restart the kernel before executing, its variables are lost",
+			},
+			"timeout": map[string]any{
+				"type":        "number",
+				"description": "Optional timeout of the execution in milliseconds (max 600000)",
+			},
+		},
+		Required: []string{"notebook_path", "action"},
+	}
+}
+
+func (n *notebookTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error) {
+	var params NotebookParams
+	if err := json.Unmarshal([]byte(call.Input), &params); err != nil {
+		return NewTextErrorResponse(fmt.Sprintf("error parsing parameters: %s", err)), nil
+	}
+	if params.NotebookPath == "" {
+		return NewTextErrorResponse("notebook_path is required"), nil
+	}
+	path := params.NotebookPath
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(config.WorkingDirectory(), path)
+	}
+	if filepath.Ext(path) != ".ipynb" {
+		return NewTextErrorResponse(fmt.Sprintf("%s is not a notebook, it must be an .ipynb file", path)), nil
+	}
+
+	switch params.Action {
+	case "read":
+		return n.read(path)
+	case "edit":
+		return n.edit(ctx, path, params)
+	case "execute":
+		return n.execute(ctx, path, params)
+	}
+	return NewTextErrorResponse("action must be one of: read, edit, execute"), nil
+}
+
+func (n *notebookTool) read(path string) (ToolResponse, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return NewTextErrorResponse(fmt.Sprintf("notebook not found: %s", path)), nil
+		}
+		return ToolResponse{}, fmt.Errorf("failed to read notebook: %w", err)
+	}
+	nb, err := notebook.Parse(content)
+	if err != nil {
+		return NewTextErrorResponse(err.Error()), nil
+	}
+	recordFileRead(path)
+
+	var result strings.Builder
+	fmt.Fprintf(&result, "<notebook path=%q kernel=%q cells=\"%d\">\n", path, nb.KernelName(), nb.Len())
+	for i := range nb.Len() {
+		result.WriteString(formatNotebookCell(i, nb.Cell(i), true))
+	}
+	result.WriteString("</notebook>")
+	return WithResponseMetadata(NewTextResponse(truncateOutput(result.String())), NotebookResponseMetadata{
+		Cells:  nb.Len(),
+		Kernel: nb.KernelName(),
+	}), nil
+}
+
+func (n *notebookTool) edit(ctx context.Context, path string, params NotebookParams) (ToolResponse, error) {
+	if params.CellIndex == nil {
+		return NewTextErrorResponse("cell_index is required to edit a notebook"), nil
+	}
+	oldContent, nb, errResponse, err := readNotebook(path)
+	if err != nil {
+		return ToolResponse{}, err
+	}
+	if errResponse != "" {
+		return NewTextErrorResponse(errResponse), nil
+	}
+
+	index := *params.CellIndex
+	var oldSource, description string
+	switch params.EditMode {
+	case "", "replace":
+		if err := nb.ReplaceCell(index, params.CellType, params.Source); err != nil {
+			return NewTextErrorResponse(err.Error()), nil
+		}
+		oldSource, description = cellSource(oldContent, index), fmt.Sprintf("Replace cell %d of %s", index, path)
+	case "insert":
+		if params.CellType == "" {
+			return NewTextErrorResponse("cell_type is required to insert a cell"), nil
+		}
+		if err := nb.InsertCell(index, params.CellType, params.Source); err != nil {
+			return NewTextErrorResponse(err.Error()), nil
+		}
+		description = fmt.Sprintf("Insert a %s cell at %d in %s", params.CellType, index, path)
+	case "delete":
+		oldSource = cellSource(oldContent, index)
+		if err := nb.DeleteCell(index); err != nil {
+			return NewTextErrorResponse(err.Error()), nil
+		}
+		params.Source, description = "", fmt.Sprintf("Delete cell %d of %s", index, path)
+	default:
+		return NewTextErrorResponse("edit_mode must be one of: replace, insert, delete"), nil
+	}
+
+	sessionID, messageID := GetContextValues(ctx)
+	if sessionID == "" || messageID == "" {
+		return ToolResponse{}, fmt.Errorf("session ID and message ID are required for editing a notebook")
+	}
+	cellDiff, _, _ := diff.GenerateDiff(oldSource, params.Source, path)
+	p := n.permissions.Request(
+		permission.CreatePermissionRequest{
+			SessionID:   sessionID,
+			Path:        filepath.Dir(path),
+			ToolName:    NotebookToolName,
+			Action:      "edit",
+			Description: description,
+			Params: EditPermissionsParams{
+				FilePath: path,
+				Diff:     cellDiff,
+			},
+		},
+	)
+	if !p {
+		return ToolResponse{}, permission.ErrorPermissionDenied
+	}
+	if err := n.save(ctx, sessionID, path, oldContent, nb); err != nil {
+		return ToolResponse{}, err
+	}
+	return WithResponseMetadata(NewTextResponse(fmt.Sprintf("%s, the notebook has %d cells", description, nb.Len())), NotebookResponseMetadata{
+		Cells: nb.Len(),
+	}), nil
+}
+
+func (n *notebookTool) execute(ctx context.Context, path string, params NotebookParams) (ToolResponse, error) {
+	if params.Timeout > MaxTimeout {
+		params.Timeout = MaxTimeout
+	} else if params.Timeout <= 0 {
+		params.Timeout = notebookDefaultTimeout
+	}
+	oldContent, nb, errResponse, err := readNotebook(path)
+	if err != nil {
+		return ToolResponse{}, err
+	}
+	if errResponse != "" {
+		return NewTextErrorResponse(errResponse), nil
+	}
+
+	var cells []int
+	if params.CellIndex != nil {
+		index := *params.CellIndex
+		if index < 0 || index >= nb.Len() {
+			return NewTextErrorResponse(fmt.Sprintf("cell index %d is out of range, the notebook has %d cells", index, nb.Len())), nil
+		}
+		if cellType := nb.Cell(index).Type; cellType != notebook.CellCode {
+			return NewTextErrorResponse(fmt.Sprintf("cell %d is a %s cell, only code cells can be executed", index, cellType)), nil
+		}
+		cells = []int{index}
+	} else {
+		for i := range nb.Len() {
+			if nb.Cell(i).Type == notebook.CellCode {
+				cells = append(cells, i)
+			}
+		}
+		if len(cells) == 0 {
+			return NewTextErrorResponse("the notebook has no code cells"), nil
+		}
+	}
+
+	sessionID, messageID := GetContextValues(ctx)
+	if sessionID == "" || messageID == "" {
+		return ToolResponse{}, fmt.Errorf("session ID and message ID are required for executing a notebook")
+	}
+	var code []string
+	for _, i := range cells {
+		code = append(code, nb.Cell(i).Source)
+	}
+	kernelName := nb.KernelName()
+	description := fmt.Sprintf("Execute cell %d of %s in the %s kernel", cells[0], path, kernelName)
+	if len(cells) > 1 {
+		description = fmt.Sprintf("Execute the %d code cells of %s in the %s kernel", len(cells), path, kernelName)
+	}
+	p := n.permissions.Request(
+		permission.CreatePermissionRequest{
+			SessionID:   sessionID,
+			Path:        filepath.Dir(path),
+			ToolName:    NotebookToolName,
+			Action:      "execute",
+			Description: description,
+			Params: NotebookPermissionsParams{
+				NotebookPath: path,
+				Kernel:       kernelName,
+				Code:         strings.Join(code, "\n\n"),
+			},
+		},
+	)
+	if !p {
+		return ToolResponse{}, permission.ErrorPermissionDenied
+	}
+
+	if params.Restart {
+		notebook.CloseKernel(path)
+	}
+	kernel, started, err := notebook.KernelFor(ctx, path, kernelName)
+	if err != nil {
+		return NewTextErrorResponse(err.Error()), nil
+	}
+
+	execCtx, cancel := context.WithTimeout(ctx, time.Duration(params.Timeout)*time.Millisecond)
+	defer cancel()
+	var result strings.Builder
+	if started {
+		fmt.Fprintf(&result, "Started a %s kernel.\n\n", kernelName)
+	}
+	executed, failed := 0, false
+	for _, i := range cells {
+		outputs, execErr := kernel.Execute(execCtx, nb.Cell(i).Source)
+		if execErr != nil && execCtx.Err() == nil {
+			// The kernel died, the next execution starts a new one
+			notebook.CloseKernel(path)
+			result.WriteString(fmt.Sprintf("Cell %d failed: %s\n", i, execErr))
+			failed = true
+			break
+		}
+		if err := nb.SetOutputs(i, outputs.Outputs, outputs.ExecutionCount); err != nil {
+			return ToolResponse{}, err
+		}
+		executed++
+		result.WriteString(formatNotebookCell(i, nb.Cell(i), false))
+		if execErr != nil {
+			reason := fmt.Sprintf("after %s", time.Duration(params.Timeout)*time.Millisecond)
+			if ctx.Err() != nil {
+				reason = "because the call was cancelled"
+			}
+			result.WriteString(fmt.Sprintf("Cell %d was interrupted %s, the kernel keeps running\n", i, reason))
+			failed = true
+			break
+		}
+		if outputs.Status == "error" {
+			if len(cells) > 1 && i != cells[len(cells)-1] {
+				result.WriteString(fmt.Sprintf("Cell %d raised an error, the following cells were not executed\n", i))
+			}
+			failed = true
+			break
+		}
+	}
+
+	if executed > 0 {
+		if err := n.save(ctx, sessionID, path, oldContent, nb); err != nil {
+			return ToolResponse{}, err
+		}
+	}
+	response := NewTextResponse(truncateOutput(result.String()))
+	if failed {
+		response = NewTextErrorResponse(truncateOutput(result.String()))
+	}
+	return WithResponseMetadata(response, NotebookResponseMetadata{
+		Cells:    nb.Len(),
+		Kernel:   kernelName,
+		Executed: executed,
+		Failed:   failed,
+	}), nil
+}
+
+// save writes the notebook and records the change in the history of the
+// session.
+func (n *notebookTool) save(ctx context.Context, sessionID, path, oldContent string, nb *notebook.Notebook) error {
+	data, err := nb.Marshal()
+	if err != nil {
+		return fmt.Errorf("failed to encode notebook: %w", err)
+	}
+	newContent := string(data)
+	commit := diff.Commit{Changes: map[string]diff.FileChange{
+		path: {Type: diff.ActionUpdate, OldContent: &oldContent, NewContent: &newContent},
+	}}
+	if _, _, _, err := writeCommit(ctx, n.files, sessionID, commit); err != nil {
+		return fmt.Errorf("failed to write notebook: %w", err)
+	}
+	return nil
+}
+
+// readNotebook reads a notebook to change, which must have been read since it
+// was last modified. Problems of the notebook are returned as the message of
+// an error response.
+func readNotebook(path string) (string, *notebook.Notebook, string, error) {
+	content, errResponse, err := readDiffFile(path)
+	if err != nil || errResponse != "" {
+		return "", nil, strings.Replace(errResponse, "Use the View tool first", "Use the read action first", 1), err
+	}
+	nb, err := notebook.Parse([]byte(content))
+	if err != nil {
+		return "", nil, err.Error(), nil
+	}
+	return content, nb, "", nil
+}
+
+// cellSource returns the source of a cell of the notebook content, for the
+// diff of the permission request.
+func cellSource(content string, index int) string {
+	nb, err := notebook.Parse([]byte(content))
+	if err != nil || index < 0 || index >= nb.Len() {
+		return ""
+	}
+	return nb.Cell(index).Source
+}
+
+// formatNotebookCell formats a cell for the model, with its source unless it
+// only shows the outputs of an execution.
+func formatNotebookCell(index int, cell notebook.Cell, withSource bool) string {
+	var b strings.Builder
+	attributes := fmt.Sprintf("index=\"%d\" type=%q", index, cell.Type)
+	if cell.ExecutionCount > 0 {
+		attributes += fmt.Sprintf(" execution_count=\"%d\"", cell.ExecutionCount)
+	}
+	fmt.Fprintf(&b, "<cell %s>\n", attributes)
+	if withSource {
+		b.WriteString(strings.TrimSuffix(cell.Source, "\n"))
+		b.WriteString("\n")
+	}
+	for _, output := range cell.Outputs {
+		fmt.Fprintf(&b, "<output type=%q>\n%s\n</output>\n", output.Type, formatNotebookOutput(output))
+	}
+	if !withSource && len(cell.Outputs) == 0 {
+		b.WriteString("(no output)\n")
+	}
+	b.WriteString("</cell>\n")
+	return b.String()
+}
+
+func formatNotebookOutput(output notebook.Output) string {
+	switch output.Type {
+	case "stream":
+		text := strings.TrimSuffix(output.TextOf(), "\n")
+		if output.Name == "stderr" {
+			return "stderr:\n" + text
+		}
+		return text
+	case "error":
+		traceback := ansiEscape.ReplaceAllString(strings.Join(output.Traceback, "\n"), "")
+		if traceback == "" {
+			return fmt.Sprintf("%s: %s", output.EName, output.EValue)
+		}
+		return traceback
+	}
+
+	var parts []string
+	if text := output.TextOf(); text != "" {
+		parts = append(parts, strings.TrimSuffix(text, "\n"))
+	}
+	var others []string
+	for mimeType := range output.Data {
+		if mimeType != "text/plain" {
+			others = append(others, mimeType)
+		}
+	}
+	sort.Strings(others)
+	for _, mimeType := range others {
+		parts = append(parts, fmt.Sprintf("[%s output]", mimeType))
+	}
+	return strings.Join(parts, "\n")
+}
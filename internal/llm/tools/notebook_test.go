@@ -0,0 +1,70 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/opencode-ai/opencode/internal/notebook"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotebookRead(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "analysis.ipynb")
+	require.NoError(t, os.WriteFile(path, []byte(`{
+ "cells": [
+  {"cell_type": "markdown", "metadata": {}, "source": ["# Analysis"]},
+  {"cell_type": "code", "execution_count": 2, "metadata": {}, "outputs": [
+    {"output_type": "stream", "name": "stderr", "text": "warning: old pandas\n"},
+    {"output_type": "display_data", "data": {"image/png": "iVBOR", "text/plain": ["<Figure size 640x480>"]}, "metadata": {}},
+    {"output_type": "error", "ename": "KeyError", "evalue": "'price'", "traceback": ["\u001b[0;31mKeyError\u001b[0m: 'price'"]}
+   ], "source": "df.plot()\ndf['price']"}
+ ],
+ "metadata": {},
+ "nbformat": 4,
+ "nbformat_minor": 4
+}`), 0o644))
+
+	tool := NewNotebookTool(nil, nil)
+	response, err := tool.Run(context.Background(), ToolCall{
+		Name:  NotebookToolName,
+		Input: `{"notebook_path": "` + path + `", "action": "read"}`,
+	})
+	require.NoError(t, err)
+	assert.False(t, response.IsError)
+	assert.Equal(t, `<notebook path="`+path+`" kernel="python3" cells="2">
+<cell index="0" type="markdown">
+# Analysis
+</cell>
+<cell index="1" type="code" execution_count="2">
+df.plot()
+df['price']
+<output type="stream">
+stderr:
+warning: old pandas
+</output>
+<output type="display_data">
+<Figure size 640x480>
+[image/png output]
+</output>
+<output type="error">
+KeyError: 'price'
+</output>
+</cell>
+</notebook>`, response.Content)
+	assert.False(t, getLastReadTime(path).IsZero())
+
+	response, err = tool.Run(context.Background(), ToolCall{
+		Name:  NotebookToolName,
+		Input: `{"notebook_path": "` + filepath.Join(filepath.Dir(path), "data.csv") + `", "action": "read"}`,
+	})
+	require.NoError(t, err)
+	assert.True(t, response.IsError)
+}
+
+func TestFormatNotebookCellOutputs(t *testing.T) {
+	cell := notebook.Cell{Type: notebook.CellCode, Source: "x = 1", ExecutionCount: 1}
+	assert.Equal(t, "<cell index=\"3\" type=\"code\" execution_count=\"1\">\n(no output)\n</cell>\n", formatNotebookCell(3, cell, false))
+}
@@ -0,0 +1,185 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/opencode-ai/opencode/internal/note"
+)
+
+type NotesParams struct {
+	Action  string `json:"action"`
+	Name    string `json:"name"`
+	Content string `json:"content"`
+}
+
+type NotesResponseMetadata struct {
+	Action string `json:"action"`
+	Name   string `json:"name,omitempty"`
+	Count  int    `json:"count,omitempty"`
+}
+
+type notesTool struct {
+	notes note.Service
+}
+
+const (
+	NotesToolName = "notes"
+
+	// maxNoteLength is the longest content a note may have
+	maxNoteLength = 20000
+	// maxNotes is the number of notes a session may have
+	maxNotes = 50
+
+	notesDescription = `Saves and reads named notes that are kept for the whole session, like your plan, what you found out so far and the state of your TODO list.
+
+WHEN TO USE THIS TOOL:
+- Use on long tasks to write down the plan, and update it as steps are done
+- Use to keep findings you will need later, like the cause of a bug, the files involved or the commands that build and test the project
+- Use after the conversation was summarized, or when you lost track, to list and read your notes
+
+HOW TO USE:
+- action "write" creates a note of the name, or replaces its content
+- action "append" adds the content as a new line to the end of a note, and creates it if it doesn't exist
+- action "read" returns the content of the note of the name
+- action "list" returns the names of all notes with their first line
+- action "delete" removes the note of the name
+- Names are short identifiers of letters, digits, dashes, underscores and dots, like "plan" or "findings"
+
+LIMITATIONS:
+- Notes belong to the session, other sessions and sub-agents don't see them
+- A note has at most %d characters, and a session at most %d notes
+- The user doesn't see the notes in the conversation, tell them what matters`
+)
+
+var noteNamePattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+func NewNotesTool(notes note.Service) BaseTool {
+	return &notesTool{notes: notes}
+}
+
+func (n *notesTool) Info() ToolInfo {
+	return ToolInfo{
+		Name:        NotesToolName,
+		Description: fmt.Sprintf(notesDescription, maxNoteLength, maxNotes),
+		Parameters: map[string]any{
+			"action": map[string]any{
+				"type":        "string",
+				"description": "What to do with the notes",
+				"enum":        []string{"write", "append", "read", "list", "delete"},
+			},
+			"name": map[string]any{
+				"type":        "string",
+				"description": "The name of the note, required for all actions but list",
+			},
+			"content": map[string]any{
+				"type":        "string",
+				"description": "The content to write or append",
+			},
+		},
+		Required: []string{"action"},
+	}
+}
+
+func (n *notesTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error) {
+	var params NotesParams
+	if err := json.Unmarshal([]byte(call.Input), &params); err != nil {
+		return NewTextErrorResponse(fmt.Sprintf("error parsing parameters: %s", err)), nil
+	}
+	sessionID, _ := GetContextValues(ctx)
+	if sessionID == "" {
+		return ToolResponse{}, errors.New("session ID is required for notes")
+	}
+	if params.Action != "list" && !noteNamePattern.MatchString(params.Name) {
+		return NewTextErrorResponse("name must be 1 to 64 letters, digits, dashes, underscores or dots"), nil
+	}
+	metadata := NotesResponseMetadata{Action: params.Action, Name: params.Name}
+
+	switch params.Action {
+	case "write", "append":
+		return n.write(ctx, sessionID, params, metadata)
+	case "read":
+		saved, err := n.notes.Get(ctx, sessionID, params.Name)
+		if errors.Is(err, note.ErrNotFound) {
+			return NewTextErrorResponse(fmt.Sprintf("there is no note named %q", params.Name)), nil
+		}
+		if err != nil {
+			return ToolResponse{}, fmt.Errorf("error reading note: %w", err)
+		}
+		return WithResponseMetadata(NewTextResponse(saved.Content), metadata), nil
+	case "list":
+		notes, err := n.notes.List(ctx, sessionID)
+		if err != nil {
+			return ToolResponse{}, fmt.Errorf("error listing notes: %w", err)
+		}
+		metadata.Count = len(notes)
+		return WithResponseMetadata(NewTextResponse(formatNoteList(notes)), metadata), nil
+	case "delete":
+		if _, err := n.notes.Get(ctx, sessionID, params.Name); errors.Is(err, note.ErrNotFound) {
+			return NewTextErrorResponse(fmt.Sprintf("there is no note named %q", params.Name)), nil
+		}
+		if err := n.notes.Delete(ctx, sessionID, params.Name); err != nil {
+			return ToolResponse{}, fmt.Errorf("error deleting note: %w", err)
+		}
+		return WithResponseMetadata(NewTextResponse(fmt.Sprintf("Deleted note %q", params.Name)), metadata), nil
+	}
+	return NewTextErrorResponse(fmt.Sprintf("unknown action %q, must be one of: write, append, read, list, delete", params.Action)), nil
+}
+
+func (n *notesTool) write(ctx context.Context, sessionID string, params NotesParams, metadata NotesResponseMetadata) (ToolResponse, error) {
+	if strings.TrimSpace(params.Content) == "" {
+		return NewTextErrorResponse("content is required"), nil
+	}
+	content := params.Content
+	saved, err := n.notes.Get(ctx, sessionID, params.Name)
+	exists := err == nil
+	if err != nil && !errors.Is(err, note.ErrNotFound) {
+		return ToolResponse{}, fmt.Errorf("error reading note: %w", err)
+	}
+	if params.Action == "append" && exists && saved.Content != "" {
+		content = strings.TrimSuffix(saved.Content, "\n") + "\n" + content
+	}
+	if len(content) > maxNoteLength {
+		return NewTextErrorResponse(fmt.Sprintf("the note would have %d characters, at most %d are allowed, shorten it or split it into several notes", len(content), maxNoteLength)), nil
+	}
+	if !exists {
+		notes, err := n.notes.List(ctx, sessionID)
+		if err != nil {
+			return ToolResponse{}, fmt.Errorf("error listing notes: %w", err)
+		}
+		if len(notes) >= maxNotes {
+			return NewTextErrorResponse(fmt.Sprintf("the session already has %d notes, delete or merge some first", maxNotes)), nil
+		}
+	}
+	if _, err := n.notes.Save(ctx, sessionID, params.Name, content); err != nil {
+		return ToolResponse{}, fmt.Errorf("error saving note: %w", err)
+	}
+	action := "Updated"
+	if !exists {
+		action = "Created"
+	}
+	return WithResponseMetadata(NewTextResponse(fmt.Sprintf("%s note %q (%d characters)", action, params.Name, len(content))), metadata), nil
+}
+
+// formatNoteList lists the notes with the time they were last changed and
+// their first line.
+func formatNoteList(notes []note.Note) string {
+	if len(notes) == 0 {
+		return "There are no notes in this session"
+	}
+	var b strings.Builder
+	for _, saved := range notes {
+		firstLine, _, _ := strings.Cut(strings.TrimSpace(saved.Content), "\n")
+		if runes := []rune(firstLine); len(runes) > 80 {
+			firstLine = string(runes[:77]) + "..."
+		}
+		updated := time.Unix(saved.UpdatedAt, 0).Format("15:04")
+		fmt.Fprintf(&b, "- %s (updated %s, %d characters): %s\n", saved.Name, updated, len(saved.Content), firstLine)
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
@@ -0,0 +1,191 @@
+package tools
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/opencode-ai/opencode/internal/config"
+)
+
+type OutputParams struct {
+	ID     string `json:"id"`
+	Offset int    `json:"offset"`
+	Limit  int    `json:"limit"`
+}
+
+type OutputResponseMetadata struct {
+	ID         string `json:"id"`
+	StartLine  int    `json:"start_line"`
+	EndLine    int    `json:"end_line"`
+	TotalLines int    `json:"total_lines"`
+}
+
+type outputTool struct{}
+
+const (
+	OutputToolName = "output"
+
+	// defaultOutputLimit is the number of lines read when no limit is given
+	defaultOutputLimit = 500
+	// storedOutputRetention is how long stored outputs are kept
+	storedOutputRetention = 24 * time.Hour
+
+	outputDescription = `Reads the full output of a tool call, when it was too long and truncated in the result.
+
+WHEN TO USE THIS TOOL:
+- Use when a truncated tool result says its full output is stored with an id, and you need the lines that were left out
+- Use to page through long outputs like build logs or test runs
+
+HOW TO USE:
+- Provide the id from the truncation note of the result
+- Optionally set offset, the line number to start reading from (0-based), and limit, the number of lines to read (default 500)
+- Lines are returned with line numbers, like the View tool
+
+LIMITATIONS:
+- Stored outputs are removed after a day
+- Results over %d characters end early, continue with a greater offset`
+)
+
+var storedOutputIDPattern = regexp.MustCompile(`^[0-9a-f]{12}$`)
+
+func NewOutputTool() BaseTool {
+	return &outputTool{}
+}
+
+func (o *outputTool) Info() ToolInfo {
+	return ToolInfo{
+		Name:        OutputToolName,
+		Description: fmt.Sprintf(outputDescription, MaxOutputLength),
+		Parameters: map[string]any{
+			"id": map[string]any{
+				"type":        "string",
+				"description": "The id of the stored output",
+			},
+			"offset": map[string]any{
+				"type":        "integer",
+				"description": "The line number to start reading from (0-based)",
+			},
+			"limit": map[string]any{
+				"type":        "integer",
+				"description": "The number of lines to read (defaults to 500)",
+			},
+		},
+		Required: []string{"id"},
+	}
+}
+
+func (o *outputTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error) {
+	var params OutputParams
+	if err := json.Unmarshal([]byte(call.Input), &params); err != nil {
+		return NewTextErrorResponse(fmt.Sprintf("error parsing parameters: %s", err)), nil
+	}
+	if params.ID == "" {
+		return NewTextErrorResponse("id is required"), nil
+	}
+	if params.Offset < 0 {
+		return NewTextErrorResponse("offset can't be negative"), nil
+	}
+	if params.Limit <= 0 {
+		params.Limit = defaultOutputLimit
+	}
+
+	content, metadata, err := readStoredOutput(storedOutputDir(), params.ID, params.Offset, params.Limit)
+	if err != nil {
+		return NewTextErrorResponse(err.Error()), nil
+	}
+	if metadata.StartLine > metadata.EndLine {
+		return NewTextErrorResponse(fmt.Sprintf("offset %d is beyond the end of the output, which has %d lines", params.Offset, metadata.TotalLines)), nil
+	}
+
+	output := "<output>\n" + content
+	if metadata.EndLine < metadata.TotalLines {
+		output += fmt.Sprintf("\n\n(Output has more lines. Use 'offset' parameter to read beyond line %d)", metadata.EndLine)
+	}
+	output += "\n</output>\n"
+	return WithResponseMetadata(NewTextResponse(output), metadata), nil
+}
+
+// storedOutputDir is where the full outputs of truncated tool results are
+// stored, or empty when there is no configuration.
+func storedOutputDir() string {
+	cfg := config.Get()
+	if cfg == nil || cfg.Data.Directory == "" {
+		return ""
+	}
+	return filepath.Join(cfg.Data.Directory, "outputs")
+}
+
+// storeOutput writes the output to dir and returns its id. Outputs older than
+// storedOutputRetention are removed.
+func storeOutput(dir, content string) (string, error) {
+	if dir == "" {
+		return "", errors.New("no output directory")
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	if entries, err := os.ReadDir(dir); err == nil {
+		for _, entry := range entries {
+			if info, err := entry.Info(); err == nil && time.Since(info.ModTime()) > storedOutputRetention {
+				os.Remove(filepath.Join(dir, entry.Name()))
+			}
+		}
+	}
+
+	b := make([]byte, 6)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	id := hex.EncodeToString(b)
+	if err := os.WriteFile(filepath.Join(dir, id+".txt"), []byte(content), 0o600); err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+// readStoredOutput returns the lines of a stored output from offset with line
+// numbers, ending early to stay under MaxOutputLength.
+func readStoredOutput(dir, id string, offset, limit int) (string, OutputResponseMetadata, error) {
+	metadata := OutputResponseMetadata{ID: id}
+	if !storedOutputIDPattern.MatchString(id) {
+		return "", metadata, fmt.Errorf("invalid output id: %s", id)
+	}
+	if dir == "" {
+		return "", metadata, errors.New("no stored outputs are available")
+	}
+	content, err := os.ReadFile(filepath.Join(dir, id+".txt"))
+	if errors.Is(err, os.ErrNotExist) {
+		return "", metadata, fmt.Errorf("no output stored with id %s, it may have expired", id)
+	}
+	if err != nil {
+		return "", metadata, fmt.Errorf("failed to read stored output: %w", err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(string(content), "\n"), "\n")
+	metadata.TotalLines = len(lines)
+	metadata.StartLine = offset + 1
+	end := min(offset+limit, len(lines))
+	size := 0
+	for i := offset; i < end; i++ {
+		size += len(lines[i]) + 8
+		if size > MaxOutputLength && i > offset {
+			end = i
+			break
+		}
+	}
+	if offset >= end {
+		metadata.EndLine = offset
+		return "", metadata, nil
+	}
+	metadata.EndLine = end
+	return addLineNumbers(strings.Join(lines[offset:end], "\n"), offset+1), metadata, nil
+}
@@ -0,0 +1,66 @@
+package tools
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStoredOutput(t *testing.T) {
+	dir := t.TempDir()
+	var lines []string
+	for i := 1; i <= 10; i++ {
+		lines = append(lines, fmt.Sprintf("line %d", i))
+	}
+	id, err := storeOutput(dir, strings.Join(lines, "\n")+"\n")
+	require.NoError(t, err)
+	assert.Regexp(t, storedOutputIDPattern, id)
+
+	content, metadata, err := readStoredOutput(dir, id, 3, 2)
+	require.NoError(t, err)
+	assert.Equal(t, "     4|line 4\n     5|line 5", content)
+	assert.Equal(t, OutputResponseMetadata{ID: id, StartLine: 4, EndLine: 5, TotalLines: 10}, metadata)
+
+	_, metadata, err = readStoredOutput(dir, id, 8, 100)
+	require.NoError(t, err)
+	assert.Equal(t, 10, metadata.EndLine)
+
+	_, metadata, err = readStoredOutput(dir, id, 10, 100)
+	require.NoError(t, err)
+	assert.Greater(t, metadata.StartLine, metadata.EndLine)
+
+	_, _, err = readStoredOutput(dir, "../../etc/passwd", 0, 10)
+	assert.ErrorContains(t, err, "invalid output id")
+	_, _, err = readStoredOutput(dir, "000000000000", 0, 10)
+	assert.ErrorContains(t, err, "no output stored")
+}
+
+func TestStoredOutputLimit(t *testing.T) {
+	dir := t.TempDir()
+	line := strings.Repeat("x", 1000)
+	id, err := storeOutput(dir, strings.Repeat(line+"\n", 100))
+	require.NoError(t, err)
+
+	content, metadata, err := readStoredOutput(dir, id, 0, 100)
+	require.NoError(t, err)
+	assert.LessOrEqual(t, len(content), MaxOutputLength)
+	assert.Less(t, metadata.EndLine, 100)
+}
+
+func TestStoreOutputRemovesExpired(t *testing.T) {
+	dir := t.TempDir()
+	expired := filepath.Join(dir, "0123456789ab.txt")
+	require.NoError(t, os.WriteFile(expired, []byte("old"), 0o600))
+	old := time.Now().Add(-2 * storedOutputRetention)
+	require.NoError(t, os.Chtimes(expired, old, old))
+
+	_, err := storeOutput(dir, "new")
+	require.NoError(t, err)
+	assert.NoFileExists(t, expired)
+}
@@ -186,102 +186,110 @@ func (p *patchTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error
 		return ToolResponse{}, fmt.Errorf("session ID and message ID are required for creating a patch")
 	}
 
-	// Request permission for all changes
+	if !requestCommitPermissions(p.permissions, PatchToolName, sessionID, commit) {
+		return ToolResponse{}, permission.ErrorPermissionDenied
+	}
+
+	changedFiles, totalAdditions, totalRemovals, err := writeCommit(ctx, p.files, sessionID, commit)
+	if err != nil {
+		return NewTextErrorResponse(fmt.Sprintf("failed to apply patch: %s", err)), nil
+	}
+
+	// Run LSP diagnostics on all changed files
+	for _, filePath := range changedFiles {
+		waitForLspDiagnostics(ctx, filePath, p.lspClients)
+	}
+
+	result := fmt.Sprintf("Patch applied successfully. %d files changed, %d additions, %d removals",
+		len(changedFiles), totalAdditions, totalRemovals)
+
+	diagnosticsText := ""
+	for _, filePath := range changedFiles {
+		diagnosticsText += getDiagnostics(filePath, p.lspClients)
+	}
+
+	if diagnosticsText != "" {
+		result += "\n\nDiagnostics:\n" + diagnosticsText
+	}
+
+	return WithResponseMetadata(
+		NewTextResponse(result),
+		PatchResponseMetadata{
+			FilesChanged: changedFiles,
+			Additions:    totalAdditions,
+			Removals:     totalRemovals,
+		}), nil
+}
+
+// requestCommitPermissions asks for the permission of each change of the
+// commit, and reports whether all of them were granted.
+func requestCommitPermissions(permissions permission.Service, toolName, sessionID string, commit diff.Commit) bool {
 	for path, change := range commit.Changes {
+		oldContent := ""
+		if change.OldContent != nil {
+			oldContent = *change.OldContent
+		}
+		newContent := ""
+		if change.NewContent != nil {
+			newContent = *change.NewContent
+		}
+		var action, description string
 		switch change.Type {
 		case diff.ActionAdd:
-			dir := filepath.Dir(path)
-			patchDiff, _, _ := diff.GenerateDiff("", *change.NewContent, path)
-			p := p.permissions.Request(
-				permission.CreatePermissionRequest{
-					SessionID:   sessionID,
-					Path:        dir,
-					ToolName:    PatchToolName,
-					Action:      "create",
-					Description: fmt.Sprintf("Create file %s", path),
-					Params: EditPermissionsParams{
-						FilePath: path,
-						Diff:     patchDiff,
-					},
-				},
-			)
-			if !p {
-				return ToolResponse{}, permission.ErrorPermissionDenied
-			}
+			action, description = "create", fmt.Sprintf("Create file %s", path)
 		case diff.ActionUpdate:
-			currentContent := ""
-			if change.OldContent != nil {
-				currentContent = *change.OldContent
-			}
-			newContent := ""
-			if change.NewContent != nil {
-				newContent = *change.NewContent
-			}
-			patchDiff, _, _ := diff.GenerateDiff(currentContent, newContent, path)
-			dir := filepath.Dir(path)
-			p := p.permissions.Request(
-				permission.CreatePermissionRequest{
-					SessionID:   sessionID,
-					Path:        dir,
-					ToolName:    PatchToolName,
-					Action:      "update",
-					Description: fmt.Sprintf("Update file %s", path),
-					Params: EditPermissionsParams{
-						FilePath: path,
-						Diff:     patchDiff,
-					},
-				},
-			)
-			if !p {
-				return ToolResponse{}, permission.ErrorPermissionDenied
+			action, description = "update", fmt.Sprintf("Update file %s", path)
+			if change.MovePath != nil {
+				description = fmt.Sprintf("Update file %s and move it to %s", path, *change.MovePath)
 			}
 		case diff.ActionDelete:
-			dir := filepath.Dir(path)
-			patchDiff, _, _ := diff.GenerateDiff(*change.OldContent, "", path)
-			p := p.permissions.Request(
-				permission.CreatePermissionRequest{
-					SessionID:   sessionID,
-					Path:        dir,
-					ToolName:    PatchToolName,
-					Action:      "delete",
-					Description: fmt.Sprintf("Delete file %s", path),
-					Params: EditPermissionsParams{
-						FilePath: path,
-						Diff:     patchDiff,
-					},
+			action, description = "delete", fmt.Sprintf("Delete file %s", path)
+		default:
+			continue
+		}
+		patchDiff, _, _ := diff.GenerateDiff(oldContent, newContent, path)
+		p := permissions.Request(
+			permission.CreatePermissionRequest{
+				SessionID:   sessionID,
+				Path:        filepath.Dir(path),
+				ToolName:    toolName,
+				Action:      action,
+				Description: description,
+				Params: EditPermissionsParams{
+					FilePath: path,
+					Diff:     patchDiff,
 				},
-			)
-			if !p {
-				return ToolResponse{}, permission.ErrorPermissionDenied
-			}
+			},
+		)
+		if !p {
+			return false
 		}
 	}
+	return true
+}
 
-	// Apply the changes to the filesystem
-	err = diff.ApplyCommit(commit, func(path string, content string) error {
-		absPath := path
-		if !filepath.IsAbs(absPath) {
-			wd := config.WorkingDirectory()
-			absPath = filepath.Join(wd, absPath)
+// writeCommit applies the changes of the commit to the files and records the
+// new versions in the file history. It returns the changed files and the
+// number of added and removed lines.
+func writeCommit(ctx context.Context, files history.Service, sessionID string, commit diff.Commit) ([]string, int, int, error) {
+	absPath := func(path string) string {
+		if !filepath.IsAbs(path) {
+			return filepath.Join(config.WorkingDirectory(), path)
 		}
-
+		return path
+	}
+	err := diff.ApplyCommit(commit, func(path string, content string) error {
+		path = absPath(path)
 		// Create parent directories if needed
-		dir := filepath.Dir(absPath)
-		if err := os.MkdirAll(dir, 0o755); err != nil {
-			return fmt.Errorf("failed to create parent directories for %s: %w", absPath, err)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return fmt.Errorf("failed to create parent directories for %s: %w", path, err)
 		}
-
-		return os.WriteFile(absPath, []byte(content), 0o644)
+		return os.WriteFile(path, []byte(content), 0o644)
 	}, func(path string) error {
-		absPath := path
-		if !filepath.IsAbs(absPath) {
-			wd := config.WorkingDirectory()
-			absPath = filepath.Join(wd, absPath)
-		}
-		return os.Remove(absPath)
+		return os.Remove(absPath(path))
 	})
 	if err != nil {
-		return NewTextErrorResponse(fmt.Sprintf("failed to apply patch: %s", err)), nil
+		return nil, 0, 0, err
 	}
 
 	// Update file history for all modified files
@@ -290,12 +298,11 @@ func (p *patchTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error
 	totalRemovals := 0
 
 	for path, change := range commit.Changes {
-		absPath := path
-		if !filepath.IsAbs(absPath) {
-			wd := config.WorkingDirectory()
-			absPath = filepath.Join(wd, absPath)
+		filePath := absPath(path)
+		if change.MovePath != nil {
+			filePath = absPath(*change.MovePath)
 		}
-		changedFiles = append(changedFiles, absPath)
+		changedFiles = append(changedFiles, filePath)
 
 		oldContent := ""
 		if change.OldContent != nil {
@@ -313,10 +320,15 @@ func (p *patchTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error
 		totalRemovals += removals
 
 		// Update history
-		file, err := p.files.GetByPathAndSession(ctx, absPath, sessionID)
-		if err != nil && change.Type != diff.ActionAdd {
+		file, err := files.GetByPathAndSession(ctx, filePath, sessionID)
+		if err != nil && change.Type == diff.ActionAdd {
+			// The added file didn't exist before
+			if _, err := files.CreateNew(ctx, sessionID, filePath); err != nil {
+				logging.Debug("Error creating file history", "error", err)
+			}
+		} else if err != nil {
 			// If not adding a file, create history entry for existing file
-			_, err = p.files.Create(ctx, sessionID, absPath, oldContent)
+			_, err = files.Create(ctx, sessionID, filePath, oldContent)
 			if err != nil {
 				logging.Debug("Error creating file history", "error", err)
 			}
@@ -324,7 +336,7 @@ func (p *patchTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error
 
 		if err == nil && change.Type != diff.ActionAdd && file.Content != oldContent {
 			// User manually changed content, store intermediate version
-			_, err = p.files.CreateVersion(ctx, sessionID, absPath, oldContent)
+			_, err = files.CreateVersion(ctx, sessionID, filePath, oldContent)
 			if err != nil {
 				logging.Debug("Error creating file history version", "error", err)
 			}
@@ -332,41 +344,17 @@ func (p *patchTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error
 
 		// Store new version
 		if change.Type == diff.ActionDelete {
-			_, err = p.files.CreateVersion(ctx, sessionID, absPath, "")
+			_, err = files.CreateVersion(ctx, sessionID, filePath, "")
 		} else {
-			_, err = p.files.CreateVersion(ctx, sessionID, absPath, newContent)
+			_, err = files.CreateVersion(ctx, sessionID, filePath, newContent)
 		}
 		if err != nil {
 			logging.Debug("Error creating file history version", "error", err)
 		}
 
 		// Record file operations
-		recordFileWrite(absPath)
-		recordFileRead(absPath)
+		recordFileWrite(filePath)
+		recordFileRead(filePath)
 	}
-
-	// Run LSP diagnostics on all changed files
-	for _, filePath := range changedFiles {
-		waitForLspDiagnostics(ctx, filePath, p.lspClients)
-	}
-
-	result := fmt.Sprintf("Patch applied successfully. %d files changed, %d additions, %d removals",
-		len(changedFiles), totalAdditions, totalRemovals)
-
-	diagnosticsText := ""
-	for _, filePath := range changedFiles {
-		diagnosticsText += getDiagnostics(filePath, p.lspClients)
-	}
-
-	if diagnosticsText != "" {
-		result += "\n\nDiagnostics:\n" + diagnosticsText
-	}
-
-	return WithResponseMetadata(
-		NewTextResponse(result),
-		PatchResponseMetadata{
-			FilesChanged: changedFiles,
-			Additions:    totalAdditions,
-			Removals:     totalRemovals,
-		}), nil
+	return changedFiles, totalAdditions, totalRemovals, nil
 }
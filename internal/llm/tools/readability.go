@@ -0,0 +1,139 @@
+package tools
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+
+	md "github.com/JohannesKaufmann/html-to-markdown"
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+)
+
+// readabilityBoilerplate are the elements of a page around its content.
+const readabilityBoilerplate = "script, style, noscript, template, iframe, svg, canvas, form, button, nav, aside, " +
+	"[role=navigation], [role=banner], [role=contentinfo], [role=complementary], [aria-hidden=true], [hidden]"
+
+// readabilityBoilerplateClass matches the class and id of navigation, ads and
+// similar blocks that don't use semantic elements.
+var readabilityBoilerplateClass = regexp.MustCompile(`(?i)(^|[\s_-])(nav|navbar|navigation|menu|sidebar|footer|cookies?|consent|banner|breadcrumbs?|share|social|comments?|advert|ads|promo|related|newsletter|skip)([\s_-]|$)`)
+
+// readableContent returns the main content of an HTML page without its
+// navigation, headers, footers and scripts, and the title of the page.
+func readableContent(page string) (*goquery.Selection, string, error) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(page))
+	if err != nil {
+		return nil, "", err
+	}
+	title := strings.TrimSpace(doc.Find("title").First().Text())
+
+	doc.Find(readabilityBoilerplate).Remove()
+	// The header of an article has its heading
+	doc.Find("header, footer").Each(func(_ int, s *goquery.Selection) {
+		if s.ParentsFiltered("main, article").Length() == 0 {
+			s.Remove()
+		}
+	})
+	doc.Find("[class], [id]").Each(func(_ int, s *goquery.Selection) {
+		if s.Is("html, body, main, article") {
+			return
+		}
+		class, _ := s.Attr("class")
+		id, _ := s.Attr("id")
+		if readabilityBoilerplateClass.MatchString(class) || readabilityBoilerplateClass.MatchString(id) {
+			s.Remove()
+		}
+	})
+
+	body := doc.Find("body")
+	if body.Length() == 0 {
+		body = doc.Selection
+	}
+
+	// Pages that mark their content are trusted, the others are scored by the
+	// paragraphs of every block
+	var content *goquery.Selection
+	best := 0
+	doc.Find("main, article, [role=main]").Each(func(_ int, s *goquery.Selection) {
+		if n := len(strings.TrimSpace(s.Text())); n > best {
+			content, best = s, n
+		}
+	})
+	if content == nil {
+		type candidate struct {
+			s     *goquery.Selection
+			score int
+		}
+		candidates := map[*html.Node]*candidate{}
+		var order []*candidate
+		doc.Find("p, pre, li, td").Each(func(_ int, p *goquery.Selection) {
+			n := len(strings.TrimSpace(p.Text()))
+			if n < 25 {
+				return
+			}
+			// The grandparent gets half of the score, for content split into
+			// sections
+			for depth, parent := range []*goquery.Selection{p.Parent(), p.Parent().Parent()} {
+				if parent.Length() == 0 {
+					continue
+				}
+				c, ok := candidates[parent.Get(0)]
+				if !ok {
+					c = &candidate{s: parent}
+					candidates[parent.Get(0)] = c
+					order = append(order, c)
+				}
+				c.score += n >> depth
+			}
+		})
+		for _, c := range order {
+			if c.score > best {
+				content, best = c.s, c.score
+			}
+		}
+	}
+	// A block with a fraction of the text of the page likely missed content
+	if content == nil || best < len(strings.TrimSpace(body.Text()))/3 {
+		content = body
+	}
+	return content, title, nil
+}
+
+// readableMarkdown converts the main content of an HTML page to markdown,
+// with the links resolved against the URL of the page.
+func readableMarkdown(page, pageURL string) (string, error) {
+	content, title, err := readableContent(page)
+	if err != nil {
+		return "", err
+	}
+	if base, err := url.Parse(pageURL); err == nil {
+		content.Find("a[href], img[src]").Each(func(_ int, s *goquery.Selection) {
+			attr := "href"
+			if s.Is("img") {
+				attr = "src"
+			}
+			link, _ := s.Attr(attr)
+			if ref, err := url.Parse(link); err == nil {
+				s.SetAttr(attr, base.ResolveReference(ref).String())
+			}
+		})
+	}
+	markdown := strings.TrimSpace(md.NewConverter("", true, nil).Convert(content))
+	if title != "" && !strings.HasPrefix(markdown, "# ") {
+		markdown = "# " + title + "\n\n" + markdown
+	}
+	return markdown, nil
+}
+
+// readableText returns the text of the main content of an HTML page.
+func readableText(page string) (string, error) {
+	content, title, err := readableContent(page)
+	if err != nil {
+		return "", err
+	}
+	text := strings.Join(strings.Fields(content.Text()), " ")
+	if title != "" && !strings.HasPrefix(text, title) {
+		text = title + "\n\n" + text
+	}
+	return text, nil
+}
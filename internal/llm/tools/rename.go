@@ -0,0 +1,304 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+
+	"github.com/opencode-ai/opencode/internal/config"
+	"github.com/opencode-ai/opencode/internal/diff"
+	"github.com/opencode-ai/opencode/internal/history"
+	"github.com/opencode-ai/opencode/internal/lsp"
+	"github.com/opencode-ai/opencode/internal/lsp/protocol"
+	"github.com/opencode-ai/opencode/internal/lsp/util"
+	"github.com/opencode-ai/opencode/internal/permission"
+)
+
+type RenameParams struct {
+	FilePath string `json:"file_path"`
+	Line     int    `json:"line"`
+	Symbol   string `json:"symbol"`
+	NewName  string `json:"new_name"`
+}
+
+type renameTool struct {
+	lspClients  map[string]*lsp.Client
+	permissions permission.Service
+	files       history.Service
+}
+
+const (
+	RenameToolName    = "rename"
+	renameDescription = `Renames a symbol, like a function, type, method, field or variable, in the whole workspace using the language server of its file. All references are updated, including the ones in other files and packages.
+
+WHEN TO USE THIS TOOL:
+- Use to rename a symbol that is used in several places, instead of searching and editing every call site
+- Prefer it over text replacement, the language server only changes references to this symbol and not other symbols of the same name
+
+HOW TO USE:
+- Provide the file_path and the line (1-based) of an occurrence of the symbol, like its declaration or a call
+- Provide the symbol as it is written on that line, and the new_name
+- The first occurrence of the symbol on the line is renamed
+- The result lists the changed files, and the diagnostics found after the rename
+
+LIMITATIONS:
+- Needs a configured LSP server for the language of the file
+- Renames that would also rename or create files, like the module files of some languages, are not supported
+- The language server may refuse names that are not valid or would conflict with other symbols`
+)
+
+func NewRenameTool(lspClients map[string]*lsp.Client, permissions permission.Service, files history.Service) BaseTool {
+	return &renameTool{
+		lspClients:  lspClients,
+		permissions: permissions,
+		files:       files,
+	}
+}
+
+func (r *renameTool) Info() ToolInfo {
+	return ToolInfo{
+		Name:        RenameToolName,
+		Description: renameDescription,
+		Parameters: map[string]any{
+			"file_path": map[string]any{
+				"type":        "string",
+				"description": "The path of a file with an occurrence of the symbol",
+			},
+			"line": map[string]any{
+				"type":        "integer",
+				"description": "The line number of the occurrence (1-based)",
+			},
+			"symbol": map[string]any{
+				"type":        "string",
+				"description": "The current name of the symbol, as written on the line",
+			},
+			"new_name": map[string]any{
+				"type":        "string",
+				"description": "The new name of the symbol",
+			},
+		},
+		Required: []string{"file_path", "line", "symbol", "new_name"},
+	}
+}
+
+func (r *renameTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error) {
+	var params RenameParams
+	if err := json.Unmarshal([]byte(call.Input), &params); err != nil {
+		return NewTextErrorResponse(fmt.Sprintf("error parsing parameters: %s", err)), nil
+	}
+	if params.FilePath == "" || params.Symbol == "" || params.NewName == "" {
+		return NewTextErrorResponse("file_path, symbol and new_name are required"), nil
+	}
+	if params.Symbol == params.NewName {
+		return NewTextErrorResponse("new_name is the current name of the symbol"), nil
+	}
+	if len(r.lspClients) == 0 {
+		return NewTextErrorResponse("no LSP server is configured, renaming needs one for the language of the file"), nil
+	}
+
+	rootDir := config.WorkingDirectory()
+	filePath := params.FilePath
+	if !filepath.IsAbs(filePath) {
+		filePath = filepath.Join(rootDir, filePath)
+	}
+	content, err := os.ReadFile(filePath)
+	if os.IsNotExist(err) {
+		return NewTextErrorResponse(fmt.Sprintf("file not found: %s", filePath)), nil
+	}
+	if err != nil {
+		return ToolResponse{}, fmt.Errorf("failed to read file: %w", err)
+	}
+	position, err := symbolPosition(string(content), params.Line, params.Symbol)
+	if err != nil {
+		return NewTextErrorResponse(err.Error()), nil
+	}
+
+	fileEdits, err := r.rename(ctx, filePath, position, params.NewName)
+	if err != nil {
+		return NewTextErrorResponse(err.Error()), nil
+	}
+	files, err := planRename(fileEdits)
+	if err != nil {
+		return NewTextErrorResponse(err.Error()), nil
+	}
+
+	sessionID, messageID := GetContextValues(ctx)
+	if sessionID == "" || messageID == "" {
+		return ToolResponse{}, fmt.Errorf("session ID and message ID are required for editing files")
+	}
+
+	metadata := MultiEditResponseMetadata{}
+	var summary []string
+	permissionPath := rootDir
+	for _, file := range files {
+		fileDiff, additions, removals := diff.GenerateDiff(file.oldContent, file.newContent, file.path)
+		metadata.Files = append(metadata.Files, MultiEditFileDiff{
+			FilePath:  file.path,
+			Diff:      fileDiff,
+			Additions: additions,
+			Removals:  removals,
+		})
+		metadata.Additions += additions
+		metadata.Removals += removals
+		summary = append(summary, fmt.Sprintf("%s (%d)", file.path, len(fileEdits[file.path])))
+		if !strings.HasPrefix(file.path, rootDir) {
+			permissionPath = filepath.Dir(file.path)
+		}
+	}
+
+	p := r.permissions.Request(
+		permission.CreatePermissionRequest{
+			SessionID:   sessionID,
+			Path:        permissionPath,
+			ToolName:    RenameToolName,
+			Action:      "write",
+			Description: fmt.Sprintf("Rename %s to %s in %d files:\n- %s", params.Symbol, params.NewName, len(files), strings.Join(summary, "\n- ")),
+			Params: MultiEditPermissionsParams{
+				Files: metadata.Files,
+			},
+		},
+	)
+	if !p {
+		return ToolResponse{}, permission.ErrorPermissionDenied
+	}
+
+	if err := applyMultiEdit(files); err != nil {
+		return NewTextErrorResponse(err.Error()), nil
+	}
+
+	var formatNotes strings.Builder
+	for _, file := range files {
+		var formatNote string
+		file.newContent, formatNote = formatWrittenFile(ctx, file.path, file.newContent)
+		formatNotes.WriteString(formatNote)
+		recordEditHistory(ctx, r.files, sessionID, file)
+		recordFileWrite(file.path)
+		recordFileRead(file.path)
+		for _, client := range r.lspClients {
+			if client.IsFileOpen(file.path) {
+				client.NotifyChange(ctx, file.path)
+			}
+		}
+	}
+
+	var result strings.Builder
+	fmt.Fprintf(&result, "<result>\nRenamed %s to %s in %d files, with the number of changed occurrences:\n- %s\n</result>\n",
+		params.Symbol, params.NewName, len(files), strings.Join(summary, "\n- "))
+	result.WriteString(formatNotes.String())
+	waitForLspDiagnostics(ctx, filePath, r.lspClients)
+	result.WriteString(getDiagnostics(filePath, r.lspClients))
+	return WithResponseMetadata(NewTextResponse(result.String()), metadata), nil
+}
+
+// rename asks the LSP servers for the edits renaming the symbol at the
+// position, the first one that returns edits is used.
+func (r *renameTool) rename(ctx context.Context, filePath string, position protocol.Position, newName string) (map[string][]protocol.TextEdit, error) {
+	var errs []string
+	for _, name := range slices.Sorted(maps.Keys(r.lspClients)) {
+		client := r.lspClients[name]
+		if err := client.OpenFile(ctx, filePath); err != nil {
+			continue
+		}
+		edit, err := client.Rename(ctx, protocol.RenameParams{
+			TextDocument: protocol.TextDocumentIdentifier{
+				URI: protocol.DocumentUri("file://" + filePath),
+			},
+			Position: position,
+			NewName:  newName,
+		})
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %s", name, err))
+			continue
+		}
+		fileEdits, err := util.FileEdits(edit)
+		if err != nil {
+			return nil, fmt.Errorf("%s can't be renamed with this tool, %s", filepath.Base(filePath), err)
+		}
+		if len(fileEdits) > 0 {
+			return fileEdits, nil
+		}
+	}
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("the rename failed:\n%s", strings.Join(errs, "\n"))
+	}
+	return nil, fmt.Errorf("no LSP server could rename the symbol in %s", filepath.Base(filePath))
+}
+
+// planRename applies the edits to the contents of their files, in the order
+// of their paths. No file is changed.
+func planRename(fileEdits map[string][]protocol.TextEdit) ([]*multiEditFile, error) {
+	var files []*multiEditFile
+	for _, path := range slices.Sorted(maps.Keys(fileEdits)) {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		newContent, err := util.ApplyTextEdits(string(content), fileEdits[path])
+		if err != nil {
+			return nil, fmt.Errorf("failed to apply the rename to %s: %w", path, err)
+		}
+		if newContent != string(content) {
+			files = append(files, &multiEditFile{path: path, oldContent: string(content), newContent: newContent})
+		}
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("the rename doesn't change any file")
+	}
+	return files, nil
+}
+
+// symbolPosition returns the LSP position of the first occurrence of the
+// symbol on the line (1-based) as a whole word. LSP counts the characters of
+// a line in UTF-16 code units.
+func symbolPosition(content string, line int, symbol string) (protocol.Position, error) {
+	lines := strings.Split(content, "\n")
+	if line < 1 || line > len(lines) {
+		return protocol.Position{}, fmt.Errorf("line %d is out of range, the file has %d lines", line, len(lines))
+	}
+	text := strings.TrimSuffix(lines[line-1], "\r")
+	for offset := 0; ; {
+		i := strings.Index(text[offset:], symbol)
+		if i == -1 {
+			break
+		}
+		start, end := offset+i, offset+i+len(symbol)
+		if !identifierRuneBefore(text, start) && !identifierRuneAt(text, end) {
+			return protocol.Position{
+				Line:      uint32(line - 1),
+				Character: uint32(len(utf16.Encode([]rune(text[:start])))),
+			}, nil
+		}
+		offset = start + 1
+	}
+	return protocol.Position{}, fmt.Errorf("%s is not on line %d, which is:\n%s", symbol, line, text)
+}
+
+// identifierRuneBefore reports if the rune before the byte offset belongs
+// to an identifier.
+func identifierRuneBefore(text string, i int) bool {
+	if i == 0 {
+		return false
+	}
+	r := []rune(text[:i])
+	return isIdentifierRune(r[len(r)-1])
+}
+
+// identifierRuneAt reports if the rune at the byte offset belongs to an
+// identifier.
+func identifierRuneAt(text string, i int) bool {
+	if i >= len(text) {
+		return false
+	}
+	return isIdentifierRune([]rune(text[i:])[0])
+}
+
+func isIdentifierRune(r rune) bool {
+	return r == '_' || r == '$' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
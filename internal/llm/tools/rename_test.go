@@ -0,0 +1,27 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/opencode-ai/opencode/internal/lsp/protocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSymbolPosition(t *testing.T) {
+	content := "package main\n\n// größe is the size\nfunc größe(größen int) int { return größen }\n"
+
+	position, err := symbolPosition(content, 4, "größe")
+	require.NoError(t, err)
+	assert.Equal(t, protocol.Position{Line: 3, Character: 5}, position)
+
+	// größen contains the symbol but is another identifier
+	position, err = symbolPosition(content, 4, "größen")
+	require.NoError(t, err)
+	assert.Equal(t, protocol.Position{Line: 3, Character: 11}, position)
+
+	_, err = symbolPosition(content, 1, "größe")
+	assert.ErrorContains(t, err, "not on line 1")
+	_, err = symbolPosition(content, 9, "größe")
+	assert.ErrorContains(t, err, "out of range")
+}
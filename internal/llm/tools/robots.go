@@ -0,0 +1,95 @@
+package tools
+
+import (
+	"bufio"
+	"regexp"
+	"strings"
+)
+
+// robotsUserAgent is the product token matched against the user-agent lines
+// of robots.txt files.
+const robotsUserAgent = "opencode"
+
+type robotsRule struct {
+	pattern *regexp.Regexp
+	length  int
+	allow   bool
+}
+
+// robotsRules are the rules of a robots.txt that apply to opencode.
+type robotsRules []robotsRule
+
+// parseRobots returns the rules of the group for opencode, or of the group for
+// all crawlers when there is none.
+func parseRobots(robots string) robotsRules {
+	var own, all robotsRules
+	var hasOwn bool
+	var agents []string
+	inRules := false
+	scanner := bufio.NewScanner(strings.NewReader(robots))
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key, value = strings.ToLower(strings.TrimSpace(key)), strings.TrimSpace(value)
+		switch key {
+		case "user-agent":
+			// A user-agent line after rules starts a new group
+			if inRules {
+				agents, inRules = nil, false
+			}
+			agent := strings.ToLower(value)
+			if agent == robotsUserAgent {
+				hasOwn = true
+			}
+			agents = append(agents, agent)
+		case "allow", "disallow":
+			inRules = true
+			if value == "" {
+				continue
+			}
+			rule := robotsRule{pattern: robotsPattern(value), length: len(value), allow: key == "allow"}
+			for _, agent := range agents {
+				switch {
+				case agent == "*":
+					all = append(all, rule)
+				case agent == robotsUserAgent:
+					own = append(own, rule)
+				}
+			}
+		}
+	}
+	if hasOwn {
+		return own
+	}
+	return all
+}
+
+// robotsPattern compiles a path pattern, where * matches any characters and a
+// trailing $ the end of the path.
+func robotsPattern(path string) *regexp.Regexp {
+	anchored := strings.HasSuffix(path, "$")
+	path = strings.TrimSuffix(path, "$")
+	expr := "^" + strings.ReplaceAll(regexp.QuoteMeta(path), `\*`, ".*")
+	if anchored {
+		expr += "$"
+	}
+	return regexp.MustCompile(expr)
+}
+
+// allowed reports whether the path may be fetched. The longest matching rule
+// decides, allow wins over an equally long disallow.
+func (r robotsRules) allowed(path string) bool {
+	allowed, length := true, -1
+	for _, rule := range r {
+		if !rule.pattern.MatchString(path) {
+			continue
+		}
+		if rule.length > length || (rule.length == length && rule.allow) {
+			allowed, length = rule.allow, rule.length
+		}
+	}
+	return allowed
+}
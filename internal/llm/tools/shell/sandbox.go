@@ -0,0 +1,75 @@
+package shell
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+
+	"github.com/opencode-ai/opencode/internal/config"
+)
+
+// sandboxCommand returns the command starting the login shell in the sandbox.
+// Only the working directory, the writable paths of the sandbox and dir, where
+// the output of the commands is written, can be written to.
+func sandboxCommand(sandbox config.Sandbox, shellPath, workingDir, dir, name string) (string, []string) {
+	switch sandbox.Backend {
+	case config.SandboxBubblewrap:
+		args := []string{
+			"--ro-bind", "/", "/",
+			"--dev", "/dev",
+			"--proc", "/proc",
+			"--tmpfs", "/tmp",
+			"--bind", dir, dir,
+			"--bind", workingDir, workingDir,
+		}
+		for _, path := range sandbox.WritablePaths {
+			args = append(args, "--bind-try", path, path)
+		}
+		if !sandbox.Network {
+			args = append(args, "--unshare-net")
+		}
+		return "bwrap", append(args, "--die-with-parent", "--new-session", "--chdir", workingDir, "--", shellPath, "-l")
+	case config.SandboxFirejail:
+		args := []string{
+			"--quiet", "--noprofile",
+			"--read-only=/",
+			"--read-write=" + dir,
+			"--read-write=" + workingDir,
+		}
+		for _, path := range sandbox.WritablePaths {
+			args = append(args, "--read-write="+path)
+		}
+		if !sandbox.Network {
+			args = append(args, "--net=none")
+		}
+		return "firejail", append(args, "--", shellPath, "-l")
+	case config.SandboxDocker:
+		args := []string{
+			"run", "--rm", "--interactive", "--init",
+			"--name", name,
+			"--user", fmt.Sprintf("%d:%d", os.Getuid(), os.Getgid()),
+			"--volume", dir + ":" + dir,
+			"--volume", workingDir + ":" + workingDir,
+		}
+		for _, path := range sandbox.WritablePaths {
+			args = append(args, "--volume", path+":"+path)
+		}
+		if !sandbox.Network {
+			args = append(args, "--network", "none")
+		}
+		// The shell of the host may not exist in the image
+		return "docker", append(args, "--workdir", workingDir, "--env", "GIT_EDITOR=true", sandbox.Image, "sh", "-l")
+	}
+	return shellPath, []string{"-l"}
+}
+
+// stopSandbox stops the sandbox of the shell, the commands running in it
+// can't be told apart from the shell from outside of the sandbox.
+func (s *PersistentShell) stopSandbox() {
+	if s.cmd.Process != nil {
+		s.cmd.Process.Kill()
+	}
+	if s.sandbox.Backend == config.SandboxDocker {
+		exec.Command("docker", "rm", "--force", s.name).Run()
+	}
+}
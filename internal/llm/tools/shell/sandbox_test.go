@@ -0,0 +1,65 @@
+package shell
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/opencode-ai/opencode/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSandboxCommand(t *testing.T) {
+	path, args := sandboxCommand(config.Sandbox{}, "/bin/bash", "/repo", "/tmp/out", "")
+	assert.Equal(t, "/bin/bash", path)
+	assert.Equal(t, []string{"-l"}, args)
+
+	path, args = sandboxCommand(config.Sandbox{
+		Backend:       config.SandboxBubblewrap,
+		WritablePaths: []string{"/home/u/.cache"},
+	}, "/bin/bash", "/repo", "/tmp/out", "")
+	assert.Equal(t, "bwrap", path)
+	assert.Equal(t, []string{
+		"--ro-bind", "/", "/", "--dev", "/dev", "--proc", "/proc", "--tmpfs", "/tmp",
+		"--bind", "/tmp/out", "/tmp/out", "--bind", "/repo", "/repo",
+		"--bind-try", "/home/u/.cache", "/home/u/.cache",
+		"--unshare-net", "--die-with-parent", "--new-session", "--chdir", "/repo", "--", "/bin/bash", "-l",
+	}, args)
+
+	path, args = sandboxCommand(config.Sandbox{Backend: config.SandboxFirejail, Network: true}, "/bin/zsh", "/repo", "/tmp/out", "")
+	assert.Equal(t, "firejail", path)
+	assert.Equal(t, []string{"--quiet", "--noprofile", "--read-only=/", "--read-write=/tmp/out", "--read-write=/repo", "--", "/bin/zsh", "-l"}, args)
+
+	path, args = sandboxCommand(config.Sandbox{Backend: config.SandboxDocker, Image: "node:22"}, "/bin/bash", "/repo", "/tmp/out", "opencode-shell-1")
+	assert.Equal(t, "docker", path)
+	assert.Equal(t, []string{
+		"run", "--rm", "--interactive", "--init", "--name", "opencode-shell-1",
+		"--user", fmt.Sprintf("%d:%d", os.Getuid(), os.Getgid()),
+		"--volume", "/tmp/out:/tmp/out", "--volume", "/repo:/repo", "--network", "none",
+		"--workdir", "/repo", "--env", "GIT_EDITOR=true", "node:22", "sh", "-l",
+	}, args)
+}
+
+func TestSessionShell(t *testing.T) {
+	dir := t.TempDir()
+	shell, err := GetSessionShell("session", "a", dir, config.Sandbox{})
+	require.NoError(t, err)
+	_, _, exitCode, _, err := shell.Exec(context.Background(), "cd / && export OPENCODE_TEST=1", 5000)
+	require.NoError(t, err)
+	require.Equal(t, 0, exitCode)
+
+	stdout, _, _, _, err := shell.Exec(context.Background(), "pwd; echo $OPENCODE_TEST", 5000)
+	require.NoError(t, err)
+	assert.Equal(t, "/\n1\n", stdout)
+
+	other, err := GetSessionShell("session", "b", dir, config.Sandbox{})
+	require.NoError(t, err)
+	stdout, _, _, _, err = other.Exec(context.Background(), "pwd; echo $OPENCODE_TEST", 5000)
+	require.NoError(t, err)
+	assert.Equal(t, dir+"\n\n", stdout)
+
+	CloseSessionShells("session")
+	assert.False(t, shell.isAlive.Load())
+}
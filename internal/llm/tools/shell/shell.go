@@ -4,22 +4,33 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
+
+	"github.com/opencode-ai/opencode/internal/config"
 )
 
 type PersistentShell struct {
-	cmd          *exec.Cmd
-	stdin        *os.File
-	isAlive      bool
+	cmd   *exec.Cmd
+	stdin *os.File
+	// isAlive is cleared by the goroutine waiting for the shell to exit
+	isAlive      atomic.Bool
 	cwd          string
 	mu           sync.Mutex
 	commandQueue chan *commandExecution
+
+	// sandbox isolates the shell from the host when it has a backend, its
+	// commands write their output to tempDir
+	sandbox config.Sandbox
+	tempDir string
+	name    string
 }
 
 type commandExecution struct {
@@ -27,6 +38,7 @@ type commandExecution struct {
 	timeout    time.Duration
 	resultChan chan commandResult
 	ctx        context.Context
+	output     io.Writer
 }
 
 type commandResult struct {
@@ -37,32 +49,96 @@ type commandResult struct {
 	err         error
 }
 
+// MaxSessionShells is the number of named shells an agent session can have
+// open at the same time.
+const MaxSessionShells = 5
+
 var (
 	shellInstance     *PersistentShell
 	shellInstanceOnce sync.Once
+
+	// sessionShells are the named shells of the agent sessions, by session ID
+	// and shell name.
+	sessionShells   = make(map[string]map[string]*PersistentShell)
+	sessionShellsMu sync.Mutex
 )
 
-func GetPersistentShell(workingDir string) *PersistentShell {
+func GetPersistentShell(workingDir string, sandbox config.Sandbox) *PersistentShell {
 	shellInstanceOnce.Do(func() {
-		shellInstance = newPersistentShell(workingDir)
+		shellInstance = newPersistentShell(workingDir, sandbox)
 	})
 
 	if shellInstance == nil {
-		shellInstance = newPersistentShell(workingDir)
-	} else if !shellInstance.isAlive {
-		shellInstance = newPersistentShell(shellInstance.cwd)
+		shellInstance = newPersistentShell(workingDir, sandbox)
+	} else if !shellInstance.isAlive.Load() {
+		shellInstance = newPersistentShell(shellInstance.cwd, sandbox)
 	}
 
 	return shellInstance
 }
 
-func newPersistentShell(cwd string) *PersistentShell {
+// GetSessionShell returns the named shell of an agent session, starting it in
+// workingDir on first use. Unlike the shared shell, the named shells of a
+// session are independent of each other and closed by CloseSessionShells.
+func GetSessionShell(sessionID, name, workingDir string, sandbox config.Sandbox) (*PersistentShell, error) {
+	sessionShellsMu.Lock()
+	defer sessionShellsMu.Unlock()
+
+	shells := sessionShells[sessionID]
+	if shell, ok := shells[name]; ok && shell.isAlive.Load() {
+		return shell, nil
+	}
+	if shells == nil {
+		shells = make(map[string]*PersistentShell)
+		sessionShells[sessionID] = shells
+	}
+	if _, ok := shells[name]; !ok && len(shells) >= MaxSessionShells {
+		return nil, fmt.Errorf("too many shell sessions, at most %d can be open at the same time", MaxSessionShells)
+	}
+
+	shell := newPersistentShell(workingDir, sandbox)
+	if shell == nil {
+		return nil, errors.New("failed to start shell")
+	}
+	shells[name] = shell
+	return shell, nil
+}
+
+// CloseSessionShells closes the named shells of an agent session.
+func CloseSessionShells(sessionID string) {
+	sessionShellsMu.Lock()
+	shells := sessionShells[sessionID]
+	delete(sessionShells, sessionID)
+	sessionShellsMu.Unlock()
+
+	for _, shell := range shells {
+		shell.Close()
+	}
+}
+
+func newPersistentShell(cwd string, sandbox config.Sandbox) *PersistentShell {
 	shellPath := os.Getenv("SHELL")
 	if shellPath == "" {
 		shellPath = "/bin/bash"
 	}
 
+	tempDir := os.TempDir()
+	var name string
+	if sandbox.Backend != "" {
+		// The output files must be writable inside of the sandbox
+		dir, err := os.MkdirTemp("", "opencode-shell-")
+		if err != nil {
+			return nil
+		}
+		tempDir = dir
+		name = filepath.Base(dir)
+	}
+
 	cmd := exec.Command(shellPath, "-l")
+	if sandbox.Backend != "" {
+		path, args := sandboxCommand(sandbox, shellPath, cwd, tempDir, name)
+		cmd = exec.Command(path, args...)
+	}
 	cmd.Dir = cwd
 
 	stdinPipe, err := cmd.StdinPipe()
@@ -74,22 +150,28 @@ func newPersistentShell(cwd string) *PersistentShell {
 
 	err = cmd.Start()
 	if err != nil {
+		if sandbox.Backend != "" {
+			os.RemoveAll(tempDir)
+		}
 		return nil
 	}
 
 	shell := &PersistentShell{
 		cmd:          cmd,
 		stdin:        stdinPipe.(*os.File),
-		isAlive:      true,
 		cwd:          cwd,
 		commandQueue: make(chan *commandExecution, 10),
+		sandbox:      sandbox,
+		tempDir:      tempDir,
+		name:         name,
 	}
+	shell.isAlive.Store(true)
 
 	go func() {
 		defer func() {
 			if r := recover(); r != nil {
 				fmt.Fprintf(os.Stderr, "Panic in shell command processor: %v\n", r)
-				shell.isAlive = false
+				shell.isAlive.Store(false)
 				close(shell.commandQueue)
 			}
 		}()
@@ -101,8 +183,14 @@ func newPersistentShell(cwd string) *PersistentShell {
 		if err != nil {
 			// Log the error if needed
 		}
-		shell.isAlive = false
+		shell.isAlive.Store(false)
 		close(shell.commandQueue)
+		if sandbox.Backend != "" {
+			// Wait for the command reading its output
+			shell.mu.Lock()
+			os.RemoveAll(tempDir)
+			shell.mu.Unlock()
+		}
 	}()
 
 	return shell
@@ -110,16 +198,16 @@ func newPersistentShell(cwd string) *PersistentShell {
 
 func (s *PersistentShell) processCommands() {
 	for cmd := range s.commandQueue {
-		result := s.execCommand(cmd.command, cmd.timeout, cmd.ctx)
+		result := s.execCommand(cmd.command, cmd.timeout, cmd.ctx, cmd.output)
 		cmd.resultChan <- result
 	}
 }
 
-func (s *PersistentShell) execCommand(command string, timeout time.Duration, ctx context.Context) commandResult {
+func (s *PersistentShell) execCommand(command string, timeout time.Duration, ctx context.Context, output io.Writer) commandResult {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	if !s.isAlive {
+	if !s.isAlive.Load() {
 		return commandResult{
 			stderr:   "Shell is not alive",
 			exitCode: 1,
@@ -127,7 +215,7 @@ func (s *PersistentShell) execCommand(command string, timeout time.Duration, ctx
 		}
 	}
 
-	tempDir := os.TempDir()
+	tempDir := s.tempDir
 	stdoutFile := filepath.Join(tempDir, fmt.Sprintf("opencode-stdout-%d", time.Now().UnixNano()))
 	stderrFile := filepath.Join(tempDir, fmt.Sprintf("opencode-stderr-%d", time.Now().UnixNano()))
 	statusFile := filepath.Join(tempDir, fmt.Sprintf("opencode-status-%d", time.Now().UnixNano()))
@@ -165,18 +253,28 @@ echo $EXEC_EXIT_CODE > %s
 	interrupted := false
 
 	startTime := time.Now()
+	followers := []*fileFollower{{path: stdoutFile}, {path: stderrFile}}
+	follow := func() {
+		if output == nil {
+			return
+		}
+		for _, f := range followers {
+			f.copyTo(output)
+		}
+	}
 
 	done := make(chan bool)
 	go func() {
 		for {
 			select {
 			case <-ctx.Done():
-				s.killChildren()
+				s.interrupt()
 				interrupted = true
 				done <- true
 				return
 
 			case <-time.After(10 * time.Millisecond):
+				follow()
 				if fileExists(statusFile) && fileSize(statusFile) > 0 {
 					done <- true
 					return
@@ -185,7 +283,7 @@ echo $EXEC_EXIT_CODE > %s
 				if timeout > 0 {
 					elapsed := time.Since(startTime)
 					if elapsed > timeout {
-						s.killChildren()
+						s.interrupt()
 						interrupted = true
 						done <- true
 						return
@@ -196,6 +294,7 @@ echo $EXEC_EXIT_CODE > %s
 	}()
 
 	<-done
+	follow()
 
 	stdout := readFileOrEmpty(stdoutFile)
 	stderr := readFileOrEmpty(stderrFile)
@@ -222,6 +321,16 @@ echo $EXEC_EXIT_CODE > %s
 	}
 }
 
+// interrupt stops the running command. Sandboxed shells are stopped with it,
+// the next command starts a new one.
+func (s *PersistentShell) interrupt() {
+	if s.sandbox.Backend != "" {
+		s.stopSandbox()
+		return
+	}
+	s.killChildren()
+}
+
 func (s *PersistentShell) killChildren() {
 	if s.cmd == nil || s.cmd.Process == nil {
 		return
@@ -248,7 +357,14 @@ func (s *PersistentShell) killChildren() {
 }
 
 func (s *PersistentShell) Exec(ctx context.Context, command string, timeoutMs int) (string, string, int, bool, error) {
-	if !s.isAlive {
+	return s.ExecStream(ctx, command, timeoutMs, nil)
+}
+
+// ExecStream is Exec that also writes the stdout and stderr of the command to
+// output while it runs. Output written to both at about the same time is
+// interleaved by the polling, not in the order it was written.
+func (s *PersistentShell) ExecStream(ctx context.Context, command string, timeoutMs int, output io.Writer) (string, string, int, bool, error) {
+	if !s.isAlive.Load() {
 		return "", "Shell is not alive", 1, false, errors.New("shell is not alive")
 	}
 
@@ -260,6 +376,7 @@ func (s *PersistentShell) Exec(ctx context.Context, command string, timeoutMs in
 		timeout:    timeout,
 		resultChan: resultChan,
 		ctx:        ctx,
+		output:     output,
 	}
 
 	result := <-resultChan
@@ -270,14 +387,19 @@ func (s *PersistentShell) Close() {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	if !s.isAlive {
+	if !s.isAlive.Load() {
 		return
 	}
 
 	s.stdin.Write([]byte("exit\n"))
 
-	s.cmd.Process.Kill()
-	s.isAlive = false
+	if s.sandbox.Backend != "" {
+		s.stopSandbox()
+	} else {
+		s.killChildren()
+		s.cmd.Process.Kill()
+	}
+	s.isAlive.Store(false)
 }
 
 func shellQuote(s string) string {
@@ -292,6 +414,25 @@ func readFileOrEmpty(path string) string {
 	return string(content)
 }
 
+// fileFollower copies what was appended to a file since the last copy.
+type fileFollower struct {
+	path   string
+	offset int64
+}
+
+func (f *fileFollower) copyTo(w io.Writer) {
+	file, err := os.Open(f.path)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+	if _, err := file.Seek(f.offset, io.SeekStart); err != nil {
+		return
+	}
+	n, _ := io.Copy(w, file)
+	f.offset += n
+}
+
 func fileExists(path string) bool {
 	_, err := os.Stat(path)
 	return err == nil
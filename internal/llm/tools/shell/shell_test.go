@@ -0,0 +1,53 @@
+package shell
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/opencode-ai/opencode/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// syncBuffer is a buffer that is read while the shell writes to it.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestExecStream(t *testing.T) {
+	sh := newPersistentShell(t.TempDir(), config.Sandbox{})
+	require.NotNil(t, sh)
+	defer sh.Close()
+
+	var output syncBuffer
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		stdout, stderr, exitCode, _, err := sh.ExecStream(context.Background(), "echo building; sleep 1; echo failed >&2; (exit 2)", 10000, &output)
+		assert.NoError(t, err)
+		assert.Equal(t, "building\n", stdout)
+		assert.Equal(t, "failed\n", stderr)
+		assert.Equal(t, 2, exitCode)
+	}()
+
+	// The output is written before the command finished
+	assert.Eventually(t, func() bool { return output.String() == "building\n" }, 5*time.Second, 10*time.Millisecond)
+	<-done
+	assert.Equal(t, "building\nfailed\n", output.String())
+}
@@ -0,0 +1,500 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/opencode-ai/opencode/internal/config"
+	"github.com/opencode-ai/opencode/internal/lsp"
+	"github.com/opencode-ai/opencode/internal/lsp/protocol"
+)
+
+type SymbolsParams struct {
+	Path   string `json:"path"`
+	Action string `json:"action"`
+	Name   string `json:"name"`
+}
+
+type SymbolsResponseMetadata struct {
+	Action  string `json:"action"`
+	Symbols int    `json:"symbols"`
+}
+
+// codeSymbol is a declaration of a file, with the lines it spans including its
+// doc comment.
+type codeSymbol struct {
+	name string
+	kind string
+	// container is the type of a method or the symbol containing a nested
+	// symbol
+	container string
+	depth     int
+	file      string
+	startLine int
+	endLine   int
+}
+
+func (s codeSymbol) qualifiedName() string {
+	if s.container == "" {
+		return s.name
+	}
+	return s.container + "." + s.name
+}
+
+// matches reports if name is the name of the symbol, or its name qualified by
+// its container like Type.Method.
+func (s codeSymbol) matches(name string) bool {
+	return s.name == name || s.qualifiedName() == name
+}
+
+type symbolsTool struct {
+	lspClients map[string]*lsp.Client
+}
+
+const (
+	SymbolsToolName = "symbols"
+
+	// maxSymbolMatches is the number of definitions listed by the find action
+	maxSymbolMatches = 100
+
+	symbolsDescription = `Navigates the structure of source code: lists the declarations of a file, finds where a symbol is defined and extracts the source of a single symbol.
+
+WHEN TO USE THIS TOOL:
+- Use to get an outline of a large file before reading parts of it
+- Use to find the file and lines where a function, method or type is defined
+- Use to read one function or type instead of viewing the whole file
+
+HOW TO USE:
+- Set action to "list" with a file path to list its symbols with their kinds and line ranges
+- Set action to "find" with a name and a file or directory path to find the definitions of the name, the directory defaults to the working directory
+- Set action to "extract" with a file path and a name to get the source of the symbol with line numbers, like the View tool
+- Methods can be named with their type, like Server.Start
+
+LIMITATIONS:
+- Go files are parsed directly, other languages need a configured LSP server for them
+- In directories other languages are searched through the workspace symbols of the LSP servers
+- Files that don't parse can't be navigated, use the View tool instead`
+)
+
+func NewSymbolsTool(lspClients map[string]*lsp.Client) BaseTool {
+	return &symbolsTool{
+		lspClients: lspClients,
+	}
+}
+
+func (s *symbolsTool) Info() ToolInfo {
+	return ToolInfo{
+		Name:        SymbolsToolName,
+		Description: symbolsDescription,
+		Parameters: map[string]any{
+			"path": map[string]any{
+				"type":        "string",
+				"description": "The file, or for find the file or directory, to search",
+			},
+			"action": map[string]any{
+				"type":        "string",
+				"description": "What to do: list, find or extract",
+				"enum":        []string{"list", "find", "extract"},
+			},
+			"name": map[string]any{
+				"type":        "string",
+				"description": "The name of the symbol to find or extract",
+			},
+		},
+		Required: []string{"action"},
+	}
+}
+
+func (s *symbolsTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error) {
+	var params SymbolsParams
+	if err := json.Unmarshal([]byte(call.Input), &params); err != nil {
+		return NewTextErrorResponse(fmt.Sprintf("error parsing parameters: %s", err)), nil
+	}
+	if params.Action != "list" && params.Action != "find" && params.Action != "extract" {
+		return NewTextErrorResponse("action must be list, find or extract"), nil
+	}
+	if params.Action != "list" && params.Name == "" {
+		return NewTextErrorResponse(fmt.Sprintf("name is required to %s a symbol", params.Action)), nil
+	}
+
+	rootDir := config.WorkingDirectory()
+	path := params.Path
+	if path == "" {
+		if params.Action != "find" {
+			return NewTextErrorResponse("path is required"), nil
+		}
+		path = rootDir
+	} else if !filepath.IsAbs(path) {
+		path = filepath.Join(rootDir, path)
+	}
+	fileInfo, err := os.Stat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return NewTextErrorResponse(fmt.Sprintf("Path not found: %s", path)), nil
+		}
+		return ToolResponse{}, fmt.Errorf("error accessing path: %w", err)
+	}
+	if fileInfo.IsDir() && params.Action != "find" {
+		return NewTextErrorResponse(fmt.Sprintf("Path is a directory, not a file: %s", path)), nil
+	}
+
+	var symbols []codeSymbol
+	if fileInfo.IsDir() {
+		symbols, err = s.findInDir(ctx, path, params.Name)
+	} else {
+		symbols, err = s.fileSymbols(ctx, path)
+	}
+	if err != nil {
+		return NewTextErrorResponse(err.Error()), nil
+	}
+	if params.Action != "list" {
+		var matches []codeSymbol
+		for _, symbol := range symbols {
+			if symbol.matches(params.Name) {
+				matches = append(matches, symbol)
+			}
+		}
+		symbols = matches
+	}
+
+	metadata := SymbolsResponseMetadata{
+		Action:  params.Action,
+		Symbols: len(symbols),
+	}
+	if len(symbols) == 0 {
+		if params.Action == "list" {
+			return WithResponseMetadata(NewTextResponse("No symbols found"), metadata), nil
+		}
+		return WithResponseMetadata(NewTextResponse(fmt.Sprintf("No symbol named %s found", params.Name)), metadata), nil
+	}
+
+	var output string
+	switch params.Action {
+	case "list":
+		output = formatSymbolOutline(symbols)
+	case "find":
+		output = formatSymbolLocations(rootDir, symbols)
+	case "extract":
+		output, err = extractSymbols(path, symbols)
+		if err != nil {
+			return ToolResponse{}, fmt.Errorf("error reading file: %w", err)
+		}
+		recordFileRead(path)
+	}
+	return WithResponseMetadata(NewTextResponse(output), metadata), nil
+}
+
+// fileSymbols parses Go files and asks the LSP servers for the symbols of
+// other files.
+func (s *symbolsTool) fileSymbols(ctx context.Context, path string) ([]codeSymbol, error) {
+	if filepath.Ext(path) == ".go" {
+		return goFileSymbols(path)
+	}
+	if len(s.lspClients) == 0 {
+		return nil, fmt.Errorf("no LSP server is configured for %s, only Go files can be parsed without one", filepath.Base(path))
+	}
+	for _, client := range s.lspClients {
+		if err := client.OpenFile(ctx, path); err != nil {
+			continue
+		}
+		result, err := client.DocumentSymbol(ctx, protocol.DocumentSymbolParams{
+			TextDocument: protocol.TextDocumentIdentifier{
+				URI: protocol.DocumentUri("file://" + path),
+			},
+		})
+		if err != nil {
+			continue
+		}
+		results, err := result.Results()
+		if err != nil || len(results) == 0 {
+			continue
+		}
+		var symbols []codeSymbol
+		for _, r := range results {
+			symbols = appendLspSymbol(symbols, path, r, "", 0)
+		}
+		return symbols, nil
+	}
+	return nil, fmt.Errorf("no LSP server returned the symbols of %s", filepath.Base(path))
+}
+
+// findInDir parses the Go files of the directory and asks the LSP servers for
+// the workspace symbols of the name for other languages.
+func (s *symbolsTool) findInDir(ctx context.Context, dir, name string) ([]codeSymbol, error) {
+	var symbols []codeSymbol
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if rel, relErr := filepath.Rel(dir, path); relErr == nil && rel != "." && skipHidden(rel) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() || filepath.Ext(path) != ".go" || len(symbols) >= maxSymbolMatches {
+			return nil
+		}
+		fileSymbols, err := goFileSymbols(path)
+		if err != nil {
+			return nil
+		}
+		for _, symbol := range fileSymbols {
+			if symbol.matches(name) {
+				symbols = append(symbols, symbol)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error walking directory: %w", err)
+	}
+
+	query := name
+	if i := strings.LastIndex(query, "."); i != -1 {
+		query = query[i+1:]
+	}
+	for _, client := range s.lspClients {
+		result, err := client.Symbol(ctx, protocol.WorkspaceSymbolParams{Query: query})
+		if err != nil {
+			continue
+		}
+		results, err := result.Results()
+		if err != nil {
+			continue
+		}
+		for _, r := range results {
+			location := r.GetLocation()
+			path := location.URI.Path()
+			if filepath.Ext(path) == ".go" || !strings.HasPrefix(path, dir+string(filepath.Separator)) {
+				continue
+			}
+			symbol := codeSymbol{
+				name:      r.GetName(),
+				kind:      "symbol",
+				file:      path,
+				startLine: int(location.Range.Start.Line) + 1,
+				endLine:   int(location.Range.End.Line) + 1,
+			}
+			switch r := r.(type) {
+			case *protocol.SymbolInformation:
+				symbol.kind, symbol.container = lspSymbolKind(r.Kind), r.ContainerName
+			case *protocol.WorkspaceSymbol:
+				symbol.kind, symbol.container = lspSymbolKind(r.Kind), r.ContainerName
+			}
+			symbols = append(symbols, symbol)
+		}
+	}
+	if len(symbols) > maxSymbolMatches {
+		symbols = symbols[:maxSymbolMatches]
+	}
+	return symbols, nil
+}
+
+// goFileSymbols returns the top level declarations and the methods of a Go
+// file.
+func goFileSymbols(path string) ([]codeSymbol, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, path, nil, parser.ParseComments|parser.SkipObjectResolution)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", filepath.Base(path), err)
+	}
+
+	var symbols []codeSymbol
+	add := func(name, kind, container string, doc *ast.CommentGroup, node ast.Node) {
+		start := node.Pos()
+		if doc != nil {
+			start = doc.Pos()
+		}
+		symbols = append(symbols, codeSymbol{
+			name:      name,
+			kind:      kind,
+			container: container,
+			file:      path,
+			startLine: fset.Position(start).Line,
+			endLine:   fset.Position(node.End()).Line,
+		})
+	}
+	for _, decl := range file.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			if decl.Recv == nil || len(decl.Recv.List) == 0 {
+				add(decl.Name.Name, "function", "", decl.Doc, decl)
+				continue
+			}
+			add(decl.Name.Name, "method", goReceiverType(decl.Recv.List[0].Type), decl.Doc, decl)
+		case *ast.GenDecl:
+			for _, spec := range decl.Specs {
+				// The doc comment of an ungrouped declaration is on the GenDecl
+				var doc *ast.CommentGroup
+				var node ast.Node = spec
+				if !decl.Lparen.IsValid() {
+					doc, node = decl.Doc, decl
+				}
+				switch spec := spec.(type) {
+				case *ast.TypeSpec:
+					if spec.Doc != nil {
+						doc = spec.Doc
+					}
+					kind := "type"
+					switch spec.Type.(type) {
+					case *ast.StructType:
+						kind = "struct"
+					case *ast.InterfaceType:
+						kind = "interface"
+					}
+					add(spec.Name.Name, kind, "", doc, node)
+				case *ast.ValueSpec:
+					if spec.Doc != nil {
+						doc = spec.Doc
+					}
+					kind := "variable"
+					if decl.Tok == token.CONST {
+						kind = "constant"
+					}
+					for _, name := range spec.Names {
+						if name.Name != "_" {
+							add(name.Name, kind, "", doc, node)
+						}
+					}
+				}
+			}
+		}
+	}
+	return symbols, nil
+}
+
+// goReceiverType returns the name of the type of a method receiver, without
+// pointer and type parameters.
+func goReceiverType(expr ast.Expr) string {
+	for {
+		switch e := expr.(type) {
+		case *ast.StarExpr:
+			expr = e.X
+		case *ast.IndexExpr:
+			expr = e.X
+		case *ast.IndexListExpr:
+			expr = e.X
+		case *ast.ParenExpr:
+			expr = e.X
+		case *ast.Ident:
+			return e.Name
+		default:
+			return ""
+		}
+	}
+}
+
+func appendLspSymbol(symbols []codeSymbol, path string, result protocol.DocumentSymbolResult, container string, depth int) []codeSymbol {
+	switch r := result.(type) {
+	case *protocol.DocumentSymbol:
+		symbols = append(symbols, codeSymbol{
+			name:      r.Name,
+			kind:      lspSymbolKind(r.Kind),
+			container: container,
+			depth:     depth,
+			file:      path,
+			startLine: int(r.Range.Start.Line) + 1,
+			endLine:   int(r.Range.End.Line) + 1,
+		})
+		for i := range r.Children {
+			symbols = appendLspSymbol(symbols, path, &r.Children[i], r.Name, depth+1)
+		}
+	case *protocol.SymbolInformation:
+		symbols = append(symbols, codeSymbol{
+			name:      r.Name,
+			kind:      lspSymbolKind(r.Kind),
+			container: r.ContainerName,
+			depth:     depth,
+			file:      path,
+			startLine: int(r.Location.Range.Start.Line) + 1,
+			endLine:   int(r.Location.Range.End.Line) + 1,
+		})
+	}
+	return symbols
+}
+
+var lspSymbolKinds = map[protocol.SymbolKind]string{
+	protocol.File:          "file",
+	protocol.Module:        "module",
+	protocol.Namespace:     "namespace",
+	protocol.Package:       "package",
+	protocol.Class:         "class",
+	protocol.Method:        "method",
+	protocol.Property:      "property",
+	protocol.Field:         "field",
+	protocol.Constructor:   "constructor",
+	protocol.Enum:          "enum",
+	protocol.Interface:     "interface",
+	protocol.Function:      "function",
+	protocol.Variable:      "variable",
+	protocol.Constant:      "constant",
+	protocol.EnumMember:    "enum member",
+	protocol.Struct:        "struct",
+	protocol.Event:         "event",
+	protocol.Operator:      "operator",
+	protocol.TypeParameter: "type parameter",
+}
+
+func lspSymbolKind(kind protocol.SymbolKind) string {
+	if name, ok := lspSymbolKinds[kind]; ok {
+		return name
+	}
+	return "symbol"
+}
+
+func formatSymbolOutline(symbols []codeSymbol) string {
+	var output strings.Builder
+	for _, symbol := range symbols {
+		name := symbol.name
+		if symbol.depth == 0 {
+			name = symbol.qualifiedName()
+		}
+		fmt.Fprintf(&output, "%s%s %s (lines %d-%d)\n", strings.Repeat("  ", symbol.depth), symbol.kind, name, symbol.startLine, symbol.endLine)
+	}
+	return output.String()
+}
+
+func formatSymbolLocations(rootDir string, symbols []codeSymbol) string {
+	var output strings.Builder
+	for _, symbol := range symbols {
+		path := symbol.file
+		if rel, err := filepath.Rel(rootDir, path); err == nil && !strings.HasPrefix(rel, "..") {
+			path = rel
+		}
+		fmt.Fprintf(&output, "%s:%d-%d: %s %s\n", path, symbol.startLine, symbol.endLine, symbol.kind, symbol.qualifiedName())
+	}
+	if len(symbols) == maxSymbolMatches {
+		fmt.Fprintf(&output, "\n(Results are limited to %d definitions, use a more specific path)\n", maxSymbolMatches)
+	}
+	return output.String()
+}
+
+// extractSymbols returns the lines of the symbols of a file with line
+// numbers.
+func extractSymbols(path string, symbols []codeSymbol) (string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	lines := strings.Split(string(content), "\n")
+	var output strings.Builder
+	for _, symbol := range symbols {
+		start := max(symbol.startLine, 1)
+		end := min(symbol.endLine, len(lines))
+		if start > end {
+			continue
+		}
+		fmt.Fprintf(&output, "<symbol name=%q kind=%q lines=\"%d-%d\">\n", symbol.qualifiedName(), symbol.kind, start, end)
+		output.WriteString(addLineNumbers(strings.Join(lines[start-1:end], "\n"), start))
+		output.WriteString("\n</symbol>\n")
+	}
+	return output.String(), nil
+}
@@ -0,0 +1,88 @@
+package tools
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const symbolsTestSource = `package shop
+
+import "errors"
+
+// ErrEmpty is returned for empty carts.
+var ErrEmpty = errors.New("empty cart")
+
+const (
+	MaxItems = 10
+	minItems = 1
+)
+
+// Cart holds the items of a customer.
+type Cart struct {
+	Items []string
+}
+
+type Store interface {
+	Save(*Cart) error
+}
+
+// Add adds an item.
+func (c *Cart) Add(item string) {
+	c.Items = append(c.Items, item)
+}
+
+func New() *Cart {
+	return &Cart{}
+}
+`
+
+func TestGoFileSymbols(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "shop.go")
+	require.NoError(t, os.WriteFile(path, []byte(symbolsTestSource), 0o644))
+
+	symbols, err := goFileSymbols(path)
+	require.NoError(t, err)
+	assert.Equal(t, "variable ErrEmpty (lines 5-6)\n"+
+		"constant MaxItems (lines 9-9)\n"+
+		"constant minItems (lines 10-10)\n"+
+		"struct Cart (lines 13-16)\n"+
+		"interface Store (lines 18-20)\n"+
+		"method Cart.Add (lines 22-25)\n"+
+		"function New (lines 27-29)\n", formatSymbolOutline(symbols))
+
+	var matches []codeSymbol
+	for _, symbol := range symbols {
+		if symbol.matches("Cart.Add") {
+			matches = append(matches, symbol)
+		}
+	}
+	require.Len(t, matches, 1)
+	assert.True(t, matches[0].matches("Add"))
+
+	output, err := extractSymbols(path, matches)
+	require.NoError(t, err)
+	assert.Equal(t, `<symbol name="Cart.Add" kind="method" lines="22-25">
+    22|// Add adds an item.
+    23|func (c *Cart) Add(item string) {
+    24|	c.Items = append(c.Items, item)
+    25|}
+</symbol>
+`, output)
+
+	broken := filepath.Join(t.TempDir(), "broken.go")
+	require.NoError(t, os.WriteFile(broken, []byte("package shop\n\nfunc {"), 0o644))
+	_, err = goFileSymbols(broken)
+	assert.Error(t, err)
+}
+
+func TestFormatSymbolLocations(t *testing.T) {
+	symbols := []codeSymbol{
+		{name: "Add", kind: "method", container: "Cart", file: "/repo/shop/cart.go", startLine: 22, endLine: 25},
+		{name: "add", kind: "function", file: "/other/add.py", startLine: 1, endLine: 3},
+	}
+	assert.Equal(t, "shop/cart.go:22-25: method Cart.Add\n/other/add.py:1-3: function add\n", formatSymbolLocations("/repo", symbols))
+}
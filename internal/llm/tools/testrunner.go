@@ -0,0 +1,713 @@
+package tools
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/opencode-ai/opencode/internal/config"
+	"github.com/opencode-ai/opencode/internal/permission"
+)
+
+type TestParams struct {
+	Framework string `json:"framework,omitempty"`
+	Path      string `json:"path,omitempty"`
+	Filter    string `json:"filter,omitempty"`
+	Timeout   int    `json:"timeout,omitempty"`
+}
+
+type TestPermissionsParams struct {
+	Framework string `json:"framework"`
+	Command   string `json:"command"`
+}
+
+// TestFailure is a failed test, or a build error that kept tests from
+// running.
+type TestFailure struct {
+	// Suite is the package, file or crate of the test
+	Suite   string `json:"suite,omitempty"`
+	Test    string `json:"test,omitempty"`
+	File    string `json:"file,omitempty"`
+	Line    int    `json:"line,omitempty"`
+	Message string `json:"message"`
+}
+
+type TestResponseMetadata struct {
+	Framework string        `json:"framework"`
+	Command   string        `json:"command"`
+	Passed    int           `json:"passed"`
+	Failed    int           `json:"failed"`
+	Failures  []TestFailure `json:"failures,omitempty"`
+}
+
+type testReport struct {
+	passed   int
+	failed   int
+	failures []TestFailure
+}
+
+// testFramework runs the tests of a project and parses the failures from the
+// output of its runner.
+type testFramework struct {
+	name    string
+	command func(workingDir string, params TestParams) []string
+	parse   func(workingDir, stdout, stderr string) testReport
+}
+
+type testTool struct {
+	permissions permission.Service
+}
+
+const (
+	TestToolName = "test"
+
+	defaultTestTimeout = 5 * 60 * 1000 // 5 minutes in milliseconds
+
+	testToolDescription = `Runs the tests of the project and returns the failed tests with their file, line and message.
+
+WHEN TO USE THIS TOOL:
+- Use instead of running tests through the bash tool
+- Use after changing code to check that the tests still pass
+- Use to run a single failing test while fixing it
+
+HOW TO USE:
+- The test framework is detected from the project: go test, pytest, jest or cargo test
+- Set framework to one of go, pytest, jest or cargo when the detection picks the wrong one
+- Optionally limit the run to a path: a package or file for go, a file or directory for pytest and jest, a crate directory for cargo
+- Optionally set filter to the name or pattern of the tests to run
+- Optionally set a timeout in milliseconds (default: 300000, max: 600000)
+
+LIMITATIONS:
+- Only the failures are returned, not the output of passing tests
+- Build errors are reported as failures of the build
+- Running tests needs the permission of the user, as they run code of the project`
+)
+
+var testFrameworks = map[string]testFramework{
+	"go": {
+		name: "go",
+		command: func(workingDir string, params TestParams) []string {
+			args := []string{"go", "test", "-json"}
+			if params.Filter != "" {
+				args = append(args, "-run", params.Filter)
+			}
+			return append(args, goTestTarget(workingDir, params.Path))
+		},
+		parse: parseGoTestOutput,
+	},
+	"pytest": {
+		name: "pytest",
+		command: func(workingDir string, params TestParams) []string {
+			python := "python3"
+			if _, err := exec.LookPath(python); err != nil {
+				python = "python"
+			}
+			args := []string{python, "-m", "pytest", "-q", "-rfE", "--tb=line", "-p", "no:cacheprovider"}
+			if params.Filter != "" {
+				args = append(args, "-k", params.Filter)
+			}
+			if params.Path != "" {
+				args = append(args, params.Path)
+			}
+			return args
+		},
+		parse: parsePytestOutput,
+	},
+	"jest": {
+		name: "jest",
+		command: func(workingDir string, params TestParams) []string {
+			args := []string{"npx", "--no-install", "jest", "--ci", "--json", "--testLocationInResults"}
+			if params.Filter != "" {
+				args = append(args, "-t", params.Filter)
+			}
+			if params.Path != "" {
+				args = append(args, params.Path)
+			}
+			return args
+		},
+		parse: parseJestOutput,
+	},
+	"cargo": {
+		name: "cargo",
+		command: func(workingDir string, params TestParams) []string {
+			args := []string{"cargo", "test", "--no-fail-fast"}
+			if params.Path != "" {
+				args = append(args, "--manifest-path", filepath.Join(params.Path, "Cargo.toml"))
+			}
+			if params.Filter != "" {
+				args = append(args, params.Filter)
+			}
+			return args
+		},
+		parse: parseCargoTestOutput,
+	},
+}
+
+func NewTestTool(permission permission.Service) BaseTool {
+	return &testTool{
+		permissions: permission,
+	}
+}
+
+func (t *testTool) Info() ToolInfo {
+	return ToolInfo{
+		Name:        TestToolName,
+		Description: testToolDescription,
+		Parameters: map[string]any{
+			"framework": map[string]any{
+				"type":        "string",
+				"description": "The test framework, detected from the project when not set",
+				"enum":        []string{"go", "pytest", "jest", "cargo"},
+			},
+			"path": map[string]any{
+				"type":        "string",
+				"description": "Optional package, file or directory of the tests to run",
+			},
+			"filter": map[string]any{
+				"type":        "string",
+				"description": "Optional name or pattern of the tests to run",
+			},
+			"timeout": map[string]any{
+				"type":        "number",
+				"description": "Optional timeout in milliseconds (max 600000)",
+			},
+		},
+		Required: []string{},
+	}
+}
+
+func (t *testTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error) {
+	var params TestParams
+	if err := json.Unmarshal([]byte(call.Input), &params); err != nil {
+		return NewTextErrorResponse("Failed to parse test parameters: " + err.Error()), nil
+	}
+
+	if params.Timeout > MaxTimeout {
+		params.Timeout = MaxTimeout
+	} else if params.Timeout <= 0 {
+		params.Timeout = defaultTestTimeout
+	}
+
+	workingDir := config.WorkingDirectory()
+	if params.Path != "" {
+		if err := gitValidatePath(workingDir, params.Path); err != nil {
+			return NewTextErrorResponse(err.Error()), nil
+		}
+	}
+	name := params.Framework
+	if name == "" {
+		name = detectTestFramework(workingDir, params.Path)
+		if name == "" {
+			return NewTextErrorResponse("No test framework found in the working directory, set framework to one of: go, pytest, jest, cargo"), nil
+		}
+	}
+	framework, ok := testFrameworks[name]
+	if !ok {
+		return NewTextErrorResponse(fmt.Sprintf("Unknown framework %q, must be one of: go, pytest, jest, cargo", name)), nil
+	}
+	args := framework.command(workingDir, params)
+	command := strings.Join(args, " ")
+
+	sessionID, messageID := GetContextValues(ctx)
+	if sessionID == "" || messageID == "" {
+		return ToolResponse{}, fmt.Errorf("session ID and message ID are required for running tests")
+	}
+	p := t.permissions.Request(
+		permission.CreatePermissionRequest{
+			SessionID:   sessionID,
+			Path:        workingDir,
+			ToolName:    TestToolName,
+			Action:      framework.name,
+			Description: fmt.Sprintf("Run the tests with `%s`", command),
+			Params: TestPermissionsParams{
+				Framework: framework.name,
+				Command:   command,
+			},
+		},
+	)
+	if !p {
+		return ToolResponse{}, permission.ErrorPermissionDenied
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(params.Timeout)*time.Millisecond)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Dir = workingDir
+	cmd.Env = append(os.Environ(), "CI=1", "NO_COLOR=1", "FORCE_COLOR=0", "CARGO_TERM_COLOR=never", "RUST_BACKTRACE=0")
+	var stdout, stderr bytes.Buffer
+	progress := GetProgressWriter(ctx)
+	cmd.Stdout, cmd.Stderr = io.MultiWriter(&stdout, progress), io.MultiWriter(&stderr, progress)
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		return NewTextErrorResponse(fmt.Sprintf("Failed to run %s: %s", command, err)), nil
+	}
+
+	report := framework.parse(workingDir, stdout.String(), stderr.String())
+	metadata := TestResponseMetadata{
+		Framework: framework.name,
+		Command:   command,
+		Passed:    report.passed,
+		Failed:    report.failed,
+		Failures:  report.failures,
+	}
+
+	var output strings.Builder
+	fmt.Fprintf(&output, "%s: %d passed, %d failed", command, report.passed, report.failed)
+	if ctx.Err() != nil {
+		fmt.Fprintf(&output, "\nThe tests were stopped after the timeout of %dms", params.Timeout)
+	}
+	for _, failure := range report.failures {
+		output.WriteString("\n\n" + formatTestFailure(failure))
+	}
+	if err != nil && len(report.failures) == 0 {
+		// The runner failed without a failure we understand, e.g. a missing
+		// dependency or a usage error
+		raw := strings.TrimSpace(stderr.String() + "\n" + stdout.String())
+		fmt.Fprintf(&output, "\n\nExit code %d\n%s", exitErr.ExitCode(), raw)
+	}
+	return WithResponseMetadata(NewTextResponse(truncateOutput(output.String())), metadata), nil
+}
+
+func formatTestFailure(failure TestFailure) string {
+	var b strings.Builder
+	b.WriteString("FAIL")
+	if failure.Test != "" {
+		b.WriteString(" " + failure.Test)
+	}
+	if failure.Suite != "" {
+		fmt.Fprintf(&b, " (%s)", failure.Suite)
+	}
+	if failure.File != "" {
+		fmt.Fprintf(&b, "\n%s:%d", failure.File, failure.Line)
+	}
+	if failure.Message != "" {
+		b.WriteString("\n" + failure.Message)
+	}
+	return b.String()
+}
+
+// detectTestFramework finds the test framework from the file the tests are
+// limited to, or from the project files in the working directory.
+func detectTestFramework(workingDir, path string) string {
+	switch filepath.Ext(path) {
+	case ".go":
+		return "go"
+	case ".py":
+		return "pytest"
+	case ".js", ".jsx", ".ts", ".tsx", ".mjs", ".cjs":
+		return "jest"
+	case ".rs":
+		return "cargo"
+	}
+
+	exists := func(name string) bool {
+		_, err := os.Stat(filepath.Join(workingDir, name))
+		return err == nil
+	}
+	contains := func(name, text string) bool {
+		data, err := os.ReadFile(filepath.Join(workingDir, name))
+		return err == nil && bytes.Contains(data, []byte(text))
+	}
+	switch {
+	case exists("go.mod"):
+		return "go"
+	case exists("Cargo.toml"):
+		return "cargo"
+	case contains("package.json", `"jest"`) || exists("jest.config.js") || exists("jest.config.ts") || exists("jest.config.mjs"):
+		return "jest"
+	case exists("pytest.ini") || exists("conftest.py") || contains("pyproject.toml", "pytest") ||
+		contains("setup.cfg", "pytest") || contains("tox.ini", "pytest"):
+		return "pytest"
+	}
+	return ""
+}
+
+// goTestTarget returns the packages to test for a path, which may be a file,
+// a package directory or a package pattern.
+func goTestTarget(workingDir, path string) string {
+	if path == "" {
+		return "./..."
+	}
+	if filepath.IsAbs(path) {
+		if rel, err := filepath.Rel(workingDir, path); err == nil {
+			path = rel
+		}
+	}
+	if strings.HasSuffix(path, ".go") {
+		path = filepath.Dir(path)
+	}
+	path = filepath.ToSlash(path)
+	if path != "." && !strings.HasPrefix(path, "./") && !strings.HasPrefix(path, "../") {
+		path = "./" + path
+	}
+	return path
+}
+
+var (
+	goTestLocation  = regexp.MustCompile(`^\s*([^\s:]+\.go):(\d+): ?(.*)$`)
+	goBuildLocation = regexp.MustCompile(`^([^\s:]+\.go):(\d+)(?::\d+)?: (.*)$`)
+)
+
+// parseGoTestOutput parses the events of go test -json. Test output names
+// files without their directory, they are resolved with the module path.
+func parseGoTestOutput(workingDir, stdout, stderr string) testReport {
+	type testKey struct{ pkg, test string }
+	var report testReport
+	var failed []testKey
+	output := map[testKey][]string{}
+	failedPackages := map[string]bool{}
+	builtFailed := map[string]bool{}
+	var buildOutput []string
+
+	scanner := bufio.NewScanner(strings.NewReader(stdout))
+	scanner.Buffer(make([]byte, 1024*1024), 1024*1024)
+	for scanner.Scan() {
+		var event struct {
+			Action      string
+			Package     string
+			Test        string
+			Output      string
+			FailedBuild string
+		}
+		if json.Unmarshal(scanner.Bytes(), &event) != nil {
+			continue
+		}
+		key := testKey{event.Package, event.Test}
+		switch event.Action {
+		case "output":
+			output[key] = append(output[key], strings.TrimRight(event.Output, "\n"))
+		case "build-output":
+			buildOutput = append(buildOutput, strings.TrimRight(event.Output, "\n"))
+		case "pass":
+			if event.Test != "" {
+				report.passed++
+			}
+		case "fail":
+			if event.Test != "" {
+				failed = append(failed, key)
+			} else if event.FailedBuild != "" {
+				builtFailed[event.Package] = true
+			} else {
+				failedPackages[event.Package] = true
+			}
+		}
+	}
+	// Older versions of go print build errors to stderr
+	buildOutput = append(buildOutput, strings.Split(stderr, "\n")...)
+
+	module := goModulePath(workingDir)
+	packageDir := func(pkg string) string {
+		if module == "" || !strings.HasPrefix(pkg, module) {
+			return ""
+		}
+		return strings.TrimPrefix(strings.TrimPrefix(pkg, module), "/")
+	}
+
+	for _, key := range failed {
+		// A test fails with its subtests, only the subtests are reported
+		parent := false
+		for _, other := range failed {
+			if other.pkg == key.pkg && strings.HasPrefix(other.test, key.test+"/") {
+				parent = true
+				break
+			}
+		}
+		if parent {
+			continue
+		}
+		failure := TestFailure{Suite: key.pkg, Test: key.test}
+		var lines []string
+		for _, line := range output[key] {
+			trimmed := strings.TrimSpace(line)
+			if strings.HasPrefix(trimmed, "=== ") || strings.HasPrefix(trimmed, "--- FAIL") || trimmed == "" {
+				continue
+			}
+			if failure.File == "" {
+				if m := goTestLocation.FindStringSubmatch(line); m != nil {
+					failure.File = filepath.ToSlash(filepath.Join(packageDir(key.pkg), m[1]))
+					failure.Line, _ = strconv.Atoi(m[2])
+					trimmed = m[3]
+				}
+			}
+			lines = append(lines, trimmed)
+		}
+		failure.Message = strings.Join(lines, "\n")
+		report.failures = append(report.failures, failure)
+		delete(failedPackages, key.pkg)
+	}
+	report.failed = len(report.failures)
+
+	for _, line := range buildOutput {
+		if m := goBuildLocation.FindStringSubmatch(line); m != nil {
+			lineNumber, _ := strconv.Atoi(m[2])
+			report.failures = append(report.failures, TestFailure{Test: "build", File: m[1], Line: lineNumber, Message: m[3]})
+		}
+	}
+	// Packages that fail without a failed test, e.g. a panic in TestMain
+	for _, key := range sortedKeys(failedPackages) {
+		if builtFailed[key] {
+			continue
+		}
+		var lines []string
+		for _, line := range output[testKey{key, ""}] {
+			if line != "FAIL" && !strings.HasPrefix(line, "FAIL\t") {
+				lines = append(lines, line)
+			}
+		}
+		report.failures = append(report.failures, TestFailure{Suite: key, Message: strings.TrimSpace(strings.Join(lines, "\n"))})
+	}
+	return report
+}
+
+func goModulePath(workingDir string) string {
+	data, err := os.ReadFile(filepath.Join(workingDir, "go.mod"))
+	if err != nil {
+		return ""
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if module, ok := strings.CutPrefix(strings.TrimSpace(line), "module "); ok {
+			return strings.Trim(strings.TrimSpace(module), `"`)
+		}
+	}
+	return ""
+}
+
+var (
+	pytestLocation = regexp.MustCompile(`^(\S+\.py):(\d+): (.*)$`)
+	pytestSummary  = regexp.MustCompile(`^(FAILED|ERROR) (\S+?)(?: - (.*))?$`)
+	pytestCounts   = regexp.MustCompile(`(\d+) (passed|failed|errors?)\b`)
+)
+
+// parsePytestOutput parses the short tracebacks and the summary of the
+// failures, both are printed in the order of the tests.
+func parsePytestOutput(workingDir, stdout, stderr string) testReport {
+	var report testReport
+	var locations []TestFailure
+	for _, line := range strings.Split(stdout, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if m := pytestLocation.FindStringSubmatch(line); m != nil {
+			lineNumber, _ := strconv.Atoi(m[2])
+			locations = append(locations, TestFailure{File: testRelativePath(workingDir, m[1]), Line: lineNumber, Message: m[3]})
+			continue
+		}
+		if m := pytestSummary.FindStringSubmatch(line); m != nil {
+			suite, test, _ := strings.Cut(m[2], "::")
+			failure := TestFailure{Suite: suite, Test: test, Message: m[3]}
+			if m[1] == "ERROR" && test == "" {
+				failure.Test = "collection"
+			}
+			report.failures = append(report.failures, failure)
+			continue
+		}
+		if strings.HasPrefix(line, "=") || strings.Contains(line, " in ") {
+			for _, m := range pytestCounts.FindAllStringSubmatch(line, -1) {
+				n, _ := strconv.Atoi(m[1])
+				if m[2] == "passed" {
+					report.passed = n
+				} else {
+					report.failed += n
+				}
+			}
+		}
+	}
+	for i := range report.failures {
+		if i >= len(locations) {
+			break
+		}
+		report.failures[i].File = locations[i].File
+		report.failures[i].Line = locations[i].Line
+		if report.failures[i].Message == "" {
+			report.failures[i].Message = locations[i].Message
+		}
+	}
+	return report
+}
+
+var (
+	ansiEscape    = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+	jestStackLine = regexp.MustCompile(`\(?([^\s()]+):(\d+):\d+\)?$`)
+)
+
+// parseJestOutput parses the JSON report of jest.
+func parseJestOutput(workingDir, stdout, stderr string) testReport {
+	var result struct {
+		NumPassedTests int `json:"numPassedTests"`
+		NumFailedTests int `json:"numFailedTests"`
+		TestResults    []struct {
+			Name             string `json:"name"`
+			Status           string `json:"status"`
+			Message          string `json:"message"`
+			AssertionResults []struct {
+				FullName        string   `json:"fullName"`
+				Status          string   `json:"status"`
+				FailureMessages []string `json:"failureMessages"`
+				Location        *struct {
+					Line int `json:"line"`
+				} `json:"location"`
+			} `json:"assertionResults"`
+		} `json:"testResults"`
+	}
+	// npx may print notices before the report
+	start := strings.Index(stdout, "{")
+	if start < 0 || json.Unmarshal([]byte(stdout[start:]), &result) != nil {
+		return testReport{}
+	}
+
+	report := testReport{passed: result.NumPassedTests, failed: result.NumFailedTests}
+	for _, suite := range result.TestResults {
+		file := testRelativePath(workingDir, suite.Name)
+		suiteFailed := false
+		for _, assertion := range suite.AssertionResults {
+			if assertion.Status != "failed" {
+				continue
+			}
+			suiteFailed = true
+			message := ansiEscape.ReplaceAllString(strings.Join(assertion.FailureMessages, "\n"), "")
+			failure := TestFailure{Suite: file, Test: assertion.FullName, File: file}
+			if assertion.Location != nil {
+				failure.Line = assertion.Location.Line
+			}
+			// The line of the failed expectation is in the stack of the message
+			var lines []string
+			for _, line := range strings.Split(message, "\n") {
+				trimmed := strings.TrimSpace(line)
+				if strings.HasPrefix(trimmed, "at ") {
+					if m := jestStackLine.FindStringSubmatch(trimmed); m != nil && strings.HasSuffix(m[1], file) {
+						failure.Line, _ = strconv.Atoi(m[2])
+					}
+					continue
+				}
+				if trimmed != "" {
+					lines = append(lines, trimmed)
+				}
+			}
+			failure.Message = strings.Join(lines, "\n")
+			report.failures = append(report.failures, failure)
+		}
+		if suite.Status == "failed" && !suiteFailed && suite.Message != "" {
+			// The file failed to run, e.g. with a syntax error
+			report.failures = append(report.failures, TestFailure{
+				Suite:   file,
+				File:    file,
+				Message: strings.TrimSpace(ansiEscape.ReplaceAllString(suite.Message, "")),
+			})
+		}
+	}
+	return report
+}
+
+var (
+	cargoFailureHeader = regexp.MustCompile(`^---- (\S+) stdout ----$`)
+	cargoPanic         = regexp.MustCompile(`panicked at (?:'(.*)', )?([^\s:]+\.rs):(\d+):\d+:?$`)
+	cargoResult        = regexp.MustCompile(`^test result: \w+\. (\d+) passed; (\d+) failed`)
+	cargoError         = regexp.MustCompile(`^error(?:\[\w+\])?: (.*)$`)
+	cargoErrorLocation = regexp.MustCompile(`^\s*--> ([^\s:]+):(\d+):\d+$`)
+)
+
+// parseCargoTestOutput parses the failures of cargo test from the output of
+// the failed tests, and the compile errors from stderr.
+func parseCargoTestOutput(workingDir, stdout, stderr string) testReport {
+	var report testReport
+	var current *TestFailure
+	var lines []string
+	var backtrace bool
+	finish := func() {
+		if current != nil {
+			current.Message = strings.TrimSpace(strings.Join(lines, "\n"))
+			report.failures = append(report.failures, *current)
+		}
+		current, lines, backtrace = nil, nil, false
+	}
+	for _, line := range strings.Split(stdout, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if m := cargoFailureHeader.FindStringSubmatch(line); m != nil {
+			finish()
+			current = &TestFailure{Test: m[1]}
+			continue
+		}
+		if m := cargoResult.FindStringSubmatch(line); m != nil {
+			finish()
+			passed, _ := strconv.Atoi(m[1])
+			failed, _ := strconv.Atoi(m[2])
+			report.passed += passed
+			report.failed += failed
+			continue
+		}
+		if current == nil {
+			continue
+		}
+		if line == "failures:" || line == "" && len(lines) > 0 && lines[len(lines)-1] == "" {
+			finish()
+			continue
+		}
+		if m := cargoPanic.FindStringSubmatch(line); m != nil {
+			current.File = m[2]
+			current.Line, _ = strconv.Atoi(m[3])
+			if m[1] != "" {
+				lines = append(lines, m[1])
+			}
+			continue
+		}
+		if line == "stack backtrace:" {
+			backtrace = true
+		}
+		if !backtrace && !strings.HasPrefix(line, "note: ") {
+			lines = append(lines, line)
+		}
+	}
+	finish()
+
+	var compileError *TestFailure
+	for _, line := range strings.Split(stderr, "\n") {
+		if m := cargoError.FindStringSubmatch(line); m != nil {
+			// Summaries of the errors and the failed tests
+			if strings.HasPrefix(m[1], "could not compile") || strings.HasPrefix(m[1], "test failed") ||
+				strings.HasSuffix(m[1], "failed:") {
+				compileError = nil
+				continue
+			}
+			report.failures = append(report.failures, TestFailure{Test: "build", Message: m[1]})
+			compileError = &report.failures[len(report.failures)-1]
+			continue
+		}
+		if m := cargoErrorLocation.FindStringSubmatch(line); m != nil && compileError != nil && compileError.File == "" {
+			compileError.File = m[1]
+			compileError.Line, _ = strconv.Atoi(m[2])
+		}
+	}
+	return report
+}
+
+// testRelativePath returns the path relative to the working directory when the
+// runner prints absolute paths.
+func testRelativePath(workingDir, path string) string {
+	if !filepath.IsAbs(path) {
+		return path
+	}
+	if rel, err := filepath.Rel(workingDir, path); err == nil && !strings.HasPrefix(rel, "..") {
+		return filepath.ToSlash(rel)
+	}
+	return path
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
@@ -0,0 +1,165 @@
+package tools
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectTestFramework(t *testing.T) {
+	dir := t.TempDir()
+	assert.Equal(t, "", detectTestFramework(dir, ""))
+	assert.Equal(t, "pytest", detectTestFramework(dir, "tests/test_api.py"))
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "package.json"), []byte(`{"devDependencies": {"jest": "^29.0.0"}}`), 0o644))
+	assert.Equal(t, "jest", detectTestFramework(dir, ""))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/m\n"), 0o644))
+	assert.Equal(t, "go", detectTestFramework(dir, ""))
+	assert.Equal(t, "jest", detectTestFramework(dir, "web/app.test.ts"))
+}
+
+func TestGoTestTarget(t *testing.T) {
+	assert.Equal(t, "./...", goTestTarget("/repo", ""))
+	assert.Equal(t, "./internal/app", goTestTarget("/repo", "internal/app/app_test.go"))
+	assert.Equal(t, "./internal/...", goTestTarget("/repo", "internal/..."))
+	assert.Equal(t, "./internal/app", goTestTarget("/repo", "/repo/internal/app"))
+	assert.Equal(t, ".", goTestTarget("/repo", "."))
+}
+
+func TestParseGoTestOutput(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go is not installed")
+	}
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod": "module example.com/m\n\ngo 1.21\n",
+		"a/a_test.go": `package a
+
+import "testing"
+
+func TestOK(t *testing.T) {}
+
+func TestEqual(t *testing.T) {
+	t.Errorf("expected 1, got %d", 2)
+}
+
+func TestSub(t *testing.T) {
+	t.Run("ok", func(t *testing.T) {})
+	t.Run("bad", func(t *testing.T) { t.Fatal("bad input") })
+}
+`,
+		"b/b_test.go": `package b
+
+import "testing"
+
+func TestBuild(t *testing.T) { x := 1 }
+`,
+	}
+	for name, content := range files {
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, filepath.Dir(name)), 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+	}
+
+	cmd := exec.Command("go", "test", "-json", "./...")
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GOFLAGS=-mod=mod", "GOTOOLCHAIN=local")
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	require.Error(t, cmd.Run())
+
+	report := parseGoTestOutput(dir, stdout.String(), stderr.String())
+	assert.Equal(t, 2, report.passed)
+	assert.Equal(t, 2, report.failed)
+	require.Len(t, report.failures, 3)
+	assert.Equal(t, TestFailure{Suite: "example.com/m/a", Test: "TestEqual", File: "a/a_test.go", Line: 8, Message: "expected 1, got 2"}, report.failures[0])
+	assert.Equal(t, TestFailure{Suite: "example.com/m/a", Test: "TestSub/bad", File: "a/a_test.go", Line: 13, Message: "bad input"}, report.failures[1])
+	assert.Equal(t, "build", report.failures[2].Test)
+	assert.Equal(t, "b/b_test.go", report.failures[2].File)
+	assert.Equal(t, 5, report.failures[2].Line)
+	assert.Contains(t, report.failures[2].Message, "declared and not used")
+}
+
+func TestParsePytestOutput(t *testing.T) {
+	stdout := `.FF                                                                      [100%]
+=================================== FAILURES ===================================
+/repo/tests/test_a.py:6: AssertionError: x should be one
+/repo/tests/test_a.py:9: ValueError: boom
+=========================== short test summary info ============================
+FAILED tests/test_a.py::test_bad - AssertionError: x should be one
+FAILED tests/test_a.py::test_err - ValueError: boom
+1 passed, 2 failed in 0.03s
+`
+	report := parsePytestOutput("/repo", stdout, "")
+	assert.Equal(t, 1, report.passed)
+	assert.Equal(t, 2, report.failed)
+	assert.Equal(t, []TestFailure{
+		{Suite: "tests/test_a.py", Test: "test_bad", File: "tests/test_a.py", Line: 6, Message: "AssertionError: x should be one"},
+		{Suite: "tests/test_a.py", Test: "test_err", File: "tests/test_a.py", Line: 9, Message: "ValueError: boom"},
+	}, report.failures)
+}
+
+func TestParseJestOutput(t *testing.T) {
+	stdout := `{"numPassedTests":1,"numFailedTests":1,"testResults":[
+{"name":"/repo/src/sum.test.js","status":"failed","message":"","assertionResults":[
+{"fullName":"sum adds","status":"passed","failureMessages":[],"location":{"line":3,"column":3}},
+{"fullName":"sum subtracts","status":"failed","location":{"line":7,"column":3},"failureMessages":["Error: \u001b[2mexpect(\u001b[22mreceived\u001b[2m).\u001b[22mtoBe(expected)\n\nExpected: 1\nReceived: 2\n    at Object.toBe (/repo/src/sum.test.js:8:22)\n    at Promise.then.completed (/repo/node_modules/jest-circus/build/utils.js:298:28)"]}]},
+{"name":"/repo/src/broken.test.js","status":"failed","message":"SyntaxError: Unexpected token (3:1)","assertionResults":[]}]}`
+	report := parseJestOutput("/repo", stdout, "")
+	assert.Equal(t, 1, report.passed)
+	assert.Equal(t, 1, report.failed)
+	assert.Equal(t, []TestFailure{
+		{Suite: "src/sum.test.js", Test: "sum subtracts", File: "src/sum.test.js", Line: 8, Message: "Error: expect(received).toBe(expected)\nExpected: 1\nReceived: 2"},
+		{Suite: "src/broken.test.js", File: "src/broken.test.js", Message: "SyntaxError: Unexpected token (3:1)"},
+	}, report.failures)
+}
+
+func TestParseCargoTestOutput(t *testing.T) {
+	stdout := `
+running 2 tests
+test tests::bad ... FAILED
+test tests::ok ... ok
+
+failures:
+
+---- tests::bad stdout ----
+
+thread 'tests::bad' panicked at src/lib.rs:8:16:
+assertion ` + "`left == right`" + ` failed
+  left: 2
+ right: 3
+stack backtrace:
+   0: __rustc::rust_begin_unwind
+note: Some details are omitted, run with ` + "`RUST_BACKTRACE=full`" + ` for a verbose backtrace.
+
+
+failures:
+    tests::bad
+
+test result: FAILED. 1 passed; 1 failed; 0 ignored; 0 measured; 0 filtered out; finished in 0.01s
+`
+	report := parseCargoTestOutput("/repo", stdout, "error: test failed, to rerun pass `--lib`\nerror: 1 target failed:\n    `--lib`\n")
+	assert.Equal(t, 1, report.passed)
+	assert.Equal(t, 1, report.failed)
+	assert.Equal(t, []TestFailure{
+		{Test: "tests::bad", File: "src/lib.rs", Line: 8, Message: "assertion `left == right` failed\n  left: 2\n right: 3"},
+	}, report.failures)
+
+	stderr := `   Compiling ct v0.1.0 (/repo)
+error[E0308]: mismatched types
+ --> src/lib.rs:8:29
+  |
+8 |     fn bad() { let y: i32 = "a"; }
+  |                             ^^^ expected ` + "`i32`, found `&str`" + `
+
+error: could not compile ` + "`ct`" + ` (lib test) due to 1 previous error
+`
+	report = parseCargoTestOutput("/repo", "", stderr)
+	assert.Equal(t, []TestFailure{
+		{Test: "build", File: "src/lib.rs", Line: 8, Message: "mismatched types"},
+	}, report.failures)
+}
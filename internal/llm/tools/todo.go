@@ -0,0 +1,158 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/opencode-ai/opencode/internal/todo"
+)
+
+type TodoItem struct {
+	Content string `json:"content"`
+	Status  string `json:"status"`
+}
+
+type TodoParams struct {
+	Todos []TodoItem `json:"todos"`
+}
+
+type TodoResponseMetadata struct {
+	Done  int `json:"done"`
+	Total int `json:"total"`
+}
+
+type todoTool struct {
+	todos todo.Service
+}
+
+const (
+	TodoToolName = "todo"
+
+	// maxTodos is the number of items a todo list may have
+	maxTodos = 50
+
+	todoDescription = `Writes the todo list of the job you are working on, which the user sees as a panel next to the conversation showing your progress.
+
+WHEN TO USE THIS TOOL:
+- Use at the start of tasks with three or more steps, like refactorings touching several files or features needing code, tests and docs
+- Use when the user gives you a list of things to do
+- Update the list as you work: mark an item in_progress before starting it and completed right after finishing it
+- Don't use it for single, trivial tasks
+
+HOW TO USE:
+- Always provide the whole list, it replaces the previous one
+- Each item has a content, a short imperative description like "Update the callers of ParseConfig", and a status
+- status is one of: pending, in_progress, completed, cancelled
+- Only one item may be in_progress at a time
+- Add items you discover while working, and cancel the ones that turned out to be unneeded
+- Provide an empty list to clear it once the job is done and the user moves on to something else
+
+LIMITATIONS:
+- The list belongs to the session, sub-agents don't see it
+- A list has at most %d items`
+)
+
+func NewTodoTool(todos todo.Service) BaseTool {
+	return &todoTool{todos: todos}
+}
+
+func (t *todoTool) Info() ToolInfo {
+	return ToolInfo{
+		Name:        TodoToolName,
+		Description: fmt.Sprintf(todoDescription, maxTodos),
+		Parameters: map[string]any{
+			"todos": map[string]any{
+				"type":        "array",
+				"description": "The whole todo list, in the order the items are done",
+				"items": map[string]any{
+					"type": "object",
+					"properties": map[string]any{
+						"content": map[string]any{
+							"type":        "string",
+							"description": "What to do",
+						},
+						"status": map[string]any{
+							"type": "string",
+							"enum": []string{
+								string(todo.StatusPending),
+								string(todo.StatusInProgress),
+								string(todo.StatusCompleted),
+								string(todo.StatusCancelled),
+							},
+						},
+					},
+					"required": []string{"content", "status"},
+				},
+			},
+		},
+		Required: []string{"todos"},
+	}
+}
+
+func (t *todoTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error) {
+	var params TodoParams
+	if err := json.Unmarshal([]byte(call.Input), &params); err != nil {
+		return NewTextErrorResponse(fmt.Sprintf("error parsing parameters: %s", err)), nil
+	}
+	sessionID, _ := GetContextValues(ctx)
+	if sessionID == "" {
+		return ToolResponse{}, errors.New("session ID is required for the todo list")
+	}
+	if len(params.Todos) > maxTodos {
+		return NewTextErrorResponse(fmt.Sprintf("the list has %d items, at most %d are allowed, merge some of them", len(params.Todos), maxTodos)), nil
+	}
+
+	items := make([]todo.Item, len(params.Todos))
+	inProgress := 0
+	for i, param := range params.Todos {
+		item := todo.Item{Content: strings.TrimSpace(param.Content), Status: todo.Status(param.Status)}
+		if item.Content == "" {
+			return NewTextErrorResponse(fmt.Sprintf("item %d has no content", i+1)), nil
+		}
+		if !item.Status.Valid() {
+			return NewTextErrorResponse(fmt.Sprintf("item %d has the unknown status %q, must be one of: pending, in_progress, completed, cancelled", i+1, param.Status)), nil
+		}
+		if item.Status == todo.StatusInProgress {
+			inProgress++
+		}
+		items[i] = item
+	}
+	if inProgress > 1 {
+		return NewTextErrorResponse(fmt.Sprintf("%d items are in_progress, only one may be at a time", inProgress)), nil
+	}
+
+	list, err := t.todos.Set(ctx, sessionID, items)
+	if err != nil {
+		return ToolResponse{}, fmt.Errorf("error saving the todo list: %w", err)
+	}
+	done, total := list.Progress()
+	return WithResponseMetadata(
+		NewTextResponse(formatTodoList(list)),
+		TodoResponseMetadata{Done: done, Total: total},
+	), nil
+}
+
+// formatTodoList returns the items of the list with their status and the
+// progress.
+func formatTodoList(list todo.List) string {
+	if len(list.Items) == 0 {
+		return "Cleared the todo list"
+	}
+	var b strings.Builder
+	done, total := list.Progress()
+	fmt.Fprintf(&b, "Todo list updated, %d of %d done:\n", done, total)
+	for _, item := range list.Items {
+		fmt.Fprintf(&b, "%s %s\n", todoMarks[item.Status], item.Content)
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+var todoMarks = map[todo.Status]string{
+	todo.StatusPending:    "[ ]",
+	todo.StatusInProgress: "[>]",
+	todo.StatusCompleted:  "[x]",
+	todo.StatusCancelled:  "[-]",
+}
@@ -0,0 +1,54 @@
+package tools
+
+import (
+	"context"
+	"testing"
+
+	"github.com/opencode-ai/opencode/internal/pubsub"
+	"github.com/opencode-ai/opencode/internal/todo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type memoryTodos struct {
+	*pubsub.Broker[todo.List]
+	lists map[string]todo.List
+}
+
+func (m *memoryTodos) Get(ctx context.Context, sessionID string) (todo.List, error) {
+	return m.lists[sessionID], nil
+}
+
+func (m *memoryTodos) Set(ctx context.Context, sessionID string, items []todo.Item) (todo.List, error) {
+	m.lists[sessionID] = todo.List{SessionID: sessionID, Items: items}
+	return m.lists[sessionID], nil
+}
+
+func TestTodoTool(t *testing.T) {
+	todos := &memoryTodos{Broker: pubsub.NewBroker[todo.List](), lists: map[string]todo.List{}}
+	tool := NewTodoTool(todos)
+	ctx := context.WithValue(context.Background(), SessionIDContextKey, "s1")
+
+	response, err := tool.Run(ctx, ToolCall{Input: `{"todos": [
+		{"content": "Rename Load to LoadConfig", "status": "completed"},
+		{"content": "Update the callers", "status": "in_progress"},
+		{"content": "Run the tests", "status": "pending"}
+	]}`})
+	require.NoError(t, err)
+	assert.False(t, response.IsError)
+	assert.Equal(t, "Todo list updated, 1 of 3 done:\n[x] Rename Load to LoadConfig\n[>] Update the callers\n[ ] Run the tests", response.Content)
+	assert.Len(t, todos.lists["s1"].Items, 3)
+
+	response, err = tool.Run(ctx, ToolCall{Input: `{"todos": [
+		{"content": "Update the callers", "status": "in_progress"},
+		{"content": "Run the tests", "status": "in_progress"}
+	]}`})
+	require.NoError(t, err)
+	assert.True(t, response.IsError)
+	assert.Contains(t, response.Content, "only one may be at a time")
+
+	response, err = tool.Run(ctx, ToolCall{Input: `{"todos": [{"content": "Run the tests", "status": "done"}]}`})
+	require.NoError(t, err)
+	assert.True(t, response.IsError)
+	assert.Len(t, todos.lists["s1"].Items, 3)
+}
@@ -3,6 +3,7 @@ package tools
 import (
 	"context"
 	"encoding/json"
+	"io"
 )
 
 type ToolInfo struct {
@@ -17,6 +18,7 @@ type toolResponseType string
 type (
 	sessionIDContextKey string
 	messageIDContextKey string
+	progressContextKey  string
 )
 
 const (
@@ -25,6 +27,7 @@ const (
 
 	SessionIDContextKey sessionIDContextKey = "session_id"
 	MessageIDContextKey messageIDContextKey = "message_id"
+	ProgressContextKey  progressContextKey  = "progress"
 )
 
 type ToolResponse struct {
@@ -82,3 +85,18 @@ func GetContextValues(ctx context.Context) (string, string) {
 	}
 	return sessionID.(string), messageID.(string)
 }
+
+// WithProgress returns a context whose tool writes the output it produces
+// while it runs to w, so it can be shown before the tool returns its result.
+func WithProgress(ctx context.Context, w io.Writer) context.Context {
+	return context.WithValue(ctx, ProgressContextKey, w)
+}
+
+// GetProgressWriter returns the writer for the output of the running tool,
+// which discards it when nobody follows the tool.
+func GetProgressWriter(ctx context.Context) io.Writer {
+	if w, ok := ctx.Value(ProgressContextKey).(io.Writer); ok {
+		return w
+	}
+	return io.Discard
+}
@@ -0,0 +1,134 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// ValidateInput checks the arguments of a call of the tool against its
+// parameters: they must be a JSON object with the required parameters, and
+// the parameters must have the type and one of the enum values of their
+// schema. Parameters the schema doesn't describe are left to the tool, like
+// the nested values of arrays and objects.
+func ValidateInput(info ToolInfo, input string) error {
+	if strings.TrimSpace(input) == "" {
+		input = "{}"
+	}
+	var args map[string]any
+	if err := json.Unmarshal([]byte(input), &args); err != nil {
+		return fmt.Errorf("the arguments are not a valid JSON object: %w", err)
+	}
+
+	var problems []string
+	for _, name := range info.Required {
+		if value, ok := args[name]; !ok || value == nil {
+			problems = append(problems, fmt.Sprintf("missing required parameter %q", name))
+		}
+	}
+	names := make([]string, 0, len(args))
+	for name := range args {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		schema, ok := info.Parameters[name].(map[string]any)
+		if !ok || args[name] == nil {
+			continue
+		}
+		if problem := checkParameter(schema, args[name]); problem != "" {
+			problems = append(problems, fmt.Sprintf("parameter %q %s", name, problem))
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("%s", strings.Join(problems, ", "))
+	}
+	return nil
+}
+
+// checkParameter describes how the value doesn't match the schema of its
+// parameter, empty when it does.
+func checkParameter(schema map[string]any, value any) string {
+	var types []string
+	switch t := schema["type"].(type) {
+	case string:
+		types = []string{t}
+	case []string:
+		types = t
+	case []any:
+		for _, v := range t {
+			if s, ok := v.(string); ok {
+				types = append(types, s)
+			}
+		}
+	}
+	if len(types) > 0 && !slices.ContainsFunc(types, func(t string) bool { return hasType(value, t) }) {
+		return fmt.Sprintf("must be of type %s, got %s", strings.Join(types, " or "), jsonType(value))
+	}
+
+	var enum []any
+	switch e := schema["enum"].(type) {
+	case []string:
+		for _, v := range e {
+			enum = append(enum, v)
+		}
+	case []any:
+		enum = e
+	}
+	if len(enum) > 0 && !slices.Contains(enum, value) {
+		values := make([]string, len(enum))
+		for i, v := range enum {
+			values[i] = fmt.Sprintf("%v", v)
+		}
+		return fmt.Sprintf("must be one of %s, got %v", strings.Join(values, ", "), value)
+	}
+	return ""
+}
+
+// hasType reports whether the decoded JSON value has the JSON schema type.
+// Types the check doesn't know are accepted.
+func hasType(value any, schemaType string) bool {
+	switch schemaType {
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	case "integer":
+		n, ok := value.(float64)
+		return ok && n == math.Trunc(n)
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "array":
+		_, ok := value.([]any)
+		return ok
+	case "object":
+		_, ok := value.(map[string]any)
+		return ok
+	case "null":
+		return value == nil
+	}
+	return true
+}
+
+// jsonType is the JSON type of a decoded value.
+func jsonType(value any) string {
+	switch value.(type) {
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case bool:
+		return "boolean"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+	return "null"
+}
@@ -0,0 +1,42 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateInput(t *testing.T) {
+	info := ToolInfo{
+		Name: "example",
+		Parameters: map[string]any{
+			"path":    map[string]any{"type": "string"},
+			"limit":   map[string]any{"type": "integer"},
+			"format":  map[string]any{"type": "string", "enum": []string{"text", "html"}},
+			"headers": map[string]any{"type": []any{"object", "null"}},
+		},
+		Required: []string{"path"},
+	}
+
+	tests := []struct {
+		input string
+		err   string
+	}{
+		{input: `{"path": "/a", "limit": 10, "format": "html", "headers": null}`},
+		{input: `{"path": "/a", "extra": true}`},
+		{input: ``, err: `missing required parameter "path"`},
+		{input: `{"path": "/a", "limit": 1.5}`, err: `parameter "limit" must be of type integer, got number`},
+		{input: `{"path": 3, "format": "pdf"}`, err: `parameter "format" must be one of text, html, got pdf, parameter "path" must be of type string, got number`},
+		{input: `{"path": "/a", "headers": "x"}`, err: `parameter "headers" must be of type object or null, got string`},
+		{input: `{"path": "/a"`, err: "the arguments are not a valid JSON object: unexpected end of JSON input"},
+		{input: `["/a"]`, err: "the arguments are not a valid JSON object: json: cannot unmarshal array into Go value of type map[string]interface {}"},
+	}
+	for _, tt := range tests {
+		err := ValidateInput(info, tt.input)
+		if tt.err == "" {
+			assert.NoError(t, err, tt.input)
+			continue
+		}
+		assert.EqualError(t, err, tt.err, tt.input)
+	}
+}
@@ -0,0 +1,344 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/opencode-ai/opencode/internal/config"
+)
+
+type WebSearchParams struct {
+	Query string `json:"query"`
+	Count int    `json:"count,omitempty"`
+}
+
+type WebSearchResponseMetadata struct {
+	Engine          string `json:"engine"`
+	NumberOfResults int    `json:"number_of_results"`
+}
+
+type webSearchResult struct {
+	Title   string
+	URL     string
+	Snippet string
+}
+
+// webSearchEngine returns the results of a query, at most count of them.
+type webSearchEngine interface {
+	search(ctx context.Context, client *http.Client, query string, count int) ([]webSearchResult, error)
+}
+
+type webSearchTool struct {
+	client     *http.Client
+	engineName config.WebSearchEngine
+	engine     webSearchEngine
+}
+
+const (
+	WebSearchToolName        = "websearch"
+	webSearchToolDescription = `Searches the web and returns the titles, URLs and snippets of the results.
+
+WHEN TO USE THIS TOOL:
+- Use when you need current information that may not be in your training data
+- Helpful for questions about recent releases, APIs and documentation of libraries
+- Useful for finding the page to read with the fetch tool
+
+HOW TO USE:
+- Provide a search query, as you would type it into a search engine
+- Optionally specify the number of results to return (default: 5, max: 10)
+
+LIMITATIONS:
+- Only the snippets of the results are returned, not the content of the pages
+- Results depend on the configured search engine
+- Rate limits may apply
+
+TIPS:
+- Include the name and version of a library to find its documentation
+- Use the fetch tool to read a result when the snippet is not enough
+- Prefer the official documentation over blog posts and forums`
+)
+
+// NewWebSearchTool creates the websearch tool for the configured engine.
+func NewWebSearchTool(cfg config.WebSearch) BaseTool {
+	t := &webSearchTool{
+		client: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+		engineName: cfg.Engine,
+	}
+	switch cfg.Engine {
+	case config.WebSearchSearxNG:
+		t.engine = &searxngEngine{baseURL: strings.TrimSuffix(cfg.BaseURL, "/")}
+	case config.WebSearchBrave:
+		t.engine = &braveEngine{endpoint: "https://api.search.brave.com/res/v1/web/search", apiKey: cfg.APIKey}
+	case config.WebSearchGoogle:
+		t.engine = &googleEngine{endpoint: "https://www.googleapis.com/customsearch/v1", apiKey: cfg.APIKey, searchEngineID: cfg.SearchEngineID}
+	default:
+		t.engineName = config.WebSearchDuckDuckGo
+		t.engine = &duckDuckGoEngine{endpoint: "https://html.duckduckgo.com/html/"}
+	}
+	return t
+}
+
+func (t *webSearchTool) Info() ToolInfo {
+	return ToolInfo{
+		Name:        WebSearchToolName,
+		Description: webSearchToolDescription,
+		Parameters: map[string]any{
+			"query": map[string]any{
+				"type":        "string",
+				"description": "The search query",
+			},
+			"count": map[string]any{
+				"type":        "number",
+				"description": "Optional number of results to return (default: 5, max: 10)",
+			},
+		},
+		Required: []string{"query"},
+	}
+}
+
+func (t *webSearchTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error) {
+	var params WebSearchParams
+	if err := json.Unmarshal([]byte(call.Input), &params); err != nil {
+		return NewTextErrorResponse("Failed to parse websearch parameters: " + err.Error()), nil
+	}
+
+	if strings.TrimSpace(params.Query) == "" {
+		return NewTextErrorResponse("Query parameter is required"), nil
+	}
+
+	if params.Count <= 0 {
+		params.Count = 5
+	} else if params.Count > 10 {
+		params.Count = 10
+	}
+
+	results, err := t.engine.search(ctx, t.client, params.Query, params.Count)
+	if err != nil {
+		return NewTextErrorResponse(fmt.Sprintf("Search with %s failed: %s", t.engineName, err)), nil
+	}
+	if len(results) > params.Count {
+		results = results[:params.Count]
+	}
+
+	return WithResponseMetadata(
+		NewTextResponse(formatWebSearchResults(params.Query, results)),
+		WebSearchResponseMetadata{
+			Engine:          string(t.engineName),
+			NumberOfResults: len(results),
+		},
+	), nil
+}
+
+func formatWebSearchResults(query string, results []webSearchResult) string {
+	if len(results) == 0 {
+		return fmt.Sprintf("No results found for %q. Try a different query.", query)
+	}
+	var buffer strings.Builder
+	fmt.Fprintf(&buffer, "# Web search results for %q\n\n", query)
+	for i, result := range results {
+		fmt.Fprintf(&buffer, "## %d. %s\n\nURL: %s\n\n", i+1, result.Title, result.URL)
+		if result.Snippet != "" {
+			buffer.WriteString(result.Snippet + "\n\n")
+		}
+	}
+	return strings.TrimSpace(buffer.String())
+}
+
+// getWebSearchJSON sends a search request and decodes the JSON response.
+func getWebSearchJSON(client *http.Client, req *http.Request, v any) error {
+	req.Header.Set("Accept", "application/json")
+	if req.Header.Get("User-Agent") == "" {
+		req.Header.Set("User-Agent", "opencode/1.0")
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		if len(body) > 0 {
+			return fmt.Errorf("request failed with status code: %d, response: %s", resp.StatusCode, body)
+		}
+		return fmt.Errorf("request failed with status code: %d", resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// searxngEngine uses the JSON API of a SearxNG instance, which has to allow
+// the json format in its settings.
+type searxngEngine struct {
+	baseURL string
+}
+
+func (e *searxngEngine) search(ctx context.Context, client *http.Client, query string, count int) ([]webSearchResult, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, e.baseURL+"/search?"+url.Values{
+		"q":      {query},
+		"format": {"json"},
+	}.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	var response struct {
+		Results []struct {
+			Title   string `json:"title"`
+			URL     string `json:"url"`
+			Content string `json:"content"`
+		} `json:"results"`
+	}
+	if err := getWebSearchJSON(client, req, &response); err != nil {
+		return nil, err
+	}
+	results := make([]webSearchResult, 0, len(response.Results))
+	for _, r := range response.Results {
+		results = append(results, webSearchResult{Title: r.Title, URL: r.URL, Snippet: r.Content})
+	}
+	return results, nil
+}
+
+type braveEngine struct {
+	endpoint string
+	apiKey   string
+}
+
+func (e *braveEngine) search(ctx context.Context, client *http.Client, query string, count int) ([]webSearchResult, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, e.endpoint+"?"+url.Values{
+		"q":     {query},
+		"count": {fmt.Sprint(count)},
+	}.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Subscription-Token", e.apiKey)
+	var response struct {
+		Web struct {
+			Results []struct {
+				Title       string `json:"title"`
+				URL         string `json:"url"`
+				Description string `json:"description"`
+			} `json:"results"`
+		} `json:"web"`
+	}
+	if err := getWebSearchJSON(client, req, &response); err != nil {
+		return nil, err
+	}
+	results := make([]webSearchResult, 0, len(response.Web.Results))
+	for _, r := range response.Web.Results {
+		// The matches are highlighted with <strong> in the descriptions
+		snippet, err := extractTextFromHTML(r.Description)
+		if err != nil {
+			snippet = r.Description
+		}
+		results = append(results, webSearchResult{Title: r.Title, URL: r.URL, Snippet: snippet})
+	}
+	return results, nil
+}
+
+// googleEngine uses the Custom Search JSON API with a programmable search
+// engine.
+type googleEngine struct {
+	endpoint       string
+	apiKey         string
+	searchEngineID string
+}
+
+func (e *googleEngine) search(ctx context.Context, client *http.Client, query string, count int) ([]webSearchResult, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, e.endpoint+"?"+url.Values{
+		"key": {e.apiKey},
+		"cx":  {e.searchEngineID},
+		"q":   {query},
+		"num": {fmt.Sprint(count)},
+	}.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	var response struct {
+		Items []struct {
+			Title   string `json:"title"`
+			Link    string `json:"link"`
+			Snippet string `json:"snippet"`
+		} `json:"items"`
+	}
+	if err := getWebSearchJSON(client, req, &response); err != nil {
+		return nil, err
+	}
+	results := make([]webSearchResult, 0, len(response.Items))
+	for _, r := range response.Items {
+		results = append(results, webSearchResult{Title: r.Title, URL: r.Link, Snippet: r.Snippet})
+	}
+	return results, nil
+}
+
+// duckDuckGoEngine scrapes the HTML version of DuckDuckGo, which needs no API
+// key.
+type duckDuckGoEngine struct {
+	endpoint string
+}
+
+func (e *duckDuckGoEngine) search(ctx context.Context, client *http.Client, query string, count int) ([]webSearchResult, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.endpoint, strings.NewReader(url.Values{
+		"q": {query},
+	}.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	// The HTML version refuses clients that don't look like a browser
+	req.Header.Set("User-Agent", "Mozilla/5.0 (compatible; opencode/1.0)")
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("request failed with status code: %d", resp.StatusCode)
+	}
+	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse results: %w", err)
+	}
+
+	var results []webSearchResult
+	doc.Find(".result").Not(".result--ad").EachWithBreak(func(_ int, s *goquery.Selection) bool {
+		link := s.Find("a.result__a").First()
+		href, ok := link.Attr("href")
+		if !ok {
+			return true
+		}
+		results = append(results, webSearchResult{
+			Title:   strings.TrimSpace(link.Text()),
+			URL:     duckDuckGoResultURL(href),
+			Snippet: strings.Join(strings.Fields(s.Find(".result__snippet").Text()), " "),
+		})
+		return len(results) < count
+	})
+	return results, nil
+}
+
+// duckDuckGoResultURL returns the target of a result link, which goes through
+// the redirect of DuckDuckGo.
+func duckDuckGoResultURL(href string) string {
+	u, err := url.Parse(href)
+	if err != nil {
+		return href
+	}
+	if target := u.Query().Get("uddg"); target != "" {
+		return target
+	}
+	if u.Scheme == "" {
+		u.Scheme = "https"
+	}
+	return u.String()
+}
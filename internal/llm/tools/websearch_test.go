@@ -0,0 +1,112 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/opencode-ai/opencode/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebSearchTool_Info(t *testing.T) {
+	tool := NewWebSearchTool(config.WebSearch{})
+	info := tool.Info()
+
+	assert.Equal(t, WebSearchToolName, info.Name)
+	assert.NotEmpty(t, info.Description)
+	assert.Contains(t, info.Parameters, "query")
+	assert.Contains(t, info.Required, "query")
+}
+
+func TestWebSearchTool_Engines(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		engine  func(url string) webSearchEngine
+	}{
+		{
+			name: "searxng",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "/search", r.URL.Path)
+				assert.Equal(t, "json", r.URL.Query().Get("format"))
+				assert.Equal(t, "go generics", r.URL.Query().Get("q"))
+				fmt.Fprint(w, `{"results":[{"title":"Generics","url":"https://go.dev/doc/tutorial/generics","content":"A tutorial."}]}`)
+			},
+			engine: func(url string) webSearchEngine { return &searxngEngine{baseURL: url} },
+		},
+		{
+			name: "brave",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "key", r.Header.Get("X-Subscription-Token"))
+				assert.Equal(t, "3", r.URL.Query().Get("count"))
+				fmt.Fprint(w, `{"web":{"results":[{"title":"Generics","url":"https://go.dev/doc/tutorial/generics","description":"A <strong>tutorial</strong>."}]}}`)
+			},
+			engine: func(url string) webSearchEngine { return &braveEngine{endpoint: url, apiKey: "key"} },
+		},
+		{
+			name: "google",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "key", r.URL.Query().Get("key"))
+				assert.Equal(t, "cx", r.URL.Query().Get("cx"))
+				fmt.Fprint(w, `{"items":[{"title":"Generics","link":"https://go.dev/doc/tutorial/generics","snippet":"A tutorial."}]}`)
+			},
+			engine: func(url string) webSearchEngine {
+				return &googleEngine{endpoint: url, apiKey: "key", searchEngineID: "cx"}
+			},
+		},
+		{
+			name: "duckduckgo",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, http.MethodPost, r.Method)
+				assert.Equal(t, "go generics", r.FormValue("q"))
+				fmt.Fprint(w, `<html><body>
+<div class="result result--ad"><a class="result__a" href="https://ads.example.com">Ad</a></div>
+<div class="result"><h2><a class="result__a" href="//duckduckgo.com/l/?uddg=https%3A%2F%2Fgo.dev%2Fdoc%2Ftutorial%2Fgenerics&rut=x">Generics</a></h2>
+<a class="result__snippet">A
+  tutorial.</a></div>
+</body></html>`)
+			},
+			engine: func(url string) webSearchEngine { return &duckDuckGoEngine{endpoint: url} },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(tt.handler)
+			defer server.Close()
+
+			tool := &webSearchTool{client: server.Client(), engineName: config.WebSearchEngine(tt.name), engine: tt.engine(server.URL)}
+			input, _ := json.Marshal(WebSearchParams{Query: "go generics", Count: 3})
+			response, err := tool.Run(context.Background(), ToolCall{Input: string(input)})
+			require.NoError(t, err)
+			require.False(t, response.IsError, response.Content)
+
+			assert.Contains(t, response.Content, "## 1. Generics")
+			assert.Contains(t, response.Content, "URL: https://go.dev/doc/tutorial/generics")
+			assert.Contains(t, response.Content, "A tutorial.")
+			assert.NotContains(t, response.Content, "Ad")
+
+			var metadata WebSearchResponseMetadata
+			require.NoError(t, json.Unmarshal([]byte(response.Metadata), &metadata))
+			assert.Equal(t, 1, metadata.NumberOfResults)
+		})
+	}
+}
+
+func TestWebSearchTool_EngineError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "format not allowed", http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	tool := &webSearchTool{client: server.Client(), engineName: config.WebSearchSearxNG, engine: &searxngEngine{baseURL: server.URL}}
+	response, err := tool.Run(context.Background(), ToolCall{Input: `{"query": "go"}`})
+	require.NoError(t, err)
+	assert.True(t, response.IsError)
+	assert.Contains(t, response.Content, "status code: 403")
+}
@@ -187,11 +187,17 @@ func (w *writeTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error
 	if err != nil {
 		return ToolResponse{}, fmt.Errorf("error writing file: %w", err)
 	}
+	var formatNote string
+	params.Content, formatNote = formatWrittenFile(ctx, filePath, params.Content)
 
 	// Check if file exists in history
 	file, err := w.files.GetByPathAndSession(ctx, filePath, sessionID)
 	if err != nil {
-		_, err = w.files.Create(ctx, sessionID, filePath, oldContent)
+		if fileInfo == nil {
+			_, err = w.files.CreateNew(ctx, sessionID, filePath)
+		} else {
+			_, err = w.files.Create(ctx, sessionID, filePath, oldContent)
+		}
 		if err != nil {
 			// Log error but don't fail the operation
 			return ToolResponse{}, fmt.Errorf("error creating file history: %w", err)
@@ -216,6 +222,7 @@ func (w *writeTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error
 
 	result := fmt.Sprintf("File successfully written: %s", filePath)
 	result = fmt.Sprintf("<result>\n%s\n</result>", result)
+	result += formatNote
 	result += getDiagnostics(filePath, w.lspClients)
 	return WithResponseMetadata(NewTextResponse(result),
 		WriteResponseMetadata{
@@ -1,7 +1,6 @@
 package util
 
 import (
-	"bytes"
 	"fmt"
 	"os"
 	"sort"
@@ -19,25 +18,40 @@ func applyTextEdits(uri protocol.DocumentUri, edits []protocol.TextEdit) error {
 		return fmt.Errorf("failed to read file: %w", err)
 	}
 
+	newContent, err := ApplyTextEdits(string(content), edits)
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(path, []byte(newContent), 0o644); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+
+	return nil
+}
+
+// ApplyTextEdits returns the content with the edits applied, without writing
+// any file.
+func ApplyTextEdits(content string, edits []protocol.TextEdit) (string, error) {
 	// Detect line ending style
 	var lineEnding string
-	if bytes.Contains(content, []byte("\r\n")) {
+	if strings.Contains(content, "\r\n") {
 		lineEnding = "\r\n"
 	} else {
 		lineEnding = "\n"
 	}
 
 	// Track if file ends with a newline
-	endsWithNewline := len(content) > 0 && bytes.HasSuffix(content, []byte(lineEnding))
+	endsWithNewline := len(content) > 0 && strings.HasSuffix(content, lineEnding)
 
 	// Split into lines without the endings
-	lines := strings.Split(string(content), lineEnding)
+	lines := strings.Split(content, lineEnding)
 
 	// Check for overlapping edits
 	for i, edit1 := range edits {
 		for j := i + 1; j < len(edits); j++ {
 			if rangesOverlap(edit1.Range, edits[j].Range) {
-				return fmt.Errorf("overlapping edits detected between edit %d and %d", i, j)
+				return "", fmt.Errorf("overlapping edits detected between edit %d and %d", i, j)
 			}
 		}
 	}
@@ -56,7 +70,7 @@ func applyTextEdits(uri protocol.DocumentUri, edits []protocol.TextEdit) error {
 	for _, edit := range sortedEdits {
 		newLines, err := applyTextEdit(lines, edit)
 		if err != nil {
-			return fmt.Errorf("failed to apply edit: %w", err)
+			return "", fmt.Errorf("failed to apply edit: %w", err)
 		}
 		lines = newLines
 	}
@@ -75,11 +89,7 @@ func applyTextEdits(uri protocol.DocumentUri, edits []protocol.TextEdit) error {
 		newContent.WriteString(lineEnding)
 	}
 
-	if err := os.WriteFile(path, []byte(newContent.String()), 0o644); err != nil {
-		return fmt.Errorf("failed to write file: %w", err)
-	}
-
-	return nil
+	return newContent.String(), nil
 }
 
 func applyTextEdit(lines []string, edit protocol.TextEdit) ([]string, error) {
@@ -225,6 +235,37 @@ func ApplyWorkspaceEdit(edit protocol.WorkspaceEdit) error {
 	return nil
 }
 
+// FileEdits returns the text edits of the workspace edit by the path of their
+// file. Workspace edits creating, renaming or deleting files are rejected, as
+// only the content of existing files can be changed this way.
+func FileEdits(edit protocol.WorkspaceEdit) (map[string][]protocol.TextEdit, error) {
+	files := make(map[string][]protocol.TextEdit)
+	for uri, textEdits := range edit.Changes {
+		path := uri.Path()
+		files[path] = append(files[path], textEdits...)
+	}
+	for _, change := range edit.DocumentChanges {
+		switch {
+		case change.CreateFile != nil:
+			return nil, fmt.Errorf("the edit creates the file %s", change.CreateFile.URI.Path())
+		case change.RenameFile != nil:
+			return nil, fmt.Errorf("the edit renames the file %s to %s", change.RenameFile.OldURI.Path(), change.RenameFile.NewURI.Path())
+		case change.DeleteFile != nil:
+			return nil, fmt.Errorf("the edit deletes the file %s", change.DeleteFile.URI.Path())
+		case change.TextDocumentEdit != nil:
+			path := change.TextDocumentEdit.TextDocument.URI.Path()
+			for _, e := range change.TextDocumentEdit.Edits {
+				textEdit, err := e.AsTextEdit()
+				if err != nil {
+					return nil, fmt.Errorf("invalid edit type: %w", err)
+				}
+				files[path] = append(files[path], textEdit)
+			}
+		}
+	}
+	return files, nil
+}
+
 func rangesOverlap(r1, r2 protocol.Range) bool {
 	if r1.Start.Line > r2.End.Line || r2.Start.Line > r1.End.Line {
 		return false
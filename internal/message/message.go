@@ -113,6 +113,7 @@ func (s *service) Update(ctx context.Context, message Message) error {
 		ID:         message.ID,
 		Parts:      string(parts),
 		FinishedAt: finishedAt,
+		Model:      sql.NullString{String: string(message.Model), Valid: true},
 	})
 	if err != nil {
 		return err
@@ -248,6 +249,7 @@ func unmarshallParts(data []byte) ([]ContentPart, error) {
 			if err := json.Unmarshal(wrapper.Data, &part); err != nil {
 				return nil, err
 			}
+			parts = append(parts, part)
 		case binaryType:
 			part := BinaryContent{}
 			if err := json.Unmarshal(wrapper.Data, &part); err != nil {
@@ -0,0 +1,97 @@
+package note
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/opencode-ai/opencode/internal/db"
+)
+
+// ErrNotFound is returned when the session has no note of the name.
+var ErrNotFound = errors.New("note not found")
+
+// Note is a named note the agent keeps in a session, like its plan or what it
+// found out. Notes are stored apart from the messages, so they don't depend on
+// the earlier messages of the conversation.
+type Note struct {
+	SessionID string
+	Name      string
+	Content   string
+	CreatedAt int64
+	UpdatedAt int64
+}
+
+type Service interface {
+	Save(ctx context.Context, sessionID, name, content string) (Note, error)
+	Get(ctx context.Context, sessionID, name string) (Note, error)
+	List(ctx context.Context, sessionID string) ([]Note, error)
+	Delete(ctx context.Context, sessionID, name string) error
+}
+
+type service struct {
+	q db.Querier
+}
+
+// Save creates the note, or replaces the content of the note of the name.
+func (s *service) Save(ctx context.Context, sessionID, name, content string) (Note, error) {
+	dbNote, err := s.q.UpsertNote(ctx, db.UpsertNoteParams{
+		ID:        uuid.New().String(),
+		SessionID: sessionID,
+		Name:      name,
+		Content:   content,
+	})
+	if err != nil {
+		return Note{}, err
+	}
+	return fromDBItem(dbNote), nil
+}
+
+func (s *service) Get(ctx context.Context, sessionID, name string) (Note, error) {
+	dbNote, err := s.q.GetNote(ctx, db.GetNoteParams{
+		SessionID: sessionID,
+		Name:      name,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return Note{}, ErrNotFound
+	}
+	if err != nil {
+		return Note{}, err
+	}
+	return fromDBItem(dbNote), nil
+}
+
+// List returns the notes of the session, sorted by name.
+func (s *service) List(ctx context.Context, sessionID string) ([]Note, error) {
+	dbNotes, err := s.q.ListNotes(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	notes := make([]Note, len(dbNotes))
+	for i, dbNote := range dbNotes {
+		notes[i] = fromDBItem(dbNote)
+	}
+	return notes, nil
+}
+
+func (s *service) Delete(ctx context.Context, sessionID, name string) error {
+	return s.q.DeleteNote(ctx, db.DeleteNoteParams{
+		SessionID: sessionID,
+		Name:      name,
+	})
+}
+
+func fromDBItem(item db.Note) Note {
+	return Note{
+		SessionID: item.SessionID,
+		Name:      item.Name,
+		Content:   item.Content,
+		CreatedAt: item.CreatedAt,
+		UpdatedAt: item.UpdatedAt,
+	}
+}
+
+func NewService(q db.Querier) Service {
+	return &service{q: q}
+}
@@ -0,0 +1,52 @@
+package note
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	_ "github.com/ncruces/go-sqlite3/driver"
+	_ "github.com/ncruces/go-sqlite3/embed"
+	"github.com/opencode-ai/opencode/internal/db"
+	"github.com/pressly/goose/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotes(t *testing.T) {
+	conn, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+	defer conn.Close()
+	conn.SetMaxOpenConns(1)
+	goose.SetBaseFS(db.FS)
+	require.NoError(t, goose.SetDialect("sqlite3"))
+	require.NoError(t, goose.Up(conn, "migrations"))
+	q := db.New(conn)
+	ctx := context.Background()
+	for _, id := range []string{"s1", "s2"} {
+		_, err := q.CreateSession(ctx, db.CreateSessionParams{ID: id, Title: id})
+		require.NoError(t, err)
+	}
+
+	notes := NewService(q)
+	_, err = notes.Save(ctx, "s1", "plan", "1. find the bug")
+	require.NoError(t, err)
+	_, err = notes.Save(ctx, "s1", "findings", "parser drops the last token")
+	require.NoError(t, err)
+	saved, err := notes.Save(ctx, "s1", "plan", "1. find the bug\n2. fix it")
+	require.NoError(t, err)
+	assert.Equal(t, "1. find the bug\n2. fix it", saved.Content)
+
+	list, err := notes.List(ctx, "s1")
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	assert.Equal(t, "findings", list[0].Name)
+	assert.Equal(t, "plan", list[1].Name)
+
+	_, err = notes.Get(ctx, "s2", "plan")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	require.NoError(t, notes.Delete(ctx, "s1", "plan"))
+	_, err = notes.Get(ctx, "s1", "plan")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
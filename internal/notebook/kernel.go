@@ -0,0 +1,290 @@
+package notebook
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// kernelHelper runs a Jupyter kernel with jupyter_client. It reads code to
+// execute as lines of JSON from stdin and writes the outputs of each as a line
+// of JSON to stdout. SIGINT interrupts the running code.
+const kernelHelper = `
+import json, queue, signal, sys
+from jupyter_client.manager import start_new_kernel
+
+interrupted = False
+def interrupt(signum, frame):
+    global interrupted
+    interrupted = True
+signal.signal(signal.SIGINT, interrupt)
+
+km, kc = start_new_kernel(kernel_name=sys.argv[1])
+print(json.dumps({"ready": True}), flush=True)
+for line in sys.stdin:
+    msg_id = kc.execute(json.loads(line)["code"], allow_stdin=False)
+    outputs, count, status = [], None, "ok"
+    while True:
+        if interrupted:
+            interrupted = False
+            km.interrupt_kernel()
+        try:
+            msg = kc.get_iopub_msg(timeout=0.2)
+        except queue.Empty:
+            continue
+        if msg["parent_header"].get("msg_id") != msg_id:
+            continue
+        kind, content = msg["msg_type"], msg["content"]
+        if kind == "status" and content["execution_state"] == "idle":
+            break
+        if kind == "execute_input":
+            count = content.get("execution_count")
+        elif kind == "stream":
+            outputs.append({"output_type": "stream", "name": content["name"], "text": content["text"]})
+        elif kind in ("execute_result", "display_data"):
+            output = {"output_type": kind, "data": content["data"], "metadata": content.get("metadata", {})}
+            if kind == "execute_result":
+                output["execution_count"] = content.get("execution_count")
+            outputs.append(output)
+        elif kind == "error":
+            status = "error"
+            outputs.append({"output_type": "error", "ename": content["ename"], "evalue": content["evalue"], "traceback": content["traceback"]})
+        elif kind == "clear_output":
+            outputs = []
+    print(json.dumps({"outputs": outputs, "execution_count": count, "status": status}), flush=True)
+kc.stop_channels()
+km.shutdown_kernel(now=True)
+`
+
+// ErrKernelUnavailable is returned when Python or jupyter_client are not
+// installed.
+var ErrKernelUnavailable = errors.New("running notebooks needs Python 3 with jupyter_client and the kernel of the notebook, install them with pip install jupyter_client ipykernel")
+
+// interruptTimeout is how long interrupted code may take to stop before the
+// kernel is killed.
+const interruptTimeout = 10 * time.Second
+
+// Kernel is a running Jupyter kernel, the variables of the executed code are
+// kept between executions.
+type Kernel struct {
+	name   string
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	lines  chan []byte
+	stderr *lockedBuffer
+	mu     sync.Mutex
+}
+
+// Result are the outputs of executed code.
+type Result struct {
+	Outputs        []json.RawMessage `json:"outputs"`
+	ExecutionCount int               `json:"execution_count"`
+	Status         string            `json:"status"`
+}
+
+// StartKernel starts the kernel with the name in the directory.
+func StartKernel(ctx context.Context, name, dir string) (*Kernel, error) {
+	python, err := exec.LookPath("python3")
+	if err != nil {
+		if python, err = exec.LookPath("python"); err != nil {
+			return nil, ErrKernelUnavailable
+		}
+	}
+	cmd := exec.Command(python, "-c", kernelHelper, name)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "PYTHONUNBUFFERED=1")
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	k := &Kernel{
+		name:   name,
+		cmd:    cmd,
+		stdin:  stdin,
+		lines:  make(chan []byte, 1),
+		stderr: &lockedBuffer{},
+	}
+	cmd.Stderr = k.stderr
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start the kernel: %w", err)
+	}
+	go func() {
+		defer close(k.lines)
+		reader := bufio.NewReader(stdout)
+		for {
+			line, err := reader.ReadBytes('\n')
+			if err != nil {
+				return
+			}
+			k.lines <- line
+		}
+	}()
+
+	var ready struct {
+		Ready bool `json:"ready"`
+	}
+	if err := k.read(ctx, &ready); err != nil {
+		k.Close()
+		if strings.Contains(k.stderr.String(), "ModuleNotFoundError") {
+			return nil, ErrKernelUnavailable
+		}
+		return nil, fmt.Errorf("failed to start the %s kernel: %w", name, err)
+	}
+	return k, nil
+}
+
+// Name returns the name of the kernel.
+func (k *Kernel) Name() string {
+	return k.name
+}
+
+// Execute runs the code in the kernel. When the context is done the code is
+// interrupted, and the outputs until then are returned with the error of the
+// context.
+func (k *Kernel) Execute(ctx context.Context, code string) (Result, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	request, _ := json.Marshal(map[string]string{"code": code})
+	if _, err := k.stdin.Write(append(request, '\n')); err != nil {
+		return Result{}, k.exited()
+	}
+	var result Result
+	err := k.read(ctx, &result)
+	if err == nil || ctx.Err() == nil {
+		return result, err
+	}
+
+	// The helper interrupts the code and still sends its outputs
+	k.cmd.Process.Signal(os.Interrupt)
+	interruptCtx, cancel := context.WithTimeout(context.Background(), interruptTimeout)
+	defer cancel()
+	if err := k.read(interruptCtx, &result); err != nil {
+		k.cmd.Process.Kill()
+		return Result{}, errors.New("the code did not stop when interrupted, the kernel was killed")
+	}
+	return result, ctx.Err()
+}
+
+// read decodes the next line of the helper into v.
+func (k *Kernel) read(ctx context.Context, v any) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case line, ok := <-k.lines:
+		if !ok {
+			return k.exited()
+		}
+		return json.Unmarshal(line, v)
+	}
+}
+
+// exited returns the error of a kernel whose helper exited, with the last
+// line of its stderr, which names the Python exception.
+func (k *Kernel) exited() error {
+	stderr := strings.TrimSpace(k.stderr.String())
+	if stderr == "" {
+		return errors.New("the kernel exited")
+	}
+	lines := strings.Split(stderr, "\n")
+	return fmt.Errorf("the kernel exited: %s", lines[len(lines)-1])
+}
+
+// Close shuts the kernel down.
+func (k *Kernel) Close() {
+	k.stdin.Close()
+	done := make(chan struct{})
+	go func() {
+		k.cmd.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(interruptTimeout):
+		k.cmd.Process.Kill()
+		<-done
+	}
+}
+
+// lockedBuffer is a buffer for the stderr of the helper, which is written
+// while it is read.
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// kernels are the running kernels by the path of their notebook.
+var kernels = struct {
+	sync.Mutex
+	byPath map[string]*Kernel
+}{byPath: make(map[string]*Kernel)}
+
+// KernelFor returns the running kernel of the notebook at the path, and
+// starts one with the name when it has none, or a kernel of another name. It
+// reports whether the kernel was started.
+func KernelFor(ctx context.Context, path, name string) (*Kernel, bool, error) {
+	kernels.Lock()
+	defer kernels.Unlock()
+	if k, ok := kernels.byPath[path]; ok {
+		if k.name == name {
+			return k, false, nil
+		}
+		delete(kernels.byPath, path)
+		k.Close()
+	}
+	k, err := StartKernel(ctx, name, filepath.Dir(path))
+	if err != nil {
+		return nil, false, err
+	}
+	kernels.byPath[path] = k
+	return k, true, nil
+}
+
+// CloseKernel shuts the kernel of the notebook at the path down, its
+// variables are lost.
+func CloseKernel(path string) {
+	kernels.Lock()
+	k, ok := kernels.byPath[path]
+	delete(kernels.byPath, path)
+	kernels.Unlock()
+	if ok {
+		k.Close()
+	}
+}
+
+// CloseKernels shuts all kernels down.
+func CloseKernels() {
+	kernels.Lock()
+	running := kernels.byPath
+	kernels.byPath = make(map[string]*Kernel)
+	kernels.Unlock()
+	for _, k := range running {
+		k.Close()
+	}
+}
@@ -0,0 +1,289 @@
+// Package notebook reads and edits Jupyter notebooks and executes their code
+// cells in Jupyter kernels.
+package notebook
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Cell types of notebooks.
+const (
+	CellCode     = "code"
+	CellMarkdown = "markdown"
+	CellRaw      = "raw"
+)
+
+// Notebook is a Jupyter notebook in the nbformat 4 format. The fields the
+// package doesn't know, like metadata, are kept as they are.
+type Notebook struct {
+	doc   map[string]any
+	cells []map[string]any
+}
+
+// Cell is a cell of a notebook.
+type Cell struct {
+	ID             string
+	Type           string
+	Source         string
+	ExecutionCount int
+	Outputs        []Output
+}
+
+// Output is an output of a code cell. Streams have a Name and Text, results
+// and display data the Data of their MIME types, and errors an EName, EValue
+// and Traceback.
+type Output struct {
+	Type      string         `json:"output_type"`
+	Name      string         `json:"name,omitempty"`
+	Text      any            `json:"text,omitempty"`
+	Data      map[string]any `json:"data,omitempty"`
+	EName     string         `json:"ename,omitempty"`
+	EValue    string         `json:"evalue,omitempty"`
+	Traceback []string       `json:"traceback,omitempty"`
+}
+
+// Parse parses a notebook.
+func Parse(data []byte) (*Notebook, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var doc map[string]any
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("invalid notebook: %w", err)
+	}
+	if major, _ := doc["nbformat"].(json.Number); major.String() != "4" {
+		return nil, fmt.Errorf("unsupported notebook format %v, only nbformat 4 is supported", doc["nbformat"])
+	}
+	nb := &Notebook{doc: doc}
+	rawCells, _ := doc["cells"].([]any)
+	for _, rawCell := range rawCells {
+		cell, ok := rawCell.(map[string]any)
+		if !ok {
+			return nil, errors.New("invalid notebook: a cell is not an object")
+		}
+		nb.cells = append(nb.cells, cell)
+	}
+	return nb, nil
+}
+
+// Marshal returns the notebook as Jupyter writes it, with sorted keys and an
+// indent of one space.
+func (nb *Notebook) Marshal() ([]byte, error) {
+	cells := make([]any, len(nb.cells))
+	for i, cell := range nb.cells {
+		cells[i] = cell
+	}
+	nb.doc["cells"] = cells
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", " ")
+	if err := encoder.Encode(nb.doc); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// KernelName returns the name of the kernel of the notebook, python3 when it
+// has none.
+func (nb *Notebook) KernelName() string {
+	metadata, _ := nb.doc["metadata"].(map[string]any)
+	kernelspec, _ := metadata["kernelspec"].(map[string]any)
+	if name, _ := kernelspec["name"].(string); name != "" {
+		return name
+	}
+	return "python3"
+}
+
+// Len returns the number of cells.
+func (nb *Notebook) Len() int {
+	return len(nb.cells)
+}
+
+// Cell returns the cell at the index.
+func (nb *Notebook) Cell(index int) Cell {
+	raw := nb.cells[index]
+	cell := Cell{
+		Source: joinText(raw["source"]),
+	}
+	cell.ID, _ = raw["id"].(string)
+	cell.Type, _ = raw["cell_type"].(string)
+	if count, ok := raw["execution_count"].(json.Number); ok {
+		n, _ := count.Int64()
+		cell.ExecutionCount = int(n)
+	}
+	outputs, _ := raw["outputs"].([]any)
+	for _, rawOutput := range outputs {
+		data, err := json.Marshal(rawOutput)
+		if err != nil {
+			continue
+		}
+		var output Output
+		if err := json.Unmarshal(data, &output); err == nil {
+			cell.Outputs = append(cell.Outputs, output)
+		}
+	}
+	return cell
+}
+
+func (nb *Notebook) checkIndex(index int) error {
+	if index < 0 || index >= len(nb.cells) {
+		return fmt.Errorf("cell index %d is out of range, the notebook has %d cells", index, len(nb.cells))
+	}
+	return nil
+}
+
+// ReplaceCell changes the source and, unless it is empty, the type of a cell.
+// The outputs of a code cell are cleared as they belong to the old source.
+func (nb *Notebook) ReplaceCell(index int, cellType, source string) error {
+	if err := nb.checkIndex(index); err != nil {
+		return err
+	}
+	cell := nb.cells[index]
+	if cellType == "" {
+		cellType, _ = cell["cell_type"].(string)
+	}
+	if err := checkCellType(cellType); err != nil {
+		return err
+	}
+	cell["cell_type"] = cellType
+	cell["source"] = splitLines(source)
+	setCellFields(cell, cellType)
+	return nil
+}
+
+// InsertCell inserts a cell before the index, or at the end when the index is
+// the number of cells.
+func (nb *Notebook) InsertCell(index int, cellType, source string) error {
+	if index < 0 || index > len(nb.cells) {
+		return fmt.Errorf("cell index %d is out of range, the notebook has %d cells", index, len(nb.cells))
+	}
+	if err := checkCellType(cellType); err != nil {
+		return err
+	}
+	cell := map[string]any{
+		"cell_type": cellType,
+		"metadata":  map[string]any{},
+		"source":    splitLines(source),
+	}
+	// Cells have ids since nbformat 4.5
+	if minor, _ := nb.doc["nbformat_minor"].(json.Number); minor != "" {
+		if n, _ := minor.Int64(); n >= 5 {
+			cell["id"] = newCellID()
+		}
+	}
+	setCellFields(cell, cellType)
+	nb.cells = append(nb.cells[:index], append([]map[string]any{cell}, nb.cells[index:]...)...)
+	return nil
+}
+
+// DeleteCell removes the cell at the index.
+func (nb *Notebook) DeleteCell(index int) error {
+	if err := nb.checkIndex(index); err != nil {
+		return err
+	}
+	nb.cells = append(nb.cells[:index], nb.cells[index+1:]...)
+	return nil
+}
+
+// SetOutputs replaces the outputs and the execution count of a code cell.
+func (nb *Notebook) SetOutputs(index int, outputs []json.RawMessage, executionCount int) error {
+	if err := nb.checkIndex(index); err != nil {
+		return err
+	}
+	cell := nb.cells[index]
+	if cellType, _ := cell["cell_type"].(string); cellType != CellCode {
+		return fmt.Errorf("cell %d is a %s cell, not a code cell", index, cellType)
+	}
+	rawOutputs := make([]any, 0, len(outputs))
+	for _, output := range outputs {
+		decoder := json.NewDecoder(bytes.NewReader(output))
+		decoder.UseNumber()
+		var rawOutput map[string]any
+		if err := decoder.Decode(&rawOutput); err != nil {
+			return fmt.Errorf("invalid output: %w", err)
+		}
+		rawOutputs = append(rawOutputs, rawOutput)
+	}
+	cell["outputs"] = rawOutputs
+	cell["execution_count"] = nil
+	if executionCount > 0 {
+		cell["execution_count"] = json.Number(strconv.Itoa(executionCount))
+	}
+	return nil
+}
+
+// setCellFields sets the fields a cell of the type must have, and removes
+// those it must not have.
+func setCellFields(cell map[string]any, cellType string) {
+	if cellType == CellCode {
+		cell["outputs"] = []any{}
+		cell["execution_count"] = nil
+		return
+	}
+	delete(cell, "outputs")
+	delete(cell, "execution_count")
+}
+
+func checkCellType(cellType string) error {
+	switch cellType {
+	case CellCode, CellMarkdown, CellRaw:
+		return nil
+	}
+	return fmt.Errorf("invalid cell type %q, must be one of: code, markdown, raw", cellType)
+}
+
+func newCellID() string {
+	b := make([]byte, 4)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// joinText returns the text of a multiline string of a notebook, which is a
+// string or a list of lines.
+func joinText(value any) string {
+	switch value := value.(type) {
+	case string:
+		return value
+	case []any:
+		var text strings.Builder
+		for _, line := range value {
+			if line, ok := line.(string); ok {
+				text.WriteString(line)
+			}
+		}
+		return text.String()
+	}
+	return ""
+}
+
+// splitLines returns the text as the list of lines, with their line endings,
+// Jupyter writes.
+func splitLines(text string) []any {
+	lines := []any{}
+	for text != "" {
+		line, rest, found := strings.Cut(text, "\n")
+		if found {
+			line += "\n"
+		}
+		lines = append(lines, line)
+		text = rest
+	}
+	return lines
+}
+
+// TextOf returns the text of a stream output, or of the text/plain data of a
+// result.
+func (o Output) TextOf() string {
+	if o.Type == "stream" {
+		return joinText(o.Text)
+	}
+	return joinText(o.Data["text/plain"])
+}
@@ -0,0 +1,113 @@
+package notebook
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testNotebook = `{
+ "cells": [
+  {
+   "cell_type": "markdown",
+   "id": "a1",
+   "metadata": {},
+   "source": [
+    "# Sales <2024>\n",
+    "By region"
+   ]
+  },
+  {
+   "cell_type": "code",
+   "execution_count": 3,
+   "id": "b2",
+   "metadata": {
+    "tags": [
+     "setup"
+    ]
+   },
+   "outputs": [
+    {
+     "name": "stdout",
+     "output_type": "stream",
+     "text": [
+      "loaded 12 rows\n"
+     ]
+    }
+   ],
+   "source": "import pandas as pd\ndf = pd.read_csv(\"sales.csv\")"
+  }
+ ],
+ "metadata": {
+  "kernelspec": {
+   "display_name": "Python 3",
+   "language": "python",
+   "name": "python3"
+  }
+ },
+ "nbformat": 4,
+ "nbformat_minor": 5
+}
+`
+
+func TestParseAndMarshal(t *testing.T) {
+	nb, err := Parse([]byte(testNotebook))
+	require.NoError(t, err)
+	assert.Equal(t, "python3", nb.KernelName())
+	require.Equal(t, 2, nb.Len())
+
+	assert.Equal(t, Cell{ID: "a1", Type: CellMarkdown, Source: "# Sales <2024>\nBy region"}, nb.Cell(0))
+	code := nb.Cell(1)
+	assert.Equal(t, 3, code.ExecutionCount)
+	assert.Equal(t, "import pandas as pd\ndf = pd.read_csv(\"sales.csv\")", code.Source)
+	require.Len(t, code.Outputs, 1)
+	assert.Equal(t, "loaded 12 rows\n", code.Outputs[0].TextOf())
+
+	// Unchanged cells and metadata are written like Jupyter writes them
+	data, err := nb.Marshal()
+	require.NoError(t, err)
+	assert.Equal(t, testNotebook, string(data))
+
+	_, err = Parse([]byte(`{"nbformat": 3, "worksheets": []}`))
+	assert.Error(t, err)
+}
+
+func TestEditCells(t *testing.T) {
+	nb, err := Parse([]byte(testNotebook))
+	require.NoError(t, err)
+
+	require.NoError(t, nb.ReplaceCell(1, "", "df.head()\n"))
+	cell := nb.Cell(1)
+	assert.Equal(t, CellCode, cell.Type)
+	assert.Equal(t, "df.head()\n", cell.Source)
+	assert.Empty(t, cell.Outputs)
+	assert.Zero(t, cell.ExecutionCount)
+
+	require.NoError(t, nb.InsertCell(2, CellCode, "df.describe()"))
+	assert.Len(t, nb.Cell(2).ID, 8)
+	assert.Error(t, nb.InsertCell(4, CellCode, ""))
+	assert.Error(t, nb.InsertCell(0, "python", ""))
+
+	require.NoError(t, nb.SetOutputs(2, []json.RawMessage{
+		json.RawMessage(`{"output_type": "execute_result", "data": {"text/plain": ["count 12"], "text/html": "<table/>"}, "execution_count": 4, "metadata": {}}`),
+	}, 4))
+	cell = nb.Cell(2)
+	assert.Equal(t, 4, cell.ExecutionCount)
+	assert.Equal(t, "count 12", cell.Outputs[0].TextOf())
+	assert.Error(t, nb.SetOutputs(0, nil, 1))
+
+	require.NoError(t, nb.ReplaceCell(1, CellMarkdown, "Notes"))
+	require.NoError(t, nb.DeleteCell(0))
+	assert.Equal(t, 2, nb.Len())
+	assert.Equal(t, CellMarkdown, nb.Cell(0).Type)
+	assert.Error(t, nb.DeleteCell(2))
+
+	data, err := nb.Marshal()
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), `"outputs": []`)
+	reparsed, err := Parse(data)
+	require.NoError(t, err)
+	assert.Equal(t, nb.Cell(1), reparsed.Cell(1))
+}
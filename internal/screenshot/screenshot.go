@@ -0,0 +1,161 @@
+// Package screenshot captures regions of the screen and renders frames of the
+// TUI as PNG images, so they can be attached to a prompt for models that can
+// see images.
+package screenshot
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// MIMEType is the type of the images returned by the package.
+const MIMEType = "image/png"
+
+var (
+	// ErrUnavailable is returned when no screenshot command is installed.
+	ErrUnavailable = errors.New("no screenshot command found, install grim and slurp, maim or ImageMagick")
+	// ErrNoRenderer is returned when freeze, which renders the TUI, is not installed.
+	ErrNoRenderer = errors.New("rendering the TUI needs freeze, install it from https://github.com/charmbracelet/freeze")
+)
+
+var (
+	lookPath = exec.LookPath
+	getenv   = os.Getenv
+	goos     = runtime.GOOS
+)
+
+// capturer is a command that lets the user select a region of the screen and
+// returns it as a PNG image.
+type capturer struct {
+	name     string
+	requires []string
+	capture  func(ctx context.Context) ([]byte, error)
+}
+
+// capturers returns the screenshot commands that are installed, in the order
+// they are tried.
+func capturers() []capturer {
+	var candidates []capturer
+	switch goos {
+	case "darwin":
+		candidates = append(candidates, capturer{"screencapture", []string{"screencapture"}, func(ctx context.Context) ([]byte, error) {
+			return captureToFile(ctx, "screencapture", "-i", "-x", "-t", "png")
+		}})
+	case "windows":
+	default:
+		if getenv("WAYLAND_DISPLAY") != "" {
+			candidates = append(candidates, capturer{"grim", []string{"grim", "slurp"}, func(ctx context.Context) ([]byte, error) {
+				geometry, err := run(ctx, "slurp")
+				if err != nil {
+					return nil, err
+				}
+				return run(ctx, "grim", "-g", strings.TrimSpace(string(geometry)), "-")
+			}})
+		}
+		if getenv("DISPLAY") != "" {
+			candidates = append(candidates,
+				capturer{"maim", []string{"maim"}, func(ctx context.Context) ([]byte, error) {
+					return run(ctx, "maim", "--select", "--format", "png")
+				}},
+				capturer{"import", []string{"import"}, func(ctx context.Context) ([]byte, error) {
+					return run(ctx, "import", "png:-")
+				}},
+			)
+		}
+	}
+
+	var installed []capturer
+	for _, c := range candidates {
+		if c.available() {
+			installed = append(installed, c)
+		}
+	}
+	return installed
+}
+
+func (c capturer) available() bool {
+	for _, name := range c.requires {
+		if _, err := lookPath(name); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// CaptureRegion lets the user select a region of the screen with the mouse
+// and returns it as a PNG image.
+func CaptureRegion(ctx context.Context) ([]byte, error) {
+	installed := capturers()
+	if len(installed) == 0 {
+		return nil, ErrUnavailable
+	}
+	data, err := installed[0].capture(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", installed[0].name, err)
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("%s: the selection was cancelled", installed[0].name)
+	}
+	return data, nil
+}
+
+// RenderFrame renders a frame of the TUI, text with ANSI escape sequences, as
+// a PNG image with freeze.
+func RenderFrame(ctx context.Context, frame string) ([]byte, error) {
+	if _, err := lookPath("freeze"); err != nil {
+		return nil, ErrNoRenderer
+	}
+	dir, err := os.MkdirTemp("", "opencode-screenshot-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	output := filepath.Join(dir, "frame.png")
+	cmd := exec.CommandContext(ctx, "freeze", "--language", "ansi", "--output", output)
+	cmd.Stdin = strings.NewReader(frame)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("freeze: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return os.ReadFile(output)
+}
+
+// run returns the stdout of a command.
+func run(ctx context.Context, name string, args ...string) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.Bytes(), nil
+}
+
+// captureToFile runs a command that writes the image to the file given as its
+// last argument, and no file when the user cancels the selection.
+func captureToFile(ctx context.Context, name string, args ...string) ([]byte, error) {
+	dir, err := os.MkdirTemp("", "opencode-screenshot-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	output := filepath.Join(dir, "region.png")
+	if _, err := run(ctx, name, append(args, output)...); err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(output)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	return data, err
+}
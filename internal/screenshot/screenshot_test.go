@@ -0,0 +1,52 @@
+package screenshot
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCapturers(t *testing.T) {
+	defer func(l func(string) (string, error), g func(string) string, o string) {
+		lookPath, getenv, goos = l, g, o
+	}(lookPath, getenv, goos)
+
+	installed := []string{"screencapture", "grim", "import"}
+	lookPath = func(file string) (string, error) {
+		if slices.Contains(installed, file) {
+			return "/usr/bin/" + file, nil
+		}
+		return "", exec.ErrNotFound
+	}
+	env := map[string]string{}
+	getenv = func(key string) string { return env[key] }
+	names := func() []string {
+		var names []string
+		for _, c := range capturers() {
+			names = append(names, c.name)
+		}
+		return names
+	}
+
+	goos = "darwin"
+	assert.Equal(t, []string{"screencapture"}, names())
+
+	goos = "linux"
+	assert.Empty(t, names())
+	env["WAYLAND_DISPLAY"] = "wayland-0"
+	env["DISPLAY"] = ":0"
+	// grim needs slurp to select the region
+	assert.Equal(t, []string{"import"}, names())
+	installed = append(installed, "slurp")
+	assert.Equal(t, []string{"grim", "import"}, names())
+
+	installed = nil
+	_, err := CaptureRegion(context.Background())
+	assert.True(t, errors.Is(err, ErrUnavailable))
+	_, err = RenderFrame(context.Background(), "frame")
+	assert.True(t, errors.Is(err, ErrNoRenderer))
+}
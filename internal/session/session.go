@@ -17,8 +17,29 @@ type Session struct {
 	PromptTokens     int64
 	CompletionTokens int64
 	Cost             float64
-	CreatedAt        int64
-	UpdatedAt        int64
+	// ContextTokens is the size of the conversation sent with the last turn
+	ContextTokens int64
+	// ToolProfile is the name of the tool profile restricting the tools
+	// offered in the session, empty for the default one
+	ToolProfile string
+	// Summary replaces the messages before SummaryMessageID in the
+	// conversation sent to the model, after it was compacted
+	Summary          string
+	SummaryMessageID string
+	// PlanMode offers only the read-only tools, the agent proposes a plan
+	// for the user to approve before it changes anything
+	PlanMode  bool
+	CreatedAt int64
+	UpdatedAt int64
+}
+
+// Usage is the usage of a request added to a session.
+type Usage struct {
+	PromptTokens     int64
+	CompletionTokens int64
+	Cost             float64
+	// ContextTokens replaces the size of the conversation when it isn't 0
+	ContextTokens int64
 }
 
 type Service interface {
@@ -28,7 +49,13 @@ type Service interface {
 	CreateTaskSession(ctx context.Context, toolCallID, parentSessionID, title string) (Session, error)
 	Get(ctx context.Context, id string) (Session, error)
 	List(ctx context.Context) ([]Session, error)
+	// Save updates the session, except its tokens and cost which only
+	// AddUsage changes, so saving a copy read before a request finished
+	// doesn't drop the usage of the request.
 	Save(ctx context.Context, session Session) (Session, error)
+	// AddUsage adds the usage to the session in a single update, requests
+	// running at the same time don't lose each other's usage.
+	AddUsage(ctx context.Context, id string, usage Usage) (Session, error)
 	Delete(ctx context.Context, id string) error
 }
 
@@ -103,9 +130,11 @@ func (s *service) Save(ctx context.Context, session Session) (Session, error) {
 	dbSession, err := s.q.UpdateSession(ctx, db.UpdateSessionParams{
 		ID:               session.ID,
 		Title:            session.Title,
-		PromptTokens:     session.PromptTokens,
-		CompletionTokens: session.CompletionTokens,
-		Cost:             session.Cost,
+		ContextTokens:    session.ContextTokens,
+		ToolProfile:      session.ToolProfile,
+		Summary:          session.Summary,
+		SummaryMessageID: session.SummaryMessageID,
+		PlanMode:         session.PlanMode,
 	})
 	if err != nil {
 		return Session{}, err
@@ -115,6 +144,22 @@ func (s *service) Save(ctx context.Context, session Session) (Session, error) {
 	return session, nil
 }
 
+func (s *service) AddUsage(ctx context.Context, id string, usage Usage) (Session, error) {
+	dbSession, err := s.q.AddSessionUsage(ctx, db.AddSessionUsageParams{
+		PromptTokens:     usage.PromptTokens,
+		CompletionTokens: usage.CompletionTokens,
+		Cost:             usage.Cost,
+		ContextTokens:    usage.ContextTokens,
+		ID:               id,
+	})
+	if err != nil {
+		return Session{}, err
+	}
+	session := s.fromDBItem(dbSession)
+	s.Publish(pubsub.UpdatedEvent, session)
+	return session, nil
+}
+
 func (s *service) List(ctx context.Context) ([]Session, error) {
 	dbSessions, err := s.q.ListSessions(ctx)
 	if err != nil {
@@ -136,6 +181,11 @@ func (s service) fromDBItem(item db.Session) Session {
 		PromptTokens:     item.PromptTokens,
 		CompletionTokens: item.CompletionTokens,
 		Cost:             item.Cost,
+		ContextTokens:    item.ContextTokens,
+		ToolProfile:      item.ToolProfile,
+		Summary:          item.Summary,
+		SummaryMessageID: item.SummaryMessageID,
+		PlanMode:         item.PlanMode,
 		CreatedAt:        item.CreatedAt,
 		UpdatedAt:        item.UpdatedAt,
 	}
@@ -0,0 +1,117 @@
+package todo
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/opencode-ai/opencode/internal/db"
+	"github.com/opencode-ai/opencode/internal/pubsub"
+)
+
+type Status string
+
+const (
+	StatusPending    Status = "pending"
+	StatusInProgress Status = "in_progress"
+	StatusCompleted  Status = "completed"
+	StatusCancelled  Status = "cancelled"
+)
+
+// Valid reports whether the status is one of the known ones.
+func (s Status) Valid() bool {
+	switch s {
+	case StatusPending, StatusInProgress, StatusCompleted, StatusCancelled:
+		return true
+	}
+	return false
+}
+
+// Item is a task of the todo list.
+type Item struct {
+	Content string
+	Status  Status
+}
+
+// List is the todo list the agent keeps for the job it is working on in a
+// session, the items are in the order they are done.
+type List struct {
+	SessionID string
+	Items     []Item
+}
+
+// Progress returns how many items are finished, completed or cancelled, and
+// the number of items.
+func (l List) Progress() (int, int) {
+	done := 0
+	for _, item := range l.Items {
+		if item.Status == StatusCompleted || item.Status == StatusCancelled {
+			done++
+		}
+	}
+	return done, len(l.Items)
+}
+
+type Service interface {
+	pubsub.Suscriber[List]
+	Get(ctx context.Context, sessionID string) (List, error)
+	Set(ctx context.Context, sessionID string, items []Item) (List, error)
+}
+
+type service struct {
+	*pubsub.Broker[List]
+	db *sql.DB
+	q  *db.Queries
+}
+
+func NewService(q *db.Queries, db *sql.DB) Service {
+	return &service{
+		Broker: pubsub.NewBroker[List](),
+		q:      q,
+		db:     db,
+	}
+}
+
+func (s *service) Get(ctx context.Context, sessionID string) (List, error) {
+	dbTodos, err := s.q.ListTodos(ctx, sessionID)
+	if err != nil {
+		return List{}, err
+	}
+	list := List{SessionID: sessionID, Items: make([]Item, len(dbTodos))}
+	for i, dbTodo := range dbTodos {
+		list.Items[i] = Item{Content: dbTodo.Content, Status: Status(dbTodo.Status)}
+	}
+	return list, nil
+}
+
+// Set replaces the todo list of the session.
+func (s *service) Set(ctx context.Context, sessionID string, items []Item) (List, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return List{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	qtx := s.q.WithTx(tx)
+	if err := qtx.DeleteSessionTodos(ctx, sessionID); err != nil {
+		return List{}, err
+	}
+	for i, item := range items {
+		if err := qtx.CreateTodo(ctx, db.CreateTodoParams{
+			ID:        uuid.New().String(),
+			SessionID: sessionID,
+			Position:  int64(i),
+			Content:   item.Content,
+			Status:    string(item.Status),
+		}); err != nil {
+			return List{}, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return List{}, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	list := List{SessionID: sessionID, Items: items}
+	s.Publish(pubsub.UpdatedEvent, list)
+	return list, nil
+}
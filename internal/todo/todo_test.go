@@ -0,0 +1,62 @@
+package todo
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	_ "github.com/ncruces/go-sqlite3/driver"
+	_ "github.com/ncruces/go-sqlite3/embed"
+	"github.com/opencode-ai/opencode/internal/db"
+	"github.com/opencode-ai/opencode/internal/pubsub"
+	"github.com/pressly/goose/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTodos(t *testing.T) {
+	conn, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+	defer conn.Close()
+	conn.SetMaxOpenConns(1)
+	goose.SetBaseFS(db.FS)
+	require.NoError(t, goose.SetDialect("sqlite3"))
+	require.NoError(t, goose.Up(conn, "migrations"))
+	q := db.New(conn)
+	ctx := context.Background()
+	for _, id := range []string{"s1", "s2"} {
+		_, err := q.CreateSession(ctx, db.CreateSessionParams{ID: id, Title: id})
+		require.NoError(t, err)
+	}
+
+	todos := NewService(q, conn)
+	events := todos.Subscribe(ctx)
+	_, err = todos.Set(ctx, "s1", []Item{
+		{Content: "rename the config loader", Status: StatusInProgress},
+		{Content: "update the callers", Status: StatusPending},
+	})
+	require.NoError(t, err)
+	items := []Item{
+		{Content: "rename the config loader", Status: StatusCompleted},
+		{Content: "update the callers", Status: StatusInProgress},
+		{Content: "run the tests", Status: StatusPending},
+	}
+	_, err = todos.Set(ctx, "s1", items)
+	require.NoError(t, err)
+
+	list, err := todos.Get(ctx, "s1")
+	require.NoError(t, err)
+	assert.Equal(t, items, list.Items)
+	done, total := list.Progress()
+	assert.Equal(t, 1, done)
+	assert.Equal(t, 3, total)
+
+	list, err = todos.Get(ctx, "s2")
+	require.NoError(t, err)
+	assert.Empty(t, list.Items)
+
+	event := <-events
+	assert.Equal(t, pubsub.UpdatedEvent, event.Type)
+	assert.Equal(t, "s1", event.Payload.SessionID)
+	assert.Len(t, event.Payload.Items, 2)
+}
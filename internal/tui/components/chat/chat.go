@@ -2,18 +2,31 @@ package chat
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 	"sort"
+	"strings"
 
 	"github.com/charmbracelet/lipgloss"
 	"github.com/charmbracelet/x/ansi"
 	"github.com/opencode-ai/opencode/internal/config"
+	"github.com/opencode-ai/opencode/internal/llm/prompt"
+	"github.com/opencode-ai/opencode/internal/message"
 	"github.com/opencode-ai/opencode/internal/session"
 	"github.com/opencode-ai/opencode/internal/tui/styles"
 	"github.com/opencode-ai/opencode/internal/version"
 )
 
 type SendMsg struct {
-	Text string
+	Text        string
+	Attachments []message.BinaryContent
+}
+
+// AttachMsg adds an image, like a screenshot, to the next message of the
+// editor.
+type AttachMsg struct {
+	Name       string
+	Attachment message.BinaryContent
 }
 
 type SessionSelectedMsg = session.Session
@@ -22,6 +35,96 @@ type SessionClearedMsg struct{}
 
 type EditorFocusMsg bool
 
+// CopyMsg copies the last response of the assistant to the clipboard, or only
+// its last code block.
+type CopyMsg struct {
+	CodeBlock bool
+}
+
+// ToggleTodosMsg collapses or expands the todo list of the sidebar.
+type ToggleTodosMsg struct{}
+
+// ToggleReasoningMsg expands or collapses the reasoning of the responses.
+type ToggleReasoningMsg struct{}
+
+// TogglePlanModeMsg turns plan mode of the session on or off.
+type TogglePlanModeMsg struct{}
+
+// PlanProposedMsg is sent when the assistant finished a response in plan mode,
+// the plan is its text.
+type PlanProposedMsg struct {
+	SessionID string
+	Plan      string
+}
+
+// ExecutePlanMsg turns plan mode of the session off and asks the assistant to
+// carry out the plan.
+type ExecutePlanMsg struct {
+	SessionID string
+	Plan      string
+}
+
+// BudgetReachedMsg is sent when the turn of the session paused at a limit of
+// its budget.
+type BudgetReachedMsg struct {
+	SessionID string
+	Reason    string
+}
+
+// instructionsLoaded lists the instruction files of the user and of the
+// project in the system prompt, empty when there are none.
+func instructionsLoaded(width int) string {
+	files := prompt.ContextFiles()
+	if len(files) == 0 {
+		return ""
+	}
+	title := ansi.Truncate("Instructions", width, "…")
+	views := []string{styles.BaseStyle.Width(width).Foreground(styles.PrimaryColor).Bold(true).Render(title)}
+	for _, f := range files {
+		note := fmt.Sprintf(" (%s)", formatSize(f.Size))
+		switch {
+		case f.Skipped:
+			note = " (skipped, over the size limit)"
+		case f.Truncated:
+			note = fmt.Sprintf(" (%s, cut to the size limit)", formatSize(f.Size))
+		case f.User:
+			note = fmt.Sprintf(" (%s, all projects)", formatSize(f.Size))
+		}
+		name := styles.BaseStyle.Foreground(styles.Forground).Render(
+			"• " + ansi.Truncate(contextFilePath(f.Path), width-lipgloss.Width(note)-2, "…"),
+		)
+		views = append(views, styles.BaseStyle.Width(width).Render(
+			lipgloss.JoinHorizontal(
+				lipgloss.Left,
+				name,
+				styles.BaseStyle.Foreground(styles.ForgroundDim).Render(note),
+			),
+		))
+	}
+	return styles.BaseStyle.Width(width).Render(lipgloss.JoinVertical(lipgloss.Left, views...))
+}
+
+// contextFilePath shortens the path of an instruction file to the project or
+// the home directory.
+func contextFilePath(path string) string {
+	if rel, err := filepath.Rel(config.WorkingDirectory(), path); err == nil && !strings.HasPrefix(rel, "..") {
+		return rel
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		if rel, err := filepath.Rel(home, path); err == nil && !strings.HasPrefix(rel, "..") {
+			return filepath.Join("~", rel)
+		}
+	}
+	return path
+}
+
+func formatSize(n int) string {
+	if n < 1000 {
+		return fmt.Sprintf("%d B", n)
+	}
+	return fmt.Sprintf("%.1f kB", float64(n)/1000)
+}
+
 func lspsConfigured(width int) string {
 	cfg := config.Get()
 	title := "LSP Configuration"
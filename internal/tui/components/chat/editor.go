@@ -1,14 +1,19 @@
 package chat
 
 import (
+	"fmt"
 	"os"
 	"os/exec"
+	"strings"
 
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/textarea"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
 	"github.com/opencode-ai/opencode/internal/app"
+	"github.com/opencode-ai/opencode/internal/clipboard"
+	"github.com/opencode-ai/opencode/internal/message"
 	"github.com/opencode-ai/opencode/internal/session"
 	"github.com/opencode-ai/opencode/internal/tui/layout"
 	"github.com/opencode-ai/opencode/internal/tui/styles"
@@ -16,14 +21,19 @@ import (
 )
 
 type editorCmp struct {
-	app      *app.App
-	session  session.Session
-	textarea textarea.Model
+	app         *app.App
+	session     session.Session
+	textarea    textarea.Model
+	width       int
+	height      int
+	attachments []AttachMsg
 }
 
 type EditorKeyMaps struct {
 	Send       key.Binding
 	OpenEditor key.Binding
+	Paste      key.Binding
+	Detach     key.Binding
 }
 
 type bluredEditorKeyMaps struct {
@@ -41,6 +51,14 @@ var editorMaps = EditorKeyMaps{
 		key.WithKeys("ctrl+e"),
 		key.WithHelp("ctrl+e", "open editor"),
 	),
+	Paste: key.NewBinding(
+		key.WithKeys("ctrl+v"),
+		key.WithHelp("ctrl+v", "paste clipboard"),
+	),
+	Detach: key.NewBinding(
+		key.WithKeys("ctrl+r"),
+		key.WithHelp("ctrl+r", "remove attachments"),
+	),
 }
 
 func openEditor() tea.Cmd {
@@ -70,12 +88,14 @@ func openEditor() tea.Cmd {
 			return util.ReportWarn("Message is empty")
 		}
 		os.Remove(tmpfile.Name())
-		return SendMsg{
-			Text: string(content),
-		}
+		return externalEditorMsg(content)
 	})
 }
 
+// externalEditorMsg is the message written in the external editor, it is sent
+// with the attachments of the editor.
+type externalEditorMsg string
+
 func (m *editorCmp) Init() tea.Cmd {
 	return textarea.Blink
 }
@@ -87,16 +107,33 @@ func (m *editorCmp) send() tea.Cmd {
 
 	value := m.textarea.Value()
 	m.textarea.Reset()
+	return m.sendText(value)
+}
+
+func (m *editorCmp) sendText(value string) tea.Cmd {
 	if value == "" {
 		return nil
 	}
+	var attachments []message.BinaryContent
+	for _, attachment := range m.attachments {
+		attachments = append(attachments, attachment.Attachment)
+	}
+	m.setAttachments(nil)
 	return tea.Batch(
 		util.CmdHandler(SendMsg{
-			Text: value,
+			Text:        value,
+			Attachments: attachments,
 		}),
 	)
 }
 
+// setAttachments changes the attachments of the next message, they take a
+// line of the editor.
+func (m *editorCmp) setAttachments(attachments []AttachMsg) {
+	m.attachments = attachments
+	m.SetSize(m.width, m.height)
+}
+
 func (m *editorCmp) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmd tea.Cmd
 	switch msg := msg.(type) {
@@ -105,6 +142,11 @@ func (m *editorCmp) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.session = msg
 		}
 		return m, nil
+	case externalEditorMsg:
+		return m, m.sendText(string(msg))
+	case AttachMsg:
+		m.setAttachments(append(m.attachments, msg))
+		return m, util.ReportInfo("Attached " + msg.Name + " to the next message")
 	case tea.KeyMsg:
 		if key.Matches(msg, messageKeys.PageUp) || key.Matches(msg, messageKeys.PageDown) ||
 			key.Matches(msg, messageKeys.HalfPageUp) || key.Matches(msg, messageKeys.HalfPageDown) {
@@ -116,6 +158,18 @@ func (m *editorCmp) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 			return m, openEditor()
 		}
+		if key.Matches(msg, editorMaps.Detach) && len(m.attachments) > 0 {
+			m.setAttachments(nil)
+			return m, util.ReportInfo("Removed the attachments")
+		}
+		if m.textarea.Focused() && key.Matches(msg, editorMaps.Paste) {
+			text, err := clipboard.Read()
+			if err != nil {
+				return m, util.ReportError(err)
+			}
+			m.textarea.InsertString(text)
+			return m, nil
+		}
 		// Handle Enter key
 		if m.textarea.Focused() && key.Matches(msg, editorMaps.Send) {
 			value := m.textarea.Value()
@@ -136,11 +190,28 @@ func (m *editorCmp) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 func (m *editorCmp) View() string {
 	style := lipgloss.NewStyle().Padding(0, 0, 0, 1).Bold(true)
 
-	return lipgloss.JoinHorizontal(lipgloss.Top, style.Render(">"), m.textarea.View())
+	editor := lipgloss.JoinHorizontal(lipgloss.Top, style.Render(">"), m.textarea.View())
+	if len(m.attachments) == 0 {
+		return editor
+	}
+	var names []string
+	for _, attachment := range m.attachments {
+		names = append(names, fmt.Sprintf("%s (%d KB)", attachment.Name, (len(attachment.Attachment.Data)+1023)/1024))
+	}
+	attachments := styles.BaseStyle.
+		Foreground(styles.ForgroundDim).
+		Padding(0, 0, 0, 1).
+		Width(m.width).
+		Render(ansi.Truncate("Attached: "+strings.Join(names, ", ")+" · ctrl+r to remove", m.width-1, "…"))
+	return lipgloss.JoinVertical(lipgloss.Left, attachments, editor)
 }
 
 func (m *editorCmp) SetSize(width, height int) tea.Cmd {
+	m.width, m.height = width, height
 	m.textarea.SetWidth(width - 3) // account for the prompt and padding right
+	if len(m.attachments) > 0 && height > 1 {
+		height--
+	}
 	m.textarea.SetHeight(height)
 	return nil
 }
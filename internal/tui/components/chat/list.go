@@ -11,6 +11,7 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/opencode-ai/opencode/internal/app"
+	"github.com/opencode-ai/opencode/internal/llm/agent"
 	"github.com/opencode-ai/opencode/internal/message"
 	"github.com/opencode-ai/opencode/internal/pubsub"
 	"github.com/opencode-ai/opencode/internal/session"
@@ -33,6 +34,10 @@ type messagesCmp struct {
 	cachedContent map[string]cacheItem
 	spinner       spinner.Model
 	rendering     bool
+	// toolOutputs is the output of the running tool calls, by tool call ID
+	toolOutputs map[string]string
+	// showReasoning expands the reasoning of the responses
+	showReasoning bool
 }
 type renderFinishedMsg struct{}
 
@@ -80,6 +85,7 @@ func (m *messagesCmp) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.session = session.Session{}
 		m.messages = make([]message.Message, 0)
 		m.currentMsgID = ""
+		m.toolOutputs = make(map[string]string)
 		m.rendering = false
 		return m, nil
 
@@ -91,9 +97,38 @@ func (m *messagesCmp) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			cmds = append(cmds, cmd)
 		}
 
+	case pubsub.Event[session.Session]:
+		if msg.Type == pubsub.UpdatedEvent && msg.Payload.ID == m.session.ID {
+			compacted := msg.Payload.SummaryMessageID != m.session.SummaryMessageID
+			m.session = msg.Payload
+			if compacted {
+				m.renderView()
+			}
+		}
+	case ToggleReasoningMsg:
+		m.showReasoning = !m.showReasoning
+		m.cachedContent = make(map[string]cacheItem)
+		m.renderView()
 	case renderFinishedMsg:
 		m.rendering = false
 		m.viewport.GotoBottom()
+	case pubsub.Event[agent.ToolOutput]:
+		if msg.Type == pubsub.DeletedEvent {
+			// The result of the tool replaces the output when it is published
+			delete(m.toolOutputs, msg.Payload.ToolCallID)
+			break
+		}
+		if msg.Payload.SessionID != m.session.ID {
+			break
+		}
+		m.toolOutputs[msg.Payload.ToolCallID] = msg.Payload.Output
+		if i := m.toolCallMessage(msg.Payload.ToolCallID); i >= 0 {
+			delete(m.cachedContent, m.messages[i].ID)
+			m.renderView()
+			if i == len(m.messages)-1 {
+				m.viewport.GotoBottom()
+			}
+		}
 	case pubsub.Event[message.Message]:
 		needsRerender := false
 		if msg.Type == pubsub.CreatedEvent {
@@ -137,6 +172,19 @@ func (m *messagesCmp) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					break
 				}
 			}
+		} else if msg.Type == pubsub.DeletedEvent && msg.Payload.SessionID == m.session.ID {
+			// Rewinding to a checkpoint discards the messages after it
+			for i, v := range m.messages {
+				if v.ID == msg.Payload.ID {
+					m.messages = append(m.messages[:i], m.messages[i+1:]...)
+					delete(m.cachedContent, msg.Payload.ID)
+					if len(m.messages) > 0 {
+						delete(m.cachedContent, m.messages[len(m.messages)-1].ID)
+					}
+					needsRerender = true
+					break
+				}
+			}
 		}
 		if needsRerender {
 			m.renderView()
@@ -155,6 +203,18 @@ func (m *messagesCmp) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, tea.Batch(cmds...)
 }
 
+// toolCallMessage returns the index of the message with the tool call, or -1.
+func (m *messagesCmp) toolCallMessage(toolCallID string) int {
+	for i, v := range m.messages {
+		for _, c := range v.ToolCalls() {
+			if c.ID == toolCallID {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
 func (m *messagesCmp) IsAgentWorking() bool {
 	return m.app.CoderAgent.IsSessionBusy(m.session.ID)
 }
@@ -179,6 +239,11 @@ func (m *messagesCmp) renderView() {
 		return
 	}
 	for inx, msg := range m.messages {
+		if msg.ID == m.session.SummaryMessageID && inx > 0 {
+			divider := renderSummaryDivider(m.width, pos)
+			m.uiMessages = append(m.uiMessages, divider)
+			pos += divider.height + 1
+		}
 		switch msg.Role {
 		case message.User:
 			if cache, ok := m.cachedContent[msg.ID]; ok && cache.width == m.width {
@@ -208,6 +273,8 @@ func (m *messagesCmp) renderView() {
 				m.messages,
 				m.app.Messages,
 				m.currentMsgID,
+				m.toolOutputs,
+				m.showReasoning,
 				m.width,
 				pos,
 			)
@@ -334,6 +401,8 @@ func (m *messagesCmp) working() string {
 			task = "Waiting for tool response..."
 		} else if hasUnfinishedToolCalls(m.messages) {
 			task = "Building tool call..."
+		} else if lastMessage.IsThinking() {
+			task = "Reasoning..."
 		} else if !lastMessage.IsFinished() {
 			task = "Generating..."
 		}
@@ -373,13 +442,13 @@ func (m *messagesCmp) help() string {
 }
 
 func (m *messagesCmp) initialScreen() string {
+	sections := []string{header(m.width), ""}
+	if instructions := instructionsLoaded(m.width); instructions != "" {
+		sections = append(sections, instructions, "")
+	}
+	sections = append(sections, lspsConfigured(m.width))
 	return styles.BaseStyle.Width(m.width).Render(
-		lipgloss.JoinVertical(
-			lipgloss.Top,
-			header(m.width),
-			"",
-			lspsConfigured(m.width),
-		),
+		lipgloss.JoinVertical(lipgloss.Top, sections...),
 	)
 }
 
@@ -442,6 +511,7 @@ func NewMessagesCmp(app *app.App) tea.Model {
 	return &messagesCmp{
 		app:           app,
 		cachedContent: make(map[string]cacheItem),
+		toolOutputs:   make(map[string]string),
 		viewport:      vp,
 		spinner:       s,
 	}
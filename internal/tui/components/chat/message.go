@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"path/filepath"
+	"slices"
 	"strings"
 	"sync"
 	"time"
@@ -18,6 +19,7 @@ import (
 	"github.com/opencode-ai/opencode/internal/llm/models"
 	"github.com/opencode-ai/opencode/internal/llm/tools"
 	"github.com/opencode-ai/opencode/internal/message"
+	"github.com/opencode-ai/opencode/internal/todo"
 	"github.com/opencode-ai/opencode/internal/tui/styles"
 )
 
@@ -27,6 +29,8 @@ const (
 	userMessageType uiMessageType = iota
 	assistantMessageType
 	toolMessageType
+	summaryMessageType
+	reasoningMessageType
 
 	maxResultHeight = 10
 )
@@ -92,7 +96,13 @@ func renderMessage(msg string, isUser bool, isFocused bool, width int, info ...s
 }
 
 func renderUserMessage(msg message.Message, isFocused bool, width int, position int) uiMessage {
-	content := renderMessage(msg.Content().String(), true, isFocused, width)
+	text := msg.Content().String()
+	if images := len(msg.BinaryContent()); images == 1 {
+		text += "\n\n*1 image attached*"
+	} else if images > 1 {
+		text += fmt.Sprintf("\n\n*%d images attached*", images)
+	}
+	content := renderMessage(text, true, isFocused, width)
 	userMsg := uiMessage{
 		ID:          msg.ID,
 		messageType: userMessageType,
@@ -103,6 +113,33 @@ func renderUserMessage(msg message.Message, isFocused bool, width int, position
 	return userMsg
 }
 
+// renderSummaryDivider marks where the conversation sent to the model starts
+// after it was compacted, the messages above it were summarized.
+func renderSummaryDivider(width int, position int) uiMessage {
+	label := " Earlier messages were summarized to fit the context window "
+	line := strings.Repeat("─", max(0, (width-lipgloss.Width(label))/2))
+	content := styles.BaseStyle.
+		Width(width).
+		Foreground(styles.ForgroundDim).
+		Align(lipgloss.Center).
+		Render(line + label + line)
+	return uiMessage{
+		messageType: summaryMessageType,
+		position:    position,
+		height:      lipgloss.Height(content),
+		content:     content,
+	}
+}
+
+// modelName returns the display name of a model, models that are no longer
+// available are shown by their ID.
+func modelName(id models.ModelID) string {
+	if model, ok := models.SupportedModels[id]; ok && model.Name != "" {
+		return model.Name
+	}
+	return string(id)
+}
+
 // Returns multiple uiMessages because of the tool calls
 func renderAssistantMessage(
 	msg message.Message,
@@ -110,13 +147,13 @@ func renderAssistantMessage(
 	allMessages []message.Message, // we need this to get tool results and the user message
 	messagesService message.Service, // We need this to get the task tool messages
 	focusedUIMessageId string,
+	toolOutputs map[string]string, // the output of the running tool calls
+	showReasoning bool,
 	width int,
 	position int,
 ) []uiMessage {
 	messages := []uiMessage{}
 	content := msg.Content().String()
-	thinking := msg.IsThinking()
-	thinkingContent := msg.ReasoningContent().Thinking
 	finished := msg.IsFinished()
 	finishData := msg.FinishPart()
 	info := []string{}
@@ -127,22 +164,34 @@ func renderAssistantMessage(
 		case message.FinishReasonEndTurn:
 			took := formatTimeDifference(msg.CreatedAt, finishData.Time)
 			info = append(info, styles.BaseStyle.Width(width-1).Foreground(styles.ForgroundDim).Render(
-				fmt.Sprintf(" %s (%s)", models.SupportedModels[msg.Model].Name, took),
+				fmt.Sprintf(" %s (%s)", modelName(msg.Model), took),
 			))
 		case message.FinishReasonCanceled:
 			info = append(info, styles.BaseStyle.Width(width-1).Foreground(styles.ForgroundDim).Render(
-				fmt.Sprintf(" %s (%s)", models.SupportedModels[msg.Model].Name, "canceled"),
+				fmt.Sprintf(" %s (%s)", modelName(msg.Model), "canceled"),
 			))
 		case message.FinishReasonError:
 			info = append(info, styles.BaseStyle.Width(width-1).Foreground(styles.ForgroundDim).Render(
-				fmt.Sprintf(" %s (%s)", models.SupportedModels[msg.Model].Name, "error"),
+				fmt.Sprintf(" %s (%s)", modelName(msg.Model), "error"),
 			))
 		case message.FinishReasonPermissionDenied:
 			info = append(info, styles.BaseStyle.Width(width-1).Foreground(styles.ForgroundDim).Render(
-				fmt.Sprintf(" %s (%s)", models.SupportedModels[msg.Model].Name, "permission denied"),
+				fmt.Sprintf(" %s (%s)", modelName(msg.Model), "permission denied"),
+			))
+		default:
+			// Show which model produced the message, the model can change
+			// during a session
+			info = append(info, styles.BaseStyle.Width(width-1).Foreground(styles.ForgroundDim).Render(
+				fmt.Sprintf(" %s", modelName(msg.Model)),
 			))
 		}
 	}
+	if reasoning := msg.ReasoningContent().Thinking; reasoning != "" {
+		reasoningMsg := renderReasoning(reasoning, showReasoning, msg.IsThinking(), width, position)
+		messages = append(messages, reasoningMsg)
+		position += reasoningMsg.height
+		position++ // for the space
+	}
 	if content != "" || (finished && finishData.Reason == message.FinishReasonEndTurn) {
 		if content == "" {
 			content = "*Finished without output*"
@@ -156,11 +205,8 @@ func renderAssistantMessage(
 			height:      lipgloss.Height(content),
 			content:     content,
 		})
-		position += messages[0].height
+		position += messages[len(messages)-1].height
 		position++ // for the space
-	} else if thinking && thinkingContent != "" {
-		// Render the thinking content
-		content = renderMessage(thinkingContent, false, msg.ID == focusedUIMessageId, width)
 	}
 
 	for i, toolCall := range msg.ToolCalls() {
@@ -169,6 +215,7 @@ func renderAssistantMessage(
 			allMessages,
 			messagesService,
 			focusedUIMessageId,
+			toolOutputs,
 			false,
 			width,
 			i+1,
@@ -180,6 +227,51 @@ func renderAssistantMessage(
 	return messages
 }
 
+// reasoningPreviewLines is how many of the last lines of the reasoning are
+// shown while the model reasons with the reasoning collapsed
+const reasoningPreviewLines = 4
+
+// renderReasoning renders the reasoning of the model in a dim section above
+// its response. Collapsed, only its size is shown once the response starts,
+// and its last lines while the model reasons.
+func renderReasoning(reasoning string, expanded, streaming bool, width int, position int) uiMessage {
+	style := styles.BaseStyle.
+		Width(width - 1).
+		BorderLeft(true).
+		BorderStyle(lipgloss.NormalBorder()).
+		PaddingLeft(1).
+		BorderForeground(styles.ForgroundDim).
+		Foreground(styles.ForgroundDim)
+	textStyle := styles.BaseStyle.Width(width - 3).Foreground(styles.ForgroundDim).Italic(true)
+
+	reasoning = strings.TrimSpace(reasoning)
+	words := len(strings.Fields(reasoning))
+	var header, body string
+	switch {
+	case expanded:
+		header = fmt.Sprintf("▾ Reasoning (%d words)", words)
+		body = textStyle.Render(reasoning)
+	case streaming:
+		header = "▸ Reasoning…"
+		lines := strings.Split(textStyle.Render(reasoning), "\n")
+		body = strings.Join(lines[max(0, len(lines)-reasoningPreviewLines):], "\n")
+	default:
+		header = fmt.Sprintf("▸ Reasoning (%d words), ctrl+g to expand", words)
+	}
+
+	parts := []string{styles.BaseStyle.Foreground(styles.ForgroundDim).Bold(true).Render(header)}
+	if body != "" {
+		parts = append(parts, body)
+	}
+	content := style.Render(lipgloss.JoinVertical(lipgloss.Left, parts...))
+	return uiMessage{
+		messageType: reasoningMessageType,
+		position:    position,
+		height:      lipgloss.Height(content),
+		content:     content,
+	}
+}
+
 func findToolResponse(toolCallID string, futureMessages []message.Message) *message.ToolResult {
 	for _, msg := range futureMessages {
 		for _, result := range msg.ToolResults() {
@@ -197,24 +289,58 @@ func toolName(name string) string {
 		return "Task"
 	case tools.BashToolName:
 		return "Bash"
+	case tools.BrowserToolName:
+		return "Browser"
+	case tools.ClipboardToolName:
+		return "Clipboard"
+	case tools.ContainerToolName:
+		return "Container"
+	case tools.DependenciesToolName:
+		return "Dependencies"
 	case tools.EditToolName:
 		return "Edit"
 	case tools.FetchToolName:
 		return "Fetch"
+	case tools.GitToolName:
+		return "Git"
 	case tools.GlobToolName:
 		return "Glob"
 	case tools.GrepToolName:
 		return "Grep"
+	case tools.HTTPToolName:
+		return "HTTP"
 	case tools.LSToolName:
 		return "List"
+	case tools.MultiEditToolName:
+		return "Multi-Edit"
+	case tools.NotebookToolName:
+		return "Notebook"
+	case tools.NotesToolName:
+		return "Notes"
+	case tools.OutputToolName:
+		return "Output"
+	case tools.RenameToolName:
+		return "Rename"
 	case tools.SourcegraphToolName:
 		return "Sourcegraph"
+	case tools.SQLToolName:
+		return "SQL"
+	case tools.SymbolsToolName:
+		return "Symbols"
+	case tools.TestToolName:
+		return "Test"
+	case tools.TodoToolName:
+		return "Todo"
+	case tools.WebSearchToolName:
+		return "Web Search"
 	case tools.ViewToolName:
 		return "View"
 	case tools.WriteToolName:
 		return "Write"
 	case tools.PatchToolName:
 		return "Patch"
+	case tools.ApplyDiffToolName:
+		return "Apply Diff"
 	}
 	return name
 }
@@ -225,24 +351,58 @@ func getToolAction(name string) string {
 		return "Preparing prompt..."
 	case tools.BashToolName:
 		return "Building command..."
+	case tools.BrowserToolName:
+		return "Using browser..."
+	case tools.ClipboardToolName:
+		return "Using clipboard..."
+	case tools.ContainerToolName:
+		return "Building command..."
+	case tools.DependenciesToolName:
+		return "Reading dependencies..."
 	case tools.EditToolName:
 		return "Preparing edit..."
 	case tools.FetchToolName:
 		return "Writing fetch..."
+	case tools.GitToolName:
+		return "Running git..."
 	case tools.GlobToolName:
 		return "Finding files..."
 	case tools.GrepToolName:
 		return "Searching content..."
+	case tools.HTTPToolName:
+		return "Preparing request..."
 	case tools.LSToolName:
 		return "Listing directory..."
+	case tools.MultiEditToolName:
+		return "Preparing edits..."
+	case tools.NotebookToolName:
+		return "Using notebook..."
+	case tools.NotesToolName:
+		return "Taking notes..."
+	case tools.OutputToolName:
+		return "Reading output..."
+	case tools.RenameToolName:
+		return "Preparing rename..."
 	case tools.SourcegraphToolName:
 		return "Searching code..."
+	case tools.SQLToolName:
+		return "Writing query..."
+	case tools.SymbolsToolName:
+		return "Reading symbols..."
+	case tools.TestToolName:
+		return "Running tests..."
+	case tools.TodoToolName:
+		return "Updating tasks..."
+	case tools.WebSearchToolName:
+		return "Searching the web..."
 	case tools.ViewToolName:
 		return "Reading file..."
 	case tools.WriteToolName:
 		return "Preparing write..."
 	case tools.PatchToolName:
 		return "Preparing patch..."
+	case tools.ApplyDiffToolName:
+		return "Preparing diff..."
 	}
 	return "Working..."
 }
@@ -313,18 +473,80 @@ func renderToolParams(paramWidth int, toolCall message.ToolCall) string {
 	case agent.AgentToolName:
 		var params agent.AgentParams
 		json.Unmarshal([]byte(toolCall.Input), &params)
-		prompt := strings.ReplaceAll(params.Prompt, "\n", " ")
-		return renderParams(paramWidth, prompt)
+		task := params.Description
+		if task == "" {
+			task = strings.ReplaceAll(params.Prompt, "\n", " ")
+		}
+		toolParams := []string{task}
+		if params.Model != "" && params.Model != agent.AgentModelTask {
+			toolParams = append(toolParams, "model", params.Model)
+		}
+		return renderParams(paramWidth, toolParams...)
 	case tools.BashToolName:
 		var params tools.BashParams
 		json.Unmarshal([]byte(toolCall.Input), &params)
 		command := strings.ReplaceAll(params.Command, "\n", " ")
+		toolParams := []string{command}
+		if params.SessionID != "" {
+			toolParams = append(toolParams, "session", params.SessionID)
+		}
+		return renderParams(paramWidth, toolParams...)
+	case tools.ContainerToolName:
+		var params tools.ContainerParams
+		json.Unmarshal([]byte(toolCall.Input), &params)
+		command := strings.ReplaceAll(params.Command, "\n", " ")
 		return renderParams(paramWidth, command)
+	case tools.DependenciesToolName:
+		var params tools.DependenciesParams
+		json.Unmarshal([]byte(toolCall.Input), &params)
+		path := removeWorkingDirPrefix(params.Path)
+		if path == "" {
+			path = "."
+		}
+		toolParams := []string{path}
+		if params.Name != "" {
+			toolParams = append(toolParams, "name", params.Name)
+		}
+		return renderParams(paramWidth, toolParams...)
 	case tools.EditToolName:
 		var params tools.EditParams
 		json.Unmarshal([]byte(toolCall.Input), &params)
 		filePath := removeWorkingDirPrefix(params.FilePath)
 		return renderParams(paramWidth, filePath)
+	case tools.MultiEditToolName:
+		var params tools.MultiEditParams
+		json.Unmarshal([]byte(toolCall.Input), &params)
+		var filePaths []string
+		for _, edit := range params.Edits {
+			filePath := removeWorkingDirPrefix(edit.FilePath)
+			if !slices.Contains(filePaths, filePath) {
+				filePaths = append(filePaths, filePath)
+			}
+		}
+		return renderParams(paramWidth, strings.Join(filePaths, ", "))
+	case tools.RenameToolName:
+		var params tools.RenameParams
+		json.Unmarshal([]byte(toolCall.Input), &params)
+		location := fmt.Sprintf("%s:%d", removeWorkingDirPrefix(params.FilePath), params.Line)
+		return renderParams(paramWidth, params.Symbol+" -> "+params.NewName, "file", location)
+	case tools.BrowserToolName:
+		var params tools.BrowserParams
+		json.Unmarshal([]byte(toolCall.Input), &params)
+		toolParams := []string{params.Action}
+		switch {
+		case params.URL != "":
+			toolParams[0] += " " + params.URL
+		case params.Selector != "":
+			toolParams[0] += " " + params.Selector
+		case params.Key != "":
+			toolParams[0] += " " + params.Key
+		case params.Expression != "":
+			toolParams[0] += " " + strings.ReplaceAll(params.Expression, "\n", " ")
+		}
+		if params.Timeout != 0 {
+			toolParams = append(toolParams, "timeout", (time.Duration(params.Timeout) * time.Second).String())
+		}
+		return renderParams(paramWidth, toolParams...)
 	case tools.FetchToolName:
 		var params tools.FetchParams
 		json.Unmarshal([]byte(toolCall.Input), &params)
@@ -367,6 +589,45 @@ func renderToolParams(paramWidth int, toolCall message.ToolCall) string {
 			toolParams = append(toolParams, "literal", "true")
 		}
 		return renderParams(paramWidth, toolParams...)
+	case tools.ClipboardToolName:
+		var params tools.ClipboardParams
+		json.Unmarshal([]byte(toolCall.Input), &params)
+		return renderParams(paramWidth, params.Action)
+	case tools.NotebookToolName:
+		var params tools.NotebookParams
+		json.Unmarshal([]byte(toolCall.Input), &params)
+		toolParams := []string{params.Action + " " + removeWorkingDirPrefix(params.NotebookPath)}
+		if params.CellIndex != nil {
+			toolParams = append(toolParams, "cell", fmt.Sprintf("%d", *params.CellIndex))
+		}
+		if params.EditMode != "" {
+			toolParams = append(toolParams, "mode", params.EditMode)
+		}
+		if params.Restart {
+			toolParams = append(toolParams, "restart", "true")
+		}
+		return renderParams(paramWidth, toolParams...)
+	case tools.NotesToolName:
+		var params tools.NotesParams
+		json.Unmarshal([]byte(toolCall.Input), &params)
+		return renderParams(paramWidth, strings.TrimSpace(params.Action+" "+params.Name))
+	case tools.TodoToolName:
+		var params tools.TodoParams
+		json.Unmarshal([]byte(toolCall.Input), &params)
+		var list todo.List
+		for _, item := range params.Todos {
+			list.Items = append(list.Items, todo.Item{Content: item.Content, Status: todo.Status(item.Status)})
+		}
+		done, total := list.Progress()
+		return renderParams(paramWidth, fmt.Sprintf("%d/%d done", done, total))
+	case tools.HTTPToolName:
+		var params tools.HTTPParams
+		json.Unmarshal([]byte(toolCall.Input), &params)
+		toolParams := []string{strings.ToUpper(params.Method) + " " + params.URL}
+		if params.Timeout != 0 {
+			toolParams = append(toolParams, "timeout", (time.Duration(params.Timeout) * time.Second).String())
+		}
+		return renderParams(paramWidth, toolParams...)
 	case tools.LSToolName:
 		var params tools.LSParams
 		json.Unmarshal([]byte(toolCall.Input), &params)
@@ -379,6 +640,71 @@ func renderToolParams(paramWidth int, toolCall message.ToolCall) string {
 		var params tools.SourcegraphParams
 		json.Unmarshal([]byte(toolCall.Input), &params)
 		return renderParams(paramWidth, params.Query)
+	case tools.WebSearchToolName:
+		var params tools.WebSearchParams
+		json.Unmarshal([]byte(toolCall.Input), &params)
+		return renderParams(paramWidth, params.Query)
+	case tools.GitToolName:
+		var params tools.GitParams
+		json.Unmarshal([]byte(toolCall.Input), &params)
+		toolParams := []string{params.Action + " " + strings.Join(params.Paths, " ")}
+		if params.Ref != "" {
+			toolParams = append(toolParams, "ref", params.Ref)
+		}
+		if params.Staged {
+			toolParams = append(toolParams, "staged", "true")
+		}
+		return renderParams(paramWidth, toolParams...)
+	case tools.SQLToolName:
+		var params tools.SQLParams
+		json.Unmarshal([]byte(toolCall.Input), &params)
+		query := strings.Join(strings.Fields(params.Query), " ")
+		toolParams := []string{query}
+		if params.Database != "" {
+			toolParams = append(toolParams, "database", params.Database)
+		}
+		if params.Write {
+			toolParams = append(toolParams, "write", "true")
+		}
+		return renderParams(paramWidth, toolParams...)
+	case tools.OutputToolName:
+		var params tools.OutputParams
+		json.Unmarshal([]byte(toolCall.Input), &params)
+		toolParams := []string{params.ID}
+		if params.Offset != 0 {
+			toolParams = append(toolParams, "offset", fmt.Sprintf("%d", params.Offset))
+		}
+		if params.Limit != 0 {
+			toolParams = append(toolParams, "limit", fmt.Sprintf("%d", params.Limit))
+		}
+		return renderParams(paramWidth, toolParams...)
+	case tools.SymbolsToolName:
+		var params tools.SymbolsParams
+		json.Unmarshal([]byte(toolCall.Input), &params)
+		path := removeWorkingDirPrefix(params.Path)
+		if path == "" {
+			path = "."
+		}
+		toolParams := []string{params.Action + " " + path}
+		if params.Name != "" {
+			toolParams = append(toolParams, "name", params.Name)
+		}
+		return renderParams(paramWidth, toolParams...)
+	case tools.TestToolName:
+		var params tools.TestParams
+		json.Unmarshal([]byte(toolCall.Input), &params)
+		path := params.Path
+		if path == "" {
+			path = "."
+		}
+		toolParams := []string{path}
+		if params.Framework != "" {
+			toolParams = append(toolParams, "framework", params.Framework)
+		}
+		if params.Filter != "" {
+			toolParams = append(toolParams, "filter", params.Filter)
+		}
+		return renderParams(paramWidth, toolParams...)
 	case tools.ViewToolName:
 		var params tools.ViewParams
 		json.Unmarshal([]byte(toolCall.Input), &params)
@@ -398,6 +724,19 @@ func renderToolParams(paramWidth int, toolCall message.ToolCall) string {
 		json.Unmarshal([]byte(toolCall.Input), &params)
 		filePath := removeWorkingDirPrefix(params.FilePath)
 		return renderParams(paramWidth, filePath)
+	case tools.ApplyDiffToolName:
+		var params tools.ApplyDiffParams
+		json.Unmarshal([]byte(toolCall.Input), &params)
+		fileDiffs, _ := diff.ParseFileDiffs(params.Diff)
+		var filePaths []string
+		for _, fd := range fileDiffs {
+			filePath := fd.NewPath
+			if filePath == "" {
+				filePath = fd.OldPath
+			}
+			filePaths = append(filePaths, removeWorkingDirPrefix(filePath))
+		}
+		return renderParams(paramWidth, strings.Join(filePaths, ", "))
 	default:
 		input := strings.ReplaceAll(toolCall.Input, "\n", " ")
 		params = renderParams(paramWidth, input)
@@ -413,6 +752,28 @@ func truncateHeight(content string, height int) string {
 	return content
 }
 
+// renderToolOutput renders the last lines of the output of a running tool.
+func renderToolOutput(output string, width int) string {
+	output = strings.ToValidUTF8(ansi.Strip(output), "")
+	output = strings.ReplaceAll(strings.TrimRight(output, "\n"), "\t", "    ")
+	lines := strings.Split(output, "\n")
+	if len(lines) > maxResultHeight {
+		lines = lines[len(lines)-maxResultHeight:]
+	}
+	for i, line := range lines {
+		// Progress bars redraw their line with carriage returns
+		line = strings.TrimSuffix(line, "\r")
+		if j := strings.LastIndex(line, "\r"); j >= 0 {
+			line = line[j+1:]
+		}
+		lines[i] = ansi.Truncate(line, width, "...")
+	}
+	return styles.BaseStyle.
+		Width(width).
+		Foreground(styles.ForgroundMid).
+		Render(strings.Join(lines, "\n"))
+}
+
 func renderToolResponse(toolCall message.ToolCall, response message.ToolResult, width int) string {
 	if response.IsError {
 		errContent := fmt.Sprintf("Error: %s", strings.ReplaceAll(response.Content, "\n", " "))
@@ -429,7 +790,7 @@ func renderToolResponse(toolCall message.ToolCall, response message.ToolResult,
 			toMarkdown(resultContent, false, width),
 			styles.Background,
 		)
-	case tools.BashToolName:
+	case tools.BashToolName, tools.ContainerToolName:
 		resultContent = fmt.Sprintf("```bash\n%s\n```", resultContent)
 		return styles.ForceReplaceBackgroundWithLipgloss(
 			toMarkdown(resultContent, true, width),
@@ -441,6 +802,16 @@ func renderToolResponse(toolCall message.ToolCall, response message.ToolResult,
 		truncDiff := truncateHeight(metadata.Diff, maxResultHeight)
 		formattedDiff, _ := diff.FormatDiff(truncDiff, diff.WithTotalWidth(width), diff.WithStyle(diffStyle))
 		return formattedDiff
+	case tools.MultiEditToolName, tools.RenameToolName:
+		metadata := tools.MultiEditResponseMetadata{}
+		json.Unmarshal([]byte(response.Metadata), &metadata)
+		formattedDiffs := make([]string, 0, len(metadata.Files))
+		for _, file := range metadata.Files {
+			truncDiff := truncateHeight(file.Diff, maxResultHeight)
+			formattedDiff, _ := diff.FormatDiff(truncDiff, diff.WithTotalWidth(width), diff.WithStyle(diffStyle))
+			formattedDiffs = append(formattedDiffs, formattedDiff)
+		}
+		return strings.Join(formattedDiffs, "\n")
 	case tools.FetchToolName:
 		var params tools.FetchParams
 		json.Unmarshal([]byte(toolCall.Input), &params)
@@ -456,6 +827,17 @@ func renderToolResponse(toolCall message.ToolCall, response message.ToolResult,
 			toMarkdown(resultContent, true, width),
 			styles.Background,
 		)
+	case tools.GitToolName:
+		var params tools.GitParams
+		json.Unmarshal([]byte(toolCall.Input), &params)
+		if params.Action == "diff" {
+			resultContent = fmt.Sprintf("```diff\n%s\n```", resultContent)
+			return styles.ForceReplaceBackgroundWithLipgloss(
+				toMarkdown(resultContent, true, width),
+				styles.Background,
+			)
+		}
+		return styles.BaseStyle.Width(width).Foreground(styles.ForgroundMid).Render(resultContent)
 	case tools.GlobToolName:
 		return styles.BaseStyle.Width(width).Foreground(styles.ForgroundMid).Render(resultContent)
 	case tools.GrepToolName:
@@ -464,6 +846,13 @@ func renderToolResponse(toolCall message.ToolCall, response message.ToolResult,
 		return styles.BaseStyle.Width(width).Foreground(styles.ForgroundMid).Render(resultContent)
 	case tools.SourcegraphToolName:
 		return styles.BaseStyle.Width(width).Foreground(styles.ForgroundMid).Render(resultContent)
+	case tools.ApplyDiffToolName, tools.BrowserToolName, tools.DependenciesToolName, tools.HTTPToolName, tools.NotebookToolName, tools.NotesToolName, tools.OutputToolName, tools.SymbolsToolName, tools.TodoToolName:
+		return styles.BaseStyle.Width(width).Foreground(styles.ForgroundMid).Render(resultContent)
+	case tools.SQLToolName, tools.WebSearchToolName:
+		return styles.ForceReplaceBackgroundWithLipgloss(
+			toMarkdown(resultContent, true, width),
+			styles.Background,
+		)
 	case tools.ViewToolName:
 		metadata := tools.ViewResponseMetadata{}
 		json.Unmarshal([]byte(response.Metadata), &metadata)
@@ -508,6 +897,7 @@ func renderToolMessage(
 	allMessages []message.Message,
 	messagesService message.Service,
 	focusedUIMessageId string,
+	toolOutputs map[string]string,
 	nested bool,
 	width int,
 	position int,
@@ -561,6 +951,8 @@ func renderToolMessage(
 	if response != nil {
 		responseContent = renderToolResponse(toolCall, *response, width-2)
 		responseContent = strings.TrimSuffix(responseContent, "\n")
+	} else if output := toolOutputs[toolCall.ID]; output != "" {
+		responseContent = renderToolOutput(output, width-2)
 	} else {
 		responseContent = styles.BaseStyle.
 			Italic(true).
@@ -594,7 +986,7 @@ func renderToolMessage(
 			toolCalls = append(toolCalls, v.ToolCalls()...)
 		}
 		for _, call := range toolCalls {
-			rendered := renderToolMessage(call, []message.Message{}, messagesService, focusedUIMessageId, true, width, 0)
+			rendered := renderToolMessage(call, []message.Message{}, messagesService, focusedUIMessageId, nil, true, width, 0)
 			parts = append(parts, rendered.content)
 		}
 	}
@@ -0,0 +1,32 @@
+package chat
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderReasoning(t *testing.T) {
+	reasoning := "The config is read once.\nThe flag is parsed later.\nSo the flag is ignored.\nMove the parsing first.\nThen read the config.\nCheck the tests."
+
+	collapsed := renderReasoning(reasoning, false, false, 80, 3).content
+	assert.Contains(t, collapsed, "▸ Reasoning (26 words), ctrl+g to expand")
+	assert.NotContains(t, collapsed, "The config is read once.")
+
+	// While the model reasons, its last lines are shown
+	streaming := renderReasoning(reasoning, false, true, 80, 3)
+	assert.Contains(t, streaming.content, "▸ Reasoning…")
+	assert.NotContains(t, streaming.content, "The flag is parsed later.")
+	assert.Contains(t, streaming.content, "So the flag is ignored.")
+	assert.Contains(t, streaming.content, "Check the tests.")
+	assert.Equal(t, 1+reasoningPreviewLines, streaming.height)
+
+	expanded := renderReasoning("  "+reasoning+"\n", true, true, 80, 3)
+	assert.Contains(t, expanded.content, "▾ Reasoning (26 words)")
+	for _, line := range strings.Split(reasoning, "\n") {
+		assert.Contains(t, expanded.content, line)
+	}
+	assert.Equal(t, reasoningMessageType, expanded.messageType)
+	assert.Equal(t, 3, expanded.position)
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/opencode-ai/opencode/internal/history"
 	"github.com/opencode-ai/opencode/internal/pubsub"
 	"github.com/opencode-ai/opencode/internal/session"
+	"github.com/opencode-ai/opencode/internal/todo"
 	"github.com/opencode-ai/opencode/internal/tui/styles"
 )
 
@@ -24,9 +25,13 @@ type sidebarCmp struct {
 		additions int
 		removals  int
 	}
+	todos          todo.Service
+	todoList       todo.List
+	todosCollapsed bool
 }
 
 func (m *sidebarCmp) Init() tea.Cmd {
+	m.loadTodos(context.Background())
 	if m.history != nil {
 		ctx := context.Background()
 		// Subscribe to file events
@@ -56,7 +61,14 @@ func (m *sidebarCmp) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.session = msg
 			ctx := context.Background()
 			m.loadModifiedFiles(ctx)
+			m.loadTodos(ctx)
 		}
+	case pubsub.Event[todo.List]:
+		if msg.Payload.SessionID == m.session.ID {
+			m.todoList = msg.Payload
+		}
+	case ToggleTodosMsg:
+		m.todosCollapsed = !m.todosCollapsed
 	case pubsub.Event[session.Session]:
 		if msg.Type == pubsub.UpdatedEvent {
 			if m.session.ID == msg.Payload.ID {
@@ -81,6 +93,23 @@ func (m *sidebarCmp) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 }
 
 func (m *sidebarCmp) View() string {
+	sections := []string{
+		header(m.width),
+		" ",
+		m.sessionSection(),
+	}
+	if len(m.todoList.Items) > 0 {
+		sections = append(sections, " ", m.todoSection())
+	}
+	if instructions := instructionsLoaded(m.width); instructions != "" {
+		sections = append(sections, " ", instructions)
+	}
+	sections = append(sections,
+		" ",
+		lspsConfigured(m.width),
+		" ",
+		m.modifiedFiles(),
+	)
 	return styles.BaseStyle.
 		Width(m.width).
 		PaddingLeft(4).
@@ -89,13 +118,7 @@ func (m *sidebarCmp) View() string {
 		Render(
 			lipgloss.JoinVertical(
 				lipgloss.Top,
-				header(m.width),
-				" ",
-				m.sessionSection(),
-				" ",
-				lspsConfigured(m.width),
-				" ",
-				m.modifiedFiles(),
+				sections...,
 			),
 		)
 }
@@ -106,10 +129,82 @@ func (m *sidebarCmp) sessionSection() string {
 		Foreground(styles.Forground).
 		Width(m.width - lipgloss.Width(sessionKey)).
 		Render(fmt.Sprintf(": %s", m.session.Title))
-	return lipgloss.JoinHorizontal(
+	lines := []string{lipgloss.JoinHorizontal(
 		lipgloss.Left,
 		sessionKey,
 		sessionValue,
+	)}
+
+	// The tool profile, when the session doesn't offer all the tools
+	profile := m.session.ToolProfile
+	if profile == "" {
+		profile = config.DefaultToolProfile()
+	}
+	if profile != config.ToolProfileAll {
+		lines = append(lines, m.sessionLine("Tools", profile))
+	}
+	if m.session.PlanMode {
+		lines = append(lines, m.sessionLine("Mode", "plan (ctrl+p to turn off)"))
+	}
+	return lipgloss.JoinVertical(lipgloss.Top, lines...)
+}
+
+// sessionLine renders a setting of the session in the session section.
+func (m *sidebarCmp) sessionLine(name, value string) string {
+	key := styles.BaseStyle.Foreground(styles.PrimaryColor).Bold(true).Render(name)
+	return lipgloss.JoinHorizontal(
+		lipgloss.Left,
+		key,
+		styles.BaseStyle.
+			Foreground(styles.Forground).
+			Width(m.width-lipgloss.Width(key)).
+			Render(fmt.Sprintf(": %s", value)),
+	)
+}
+
+// todoSection shows the todo list of the session with its progress, or only
+// the item in progress when it is collapsed.
+func (m *sidebarCmp) todoSection() string {
+	done, total := m.todoList.Progress()
+	title := fmt.Sprintf("Tasks %d/%d:", done, total)
+	if m.todosCollapsed {
+		title = fmt.Sprintf("Tasks %d/%d (ctrl+t to expand):", done, total)
+	}
+	views := []string{
+		styles.BaseStyle.Width(m.width).Foreground(styles.PrimaryColor).Bold(true).Render(title),
+	}
+	for _, item := range m.todoList.Items {
+		if m.todosCollapsed && item.Status != todo.StatusInProgress {
+			continue
+		}
+		views = append(views, m.todoItem(item))
+	}
+	return styles.BaseStyle.
+		Width(m.width).
+		Render(
+			lipgloss.JoinVertical(
+				lipgloss.Top,
+				views...,
+			),
+		)
+}
+
+func (m *sidebarCmp) todoItem(item todo.Item) string {
+	icon, style := "○", styles.BaseStyle.Foreground(styles.Forground)
+	switch item.Status {
+	case todo.StatusInProgress:
+		icon, style = "▸", styles.BaseStyle.Foreground(styles.PrimaryColor).Bold(true)
+	case todo.StatusCompleted:
+		icon, style = styles.CheckIcon, styles.BaseStyle.Foreground(styles.ForgroundDim)
+	case todo.StatusCancelled:
+		icon, style = styles.ErrorIcon, styles.BaseStyle.Foreground(styles.ForgroundDim).Strikethrough(true)
+	}
+	// The sidebar is padded by 6 columns, the icon takes 2 of the rest
+	content := style.Width(max(m.width-8, 10)).Render(item.Content)
+	return lipgloss.JoinHorizontal(
+		lipgloss.Top,
+		style.Strikethrough(false).Render(icon+" "),
+		content,
 	)
 }
 
@@ -199,11 +294,24 @@ func (m *sidebarCmp) GetSize() (int, int) {
 	return m.width, m.height
 }
 
-func NewSidebarCmp(session session.Session, history history.Service) tea.Model {
+func NewSidebarCmp(session session.Session, history history.Service, todos todo.Service) tea.Model {
 	return &sidebarCmp{
 		session: session,
 		history: history,
+		todos:   todos,
+	}
+}
+
+func (m *sidebarCmp) loadTodos(ctx context.Context) {
+	m.todoList = todo.List{SessionID: m.session.ID}
+	if m.todos == nil || m.session.ID == "" {
+		return
+	}
+	list, err := m.todos.Get(ctx, m.session.ID)
+	if err != nil {
+		return
 	}
+	m.todoList = list
 }
 
 func (m *sidebarCmp) loadModifiedFiles(ctx context.Context) {
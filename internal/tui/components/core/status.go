@@ -98,10 +98,24 @@ func formatTokensAndCost(tokens int64, cost float64) string {
 	return fmt.Sprintf("Tokens: %s, Cost: %s", formattedTokens, formattedCost)
 }
 
+// tokens returns the token usage and cost of the session, with the share of
+// the context window of the coder model used by the conversation.
+func (m statusCmp) tokens() string {
+	tokens := formatTokensAndCost(m.session.PromptTokens+m.session.CompletionTokens, m.session.Cost)
+	coder, ok := config.Get().Agents[config.AgentCoder]
+	if !ok || m.session.ContextTokens == 0 {
+		return tokens
+	}
+	if window := models.SupportedModels[coder.Model].ContextWindow; window > 0 {
+		tokens += fmt.Sprintf(", Context: %d%%", min(100, m.session.ContextTokens*100/window))
+	}
+	return tokens
+}
+
 func (m statusCmp) View() string {
 	status := helpWidget
 	if m.session.ID != "" {
-		tokens := formatTokensAndCost(m.session.PromptTokens+m.session.CompletionTokens, m.session.Cost)
+		tokens := m.tokens()
 		tokensStyle := styles.Padded.
 			Background(styles.Forground).
 			Foreground(styles.BackgroundDim).
@@ -223,7 +237,7 @@ func (m statusCmp) availableFooterMsgWidth(diagnostics string) int {
 	tokens := ""
 	tokensWidth := 0
 	if m.session.ID != "" {
-		tokens = formatTokensAndCost(m.session.PromptTokens+m.session.CompletionTokens, m.session.Cost)
+		tokens = m.tokens()
 		tokensWidth = lipgloss.Width(tokens) + 2
 	}
 	return max(0, m.width-lipgloss.Width(helpWidget)-lipgloss.Width(m.model())-lipgloss.Width(diagnostics)-tokensWidth)
@@ -0,0 +1,276 @@
+package dialog
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/opencode-ai/opencode/internal/llm/models"
+	"github.com/opencode-ai/opencode/internal/tui/layout"
+	"github.com/opencode-ai/opencode/internal/tui/styles"
+	"github.com/opencode-ai/opencode/internal/tui/util"
+)
+
+// ModelSelectedMsg is sent when a model is selected
+type ModelSelectedMsg struct {
+	Model models.Model
+}
+
+// CloseModelDialogMsg is sent when the model dialog is closed
+type CloseModelDialogMsg struct{}
+
+// ModelDialog interface for the model switching dialog
+type ModelDialog interface {
+	tea.Model
+	layout.Bindings
+	SetModels(available []models.Model, selected models.ModelID)
+}
+
+type modelDialogCmp struct {
+	// models are grouped by provider, one provider is shown at a time
+	providers   []models.ModelProvider
+	models      map[models.ModelProvider][]models.Model
+	providerIdx int
+	selectedIdx int
+	width       int
+	height      int
+}
+
+type modelKeyMap struct {
+	Up     key.Binding
+	Down   key.Binding
+	Left   key.Binding
+	Right  key.Binding
+	Enter  key.Binding
+	Escape key.Binding
+	J      key.Binding
+	K      key.Binding
+	H      key.Binding
+	L      key.Binding
+}
+
+var modelKeys = modelKeyMap{
+	Up: key.NewBinding(
+		key.WithKeys("up"),
+		key.WithHelp("↑", "previous model"),
+	),
+	Down: key.NewBinding(
+		key.WithKeys("down"),
+		key.WithHelp("↓", "next model"),
+	),
+	Left: key.NewBinding(
+		key.WithKeys("left"),
+		key.WithHelp("←", "previous provider"),
+	),
+	Right: key.NewBinding(
+		key.WithKeys("right"),
+		key.WithHelp("→", "next provider"),
+	),
+	Enter: key.NewBinding(
+		key.WithKeys("enter"),
+		key.WithHelp("enter", "select model"),
+	),
+	Escape: key.NewBinding(
+		key.WithKeys("esc"),
+		key.WithHelp("esc", "close"),
+	),
+	J: key.NewBinding(
+		key.WithKeys("j"),
+		key.WithHelp("j", "next model"),
+	),
+	K: key.NewBinding(
+		key.WithKeys("k"),
+		key.WithHelp("k", "previous model"),
+	),
+	H: key.NewBinding(
+		key.WithKeys("h"),
+		key.WithHelp("h", "previous provider"),
+	),
+	L: key.NewBinding(
+		key.WithKeys("l"),
+		key.WithHelp("l", "next provider"),
+	),
+}
+
+func (m *modelDialogCmp) Init() tea.Cmd {
+	return nil
+}
+
+func (m *modelDialogCmp) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, modelKeys.Up) || key.Matches(msg, modelKeys.K):
+			if m.selectedIdx > 0 {
+				m.selectedIdx--
+			}
+			return m, nil
+		case key.Matches(msg, modelKeys.Down) || key.Matches(msg, modelKeys.J):
+			if m.selectedIdx < len(m.current())-1 {
+				m.selectedIdx++
+			}
+			return m, nil
+		case key.Matches(msg, modelKeys.Left) || key.Matches(msg, modelKeys.H):
+			if m.providerIdx > 0 {
+				m.providerIdx--
+				m.selectedIdx = 0
+			}
+			return m, nil
+		case key.Matches(msg, modelKeys.Right) || key.Matches(msg, modelKeys.L):
+			if m.providerIdx < len(m.providers)-1 {
+				m.providerIdx++
+				m.selectedIdx = 0
+			}
+			return m, nil
+		case key.Matches(msg, modelKeys.Enter):
+			if current := m.current(); len(current) > 0 {
+				return m, util.CmdHandler(ModelSelectedMsg{
+					Model: current[m.selectedIdx],
+				})
+			}
+		case key.Matches(msg, modelKeys.Escape):
+			return m, util.CmdHandler(CloseModelDialogMsg{})
+		}
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+	}
+	return m, nil
+}
+
+// current returns the models of the selected provider
+func (m *modelDialogCmp) current() []models.Model {
+	if len(m.providers) == 0 {
+		return nil
+	}
+	return m.models[m.providers[m.providerIdx]]
+}
+
+func (m *modelDialogCmp) View() string {
+	current := m.current()
+	if len(current) == 0 {
+		return styles.BaseStyle.Padding(1, 2).
+			Border(lipgloss.RoundedBorder()).
+			BorderBackground(styles.Background).
+			BorderForeground(styles.ForgroundDim).
+			Width(40).
+			Render("No models available")
+	}
+
+	// Calculate max width needed for model names
+	maxWidth := 40 // Minimum width
+	for _, model := range current {
+		if len(model.Name) > maxWidth-4 { // Account for padding
+			maxWidth = len(model.Name) + 4
+		}
+	}
+
+	maxWidth = max(30, min(maxWidth, m.width-15)) // Limit width to avoid overflow
+
+	// Limit height to avoid taking up too much screen space
+	maxVisibleModels := min(10, len(current))
+
+	// Build the model list
+	modelItems := make([]string, 0, maxVisibleModels)
+	startIdx := 0
+
+	// If we have more models than can be displayed, adjust the start index
+	if len(current) > maxVisibleModels {
+		// Center the selected item when possible
+		halfVisible := maxVisibleModels / 2
+		if m.selectedIdx >= halfVisible && m.selectedIdx < len(current)-halfVisible {
+			startIdx = m.selectedIdx - halfVisible
+		} else if m.selectedIdx >= len(current)-halfVisible {
+			startIdx = len(current) - maxVisibleModels
+		}
+	}
+
+	endIdx := min(startIdx+maxVisibleModels, len(current))
+
+	for i := startIdx; i < endIdx; i++ {
+		itemStyle := styles.BaseStyle.Width(maxWidth)
+
+		if i == m.selectedIdx {
+			itemStyle = itemStyle.
+				Background(styles.PrimaryColor).
+				Foreground(styles.Background).
+				Bold(true)
+		}
+
+		modelItems = append(modelItems, itemStyle.Padding(0, 1).Render(current[i].Name))
+	}
+
+	title := styles.BaseStyle.
+		Foreground(styles.PrimaryColor).
+		Bold(true).
+		Width(maxWidth).
+		Padding(0, 1).
+		Render("Switch Model")
+
+	provider := string(m.providers[m.providerIdx])
+	if len(m.providers) > 1 {
+		provider = fmt.Sprintf("← %s (%d/%d) →", provider, m.providerIdx+1, len(m.providers))
+	}
+	providerLine := styles.BaseStyle.
+		Foreground(styles.ForgroundDim).
+		Width(maxWidth).
+		Padding(0, 1).
+		Render(provider)
+
+	content := lipgloss.JoinVertical(
+		lipgloss.Left,
+		title,
+		providerLine,
+		styles.BaseStyle.Width(maxWidth).Render(""),
+		styles.BaseStyle.Width(maxWidth).Render(lipgloss.JoinVertical(lipgloss.Left, modelItems...)),
+		styles.BaseStyle.Width(maxWidth).Render(""),
+	)
+
+	return styles.BaseStyle.Padding(1, 2).
+		Border(lipgloss.RoundedBorder()).
+		BorderBackground(styles.Background).
+		BorderForeground(styles.ForgroundDim).
+		Width(lipgloss.Width(content) + 4).
+		Render(content)
+}
+
+func (m *modelDialogCmp) BindingKeys() []key.Binding {
+	return layout.KeyMapToSlice(modelKeys)
+}
+
+func (m *modelDialogCmp) SetModels(available []models.Model, selected models.ModelID) {
+	m.providers = nil
+	m.models = make(map[models.ModelProvider][]models.Model)
+	for _, model := range available {
+		if _, ok := m.models[model.Provider]; !ok {
+			m.providers = append(m.providers, model.Provider)
+		}
+		m.models[model.Provider] = append(m.models[model.Provider], model)
+	}
+	slices.Sort(m.providers)
+	for _, list := range m.models {
+		slices.SortFunc(list, func(a, b models.Model) int {
+			return strings.Compare(a.Name, b.Name)
+		})
+	}
+
+	// Start at the selected model
+	m.providerIdx, m.selectedIdx = 0, 0
+	for i, provider := range m.providers {
+		for j, model := range m.models[provider] {
+			if model.ID == selected {
+				m.providerIdx, m.selectedIdx = i, j
+			}
+		}
+	}
+}
+
+// NewModelDialogCmp creates a new model switching dialog
+func NewModelDialogCmp() ModelDialog {
+	return &modelDialogCmp{
+		models: make(map[models.ModelProvider][]models.Model),
+	}
+}
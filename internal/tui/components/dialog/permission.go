@@ -223,12 +223,18 @@ func (p *permissionDialogCmp) renderHeader() string {
 	switch p.permission.ToolName {
 	case tools.BashToolName:
 		headerParts = append(headerParts, styles.BaseStyle.Foreground(styles.ForgroundDim).Width(p.width).Bold(true).Render("Command"))
-	case tools.EditToolName:
+	case tools.EditToolName, tools.MultiEditToolName, tools.RenameToolName, tools.ApplyDiffToolName:
 		headerParts = append(headerParts, styles.BaseStyle.Foreground(styles.ForgroundDim).Width(p.width).Bold(true).Render("Diff"))
 	case tools.WriteToolName:
 		headerParts = append(headerParts, styles.BaseStyle.Foreground(styles.ForgroundDim).Width(p.width).Bold(true).Render("Diff"))
 	case tools.FetchToolName:
 		headerParts = append(headerParts, styles.BaseStyle.Foreground(styles.ForgroundDim).Width(p.width).Bold(true).Render("URL"))
+	case tools.NotebookToolName:
+		header := "Diff"
+		if _, ok := p.permission.Params.(tools.NotebookPermissionsParams); ok {
+			header = "Code"
+		}
+		headerParts = append(headerParts, styles.BaseStyle.Foreground(styles.ForgroundDim).Width(p.width).Bold(true).Render(header))
 	}
 
 	return lipgloss.NewStyle().Render(lipgloss.JoinVertical(lipgloss.Left, headerParts...))
@@ -269,6 +275,26 @@ func (p *permissionDialogCmp) renderEditContent() string {
 	return ""
 }
 
+func (p *permissionDialogCmp) renderMultiEditContent() string {
+	if pr, ok := p.permission.Params.(tools.MultiEditPermissionsParams); ok {
+		diff := p.GetOrSetDiff(p.permission.ID, func() (string, error) {
+			formattedDiffs := make([]string, 0, len(pr.Files))
+			for _, file := range pr.Files {
+				formattedDiff, err := diff.FormatDiff(file.Diff, diff.WithTotalWidth(p.contentViewPort.Width))
+				if err != nil {
+					return "", err
+				}
+				formattedDiffs = append(formattedDiffs, formattedDiff)
+			}
+			return strings.Join(formattedDiffs, "\n"), nil
+		})
+
+		p.contentViewPort.SetContent(diff)
+		return p.styleViewport()
+	}
+	return ""
+}
+
 func (p *permissionDialogCmp) renderPatchContent() string {
 	if pr, ok := p.permission.Params.(tools.EditPermissionsParams); ok {
 		diff := p.GetOrSetDiff(p.permission.ID, func() (string, error) {
@@ -314,6 +340,31 @@ func (p *permissionDialogCmp) renderFetchContent() string {
 	return ""
 }
 
+func (p *permissionDialogCmp) renderNotebookContent() string {
+	pr, ok := p.permission.Params.(tools.NotebookPermissionsParams)
+	if !ok {
+		return p.renderPatchContent()
+	}
+	language := ""
+	if strings.HasPrefix(pr.Kernel, "python") {
+		language = "python"
+	}
+	content := fmt.Sprintf("```%s\n%s\n```", language, pr.Code)
+
+	// Use the cache for markdown rendering
+	renderedContent := p.GetOrSetMarkdown(p.permission.ID, func() (string, error) {
+		r, _ := glamour.NewTermRenderer(
+			glamour.WithStyles(styles.MarkdownTheme(true)),
+			glamour.WithWordWrap(p.width-10),
+		)
+		s, err := r.Render(content)
+		return styles.ForceReplaceBackgroundWithLipgloss(s, styles.Background), err
+	})
+
+	p.contentViewPort.SetContent(renderedContent)
+	return p.styleViewport()
+}
+
 func (p *permissionDialogCmp) renderDefaultContent() string {
 	content := p.permission.Description
 
@@ -365,12 +416,16 @@ func (p *permissionDialogCmp) render() string {
 		contentFinal = p.renderBashContent()
 	case tools.EditToolName:
 		contentFinal = p.renderEditContent()
-	case tools.PatchToolName:
+	case tools.MultiEditToolName, tools.RenameToolName:
+		contentFinal = p.renderMultiEditContent()
+	case tools.PatchToolName, tools.ApplyDiffToolName:
 		contentFinal = p.renderPatchContent()
 	case tools.WriteToolName:
 		contentFinal = p.renderWriteContent()
 	case tools.FetchToolName:
 		contentFinal = p.renderFetchContent()
+	case tools.NotebookToolName:
+		contentFinal = p.renderNotebookContent()
 	default:
 		contentFinal = p.renderDefaultContent()
 	}
@@ -413,7 +468,7 @@ func (p *permissionDialogCmp) SetSize() tea.Cmd {
 	case tools.BashToolName:
 		p.width = int(float64(p.windowSize.Width) * 0.4)
 		p.height = int(float64(p.windowSize.Height) * 0.3)
-	case tools.EditToolName:
+	case tools.EditToolName, tools.MultiEditToolName, tools.RenameToolName, tools.ApplyDiffToolName, tools.NotebookToolName:
 		p.width = int(float64(p.windowSize.Width) * 0.8)
 		p.height = int(float64(p.windowSize.Height) * 0.8)
 	case tools.WriteToolName:
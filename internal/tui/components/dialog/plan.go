@@ -0,0 +1,156 @@
+package dialog
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textarea"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/opencode-ai/opencode/internal/tui/layout"
+	"github.com/opencode-ai/opencode/internal/tui/styles"
+	"github.com/opencode-ai/opencode/internal/tui/util"
+)
+
+// PlanApprovedMsg is sent when the plan is approved, with the edits of the
+// user
+type PlanApprovedMsg struct {
+	SessionID string
+	Plan      string
+}
+
+// ClosePlanDialogMsg is sent when the plan dialog is closed without approving
+// the plan
+type ClosePlanDialogMsg struct{}
+
+// PlanDialog shows the plan the agent proposed in plan mode for the user to
+// edit and approve
+type PlanDialog interface {
+	tea.Model
+	layout.Bindings
+	SetPlan(sessionID, plan string)
+	SetSize(width, height int)
+}
+
+type planDialogCmp struct {
+	sessionID     string
+	textarea      textarea.Model
+	width, height int
+}
+
+type planKeyMap struct {
+	Approve key.Binding
+	Escape  key.Binding
+}
+
+var planKeys = planKeyMap{
+	Approve: key.NewBinding(
+		key.WithKeys("ctrl+s"),
+		key.WithHelp("ctrl+s", "approve and execute"),
+	),
+	Escape: key.NewBinding(
+		key.WithKeys("esc"),
+		key.WithHelp("esc", "keep planning"),
+	),
+}
+
+func (p *planDialogCmp) Init() tea.Cmd {
+	return textarea.Blink
+}
+
+func (p *planDialogCmp) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, planKeys.Approve):
+			plan := strings.TrimSpace(p.textarea.Value())
+			if plan == "" {
+				return p, util.ReportWarn("The plan is empty")
+			}
+			return p, util.CmdHandler(PlanApprovedMsg{SessionID: p.sessionID, Plan: plan})
+		case key.Matches(msg, planKeys.Escape):
+			return p, util.CmdHandler(ClosePlanDialogMsg{})
+		}
+	case tea.WindowSizeMsg:
+		p.SetSize(msg.Width, msg.Height)
+		return p, nil
+	}
+	var cmd tea.Cmd
+	p.textarea, cmd = p.textarea.Update(msg)
+	return p, cmd
+}
+
+// SetPlan shows the plan of the session in the editor.
+func (p *planDialogCmp) SetPlan(sessionID, plan string) {
+	p.sessionID = sessionID
+	p.textarea.SetValue(plan)
+	// Start reading at the top of the plan
+	for p.textarea.Line() > 0 {
+		p.textarea.CursorUp()
+	}
+	p.textarea.CursorStart()
+	p.textarea.Focus()
+}
+
+func (p *planDialogCmp) SetSize(width, height int) {
+	p.width, p.height = width, height
+	p.textarea.SetWidth(max(20, min(100, width-16)))
+	p.textarea.SetHeight(max(5, min(25, height-14)))
+}
+
+func (p *planDialogCmp) View() string {
+	width := p.textarea.Width() + 2
+	title := styles.BaseStyle.
+		Foreground(styles.PrimaryColor).
+		Bold(true).
+		Width(width).
+		Padding(0, 1).
+		Render("Review the plan")
+	explanation := styles.BaseStyle.
+		Foreground(styles.ForgroundDim).
+		Width(width).
+		Padding(0, 1).
+		Render("Edit the plan if needed. Approving it turns plan mode off and the assistant carries it out with all the tools.")
+	help := styles.BaseStyle.
+		Foreground(styles.ForgroundDim).
+		Width(width).
+		Padding(0, 1).
+		Render("ctrl+s approve and execute • esc keep planning")
+
+	content := lipgloss.JoinVertical(
+		lipgloss.Left,
+		title,
+		explanation,
+		styles.BaseStyle.Width(width).Render(""),
+		styles.BaseStyle.Padding(0, 1).Render(p.textarea.View()),
+		styles.BaseStyle.Width(width).Render(""),
+		help,
+	)
+
+	return styles.BaseStyle.Padding(1, 2).
+		Border(lipgloss.RoundedBorder()).
+		BorderBackground(styles.Background).
+		BorderForeground(styles.ForgroundDim).
+		Width(lipgloss.Width(content) + 4).
+		Render(content)
+}
+
+func (p *planDialogCmp) BindingKeys() []key.Binding {
+	return layout.KeyMapToSlice(planKeys)
+}
+
+func NewPlanDialogCmp() PlanDialog {
+	ti := textarea.New()
+	ti.Prompt = " "
+	ti.ShowLineNumbers = false
+	ti.CharLimit = -1
+	ti.BlurredStyle.Base = ti.BlurredStyle.Base.Background(styles.Background)
+	ti.BlurredStyle.CursorLine = ti.BlurredStyle.CursorLine.Background(styles.Background)
+	ti.BlurredStyle.Text = ti.BlurredStyle.Text.Background(styles.Background)
+	ti.FocusedStyle.Base = ti.FocusedStyle.Base.Background(styles.Background)
+	ti.FocusedStyle.CursorLine = ti.FocusedStyle.CursorLine.Background(styles.Background)
+	ti.FocusedStyle.Text = ti.BlurredStyle.Text.Background(styles.Background)
+	p := &planDialogCmp{textarea: ti}
+	p.SetSize(80, 30)
+	return p
+}
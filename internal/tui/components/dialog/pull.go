@@ -0,0 +1,242 @@
+package dialog
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/opencode-ai/opencode/internal/llm/discovery"
+	"github.com/opencode-ai/opencode/internal/llm/models"
+	"github.com/opencode-ai/opencode/internal/tui/styles"
+	"github.com/opencode-ai/opencode/internal/tui/util"
+)
+
+// PullModelDialogCmp is a component that asks the user if they want to pull a
+// missing Ollama model and shows the download progress.
+type PullModelDialogCmp struct {
+	width, height int
+	selected      int
+	model         models.Model
+	pulling       bool
+	progress      discovery.OllamaPullProgress
+}
+
+// NewPullModelDialogCmp creates a new PullModelDialogCmp for the given model.
+func NewPullModelDialogCmp(model models.Model) PullModelDialogCmp {
+	return PullModelDialogCmp{
+		model: model,
+	}
+}
+
+// Init implements tea.Model.
+func (m PullModelDialogCmp) Init() tea.Cmd {
+	return nil
+}
+
+// Update implements tea.Model.
+func (m PullModelDialogCmp) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		if m.pulling {
+			return m, nil
+		}
+		switch {
+		case key.Matches(msg, key.NewBinding(key.WithKeys("tab", "left", "right", "h", "l"))):
+			m.selected = (m.selected + 1) % 2
+			return m, nil
+		case key.Matches(msg, key.NewBinding(key.WithKeys("enter"))):
+			return m, util.CmdHandler(ClosePullModelDialogMsg{Model: m.model, Pull: m.selected == 0})
+		case key.Matches(msg, key.NewBinding(key.WithKeys("y"))):
+			return m, util.CmdHandler(ClosePullModelDialogMsg{Model: m.model, Pull: true})
+		case key.Matches(msg, key.NewBinding(key.WithKeys("n"))):
+			return m, util.CmdHandler(ClosePullModelDialogMsg{Model: m.model, Pull: false})
+		}
+	case ClosePullModelDialogMsg:
+		m.pulling = msg.Pull
+	case PullModelProgressMsg:
+		m.progress = msg.Progress
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+	}
+	return m, nil
+}
+
+// View implements tea.Model.
+func (m PullModelDialogCmp) View() string {
+	maxWidth := 60
+
+	title := styles.BaseStyle.
+		Foreground(styles.PrimaryColor).
+		Bold(true).
+		Width(maxWidth).
+		Padding(0, 1).
+		Render("Pull Ollama Model")
+
+	explanation := styles.BaseStyle.
+		Foreground(styles.Forground).
+		Width(maxWidth).
+		Padding(0, 1).
+		Render(fmt.Sprintf("The model %s is not available on your Ollama server. Models are often several gigabytes, so the download may take a while.", m.model.APIModel))
+
+	var body string
+	if m.pulling {
+		body = m.progressView(maxWidth)
+	} else {
+		body = m.confirmView(maxWidth)
+	}
+
+	content := lipgloss.JoinVertical(
+		lipgloss.Left,
+		title,
+		styles.BaseStyle.Width(maxWidth).Render(""),
+		explanation,
+		body,
+		styles.BaseStyle.Width(maxWidth).Render(""),
+	)
+
+	return styles.BaseStyle.Padding(1, 2).
+		Border(lipgloss.RoundedBorder()).
+		BorderBackground(styles.Background).
+		BorderForeground(styles.ForgroundDim).
+		Width(lipgloss.Width(content) + 4).
+		Render(content)
+}
+
+func (m PullModelDialogCmp) confirmView(maxWidth int) string {
+	question := styles.BaseStyle.
+		Foreground(styles.Forground).
+		Width(maxWidth).
+		Padding(1, 1).
+		Render("Would you like to pull it now?")
+
+	yesStyle := styles.BaseStyle
+	noStyle := styles.BaseStyle
+	if m.selected == 0 {
+		yesStyle = yesStyle.
+			Background(styles.PrimaryColor).
+			Foreground(styles.Background).
+			Bold(true)
+		noStyle = noStyle.
+			Background(styles.Background).
+			Foreground(styles.PrimaryColor)
+	} else {
+		noStyle = noStyle.
+			Background(styles.PrimaryColor).
+			Foreground(styles.Background).
+			Bold(true)
+		yesStyle = yesStyle.
+			Background(styles.Background).
+			Foreground(styles.PrimaryColor)
+	}
+
+	yes := yesStyle.Padding(0, 3).Render("Yes")
+	no := noStyle.Padding(0, 3).Render("No")
+
+	buttons := lipgloss.JoinHorizontal(lipgloss.Center, yes, styles.BaseStyle.Render("  "), no)
+	buttons = styles.BaseStyle.
+		Width(maxWidth).
+		Padding(1, 0).
+		Render(buttons)
+
+	return lipgloss.JoinVertical(lipgloss.Left, question, buttons)
+}
+
+func (m PullModelDialogCmp) progressView(maxWidth int) string {
+	status := m.progress.Status
+	if status == "" {
+		status = "starting download"
+	}
+	status = styles.BaseStyle.
+		Foreground(styles.Forground).
+		Width(maxWidth).
+		Padding(1, 1, 0, 1).
+		Render(status)
+
+	barWidth := maxWidth - 20
+	filled := 0
+	percent := 0.0
+	if m.progress.Total > 0 {
+		percent = float64(m.progress.Completed) / float64(m.progress.Total)
+		filled = int(percent * float64(barWidth))
+	}
+	bar := styles.BaseStyle.Foreground(styles.PrimaryColor).Render(strings.Repeat("█", filled)) +
+		styles.BaseStyle.Foreground(styles.ForgroundDim).Render(strings.Repeat("░", barWidth-filled))
+	details := fmt.Sprintf(" %3.0f%%", percent*100)
+	if m.progress.Total > 0 {
+		details += fmt.Sprintf("  %s / %s", formatBytes(m.progress.Completed), formatBytes(m.progress.Total))
+	}
+	progress := styles.BaseStyle.
+		Width(maxWidth).
+		Padding(1, 1, 0, 1).
+		Render(bar + styles.BaseStyle.Foreground(styles.Forground).Render(details))
+
+	hint := styles.BaseStyle.
+		Foreground(styles.ForgroundDim).
+		Width(maxWidth).
+		Padding(1, 1, 0, 1).
+		Render("Press esc to cancel")
+
+	return lipgloss.JoinVertical(lipgloss.Left, status, progress, hint)
+}
+
+func formatBytes(n int64) string {
+	const unit = 1000
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for v := n / unit; v >= unit; v /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "kMGTPE"[exp])
+}
+
+// SetSize sets the size of the component.
+func (m *PullModelDialogCmp) SetSize(width, height int) {
+	m.width = width
+	m.height = height
+}
+
+// Model returns the model the dialog was opened for.
+func (m PullModelDialogCmp) Model() models.Model {
+	return m.model
+}
+
+// Pulling reports whether the model download is in progress.
+func (m PullModelDialogCmp) Pulling() bool {
+	return m.pulling
+}
+
+// Bindings implements layout.Bindings.
+func (m PullModelDialogCmp) Bindings() []key.Binding {
+	return initDialogKeyMap{}.ShortHelp()
+}
+
+// ShowPullModelDialogMsg is a message that is sent to show the pull dialog.
+type ShowPullModelDialogMsg struct {
+	Model models.Model
+}
+
+// ClosePullModelDialogMsg is a message that is sent when the user answered the
+// pull confirmation.
+type ClosePullModelDialogMsg struct {
+	Model models.Model
+	Pull  bool
+}
+
+// PullModelProgressMsg is a message that is sent for every progress update
+// while a model is pulled.
+type PullModelProgressMsg struct {
+	Progress discovery.OllamaPullProgress
+}
+
+// PullModelDoneMsg is a message that is sent when a model pull has finished.
+type PullModelDoneMsg struct {
+	Model models.Model
+	Err   error
+}
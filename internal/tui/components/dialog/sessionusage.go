@@ -0,0 +1,249 @@
+package dialog
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/opencode-ai/opencode/internal/tui/layout"
+	"github.com/opencode-ai/opencode/internal/tui/styles"
+	"github.com/opencode-ai/opencode/internal/tui/util"
+	"github.com/opencode-ai/opencode/internal/usage"
+)
+
+// CloseSessionUsageDialogMsg is sent when the session usage dialog is closed
+type CloseSessionUsageDialogMsg struct{}
+
+// SessionUsageDialog interface for the chart of the usage of a session over
+// time
+type SessionUsageDialog interface {
+	tea.Model
+	layout.Bindings
+	SetUsage(title string, records []usage.Record, turns []usage.Stats)
+}
+
+// usageSeries is a cumulative value of the usage charted by the dialog
+type usageSeries struct {
+	name   string
+	value  func(usage.Record) float64
+	format func(float64) string
+}
+
+func formatTokenValue(v float64) string {
+	return usage.FormatTokens(int64(v))
+}
+
+var sessionUsageSeries = []usageSeries{
+	{"Input tokens", func(r usage.Record) float64 { return float64(r.InputTokens) }, formatTokenValue},
+	{"Output tokens", func(r usage.Record) float64 { return float64(r.OutputTokens) }, formatTokenValue},
+	{"Cached tokens", func(r usage.Record) float64 { return float64(r.CacheCreationTokens + r.CacheReadTokens) }, formatTokenValue},
+	{"Cost", func(r usage.Record) float64 { return r.Cost }, func(v float64) string { return fmt.Sprintf("$%.2f", v) }},
+}
+
+const (
+	// sessionUsageChartHeight is the number of rows of the chart
+	sessionUsageChartHeight = 8
+	// sessionUsageTopTurns is the number of the most expensive turns listed
+	// under the chart
+	sessionUsageTopTurns = 5
+)
+
+// chartBlocks draw the top of a bar in eighths of a row
+var chartBlocks = []string{" ", "▁", "▂", "▃", "▄", "▅", "▆", "▇", "█"}
+
+type sessionUsageDialogCmp struct {
+	title     string
+	records   []usage.Record
+	turns     []usage.Stats
+	seriesIdx int
+	width     int
+	height    int
+}
+
+type sessionUsageKeyMap struct {
+	Left   key.Binding
+	Right  key.Binding
+	Escape key.Binding
+}
+
+var sessionUsageKeys = sessionUsageKeyMap{
+	Left: key.NewBinding(
+		key.WithKeys("left", "h"),
+		key.WithHelp("←/h", "previous chart"),
+	),
+	Right: key.NewBinding(
+		key.WithKeys("right", "l", "tab"),
+		key.WithHelp("→/l", "next chart"),
+	),
+	Escape: key.NewBinding(
+		key.WithKeys("esc"),
+		key.WithHelp("esc", "close"),
+	),
+}
+
+func (s *sessionUsageDialogCmp) Init() tea.Cmd {
+	return nil
+}
+
+func (s *sessionUsageDialogCmp) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, sessionUsageKeys.Left):
+			s.seriesIdx = (s.seriesIdx + len(sessionUsageSeries) - 1) % len(sessionUsageSeries)
+		case key.Matches(msg, sessionUsageKeys.Right):
+			s.seriesIdx = (s.seriesIdx + 1) % len(sessionUsageSeries)
+		case key.Matches(msg, sessionUsageKeys.Escape):
+			return s, util.CmdHandler(CloseSessionUsageDialogMsg{})
+		}
+	case tea.WindowSizeMsg:
+		s.width = msg.Width
+		s.height = msg.Height
+	}
+	return s, nil
+}
+
+func (s *sessionUsageDialogCmp) View() string {
+	series := sessionUsageSeries[s.seriesIdx]
+	nameWidth := 30
+	if s.width > 0 {
+		nameWidth = max(16, min(nameWidth, s.width-60))
+	}
+	row := func(name, requests, input, output, cached, cost string) string {
+		return fmt.Sprintf("%-*s %8s %8s %8s %8s %9s", nameWidth, truncate(name, nameWidth), requests, input, output, cached, cost)
+	}
+	header := row("Turn", "Requests", "Input", "Output", "Cached", "Cost")
+	width := lipgloss.Width(header) + 2
+
+	cumulative := make([]float64, len(s.records))
+	total := 0.0
+	for i, r := range s.records {
+		total += series.value(r)
+		cumulative[i] = total
+	}
+
+	title := styles.BaseStyle.
+		Foreground(styles.PrimaryColor).
+		Bold(true).
+		Width(width).
+		Padding(0, 1).
+		Render(s.title)
+	tabs := styles.BaseStyle.
+		Foreground(styles.ForgroundDim).
+		Width(width).
+		Padding(0, 1).
+		Render(fmt.Sprintf("← %s: %s (%d/%d) →", series.name, series.format(total), s.seriesIdx+1, len(sessionUsageSeries)))
+
+	var chart string
+	if len(s.records) == 0 {
+		chart = styles.BaseStyle.Width(width).Padding(0, 1).Foreground(styles.ForgroundDim).Render("No usage recorded")
+	} else {
+		chart = styles.BaseStyle.Width(width).Padding(0, 1).Render(s.renderChart(cumulative, series, width-2))
+	}
+
+	turnRows := []string{}
+	for i, turn := range s.turns {
+		if i == sessionUsageTopTurns {
+			break
+		}
+		turnRows = append(turnRows, styles.BaseStyle.Width(width).Padding(0, 1).Render(statsRow(row, turn)))
+	}
+	if len(turnRows) == 0 {
+		turnRows = append(turnRows, styles.BaseStyle.Width(width).Padding(0, 1).Foreground(styles.ForgroundDim).Render("No turns"))
+	}
+
+	headerStyle := styles.BaseStyle.Width(width).Padding(0, 1).Bold(true)
+	content := lipgloss.JoinVertical(
+		lipgloss.Left,
+		title,
+		tabs,
+		styles.BaseStyle.Width(width).Render(""),
+		chart,
+		styles.BaseStyle.Width(width).Render(""),
+		headerStyle.Render(header),
+		styles.BaseStyle.Width(width).Render(lipgloss.JoinVertical(lipgloss.Left, turnRows...)),
+	)
+
+	return styles.BaseStyle.Padding(1, 2).
+		Border(lipgloss.RoundedBorder()).
+		BorderBackground(styles.Background).
+		BorderForeground(styles.ForgroundDim).
+		Width(lipgloss.Width(content) + 4).
+		Render(content)
+}
+
+// renderChart draws the cumulative values as bars, one per request or per
+// group of requests when there are more than columns, with the scale on the
+// left and the time of the first and the last request below.
+func (s *sessionUsageDialogCmp) renderChart(cumulative []float64, series usageSeries, width int) string {
+	top := cumulative[len(cumulative)-1]
+	topLabel := series.format(top)
+	labelWidth := max(lipgloss.Width(topLabel), lipgloss.Width(series.format(0)))
+	chartWidth := width - labelWidth - 1
+
+	columns := min(chartWidth, len(cumulative))
+	barWidth := max(1, min(4, chartWidth/columns))
+	levels := make([]int, columns)
+	for c := range columns {
+		// The cumulative value at the last request of the column
+		v := cumulative[(c+1)*len(cumulative)/columns-1]
+		if top > 0 {
+			levels[c] = int(math.Round(v / top * sessionUsageChartHeight * 8))
+		}
+	}
+
+	barStyle := styles.BaseStyle.Foreground(styles.PrimaryColor)
+	axisStyle := styles.BaseStyle.Foreground(styles.ForgroundDim)
+	lines := make([]string, 0, sessionUsageChartHeight+2)
+	for r := range sessionUsageChartHeight {
+		level := sessionUsageChartHeight - 1 - r
+		var bars strings.Builder
+		for _, l := range levels {
+			fill := min(8, max(0, l-level*8))
+			bars.WriteString(strings.Repeat(chartBlocks[fill], barWidth))
+		}
+		label := ""
+		switch r {
+		case 0:
+			label = topLabel
+		case sessionUsageChartHeight - 1:
+			label = series.format(0)
+		}
+		lines = append(lines, axisStyle.Render(fmt.Sprintf("%*s│", labelWidth, label))+barStyle.Render(bars.String()))
+	}
+	barsWidth := columns * barWidth
+	lines = append(lines, axisStyle.Render(strings.Repeat(" ", labelWidth)+"└"+strings.Repeat("─", barsWidth)))
+
+	first := time.Unix(s.records[0].CreatedAt, 0)
+	last := time.Unix(s.records[len(s.records)-1].CreatedAt, 0)
+	timeLayout := "15:04"
+	if first.YearDay() != last.YearDay() || first.Year() != last.Year() {
+		timeLayout = "Jan 2 15:04"
+	}
+	start, end := first.Format(timeLayout), last.Format(timeLayout)
+	gap := max(1, barsWidth-lipgloss.Width(start)-lipgloss.Width(end))
+	lines = append(lines, axisStyle.Render(strings.Repeat(" ", labelWidth+1)+start+strings.Repeat(" ", gap)+end))
+	return lipgloss.JoinVertical(lipgloss.Left, lines...)
+}
+
+func (s *sessionUsageDialogCmp) BindingKeys() []key.Binding {
+	return layout.KeyMapToSlice(sessionUsageKeys)
+}
+
+// SetUsage sets the usage records of the session, in the order they were
+// recorded, and its turns, the most expensive first.
+func (s *sessionUsageDialogCmp) SetUsage(title string, records []usage.Record, turns []usage.Stats) {
+	s.title = title
+	s.records = records
+	s.turns = turns
+	s.seriesIdx = 0
+}
+
+// NewSessionUsageDialogCmp creates a new session usage dialog
+func NewSessionUsageDialogCmp() SessionUsageDialog {
+	return &sessionUsageDialogCmp{}
+}
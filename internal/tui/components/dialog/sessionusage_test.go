@@ -0,0 +1,38 @@
+package dialog
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/opencode-ai/opencode/internal/usage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSessionUsageDialog(t *testing.T) {
+	d := NewSessionUsageDialogCmp()
+	d.SetUsage("Usage of Fix the parser", []usage.Record{
+		{InputTokens: 1000, OutputTokens: 100, CacheReadTokens: 500, Cost: 0.25, CreatedAt: 1_700_000_000},
+		{InputTokens: 500, OutputTokens: 400, CacheCreationTokens: 1500, Cost: 1, CreatedAt: 1_700_000_060},
+	}, []usage.Stats{
+		{Title: "fix the parser", Requests: 2, InputTokens: 1500, OutputTokens: 500, CacheReadTokens: 500, CacheCreationTokens: 1500, Cost: 1.25},
+	})
+
+	// The totals of the series, switched with the keys
+	view := d.View()
+	assert.Contains(t, view, "Usage of Fix the parser")
+	assert.Contains(t, view, "Input tokens: 1.5K (1/4)")
+	assert.Contains(t, view, "fix the parser")
+	for _, want := range []string{"Output tokens: 500 (2/4)", "Cached tokens: 2K (3/4)", "Cost: $1.25 (4/4)", "Input tokens: 1.5K (1/4)"} {
+		d.Update(tea.KeyMsg{Type: tea.KeyRight})
+		assert.Contains(t, d.View(), want)
+	}
+	d.Update(tea.KeyMsg{Type: tea.KeyLeft})
+	assert.Contains(t, d.View(), "Cost: $1.25 (4/4)")
+
+	// A new session starts with the first series
+	d.SetUsage("Usage of New session", nil, nil)
+	view = d.View()
+	assert.Contains(t, view, "Input tokens: 0 (1/4)")
+	assert.Contains(t, view, "No usage recorded")
+	assert.Contains(t, view, "No turns")
+}
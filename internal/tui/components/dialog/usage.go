@@ -0,0 +1,224 @@
+package dialog
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/opencode-ai/opencode/internal/tui/layout"
+	"github.com/opencode-ai/opencode/internal/tui/styles"
+	"github.com/opencode-ai/opencode/internal/tui/util"
+	"github.com/opencode-ai/opencode/internal/usage"
+)
+
+// CloseUsageDialogMsg is sent when the usage dialog is closed
+type CloseUsageDialogMsg struct{}
+
+// UsageDialog interface for the usage and cost report dialog
+type UsageDialog interface {
+	tea.Model
+	layout.Bindings
+	SetStats(title string, bySession, byDay, byProvider []usage.Stats)
+}
+
+type usageView struct {
+	name  string
+	stats []usage.Stats
+}
+
+type usageDialogCmp struct {
+	title       string
+	views       []usageView
+	viewIdx     int
+	selectedIdx int
+	width       int
+	height      int
+}
+
+type usageKeyMap struct {
+	Up     key.Binding
+	Down   key.Binding
+	Left   key.Binding
+	Right  key.Binding
+	Escape key.Binding
+}
+
+var usageKeys = usageKeyMap{
+	Up: key.NewBinding(
+		key.WithKeys("up", "k"),
+		key.WithHelp("↑/k", "previous row"),
+	),
+	Down: key.NewBinding(
+		key.WithKeys("down", "j"),
+		key.WithHelp("↓/j", "next row"),
+	),
+	Left: key.NewBinding(
+		key.WithKeys("left", "h"),
+		key.WithHelp("←/h", "previous view"),
+	),
+	Right: key.NewBinding(
+		key.WithKeys("right", "l", "tab"),
+		key.WithHelp("→/l", "next view"),
+	),
+	Escape: key.NewBinding(
+		key.WithKeys("esc"),
+		key.WithHelp("esc", "close"),
+	),
+}
+
+func (u *usageDialogCmp) Init() tea.Cmd {
+	return nil
+}
+
+func (u *usageDialogCmp) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, usageKeys.Up):
+			if u.selectedIdx > 0 {
+				u.selectedIdx--
+			}
+		case key.Matches(msg, usageKeys.Down):
+			if u.selectedIdx < len(u.views[u.viewIdx].stats)-1 {
+				u.selectedIdx++
+			}
+		case key.Matches(msg, usageKeys.Left):
+			u.viewIdx = (u.viewIdx + len(u.views) - 1) % len(u.views)
+			u.selectedIdx = 0
+		case key.Matches(msg, usageKeys.Right):
+			u.viewIdx = (u.viewIdx + 1) % len(u.views)
+			u.selectedIdx = 0
+		case key.Matches(msg, usageKeys.Escape):
+			return u, util.CmdHandler(CloseUsageDialogMsg{})
+		}
+	case tea.WindowSizeMsg:
+		u.width = msg.Width
+		u.height = msg.Height
+	}
+	return u, nil
+}
+
+func (u *usageDialogCmp) View() string {
+	view := u.views[u.viewIdx]
+	nameWidth := 30
+	if u.width > 0 {
+		nameWidth = max(16, min(nameWidth, u.width-60))
+	}
+	row := func(name, requests, input, output, cached, cost string) string {
+		return fmt.Sprintf("%-*s %8s %8s %8s %8s %9s", nameWidth, truncate(name, nameWidth), requests, input, output, cached, cost)
+	}
+
+	header := row(view.name, "Requests", "Input", "Output", "Cached", "Cost")
+	width := lipgloss.Width(header) + 2
+
+	var total usage.Stats
+	for _, s := range view.stats {
+		total.Requests += s.Requests
+		total.InputTokens += s.InputTokens
+		total.OutputTokens += s.OutputTokens
+		total.CacheCreationTokens += s.CacheCreationTokens
+		total.CacheReadTokens += s.CacheReadTokens
+		total.Cost += s.Cost
+	}
+
+	maxVisibleRows := min(10, len(view.stats))
+	startIdx := 0
+	if len(view.stats) > maxVisibleRows {
+		// Keep the selected row in view
+		startIdx = min(max(0, u.selectedIdx-maxVisibleRows/2), len(view.stats)-maxVisibleRows)
+	}
+
+	rows := make([]string, 0, maxVisibleRows)
+	for i := startIdx; i < startIdx+maxVisibleRows; i++ {
+		s := view.stats[i]
+		itemStyle := styles.BaseStyle.Width(width)
+		if i == u.selectedIdx {
+			itemStyle = itemStyle.
+				Background(styles.PrimaryColor).
+				Foreground(styles.Background).
+				Bold(true)
+		}
+		rows = append(rows, itemStyle.Padding(0, 1).Render(statsRow(row, s)))
+	}
+	if len(rows) == 0 {
+		rows = append(rows, styles.BaseStyle.Width(width).Padding(0, 1).Foreground(styles.ForgroundDim).Render("No usage recorded"))
+	}
+
+	title := styles.BaseStyle.
+		Foreground(styles.PrimaryColor).
+		Bold(true).
+		Width(width).
+		Padding(0, 1).
+		Render(u.title)
+
+	tabs := styles.BaseStyle.
+		Foreground(styles.ForgroundDim).
+		Width(width).
+		Padding(0, 1).
+		Render(fmt.Sprintf("← by %s (%d/%d) →", view.name, u.viewIdx+1, len(u.views)))
+
+	headerStyle := styles.BaseStyle.Width(width).Padding(0, 1).Bold(true)
+	total.Title = "Total"
+	content := lipgloss.JoinVertical(
+		lipgloss.Left,
+		title,
+		tabs,
+		styles.BaseStyle.Width(width).Render(""),
+		headerStyle.Render(header),
+		styles.BaseStyle.Width(width).Render(lipgloss.JoinVertical(lipgloss.Left, rows...)),
+		styles.BaseStyle.Width(width).Render(""),
+		headerStyle.Render(statsRow(row, total)),
+	)
+
+	return styles.BaseStyle.Padding(1, 2).
+		Border(lipgloss.RoundedBorder()).
+		BorderBackground(styles.Background).
+		BorderForeground(styles.ForgroundDim).
+		Width(lipgloss.Width(content) + 4).
+		Render(content)
+}
+
+func statsRow(row func(name, requests, input, output, cached, cost string) string, s usage.Stats) string {
+	name := s.Title
+	if name == "" {
+		name = s.Key
+	}
+	return row(
+		name,
+		fmt.Sprintf("%d", s.Requests),
+		usage.FormatTokens(s.InputTokens),
+		usage.FormatTokens(s.OutputTokens),
+		usage.FormatTokens(s.CacheCreationTokens+s.CacheReadTokens),
+		fmt.Sprintf("$%.2f", s.Cost),
+	)
+}
+
+func truncate(s string, width int) string {
+	runes := []rune(s)
+	if len(runes) <= width {
+		return s
+	}
+	return string(runes[:width-1]) + "…"
+}
+
+func (u *usageDialogCmp) BindingKeys() []key.Binding {
+	return layout.KeyMapToSlice(usageKeys)
+}
+
+func (u *usageDialogCmp) SetStats(title string, bySession, byDay, byProvider []usage.Stats) {
+	u.title = title
+	u.views = []usageView{
+		{name: "Session", stats: bySession},
+		{name: "Day", stats: byDay},
+		{name: "Provider", stats: byProvider},
+	}
+	u.viewIdx, u.selectedIdx = 0, 0
+}
+
+// NewUsageDialogCmp creates a new usage report dialog
+func NewUsageDialogCmp() UsageDialog {
+	return &usageDialogCmp{
+		views: []usageView{{name: "Session"}},
+	}
+}
@@ -2,10 +2,17 @@ package page
 
 import (
 	"context"
+	"errors"
+	"regexp"
+	"strings"
 
 	"github.com/charmbracelet/bubbles/key"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/opencode-ai/opencode/internal/app"
+	"github.com/opencode-ai/opencode/internal/clipboard"
+	"github.com/opencode-ai/opencode/internal/llm/agent"
+	"github.com/opencode-ai/opencode/internal/logging"
+	"github.com/opencode-ai/opencode/internal/message"
 	"github.com/opencode-ai/opencode/internal/session"
 	"github.com/opencode-ai/opencode/internal/tui/components/chat"
 	"github.com/opencode-ai/opencode/internal/tui/layout"
@@ -20,11 +27,17 @@ type chatPage struct {
 	messages layout.Container
 	layout   layout.SplitPaneLayout
 	session  session.Session
+	// planMode is the mode of the next new session
+	planMode bool
 }
 
 type ChatKeyMap struct {
-	NewSession key.Binding
-	Cancel     key.Binding
+	NewSession      key.Binding
+	Cancel          key.Binding
+	CopyResponse    key.Binding
+	ToggleTodos     key.Binding
+	ToggleReasoning key.Binding
+	TogglePlanMode  key.Binding
 }
 
 var keyMap = ChatKeyMap{
@@ -36,8 +49,27 @@ var keyMap = ChatKeyMap{
 		key.WithKeys("esc"),
 		key.WithHelp("esc", "cancel"),
 	),
+	CopyResponse: key.NewBinding(
+		key.WithKeys("ctrl+y"),
+		key.WithHelp("ctrl+y", "copy last response"),
+	),
+	ToggleTodos: key.NewBinding(
+		key.WithKeys("ctrl+t"),
+		key.WithHelp("ctrl+t", "toggle tasks"),
+	),
+	ToggleReasoning: key.NewBinding(
+		key.WithKeys("ctrl+g"),
+		key.WithHelp("ctrl+g", "toggle reasoning"),
+	),
+	TogglePlanMode: key.NewBinding(
+		key.WithKeys("ctrl+p"),
+		key.WithHelp("ctrl+p", "plan mode"),
+	),
 }
 
+// codeBlockRe matches the fenced code blocks of markdown.
+var codeBlockRe = regexp.MustCompile("(?s)```[^\n]*\n(.*?)\n?```")
+
 func (p *chatPage) Init() tea.Cmd {
 	cmds := []tea.Cmd{
 		p.layout.Init(),
@@ -52,10 +84,16 @@ func (p *chatPage) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		cmd := p.layout.SetSize(msg.Width, msg.Height)
 		cmds = append(cmds, cmd)
 	case chat.SendMsg:
-		cmd := p.sendMessage(msg.Text)
+		cmd := p.sendMessage(msg.Text, msg.Attachments)
 		if cmd != nil {
 			return p, cmd
 		}
+	case chat.TogglePlanModeMsg:
+		return p, p.togglePlanMode()
+	case chat.ExecutePlanMsg:
+		return p, p.executePlan(msg.SessionID, msg.Plan)
+	case chat.CopyMsg:
+		return p, p.copyResponse(msg.CodeBlock)
 	case chat.SessionSelectedMsg:
 		if p.session.ID == "" {
 			cmd := p.setSidebar()
@@ -64,6 +102,7 @@ func (p *chatPage) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 		}
 		p.session = msg
+		p.planMode = msg.PlanMode
 	case tea.KeyMsg:
 		switch {
 		case key.Matches(msg, keyMap.NewSession):
@@ -72,6 +111,14 @@ func (p *chatPage) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				p.clearSidebar(),
 				util.CmdHandler(chat.SessionClearedMsg{}),
 			)
+		case key.Matches(msg, keyMap.CopyResponse):
+			return p, p.copyResponse(false)
+		case key.Matches(msg, keyMap.ToggleTodos):
+			return p, util.CmdHandler(chat.ToggleTodosMsg{})
+		case key.Matches(msg, keyMap.ToggleReasoning):
+			return p, util.CmdHandler(chat.ToggleReasoningMsg{})
+		case key.Matches(msg, keyMap.TogglePlanMode):
+			return p, p.togglePlanMode()
 		case key.Matches(msg, keyMap.Cancel):
 			if p.session.ID != "" {
 				// Cancel the current session's generation process
@@ -89,7 +136,7 @@ func (p *chatPage) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 func (p *chatPage) setSidebar() tea.Cmd {
 	sidebarContainer := layout.NewContainer(
-		chat.NewSidebarCmp(p.session, p.app.History),
+		chat.NewSidebarCmp(p.session, p.app.History, p.app.Todos),
 		layout.WithPadding(1, 1, 1, 1),
 	)
 	return tea.Batch(p.layout.SetRightPanel(sidebarContainer), sidebarContainer.Init())
@@ -99,13 +146,20 @@ func (p *chatPage) clearSidebar() tea.Cmd {
 	return p.layout.ClearRightPanel()
 }
 
-func (p *chatPage) sendMessage(text string) tea.Cmd {
+func (p *chatPage) sendMessage(text string, attachments []message.BinaryContent) tea.Cmd {
 	var cmds []tea.Cmd
 	if p.session.ID == "" {
 		session, err := p.app.Sessions.Create(context.Background(), "New Session")
 		if err != nil {
 			return util.ReportError(err)
 		}
+		if p.planMode {
+			session.PlanMode = true
+			session, err = p.app.Sessions.Save(context.Background(), session)
+			if err != nil {
+				return util.ReportError(err)
+			}
+		}
 
 		p.session = session
 		cmd := p.setSidebar()
@@ -115,10 +169,140 @@ func (p *chatPage) sendMessage(text string) tea.Cmd {
 		cmds = append(cmds, util.CmdHandler(chat.SessionSelectedMsg(session)))
 	}
 
-	p.app.CoderAgent.Run(context.Background(), p.session.ID, text)
+	if !p.app.CoderAgent.IsSessionBusy(p.session.ID) {
+		// The session can be rewound to before each message
+		if _, err := p.app.Checkpoints.Create(context.Background(), p.session.ID, checkpointLabel(text), true); err != nil {
+			logging.Warn("Failed to create checkpoint", "error", err)
+		}
+	}
+
+	done, err := p.app.CoderAgent.Run(context.Background(), p.session.ID, text, attachments...)
+	if err != nil {
+		return tea.Batch(append(cmds, util.ReportError(err))...)
+	}
+	cmds = append(cmds, waitForResponse(p.session.ID, p.session.PlanMode, done))
 	return tea.Batch(cmds...)
 }
 
+// checkpointLabel labels the checkpoint before a message with its first line.
+func checkpointLabel(text string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(text), "\n")
+	if runes := []rune(line); len(runes) > 60 {
+		line = string(runes[:59]) + "…"
+	}
+	return "Before: " + line
+}
+
+// waitForResponse waits for the end of the turn. It asks whether to continue
+// when the turn paused at the budget of the session, and in plan mode it
+// proposes the text of the response as the plan when the turn finished.
+func waitForResponse(sessionID string, planMode bool, done <-chan agent.AgentEvent) tea.Cmd {
+	return func() tea.Msg {
+		result := <-done
+		if errors.Is(result.Err(), agent.ErrBudgetReached) {
+			return chat.BudgetReachedMsg{SessionID: sessionID, Reason: result.Err().Error()}
+		}
+		if !planMode || result.Err() != nil {
+			return nil
+		}
+		response := result.Response()
+		plan := strings.TrimSpace(response.Content().String())
+		if response.FinishReason() != message.FinishReasonEndTurn || plan == "" {
+			return nil
+		}
+		return chat.PlanProposedMsg{SessionID: sessionID, Plan: plan}
+	}
+}
+
+// togglePlanMode turns plan mode of the session on or off, or of the next new
+// session when none is selected.
+func (p *chatPage) togglePlanMode() tea.Cmd {
+	if p.session.ID == "" {
+		p.planMode = !p.planMode
+		return util.ReportInfo(planModeStatus(p.planMode) + " for the new session")
+	}
+	ctx := context.Background()
+	sess, err := p.app.Sessions.Get(ctx, p.session.ID)
+	if err != nil {
+		return util.ReportError(err)
+	}
+	sess.PlanMode = !sess.PlanMode
+	sess, err = p.app.Sessions.Save(ctx, sess)
+	if err != nil {
+		return util.ReportError(err)
+	}
+	p.session = sess
+	p.planMode = sess.PlanMode
+	return util.ReportInfo(planModeStatus(sess.PlanMode) + ", it applies from the next message")
+}
+
+func planModeStatus(on bool) string {
+	if on {
+		return "Plan mode on: the assistant only reads and proposes a plan"
+	}
+	return "Plan mode off"
+}
+
+// executePlan turns plan mode of the session off and sends the approved plan.
+func (p *chatPage) executePlan(sessionID, plan string) tea.Cmd {
+	if p.session.ID != sessionID {
+		return util.ReportWarn("The plan belongs to another session")
+	}
+	if p.app.CoderAgent.IsSessionBusy(sessionID) {
+		return util.ReportWarn("Agent is working, please wait...")
+	}
+	ctx := context.Background()
+	sess, err := p.app.Sessions.Get(ctx, sessionID)
+	if err != nil {
+		return util.ReportError(err)
+	}
+	sess.PlanMode = false
+	sess, err = p.app.Sessions.Save(ctx, sess)
+	if err != nil {
+		return util.ReportError(err)
+	}
+	p.session = sess
+	p.planMode = false
+	return p.sendMessage("The plan is approved, carry it out:\n\n"+plan, nil)
+}
+
+// copyResponse copies the text of the last response of the assistant to the
+// clipboard, or only its last code block.
+func (p *chatPage) copyResponse(codeBlock bool) tea.Cmd {
+	if p.session.ID == "" {
+		return util.ReportWarn("No response to copy")
+	}
+	messages, err := p.app.Messages.List(context.Background(), p.session.ID)
+	if err != nil {
+		return util.ReportError(err)
+	}
+	text := ""
+	for i := len(messages) - 1; i >= 0 && text == ""; i-- {
+		if messages[i].Role == message.Assistant {
+			text = messages[i].Content().String()
+		}
+	}
+	if text == "" {
+		return util.ReportWarn("No response to copy")
+	}
+	what := "response"
+	if codeBlock {
+		blocks := codeBlockRe.FindAllStringSubmatch(text, -1)
+		if len(blocks) == 0 {
+			return util.ReportWarn("The last response has no code block")
+		}
+		text, what = blocks[len(blocks)-1][1], "code block"
+	}
+	backend, err := clipboard.Write(text)
+	if err != nil {
+		return util.ReportError(err)
+	}
+	if backend == clipboard.OSC52 {
+		return util.ReportInfo("Sent the last " + what + " to the terminal clipboard")
+	}
+	return util.ReportInfo("Copied the last " + what + " to the clipboard")
+}
+
 func (p *chatPage) SetSize(width, height int) tea.Cmd {
 	return p.layout.SetSize(width, height)
 }
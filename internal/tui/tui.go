@@ -2,21 +2,34 @@ package tui
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/charmbracelet/bubbles/key"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/opencode-ai/opencode/internal/app"
+	"github.com/opencode-ai/opencode/internal/checkpoint"
 	"github.com/opencode-ai/opencode/internal/config"
+	"github.com/opencode-ai/opencode/internal/llm/discovery"
+	"github.com/opencode-ai/opencode/internal/llm/models"
 	"github.com/opencode-ai/opencode/internal/logging"
+	"github.com/opencode-ai/opencode/internal/message"
 	"github.com/opencode-ai/opencode/internal/permission"
 	"github.com/opencode-ai/opencode/internal/pubsub"
+	"github.com/opencode-ai/opencode/internal/screenshot"
 	"github.com/opencode-ai/opencode/internal/tui/components/chat"
 	"github.com/opencode-ai/opencode/internal/tui/components/core"
 	"github.com/opencode-ai/opencode/internal/tui/components/dialog"
 	"github.com/opencode-ai/opencode/internal/tui/layout"
 	"github.com/opencode-ai/opencode/internal/tui/page"
 	"github.com/opencode-ai/opencode/internal/tui/util"
+	"github.com/opencode-ai/opencode/internal/usage"
 )
 
 type keyMap struct {
@@ -24,6 +37,7 @@ type keyMap struct {
 	Quit          key.Binding
 	Help          key.Binding
 	SwitchSession key.Binding
+	SwitchModel   key.Binding
 	Commands      key.Binding
 }
 
@@ -47,6 +61,11 @@ var keys = keyMap{
 		key.WithHelp("ctrl+a", "switch session"),
 	),
 
+	SwitchModel: key.NewBinding(
+		key.WithKeys("ctrl+o"),
+		key.WithHelp("ctrl+o", "switch model"),
+	),
+
 	Commands: key.NewBinding(
 		key.WithKeys("ctrl+k"),
 		key.WithHelp("ctrl+k", "commands"),
@@ -93,8 +112,34 @@ type appModel struct {
 	commandDialog     dialog.CommandDialog
 	commands          []dialog.Command
 
+	showModelDialog bool
+	modelDialog     dialog.ModelDialog
+
+	showUsageDialog bool
+	usageDialog     dialog.UsageDialog
+
+	showSessionUsageDialog bool
+	sessionUsageDialog     dialog.SessionUsageDialog
+
 	showInitDialog bool
 	initDialog     dialog.InitDialogCmp
+
+	showPlanDialog bool
+	planDialog     dialog.PlanDialog
+	// plan is the last plan proposed in plan mode, shown again by the
+	// review plan command
+	plan chat.PlanProposedMsg
+
+	showPullDialog bool
+	pullDialog     dialog.PullModelDialogCmp
+	pullUpdates    chan tea.Msg
+	cancelPull     context.CancelFunc
+	// declinedPulls are the missing models the user didn't pull, they aren't
+	// offered again
+	declinedPulls map[models.ModelID]bool
+
+	// sessionID is the session of the chat, empty before its first message
+	sessionID string
 }
 
 func (a appModel) Init() tea.Cmd {
@@ -112,8 +157,16 @@ func (a appModel) Init() tea.Cmd {
 	cmds = append(cmds, cmd)
 	cmd = a.commandDialog.Init()
 	cmds = append(cmds, cmd)
+	cmd = a.modelDialog.Init()
+	cmds = append(cmds, cmd)
+	cmd = a.usageDialog.Init()
+	cmds = append(cmds, cmd)
+	cmd = a.sessionUsageDialog.Init()
+	cmds = append(cmds, cmd)
 	cmd = a.initDialog.Init()
 	cmds = append(cmds, cmd)
+	cmd = a.planDialog.Init()
+	cmds = append(cmds, cmd)
 
 	// Check if we should show the init dialog
 	cmds = append(cmds, func() tea.Msg {
@@ -127,6 +180,12 @@ func (a appModel) Init() tea.Cmd {
 		return dialog.ShowInitDialogMsg{Show: shouldShow}
 	})
 
+	// Offer to pull configured Ollama models that are missing on the server
+	cmds = append(cmds, showMissingModel(a.declinedPulls))
+
+	// Check that a local model is reachable before the first prompt
+	cmds = append(cmds, checkOllama)
+
 	return tea.Batch(cmds...)
 }
 
@@ -159,7 +218,21 @@ func (a appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		a.commandDialog = command.(dialog.CommandDialog)
 		cmds = append(cmds, commandCmd)
 
+		model, modelCmd := a.modelDialog.Update(msg)
+		a.modelDialog = model.(dialog.ModelDialog)
+		cmds = append(cmds, modelCmd)
+
+		usageModel, usageCmd := a.usageDialog.Update(msg)
+		a.usageDialog = usageModel.(dialog.UsageDialog)
+		cmds = append(cmds, usageCmd)
+
+		sessionUsageModel, sessionUsageCmd := a.sessionUsageDialog.Update(msg)
+		a.sessionUsageDialog = sessionUsageModel.(dialog.SessionUsageDialog)
+		cmds = append(cmds, sessionUsageCmd)
+
 		a.initDialog.SetSize(msg.Width, msg.Height)
+		a.planDialog.SetSize(msg.Width, msg.Height)
+		a.pullDialog.SetSize(msg.Width, msg.Height)
 
 		return a, tea.Batch(cmds...)
 	// Status
@@ -243,6 +316,25 @@ func (a appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		a.showCommandDialog = false
 		return a, nil
 
+	case dialog.CloseModelDialogMsg:
+		a.showModelDialog = false
+		return a, nil
+
+	case dialog.CloseUsageDialogMsg:
+		a.showUsageDialog = false
+		return a, nil
+
+	case dialog.CloseSessionUsageDialogMsg:
+		a.showSessionUsageDialog = false
+		return a, nil
+
+	case dialog.ModelSelectedMsg:
+		a.showModelDialog = false
+		if err := a.app.CoderAgent.SetModel(msg.Model.ID); err != nil {
+			return a, util.ReportError(err)
+		}
+		return a, util.ReportInfo(fmt.Sprintf("Switched to %s", msg.Model.Name))
+
 	case dialog.ShowInitDialogMsg:
 		a.showInitDialog = msg.Show
 		return a, nil
@@ -268,8 +360,122 @@ func (a appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		return a, nil
 
+	case dialog.ShowPullModelDialogMsg:
+		a.pullDialog = dialog.NewPullModelDialogCmp(msg.Model)
+		a.pullDialog.SetSize(a.width, a.height)
+		a.showPullDialog = true
+		return a, nil
+
+	case dialog.ClosePullModelDialogMsg:
+		if !msg.Pull {
+			a.showPullDialog = false
+			a.declinedPulls[msg.Model.ID] = true
+			return a, tea.Batch(
+				util.ReportWarn(fmt.Sprintf("Model %s is not available on the Ollama server", msg.Model.APIModel)),
+				showMissingModel(a.declinedPulls),
+			)
+		}
+		d, _ := a.pullDialog.Update(msg)
+		a.pullDialog = d.(dialog.PullModelDialogCmp)
+		return a, a.startPull(msg.Model)
+
+	case dialog.PullModelProgressMsg:
+		d, _ := a.pullDialog.Update(msg)
+		a.pullDialog = d.(dialog.PullModelDialogCmp)
+		return a, waitForPull(a.pullUpdates)
+
+	case dialog.PullModelDoneMsg:
+		a.showPullDialog = false
+		a.pullUpdates = nil
+		a.cancelPull = nil
+		if msg.Err != nil {
+			// The model isn't offered again, the other missing models are
+			a.declinedPulls[msg.Model.ID] = true
+			if errors.Is(msg.Err, context.Canceled) {
+				return a, tea.Batch(
+					util.ReportWarn(fmt.Sprintf("Pull of %s cancelled", msg.Model.APIModel)),
+					showMissingModel(a.declinedPulls),
+				)
+			}
+			return a, tea.Batch(util.ReportError(msg.Err), showMissingModel(a.declinedPulls))
+		}
+		return a, tea.Batch(
+			util.ReportInfo(fmt.Sprintf("Pulled %s", msg.Model.APIModel)),
+			showMissingModel(a.declinedPulls),
+		)
+
+	case chat.PlanProposedMsg:
+		a.plan = msg
+		if msg.SessionID != a.sessionID || a.currentPage != page.ChatPage {
+			return a, util.ReportInfo("A plan is ready, open it with the Review Plan command")
+		}
+		a.planDialog.SetPlan(msg.SessionID, msg.Plan)
+		a.showPlanDialog = true
+		return a, nil
+
+	case chat.BudgetReachedMsg:
+		if msg.SessionID != a.sessionID || a.currentPage != page.ChatPage {
+			return a, util.ReportWarn("A session paused at its budget, send a message to continue it")
+		}
+		a.commandDialog.SetSelectedCommand("budget-stop")
+		a.commandDialog.SetCommands(budgetCommands(msg.Reason))
+		a.showCommandDialog = true
+		return a, nil
+
+	case createCheckpointMsg:
+		if a.sessionID == "" {
+			return a, util.ReportWarn("No session to mark a checkpoint in")
+		}
+		if _, err := a.app.Checkpoints.Create(context.Background(), a.sessionID, "Marked checkpoint", false); err != nil {
+			return a, util.ReportError(err)
+		}
+		return a, util.ReportInfo("Checkpoint created, rewind to it with the Rewind to Checkpoint command")
+
+	case showCheckpointsMsg:
+		if a.sessionID == "" {
+			return a, util.ReportWarn("No session to rewind")
+		}
+		commands, err := checkpointCommands(a.app, a.sessionID)
+		if err != nil {
+			return a, util.ReportError(err)
+		}
+		if len(commands) == 0 {
+			return a, util.ReportWarn("The session has no checkpoints")
+		}
+		a.commandDialog.SetSelectedCommand(commands[0].ID)
+		a.commandDialog.SetCommands(commands)
+		a.showCommandDialog = true
+		return a, nil
+
+	case rewindCheckpointMsg:
+		commands := rewindCommands(a.app, msg.checkpoint)
+		a.commandDialog.SetSelectedCommand(commands[0].ID)
+		a.commandDialog.SetCommands(commands)
+		a.showCommandDialog = true
+		return a, nil
+
+	case showPlanMsg:
+		if a.plan.Plan == "" || a.plan.SessionID != a.sessionID {
+			return a, util.ReportWarn("No plan proposed in this session")
+		}
+		a.planDialog.SetPlan(a.plan.SessionID, a.plan.Plan)
+		a.showPlanDialog = true
+		return a, nil
+
+	case dialog.PlanApprovedMsg:
+		a.showPlanDialog = false
+		a.plan = chat.PlanProposedMsg{}
+		return a, util.CmdHandler(chat.ExecutePlanMsg{SessionID: msg.SessionID, Plan: msg.Plan})
+
+	case dialog.ClosePlanDialogMsg:
+		a.showPlanDialog = false
+		return a, util.ReportInfo("Still in plan mode, reply to refine the plan")
+
 	case chat.SessionSelectedMsg:
+		a.sessionID = msg.ID
 		a.sessionDialog.SetSelectedSession(msg.ID)
+	case chat.SessionClearedMsg:
+		a.sessionID = ""
 	case dialog.SessionSelectedMsg:
 		a.showSessionDialog = false
 		if a.currentPage == page.ChatPage {
@@ -277,6 +483,66 @@ func (a appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		return a, nil
 
+	case attachScreenshotMsg:
+		if a.currentPage != page.ChatPage {
+			return a, util.ReportWarn("Screenshots can only be attached in the chat")
+		}
+		if !models.ModelCapabilities(a.app.CoderAgent.Model()).SupportsVision {
+			return a, util.ReportWarn(a.app.CoderAgent.Model().Name + " cannot see images")
+		}
+		if msg.region {
+			return a, captureRegion
+		}
+		// The frame without the dialogs, the command dialog is still open
+		frame := lipgloss.JoinVertical(lipgloss.Top, a.pages[a.currentPage].View(), a.status.View())
+		return a, func() tea.Msg {
+			data, err := screenshot.RenderFrame(context.Background(), frame)
+			if err != nil {
+				return util.InfoMsg{Type: util.InfoTypeError, Msg: err.Error()}
+			}
+			return chat.AttachMsg{
+				Name:       "TUI screenshot",
+				Attachment: message.BinaryContent{MIMEType: screenshot.MIMEType, Data: data},
+			}
+		}
+
+	case showUsageMsg:
+		if err := a.loadUsage(); err != nil {
+			return a, util.ReportError(err)
+		}
+		a.showUsageDialog = true
+		return a, nil
+
+	case showSessionUsageMsg:
+		if a.sessionID == "" {
+			return a, util.ReportWarn("No session selected")
+		}
+		if err := a.loadSessionUsage(); err != nil {
+			return a, util.ReportError(err)
+		}
+		a.showSessionUsageDialog = true
+		return a, nil
+
+	case showReasoningMsg:
+		commands, selected, err := reasoningCommands(a.app)
+		if err != nil {
+			return a, util.ReportWarn(err.Error())
+		}
+		a.commandDialog.SetSelectedCommand(selected)
+		a.commandDialog.SetCommands(commands)
+		a.showCommandDialog = true
+		return a, nil
+
+	case showToolProfilesMsg:
+		commands, selected, err := toolProfileCommands(a.app, a.sessionID)
+		if err != nil {
+			return a, util.ReportError(err)
+		}
+		a.commandDialog.SetSelectedCommand(selected)
+		a.commandDialog.SetCommands(commands)
+		a.showCommandDialog = true
+		return a, nil
+
 	case dialog.CommandSelectedMsg:
 		a.showCommandDialog = false
 		// Execute the command handler if available
@@ -298,9 +564,32 @@ func (a appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			if a.showCommandDialog {
 				a.showCommandDialog = false
 			}
+			if a.showModelDialog {
+				a.showModelDialog = false
+			}
+			if a.showUsageDialog {
+				a.showUsageDialog = false
+			}
+			if a.showSessionUsageDialog {
+				a.showSessionUsageDialog = false
+			}
+			return a, nil
+		case key.Matches(msg, keys.SwitchModel):
+			if a.currentPage == page.ChatPage && !a.showQuit && !a.showPermissions && !a.showSessionDialog && !a.showCommandDialog && !a.showUsageDialog && !a.showSessionUsageDialog && !a.showPlanDialog {
+				if a.app.CoderAgent.IsBusy() {
+					return a, util.ReportWarn("Agent is busy, please wait...")
+				}
+				available := config.AvailableModels()
+				if len(available) == 0 {
+					return a, util.ReportWarn("No models available")
+				}
+				a.modelDialog.SetModels(available, a.app.CoderAgent.Model().ID)
+				a.showModelDialog = true
+				return a, nil
+			}
 			return a, nil
 		case key.Matches(msg, keys.SwitchSession):
-			if a.currentPage == page.ChatPage && !a.showQuit && !a.showPermissions && !a.showCommandDialog {
+			if a.currentPage == page.ChatPage && !a.showQuit && !a.showPermissions && !a.showCommandDialog && !a.showModelDialog && !a.showUsageDialog && !a.showSessionUsageDialog && !a.showPlanDialog {
 				// Load sessions and show the dialog
 				sessions, err := a.app.Sessions.List(context.Background())
 				if err != nil {
@@ -315,7 +604,7 @@ func (a appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 			return a, nil
 		case key.Matches(msg, keys.Commands):
-			if a.currentPage == page.ChatPage && !a.showQuit && !a.showPermissions && !a.showSessionDialog {
+			if a.currentPage == page.ChatPage && !a.showQuit && !a.showPermissions && !a.showSessionDialog && !a.showModelDialog && !a.showUsageDialog && !a.showSessionUsageDialog && !a.showPlanDialog {
 				// Show commands dialog
 				if len(a.commands) == 0 {
 					return a, util.ReportWarn("No commands available")
@@ -338,6 +627,9 @@ func (a appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				a.showHelp = !a.showHelp
 				return a, nil
 			}
+			if a.showPlanDialog {
+				return a, util.CmdHandler(dialog.ClosePlanDialogMsg{})
+			}
 			if a.showInitDialog {
 				a.showInitDialog = false
 				// Mark the project as initialized without running the command
@@ -346,6 +638,13 @@ func (a appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				}
 				return a, nil
 			}
+			if a.showPullDialog {
+				if a.pullDialog.Pulling() {
+					a.cancelPull()
+					return a, nil
+				}
+				return a, util.CmdHandler(dialog.ClosePullModelDialogMsg{Model: a.pullDialog.Model(), Pull: false})
+			}
 		case key.Matches(msg, keys.Logs):
 			return a, a.moveToPage(page.LogsPage)
 		case key.Matches(msg, keys.Help):
@@ -405,6 +704,36 @@ func (a appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 	}
 
+	if a.showModelDialog {
+		d, modelCmd := a.modelDialog.Update(msg)
+		a.modelDialog = d.(dialog.ModelDialog)
+		cmds = append(cmds, modelCmd)
+		// Only block key messages send all other messages down
+		if _, ok := msg.(tea.KeyMsg); ok {
+			return a, tea.Batch(cmds...)
+		}
+	}
+
+	if a.showUsageDialog {
+		d, usageCmd := a.usageDialog.Update(msg)
+		a.usageDialog = d.(dialog.UsageDialog)
+		cmds = append(cmds, usageCmd)
+		// Only block key messages send all other messages down
+		if _, ok := msg.(tea.KeyMsg); ok {
+			return a, tea.Batch(cmds...)
+		}
+	}
+
+	if a.showSessionUsageDialog {
+		d, sessionUsageCmd := a.sessionUsageDialog.Update(msg)
+		a.sessionUsageDialog = d.(dialog.SessionUsageDialog)
+		cmds = append(cmds, sessionUsageCmd)
+		// Only block key messages send all other messages down
+		if _, ok := msg.(tea.KeyMsg); ok {
+			return a, tea.Batch(cmds...)
+		}
+	}
+
 	if a.showInitDialog {
 		d, initCmd := a.initDialog.Update(msg)
 		a.initDialog = d.(dialog.InitDialogCmp)
@@ -415,6 +744,26 @@ func (a appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 	}
 
+	if a.showPlanDialog {
+		d, planCmd := a.planDialog.Update(msg)
+		a.planDialog = d.(dialog.PlanDialog)
+		cmds = append(cmds, planCmd)
+		// Only block key messages send all other messages down
+		if _, ok := msg.(tea.KeyMsg); ok {
+			return a, tea.Batch(cmds...)
+		}
+	}
+
+	if a.showPullDialog {
+		d, pullCmd := a.pullDialog.Update(msg)
+		a.pullDialog = d.(dialog.PullModelDialogCmp)
+		cmds = append(cmds, pullCmd)
+		// Only block key messages send all other messages down
+		if _, ok := msg.(tea.KeyMsg); ok {
+			return a, tea.Batch(cmds...)
+		}
+	}
+
 	s, _ := a.status.Update(msg)
 	a.status = s.(core.StatusCmp)
 	a.pages[a.currentPage], cmd = a.pages[a.currentPage].Update(msg)
@@ -422,6 +771,417 @@ func (a appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return a, tea.Batch(cmds...)
 }
 
+// startPull pulls the model in the background, the progress updates are
+// delivered to the dialog through the pullUpdates channel.
+func (a *appModel) startPull(model models.Model) tea.Cmd {
+	ctx, cancel := context.WithCancel(context.Background())
+	updates := make(chan tea.Msg, 16)
+	a.pullUpdates = updates
+	a.cancelPull = cancel
+
+	go func() {
+		defer cancel()
+		defer close(updates)
+		err := config.PullOllamaModel(ctx, model, func(progress discovery.OllamaPullProgress) {
+			// Drop intermediate updates when the UI is behind, only the latest matters
+			select {
+			case updates <- dialog.PullModelProgressMsg{Progress: progress}:
+			default:
+			}
+		})
+		updates <- dialog.PullModelDoneMsg{Model: model, Err: err}
+	}()
+
+	return waitForPull(updates)
+}
+
+func waitForPull(updates <-chan tea.Msg) tea.Cmd {
+	return func() tea.Msg {
+		msg, ok := <-updates
+		if !ok {
+			return nil
+		}
+		return msg
+	}
+}
+
+func checkOllama() tea.Msg {
+	cfg := config.Get()
+	modelID := cfg.Agents[config.AgentCoder].Model
+	if models.SupportedModels[modelID].Provider != models.ProviderOllama {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := config.CheckOllama(ctx, modelID); err != nil {
+		return util.InfoMsg{
+			Type: util.InfoTypeError,
+			Msg:  err.Error(),
+			TTL:  30 * time.Second,
+		}
+	}
+	return nil
+}
+
+// showMissingModel offers to pull the next missing model that wasn't
+// declined.
+func showMissingModel(declined map[models.ModelID]bool) tea.Cmd {
+	declined = maps.Clone(declined)
+	return func() tea.Msg {
+		for _, model := range config.MissingOllamaModels() {
+			if !declined[model.ID] {
+				return dialog.ShowPullModelDialogMsg{Model: model}
+			}
+		}
+		return nil
+	}
+}
+
+// showReasoningMsg opens the command dialog with the reasoning levels of the
+// coder model.
+type showReasoningMsg struct{}
+
+// reasoningCommands lists the reasoning efforts or thinking budgets the coder
+// model supports and the ID of the current one.
+func reasoningCommands(app *app.App) ([]dialog.Command, string, error) {
+	agentCfg := config.Get().Agents[config.AgentCoder]
+	model := models.SupportedModels[agentCfg.Model]
+	if !model.CanReason {
+		return nil, "", fmt.Errorf("%s does not support reasoning", model.Name)
+	}
+
+	set := func(effort string, budget int64) func(dialog.Command) tea.Cmd {
+		return func(cmd dialog.Command) tea.Cmd {
+			if err := app.CoderAgent.SetReasoning(effort, budget); err != nil {
+				return util.ReportError(err)
+			}
+			return util.ReportInfo("Reasoning set to " + cmd.Title)
+		}
+	}
+
+	var commands []dialog.Command
+	var selected string
+	if model.Provider == models.ProviderAnthropic || model.Provider == models.ProviderBedrock {
+		commands = append(commands, dialog.Command{
+			ID:          "thinking-auto",
+			Title:       "Think when asked",
+			Description: "Extended thinking only for prompts that ask to think",
+			Handler:     set(agentCfg.ReasoningEffort, 0),
+		})
+		selected = "thinking-auto"
+		maxTokens := agentCfg.MaxTokens
+		if maxTokens == 0 {
+			maxTokens = model.DefaultMaxTokens
+		}
+		for _, budget := range []int64{4096, 16384, 32768} {
+			if budget >= maxTokens {
+				break
+			}
+			id := fmt.Sprintf("thinking-%d", budget)
+			commands = append(commands, dialog.Command{
+				ID:          id,
+				Title:       fmt.Sprintf("Thinking budget %dK", budget/1024),
+				Description: fmt.Sprintf("Think on every prompt with up to %d tokens", budget),
+				Handler:     set(agentCfg.ReasoningEffort, budget),
+			})
+			if budget == agentCfg.ThinkingBudget {
+				selected = id
+			}
+		}
+		return commands, selected, nil
+	}
+
+	for _, effort := range []string{"low", "medium", "high"} {
+		commands = append(commands, dialog.Command{
+			ID:          "reasoning-" + effort,
+			Title:       "Reasoning effort " + effort,
+			Description: "Sets the reasoning effort of " + model.Name,
+			Handler:     set(effort, agentCfg.ThinkingBudget),
+		})
+	}
+	selected = "reasoning-" + agentCfg.ReasoningEffort
+	if agentCfg.ReasoningEffort == "" {
+		selected = "reasoning-medium"
+	}
+	return commands, selected, nil
+}
+
+// budgetCommands are the choices when the turn paused at the budget of the
+// session.
+func budgetCommands(reason string) []dialog.Command {
+	return []dialog.Command{
+		{
+			ID:          "budget-continue",
+			Title:       "Continue",
+			Description: "Let the assistant go on, " + reason,
+			Handler: func(cmd dialog.Command) tea.Cmd {
+				return util.CmdHandler(chat.SendMsg{Text: "Continue where you stopped."})
+			},
+		},
+		{
+			ID:          "budget-stop",
+			Title:       "Stop",
+			Description: "Stop the turn here, a new message continues the session",
+			Handler: func(cmd dialog.Command) tea.Cmd {
+				return util.ReportInfo("Stopped at the budget of the session")
+			},
+		},
+	}
+}
+
+// createCheckpointMsg marks the current state of the session as a checkpoint.
+type createCheckpointMsg struct{}
+
+// showCheckpointsMsg opens the command dialog with the checkpoints of the
+// session.
+type showCheckpointsMsg struct{}
+
+// rewindCheckpointMsg asks how to rewind the session to the checkpoint.
+type rewindCheckpointMsg struct {
+	checkpoint checkpoint.Checkpoint
+}
+
+// checkpointCommands lists the checkpoints of the session, the newest first.
+func checkpointCommands(app *app.App, sessionID string) ([]dialog.Command, error) {
+	checkpoints, err := app.Checkpoints.List(context.Background(), sessionID)
+	if err != nil {
+		return nil, err
+	}
+	commands := make([]dialog.Command, len(checkpoints))
+	for i, cp := range checkpoints {
+		description := time.Unix(cp.CreatedAt, 0).Format("Jan 2 15:04:05")
+		if cp.Auto {
+			description += ", before the message"
+		}
+		commands[i] = dialog.Command{
+			ID:          "checkpoint-" + cp.ID,
+			Title:       cp.Label,
+			Description: description,
+			Handler: func(cmd dialog.Command) tea.Cmd {
+				return util.CmdHandler(rewindCheckpointMsg{checkpoint: cp})
+			},
+		}
+	}
+	return commands, nil
+}
+
+// rewindCommands are the ways to rewind the session to the checkpoint.
+func rewindCommands(app *app.App, cp checkpoint.Checkpoint) []dialog.Command {
+	rewind := func(revertFiles bool) func(dialog.Command) tea.Cmd {
+		return func(cmd dialog.Command) tea.Cmd {
+			if app.CoderAgent.IsSessionBusy(cp.SessionID) {
+				return util.ReportWarn("The session can't be rewound while the agent is working")
+			}
+			result, err := app.Checkpoints.Rewind(context.Background(), cp.ID, revertFiles)
+			if err != nil {
+				return util.ReportError(err)
+			}
+			info := fmt.Sprintf("Rewound to %q, %d messages discarded", cp.Label, result.Messages)
+			if revertFiles {
+				info += fmt.Sprintf(", %d files restored, %d removed", len(result.Restored), len(result.Removed))
+			}
+			return util.ReportInfo(info)
+		}
+	}
+	return []dialog.Command{
+		{
+			ID:          "rewind-files",
+			Title:       "Rewind conversation and files",
+			Description: "Discard the later messages and restore the files the assistant changed since",
+			Handler:     rewind(true),
+		},
+		{
+			ID:          "rewind-conversation",
+			Title:       "Rewind conversation only",
+			Description: "Discard the later messages and keep the files as they are",
+			Handler:     rewind(false),
+		},
+	}
+}
+
+// showPlanMsg opens the plan dialog with the last plan proposed in plan mode.
+type showPlanMsg struct{}
+
+// showToolProfilesMsg opens the command dialog with the tool profiles.
+type showToolProfilesMsg struct{}
+
+// toolProfileCommands lists the tool profiles and the ID of the one of the
+// session. Selecting one changes the profile of the session, or the default
+// profile of the run before the first message of the chat.
+func toolProfileCommands(app *app.App, sessionID string) ([]dialog.Command, string, error) {
+	current := config.DefaultToolProfile()
+	if sessionID != "" {
+		sess, err := app.Sessions.Get(context.Background(), sessionID)
+		if err != nil {
+			return nil, "", err
+		}
+		if sess.ToolProfile != "" {
+			current = sess.ToolProfile
+		}
+	}
+
+	set := func(name string) func(dialog.Command) tea.Cmd {
+		return func(cmd dialog.Command) tea.Cmd {
+			if sessionID == "" {
+				if err := config.SetDefaultToolProfile(name); err != nil {
+					return util.ReportError(err)
+				}
+				return util.ReportInfo("Tool profile of new sessions set to " + name)
+			}
+			if app.CoderAgent.IsSessionBusy(sessionID) {
+				return util.ReportWarn("The tool profile can't change while the agent is working")
+			}
+			ctx := context.Background()
+			sess, err := app.Sessions.Get(ctx, sessionID)
+			if err != nil {
+				return util.ReportError(err)
+			}
+			sess.ToolProfile = name
+			if _, err := app.Sessions.Save(ctx, sess); err != nil {
+				return util.ReportError(err)
+			}
+			return util.ReportInfo("Tool profile of the session set to " + name)
+		}
+	}
+
+	var commands []dialog.Command
+	for _, name := range config.ToolProfileNames() {
+		profile, _ := config.GetToolProfile(name)
+		commands = append(commands, dialog.Command{
+			ID:          "tools-" + name,
+			Title:       name,
+			Description: toolProfileDescription(profile),
+			Handler:     set(name),
+		})
+	}
+	return commands, "tools-" + current, nil
+}
+
+// toolProfileDescription summarizes the tools a profile offers.
+func toolProfileDescription(profile config.ToolProfile) string {
+	var parts []string
+	if len(profile.Allow) > 0 {
+		parts = append(parts, "Only "+strings.Join(profile.Allow, ", "))
+	}
+	if len(profile.Deny) > 0 {
+		parts = append(parts, "Without "+strings.Join(profile.Deny, ", "))
+	}
+	if len(parts) == 0 {
+		return "All the tools"
+	}
+	return strings.Join(parts, "; ")
+}
+
+// attachScreenshotMsg attaches a screenshot of the TUI, or of a region of the
+// screen the user selects, to the next message
+type attachScreenshotMsg struct {
+	region bool
+}
+
+// captureRegion lets the user select a region of the screen and attaches it
+// to the next message
+func captureRegion() tea.Msg {
+	data, err := screenshot.CaptureRegion(context.Background())
+	if err != nil {
+		return util.InfoMsg{Type: util.InfoTypeError, Msg: err.Error()}
+	}
+	return chat.AttachMsg{
+		Name:       "screenshot",
+		Attachment: message.BinaryContent{MIMEType: screenshot.MIMEType, Data: data},
+	}
+}
+
+// showUsageMsg opens the usage dialog
+type showUsageMsg struct{}
+
+// usageReportDays is how far back the usage dialog reports
+const usageReportDays = 30
+
+// loadUsage loads the usage of the last days into the usage dialog
+func (a *appModel) loadUsage() error {
+	ctx := context.Background()
+	since := time.Now().AddDate(0, 0, -usageReportDays)
+	bySession, err := a.app.Usage.BySession(ctx, since)
+	if err != nil {
+		return err
+	}
+	byDay, err := a.app.Usage.ByDay(ctx, since)
+	if err != nil {
+		return err
+	}
+	byProvider, err := a.app.Usage.ByProvider(ctx, since)
+	if err != nil {
+		return err
+	}
+	for i := range bySession {
+		if bySession[i].Title == "" {
+			bySession[i].Title = "(deleted session)"
+		}
+	}
+	a.usageDialog.SetStats(fmt.Sprintf("Usage of the last %d days", usageReportDays), bySession, byDay, byProvider)
+	return nil
+}
+
+// showSessionUsageMsg opens the session usage dialog for the current session
+type showSessionUsageMsg struct{}
+
+// loadSessionUsage loads the usage of the current session into the session
+// usage dialog.
+func (a *appModel) loadSessionUsage() error {
+	ctx := context.Background()
+	sess, err := a.app.Sessions.Get(ctx, a.sessionID)
+	if err != nil {
+		return err
+	}
+	records, err := a.app.Usage.ForSession(ctx, a.sessionID)
+	if err != nil {
+		return err
+	}
+	msgs, err := a.app.Messages.List(ctx, a.sessionID)
+	if err != nil {
+		return err
+	}
+	a.sessionUsageDialog.SetUsage("Usage of "+sess.Title, records, sessionTurns(msgs, records))
+	return nil
+}
+
+// sessionTurns sums the usage records of a session by turn, the most
+// expensive first. The requests of a turn are those after its message of the
+// user, with those of its sub-agents.
+func sessionTurns(msgs []message.Message, records []usage.Record) []usage.Stats {
+	var turns []usage.Stats
+	var starts []int64
+	for _, msg := range msgs {
+		if msg.Role != message.User {
+			continue
+		}
+		prompt, _, _ := strings.Cut(strings.TrimSpace(msg.Content().String()), "\n")
+		turns = append(turns, usage.Stats{Key: msg.ID, Title: prompt})
+		starts = append(starts, msg.CreatedAt)
+	}
+	if len(turns) > 0 {
+		for _, r := range records {
+			// Records from before the first message count to the first turn
+			i := max(0, sort.Search(len(starts), func(i int) bool { return starts[i] > r.CreatedAt })-1)
+			turns[i].Requests++
+			turns[i].InputTokens += r.InputTokens
+			turns[i].OutputTokens += r.OutputTokens
+			turns[i].CacheCreationTokens += r.CacheCreationTokens
+			turns[i].CacheReadTokens += r.CacheReadTokens
+			turns[i].Cost += r.Cost
+		}
+	}
+	turns = slices.DeleteFunc(turns, func(t usage.Stats) bool { return t.Requests == 0 })
+	sort.SliceStable(turns, func(i, j int) bool {
+		if turns[i].Cost != turns[j].Cost {
+			return turns[i].Cost > turns[j].Cost
+		}
+		return turns[i].InputTokens+turns[i].OutputTokens > turns[j].InputTokens+turns[j].OutputTokens
+	})
+	return turns
+}
+
 // RegisterCommand adds a command to the command dialog
 func (a *appModel) RegisterCommand(cmd dialog.Command) {
 	a.commands = append(a.commands, cmd)
@@ -553,6 +1313,73 @@ func (a appModel) View() string {
 		)
 	}
 
+	if a.showModelDialog {
+		overlay := a.modelDialog.View()
+		row := lipgloss.Height(appView) / 2
+		row -= lipgloss.Height(overlay) / 2
+		col := lipgloss.Width(appView) / 2
+		col -= lipgloss.Width(overlay) / 2
+		appView = layout.PlaceOverlay(
+			col,
+			row,
+			overlay,
+			appView,
+			true,
+		)
+	}
+
+	if a.showUsageDialog {
+		overlay := a.usageDialog.View()
+		row := lipgloss.Height(appView) / 2
+		row -= lipgloss.Height(overlay) / 2
+		col := lipgloss.Width(appView) / 2
+		col -= lipgloss.Width(overlay) / 2
+		appView = layout.PlaceOverlay(
+			col,
+			row,
+			overlay,
+			appView,
+			true,
+		)
+	}
+
+	if a.showSessionUsageDialog {
+		overlay := a.sessionUsageDialog.View()
+		row := lipgloss.Height(appView) / 2
+		row -= lipgloss.Height(overlay) / 2
+		col := lipgloss.Width(appView) / 2
+		col -= lipgloss.Width(overlay) / 2
+		appView = layout.PlaceOverlay(
+			col,
+			row,
+			overlay,
+			appView,
+			true,
+		)
+	}
+
+	if a.showPullDialog {
+		overlay := a.pullDialog.View()
+		appView = layout.PlaceOverlay(
+			a.width/2-lipgloss.Width(overlay)/2,
+			a.height/2-lipgloss.Height(overlay)/2,
+			overlay,
+			appView,
+			true,
+		)
+	}
+
+	if a.showPlanDialog {
+		overlay := a.planDialog.View()
+		appView = layout.PlaceOverlay(
+			a.width/2-lipgloss.Width(overlay)/2,
+			a.height/2-lipgloss.Height(overlay)/2,
+			overlay,
+			appView,
+			true,
+		)
+	}
+
 	if a.showInitDialog {
 		overlay := a.initDialog.View()
 		appView = layout.PlaceOverlay(
@@ -570,17 +1397,22 @@ func (a appModel) View() string {
 func New(app *app.App) tea.Model {
 	startPage := page.ChatPage
 	model := &appModel{
-		currentPage:   startPage,
-		loadedPages:   make(map[page.PageID]bool),
-		status:        core.NewStatusCmp(app.LSPClients),
-		help:          dialog.NewHelpCmp(),
-		quit:          dialog.NewQuitCmp(),
-		sessionDialog: dialog.NewSessionDialogCmp(),
-		commandDialog: dialog.NewCommandDialogCmp(),
-		permissions:   dialog.NewPermissionDialogCmp(),
-		initDialog:    dialog.NewInitDialogCmp(),
-		app:           app,
-		commands:      []dialog.Command{},
+		currentPage:        startPage,
+		loadedPages:        make(map[page.PageID]bool),
+		status:             core.NewStatusCmp(app.LSPClients),
+		help:               dialog.NewHelpCmp(),
+		quit:               dialog.NewQuitCmp(),
+		sessionDialog:      dialog.NewSessionDialogCmp(),
+		commandDialog:      dialog.NewCommandDialogCmp(),
+		modelDialog:        dialog.NewModelDialogCmp(),
+		usageDialog:        dialog.NewUsageDialogCmp(),
+		sessionUsageDialog: dialog.NewSessionUsageDialogCmp(),
+		permissions:        dialog.NewPermissionDialogCmp(),
+		initDialog:         dialog.NewInitDialogCmp(),
+		planDialog:         dialog.NewPlanDialogCmp(),
+		declinedPulls:      make(map[models.ModelID]bool),
+		app:                app,
+		commands:           []dialog.Command{},
 		pages: map[page.PageID]tea.Model{
 			page.ChatPage: page.NewChatPage(app),
 			page.LogsPage: page.NewLogsPage(),
@@ -606,5 +1438,109 @@ If there are Cursor rules (in .cursor/rules/ or .cursorrules) or Copilot rules (
 			)
 		},
 	})
+	model.RegisterCommand(dialog.Command{
+		ID:          "reasoning",
+		Title:       "Reasoning Effort",
+		Description: "Change how much the coder model reasons",
+		Handler: func(cmd dialog.Command) tea.Cmd {
+			return util.CmdHandler(showReasoningMsg{})
+		},
+	})
+	model.RegisterCommand(dialog.Command{
+		ID:          "tool-profile",
+		Title:       "Tool Profile",
+		Description: "Restrict the tools offered to the model in the session",
+		Handler: func(cmd dialog.Command) tea.Cmd {
+			return util.CmdHandler(showToolProfilesMsg{})
+		},
+	})
+	model.RegisterCommand(dialog.Command{
+		ID:          "plan-mode",
+		Title:       "Toggle Plan Mode",
+		Description: "Only read and propose a plan to approve before changing files",
+		Handler: func(cmd dialog.Command) tea.Cmd {
+			return util.CmdHandler(chat.TogglePlanModeMsg{})
+		},
+	})
+	model.RegisterCommand(dialog.Command{
+		ID:          "review-plan",
+		Title:       "Review Plan",
+		Description: "Show the last plan proposed in plan mode to approve it",
+		Handler: func(cmd dialog.Command) tea.Cmd {
+			return util.CmdHandler(showPlanMsg{})
+		},
+	})
+	model.RegisterCommand(dialog.Command{
+		ID:          "create-checkpoint",
+		Title:       "Create Checkpoint",
+		Description: "Mark the current state of the session to rewind to",
+		Handler: func(cmd dialog.Command) tea.Cmd {
+			return util.CmdHandler(createCheckpointMsg{})
+		},
+	})
+	model.RegisterCommand(dialog.Command{
+		ID:          "rewind-checkpoint",
+		Title:       "Rewind to Checkpoint",
+		Description: "Discard the messages and optionally the file changes after a checkpoint",
+		Handler: func(cmd dialog.Command) tea.Cmd {
+			return util.CmdHandler(showCheckpointsMsg{})
+		},
+	})
+	model.RegisterCommand(dialog.Command{
+		ID:          "toggle-reasoning",
+		Title:       "Toggle Reasoning",
+		Description: "Collapse or expand the reasoning of the responses",
+		Handler: func(cmd dialog.Command) tea.Cmd {
+			return util.CmdHandler(chat.ToggleReasoningMsg{})
+		},
+	})
+	model.RegisterCommand(dialog.Command{
+		ID:          "copy-response",
+		Title:       "Copy Last Response",
+		Description: "Copy the last response of the assistant to the clipboard",
+		Handler: func(cmd dialog.Command) tea.Cmd {
+			return util.CmdHandler(chat.CopyMsg{})
+		},
+	})
+	model.RegisterCommand(dialog.Command{
+		ID:          "copy-code",
+		Title:       "Copy Last Code Block",
+		Description: "Copy the last code block of the last response to the clipboard",
+		Handler: func(cmd dialog.Command) tea.Cmd {
+			return util.CmdHandler(chat.CopyMsg{CodeBlock: true})
+		},
+	})
+	model.RegisterCommand(dialog.Command{
+		ID:          "screenshot-tui",
+		Title:       "Attach TUI Screenshot",
+		Description: "Attach an image of the TUI to the next message, needs freeze",
+		Handler: func(cmd dialog.Command) tea.Cmd {
+			return util.CmdHandler(attachScreenshotMsg{})
+		},
+	})
+	model.RegisterCommand(dialog.Command{
+		ID:          "screenshot-region",
+		Title:       "Attach Screen Region",
+		Description: "Select a region of the screen and attach it to the next message",
+		Handler: func(cmd dialog.Command) tea.Cmd {
+			return util.CmdHandler(attachScreenshotMsg{region: true})
+		},
+	})
+	model.RegisterCommand(dialog.Command{
+		ID:          "usage",
+		Title:       "Usage and Cost",
+		Description: "Show the tokens and cost of the last 30 days",
+		Handler: func(cmd dialog.Command) tea.Cmd {
+			return util.CmdHandler(showUsageMsg{})
+		},
+	})
+	model.RegisterCommand(dialog.Command{
+		ID:          "session-usage",
+		Title:       "Session Usage",
+		Description: "Chart the tokens and cost of the session over time",
+		Handler: func(cmd dialog.Command) tea.Cmd {
+			return util.CmdHandler(showSessionUsageMsg{})
+		},
+	})
 	return model
 }
@@ -0,0 +1,44 @@
+package tui
+
+import (
+	"testing"
+
+	"github.com/opencode-ai/opencode/internal/message"
+	"github.com/opencode-ai/opencode/internal/usage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSessionTurns(t *testing.T) {
+	userMessage := func(id, text string, createdAt int64) message.Message {
+		return message.Message{ID: id, Role: message.User, Parts: []message.ContentPart{message.TextContent{Text: text}}, CreatedAt: createdAt}
+	}
+	msgs := []message.Message{
+		userMessage("u1", "find the bug\nin the parser", 100),
+		{ID: "a1", Role: message.Assistant, CreatedAt: 101},
+		userMessage("u2", "fix it", 200),
+		{ID: "a2", Role: message.Assistant, CreatedAt: 201},
+		userMessage("u3", "thanks", 300),
+		userMessage("u4", "  write the test  ", 400),
+	}
+	records := []usage.Record{
+		// The title of the session, before the first message
+		{InputTokens: 50, OutputTokens: 5, Cost: 0.125, CreatedAt: 99},
+		{InputTokens: 1000, OutputTokens: 100, Cost: 0.25, CreatedAt: 110},
+		{InputTokens: 2000, OutputTokens: 300, CacheReadTokens: 1000, Cost: 1, CreatedAt: 210},
+		// A sub-agent of the turn
+		{InputTokens: 500, OutputTokens: 50, Cost: 0.5, CreatedAt: 250},
+		{InputTokens: 3000, OutputTokens: 100, Cost: 0.375, CreatedAt: 400},
+	}
+
+	// The turn of u3 has no requests, it is left out
+	turns := sessionTurns(msgs, records)
+	assert.Equal(t, []usage.Stats{
+		{Key: "u2", Title: "fix it", Requests: 2, InputTokens: 2500, OutputTokens: 350, CacheReadTokens: 1000, Cost: 1.5},
+		// The same cost, the turn with more tokens first
+		{Key: "u4", Title: "write the test", Requests: 1, InputTokens: 3000, OutputTokens: 100, Cost: 0.375},
+		{Key: "u1", Title: "find the bug", Requests: 2, InputTokens: 1050, OutputTokens: 105, Cost: 0.375},
+	}, turns)
+
+	assert.Empty(t, sessionTurns(nil, records))
+	assert.Empty(t, sessionTurns(msgs, nil))
+}
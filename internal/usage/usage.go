@@ -0,0 +1,186 @@
+package usage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/opencode-ai/opencode/internal/db"
+	"github.com/opencode-ai/opencode/internal/llm/models"
+)
+
+// Record is the usage of a single request to a provider
+type Record struct {
+	SessionID           string
+	MessageID           string
+	Provider            models.ModelProvider
+	Model               models.ModelID
+	InputTokens         int64
+	OutputTokens        int64
+	CacheCreationTokens int64
+	CacheReadTokens     int64
+	Cost                float64
+	// CreatedAt is when the usage was recorded, in seconds, set when it is
+	// stored
+	CreatedAt int64
+}
+
+// Stats is the usage summed over a session, a day or a provider
+type Stats struct {
+	// Key is the session ID, the day (YYYY-MM-DD) or the provider
+	Key                 string
+	Title               string
+	Requests            int64
+	InputTokens         int64
+	OutputTokens        int64
+	CacheCreationTokens int64
+	CacheReadTokens     int64
+	Cost                float64
+}
+
+type Service interface {
+	Record(ctx context.Context, record Record) error
+	BySession(ctx context.Context, since time.Time) ([]Stats, error)
+	ByDay(ctx context.Context, since time.Time) ([]Stats, error)
+	ByProvider(ctx context.Context, since time.Time) ([]Stats, error)
+	// ForSession returns the usage records of the session in the order they
+	// were recorded, with those of its sub-agents and title generation.
+	ForSession(ctx context.Context, sessionID string) ([]Record, error)
+	// MoveToMessage assigns the records of a message to another message, like
+	// the one replacing it.
+	MoveToMessage(ctx context.Context, fromMessageID, toMessageID string) error
+}
+
+type service struct {
+	q db.Querier
+}
+
+func (s *service) Record(ctx context.Context, record Record) error {
+	return s.q.CreateUsage(ctx, db.CreateUsageParams{
+		ID:                  uuid.New().String(),
+		SessionID:           record.SessionID,
+		MessageID:           sql.NullString{String: record.MessageID, Valid: record.MessageID != ""},
+		Provider:            string(record.Provider),
+		Model:               string(record.Model),
+		InputTokens:         record.InputTokens,
+		OutputTokens:        record.OutputTokens,
+		CacheCreationTokens: record.CacheCreationTokens,
+		CacheReadTokens:     record.CacheReadTokens,
+		Cost:                record.Cost,
+	})
+}
+
+func (s *service) BySession(ctx context.Context, since time.Time) ([]Stats, error) {
+	rows, err := s.q.ListUsageBySession(ctx, since.Unix())
+	if err != nil {
+		return nil, err
+	}
+	stats := make([]Stats, len(rows))
+	for i, row := range rows {
+		stats[i] = Stats{
+			Key:                 row.SessionID,
+			Title:               row.Title,
+			Requests:            row.Requests,
+			InputTokens:         row.InputTokens,
+			OutputTokens:        row.OutputTokens,
+			CacheCreationTokens: row.CacheCreationTokens,
+			CacheReadTokens:     row.CacheReadTokens,
+			Cost:                row.Cost,
+		}
+	}
+	return stats, nil
+}
+
+func (s *service) ByDay(ctx context.Context, since time.Time) ([]Stats, error) {
+	rows, err := s.q.ListUsageByDay(ctx, since.Unix())
+	if err != nil {
+		return nil, err
+	}
+	stats := make([]Stats, len(rows))
+	for i, row := range rows {
+		stats[i] = Stats{
+			Key:                 row.Day,
+			Title:               row.Day,
+			Requests:            row.Requests,
+			InputTokens:         row.InputTokens,
+			OutputTokens:        row.OutputTokens,
+			CacheCreationTokens: row.CacheCreationTokens,
+			CacheReadTokens:     row.CacheReadTokens,
+			Cost:                row.Cost,
+		}
+	}
+	return stats, nil
+}
+
+func (s *service) ByProvider(ctx context.Context, since time.Time) ([]Stats, error) {
+	rows, err := s.q.ListUsageByProvider(ctx, since.Unix())
+	if err != nil {
+		return nil, err
+	}
+	stats := make([]Stats, len(rows))
+	for i, row := range rows {
+		stats[i] = Stats{
+			Key:                 row.Provider,
+			Title:               row.Provider,
+			Requests:            row.Requests,
+			InputTokens:         row.InputTokens,
+			OutputTokens:        row.OutputTokens,
+			CacheCreationTokens: row.CacheCreationTokens,
+			CacheReadTokens:     row.CacheReadTokens,
+			Cost:                row.Cost,
+		}
+	}
+	return stats, nil
+}
+
+func (s *service) ForSession(ctx context.Context, sessionID string) ([]Record, error) {
+	rows, err := s.q.ListUsageForSession(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	records := make([]Record, len(rows))
+	for i, row := range rows {
+		records[i] = Record{
+			SessionID:           row.SessionID,
+			MessageID:           row.MessageID.String,
+			Provider:            models.ModelProvider(row.Provider),
+			Model:               models.ModelID(row.Model),
+			InputTokens:         row.InputTokens,
+			OutputTokens:        row.OutputTokens,
+			CacheCreationTokens: row.CacheCreationTokens,
+			CacheReadTokens:     row.CacheReadTokens,
+			Cost:                row.Cost,
+			CreatedAt:           row.CreatedAt,
+		}
+	}
+	return records, nil
+}
+
+func (s *service) MoveToMessage(ctx context.Context, fromMessageID, toMessageID string) error {
+	return s.q.MoveUsageToMessage(ctx, db.MoveUsageToMessageParams{
+		ToMessageID:   sql.NullString{String: toMessageID, Valid: true},
+		FromMessageID: sql.NullString{String: fromMessageID, Valid: true},
+	})
+}
+
+func NewService(q db.Querier) Service {
+	return &service{q: q}
+}
+
+// FormatTokens formats a token count in a human-readable way, e.g. 110K or 1.2M
+func FormatTokens(tokens int64) string {
+	var formatted string
+	switch {
+	case tokens >= 1_000_000:
+		formatted = fmt.Sprintf("%.1fM", float64(tokens)/1_000_000)
+	case tokens >= 1_000:
+		formatted = fmt.Sprintf("%.1fK", float64(tokens)/1_000)
+	default:
+		return fmt.Sprintf("%d", tokens)
+	}
+	formatted = strings.Replace(formatted, ".0K", "K", 1)
+	return strings.Replace(formatted, ".0M", "M", 1)
+}
@@ -0,0 +1,99 @@
+package usage
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	_ "github.com/ncruces/go-sqlite3/driver"
+	_ "github.com/ncruces/go-sqlite3/embed"
+	"github.com/opencode-ai/opencode/internal/db"
+	"github.com/opencode-ai/opencode/internal/llm/models"
+	"github.com/pressly/goose/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUsage(t *testing.T) {
+	conn, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+	defer conn.Close()
+	conn.SetMaxOpenConns(1)
+	goose.SetBaseFS(db.FS)
+	require.NoError(t, goose.SetDialect("sqlite3"))
+	require.NoError(t, goose.Up(conn, "migrations"))
+	q := db.New(conn)
+	ctx := context.Background()
+	for _, id := range []string{"s1", "s2"} {
+		_, err := q.CreateSession(ctx, db.CreateSessionParams{ID: id, Title: "Session " + id})
+		require.NoError(t, err)
+	}
+
+	usage := NewService(q)
+	for _, record := range []Record{
+		{SessionID: "s1", MessageID: "m1", Provider: models.ProviderAnthropic, Model: models.Claude37Sonnet, InputTokens: 1000, OutputTokens: 100, CacheReadTokens: 500, Cost: 0.5},
+		{SessionID: "s1", Provider: models.ProviderAnthropic, Model: models.Claude37Sonnet, InputTokens: 50, OutputTokens: 10, Cost: 0.1},
+		{SessionID: "s2", MessageID: "m2", Provider: models.ProviderOllama, Model: "llama3", InputTokens: 2000, OutputTokens: 300},
+	} {
+		require.NoError(t, usage.Record(ctx, record))
+	}
+	since := time.Now().Add(-time.Hour)
+
+	bySession, err := usage.BySession(ctx, since)
+	require.NoError(t, err)
+	require.Len(t, bySession, 2)
+	s1 := bySession[0]
+	if s1.Key != "s1" {
+		s1 = bySession[1]
+	}
+	assert.Equal(t, Stats{Key: "s1", Title: "Session s1", Requests: 2, InputTokens: 1050, OutputTokens: 110, CacheReadTokens: 500, Cost: 0.6}, s1)
+
+	byProvider, err := usage.ByProvider(ctx, since)
+	require.NoError(t, err)
+	require.Len(t, byProvider, 2)
+	// The most expensive provider first
+	assert.Equal(t, string(models.ProviderAnthropic), byProvider[0].Key)
+	assert.Equal(t, int64(2), byProvider[0].Requests)
+	assert.Equal(t, Stats{Key: string(models.ProviderOllama), Title: string(models.ProviderOllama), Requests: 1, InputTokens: 2000, OutputTokens: 300}, byProvider[1])
+
+	byDay, err := usage.ByDay(ctx, since)
+	require.NoError(t, err)
+	require.Len(t, byDay, 1)
+	assert.Equal(t, time.Now().Format(time.DateOnly), byDay[0].Key)
+	assert.Equal(t, int64(3), byDay[0].Requests)
+	assert.Equal(t, int64(3050), byDay[0].InputTokens)
+	assert.InDelta(t, 0.6, byDay[0].Cost, 1e-9)
+
+	// Usage before since is left out
+	bySession, err = usage.BySession(ctx, time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	assert.Empty(t, bySession)
+
+	records, err := usage.ForSession(ctx, "s1")
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	assert.Equal(t, "m1", records[0].MessageID)
+	assert.Equal(t, int64(500), records[0].CacheReadTokens)
+	assert.NotZero(t, records[0].CreatedAt)
+	assert.Empty(t, records[1].MessageID)
+
+	require.NoError(t, usage.MoveToMessage(ctx, "m1", "m3"))
+	records, err = usage.ForSession(ctx, "s1")
+	require.NoError(t, err)
+	assert.Equal(t, "m3", records[0].MessageID)
+	assert.Empty(t, records[1].MessageID)
+}
+
+func TestFormatTokens(t *testing.T) {
+	for tokens, want := range map[int64]string{
+		999:       "999",
+		1000:      "1K",
+		110_000:   "110K",
+		1_230:     "1.2K",
+		1_000_000: "1M",
+		1_200_000: "1.2M",
+	} {
+		assert.Equal(t, want, FormatTokens(tokens), "%d tokens", tokens)
+	}
+}